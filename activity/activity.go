@@ -42,6 +42,11 @@ type (
 
 	// RegisterOptions consists of options for registering an activity
 	RegisterOptions = internal.RegisterActivityOptions
+
+	// DynamicFunc is an activity function that handles all activity types that don't have a registered activity.
+	// It receives the name of the activity type and the raw input payloads and returns the raw result payloads.
+	// See worker.ActivityRegistry.RegisterDynamicActivity.
+	DynamicFunc = internal.DynamicActivityFunc
 )
 
 // ErrResultPending is returned from activity's implementation to indicate the activity is not completed when
@@ -77,6 +82,7 @@ func RecordHeartbeat(ctx context.Context, details ...interface{}) {
 }
 
 // HasHeartbeatDetails checks if there is heartbeat details from last attempt.
+// It can be used by a retried activity to decide whether to resume from the progress recorded by the last attempt.
 func HasHeartbeatDetails(ctx context.Context) bool {
 	return internal.HasHeartbeatDetails(ctx)
 }
@@ -108,6 +108,35 @@ It expects an additional parameter, "taskToken", which is the value of the binar
 "ActivityInfo" struct retrieved inside the Activity (GetActivityInfo(ctx).TaskToken). "details" is the serializable
 payload containing progress information.
 
+Resuming from the Last Heartbeat
+
+When an Activity with a retry policy fails or times out, the details of its last heartbeat are delivered to the next
+attempt. This allows a long running Activity to resume from its last checkpoint instead of starting over:
+
+    func SampleActivity(ctx context.Context, files []string) error {
+        startIdx := 0
+        if activity.HasHeartbeatDetails(ctx) {
+            // resume from the index recorded by the previous attempt
+            if err := activity.GetHeartbeatDetails(ctx, &startIdx); err == nil {
+                startIdx++
+            }
+        }
+        info := activity.GetInfo(ctx)
+        activity.GetLogger(ctx).Info("Processing files", "Attempt", info.Attempt,
+            "AttemptScheduledTime", info.CurrentAttemptScheduledTime, "StartIndex", startIdx)
+        for i := startIdx; i < len(files); i++ {
+            // process files[i]
+            ...
+            activity.RecordHeartbeat(ctx, i)
+        }
+        return nil
+    }
+
+HasHeartbeatDetails returns false for the first attempt and for retries of attempts that never heartbeated, in which
+case GetHeartbeatDetails returns temporal.ErrNoData. GetInfo(ctx).Attempt starts from 1 and
+GetInfo(ctx).CurrentAttemptScheduledTime is the time the current attempt was scheduled. The Workflow can read the
+details of the last heartbeat from the TimeoutError returned when all attempts time out.
+
 Activity Cancellation
 
 When an Activity is canceled (or its Workflow execution is completed or failed) the context passed into its function
@@ -30,13 +30,18 @@ package client
 
 import (
 	"context"
+	"io"
+	"net/http"
 
 	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	namespacepb "go.temporal.io/api/namespace/v1"
 	"go.temporal.io/api/workflowservice/v1"
 
 	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/internal"
+	"go.temporal.io/sdk/internal/common/metrics"
 )
 
 const (
@@ -53,6 +58,30 @@ const (
 	// QueryTypeOpenSessions is the build in query type for Client.QueryWorkflow() call. Use this query type to get all open
 	// sessions in the workflow. The result will be a list of SessionInfo encoded in the converter.EncodedValue.
 	QueryTypeOpenSessions string = internal.QueryTypeOpenSessions
+
+	// QueryTypeQueryTypes is the build in query type for Client.QueryWorkflow() call. Use this query type to get the
+	// types of all queries supported by the workflow. The result will be a list of strings encoded in the
+	// converter.EncodedValue.
+	QueryTypeQueryTypes string = internal.QueryTypeQueryTypes
+
+	// QueryTypeWorkflowMetadata is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the workflow type, the supported query types, the signal channels in use and the change versions of the workflow.
+	// The result will be a WorkflowMetadata encoded in the converter.EncodedValue.
+	QueryTypeWorkflowMetadata string = internal.QueryTypeWorkflowMetadata
+
+	// QueryTypeWorkflowState is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the values stored with workflow.SetValue and the variables registered with workflow.RegisterObservableVariable.
+	// The result will be a WorkflowState encoded in the converter.EncodedValue.
+	QueryTypeWorkflowState string = internal.QueryTypeWorkflowState
+)
+
+const (
+	// BatchOperationSignal signals the workflows matching the query of RunBatchOperation.
+	BatchOperationSignal = internal.BatchOperationSignal
+	// BatchOperationCancel requests cancellation of the workflows matching the query of RunBatchOperation.
+	BatchOperationCancel = internal.BatchOperationCancel
+	// BatchOperationTerminate terminates the workflows matching the query of RunBatchOperation.
+	BatchOperationTerminate = internal.BatchOperationTerminate
 )
 
 type (
@@ -62,12 +91,95 @@ type (
 	// ConnectionOptions are optional parameters that can be specified in ClientOptions
 	ConnectionOptions = internal.ConnectionOptions
 
+	// FailoverOptions configure the failover of a client between clusters, see Options.Failover.
+	FailoverOptions = internal.FailoverOptions
+
+	// ServiceRetryOptions customize the retries of the requests made to the server, see Options.RetryOptions.
+	ServiceRetryOptions = internal.ServiceRetryOptions
+
+	// BackoffStrategy randomizes the delays between retries, see ServiceRetryOptions.BackoffStrategy.
+	BackoffStrategy = internal.BackoffStrategy
+
+	// BackoffStrategyFunc is an adapter to use a function as a BackoffStrategy.
+	BackoffStrategyFunc = internal.BackoffStrategyFunc
+
 	// StartWorkflowOptions configuration parameters for starting a workflow execution.
 	StartWorkflowOptions = internal.StartWorkflowOptions
 
+	// InvalidOptionsError is returned when starting a workflow with invalid StartWorkflowOptions, see
+	// ValidateStartWorkflowOptions.
+	InvalidOptionsError = internal.InvalidOptionsError
+
+	// ActivityCompletion describes the completion of a single activity reported with Client.CompleteActivities.
+	ActivityCompletion = internal.ActivityCompletion
+
+	// WorkflowMetadata is the result of the QueryTypeWorkflowMetadata query.
+	WorkflowMetadata = internal.WorkflowMetadata
+
+	// WorkflowState is the result of the QueryTypeWorkflowState query.
+	WorkflowState = internal.WorkflowState
+
+	// WorkflowStackTrace is the result of the QueryTypeStackTrace query parsed into the stacks of the workflow
+	// coroutines, see Client.GetWorkflowStackTrace.
+	WorkflowStackTrace = internal.WorkflowStackTrace
+
+	// CoroutineStackTrace is the stack of a workflow coroutine, the root workflow function or a function started
+	// with workflow.Go.
+	CoroutineStackTrace = internal.CoroutineStackTrace
+
+	// StackFrame is a call of a CoroutineStackTrace.
+	StackFrame = internal.StackFrame
+
+	// WorkflowExecutionDescription is the result of Client.DescribeWorkflow.
+	WorkflowExecutionDescription = internal.WorkflowExecutionDescription
+
+	// PendingActivityDescription describes an activity of a workflow execution which didn't complete yet.
+	PendingActivityDescription = internal.PendingActivityDescription
+
+	// PendingChildWorkflowDescription describes a child workflow of a workflow execution which didn't complete yet.
+	PendingChildWorkflowDescription = internal.PendingChildWorkflowDescription
+
+	// BatchOperationType is the operation applied to every workflow by RunBatchOperation.
+	BatchOperationType = internal.BatchOperationType
+
+	// BatchOperationOptions configure RunBatchOperation.
+	BatchOperationOptions = internal.BatchOperationOptions
+
+	// BatchOperationResult is the result of RunBatchOperation.
+	BatchOperationResult = internal.BatchOperationResult
+
+	// BatchOperationFailure describes a workflow RunBatchOperation failed to apply the operation to.
+	BatchOperationFailure = internal.BatchOperationFailure
+
+	// CallbackToken identifies a callback created with workflow.NewCallback, see EncodeCallbackToken.
+	CallbackToken = internal.CallbackToken
+
+	// CallbackHandlerOptions configure NewCallbackHandler.
+	CallbackHandlerOptions = internal.CallbackHandlerOptions
+
+	// ScheduleClient manages schedules running a workflow on a cron schedule, backed by cron workflows.
+	ScheduleClient = internal.ScheduleClient
+
+	// ScheduleOptions configure a schedule created or updated with ScheduleClient.
+	ScheduleOptions = internal.ScheduleOptions
+
+	// ScheduleDescription is the state of a schedule returned by ScheduleClient.Describe.
+	ScheduleDescription = internal.ScheduleDescription
+
+	// ScheduleRun describes a closed run of the workflow of a schedule.
+	ScheduleRun = internal.ScheduleRun
+
 	// HistoryEventIterator is a iterator which can return history events.
 	HistoryEventIterator = internal.HistoryEventIterator
 
+	// HistoryEventFilter selects the history events returned by an iterator created with
+	// NewFilteredHistoryEventIterator.
+	HistoryEventFilter = internal.HistoryEventFilter
+
+	// HistoryDifference is a difference between two histories, found by DiffHistories, or between a history and the
+	// commands generated when replaying it, found by worker.WorkflowReplayer.DiffWorkflowHistory.
+	HistoryDifference = internal.HistoryDifference
+
 	// WorkflowRun represents a started non child workflow.
 	WorkflowRun = internal.WorkflowRun
 
@@ -77,6 +189,36 @@ type (
 	// QueryWorkflowWithOptionsResponse defines the response to QueryWorkflowWithOptions.
 	QueryWorkflowWithOptionsResponse = internal.QueryWorkflowWithOptionsResponse
 
+	// ServiceInvoker abstracts the calls an activity makes to the Temporal service, to report its heartbeats and to get
+	// a client. Implement it to replace the service in unit tests with testsuite.TestActivityEnvironment.SetServiceInvoker.
+	ServiceInvoker = internal.ServiceInvoker
+
+	// MetricsHandler is a minimal metrics handler that can be set to Options.MetricsHandler instead of tally.Scope.
+	MetricsHandler = metrics.Handler
+
+	// MetricsCounter is the counter returned by MetricsHandler.
+	MetricsCounter = metrics.Counter
+
+	// MetricsGauge is the gauge returned by MetricsHandler.
+	MetricsGauge = metrics.Gauge
+
+	// MetricsTimer is the timer returned by MetricsHandler.
+	MetricsTimer = metrics.Timer
+
+	// MetricsHistogramHandler is optionally implemented by a MetricsHandler to receive the histograms of values which
+	// are not durations, e.g. sizes in bytes. Without it such histograms are emitted as gauges.
+	MetricsHistogramHandler = metrics.HistogramHandler
+
+	// MetricsHistogram is the histogram returned by MetricsHistogramHandler.
+	MetricsHistogram = metrics.Histogram
+
+	// PrometheusMetricsHandler is a MetricsHandler which exposes metrics in Prometheus text exposition format.
+	// It implements http.Handler and can be registered as a scrape endpoint.
+	PrometheusMetricsHandler = metrics.PrometheusHandler
+
+	// PrometheusMetricsHandlerOptions are optional parameters for NewPrometheusMetricsHandler.
+	PrometheusMetricsHandlerOptions = metrics.PrometheusHandlerOptions
+
 	// Client is the client for starting and getting information about a workflow executions as well as
 	// completing activities asynchronously.
 	Client interface {
@@ -142,6 +284,9 @@ type (
 		//  - EntityNotExistsError, if namespace does not exist
 		//  - BadRequestError
 		//	- InternalServiceError
+		//  - InvalidOptionsError, if the options are invalid
+		//  - WorkflowExecutionAlreadyStartedError, if the workflow is closed and WorkflowIDReusePolicy doesn't allow
+		//    to start it again
 		SignalWithStartWorkflow(ctx context.Context, workflowID string, signalName string, signalArg interface{},
 			options StartWorkflowOptions, workflow interface{}, workflowArgs ...interface{}) (WorkflowRun, error)
 
@@ -231,6 +376,13 @@ type (
 		//	- InternalServiceError
 		RecordActivityHeartbeatByID(ctx context.Context, namespace, workflowID, runID, activityID string, details ...interface{}) error
 
+		// CompleteActivities reports completion of many activities at once. Each ActivityCompletion identifies its activity
+		// either by TaskToken or by WorkflowID and ActivityID, so the external system finishing async activities doesn't
+		// need to persist the task tokens. Completions are reported concurrently.
+		// The returned slice has the same length as completions and holds the error reported for each of them,
+		// it is nil when all completions succeeded.
+		CompleteActivities(ctx context.Context, completions []ActivityCompletion) []error
+
 		// ListClosedWorkflow gets closed workflow executions based on request filters.
 		// Retrieved workflow executions are sorted by close time in descending order.
 		// Note: heavy usage of this API may cause huge persistence pressure.
@@ -315,6 +467,16 @@ type (
 		//  - QueryFailError
 		QueryWorkflow(ctx context.Context, workflowID string, runID string, queryType string, args ...interface{}) (converter.EncodedValue, error)
 
+		// GetWorkflowStackTrace issues the QueryTypeStackTrace query and parses its result into the stacks of the
+		// coroutines of the workflow, with their frames and what they are blocked on.
+		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		//  - QueryFailError
+		GetWorkflowStackTrace(ctx context.Context, workflowID string, runID string) (*WorkflowStackTrace, error)
+
 		// QueryWorkflowWithOptions queries a given workflow execution and returns the query result synchronously.
 		// See QueryWorkflowWithOptionsRequest and QueryWorkflowWithOptionsResponse for more information.
 		// The errors it can return:
@@ -333,6 +495,17 @@ type (
 		//  - EntityNotExistError
 		DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error)
 
+		// DescribeWorkflow is DescribeWorkflowExecution with the pending activities and child workflows decoded into Go
+		// structs: the heartbeat details and last failures of the pending activities are decoded with the DataConverter
+		// of the client.
+		// - runID can be default(empty string). if empty string then it will pick the last running execution of that workflow ID.
+		//
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		DescribeWorkflow(ctx context.Context, workflowID, runID string) (*WorkflowExecutionDescription, error)
+
 		// DescribeTaskQueue returns information about the target taskqueue, right now this API returns the
 		// pollers which polled this taskqueue in last few minutes.
 		// The errors it can return:
@@ -377,26 +550,140 @@ type (
 		//	- InternalServiceError
 		Update(ctx context.Context, request *workflowservice.UpdateNamespaceRequest) error
 
+		// MarkBadBinary marks the binary with the given checksum as bad in the namespace. Workers running a bad binary
+		// can't poll workflow tasks of the namespace and workflows can be reset to the point before the binary touched
+		// them. See WorkerOptions.BinaryChecksum.
+		// The errors it can throw:
+		//	- EntityNotExistsError
+		//	- BadRequestError
+		//	- InternalServiceError
+		MarkBadBinary(ctx context.Context, namespace string, binaryChecksum string, reason string) error
+
+		// DeleteBadBinary removes the binary with the given checksum from the bad binaries of the namespace.
+		// The errors it can throw:
+		//	- EntityNotExistsError
+		//	- BadRequestError
+		//	- InternalServiceError
+		DeleteBadBinary(ctx context.Context, namespace string, binaryChecksum string) error
+
+		// GetBadBinaries returns the bad binaries of the namespace by binary checksum.
+		// The errors it can throw:
+		//	- EntityNotExistsError
+		//	- BadRequestError
+		//	- InternalServiceError
+		GetBadBinaries(ctx context.Context, namespace string) (map[string]*namespacepb.BadBinaryInfo, error)
+
+		// Close client and clean up underlying resources.
+		Close()
+	}
+
+	// ActivityCompletionClient is the client for completing and heartbeating asynchronous activities.
+	// It is meant for services that don't start or query workflows but finish activities that returned
+	// activity.ErrResultPending, possibly using a task token exported with EncodeTaskToken by another system.
+	ActivityCompletionClient interface {
+		// Complete reports the activity completed with the given result, which can be nil.
+		Complete(ctx context.Context, taskToken []byte, result interface{}) error
+
+		// Fail reports the activity failed with the given error, which is retried according to the retry policy of
+		// the activity unless it is non-retryable.
+		Fail(ctx context.Context, taskToken []byte, err error) error
+
+		// Heartbeat records a heartbeat for the activity. It returns a *temporal.CanceledError when the cancellation of the
+		// activity was requested, which must then be reported with ReportCanceled.
+		Heartbeat(ctx context.Context, taskToken []byte, details ...interface{}) error
+
+		// ReportCanceled reports the activity canceled, with optional details.
+		ReportCanceled(ctx context.Context, taskToken []byte, details ...interface{}) error
+
 		// Close client and clean up underlying resources.
 		Close()
 	}
 )
 
+// NewJitterBackoff returns a BackoffStrategy randomizing each delay by up to the jitter fraction in both directions,
+// e.g. a delay of 1s with a jitter of 0.2 becomes a delay between 800ms and 1.2s.
+func NewJitterBackoff(jitter float64) BackoffStrategy {
+	return internal.NewJitterBackoff(jitter)
+}
+
+// NewFullJitterBackoff returns a BackoffStrategy replacing each delay with a random delay between zero and the delay,
+// which spreads the retries of many clients the most after the server recovers.
+func NewFullJitterBackoff() BackoffStrategy {
+	return internal.NewFullJitterBackoff()
+}
+
 // NewClient creates an instance of a workflow client
 func NewClient(options Options) (Client, error) {
 	return internal.NewClient(options)
 }
 
+// Dial creates an instance of a workflow client and checks that the server is reachable. It is the same as NewClient,
+// under the name used by later releases of the SDK, so code written against either compiles with this one.
+func Dial(options Options) (Client, error) {
+	return internal.NewClient(options)
+}
+
+// NewLazyClient creates an instance of a workflow client which doesn't connect to the server until it is used, so that
+// it can be created while the server is unavailable. The health of the server is checked before the first request
+// instead of on creation. Until the check passes, requests fail with an error describing why the server couldn't be
+// reached and the check is repeated on the next request.
+func NewLazyClient(options Options) (Client, error) {
+	return internal.NewLazyClient(options)
+}
+
 // NewNamespaceClient creates an instance of a namespace client, to manage lifecycle of namespaces.
 func NewNamespaceClient(options Options) (NamespaceClient, error) {
 	return internal.NewNamespaceClient(options)
 }
 
+// MetricsNopHandler is a MetricsHandler that discards all metrics.
+var MetricsNopHandler = metrics.NopHandler
+
+// NewPrometheusMetricsHandler creates a MetricsHandler which exposes metrics in Prometheus text exposition format.
+// Timers are exposed as histograms in seconds.
+func NewPrometheusMetricsHandler(options PrometheusMetricsHandlerOptions) *PrometheusMetricsHandler {
+	return metrics.NewPrometheusHandler(options)
+}
+
+// NewActivityCompletionClient creates an instance of a client that can only complete and heartbeat activities.
+// It doesn't require any workflow or activity registration and can be used by a service that only finishes
+// asynchronous activities.
+func NewActivityCompletionClient(options Options) (ActivityCompletionClient, error) {
+	return internal.NewActivityCompletionClient(options)
+}
+
+// EncodeTaskToken encodes activity task token to a string which can be passed to a different system or language.
+// The activity gets its task token from activity.GetInfo(ctx).TaskToken. The token is encoded using URL safe base64
+// encoding as defined in RFC 4648.
+func EncodeTaskToken(taskToken []byte) string {
+	return internal.EncodeTaskToken(taskToken)
+}
+
+// DecodeTaskToken decodes activity task token previously encoded with EncodeTaskToken.
+func DecodeTaskToken(encodedTaskToken string) ([]byte, error) {
+	return internal.DecodeTaskToken(encodedTaskToken)
+}
+
 // make sure if new methods are added to internal.Client they are also added to public Client.
 var _ Client = internal.Client(nil)
 var _ internal.Client = Client(nil)
 var _ NamespaceClient = internal.NamespaceClient(nil)
 var _ internal.NamespaceClient = NamespaceClient(nil)
+var _ ActivityCompletionClient = internal.ActivityCompletionClient(nil)
+var _ internal.ActivityCompletionClient = ActivityCompletionClient(nil)
+
+// ValidateStartWorkflowOptions returns an *InvalidOptionsError naming the invalid fields of the options along with
+// how to fix them, or nil when the server accepts the options.
+func ValidateStartWorkflowOptions(options StartWorkflowOptions) error {
+	return internal.ValidateStartWorkflowOptions(options)
+}
+
+// ValidateSearchAttributes returns an *InvalidOptionsError naming the search attributes of StartWorkflowOptions which
+// aren't in keys, the search attributes of the cluster returned by Client.GetSearchAttributes, or have a value of the
+// wrong type. The client validates the search attributes itself when ClientOptions.ValidateSearchAttributes is set.
+func ValidateSearchAttributes(attributes map[string]interface{}, keys map[string]enumspb.IndexedValueType) error {
+	return internal.ValidateSearchAttributes(attributes, keys)
+}
 
 // NewValue creates a new converter.EncodedValue which can be used to decode binary data returned by Temporal.  For example:
 // User had Activity.RecordHeartbeat(ctx, "my-heartbeat") and then got response from calling Client.DescribeWorkflowExecution.
@@ -418,3 +705,118 @@ func NewValue(data *commonpb.Payloads) converter.EncodedValue {
 func NewValues(data *commonpb.Payloads) converter.EncodedValues {
 	return internal.NewValues(data)
 }
+
+// NewFilteredHistoryEventIterator wraps iter, usually returned by Client.GetWorkflowHistory, to only return the events
+// matching the filter:
+//  iter := client.NewFilteredHistoryEventIterator(c.GetWorkflowHistory(ctx, workflowID, runID, false,
+//      enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT), client.HistoryEventFilter{
+//      EventTypes: []enumspb.EventType{enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED},
+//  })
+// Errors returned by iter are passed through.
+func NewFilteredHistoryEventIterator(iter HistoryEventIterator, filter HistoryEventFilter) HistoryEventIterator {
+	return internal.NewFilteredHistoryEventIterator(iter, filter)
+}
+
+// GetHistoryEventPayloads returns the payloads carried by a history event, e.g. the input of a workflow execution
+// started event or the result of an activity task completed event, decoded with the given data converter. Pass the
+// DataConverter the client was created with:
+//  var result string
+//  err := client.GetHistoryEventPayloads(event, dataConverter).Get(&result)
+// It returns nil for the event types without payloads, as well as for marker events whose details are keyed by name.
+func GetHistoryEventPayloads(event *historypb.HistoryEvent, dataConverter converter.DataConverter) converter.EncodedValues {
+	return internal.GetHistoryEventPayloads(event, dataConverter)
+}
+
+// CollectHistory reads all the events from iter, usually returned by Client.GetWorkflowHistory, into a History which
+// can be written with WriteHistoryJSON or passed to worker.WorkflowReplayer.ReplayWorkflowHistory:
+//  history, err := client.CollectHistory(c.GetWorkflowHistory(ctx, workflowID, runID, false,
+//      enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT))
+func CollectHistory(iter HistoryEventIterator) (*historypb.History, error) {
+	return internal.CollectHistory(iter)
+}
+
+// WriteHistoryJSON writes the history in the JSON format used by the CLI to export histories. The result can be
+// loaded with ReadHistoryJSON or worker.WorkflowReplayer.ReplayWorkflowHistoryFromJSONFile.
+func WriteHistoryJSON(w io.Writer, history *historypb.History) error {
+	return internal.WriteHistoryJSON(w, history)
+}
+
+// ReadHistoryJSON reads a history in the JSON format used by the CLI to export histories, as written by
+// WriteHistoryJSON.
+func ReadHistoryJSON(r io.Reader) (*historypb.History, error) {
+	return internal.ReadHistoryJSON(r)
+}
+
+// WriteHistoryText writes the history in a human readable format, one event per line, with the payloads of the
+// events decoded with the given data converter. The default data converter is used if it is nil.
+func WriteHistoryText(w io.Writer, history *historypb.History, dataConverter converter.DataConverter) error {
+	return internal.WriteHistoryText(w, history, dataConverter)
+}
+
+// DiffHistories returns the semantic differences between two histories of the same workflow, e.g. a history from
+// production and the history of the same workflow run against a new version of the code. Events are compared by type
+// and identity (activity ID and type, timer ID, marker name, signal name, child workflow ID and type...), ignoring
+// times, payloads, worker identities and workflow task events, which differ between runs of the same code.
+func DiffHistories(expected, actual *historypb.History) []HistoryDifference {
+	return internal.DiffHistories(expected, actual)
+}
+
+// ParseWorkflowStackTrace parses the result of the QueryTypeStackTrace query, e.g. obtained from QueryWorkflow, see
+// Client.GetWorkflowStackTrace.
+func ParseWorkflowStackTrace(stackTrace string) *WorkflowStackTrace {
+	return internal.ParseWorkflowStackTrace(stackTrace)
+}
+
+// RunBatchOperation signals, cancels or terminates all the workflows matching a visibility query, e.g.
+//  result, err := client.RunBatchOperation(ctx, c, client.BatchOperationOptions{
+//  	Query:     "WorkflowType='OrderWorkflow' and ExecutionStatus='Running'",
+//  	Operation: client.BatchOperationTerminate,
+//  	Reason:    "bad deployment",
+//  	RPS:       10,
+//  })
+// The matching workflows are listed before the operation is applied to any of them. The operations are throttled by
+// BatchOperationOptions.RPS. Failing operations are reported in BatchOperationResult.Failures and don't stop the batch.
+// The error is only returned if the workflows couldn't be listed or the ctx is done before all of them are processed.
+func RunBatchOperation(ctx context.Context, c Client, options BatchOperationOptions) (*BatchOperationResult, error) {
+	return internal.RunBatchOperation(ctx, c, options)
+}
+
+// NewScheduleClient creates a ScheduleClient managing schedules with the client, e.g.
+//  schedules := client.NewScheduleClient(c)
+//  err := schedules.Create(ctx, client.ScheduleOptions{
+//  	ID:           "nightly-report",
+//  	CronSchedule: "0 2 * * *",
+//  	Workflow:     ReportWorkflow,
+//  	TaskQueue:    "reports",
+//  })
+// Each schedule is backed by a cron workflow with the ID of the schedule, so it works with server versions without
+// native schedules. Pausing a schedule terminates the cron workflow and updating it starts a new one.
+func NewScheduleClient(c Client) ScheduleClient {
+	return internal.NewScheduleClient(c)
+}
+
+// EncodeCallbackToken returns the URL safe token of a callback created with workflow.NewCallback, signed with the
+// secret of the handler returned by NewCallbackHandler. It is typically called by an activity, which keeps the
+// secret out of the workflow history, e.g.
+//  func RegisterWebhook(ctx context.Context, callbackID string) error {
+//  	info := activity.GetInfo(ctx)
+//  	token, err := client.EncodeCallbackToken(client.CallbackToken{
+//  		WorkflowID: info.WorkflowExecution.ID,
+//  		RunID:      info.WorkflowExecution.RunID,
+//  		CallbackID: callbackID,
+//  	}, secret)
+//  	if err != nil {
+//  		return err
+//  	}
+//  	return registerWebhook("https://callbacks.example.com/?token=" + token)
+//  }
+func EncodeCallbackToken(token CallbackToken, secret []byte) (string, error) {
+	return internal.EncodeCallbackToken(token, secret)
+}
+
+// NewCallbackHandler returns an HTTP handler which completes the callbacks created with workflow.NewCallback by
+// signaling the workflows with the client. It accepts POST requests with the token of EncodeCallbackToken in the
+// "token" query parameter and delivers the body and content type of the request to the workflow.
+func NewCallbackHandler(c Client, options CallbackHandlerOptions) http.Handler {
+	return internal.NewCallbackHandler(c, options)
+}
@@ -0,0 +1,118 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package converter
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	commonpb "go.temporal.io/api/common/v1"
+)
+
+// Run with: go test -run=^$ -bench=. -benchmem ./converter
+
+type benchmarkStruct struct {
+	ID     string
+	Values []int
+	Tags   map[string]string
+	Body   string
+}
+
+func newBenchmarkStruct(size int) benchmarkStruct {
+	values := make([]int, size/8)
+	for i := range values {
+		values[i] = i
+	}
+	return benchmarkStruct{
+		ID:     "benchmark",
+		Values: values,
+		Tags:   map[string]string{"a": "1", "b": "2"},
+		Body:   strings.Repeat("x", size),
+	}
+}
+
+func benchmarkRoundTrip(b *testing.B, dc DataConverter, newValuePtr func() interface{}, values ...interface{}) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		payloads, err := dc.ToPayloads(values...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		valuePtrs := make([]interface{}, len(values))
+		for j := range valuePtrs {
+			valuePtrs[j] = newValuePtr()
+		}
+		if err := dc.FromPayloads(payloads, valuePtrs...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDataConverter_JSON(b *testing.B) {
+	for _, size := range []int{64, 4 * 1024, 256 * 1024} {
+		value := newBenchmarkStruct(size)
+		b.Run(sizeName(size), func(b *testing.B) {
+			benchmarkRoundTrip(b, defaultDataConverter, func() interface{} { return &benchmarkStruct{} }, value, value)
+		})
+	}
+}
+
+func BenchmarkDataConverter_ByteSlice(b *testing.B) {
+	for _, size := range []int{64, 4 * 1024, 256 * 1024} {
+		value := bytes.Repeat([]byte("x"), size)
+		b.Run(sizeName(size), func(b *testing.B) {
+			benchmarkRoundTrip(b, defaultDataConverter, func() interface{} { return &[]byte{} }, value)
+		})
+	}
+}
+
+func BenchmarkDataConverter_ProtoJSON(b *testing.B) {
+	for _, size := range []int{64, 4 * 1024, 256 * 1024} {
+		value := &commonpb.WorkflowType{Name: strings.Repeat("x", size)}
+		dc := NewCompositeDataConverter(NewProtoJSONPayloadConverter())
+		b.Run(sizeName(size), func(b *testing.B) {
+			benchmarkRoundTrip(b, dc, func() interface{} { return &commonpb.WorkflowType{} }, value)
+		})
+	}
+}
+
+func BenchmarkDataConverter_Proto(b *testing.B) {
+	for _, size := range []int{64, 4 * 1024, 256 * 1024} {
+		value := &commonpb.WorkflowType{Name: strings.Repeat("x", size)}
+		dc := NewCompositeDataConverter(NewProtoPayloadConverter())
+		b.Run(sizeName(size), func(b *testing.B) {
+			benchmarkRoundTrip(b, dc, func() interface{} { return &commonpb.WorkflowType{} }, value)
+		})
+	}
+}
+
+func sizeName(size int) string {
+	if size >= 1024 {
+		return strconv.Itoa(size/1024) + "KB"
+	}
+	return strconv.Itoa(size) + "B"
+}
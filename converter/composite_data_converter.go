@@ -62,7 +62,7 @@ func (dc *CompositeDataConverter) ToPayloads(values ...interface{}) (*commonpb.P
 		return nil, nil
 	}
 
-	result := &commonpb.Payloads{}
+	result := &commonpb.Payloads{Payloads: make([]*commonpb.Payload, 0, len(values))}
 	for i, value := range values {
 		payload, err := dc.ToPayload(value)
 		if err != nil {
@@ -51,4 +51,8 @@ var (
 	ErrValuePtrMustConcreteType = errors.New("must be a concrete type, not interface")
 	// ErrTypeIsNotByteSlice is returned when value is not of *[]byte type.
 	ErrTypeIsNotByteSlice = errors.New("type is not *[]byte")
+	// ErrPayloadTooLarge is returned when a payload exceeds the size limit with PayloadSizeFail.
+	ErrPayloadTooLarge = errors.New("payload exceeds size limit")
+	// ErrPayloadTruncated is returned when decoding a payload truncated with PayloadSizeTruncate.
+	ErrPayloadTruncated = errors.New("payload was truncated")
 )
@@ -37,4 +37,8 @@ const (
 	MetadataEncodingProtoJSON = "json/protobuf"
 	// MetadataEncodingProto is "binary/protobuf"
 	MetadataEncodingProto = "binary/protobuf"
+	// MetadataTruncated is "truncated", set to the original size of a payload truncated by PayloadSizeTruncate
+	MetadataTruncated = "truncated"
+	// MetadataOffloadKey is "offload-key", set to the PayloadStore key of a payload offloaded by PayloadSizeOffload
+	MetadataOffloadKey = "offload-key"
 )
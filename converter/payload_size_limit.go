@@ -0,0 +1,250 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package converter
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/uber-go/tally"
+	commonpb "go.temporal.io/api/common/v1"
+
+	"go.temporal.io/sdk/internal/common/metrics"
+	"go.temporal.io/sdk/log"
+)
+
+type (
+	// PayloadSizeAction is what a payload size limited DataConverter does with a payload over the limit.
+	PayloadSizeAction int
+
+	// PayloadStore keeps the payloads offloaded with PayloadSizeOffload outside of the workflow history.
+	PayloadStore interface {
+		// Put stores the payload and returns the key to get it back.
+		Put(payload *commonpb.Payload) (string, error)
+		// Get returns the payload stored with the key.
+		Get(key string) (*commonpb.Payload, error)
+	}
+
+	// PayloadSizeLimitOptions configure NewPayloadSizeLimitDataConverter.
+	PayloadSizeLimitOptions struct {
+		// Required: The maximum size in bytes of a single payload.
+		MaxSize int
+
+		// Optional: What to do with a payload over MaxSize.
+		// default: PayloadSizeFail
+		Action PayloadSizeAction
+
+		// Required with PayloadSizeOffload: Where the payloads over MaxSize are stored.
+		Store PayloadStore
+
+		// Optional: Scope of the temporal_payload_size_limit_exceeded counter, tagged with the action.
+		// default: no metrics
+		MetricsScope tally.Scope
+
+		// Optional: Logger of the warnings about the payloads over MaxSize.
+		// default: no logs
+		Logger log.Logger
+	}
+
+	payloadSizeLimitDataConverter struct {
+		dataConverter DataConverter
+		options       PayloadSizeLimitOptions
+	}
+)
+
+const (
+	// PayloadSizeFail fails the serialization with an error wrapping ErrPayloadTooLarge. The error is returned where
+	// the value is passed to the SDK, e.g. by the future of ExecuteActivity or SignalExternalWorkflow, instead of
+	// the server rejecting the whole request later.
+	PayloadSizeFail PayloadSizeAction = iota
+	// PayloadSizeTruncate keeps the first MaxSize bytes of the payload and logs a warning. Decoding a truncated
+	// payload fails with ErrPayloadTruncated, so it is only meant for values that are informative, like details
+	// and progress reports.
+	PayloadSizeTruncate
+	// PayloadSizeOffload puts the payload in the PayloadStore and replaces it with a reference to it, which is
+	// resolved transparently when the payload is decoded.
+	PayloadSizeOffload
+)
+
+// String returns the name of the action used in logs and metrics.
+func (a PayloadSizeAction) String() string {
+	switch a {
+	case PayloadSizeFail:
+		return "fail"
+	case PayloadSizeTruncate:
+		return "truncate"
+	case PayloadSizeOffload:
+		return "offload"
+	}
+	return "unknown"
+}
+
+// NewPayloadSizeLimitDataConverter returns a DataConverter which applies the size limit of the options to every
+// payload produced by dataConverter: activity inputs and results, signals, queries, markers, memos, etc.
+// The limit is checked for each value separately, and the error of PayloadSizeFail names the type and the position
+// of the offending value. As workflow code serializes values again on replay, the metric and the warnings can be
+// reported more than once for the same value.
+func NewPayloadSizeLimitDataConverter(dataConverter DataConverter, options PayloadSizeLimitOptions) DataConverter {
+	if options.MaxSize <= 0 {
+		panic("PayloadSizeLimitOptions.MaxSize must be positive")
+	}
+	if options.Action == PayloadSizeOffload && options.Store == nil {
+		panic("PayloadSizeLimitOptions.Store is required with PayloadSizeOffload")
+	}
+	return &payloadSizeLimitDataConverter{dataConverter: dataConverter, options: options}
+}
+
+func (dc *payloadSizeLimitDataConverter) ToPayload(value interface{}) (*commonpb.Payload, error) {
+	payload, err := dc.dataConverter.ToPayload(value)
+	if err != nil || payload == nil {
+		return payload, err
+	}
+	return dc.limit(payload, value)
+}
+
+func (dc *payloadSizeLimitDataConverter) FromPayload(payload *commonpb.Payload, valuePtr interface{}) error {
+	payload, err := dc.resolve(payload)
+	if err != nil {
+		return err
+	}
+	return dc.dataConverter.FromPayload(payload, valuePtr)
+}
+
+func (dc *payloadSizeLimitDataConverter) ToPayloads(values ...interface{}) (*commonpb.Payloads, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	result := &commonpb.Payloads{Payloads: make([]*commonpb.Payload, 0, len(values))}
+	for i, value := range values {
+		payload, err := dc.ToPayload(value)
+		if err != nil {
+			return nil, fmt.Errorf("values[%d]: %w", i, err)
+		}
+
+		result.Payloads = append(result.Payloads, payload)
+	}
+
+	return result, nil
+}
+
+func (dc *payloadSizeLimitDataConverter) FromPayloads(payloads *commonpb.Payloads, valuePtrs ...interface{}) error {
+	if payloads == nil {
+		return nil
+	}
+
+	for i, payload := range payloads.GetPayloads() {
+		if i >= len(valuePtrs) {
+			break
+		}
+
+		err := dc.FromPayload(payload, valuePtrs[i])
+		if err != nil {
+			return fmt.Errorf("payload item %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (dc *payloadSizeLimitDataConverter) ToString(payload *commonpb.Payload) string {
+	if key, ok := payload.GetMetadata()[MetadataOffloadKey]; ok {
+		return fmt.Sprintf("offloaded payload %s", key)
+	}
+	return dc.dataConverter.ToString(payload)
+}
+
+func (dc *payloadSizeLimitDataConverter) ToStrings(payloads *commonpb.Payloads) []string {
+	if payloads == nil {
+		return nil
+	}
+
+	var result []string
+	for _, payload := range payloads.GetPayloads() {
+		result = append(result, dc.ToString(payload))
+	}
+
+	return result
+}
+
+func (dc *payloadSizeLimitDataConverter) limit(payload *commonpb.Payload, value interface{}) (*commonpb.Payload, error) {
+	size := payload.Size()
+	if size <= dc.options.MaxSize {
+		return payload, nil
+	}
+
+	if dc.options.MetricsScope != nil {
+		dc.options.MetricsScope.Tagged(map[string]string{metrics.PayloadActionTagName: dc.options.Action.String()}).
+			Counter(metrics.PayloadSizeLimitExceededCounter).Inc(1)
+	}
+
+	switch dc.options.Action {
+	case PayloadSizeTruncate:
+		if dc.options.Logger != nil {
+			dc.options.Logger.Warn("Payload exceeds size limit and is truncated.",
+				"Type", fmt.Sprintf("%T", value), "Size", size, "MaxSize", dc.options.MaxSize)
+		}
+		metadata := make(map[string][]byte, len(payload.Metadata)+1)
+		for k, v := range payload.Metadata {
+			metadata[k] = v
+		}
+		metadata[MetadataTruncated] = []byte(strconv.Itoa(size))
+		data := payload.Data
+		if len(data) > dc.options.MaxSize {
+			data = data[:dc.options.MaxSize]
+		}
+		return &commonpb.Payload{Metadata: metadata, Data: data}, nil
+	case PayloadSizeOffload:
+		key, err := dc.options.Store.Put(payload)
+		if err != nil {
+			return nil, fmt.Errorf("unable to offload value of type %T: %w", value, err)
+		}
+		if dc.options.Logger != nil {
+			dc.options.Logger.Debug("Payload exceeds size limit and is offloaded.",
+				"Type", fmt.Sprintf("%T", value), "Size", size, "Key", key)
+		}
+		return &commonpb.Payload{Metadata: map[string][]byte{
+			MetadataEncoding:   payload.Metadata[MetadataEncoding],
+			MetadataOffloadKey: []byte(key),
+		}}, nil
+	default:
+		return nil, fmt.Errorf("value of type %T is %d bytes, limit is %d: %w", value, size, dc.options.MaxSize, ErrPayloadTooLarge)
+	}
+}
+
+func (dc *payloadSizeLimitDataConverter) resolve(payload *commonpb.Payload) (*commonpb.Payload, error) {
+	metadata := payload.GetMetadata()
+	if _, ok := metadata[MetadataTruncated]; ok {
+		return nil, ErrPayloadTruncated
+	}
+	key, ok := metadata[MetadataOffloadKey]
+	if !ok {
+		return payload, nil
+	}
+	if dc.options.Store == nil {
+		return nil, fmt.Errorf("payload offloaded with key %s but no PayloadStore is set", key)
+	}
+	return dc.options.Store.Get(string(key))
+}
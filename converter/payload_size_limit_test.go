@@ -0,0 +1,83 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package converter
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+)
+
+type memoryPayloadStore struct {
+	payloads map[string]*commonpb.Payload
+}
+
+func (s *memoryPayloadStore) Put(payload *commonpb.Payload) (string, error) {
+	key := strconv.Itoa(len(s.payloads))
+	s.payloads[key] = payload
+	return key, nil
+}
+
+func (s *memoryPayloadStore) Get(key string) (*commonpb.Payload, error) {
+	payload, ok := s.payloads[key]
+	if !ok {
+		return nil, errors.New("unknown key")
+	}
+	return payload, nil
+}
+
+func TestPayloadSizeLimitDataConverter(t *testing.T) {
+	large := strings.Repeat("x", 100)
+
+	dc := NewPayloadSizeLimitDataConverter(GetDefaultDataConverter(), PayloadSizeLimitOptions{MaxSize: 50})
+	payloads, err := dc.ToPayloads("small", large)
+	assert.Nil(t, payloads)
+	assert.True(t, errors.Is(err, ErrPayloadTooLarge))
+	assert.Contains(t, err.Error(), "values[1]: value of type string")
+
+	dc = NewPayloadSizeLimitDataConverter(GetDefaultDataConverter(), PayloadSizeLimitOptions{MaxSize: 50, Action: PayloadSizeTruncate})
+	payload, err := dc.ToPayload(large)
+	require.NoError(t, err)
+	assert.Len(t, payload.Data, 50)
+	assert.NotEmpty(t, payload.Metadata[MetadataTruncated])
+	var s string
+	assert.True(t, errors.Is(dc.FromPayload(payload, &s), ErrPayloadTruncated))
+
+	store := &memoryPayloadStore{payloads: map[string]*commonpb.Payload{}}
+	dc = NewPayloadSizeLimitDataConverter(GetDefaultDataConverter(), PayloadSizeLimitOptions{MaxSize: 50, Action: PayloadSizeOffload, Store: store})
+	payloads, err = dc.ToPayloads("small", large)
+	require.NoError(t, err)
+	assert.Len(t, store.payloads, 1)
+	assert.Empty(t, payloads.Payloads[1].Data)
+	var s1, s2 string
+	require.NoError(t, dc.FromPayloads(payloads, &s1, &s2))
+	assert.Equal(t, "small", s1)
+	assert.Equal(t, large, s2)
+}
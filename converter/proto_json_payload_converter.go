@@ -81,12 +81,13 @@ func (c *ProtoJSONPayloadConverter) ToPayload(value interface{}) (*commonpb.Payl
 			return newPayload(byteSlice, c), nil
 		}
 		if valueGogoProto, ok := value.(gogoproto.Message); ok {
-			var buf bytes.Buffer
-			err := c.gogoMarshaler.Marshal(&buf, valueGogoProto)
+			data, err := encodeWithPooledBuffer(func(buf *bytes.Buffer) error {
+				return c.gogoMarshaler.Marshal(buf, valueGogoProto)
+			})
 			if err != nil {
 				return nil, fmt.Errorf("%w: %v", ErrUnableToEncode, err)
 			}
-			return newPayload(buf.Bytes(), c), nil
+			return newPayload(data, c), nil
 		}
 		if builtPointer {
 			break
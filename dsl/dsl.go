@@ -0,0 +1,274 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package dsl interprets declarative workflow definitions written in JSON or YAML on top of the workflow primitives.
+//
+// A definition consists of initial variables and a root statement. A statement is exactly one of:
+//  - activity: executes an activity by name, passing variables as arguments and storing the result in a variable,
+//  - sequence: executes the nested statements one after another,
+//  - parallel: executes the nested statements concurrently and waits for all of them,
+//  - if: executes one of two statements depending on the value of a variable,
+//  - signal: waits for a signal and stores its value in a variable.
+//
+// For example:
+//  variables:
+//    orderID: "42"
+//  root:
+//    sequence:
+//      elements:
+//        - activity:
+//            name: ReserveInventory
+//            arguments: [orderID]
+//            result: reservation
+//            options:
+//              startToCloseTimeout: 30s
+//              retryPolicy:
+//                maximumAttempts: 3
+//        - signal:
+//            name: approval
+//            result: approved
+//            timeout: 24h
+//        - if:
+//            variable: approved
+//            equals: "yes"
+//            then:
+//              activity:
+//                name: ShipOrder
+//                arguments: [reservation]
+//
+// All variables are strings. The interpreter only uses workflow APIs, so it is deterministic and can be unit tested
+// with the testsuite package like any other workflow.
+package dsl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Workflow is a declarative workflow definition.
+	Workflow struct {
+		// Variables are the initial variables of the workflow.
+		Variables map[string]string `json:"variables,omitempty" yaml:"variables,omitempty"`
+		// ActivityOptions are the default options of all activities of the workflow.
+		ActivityOptions *ActivityOptions `json:"activityOptions,omitempty" yaml:"activityOptions,omitempty"`
+		// Root is the statement executed by the workflow.
+		Root *Statement `json:"root" yaml:"root"`
+	}
+
+	// Statement is a single step of a workflow. Exactly one of the fields must be set.
+	Statement struct {
+		Activity *Activity `json:"activity,omitempty" yaml:"activity,omitempty"`
+		Sequence *Sequence `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+		Parallel *Parallel `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+		If       *If       `json:"if,omitempty" yaml:"if,omitempty"`
+		Signal   *Signal   `json:"signal,omitempty" yaml:"signal,omitempty"`
+	}
+
+	// Activity executes an activity.
+	Activity struct {
+		// Name is the activity type name.
+		Name string `json:"name" yaml:"name"`
+		// Arguments are the names of the variables passed to the activity.
+		Arguments []string `json:"arguments,omitempty" yaml:"arguments,omitempty"`
+		// Result is the name of the variable the activity result is stored in. Optional.
+		Result string `json:"result,omitempty" yaml:"result,omitempty"`
+		// Options override the default activity options of the workflow. Optional.
+		Options *ActivityOptions `json:"options,omitempty" yaml:"options,omitempty"`
+	}
+
+	// ActivityOptions configures the execution of an activity.
+	ActivityOptions struct {
+		TaskQueue              string       `json:"taskQueue,omitempty" yaml:"taskQueue,omitempty"`
+		ScheduleToCloseTimeout Duration     `json:"scheduleToCloseTimeout,omitempty" yaml:"scheduleToCloseTimeout,omitempty"`
+		StartToCloseTimeout    Duration     `json:"startToCloseTimeout,omitempty" yaml:"startToCloseTimeout,omitempty"`
+		HeartbeatTimeout       Duration     `json:"heartbeatTimeout,omitempty" yaml:"heartbeatTimeout,omitempty"`
+		RetryPolicy            *RetryPolicy `json:"retryPolicy,omitempty" yaml:"retryPolicy,omitempty"`
+	}
+
+	// RetryPolicy defines how an activity is retried. See temporal.RetryPolicy.
+	RetryPolicy struct {
+		InitialInterval        Duration `json:"initialInterval,omitempty" yaml:"initialInterval,omitempty"`
+		BackoffCoefficient     float64  `json:"backoffCoefficient,omitempty" yaml:"backoffCoefficient,omitempty"`
+		MaximumInterval        Duration `json:"maximumInterval,omitempty" yaml:"maximumInterval,omitempty"`
+		MaximumAttempts        int32    `json:"maximumAttempts,omitempty" yaml:"maximumAttempts,omitempty"`
+		NonRetryableErrorTypes []string `json:"nonRetryableErrorTypes,omitempty" yaml:"nonRetryableErrorTypes,omitempty"`
+	}
+
+	// Sequence executes statements one after another. It stops at the first failed statement.
+	Sequence struct {
+		Elements []*Statement `json:"elements" yaml:"elements"`
+	}
+
+	// Parallel executes statements concurrently. If a branch fails the remaining branches are canceled.
+	Parallel struct {
+		Branches []*Statement `json:"branches" yaml:"branches"`
+	}
+
+	// If executes Then if the variable is equal to Equals and Else otherwise.
+	If struct {
+		Variable string     `json:"variable" yaml:"variable"`
+		Equals   string     `json:"equals" yaml:"equals"`
+		Then     *Statement `json:"then,omitempty" yaml:"then,omitempty"`
+		Else     *Statement `json:"else,omitempty" yaml:"else,omitempty"`
+	}
+
+	// Signal waits for a signal with a string value.
+	Signal struct {
+		// Name is the signal name.
+		Name string `json:"name" yaml:"name"`
+		// Result is the name of the variable the signal value is stored in. Optional.
+		Result string `json:"result,omitempty" yaml:"result,omitempty"`
+		// Timeout is the maximum time to wait for the signal. Zero means wait forever.
+		Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	}
+
+	// Duration is a time.Duration that is encoded as a string like "1m30s".
+	Duration time.Duration
+)
+
+// ParseJSON parses and validates a JSON workflow definition.
+func ParseJSON(data []byte) (*Workflow, error) {
+	var w Workflow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ParseYAML parses and validates a YAML workflow definition.
+func ParseYAML(data []byte) (*Workflow, error) {
+	var w Workflow
+	if err := yaml.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Validate checks that the definition is well formed.
+func (w *Workflow) Validate() error {
+	if w.Root == nil {
+		return errors.New("root statement is required")
+	}
+	return w.Root.validate("root")
+}
+
+func (s *Statement) validate(path string) error {
+	if s == nil {
+		return fmt.Errorf("%s: statement is required", path)
+	}
+	count := 0
+	var err error
+	if s.Activity != nil {
+		count++
+		if s.Activity.Name == "" {
+			err = fmt.Errorf("%s.activity: name is required", path)
+		}
+	}
+	if s.Sequence != nil {
+		count++
+		for i, e := range s.Sequence.Elements {
+			if err == nil {
+				err = e.validate(fmt.Sprintf("%s.sequence.elements[%d]", path, i))
+			}
+		}
+	}
+	if s.Parallel != nil {
+		count++
+		for i, b := range s.Parallel.Branches {
+			if err == nil {
+				err = b.validate(fmt.Sprintf("%s.parallel.branches[%d]", path, i))
+			}
+		}
+	}
+	if s.If != nil {
+		count++
+		if s.If.Variable == "" {
+			err = fmt.Errorf("%s.if: variable is required", path)
+		}
+		if err == nil && s.If.Then != nil {
+			err = s.If.Then.validate(path + ".if.then")
+		}
+		if err == nil && s.If.Else != nil {
+			err = s.If.Else.validate(path + ".if.else")
+		}
+	}
+	if s.Signal != nil {
+		count++
+		if s.Signal.Name == "" {
+			err = fmt.Errorf("%s.signal: name is required", path)
+		}
+	}
+	if count != 1 {
+		return fmt.Errorf("%s: exactly one of activity, sequence, parallel, if or signal must be set, found %d", path, count)
+	}
+	return err
+}
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes the duration from a string like "1m30s".
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+// MarshalYAML encodes the duration as a string.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// UnmarshalYAML decodes the duration from a string like "1m30s".
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,177 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dsl
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+const orderDefinition = `
+variables:
+  orderID: "42"
+activityOptions:
+  startToCloseTimeout: 10s
+root:
+  sequence:
+    elements:
+      - parallel:
+          branches:
+            - activity:
+                name: Reserve
+                arguments: [orderID]
+                result: reservation
+            - activity:
+                name: Charge
+                arguments: [orderID]
+                result: payment
+                options:
+                  retryPolicy:
+                    maximumAttempts: 2
+      - signal:
+          name: approval
+          result: approved
+          timeout: 1h
+      - if:
+          variable: approved
+          equals: "yes"
+          then:
+            activity:
+              name: Ship
+              arguments: [reservation, payment]
+              result: shipment
+          else:
+            activity:
+              name: Release
+              arguments: [reservation]
+`
+
+type dslTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	env *testsuite.TestWorkflowEnvironment
+}
+
+func TestDSLTestSuite(t *testing.T) {
+	suite.Run(t, new(dslTestSuite))
+}
+
+func (s *dslTestSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.env.RegisterWorkflowWithOptions(Interpret, workflow.RegisterOptions{Name: WorkflowName})
+	register := func(name string, fn interface{}) {
+		s.env.RegisterActivityWithOptions(fn, activity.RegisterOptions{Name: name})
+	}
+	register("Reserve", func(orderID string) (string, error) { return "reservation-" + orderID, nil })
+	register("Charge", func(orderID string) (string, error) { return "payment-" + orderID, nil })
+	register("Ship", func(reservation, payment string) (string, error) { return reservation + "/" + payment, nil })
+	register("Release", func(reservation string) error { return nil })
+}
+
+func (s *dslTestSuite) parse(definition string) *Workflow {
+	w, err := ParseYAML([]byte(definition))
+	s.NoError(err)
+	return w
+}
+
+func (s *dslTestSuite) TestApproved() {
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow("approval", "yes")
+	}, time.Minute)
+	s.env.ExecuteWorkflow(WorkflowName, s.parse(orderDefinition))
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var variables map[string]string
+	s.NoError(s.env.GetWorkflowResult(&variables))
+	s.Equal(map[string]string{
+		"orderID":     "42",
+		"reservation": "reservation-42",
+		"payment":     "payment-42",
+		"approved":    "yes",
+		"shipment":    "reservation-42/payment-42",
+	}, variables)
+}
+
+func (s *dslTestSuite) TestRejected() {
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow("approval", "no")
+	}, time.Minute)
+	s.env.ExecuteWorkflow(WorkflowName, s.parse(orderDefinition))
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+	var variables map[string]string
+	s.NoError(s.env.GetWorkflowResult(&variables))
+	s.Equal("no", variables["approved"])
+	s.NotContains(variables, "shipment")
+}
+
+func (s *dslTestSuite) TestSignalTimeout() {
+	s.env.ExecuteWorkflow(WorkflowName, s.parse(orderDefinition))
+
+	s.True(s.env.IsWorkflowCompleted())
+	var applicationErr *temporal.ApplicationError
+	s.True(errors.As(s.env.GetWorkflowError(), &applicationErr))
+	s.Equal(signalTimeoutErrorType, applicationErr.Type())
+}
+
+func (s *dslTestSuite) TestParallelFailure() {
+	s.env.OnActivity("Charge", "42").Return("", errors.New("card declined"))
+	s.env.ExecuteWorkflow(WorkflowName, s.parse(orderDefinition))
+
+	s.True(s.env.IsWorkflowCompleted())
+	s.Error(s.env.GetWorkflowError())
+	s.Contains(s.env.GetWorkflowError().Error(), "card declined")
+}
+
+func TestParseJSON(t *testing.T) {
+	w, err := ParseJSON([]byte(`{
+		"root": {"activity": {"name": "Foo", "options": {"startToCloseTimeout": "1m30s"}}}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "Foo", w.Root.Activity.Name)
+	require.Equal(t, 90*time.Second, time.Duration(w.Root.Activity.Options.StartToCloseTimeout))
+}
+
+func TestValidate(t *testing.T) {
+	_, err := ParseJSON([]byte(`{}`))
+	require.EqualError(t, err, "root statement is required")
+
+	_, err = ParseJSON([]byte(`{"root": {"sequence": {"elements": [{"activity": {"name": "Foo"}, "signal": {"name": "bar"}}]}}}`))
+	require.EqualError(t, err, "root.sequence.elements[0]: exactly one of activity, sequence, parallel, if or signal must be set, found 2")
+
+	_, err = ParseYAML([]byte("root:\n  signal:\n    result: foo\n"))
+	require.EqualError(t, err, "root.signal: name is required")
+}
@@ -0,0 +1,225 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dsl
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	// WorkflowName is the workflow type name Register registers the interpreter with.
+	WorkflowName = "DSLWorkflow"
+
+	// defaultStartToCloseTimeout is used for activities that don't have any timeout configured.
+	defaultStartToCloseTimeout = time.Minute
+
+	invalidDefinitionErrorType = "InvalidDefinition"
+	signalTimeoutErrorType     = "SignalTimeout"
+)
+
+// interpreter holds the state of a single workflow execution. The variables are shared by all parallel branches,
+// which is safe because workflow coroutines never run concurrently.
+type interpreter struct {
+	defaults  *ActivityOptions
+	variables map[string]string
+}
+
+// Register registers the interpreter workflow with the worker under WorkflowName.
+func Register(r worker.WorkflowRegistry) {
+	r.RegisterWorkflowWithOptions(Interpret, workflow.RegisterOptions{Name: WorkflowName})
+}
+
+// Interpret is a workflow that executes the definition and returns the final values of all variables.
+func Interpret(ctx workflow.Context, definition Workflow) (map[string]string, error) {
+	if err := definition.Validate(); err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), invalidDefinitionErrorType, nil)
+	}
+	variables := make(map[string]string, len(definition.Variables))
+	// Copying the variables doesn't depend on the iteration order.
+	//workflowcheck:ignore
+	for name, value := range definition.Variables {
+		variables[name] = value
+	}
+	i := &interpreter{defaults: definition.ActivityOptions, variables: variables}
+	if err := i.execute(ctx, definition.Root); err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+func (i *interpreter) execute(ctx workflow.Context, s *Statement) error {
+	switch {
+	case s.Activity != nil:
+		return i.executeActivity(ctx, s.Activity)
+	case s.Sequence != nil:
+		for _, element := range s.Sequence.Elements {
+			if err := i.execute(ctx, element); err != nil {
+				return err
+			}
+		}
+		return nil
+	case s.Parallel != nil:
+		return i.executeParallel(ctx, s.Parallel)
+	case s.If != nil:
+		branch := s.If.Else
+		if i.variables[s.If.Variable] == s.If.Equals {
+			branch = s.If.Then
+		}
+		if branch == nil {
+			return nil
+		}
+		return i.execute(ctx, branch)
+	case s.Signal != nil:
+		return i.waitSignal(ctx, s.Signal)
+	}
+	return nil
+}
+
+func (i *interpreter) executeActivity(ctx workflow.Context, a *Activity) error {
+	args := make([]interface{}, 0, len(a.Arguments))
+	for _, name := range a.Arguments {
+		value, ok := i.variables[name]
+		if !ok {
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("activity %s: variable %s is not defined", a.Name, name), invalidDefinitionErrorType, nil)
+		}
+		args = append(args, value)
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, i.activityOptions(a.Options))
+	future := workflow.ExecuteActivity(ctx, a.Name, args...)
+	if a.Result == "" {
+		return future.Get(ctx, nil)
+	}
+	var result string
+	if err := future.Get(ctx, &result); err != nil {
+		return err
+	}
+	i.variables[a.Result] = result
+	return nil
+}
+
+func (i *interpreter) executeParallel(ctx workflow.Context, p *Parallel) error {
+	childCtx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	results := workflow.NewBufferedChannel(ctx, len(p.Branches))
+	for _, branch := range p.Branches {
+		branch := branch
+		workflow.Go(childCtx, func(ctx workflow.Context) {
+			results.Send(ctx, i.execute(ctx, branch))
+		})
+	}
+
+	var firstErr error
+	for range p.Branches {
+		var err error
+		results.Receive(ctx, &err)
+		if err != nil && firstErr == nil {
+			firstErr = err
+			// Cancel the remaining branches, but still wait for them to return.
+			cancel()
+		}
+	}
+	return firstErr
+}
+
+func (i *interpreter) waitSignal(ctx workflow.Context, s *Signal) error {
+	var value string
+	signalChan := workflow.GetSignalChannel(ctx, s.Name)
+	if s.Timeout <= 0 {
+		signalChan.Receive(ctx, &value)
+	} else {
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		defer cancelTimer()
+
+		received := false
+		selector := workflow.NewSelector(ctx)
+		selector.AddReceive(signalChan, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, &value)
+			received = true
+		})
+		selector.AddFuture(workflow.NewTimer(timerCtx, time.Duration(s.Timeout)), func(f workflow.Future) {})
+		selector.Select(ctx)
+		if !received {
+			return temporal.NewApplicationError(
+				fmt.Sprintf("timed out waiting for signal %s", s.Name), signalTimeoutErrorType)
+		}
+	}
+	if s.Result != "" {
+		i.variables[s.Result] = value
+	}
+	return nil
+}
+
+// activityOptions merges the options of an activity with the default options of the workflow.
+func (i *interpreter) activityOptions(override *ActivityOptions) workflow.ActivityOptions {
+	var merged ActivityOptions
+	if i.defaults != nil {
+		merged = *i.defaults
+	}
+	if override != nil {
+		if override.TaskQueue != "" {
+			merged.TaskQueue = override.TaskQueue
+		}
+		if override.ScheduleToCloseTimeout != 0 {
+			merged.ScheduleToCloseTimeout = override.ScheduleToCloseTimeout
+		}
+		if override.StartToCloseTimeout != 0 {
+			merged.StartToCloseTimeout = override.StartToCloseTimeout
+		}
+		if override.HeartbeatTimeout != 0 {
+			merged.HeartbeatTimeout = override.HeartbeatTimeout
+		}
+		if override.RetryPolicy != nil {
+			merged.RetryPolicy = override.RetryPolicy
+		}
+	}
+
+	options := workflow.ActivityOptions{
+		TaskQueue:              merged.TaskQueue,
+		ScheduleToCloseTimeout: time.Duration(merged.ScheduleToCloseTimeout),
+		StartToCloseTimeout:    time.Duration(merged.StartToCloseTimeout),
+		HeartbeatTimeout:       time.Duration(merged.HeartbeatTimeout),
+	}
+	if options.ScheduleToCloseTimeout == 0 && options.StartToCloseTimeout == 0 {
+		options.StartToCloseTimeout = defaultStartToCloseTimeout
+	}
+	if rp := merged.RetryPolicy; rp != nil {
+		options.RetryPolicy = &temporal.RetryPolicy{
+			InitialInterval:        time.Duration(rp.InitialInterval),
+			BackoffCoefficient:     rp.BackoffCoefficient,
+			MaximumInterval:        time.Duration(rp.MaximumInterval),
+			MaximumAttempts:        rp.MaximumAttempts,
+			NonRetryableErrorTypes: rp.NonRetryableErrorTypes,
+		}
+	}
+	return options
+}
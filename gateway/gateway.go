@@ -0,0 +1,433 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package gateway exposes workflows over HTTP with JSON bodies, so tools written in other languages can start, signal,
+// query, describe and cancel workflows without linking the SDK.
+//
+// The handler returned by NewHandler serves the following endpoints, relative to where it is mounted:
+//  POST /workflows                                  starts a workflow, see StartRequest
+//  GET  /workflows/{workflowID}                     describes a workflow, see Description
+//  POST /workflows/{workflowID}/signal/{signalName} signals a workflow with the request body as argument
+//  POST /workflows/{workflowID}/query/{queryType}   queries a workflow with the optional JSON array body as arguments
+//  POST /workflows/{workflowID}/cancel              requests cancellation of a workflow
+//
+// All but the start endpoint accept a runId query parameter. Arguments are passed to the client as raw JSON, so they
+// are encoded as JSON payloads by the default DataConverter, and results are decoded with the DataConverter of the
+// client into raw JSON. Errors are returned as {"error": "message"} with a status code matching the error.
+//
+// The handler doesn't authenticate requests by itself: set Options.Authorizer to check the credentials of the
+// requests, e.g.
+//  http.Handle("/temporal/", http.StripPrefix("/temporal", gateway.NewHandler(gateway.Options{
+//  	Client: c,
+//  	Authorizer: func(r *http.Request, operation gateway.Operation, workflowID string) error {
+//  		return checkToken(r.Header.Get("Authorization"), operation)
+//  	},
+//  })))
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+)
+
+const defaultMaxBodySize = 1 << 20
+
+// Operation is the operation of a request, passed to the Authorizer.
+type Operation string
+
+const (
+	// OperationStart starts a workflow.
+	OperationStart Operation = "start"
+	// OperationSignal signals a workflow.
+	OperationSignal Operation = "signal"
+	// OperationQuery queries a workflow.
+	OperationQuery Operation = "query"
+	// OperationDescribe describes a workflow.
+	OperationDescribe Operation = "describe"
+	// OperationCancel requests cancellation of a workflow.
+	OperationCancel Operation = "cancel"
+)
+
+type (
+	// Options configure NewHandler.
+	Options struct {
+		// Required: The client the requests are executed with.
+		Client client.Client
+
+		// Optional: Called before executing each request. An error rejects the request with a 403 status code.
+		// default: all requests are allowed
+		Authorizer func(r *http.Request, operation Operation, workflowID string) error
+
+		// Optional: The maximum size in bytes of a request body.
+		// default: 1MB
+		MaxBodySize int64
+	}
+
+	// StartRequest is the body of the start endpoint.
+	StartRequest struct {
+		ID                       string                     `json:"id"`
+		WorkflowType             string                     `json:"workflowType"`
+		TaskQueue                string                     `json:"taskQueue"`
+		Args                     []json.RawMessage          `json:"args,omitempty"`
+		WorkflowExecutionTimeout string                     `json:"workflowExecutionTimeout,omitempty"`
+		WorkflowRunTimeout       string                     `json:"workflowRunTimeout,omitempty"`
+		Memo                     map[string]json.RawMessage `json:"memo,omitempty"`
+		SearchAttributes         map[string]interface{}     `json:"searchAttributes,omitempty"`
+	}
+
+	// StartResponse is the response of the start endpoint.
+	StartResponse struct {
+		WorkflowID string `json:"workflowId"`
+		RunID      string `json:"runId"`
+	}
+
+	// QueryResponse is the response of the query endpoint.
+	QueryResponse struct {
+		Result json.RawMessage `json:"result"`
+	}
+
+	// Description is the response of the describe endpoint.
+	Description struct {
+		WorkflowID        string                 `json:"workflowId"`
+		RunID             string                 `json:"runId"`
+		WorkflowType      string                 `json:"workflowType"`
+		Status            string                 `json:"status"`
+		StartTime         time.Time              `json:"startTime"`
+		CloseTime         *time.Time             `json:"closeTime,omitempty"`
+		HistoryLength     int64                  `json:"historyLength"`
+		PendingActivities []PendingActivity      `json:"pendingActivities,omitempty"`
+		PendingChildren   []PendingChildWorkflow `json:"pendingChildren,omitempty"`
+	}
+
+	// PendingActivity is an activity of a described workflow which didn't complete yet.
+	PendingActivity struct {
+		ActivityID   string `json:"activityId"`
+		ActivityType string `json:"activityType"`
+		State        string `json:"state"`
+		Attempt      int32  `json:"attempt"`
+		LastFailure  string `json:"lastFailure,omitempty"`
+	}
+
+	// PendingChildWorkflow is a child workflow of a described workflow which didn't complete yet.
+	PendingChildWorkflow struct {
+		WorkflowID   string `json:"workflowId"`
+		RunID        string `json:"runId"`
+		WorkflowType string `json:"workflowType"`
+	}
+
+	errorResponse struct {
+		Error string `json:"error"`
+	}
+
+	handler struct {
+		options Options
+	}
+)
+
+// NewHandler returns the HTTP handler of the gateway.
+func NewHandler(options Options) http.Handler {
+	if options.Client == nil {
+		panic("Options.Client is required")
+	}
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = defaultMaxBodySize
+	}
+	return &handler{options: options}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments, err := pathSegments(r.URL)
+	if err != nil || len(segments) == 0 || segments[0] != "workflows" {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	runID := r.URL.Query().Get("runId")
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		h.start(w, r)
+	case len(segments) == 2 && r.Method == http.MethodGet:
+		if h.authorize(w, r, OperationDescribe, segments[1]) {
+			h.describe(w, r, segments[1], runID)
+		}
+	case len(segments) == 4 && segments[2] == "signal" && r.Method == http.MethodPost:
+		if h.authorize(w, r, OperationSignal, segments[1]) {
+			h.signal(w, r, segments[1], runID, segments[3])
+		}
+	case len(segments) == 4 && segments[2] == "query" && r.Method == http.MethodPost:
+		if h.authorize(w, r, OperationQuery, segments[1]) {
+			h.query(w, r, segments[1], runID, segments[3])
+		}
+	case len(segments) == 3 && segments[2] == "cancel" && r.Method == http.MethodPost:
+		if h.authorize(w, r, OperationCancel, segments[1]) {
+			writeResult(w, nil, h.options.Client.CancelWorkflow(r.Context(), segments[1], runID))
+		}
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (h *handler) authorize(w http.ResponseWriter, r *http.Request, operation Operation, workflowID string) bool {
+	if h.options.Authorizer == nil {
+		return true
+	}
+	if err := h.options.Authorizer(r, operation, workflowID); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return false
+	}
+	return true
+}
+
+func (h *handler) start(w http.ResponseWriter, r *http.Request) {
+	var request StartRequest
+	if err := h.readBody(w, r, &request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !h.authorize(w, r, OperationStart, request.ID) {
+		return
+	}
+	if request.WorkflowType == "" || request.TaskQueue == "" {
+		writeError(w, http.StatusBadRequest, errors.New("workflowType and taskQueue are required"))
+		return
+	}
+	options := client.StartWorkflowOptions{
+		ID:               request.ID,
+		TaskQueue:        request.TaskQueue,
+		SearchAttributes: request.SearchAttributes,
+	}
+	var err error
+	if options.WorkflowExecutionTimeout, err = parseDuration(request.WorkflowExecutionTimeout); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("workflowExecutionTimeout: %w", err))
+		return
+	}
+	if options.WorkflowRunTimeout, err = parseDuration(request.WorkflowRunTimeout); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("workflowRunTimeout: %w", err))
+		return
+	}
+	if len(request.Memo) > 0 {
+		options.Memo = make(map[string]interface{}, len(request.Memo))
+		for k, v := range request.Memo {
+			options.Memo[k] = v
+		}
+	}
+	args := make([]interface{}, len(request.Args))
+	for i, arg := range request.Args {
+		args[i] = arg
+	}
+
+	run, err := h.options.Client.ExecuteWorkflow(r.Context(), options, request.WorkflowType, args...)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	writeResult(w, StartResponse{WorkflowID: run.GetID(), RunID: run.GetRunID()}, nil)
+}
+
+func (h *handler) signal(w http.ResponseWriter, r *http.Request, workflowID, runID, signalName string) {
+	var arg json.RawMessage
+	if err := h.readBody(w, r, &arg); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var signalArg interface{}
+	if len(arg) > 0 {
+		signalArg = arg
+	}
+	writeResult(w, nil, h.options.Client.SignalWorkflow(r.Context(), workflowID, runID, signalName, signalArg))
+}
+
+func (h *handler) query(w http.ResponseWriter, r *http.Request, workflowID, runID, queryType string) {
+	var rawArgs []json.RawMessage
+	if err := h.readBody(w, r, &rawArgs); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	args := make([]interface{}, len(rawArgs))
+	for i, arg := range rawArgs {
+		args[i] = arg
+	}
+	value, err := h.options.Client.QueryWorkflow(r.Context(), workflowID, runID, queryType, args...)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	result, err := decodeJSON(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeResult(w, QueryResponse{Result: result}, nil)
+}
+
+func (h *handler) describe(w http.ResponseWriter, r *http.Request, workflowID, runID string) {
+	d, err := h.options.Client.DescribeWorkflow(r.Context(), workflowID, runID)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	description := Description{
+		WorkflowID:    d.WorkflowID,
+		RunID:         d.RunID,
+		WorkflowType:  d.WorkflowType,
+		Status:        d.Status.String(),
+		StartTime:     d.StartTime,
+		HistoryLength: d.HistoryLength,
+	}
+	if !d.CloseTime.IsZero() {
+		description.CloseTime = &d.CloseTime
+	}
+	for _, a := range d.PendingActivities {
+		activity := PendingActivity{
+			ActivityID:   a.ActivityID,
+			ActivityType: a.ActivityType,
+			State:        a.State.String(),
+			Attempt:      a.Attempt,
+		}
+		if a.LastFailure != nil {
+			activity.LastFailure = a.LastFailure.Error()
+		}
+		description.PendingActivities = append(description.PendingActivities, activity)
+	}
+	for _, c := range d.PendingChildren {
+		description.PendingChildren = append(description.PendingChildren, PendingChildWorkflow{
+			WorkflowID:   c.WorkflowID,
+			RunID:        c.RunID,
+			WorkflowType: c.WorkflowType,
+		})
+	}
+	writeResult(w, description, nil)
+}
+
+// readBody decodes the JSON body of the request into valuePtr, leaving it unchanged if the body is empty.
+func (h *handler) readBody(w http.ResponseWriter, r *http.Request, valuePtr interface{}) error {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.options.MaxBodySize))
+	if err != nil {
+		return fmt.Errorf("unable to read body: %w", err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, valuePtr); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+// decodeJSON decodes the value with the DataConverter of the client into raw JSON. Values which are not JSON
+// payloads are returned as their string representation.
+func decodeJSON(value converter.EncodedValue) (json.RawMessage, error) {
+	if value == nil || !value.HasValue() {
+		return json.RawMessage("null"), nil
+	}
+	var result json.RawMessage
+	if err := value.Get(&result); err == nil {
+		return result, nil
+	}
+	var decoded interface{}
+	if err := value.Get(&decoded); err != nil {
+		return nil, fmt.Errorf("unable to decode result: %w", err)
+	}
+	return json.Marshal(decoded)
+}
+
+func pathSegments(u *url.URL) ([]string, error) {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(u.EscapedPath(), "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, unescaped)
+	}
+	return segments, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func writeResult(w http.ResponseWriter, result interface{}, err error) {
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func statusCode(err error) int {
+	var alreadyStartedErr *temporal.WorkflowExecutionAlreadyStartedError
+	if errors.As(err, &alreadyStartedErr) {
+		return http.StatusConflict
+	}
+	switch err.(type) {
+	case *serviceerror.NotFound:
+		return http.StatusNotFound
+	case *serviceerror.InvalidArgument, *serviceerror.QueryFailed:
+		return http.StatusBadRequest
+	case *serviceerror.WorkflowExecutionAlreadyStarted:
+		return http.StatusConflict
+	case *serviceerror.PermissionDenied:
+		return http.StatusForbidden
+	case *serviceerror.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case *serviceerror.Unavailable:
+		return http.StatusServiceUnavailable
+	case *serviceerror.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
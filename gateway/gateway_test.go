@@ -0,0 +1,176 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+type testRun struct {
+	client.WorkflowRun
+	id, runID string
+}
+
+func (r *testRun) GetID() string    { return r.id }
+func (r *testRun) GetRunID() string { return r.runID }
+
+type testValue struct {
+	payloads *commonpb.Payloads
+}
+
+func (v *testValue) HasValue() bool { return v.payloads != nil }
+
+func (v *testValue) Get(valuePtr interface{}) error {
+	return converter.GetDefaultDataConverter().FromPayloads(v.payloads, valuePtr)
+}
+
+type testClient struct {
+	client.Client
+	options    client.StartWorkflowOptions
+	args       []interface{}
+	signalArg  interface{}
+	canceled   string
+	queryValue *commonpb.Payloads
+}
+
+func (c *testClient) ExecuteWorkflow(_ context.Context, options client.StartWorkflowOptions, _ interface{}, args ...interface{}) (client.WorkflowRun, error) {
+	if options.ID == "started" {
+		return nil, serviceerror.NewWorkflowExecutionAlreadyStarted("already started", "", "")
+	}
+	c.options, c.args = options, args
+	return &testRun{id: options.ID, runID: "run"}, nil
+}
+
+func (c *testClient) SignalWorkflow(_ context.Context, workflowID string, _ string, _ string, arg interface{}) error {
+	if workflowID == "missing" {
+		return serviceerror.NewNotFound("workflow not found")
+	}
+	c.signalArg = arg
+	return nil
+}
+
+func (c *testClient) QueryWorkflow(_ context.Context, _ string, _ string, _ string, _ ...interface{}) (converter.EncodedValue, error) {
+	return &testValue{payloads: c.queryValue}, nil
+}
+
+func (c *testClient) CancelWorkflow(_ context.Context, workflowID string, _ string) error {
+	c.canceled = workflowID
+	return nil
+}
+
+func (c *testClient) DescribeWorkflow(_ context.Context, workflowID string, runID string) (*client.WorkflowExecutionDescription, error) {
+	return &client.WorkflowExecutionDescription{
+		WorkflowID:   workflowID,
+		RunID:        "run",
+		WorkflowType: "OrderWorkflow",
+		Status:       enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING,
+		StartTime:    time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC),
+		PendingActivities: []client.PendingActivityDescription{
+			{ActivityID: "1", ActivityType: "Ship", Attempt: 2, LastFailure: errors.New("timeout")},
+		},
+	}, nil
+}
+
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	c := &testClient{}
+	h := NewHandler(Options{Client: c})
+
+	w := serve(h, http.MethodPost, "/workflows",
+		`{"id":"order-1","workflowType":"OrderWorkflow","taskQueue":"orders","args":[{"id":1},"x"],"workflowRunTimeout":"1h"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"workflowId":"order-1","runId":"run"}`, w.Body.String())
+	require.Equal(t, time.Hour, c.options.WorkflowRunTimeout)
+	require.Equal(t, []interface{}{json.RawMessage(`{"id":1}`), json.RawMessage(`"x"`)}, c.args)
+
+	w = serve(h, http.MethodPost, "/workflows", `{"id":"started","workflowType":"OrderWorkflow","taskQueue":"orders"}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+	w = serve(h, http.MethodPost, "/workflows", `{"id":"order-2"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(h, http.MethodPost, "/workflows/order%2F1/signal/approve", `{"by":"alice"}`)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, json.RawMessage(`{"by":"alice"}`), c.signalArg)
+	w = serve(h, http.MethodPost, "/workflows/missing/signal/approve", `{}`)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.JSONEq(t, `{"error":"workflow not found"}`, w.Body.String())
+
+	c.queryValue, _ = converter.GetDefaultDataConverter().ToPayloads(map[string]int{"progress": 42})
+	w = serve(h, http.MethodPost, "/workflows/order-1/query/status", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"result":{"progress":42}}`, w.Body.String())
+
+	w = serve(h, http.MethodGet, "/workflows/order-1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"workflowId":"order-1","runId":"run","workflowType":"OrderWorkflow","status":"Running",
+		"startTime":"2021-08-01T00:00:00Z","historyLength":0,
+		"pendingActivities":[{"activityId":"1","activityType":"Ship","state":"Unspecified","attempt":2,"lastFailure":"timeout"}]}`,
+		w.Body.String())
+
+	w = serve(h, http.MethodPost, "/workflows/order-1/cancel", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "order-1", c.canceled)
+
+	w = serve(h, http.MethodDelete, "/workflows/order-1", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandler_Authorizer(t *testing.T) {
+	c := &testClient{}
+	h := NewHandler(Options{
+		Client: c,
+		Authorizer: func(r *http.Request, operation Operation, workflowID string) error {
+			if operation == OperationCancel {
+				return errors.New("cancel not allowed")
+			}
+			return nil
+		},
+	})
+	w := serve(h, http.MethodPost, "/workflows/order-1/cancel", "")
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Empty(t, c.canceled)
+	w = serve(h, http.MethodPost, "/workflows/order-1/signal/approve", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+}
@@ -57,3 +57,42 @@ type (
 	// to the next link in an interceptor chain. To be used as base implementation of interceptors.
 	WorkflowOutboundCallsInterceptorBase = internal.WorkflowOutboundCallsInterceptorBase
 )
+
+type (
+	// ClientInterceptor is used to create a single link in the client interceptor chain. Set the interceptors with
+	// client.Options.Interceptors.
+	ClientInterceptor = internal.ClientInterceptor
+
+	// ClientOutboundInterceptor is an interface that can be implemented to intercept the calls made by the client to
+	// start, signal, cancel, terminate and query workflows.
+	// Use ClientOutboundInterceptorBase as a base struct for implementations that do not want to implement every method.
+	// Interceptor implementation must forward calls to the next in the interceptor chain.
+	ClientOutboundInterceptor = internal.ClientOutboundInterceptor
+
+	// ClientOutboundInterceptorBase is a noop implementation of ClientOutboundInterceptor that just forwards requests
+	// to the next link in an interceptor chain. To be used as base implementation of interceptors.
+	ClientOutboundInterceptorBase = internal.ClientOutboundInterceptorBase
+)
+
+type (
+	// ValidationInterceptorOptions are the options of the interceptor created with NewValidationInterceptor.
+	ValidationInterceptorOptions = internal.ValidationInterceptorOptions
+
+	// Validatable is implemented by values validated by their Validate method by the validation interceptor.
+	Validatable = internal.Validatable
+
+	// ValidationError is returned when a value is rejected by the validation interceptor.
+	ValidationError = internal.ValidationError
+)
+
+// NewValidationInterceptor creates a workflow interceptor validating the inputs and results of workflows, and the
+// inputs and results of the activities and child workflows they execute, so that bad payloads fail fast with a
+// *ValidationError. Set it with worker.Options.WorkflowInterceptorChainFactories:
+//  - a workflow with an invalid input fails without running, and a workflow returning an invalid result fails,
+//    with a non-retryable ApplicationError of type "ValidationError",
+//  - an activity or child workflow with an invalid input isn't scheduled, its future fails with the *ValidationError,
+//  - the Get of the future of an activity or child workflow fails with the *ValidationError when the result is
+//    invalid.
+func NewValidationInterceptor(options ValidationInterceptorOptions) WorkflowInterceptor {
+	return internal.NewValidationInterceptor(options)
+}
@@ -59,8 +59,25 @@ type (
 		StartedTime       time.Time     // Time of activity start
 		Deadline          time.Time     // Time of activity timeout
 		Attempt           int32         // Attempt starts from 1, and increased by 1 for every retry if retry policy is specified.
+		// CurrentAttemptScheduledTime is the time the current attempt was scheduled. It is equal to ScheduledTime
+		// for the first attempt and is the time the retry was scheduled for every following attempt.
+		CurrentAttemptScheduledTime time.Time
+		// Paused is true when the activity type is paused with Worker.PauseActivityType on the worker running the
+		// activity. Activities already running when their type is paused are not interrupted, long running activities
+		// can check it to stop early.
+		Paused bool
+		// IsLocalActivity is true for local activities, which have no task token and can't heartbeat.
+		IsLocalActivity bool
+		// WorkerIdentity is the identity of the worker running the activity attempt, see WorkerOptions.Identity and
+		// WorkerOptions.IdentityDetails. It is recorded by the server in the ActivityTaskStarted event of the attempt.
+		WorkerIdentity string
 	}
 
+	// DynamicActivityFunc is an activity function that handles all activity types that don't have a registered
+	// activity. It receives the name of the activity type and the raw activity input and returns the raw result, which
+	// allows the activity to be proxied to another process without decoding it.
+	DynamicActivityFunc func(ctx context.Context, activityType string, input *commonpb.Payloads) (*commonpb.Payloads, error)
+
 	// RegisterActivityOptions consists of options for registering an activity
 	RegisterActivityOptions struct {
 		// When an activity is a function the name is an actual activity type name.
@@ -72,6 +89,16 @@ type (
 		// When registering a struct with activities, skip functions that are not valid activities. If false,
 		// registration panics.
 		SkipInvalidStructFunctions bool
+
+		// Aliases are additional activity type names the activity function is registered under, e.g. its former
+		// names after a rename, so that the activities scheduled with them keep running. Not supported for structs.
+		// Optional.
+		Aliases []string
+
+		// DeprecatedAliases are additional activity type names the activity function is registered under like
+		// Aliases, which log a warning and increment the temporal_deprecated_activity_type counter when an activity
+		// is executed with them, to find the workflows still using them. Not supported for structs. Optional.
+		DeprecatedAliases []string
 	}
 
 	// ActivityOptions stores all activity-specific parameters that will be stored inside of a context.
@@ -132,6 +159,29 @@ type (
 		// To disable retries set MaximumAttempts to 1.
 		// The default RetryPolicy provided by the server can be overridden by the dynamic config.
 		RetryPolicy *RetryPolicy
+
+		// AffinityKey routes the activities with the same key to the same worker of the task queue, among the workers
+		// with WorkerOptions.EnableActivityAffinity set. The activity is scheduled on the task queue itself when no
+		// such worker polls it. Set ScheduleToStartTimeout to reroute the activity to another worker when its worker
+		// is lost. The key is ignored by activities executed in a session.
+		// Optional: default empty string
+		AffinityKey string
+
+		// Priority of the activity among the activities waiting for an execution slot on workers with
+		// WorkerOptions.ActivityTaskPrefetchSize set, the highest first, e.g. to let latency sensitive activities jump
+		// ahead of bulk backfill activities sharing the same task queue. Activities with the same priority are executed
+		// in the order they are polled. The priority doesn't change the order the server dispatches tasks in.
+		// Optional: default 0
+		Priority int
+
+		// RetryTaskQueue is the task queue of the retries of the activity, e.g. served by an isolated pool of workers so
+		// that failing activities retried over and over don't take the capacity of the healthy ones. The server retries
+		// attempts on the task queue of the first attempt, so the retries are scheduled by the workflow instead: each
+		// attempt is an activity without retries, with its own activity ID and ActivityInfo.Attempt of 1, and the
+		// workflow sleeps for the backoff of RetryPolicy between attempts. The option is ignored by activities with an
+		// AffinityKey and activities executed in a session.
+		// Optional: default empty string, the attempts are retried by the server on TaskQueue
+		RetryTaskQueue string
 	}
 
 	// LocalActivityOptions stores local activity specific parameters that will be stored inside of a context.
@@ -166,13 +216,20 @@ func GetActivityInfo(ctx context.Context) ActivityInfo {
 		Attempt:           env.attempt,
 		WorkflowType:      env.workflowType,
 		WorkflowNamespace: env.workflowNamespace,
+
+		CurrentAttemptScheduledTime: env.currentAttemptScheduledTime,
+		Paused:                      env.pauser.isPaused(env.activityType.Name),
+		IsLocalActivity:             env.isLocalActivity,
+		WorkerIdentity:              env.workerIdentity,
 	}
 }
 
 // HasHeartbeatDetails checks if there is heartbeat details from last attempt.
+// The server delivers the details recorded by the last attempt with every retry, so an activity can check for them
+// to decide whether to start from scratch or resume from the last checkpoint.
 func HasHeartbeatDetails(ctx context.Context) bool {
 	env := getActivityEnv(ctx)
-	return env.heartbeatDetails != nil
+	return len(env.heartbeatDetails.GetPayloads()) > 0
 }
 
 // GetHeartbeatDetails extract heartbeat details from last failed attempt. This is used in combination with retry policy.
@@ -182,7 +239,7 @@ func HasHeartbeatDetails(ctx context.Context) bool {
 // retry attempt. Activity could extract the details by GetHeartbeatDetails() and resume from the progress.
 func GetHeartbeatDetails(ctx context.Context, d ...interface{}) error {
 	env := getActivityEnv(ctx)
-	if env.heartbeatDetails == nil {
+	if len(env.heartbeatDetails.GetPayloads()) == 0 {
 		return ErrNoData
 	}
 	encoded := newEncodedValues(env.heartbeatDetails, env.dataConverter)
@@ -266,6 +323,7 @@ func WithActivityTask(
 ) context.Context {
 	var deadline time.Time
 	scheduled := common.TimeValue(task.GetScheduledTime())
+	currentAttemptScheduled := common.TimeValue(task.GetCurrentAttemptScheduledTime())
 	started := common.TimeValue(task.GetStartedTime())
 	scheduleToCloseTimeout := common.DurationValue(task.GetScheduleToCloseTimeout())
 	startToCloseTimeout := common.DurationValue(task.GetStartToCloseTimeout())
@@ -318,6 +376,8 @@ func WithActivityTask(
 		workerStopChannel:  workerStopChannel,
 		contextPropagators: contextPropagators,
 		tracer:             tracer,
+
+		currentAttemptScheduledTime: currentAttemptScheduled,
 	})
 }
 
@@ -349,6 +409,9 @@ func WithLocalActivityTask(ctx context.Context, task *localActivityTask, logger
 		isLocalActivity:   true,
 		dataConverter:     dataConverter,
 		attempt:           task.attempt,
+		scheduledTime:     task.params.ScheduledTime,
+		startedTime:       time.Now(),
+		workerIdentity:    task.params.WorkflowInfo.WorkerIdentity,
 	})
 	return ctx
 }
@@ -0,0 +1,213 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+)
+
+const (
+	affinityResolverContextKey contextKey = "affinityResolver"
+
+	// affinityMembersRefreshInterval is how long the workers serving the affinity task queues of a task queue are
+	// cached before they are described again.
+	affinityMembersRefreshInterval = 10 * time.Second
+
+	// affinityResolutionTimeout is the timeout of the local activity resolving the task queue of an activity with an
+	// affinity key.
+	affinityResolutionTimeout = 10 * time.Second
+)
+
+type (
+	// affinityResolver maps the affinity keys of activities to the affinity task queues of the workers polling a task
+	// queue. Keys are spread over the workers with rendezvous hashing, so only the keys of a worker which is added or
+	// removed move to another worker.
+	affinityResolver struct {
+		service   workflowservice.WorkflowServiceClient
+		namespace string
+
+		lock    sync.Mutex
+		members map[string]*affinityMembers
+	}
+
+	affinityMembers struct {
+		taskQueues  []string
+		refreshTime time.Time
+	}
+)
+
+func newAffinityResolver(service workflowservice.WorkflowServiceClient, namespace string) *affinityResolver {
+	return &affinityResolver{
+		service:   service,
+		namespace: namespace,
+		members:   make(map[string]*affinityMembers),
+	}
+}
+
+func getAffinityTaskQueue(taskQueue string, identity string) string {
+	return taskQueue + "__internal_affinity_" + identity
+}
+
+// resolveAffinityTaskQueue is the local activity returning the affinity task queue the activities with the key are
+// routed to. It returns the task queue itself when no worker polling the task queue serves an affinity task queue
+// other than the unavailable ones.
+func resolveAffinityTaskQueue(ctx context.Context, taskQueue string, key string, unavailable []string) (string, error) {
+	resolver, ok := ctx.Value(affinityResolverContextKey).(*affinityResolver)
+	if !ok {
+		return taskQueue, nil
+	}
+	return resolver.resolve(ctx, taskQueue, key, unavailable)
+}
+
+func (r *affinityResolver) resolve(ctx context.Context, taskQueue string, key string, unavailable []string) (string, error) {
+	members, err := r.getMembers(ctx, taskQueue)
+	if err != nil {
+		return "", err
+	}
+	selected := taskQueue
+	var selectedWeight uint64
+MembersLoop:
+	for _, member := range members {
+		for _, u := range unavailable {
+			if member == u {
+				continue MembersLoop
+			}
+		}
+		if weight := affinityWeight(key, member); selected == taskQueue || weight > selectedWeight {
+			selected = member
+			selectedWeight = weight
+		}
+	}
+	return selected, nil
+}
+
+// getMembers returns the affinity task queues with pollers of the workers polling the task queue. The server keeps
+// reporting a poller for a while after it stopped polling, activities routed to a lost worker are rerouted when they
+// time out waiting to be started. The lock is not held while the pollers are described, so a slow refresh of a task
+// queue doesn't block the resolutions served from the cache.
+func (r *affinityResolver) getMembers(ctx context.Context, taskQueue string) ([]string, error) {
+	r.lock.Lock()
+	members, ok := r.members[taskQueue]
+	r.lock.Unlock()
+	if ok && time.Since(members.refreshTime) < affinityMembersRefreshInterval {
+		return members.taskQueues, nil
+	}
+
+	pollers, err := r.describePollers(ctx, taskQueue)
+	if err != nil {
+		return nil, err
+	}
+	var taskQueues []string
+	seen := make(map[string]bool)
+	for _, poller := range pollers {
+		affinityTaskQueue := getAffinityTaskQueue(taskQueue, poller.GetIdentity())
+		if seen[affinityTaskQueue] {
+			continue
+		}
+		seen[affinityTaskQueue] = true
+		affinityPollers, err := r.describePollers(ctx, affinityTaskQueue)
+		if err != nil {
+			return nil, err
+		}
+		if len(affinityPollers) > 0 {
+			taskQueues = append(taskQueues, affinityTaskQueue)
+		}
+	}
+	r.lock.Lock()
+	r.members[taskQueue] = &affinityMembers{taskQueues: taskQueues, refreshTime: time.Now()}
+	r.lock.Unlock()
+	return taskQueues, nil
+}
+
+func (r *affinityResolver) describePollers(ctx context.Context, taskQueue string) ([]*taskqueuepb.PollerInfo, error) {
+	grpcCtx, cancel := newGRPCContext(ctx, defaultGrpcRetryParameters(ctx))
+	defer cancel()
+	response, err := r.service.DescribeTaskQueue(grpcCtx, &workflowservice.DescribeTaskQueueRequest{
+		Namespace:     r.namespace,
+		TaskQueue:     &taskqueuepb.TaskQueue{Name: taskQueue, Kind: enumspb.TASK_QUEUE_KIND_NORMAL},
+		TaskQueueType: enumspb.TASK_QUEUE_TYPE_ACTIVITY,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.GetPollers(), nil
+}
+
+func affinityWeight(key string, taskQueue string) uint64 {
+	sum := md5.Sum([]byte(key + "\x00" + taskQueue))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// executeActivityWithAffinity resolves the affinity task queue of the activity and schedules the activity on it. When
+// the activity isn't started before its ScheduleToStartTimeout the worker is considered lost and the activity is
+// scheduled again on the affinity task queue of another worker.
+func (wc *workflowEnvironmentInterceptor) executeActivityWithAffinity(ctx Context, params ExecuteActivityParams, settable Settable) {
+	Go(ctx, func(ctx Context) {
+		var unavailable []string
+		for {
+			var taskQueue string
+			resolveCtx := WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: affinityResolutionTimeout})
+			err := ExecuteLocalActivity(resolveCtx, resolveAffinityTaskQueue, params.TaskQueueName, params.AffinityKey,
+				unavailable).Get(ctx, &taskQueue)
+			if err != nil {
+				settable.Set(nil, err)
+				return
+			}
+
+			attemptParams := params
+			attemptParams.TaskQueueName = taskQueue
+			var result *commonpb.Payloads
+			done := false
+			wc.scheduleActivity(ctx, attemptParams, func(r *commonpb.Payloads, e error) {
+				result, err, done = r, e, true
+			})
+			// The activity is canceled together with ctx, wait for it to be resolved in any case.
+			disconnectedCtx, _ := NewDisconnectedContext(ctx)
+			_ = Await(disconnectedCtx, func() bool { return done })
+
+			if taskQueue != params.TaskQueueName && ctx.Err() == nil && isScheduleToStartTimeout(err) {
+				unavailable = append(unavailable, taskQueue)
+				continue
+			}
+			settable.Set(result, err)
+			return
+		}
+	})
+}
+
+func isScheduleToStartTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr) && timeoutErr.TimeoutType() == enumspb.TIMEOUT_TYPE_SCHEDULE_TO_START
+}
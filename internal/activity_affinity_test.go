@@ -0,0 +1,95 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/api/workflowservicemock/v1"
+	"google.golang.org/grpc"
+)
+
+func TestAffinityResolver(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	service := workflowservicemock.NewMockWorkflowServiceClient(mockCtrl)
+	pollers := map[string][]string{
+		"tq":                                  {"worker1", "worker2", "worker3", "worker4"},
+		getAffinityTaskQueue("tq", "worker1"): {"worker1"},
+		getAffinityTaskQueue("tq", "worker2"): {"worker2"},
+		getAffinityTaskQueue("tq", "worker3"): {"worker3"},
+	}
+	service.EXPECT().DescribeTaskQueue(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, request *workflowservice.DescribeTaskQueueRequest, _ ...grpc.CallOption) (*workflowservice.DescribeTaskQueueResponse, error) {
+			require.Equal(t, "ns", request.GetNamespace())
+			response := &workflowservice.DescribeTaskQueueResponse{}
+			for _, identity := range pollers[request.GetTaskQueue().GetName()] {
+				response.Pollers = append(response.Pollers, &taskqueuepb.PollerInfo{Identity: identity})
+			}
+			return response, nil
+		}).Times(5)
+	resolver := newAffinityResolver(service, "ns")
+	members := []string{
+		getAffinityTaskQueue("tq", "worker1"),
+		getAffinityTaskQueue("tq", "worker2"),
+		getAffinityTaskQueue("tq", "worker3"),
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("customer-%v", i)
+		taskQueue, err := resolver.resolve(context.Background(), "tq", key, nil)
+		require.NoError(t, err)
+		require.Contains(t, members, taskQueue)
+		counts[taskQueue]++
+
+		// The key keeps its task queue and moves only when the task queue is unavailable.
+		again, err := resolver.resolve(context.Background(), "tq", key, nil)
+		require.NoError(t, err)
+		require.Equal(t, taskQueue, again)
+		rerouted, err := resolver.resolve(context.Background(), "tq", key, []string{taskQueue})
+		require.NoError(t, err)
+		require.Contains(t, members, rerouted)
+		require.NotEqual(t, taskQueue, rerouted)
+	}
+	for _, member := range members {
+		require.Greater(t, counts[member], 50, member)
+	}
+
+	taskQueue, err := resolver.resolve(context.Background(), "tq", "customer", members)
+	require.NoError(t, err)
+	require.Equal(t, "tq", taskQueue)
+}
+
+func TestResolveAffinityTaskQueue_NoResolver(t *testing.T) {
+	taskQueue, err := resolveAffinityTaskQueue(context.Background(), "tq", "customer", nil)
+	require.NoError(t, err)
+	require.Equal(t, "tq", taskQueue)
+}
@@ -0,0 +1,88 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"sync"
+)
+
+// activityPauser tracks the paused activity types of a worker.
+type activityPauser struct {
+	sync.Mutex
+	// paused maps each paused activity type to the channel closed when it is resumed.
+	paused map[string]chan struct{}
+}
+
+func newActivityPauser() *activityPauser {
+	return &activityPauser{paused: make(map[string]chan struct{})}
+}
+
+func (p *activityPauser) pause(activityType string) {
+	p.Lock()
+	defer p.Unlock()
+	if _, ok := p.paused[activityType]; !ok {
+		p.paused[activityType] = make(chan struct{})
+	}
+}
+
+func (p *activityPauser) resume(activityType string) {
+	p.Lock()
+	defer p.Unlock()
+	if resumed, ok := p.paused[activityType]; ok {
+		close(resumed)
+		delete(p.paused, activityType)
+	}
+}
+
+func (p *activityPauser) isPaused(activityType string) bool {
+	if p == nil {
+		return false
+	}
+	p.Lock()
+	defer p.Unlock()
+	_, ok := p.paused[activityType]
+	return ok
+}
+
+// waitResumed blocks until the activity type isn't paused or ctx is done, and returns the error of ctx in the latter
+// case.
+func (p *activityPauser) waitResumed(ctx context.Context, activityType string) error {
+	if p == nil {
+		return nil
+	}
+	p.Lock()
+	resumed, ok := p.paused[activityType]
+	p.Unlock()
+	if !ok {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,152 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"container/heap"
+
+	commonpb "go.temporal.io/api/common/v1"
+
+	"go.temporal.io/sdk/converter"
+)
+
+// activityPriorityHeaderKey is the header field of an activity task holding ActivityOptions.Priority.
+const activityPriorityHeaderKey = "temporal-activity-priority"
+
+type (
+	// priorityTaskQueue holds the polled tasks waiting for an execution slot, ordered by descending priority and then
+	// by the order they were polled in.
+	priorityTaskQueue struct {
+		items    []priorityTaskItem
+		sequence uint64
+	}
+
+	priorityTaskItem struct {
+		task     *polledTask
+		priority int
+		sequence uint64
+	}
+)
+
+func (q *priorityTaskQueue) Len() int { return len(q.items) }
+
+func (q *priorityTaskQueue) Less(i, j int) bool {
+	if q.items[i].priority != q.items[j].priority {
+		return q.items[i].priority > q.items[j].priority
+	}
+	return q.items[i].sequence < q.items[j].sequence
+}
+
+func (q *priorityTaskQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *priorityTaskQueue) Push(x interface{}) {
+	item := x.(priorityTaskItem)
+	q.sequence++
+	item.sequence = q.sequence
+	q.items = append(q.items, item)
+}
+
+func (q *priorityTaskQueue) Pop() interface{} {
+	item := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return item
+}
+
+// dispatchTasksByPriority is the task dispatcher of a worker with a task priority. Up to prefetchCount polled tasks
+// wait for an execution slot, and the one with the highest priority is executed when a slot is released.
+func (bw *baseWorker) dispatchTasksByPriority() {
+	queue := &priorityTaskQueue{}
+	defer func() {
+		// The queued tasks are not executed, they time out and are retried by the server.
+		for queue.Len() > 0 {
+			heap.Pop(queue)
+			bw.releaseSharedTaskSlot()
+		}
+	}()
+
+	running := 0
+	for {
+		for running < bw.options.maxConcurrentTask && queue.Len() > 0 {
+			if bw.taskLimiter.Wait(bw.limiterContext) != nil && bw.isStop() {
+				return
+			}
+			item := heap.Pop(queue).(priorityTaskItem)
+			running++
+			bw.updateTaskSlotsMetrics(bw.taskSlotsUsed.Inc())
+			bw.stopWG.Add(1)
+			go bw.processTask(item.task)
+		}
+
+		select {
+		case <-bw.stopCh:
+			return
+		case <-bw.taskDoneCh:
+			running--
+		case task := <-bw.taskQueueCh:
+			polled, isPolledTask := task.(*polledTask)
+			if !isPolledTask {
+				bw.stopWG.Add(1)
+				go bw.processTask(task)
+				continue
+			}
+			priority, ok := bw.options.taskPriority(polled.task)
+			if !ok {
+				// The poll returned no task, poll again.
+				bw.releaseSharedTaskSlot()
+				bw.pollerRequestCh <- struct{}{}
+				continue
+			}
+			heap.Push(queue, priorityTaskItem{task: polled, priority: priority})
+		}
+	}
+}
+
+// activityTaskPriority returns the ActivityOptions.Priority of a polled activity task, and false if the poll returned
+// no task.
+func activityTaskPriority(task interface{}) (int, bool) {
+	activityTask, ok := task.(*activityTask)
+	if !ok || activityTask.task == nil {
+		return 0, false
+	}
+	payload, ok := activityTask.task.GetHeader().GetFields()[activityPriorityHeaderKey]
+	if !ok {
+		return 0, true
+	}
+	var priority int
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, &priority); err != nil {
+		return 0, true
+	}
+	return priority, true
+}
+
+// setActivityPriority records the priority of an activity in the header of its task.
+func setActivityPriority(header *commonpb.Header, priority int) error {
+	payload, err := converter.GetDefaultDataConverter().ToPayload(priority)
+	if err != nil {
+		return err
+	}
+	header.Fields[activityPriorityHeaderKey] = payload
+	return nil
+}
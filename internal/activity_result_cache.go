@@ -0,0 +1,155 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+
+	"go.temporal.io/sdk/internal/common/cache"
+)
+
+const defaultActivityResultCacheMaxSize = 10000
+
+type (
+	// ActivityResultCacheOptions configure an ActivityResultCache.
+	ActivityResultCacheOptions struct {
+		// Required: Names of the activity types whose results are cached. Only list activities which are idempotent
+		// and have no side effects, a cached result is returned instead of executing the activity.
+		ActivityTypes []string
+
+		// Required: How long a result is returned for the same activity type and arguments before the activity is
+		// executed again.
+		TTL time.Duration
+
+		// Optional: Maximum number of cached results. The least recently used results are evicted first.
+		// default: 10000
+		MaxSize int
+	}
+
+	// ActivityResultCache caches the results of activities by activity type and arguments. A worker with
+	// WorkerOptions.ActivityResultCache set completes the activity tasks of the cached activity types with a cached
+	// result when one exists, and caches the results of the activities it executes successfully. Failures are never
+	// cached. Workers sharing a cache share their results.
+	ActivityResultCache struct {
+		results       cache.Cache
+		activityTypes map[string]bool
+
+		lock        sync.Mutex
+		generations map[string]int
+	}
+)
+
+// NewActivityResultCache creates an ActivityResultCache.
+func NewActivityResultCache(options ActivityResultCacheOptions) *ActivityResultCache {
+	if options.TTL <= 0 {
+		panic("ActivityResultCacheOptions.TTL is required")
+	}
+	maxSize := options.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultActivityResultCacheMaxSize
+	}
+	activityTypes := make(map[string]bool, len(options.ActivityTypes))
+	for _, activityType := range options.ActivityTypes {
+		activityTypes[activityType] = true
+	}
+	return &ActivityResultCache{
+		results:       cache.New(maxSize, &cache.Options{TTL: options.TTL}),
+		activityTypes: activityTypes,
+		generations:   make(map[string]int),
+	}
+}
+
+// Invalidate drops the cached results of the activity type.
+func (c *ActivityResultCache) Invalidate(activityType string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// The results of the previous generation can't be looked up anymore and are evicted over time.
+	c.generations[activityType]++
+}
+
+// InvalidateAll drops all the cached results.
+func (c *ActivityResultCache) InvalidateAll() {
+	c.results.Clear()
+}
+
+// key returns the key of the result of the activity type with the input, false if the activity type isn't cached.
+func (c *ActivityResultCache) key(activityType string, input *commonpb.Payloads) (string, bool) {
+	if c == nil || !c.activityTypes[activityType] {
+		return "", false
+	}
+	c.lock.Lock()
+	generation := c.generations[activityType]
+	c.lock.Unlock()
+
+	h := sha256.New()
+	for _, payload := range input.GetPayloads() {
+		metadataKeys := make([]string, 0, len(payload.GetMetadata()))
+		for k := range payload.GetMetadata() {
+			metadataKeys = append(metadataKeys, k)
+		}
+		sort.Strings(metadataKeys)
+		writeHashField(h, []byte(strconv.Itoa(len(metadataKeys))))
+		for _, k := range metadataKeys {
+			writeHashField(h, []byte(k))
+			writeHashField(h, payload.GetMetadata()[k])
+		}
+		writeHashField(h, payload.GetData())
+	}
+	return activityType + "/" + strconv.Itoa(generation) + "/" + hex.EncodeToString(h.Sum(nil)), true
+}
+
+func (c *ActivityResultCache) get(key string) (*commonpb.Payloads, bool) {
+	result, ok := c.results.Get(key).(*activityResult)
+	if !ok {
+		return nil, false
+	}
+	return result.payloads, true
+}
+
+func (c *ActivityResultCache) put(key string, result *commonpb.Payloads) {
+	c.results.Put(key, &activityResult{payloads: result})
+}
+
+// activityResult wraps the cached result as nil results are cached too.
+type activityResult struct {
+	payloads *commonpb.Payloads
+}
+
+// writeHashField writes the length prefixed field to the hash so that different fields can't collide.
+func writeHashField(h hash.Hash, field []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+	_, _ = h.Write(length[:])
+	_, _ = h.Write(field)
+}
@@ -0,0 +1,224 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/workflowservice/v1"
+
+	"go.temporal.io/sdk/converter"
+)
+
+// The defaults of the server for the fields of the retry policy of an activity which are not set.
+const (
+	defaultActivityRetryInitialInterval     = time.Second
+	defaultActivityRetryBackoffCoefficient  = 2.0
+	defaultActivityRetryMaximumIntervalCoef = 100
+)
+
+// activityRetryHeaderKey is the header of the attempts of an activity retried by the workflow which carries the
+// activityRetryState of the attempt.
+const activityRetryHeaderKey = "temporal-activity-retry"
+
+// activityRetryState is the state the server keeps between the attempts of an activity it retries, passed by the
+// workflow to each attempt it schedules.
+type activityRetryState struct {
+	Attempt          int32
+	HeartbeatDetails *commonpb.Payloads
+}
+
+// hasWorkflowRetries returns true if the retries of the activity must be scheduled by the workflow because they use
+// options the server doesn't support: ActivityOptions.RetryTaskQueue, RetryPolicy.StartToCloseTimeoutCoefficient or
+// RetryPolicy.MaximumAttemptsDuration.
+func (p *ExecuteActivityParams) hasWorkflowRetries() bool {
+	return p.RetryTaskQueue != "" ||
+		(p.RetryStartToCloseTimeoutCoefficient != 0 && p.RetryStartToCloseTimeoutCoefficient != 1) ||
+		p.RetryMaximumAttemptsDuration > 0
+}
+
+// executeActivityWithWorkflowRetries executes the activity with the retries scheduled by the workflow. The server
+// retries attempts on the task queue of the first one with the same timeouts, so each attempt is scheduled as an
+// activity without retries, and the workflow waits for the backoff of the retry policy before scheduling the next
+// attempt on the retry task queue with the scaled StartToCloseTimeout. The attempt number and the last heartbeat
+// details of the activity are passed to each attempt in the header, the details are those of the last attempt which
+// timed out since failed attempts don't report them.
+func (wc *workflowEnvironmentInterceptor) executeActivityWithWorkflowRetries(ctx Context, params ExecuteActivityParams, settable Settable) {
+	policy := getActivityRetryPolicy(params.RetryPolicy)
+	now := Now(ctx)
+	var expireTime, retryExpireTime time.Time
+	if params.ScheduleToCloseTimeout > 0 {
+		expireTime = now.Add(params.ScheduleToCloseTimeout)
+		retryExpireTime = expireTime
+	}
+	if params.RetryMaximumAttemptsDuration > 0 {
+		attemptsExpireTime := now.Add(params.RetryMaximumAttemptsDuration)
+		if retryExpireTime.IsZero() || attemptsExpireTime.Before(retryExpireTime) {
+			retryExpireTime = attemptsExpireTime
+		}
+	}
+	startToCloseTimeout := params.StartToCloseTimeout
+	var heartbeatDetails *commonpb.Payloads
+
+	Go(ctx, func(ctx Context) {
+		for attempt := int32(1); ; attempt++ {
+			attemptParams := params
+			attemptParams.RetryPolicy = convertToPBRetryPolicy(&RetryPolicy{
+				InitialInterval:        policy.InitialInterval,
+				BackoffCoefficient:     policy.BackoffCoefficient,
+				MaximumInterval:        policy.MaximumInterval,
+				MaximumAttempts:        1,
+				NonRetryableErrorTypes: policy.NonRetryableErrorTypes,
+			})
+			if attempt > 1 {
+				if params.RetryTaskQueue != "" {
+					attemptParams.TaskQueueName = params.RetryTaskQueue
+				}
+				if params.RetryStartToCloseTimeoutCoefficient > 0 && startToCloseTimeout > 0 {
+					startToCloseTimeout = time.Duration(float64(startToCloseTimeout) * params.RetryStartToCloseTimeoutCoefficient)
+				}
+				attemptParams.StartToCloseTimeout = startToCloseTimeout
+				if params.ActivityID != "" {
+					attemptParams.ActivityID = fmt.Sprintf("%s-retry-%d", params.ActivityID, attempt)
+				}
+				if !expireTime.IsZero() {
+					attemptParams.ScheduleToCloseTimeout = expireTime.Sub(Now(ctx))
+				}
+				header, err := withActivityRetryState(params.Header, activityRetryState{
+					Attempt:          attempt,
+					HeartbeatDetails: heartbeatDetails,
+				})
+				if err != nil {
+					settable.Set(nil, err)
+					return
+				}
+				attemptParams.Header = header
+			}
+
+			var result *commonpb.Payloads
+			var err error
+			done := false
+			wc.scheduleActivity(ctx, attemptParams, func(r *commonpb.Payloads, e error) {
+				result, err, done = r, e, true
+			})
+			// The activity is canceled together with ctx, wait for it to be resolved in any case.
+			disconnectedCtx, _ := NewDisconnectedContext(ctx)
+			_ = Await(disconnectedCtx, func() bool { return done })
+
+			if err == nil || ctx.Err() != nil {
+				settable.Set(result, err)
+				return
+			}
+			var timeoutErr *TimeoutError
+			if errors.As(err, &timeoutErr) && timeoutErr.HasLastHeartbeatDetails() {
+				heartbeatDetails = convertErrDetailsToPayloads(timeoutErr.lastHeartbeatDetails, params.DataConverter)
+			}
+			backoff := getRetryBackoffWithNowTime(policy, attempt, err, Now(ctx), retryExpireTime)
+			if backoff == noRetryBackoff {
+				settable.Set(nil, err)
+				return
+			}
+			if sleepErr := Sleep(ctx, backoff); sleepErr != nil {
+				settable.Set(nil, sleepErr)
+				return
+			}
+		}
+	})
+}
+
+// withActivityRetryState returns a copy of the header with the retry state of an attempt.
+func withActivityRetryState(header *commonpb.Header, state activityRetryState) (*commonpb.Header, error) {
+	payload, err := converter.GetDefaultDataConverter().ToPayload(state)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]*commonpb.Payload, len(header.GetFields())+1)
+	for k, v := range header.GetFields() {
+		fields[k] = v
+	}
+	fields[activityRetryHeaderKey] = payload
+	return &commonpb.Header{Fields: fields}, nil
+}
+
+// applyActivityRetryState sets the attempt and the heartbeat details of an activity task scheduled by the workflow
+// retries from the retry state in its header, as the server sees each attempt as a new activity.
+func applyActivityRetryState(task *workflowservice.PollActivityTaskQueueResponse) {
+	payload, ok := task.GetHeader().GetFields()[activityRetryHeaderKey]
+	if !ok {
+		return
+	}
+	var state activityRetryState
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, &state); err != nil {
+		return
+	}
+	task.Attempt = state.Attempt
+	if task.HeartbeatDetails == nil {
+		task.HeartbeatDetails = state.HeartbeatDetails
+	}
+}
+
+// getActivityRetryPolicy returns the retry policy of an activity with the defaults of the server for the fields which
+// are not set.
+func getActivityRetryPolicy(retryPolicy *commonpb.RetryPolicy) *RetryPolicy {
+	policy := convertFromPBRetryPolicy(retryPolicy)
+	if policy == nil {
+		policy = &RetryPolicy{}
+	}
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = defaultActivityRetryInitialInterval
+	}
+	if policy.BackoffCoefficient == 0 {
+		policy.BackoffCoefficient = defaultActivityRetryBackoffCoefficient
+	}
+	if policy.MaximumInterval <= 0 {
+		policy.MaximumInterval = defaultActivityRetryMaximumIntervalCoef * policy.InitialInterval
+	}
+	return policy
+}
+
+// setActivityRetryPolicy sets the retry policy of the activity options, including the fields which are only
+// supported by the retries scheduled by the workflow.
+func setActivityRetryPolicy(eap *ExecuteActivityOptions, retryPolicy *RetryPolicy) {
+	eap.RetryPolicy = convertToPBRetryPolicy(retryPolicy)
+	eap.RetryStartToCloseTimeoutCoefficient = 0
+	eap.RetryMaximumAttemptsDuration = 0
+	if retryPolicy != nil {
+		eap.RetryStartToCloseTimeoutCoefficient = retryPolicy.StartToCloseTimeoutCoefficient
+		eap.RetryMaximumAttemptsDuration = retryPolicy.MaximumAttemptsDuration
+	}
+}
+
+// getActivityOptionsRetryPolicy returns the retry policy set with setActivityRetryPolicy.
+func getActivityOptionsRetryPolicy(eap *ExecuteActivityOptions) *RetryPolicy {
+	retryPolicy := convertFromPBRetryPolicy(eap.RetryPolicy)
+	if retryPolicy != nil {
+		retryPolicy.StartToCloseTimeoutCoefficient = eap.RetryStartToCloseTimeoutCoefficient
+		retryPolicy.MaximumAttemptsDuration = eap.RetryMaximumAttemptsDuration
+	}
+	return retryPolicy
+}
@@ -0,0 +1,220 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.temporal.io/api/workflowservice/v1"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// BatchOperationSignal signals the workflows matching the query of the batch operation.
+	BatchOperationSignal BatchOperationType = iota + 1
+	// BatchOperationCancel requests cancellation of the workflows matching the query of the batch operation.
+	BatchOperationCancel
+	// BatchOperationTerminate terminates the workflows matching the query of the batch operation.
+	BatchOperationTerminate
+)
+
+const (
+	defaultBatchOperationRPS         = 50
+	defaultBatchOperationConcurrency = 1
+	defaultBatchOperationPageSize    = 1000
+)
+
+type (
+	// BatchOperationType is the operation applied to every workflow by RunBatchOperation.
+	BatchOperationType int
+
+	// BatchOperationOptions configure RunBatchOperation.
+	BatchOperationOptions struct {
+		// Query is the visibility query selecting the workflows, e.g. "WorkflowType='OrderWorkflow' and
+		// ExecutionStatus='Running'". Required.
+		Query string
+
+		// Operation applied to every workflow matching the Query. Required.
+		Operation BatchOperationType
+
+		// SignalName and SignalArg are the signal sent by BatchOperationSignal. SignalName is required for it.
+		SignalName string
+		SignalArg  interface{}
+
+		// Reason of the termination for BatchOperationTerminate. Optional.
+		Reason string
+
+		// RPS is the maximum number of operations per second. Optional: default is 50.
+		RPS float64
+
+		// Concurrency is the number of operations in flight. Optional: default is 1.
+		Concurrency int
+
+		// PageSize is the number of workflows fetched with each ListWorkflow call. Optional: default is 1000.
+		PageSize int32
+
+		// Progress is called after every operation with the number of workflows processed so far and the total
+		// number of workflows matching the Query. It must be safe for concurrent use. Optional.
+		Progress func(processed, total int)
+	}
+
+	// BatchOperationResult is the result of RunBatchOperation.
+	BatchOperationResult struct {
+		// Total number of workflows matching the query.
+		Total int
+		// Succeeded is the number of workflows the operation was applied to successfully.
+		Succeeded int
+		// Failures are the workflows the operation failed for.
+		Failures []BatchOperationFailure
+	}
+
+	// BatchOperationFailure describes a workflow RunBatchOperation failed to apply the operation to.
+	BatchOperationFailure struct {
+		Execution WorkflowExecution
+		Err       error
+	}
+)
+
+// RunBatchOperation signals, cancels or terminates all the workflows matching a visibility query. The matching
+// workflows are listed before the operation is applied to any of them, so that workflows which stop matching the
+// query because of the operation, e.g. terminated workflows for an ExecutionStatus='Running' query, don't affect the
+// pagination. The operations are throttled by BatchOperationOptions.RPS and run until all workflows are processed or
+// the ctx is done. Failing operations are reported in the result and don't stop the batch.
+func RunBatchOperation(ctx context.Context, c Client, options BatchOperationOptions) (*BatchOperationResult, error) {
+	if err := validateBatchOperationOptions(options); err != nil {
+		return nil, err
+	}
+	executions, err := listBatchOperationExecutions(ctx, c, options)
+	if err != nil {
+		return nil, err
+	}
+
+	rps := options.RPS
+	if rps <= 0 {
+		rps = defaultBatchOperationRPS
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchOperationConcurrency
+	}
+	limiter := rate.NewLimiter(rate.Limit(rps), 1)
+
+	result := &BatchOperationResult{Total: len(executions)}
+	var lock sync.Mutex
+	processed := 0
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+Executions:
+	for _, execution := range executions {
+		if err := limiter.Wait(ctx); err != nil {
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break Executions
+		}
+		wg.Add(1)
+		go func(execution WorkflowExecution) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := applyBatchOperation(ctx, c, options, execution)
+			lock.Lock()
+			if err == nil {
+				result.Succeeded++
+			} else {
+				result.Failures = append(result.Failures, BatchOperationFailure{Execution: execution, Err: err})
+			}
+			processed++
+			p := processed
+			lock.Unlock()
+			if options.Progress != nil {
+				options.Progress(p, len(executions))
+			}
+		}(execution)
+	}
+	wg.Wait()
+	return result, ctx.Err()
+}
+
+func validateBatchOperationOptions(options BatchOperationOptions) error {
+	if options.Query == "" {
+		return errors.New("batch operation query is not set")
+	}
+	switch options.Operation {
+	case BatchOperationSignal:
+		if options.SignalName == "" {
+			return errors.New("batch operation signal name is not set")
+		}
+	case BatchOperationCancel, BatchOperationTerminate:
+	default:
+		return errors.New("batch operation type is not set")
+	}
+	return nil
+}
+
+func listBatchOperationExecutions(ctx context.Context, c Client, options BatchOperationOptions) ([]WorkflowExecution, error) {
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultBatchOperationPageSize
+	}
+	var executions []WorkflowExecution
+	var nextPageToken []byte
+	for {
+		response, err := c.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			PageSize:      pageSize,
+			NextPageToken: nextPageToken,
+			Query:         options.Query,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range response.GetExecutions() {
+			executions = append(executions, WorkflowExecution{
+				ID:    info.GetExecution().GetWorkflowId(),
+				RunID: info.GetExecution().GetRunId(),
+			})
+		}
+		nextPageToken = response.GetNextPageToken()
+		if len(nextPageToken) == 0 {
+			return executions, nil
+		}
+	}
+}
+
+func applyBatchOperation(ctx context.Context, c Client, options BatchOperationOptions, execution WorkflowExecution) error {
+	switch options.Operation {
+	case BatchOperationSignal:
+		return c.SignalWorkflow(ctx, execution.ID, execution.RunID, options.SignalName, options.SignalArg)
+	case BatchOperationCancel:
+		return c.CancelWorkflow(ctx, execution.ID, execution.RunID)
+	default:
+		return c.TerminateWorkflow(ctx, execution.ID, execution.RunID, options.Reason)
+	}
+}
@@ -0,0 +1,231 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.temporal.io/api/serviceerror"
+)
+
+const (
+	// callbackSignalName is the signal the callback HTTP handler sends to the workflow awaiting the callback.
+	callbackSignalName = "__temporal_callback"
+
+	defaultCallbackMaxBodySize = 1 << 20
+)
+
+var errInvalidCallbackToken = errors.New("invalid callback token")
+
+type (
+	// CallbackToken identifies a callback created with workflow.NewCallback. It is encoded with EncodeCallbackToken,
+	// typically by an activity, into the URL given to the external system.
+	CallbackToken struct {
+		WorkflowID string
+		RunID      string
+		CallbackID string
+	}
+
+	// CallbackRequest is the HTTP request delivered to a callback created with workflow.NewCallback.
+	CallbackRequest struct {
+		CallbackID  string
+		ContentType string
+		Body        []byte
+	}
+
+	// CallbackHandlerOptions configure NewCallbackHandler.
+	CallbackHandlerOptions struct {
+		// Required: The secret the callback tokens are signed with by EncodeCallbackToken.
+		Secret []byte
+
+		// Optional: The maximum size in bytes of the callback body.
+		// default: 1MB
+		MaxBodySize int64
+	}
+
+	// CallbackFuture is the Future of a callback created with workflow.NewCallback.
+	CallbackFuture interface {
+		Future
+		// ID returns the ID to put in the CallbackToken of the callback.
+		ID() string
+	}
+
+	callbackFutureImpl struct {
+		Future
+		id string
+	}
+
+	callbackHandler struct {
+		client  Client
+		options CallbackHandlerOptions
+	}
+
+	// workflowCallbacks are the callbacks awaited by a workflow execution.
+	workflowCallbacks struct {
+		pending   map[string]Settable
+		receiving bool
+	}
+)
+
+// EncodeCallbackToken returns the token to pass to the HTTP handler returned by NewCallbackHandler, signed with the
+// secret of the handler. The token is URL safe.
+func EncodeCallbackToken(token CallbackToken, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", errors.New("secret is empty")
+	}
+	if token.WorkflowID == "" || token.CallbackID == "" {
+		return "", errors.New("workflow ID and callback ID are required")
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(signCallbackToken(data, secret)), nil
+}
+
+func decodeCallbackToken(encoded string, secret []byte) (CallbackToken, error) {
+	var token CallbackToken
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 2 {
+		return token, errInvalidCallbackToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return token, errInvalidCallbackToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signCallbackToken(data, secret)) {
+		return token, errInvalidCallbackToken
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return token, errInvalidCallbackToken
+	}
+	return token, nil
+}
+
+func signCallbackToken(data []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// NewCallbackHandler returns an HTTP handler which completes the callbacks created with workflow.NewCallback.
+// It accepts POST requests with the token encoded by EncodeCallbackToken in the "token" query parameter, and signals
+// the body to the workflow awaiting the callback with the client. It responds 400 to a malformed or badly signed
+// token, 404 if the workflow is not running anymore and 500 if it couldn't be signaled.
+func NewCallbackHandler(c Client, options CallbackHandlerOptions) http.Handler {
+	if len(options.Secret) == 0 {
+		panic("CallbackHandlerOptions.Secret is required")
+	}
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = defaultCallbackMaxBodySize
+	}
+	return &callbackHandler{client: c, options: options}
+}
+
+func (h *callbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token, err := decodeCallbackToken(r.URL.Query().Get("token"), h.options.Secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.options.MaxBodySize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read body: %v", err), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	request := CallbackRequest{CallbackID: token.CallbackID, ContentType: r.Header.Get("Content-Type"), Body: body}
+	err = h.client.SignalWorkflow(r.Context(), token.WorkflowID, token.RunID, callbackSignalName, request)
+	var notFoundErr *serviceerror.NotFound
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.As(err, &notFoundErr):
+		http.Error(w, "workflow not found", http.StatusNotFound)
+	case errors.Is(err, context.Canceled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// NewCallback creates a callback which an external system completes through the HTTP handler returned by
+// NewCallbackHandler. Pass the ID of the returned future to an activity which encodes it with EncodeCallbackToken
+// and hands the URL out, then Get the future to wait for the CallbackRequest. Each callback completes once; later
+// requests with the same token are ignored.
+func NewCallback(ctx Context) CallbackFuture {
+	future, settable := NewFuture(ctx)
+	id := NewUUID(ctx)
+	callbacks := getWorkflowCallbacks(ctx)
+	callbacks.pending[id] = settable
+	if !callbacks.receiving {
+		callbacks.receiving = true
+		Go(ctx, callbacks.receive)
+	}
+	return &callbackFutureImpl{Future: future, id: id}
+}
+
+func (f *callbackFutureImpl) ID() string {
+	return f.id
+}
+
+func getWorkflowCallbacks(ctx Context) *workflowCallbacks {
+	wc := getWorkflowEnvironmentInterceptor(ctx)
+	if wc.callbacks == nil {
+		wc.callbacks = &workflowCallbacks{pending: make(map[string]Settable)}
+	}
+	return wc.callbacks
+}
+
+func (c *workflowCallbacks) receive(ctx Context) {
+	ch := GetSignalChannel(ctx, callbackSignalName)
+	for {
+		var request CallbackRequest
+		ch.Receive(ctx, &request)
+		settable, ok := c.pending[request.CallbackID]
+		if !ok {
+			GetLogger(ctx).Warn("Ignoring request to unknown or completed callback.", "CallbackID", request.CallbackID)
+			continue
+		}
+		delete(c.pending, request.CallbackID)
+		settable.SetValue(request)
+	}
+}
@@ -0,0 +1,97 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/serviceerror"
+)
+
+type callbackTestClient struct {
+	Client
+	workflowID string
+	runID      string
+	request    CallbackRequest
+	err        error
+}
+
+func (c *callbackTestClient) SignalWorkflow(_ context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	if signalName != callbackSignalName {
+		return serviceerror.NewInvalidArgument("unexpected signal " + signalName)
+	}
+	c.workflowID, c.runID, c.request = workflowID, runID, arg.(CallbackRequest)
+	return c.err
+}
+
+func TestCallbackToken(t *testing.T) {
+	secret := []byte("secret")
+	token := CallbackToken{WorkflowID: "workflow", RunID: "run", CallbackID: "callback"}
+	encoded, err := EncodeCallbackToken(token, secret)
+	require.NoError(t, err)
+
+	decoded, err := decodeCallbackToken(encoded, secret)
+	require.NoError(t, err)
+	require.Equal(t, token, decoded)
+
+	_, err = decodeCallbackToken(encoded, []byte("other secret"))
+	require.Equal(t, errInvalidCallbackToken, err)
+	_, err = decodeCallbackToken("x"+encoded, secret)
+	require.Equal(t, errInvalidCallbackToken, err)
+	_, err = EncodeCallbackToken(token, nil)
+	require.Error(t, err)
+}
+
+func TestCallbackHandler(t *testing.T) {
+	secret := []byte("secret")
+	c := &callbackTestClient{}
+	handler := NewCallbackHandler(c, CallbackHandlerOptions{Secret: secret, MaxBodySize: 10})
+	token, err := EncodeCallbackToken(CallbackToken{WorkflowID: "workflow", RunID: "run", CallbackID: "callback"}, secret)
+	require.NoError(t, err)
+
+	serve := func(method, token, body string) int {
+		r := httptest.NewRequest(method, "/?token="+token, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve(http.MethodPost, token, `{"ok":1}`))
+	require.Equal(t, "workflow", c.workflowID)
+	require.Equal(t, "run", c.runID)
+	require.Equal(t, CallbackRequest{CallbackID: "callback", ContentType: "application/json", Body: []byte(`{"ok":1}`)}, c.request)
+
+	require.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, token, ""))
+	require.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "bad", ""))
+	require.Equal(t, http.StatusRequestEntityTooLarge, serve(http.MethodPost, token, "too large body"))
+	c.err = serviceerror.NewNotFound("workflow completed")
+	require.Equal(t, http.StatusNotFound, serve(http.MethodPost, token, ""))
+}
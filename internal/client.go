@@ -27,6 +27,8 @@ package internal
 import (
 	"context"
 	"crypto/tls"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -35,6 +37,7 @@ import (
 	"github.com/uber-go/tally"
 	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
+	namespacepb "go.temporal.io/api/namespace/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/internal/common/backoff"
 	"google.golang.org/grpc"
@@ -42,6 +45,7 @@ import (
 
 	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/internal/common/metrics"
+	"go.temporal.io/sdk/internal/common/retry"
 	ilog "go.temporal.io/sdk/internal/log"
 	"go.temporal.io/sdk/log"
 )
@@ -58,7 +62,22 @@ const (
 	// sessions in the workflow. The result will be a list of SessionInfo encoded in the EncodedValue.
 	QueryTypeOpenSessions string = "__open_sessions"
 
+	// QueryTypeQueryTypes is the build in query type for Client.QueryWorkflow() call. Use this query type to get the
+	// types of all queries supported by the workflow. The result will be a list of strings encoded in the EncodedValue.
+	QueryTypeQueryTypes string = "__query_types"
+
+	// QueryTypeWorkflowMetadata is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the workflow type, the supported query types, the signal channels in use and the change versions of the workflow.
+	// The result will be a WorkflowMetadata encoded in the EncodedValue.
+	QueryTypeWorkflowMetadata string = "__workflow_metadata"
+
+	// QueryTypeWorkflowState is the build in query type for Client.QueryWorkflow() call. Use this query type to get
+	// the values stored with workflow.SetValue and the variables registered with workflow.RegisterObservableVariable.
+	// The result will be a WorkflowState encoded in the EncodedValue.
+	QueryTypeWorkflowState string = "__workflow_state"
+
 	healthCheckServiceName           = "temporal.api.workflowservice.v1.WorkflowService"
+	healthCheckMethod                = "/grpc.health.v1.Health/Check"
 	defaultHealthCheckAttemptTimeout = 5 * time.Second
 	defaultHealthCheckTimeout        = 10 * time.Second
 )
@@ -129,6 +148,9 @@ type (
 		//  - EntityNotExistsError, if namespace does not exist
 		//  - BadRequestError
 		//	- InternalServiceError
+		//  - InvalidOptionsError, if the options are invalid
+		//  - WorkflowExecutionAlreadyStartedError, if the workflow is closed and WorkflowIDReusePolicy doesn't allow
+		//    to start it again
 		SignalWithStartWorkflow(ctx context.Context, workflowID string, signalName string, signalArg interface{},
 			options StartWorkflowOptions, workflow interface{}, workflowArgs ...interface{}) (WorkflowRun, error)
 
@@ -215,6 +237,13 @@ type (
 		//	- InternalServiceError
 		RecordActivityHeartbeatByID(ctx context.Context, namespace, workflowID, runID, activityID string, details ...interface{}) error
 
+		// CompleteActivities reports completion of many activities at once. Each ActivityCompletion identifies its activity
+		// either by TaskToken or by WorkflowID and ActivityID, so the external system finishing async activities doesn't
+		// need to persist the task tokens. Completions are reported concurrently.
+		// The returned slice has the same length as completions and holds the error reported for each of them,
+		// it is nil when all completions succeeded.
+		CompleteActivities(ctx context.Context, completions []ActivityCompletion) []error
+
 		// ListClosedWorkflow gets closed workflow executions based on request filters
 		// The errors it can return:
 		//  - BadRequestError
@@ -295,6 +324,16 @@ type (
 		//  - QueryFailError
 		QueryWorkflow(ctx context.Context, workflowID string, runID string, queryType string, args ...interface{}) (converter.EncodedValue, error)
 
+		// GetWorkflowStackTrace issues the QueryTypeStackTrace query and parses its result into the stacks of the
+		// coroutines of the workflow, with their frames and what they are blocked on.
+		// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		//  - QueryFailError
+		GetWorkflowStackTrace(ctx context.Context, workflowID string, runID string) (*WorkflowStackTrace, error)
+
 		// QueryWorkflowWithOptions queries a given workflow execution and returns the query result synchronously.
 		// See QueryWorkflowWithOptionsRequest and QueryWorkflowWithOptionsResponse for more information.
 		// The errors it can return:
@@ -311,6 +350,17 @@ type (
 		//  - EntityNotExistError
 		DescribeWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowservice.DescribeWorkflowExecutionResponse, error)
 
+		// DescribeWorkflow is DescribeWorkflowExecution with the pending activities and child workflows decoded into Go
+		// structs: the heartbeat details and last failures of the pending activities are decoded with the DataConverter
+		// of the client.
+		// - runID can be default(empty string). if empty string then it will pick the last running execution of that workflow ID.
+		//
+		// The errors it can return:
+		//  - BadRequestError
+		//  - InternalServiceError
+		//  - EntityNotExistError
+		DescribeWorkflow(ctx context.Context, workflowID, runID string) (*WorkflowExecutionDescription, error)
+
 		// DescribeTaskQueue returns information about the target taskqueue, right now this API returns the
 		// pollers which polled this taskqueue in last few minutes.
 		// The errors it can return:
@@ -377,6 +427,13 @@ type (
 		// default: no metrics.
 		MetricsScope tally.Scope
 
+		// Optional: Metrics handler to be used instead of MetricsScope for users who don't use tally.
+		// Ignored if MetricsScope is set. Use NewPrometheusMetricsHandler to expose metrics to Prometheus:
+		// handler := client.NewPrometheusMetricsHandler(client.PrometheusMetricsHandlerOptions{})
+		// http.Handle("/metrics", handler)
+		// default: no metrics.
+		MetricsHandler metrics.Handler
+
 		// Optional: Sets an identify that can be used to track this host for debugging.
 		// default: default identity that include hostname, groupName and process ID.
 		Identity string
@@ -404,8 +461,50 @@ type (
 		// Optional parameter that is designed to be used *in tests*. It gets invoked last in
 		// the gRPC interceptor chain and can be used to induce artificial failures in test scenarios.
 		TrafficController TrafficController
+
+		// Optional: Sets the secondary clusters the client fails over to when the cluster at HostPort is unhealthy.
+		// The health of the clusters is checked periodically and the client always uses the first healthy cluster,
+		// starting with the one at HostPort. Creating the client fails if no cluster is healthy unless it is created
+		// with NewLazyClient.
+		// default: no failover
+		Failover *FailoverOptions
+
+		// Optional: Customizes the retries of the requests made to the server by the client and the workers created
+		// with it. Requests rejected by an overloaded server with the RESOURCE_EXHAUSTED code are retried with a longer
+		// backoff and a retry delay requested by the server with RetryInfo error details is always respected.
+		// default: exponential backoff starting at 200ms until the deadline of the request, 1 minute if not set.
+		RetryOptions *ServiceRetryOptions
+
+		// Optional: Sets the interceptors of the calls made by the client to start, signal, cancel, terminate and query
+		// workflows. The first interceptor in the list is the first one to be called.
+		// default: nil
+		Interceptors []ClientInterceptor
+
+		// Optional: Makes GetWorkflowHistory and DescribeWorkflow fall back to the archival of the namespace when the
+		// server returns NotFound for a workflow which was deleted after the retention period. The run ID, if not
+		// given, is resolved to the most recently closed archived run with ListArchivedWorkflow. Requires the
+		// history and visibility archival to be enabled for the namespace.
+		// default: false
+		ArchivalFallback bool
+
+		// Optional: Makes ExecuteWorkflow and SignalWithStartWorkflow validate StartWorkflowOptions.SearchAttributes
+		// with ValidateSearchAttributes against the search attributes registered on the cluster, so that an unknown
+		// search attribute or a value of the wrong type fails with an *InvalidOptionsError naming the attribute. The
+		// registered search attributes are fetched with GetSearchAttributes when first needed, and fetched again when
+		// a search attribute isn't found to pick up the ones registered since.
+		// default: false
+		ValidateSearchAttributes bool
 	}
 
+	// ServiceRetryOptions customize the retries of the requests made to the server.
+	ServiceRetryOptions = retry.ServiceRetryOptions
+
+	// BackoffStrategy randomizes the delays between retries, see ServiceRetryOptions.BackoffStrategy.
+	BackoffStrategy = retry.BackoffStrategy
+
+	// BackoffStrategyFunc is an adapter to use a function as a BackoffStrategy.
+	BackoffStrategyFunc = retry.BackoffStrategyFunc
+
 	// HeadersProvider returns a map of gRPC headers that should be used on every request.
 	HeadersProvider interface {
 		GetHeaders(ctx context.Context) (map[string]string, error)
@@ -495,13 +594,17 @@ type (
 		WorkflowTaskTimeout time.Duration
 
 		// WorkflowIDReusePolicy - Whether server allow reuse of workflow ID, can be useful
-		// for dedupe logic if set to RejectDuplicate.
+		// for dedupe logic if set to RejectDuplicate. The policy applies to the closed runs of the workflow ID:
+		// AllowDuplicate starts a new run whatever the closed runs ended with, AllowDuplicateFailedOnly starts a new run
+		// only when the last run failed, was canceled, terminated or timed out, and RejectDuplicate never starts a new
+		// run. A workflow ID with a running workflow is never reused, whatever the policy.
 		// Optional: defaulted to AllowDuplicate.
 		WorkflowIDReusePolicy enumspb.WorkflowIdReusePolicy
 
 		// When WorkflowExecutionErrorWhenAlreadyStarted is true, Client.ExecuteWorkflow will return an error if the
-		// workflow id has already been used and WorkflowIDReusePolicy would disallow a re-run. If it is set to false,
-		// rather than erroring a WorkflowRun instance representing the current or last run will be returned.
+		// workflow id has already been used and WorkflowIDReusePolicy would disallow a re-run. The error is a
+		// *WorkflowExecutionAlreadyStartedError with the run ID and start time of the current or last run. If it is set
+		// to false, rather than erroring a WorkflowRun instance representing the current or last run will be returned.
 		//
 		// Optional: defaults to false
 		WorkflowExecutionErrorWhenAlreadyStarted bool
@@ -565,6 +668,92 @@ type (
 		//  - cancellation is not a failure, so it won't be retried,
 		//  - only StartToClose or Heartbeat timeouts are retryable.
 		NonRetryableErrorTypes []string
+
+		// Coefficient the StartToCloseTimeout of an activity is multiplied by on each retry, e.g. with 2 the second
+		// attempt gets twice the StartToCloseTimeout of the first one, for downstreams whose latency degrades under
+		// retry storms. The server retries attempts with the same timeouts, so setting it makes the workflow schedule
+		// the retries itself, like ActivityOptions.RetryTaskQueue. Only applies to activities, ignored by activities
+		// executed in a session.
+		// Optional: default 1, all the attempts have the same StartToCloseTimeout
+		StartToCloseTimeoutCoefficient float64
+
+		// Maximum duration since the first attempt was scheduled after which no more attempts are scheduled. Unlike
+		// the ScheduleToCloseTimeout of the activity, it doesn't time out the attempt running when it is reached.
+		// Setting it makes the workflow schedule the retries itself, like StartToCloseTimeoutCoefficient. Only
+		// applies to activities, ignored by activities executed in a session.
+		// Optional: default 0, the retries are bound only by MaximumAttempts and ScheduleToCloseTimeout
+		MaximumAttemptsDuration time.Duration
+	}
+
+	// ActivityCompletion describes the completion of a single activity reported with Client.CompleteActivities.
+	// The activity is identified by TaskToken if it is set, otherwise by Namespace, WorkflowID, RunID and ActivityID.
+	// If Err is nil, activity task completed event will be reported; if Err is CanceledError, activity task canceled
+	// event will be reported; otherwise, activity task failed event will be reported.
+	ActivityCompletion struct {
+		// TaskToken is the value of the "TaskToken" field of the ActivityInfo retrieved inside the activity.
+		TaskToken []byte
+
+		// Namespace of the workflow that scheduled the activity. Defaults to the namespace of the client.
+		Namespace string
+		// WorkflowID of the workflow that scheduled the activity. Required if TaskToken is not set.
+		WorkflowID string
+		// RunID of the workflow that scheduled the activity. Optional.
+		RunID string
+		// ActivityID provided in ActivityOptions. Required if TaskToken is not set.
+		ActivityID string
+
+		// Result of the activity, ignored if Err is not nil.
+		Result interface{}
+		// Err fails or cancels the activity.
+		Err error
+	}
+
+	// WorkflowExecutionDescription is the result of Client.DescribeWorkflow.
+	WorkflowExecutionDescription struct {
+		WorkflowID    string
+		RunID         string
+		WorkflowType  string
+		Status        enumspb.WorkflowExecutionStatus
+		StartTime     time.Time
+		CloseTime     time.Time // zero while the workflow execution is running
+		HistoryLength int64
+
+		// Archived is true when the workflow execution was deleted after the retention period and was described from
+		// its archived visibility record, see ClientOptions.ArchivalFallback. Only the fields above are set then.
+		Archived bool
+
+		PendingActivities []PendingActivityDescription
+		PendingChildren   []PendingChildWorkflowDescription
+
+		// Response is the raw response of DescribeWorkflowExecution, for the fields which are not decoded.
+		Response *workflowservice.DescribeWorkflowExecutionResponse
+	}
+
+	// PendingActivityDescription describes an activity of a workflow execution which didn't complete yet.
+	PendingActivityDescription struct {
+		ActivityID         string
+		ActivityType       string
+		State              enumspb.PendingActivityState
+		Attempt            int32
+		MaximumAttempts    int32
+		ScheduledTime      time.Time
+		LastStartedTime    time.Time
+		LastHeartbeatTime  time.Time
+		ExpirationTime     time.Time
+		LastWorkerIdentity string
+		// HeartbeatDetails are the details recorded by the last heartbeat, nil if the activity didn't heartbeat.
+		HeartbeatDetails converter.EncodedValues
+		// LastFailure is the failure of the last attempt, nil if no attempt failed.
+		LastFailure error
+	}
+
+	// PendingChildWorkflowDescription describes a child workflow of a workflow execution which didn't complete yet.
+	PendingChildWorkflowDescription struct {
+		WorkflowID        string
+		RunID             string
+		WorkflowType      string
+		InitiatedEventID  int64
+		ParentClosePolicy enumspb.ParentClosePolicy
 	}
 
 	// NamespaceClient is the client for managing operations on the namespace.
@@ -594,19 +783,86 @@ type (
 		//	- InternalServiceError
 		Update(ctx context.Context, request *workflowservice.UpdateNamespaceRequest) error
 
+		// MarkBadBinary marks the binary with the given checksum as bad in the namespace. Workers running a bad binary
+		// can't poll workflow tasks of the namespace and workflows can be reset to the point before the binary touched
+		// them. See WorkerOptions.BinaryChecksum.
+		// The errors it can throw:
+		//	- EntityNotExistsError
+		//	- BadRequestError
+		//	- InternalServiceError
+		MarkBadBinary(ctx context.Context, namespace string, binaryChecksum string, reason string) error
+
+		// DeleteBadBinary removes the binary with the given checksum from the bad binaries of the namespace.
+		// The errors it can throw:
+		//	- EntityNotExistsError
+		//	- BadRequestError
+		//	- InternalServiceError
+		DeleteBadBinary(ctx context.Context, namespace string, binaryChecksum string) error
+
+		// GetBadBinaries returns the bad binaries of the namespace by binary checksum.
+		// The errors it can throw:
+		//	- EntityNotExistsError
+		//	- BadRequestError
+		//	- InternalServiceError
+		GetBadBinaries(ctx context.Context, namespace string) (map[string]*namespacepb.BadBinaryInfo, error)
+
+		// Close client and clean up underlying resources.
+		Close()
+	}
+
+	// ActivityCompletionClient is the client for completing and heartbeating asynchronous activities.
+	// It is meant for services that don't start or query workflows but finish activities that returned
+	// ErrActivityResultPending, possibly using a task token exported with EncodeTaskToken by another system.
+	ActivityCompletionClient interface {
+		// Complete reports the activity completed with the given result, which can be nil.
+		Complete(ctx context.Context, taskToken []byte, result interface{}) error
+
+		// Fail reports the activity failed with the given error, which is retried according to the retry policy of
+		// the activity unless it is non-retryable.
+		Fail(ctx context.Context, taskToken []byte, err error) error
+
+		// Heartbeat records a heartbeat for the activity. It returns a *CanceledError when the cancellation of the
+		// activity was requested, which must then be reported with ReportCanceled.
+		Heartbeat(ctx context.Context, taskToken []byte, details ...interface{}) error
+
+		// ReportCanceled reports the activity canceled, with optional details.
+		ReportCanceled(ctx context.Context, taskToken []byte, details ...interface{}) error
+
 		// Close client and clean up underlying resources.
 		Close()
 	}
 )
 
+// NewJitterBackoff returns a BackoffStrategy randomizing each delay by up to the jitter fraction in both directions.
+func NewJitterBackoff(jitter float64) BackoffStrategy {
+	return retry.NewJitterBackoff(jitter)
+}
+
+// NewFullJitterBackoff returns a BackoffStrategy replacing each delay with a random delay between zero and the delay.
+func NewFullJitterBackoff() BackoffStrategy {
+	return retry.NewFullJitterBackoff()
+}
+
 // NewClient creates an instance of a workflow client
 func NewClient(options ClientOptions) (Client, error) {
+	return newClient(options, false)
+}
+
+// NewLazyClient creates an instance of a workflow client which doesn't connect to the server until it is used. The
+// health of the server is checked before the first request instead of on creation. Until the check passes, requests
+// fail with an error describing why the server couldn't be reached and the check is repeated on the next request, so
+// that a client created while the server is unavailable recovers automatically once it becomes available.
+func NewLazyClient(options ClientOptions) (Client, error) {
+	return newClient(options, true)
+}
+
+func newClient(options ClientOptions, lazy bool) (Client, error) {
 	if options.Namespace == "" {
 		options.Namespace = DefaultNamespace
 	}
 
 	// Initializes the root metric scope.  These tags are included on each metric which creates a child scope from it.
-	options.MetricsScope = metrics.GetRootScope(options.MetricsScope, options.Namespace)
+	options.MetricsScope = metrics.GetRootScope(getMetricsScope(options), options.Namespace)
 
 	if options.HostPort == "" {
 		options.HostPort = LocalHostPort
@@ -617,26 +873,50 @@ func NewClient(options ClientOptions) (Client, error) {
 		options.Logger.Info("No logger configured for temporal client. Created default one.")
 	}
 
-	connection, err := dial(newDialParameters(&options))
+	dialParams := newDialParameters(&options)
+	if lazy {
+		dialParams.RequiredInterceptors = append([]grpc.UnaryClientInterceptor{
+			lazyHealthCheckInterceptor(options.HostPort, options.ConnectionOptions),
+		}, dialParams.RequiredInterceptors...)
+	}
+	if options.Failover != nil {
+		connection, err := dialFailover(dialParams, *options.Failover, options.Logger, lazy)
+		if err != nil {
+			return nil, err
+		}
+		return NewServiceClient(workflowservice.NewWorkflowServiceClient(connection.connection), connection, options), nil
+	}
+
+	connection, err := dial(dialParams)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = checkHealth(connection, options.ConnectionOptions); err != nil {
-		if err := connection.Close(); err != nil {
-			options.Logger.Warn("Unable to close connection on health check failure.", "error", err)
+	if !lazy {
+		if err = checkHealth(context.Background(), connection, options.ConnectionOptions); err != nil {
+			if err := connection.Close(); err != nil {
+				options.Logger.Warn("Unable to close connection on health check failure.", "error", err)
+			}
+			return nil, err
 		}
-		return nil, err
 	}
 
 	return NewServiceClient(workflowservice.NewWorkflowServiceClient(connection), connection, options), nil
 }
 
+// getMetricsScope returns MetricsScope of the options or a scope emitting metrics to MetricsHandler if only it is set.
+func getMetricsScope(options ClientOptions) tally.Scope {
+	if options.MetricsScope == nil && options.MetricsHandler != nil {
+		return metrics.NewHandlerScope(options.MetricsHandler)
+	}
+	return options.MetricsScope
+}
+
 func newDialParameters(options *ClientOptions) dialParameters {
 	return dialParameters{
 		UserConnectionOptions: options.ConnectionOptions,
 		HostPort:              options.HostPort,
-		RequiredInterceptors:  requiredInterceptors(options.MetricsScope, options.HeadersProvider, options.TrafficController),
+		RequiredInterceptors:  requiredInterceptors(options.MetricsScope, options.HeadersProvider, options.TrafficController, options.RetryOptions),
 		DefaultServiceConfig:  defaultServiceConfig,
 	}
 }
@@ -662,7 +942,7 @@ func NewServiceClient(workflowServiceClient workflowservice.WorkflowServiceClien
 		options.Tracer = opentracing.NoopTracer{}
 	}
 
-	return &WorkflowClient{
+	client := &WorkflowClient{
 		workflowService:    workflowServiceClient,
 		connectionCloser:   connectionCloser,
 		namespace:          options.Namespace,
@@ -673,13 +953,22 @@ func NewServiceClient(workflowServiceClient workflowservice.WorkflowServiceClien
 		dataConverter:      options.DataConverter,
 		contextPropagators: options.ContextPropagators,
 		tracer:             options.Tracer,
+		archivalFallback:   options.ArchivalFallback,
+	}
+	if options.ValidateSearchAttributes {
+		client.searchAttributeKeys = &searchAttributeKeys{}
+	}
+	if options.RetryOptions != nil {
+		client.backoffStrategy = options.RetryOptions.BackoffStrategy
 	}
+	client.interceptor = newClientInterceptors(client, options.Interceptors)
+	return client
 }
 
 // NewNamespaceClient creates an instance of a namespace client, to manager lifecycle of namespaces.
 func NewNamespaceClient(options ClientOptions) (NamespaceClient, error) {
 	// Initializes the root metric scope.  These tags are included on each metric which creates a child scope from it.
-	options.MetricsScope = metrics.GetRootScope(options.MetricsScope, metrics.NoneTagValue)
+	options.MetricsScope = metrics.GetRootScope(getMetricsScope(options), metrics.NoneTagValue)
 
 	if options.HostPort == "" {
 		options.HostPort = LocalHostPort
@@ -690,7 +979,7 @@ func NewNamespaceClient(options ClientOptions) (NamespaceClient, error) {
 		return nil, err
 	}
 
-	if err = checkHealth(connection, options.ConnectionOptions); err != nil {
+	if err = checkHealth(context.Background(), connection, options.ConnectionOptions); err != nil {
 		if err := connection.Close(); err != nil {
 			options.Logger.Warn("Unable to close connection on health check failure.", "error", err)
 		}
@@ -714,6 +1003,62 @@ func newNamespaceServiceClient(workflowServiceClient workflowservice.WorkflowSer
 	}
 }
 
+// NewActivityCompletionClient creates an instance of a client that can only complete and heartbeat activities.
+func NewActivityCompletionClient(options ClientOptions) (ActivityCompletionClient, error) {
+	client, err := NewClient(options)
+	if err != nil {
+		return nil, err
+	}
+	return &activityCompletionClient{client: client.(*WorkflowClient)}, nil
+}
+
+// activityCompletionClient is the implementation of ActivityCompletionClient, which hides the other methods of the
+// WorkflowClient.
+type activityCompletionClient struct {
+	client *WorkflowClient
+}
+
+func (c *activityCompletionClient) Complete(ctx context.Context, taskToken []byte, result interface{}) error {
+	return c.client.CompleteActivity(ctx, taskToken, result, nil)
+}
+
+func (c *activityCompletionClient) Fail(ctx context.Context, taskToken []byte, err error) error {
+	if err == nil {
+		return errors.New("nil error provided to fail the activity")
+	}
+	return c.client.CompleteActivity(ctx, taskToken, nil, err)
+}
+
+func (c *activityCompletionClient) Heartbeat(ctx context.Context, taskToken []byte, details ...interface{}) error {
+	return c.client.RecordActivityHeartbeat(ctx, taskToken, details...)
+}
+
+func (c *activityCompletionClient) ReportCanceled(ctx context.Context, taskToken []byte, details ...interface{}) error {
+	return c.client.CompleteActivity(ctx, taskToken, nil, NewCanceledError(details...))
+}
+
+func (c *activityCompletionClient) Close() {
+	c.client.Close()
+}
+
+// EncodeTaskToken encodes activity task token to a string which can be passed to a different system or language.
+// The token is encoded using URL safe base64 encoding as defined in RFC 4648.
+func EncodeTaskToken(taskToken []byte) string {
+	return base64.URLEncoding.EncodeToString(taskToken)
+}
+
+// DecodeTaskToken decodes activity task token previously encoded with EncodeTaskToken.
+func DecodeTaskToken(encodedTaskToken string) ([]byte, error) {
+	if encodedTaskToken == "" {
+		return nil, errors.New("empty task token")
+	}
+	taskToken, err := base64.URLEncoding.DecodeString(encodedTaskToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task token: %w", err)
+	}
+	return taskToken, nil
+}
+
 // NewValue creates a new converter.EncodedValue which can be used to decode binary data returned by Temporal.  For example:
 // User had Activity.RecordHeartbeat(ctx, "my-heartbeat") and then got response from calling Client.DescribeWorkflowExecution.
 // The response contains binary field PendingActivityInfo.HeartbeatDetails,
@@ -737,39 +1082,40 @@ func NewValues(data *commonpb.Payloads) converter.EncodedValues {
 
 // checkHealth checks service health using gRPC health check:
 // https://github.com/grpc/grpc/blob/master/doc/health-checking.md
-func checkHealth(connection grpc.ClientConnInterface, options ConnectionOptions) error {
+func checkHealth(ctx context.Context, connection grpc.ClientConnInterface, options ConnectionOptions) error {
 	if options.DisableHealthCheck {
 		return nil
 	}
 
-	healthClient := healthpb.NewHealthClient(connection)
-
-	request := &healthpb.HealthCheckRequest{
-		Service: healthCheckServiceName,
-	}
-
-	attemptTimeout := options.HealthCheckAttemptTimeout
-	if attemptTimeout == 0 {
-		attemptTimeout = defaultHealthCheckAttemptTimeout
-	}
 	timeout := options.HealthCheckTimeout
 	if timeout == 0 {
 		timeout = defaultHealthCheckTimeout
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	policy := createDynamicServiceRetryPolicy(ctx)
 	// TODO: refactor using grpc retry interceptor
 	return backoff.Retry(ctx, func() error {
-		healthCheckCtx, cancel := context.WithTimeout(context.Background(), attemptTimeout)
-		defer cancel()
-		resp, err := healthClient.Check(healthCheckCtx, request)
-		if err != nil {
-			return fmt.Errorf("health check error: %w", err)
-		}
-		if resp.Status != healthpb.HealthCheckResponse_SERVING {
-			return fmt.Errorf("health check returned unhealthy status: %v", resp.Status)
-		}
-		return nil
+		return checkHealthOnce(ctx, connection, options)
 	}, policy, nil)
 }
+
+// checkHealthOnce makes a single health check request without retries.
+func checkHealthOnce(ctx context.Context, connection grpc.ClientConnInterface, options ConnectionOptions) error {
+	attemptTimeout := options.HealthCheckAttemptTimeout
+	if attemptTimeout == 0 {
+		attemptTimeout = defaultHealthCheckAttemptTimeout
+	}
+	healthCheckCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(connection).Check(healthCheckCtx, &healthpb.HealthCheckRequest{
+		Service: healthCheckServiceName,
+	})
+	if err != nil {
+		return fmt.Errorf("health check error: %w", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health check returned unhealthy status: %v", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+)
+
+// ClientInterceptor is used to create a single link in the client interceptor chain. Called once per client creation.
+type ClientInterceptor interface {
+	// InterceptClient creates an interceptor instance. The created instance must delegate every call to
+	// the next parameter for the client to function correctly.
+	InterceptClient(next ClientOutboundInterceptor) ClientOutboundInterceptor
+}
+
+// ClientOutboundInterceptor is an interface that can be implemented to intercept the calls made by the client to start,
+// signal, cancel, terminate and query workflows, e.g. to add audit logging, attach idempotency information to the ctx
+// or retry failed calls.
+// Use ClientOutboundInterceptorBase as a base struct for implementations that do not want to implement every method.
+// Interceptor implementation must forward calls to the next in the interceptor chain.
+type ClientOutboundInterceptor interface {
+	// ExecuteWorkflow intercepts Client.ExecuteWorkflow. The workflow argument is either the workflow type name or
+	// the workflow function.
+	ExecuteWorkflow(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (WorkflowRun, error)
+	// SignalWorkflow intercepts Client.SignalWorkflow.
+	SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error
+	// SignalWithStartWorkflow intercepts Client.SignalWithStartWorkflow.
+	SignalWithStartWorkflow(ctx context.Context, workflowID string, signalName string, signalArg interface{},
+		options StartWorkflowOptions, workflow interface{}, workflowArgs ...interface{}) (WorkflowRun, error)
+	// CancelWorkflow intercepts Client.CancelWorkflow.
+	CancelWorkflow(ctx context.Context, workflowID string, runID string) error
+	// TerminateWorkflow intercepts Client.TerminateWorkflow.
+	TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details ...interface{}) error
+	// QueryWorkflow intercepts both Client.QueryWorkflow and Client.QueryWorkflowWithOptions.
+	QueryWorkflow(ctx context.Context, request *QueryWorkflowWithOptionsRequest) (*QueryWorkflowWithOptionsResponse, error)
+}
+
+var _ ClientOutboundInterceptor = (*ClientOutboundInterceptorBase)(nil)
+var _ ClientOutboundInterceptor = (*workflowClientInterceptor)(nil)
+
+// ClientOutboundInterceptorBase is a noop implementation of ClientOutboundInterceptor that just forwards requests
+// to the next link in an interceptor chain. To be used as base implementation of interceptors.
+type ClientOutboundInterceptorBase struct {
+	Next ClientOutboundInterceptor
+}
+
+// workflowClientInterceptor is the last link of the client interceptor chain which makes the actual calls.
+type workflowClientInterceptor struct {
+	client *WorkflowClient
+}
+
+func newClientInterceptors(client *WorkflowClient, interceptors []ClientInterceptor) ClientOutboundInterceptor {
+	var interceptor ClientOutboundInterceptor = &workflowClientInterceptor{client: client}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor = interceptors[i].InterceptClient(interceptor)
+	}
+	return interceptor
+}
+
+func (c *ClientOutboundInterceptorBase) ExecuteWorkflow(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (WorkflowRun, error) {
+	return c.Next.ExecuteWorkflow(ctx, options, workflow, args...)
+}
+
+func (c *ClientOutboundInterceptorBase) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	return c.Next.SignalWorkflow(ctx, workflowID, runID, signalName, arg)
+}
+
+func (c *ClientOutboundInterceptorBase) SignalWithStartWorkflow(ctx context.Context, workflowID string, signalName string, signalArg interface{},
+	options StartWorkflowOptions, workflow interface{}, workflowArgs ...interface{}) (WorkflowRun, error) {
+	return c.Next.SignalWithStartWorkflow(ctx, workflowID, signalName, signalArg, options, workflow, workflowArgs...)
+}
+
+func (c *ClientOutboundInterceptorBase) CancelWorkflow(ctx context.Context, workflowID string, runID string) error {
+	return c.Next.CancelWorkflow(ctx, workflowID, runID)
+}
+
+func (c *ClientOutboundInterceptorBase) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details ...interface{}) error {
+	return c.Next.TerminateWorkflow(ctx, workflowID, runID, reason, details...)
+}
+
+func (c *ClientOutboundInterceptorBase) QueryWorkflow(ctx context.Context, request *QueryWorkflowWithOptionsRequest) (*QueryWorkflowWithOptionsResponse, error) {
+	return c.Next.QueryWorkflow(ctx, request)
+}
+
+func (c *workflowClientInterceptor) ExecuteWorkflow(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (WorkflowRun, error) {
+	return c.client.executeWorkflow(ctx, options, workflow, args...)
+}
+
+func (c *workflowClientInterceptor) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	return c.client.signalWorkflow(ctx, workflowID, runID, signalName, arg)
+}
+
+func (c *workflowClientInterceptor) SignalWithStartWorkflow(ctx context.Context, workflowID string, signalName string, signalArg interface{},
+	options StartWorkflowOptions, workflow interface{}, workflowArgs ...interface{}) (WorkflowRun, error) {
+	return c.client.signalWithStartWorkflow(ctx, workflowID, signalName, signalArg, options, workflow, workflowArgs...)
+}
+
+func (c *workflowClientInterceptor) CancelWorkflow(ctx context.Context, workflowID string, runID string) error {
+	return c.client.cancelWorkflow(ctx, workflowID, runID)
+}
+
+func (c *workflowClientInterceptor) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details ...interface{}) error {
+	return c.client.terminateWorkflow(ctx, workflowID, runID, reason, details...)
+}
+
+func (c *workflowClientInterceptor) QueryWorkflow(ctx context.Context, request *QueryWorkflowWithOptionsRequest) (*QueryWorkflowWithOptionsResponse, error) {
+	return c.client.queryWorkflowWithOptions(ctx, request)
+}
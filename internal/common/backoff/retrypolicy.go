@@ -62,6 +62,7 @@ type (
 		maximumInterval    time.Duration
 		expirationInterval time.Duration
 		maximumAttempts    int
+		backoffStrategy    retry.BackoffStrategy
 	}
 
 	systemClock struct{}
@@ -129,6 +130,11 @@ func (p *ExponentialRetryPolicy) SetMaximumAttempts(maximumAttempts int) {
 	p.maximumAttempts = maximumAttempts
 }
 
+// SetBackoffStrategy sets the strategy randomizing the delays instead of the default jitter
+func (p *ExponentialRetryPolicy) SetBackoffStrategy(backoffStrategy retry.BackoffStrategy) {
+	p.backoffStrategy = backoffStrategy
+}
+
 // ComputeNextDelay returns the next delay interval.  This is used by Retrier to delay calling the operation again
 func (p *ExponentialRetryPolicy) ComputeNextDelay(elapsedTime time.Duration, attempt int) time.Duration {
 	// Check to see if we ran out of maximum number of attempts
@@ -161,6 +167,10 @@ func (p *ExponentialRetryPolicy) ComputeNextDelay(elapsedTime time.Duration, att
 		return done
 	}
 
+	if p.backoffStrategy != nil {
+		return p.backoffStrategy.NextDelay(attempt, nextDuration)
+	}
+
 	// add jitter to avoid global synchronization
 	jitterPortion := int(retry.DefaultJitter * nextInterval)
 	// Prevent overflow
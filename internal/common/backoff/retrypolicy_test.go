@@ -55,6 +55,20 @@ func TestExponentialBackoff(t *testing.T) {
 	}
 }
 
+func TestBackoffStrategy(t *testing.T) {
+	t.Parallel()
+	policy := createPolicy(time.Second)
+	policy.SetMaximumInterval(4 * time.Second)
+	policy.SetBackoffStrategy(retry.BackoffStrategyFunc(func(_ int, delay time.Duration) time.Duration {
+		return delay
+	}))
+
+	r, _ := createRetrier(policy)
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		assert.Equal(t, expected, r.NextBackOff())
+	}
+}
+
 func TestNumberOfAttempts(t *testing.T) {
 	t.Parallel()
 	policy := createPolicy(time.Second)
@@ -73,6 +73,16 @@ type Options struct {
 	// RemovedFunc is an optional function called when an element
 	// is scheduled for deletion
 	RemovedFunc RemovedFunc
+
+	// MaxTotalSize limits the total size of the cache entries as reported by SizeFunc.
+	// Least recently used entries are evicted once the limit is exceeded. Pinned entries
+	// are never evicted to satisfy this limit, so it may be temporarily exceeded.
+	// Zero means no size limit.
+	MaxTotalSize int64
+
+	// SizeFunc is an optional function returning the size of a cached value. The size of
+	// an entry is re-evaluated every time the entry is accessed. Required if MaxTotalSize is set.
+	SizeFunc SizeFunc
 }
 
 // RemovedFunc is a type for notifying applications when an item is
@@ -80,3 +90,7 @@ type Options struct {
 // appropriate signature and i is the interface{} scheduled for
 // deletion, Cache calls go f(i)
 type RemovedFunc func(interface{})
+
+// SizeFunc is a type for computing the size of a cached value, in whatever unit the
+// MaxTotalSize of the Cache is expressed. It is called with the Cache lock held.
+type SizeFunc func(interface{}) int64
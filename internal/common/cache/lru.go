@@ -45,6 +45,10 @@ type lru struct {
 	ttl      time.Duration
 	pin      bool
 	rmFunc   RemovedFunc
+
+	sizeFunc     SizeFunc
+	maxTotalSize int64
+	totalSize    int64
 }
 
 // New creates a new cache with the given options
@@ -60,6 +64,9 @@ func New(maxSize int, opts *Options) Cache {
 		maxSize:  maxSize,
 		pin:      opts.Pin,
 		rmFunc:   opts.RemovedFunc,
+
+		sizeFunc:     opts.SizeFunc,
+		maxTotalSize: opts.MaxTotalSize,
 	}
 }
 
@@ -108,10 +115,13 @@ func (c *lru) Get(key string) interface{} {
 		}
 		c.byAccess.Remove(elt)
 		delete(c.byKey, cacheEntry.key)
+		c.totalSize -= cacheEntry.size
 		return nil
 	}
 
 	c.byAccess.MoveToFront(elt)
+	c.updateSize(cacheEntry)
+	c.evictBySize(elt)
 	return cacheEntry.value
 }
 
@@ -151,6 +161,7 @@ func (c *lru) Delete(key string) {
 			go c.rmFunc(entry.value)
 		}
 		delete(c.byKey, key)
+		c.totalSize -= entry.size
 	}
 }
 
@@ -162,6 +173,8 @@ func (c *lru) Release(key string) {
 	elt := c.byKey[key]
 	cacheEntry := elt.Value.(*cacheEntry)
 	cacheEntry.refCount--
+	c.updateSize(cacheEntry)
+	c.evictBySize(nil)
 }
 
 // Size returns the number of entries currently in the lru, useful if cache is not full
@@ -186,6 +199,7 @@ func (c *lru) Clear() {
 			delete(c.byKey, key)
 		}
 	}
+	c.totalSize = 0
 }
 
 // Put puts a new value associated with a given key, returning the existing value (if present)
@@ -208,6 +222,8 @@ func (c *lru) putInternal(key string, value interface{}, allowUpdate bool) (inte
 		if c.pin {
 			entry.refCount++
 		}
+		c.updateSize(entry)
+		c.evictBySize(elt)
 		return existing, nil
 	}
 
@@ -241,14 +257,51 @@ func (c *lru) putInternal(key string, value interface{}, allowUpdate bool) (inte
 			go c.rmFunc(oldest.value)
 		}
 		delete(c.byKey, oldest.key)
+		c.totalSize -= oldest.size
 	}
 
+	elt = c.byKey[key]
+	c.updateSize(entry)
+	c.evictBySize(elt)
+
 	return nil, nil
 }
 
+// updateSize re-evaluates the size of the entry and adjusts the total size of the cache accordingly.
+func (c *lru) updateSize(entry *cacheEntry) {
+	if c.sizeFunc == nil {
+		return
+	}
+	size := c.sizeFunc(entry.value)
+	c.totalSize += size - entry.size
+	entry.size = size
+}
+
+// evictBySize evicts unpinned entries in lru order until the total size fits into maxTotalSize.
+// The keep element is never evicted.
+func (c *lru) evictBySize(keep *list.Element) {
+	if c.maxTotalSize <= 0 {
+		return
+	}
+	for elt := c.byAccess.Back(); elt != nil && c.totalSize > c.maxTotalSize; {
+		prev := elt.Prev()
+		entry := elt.Value.(*cacheEntry)
+		if elt != keep && entry.refCount == 0 {
+			c.byAccess.Remove(elt)
+			if c.rmFunc != nil {
+				go c.rmFunc(entry.value)
+			}
+			delete(c.byKey, entry.key)
+			c.totalSize -= entry.size
+		}
+		elt = prev
+	}
+}
+
 type cacheEntry struct {
 	key        string
 	expiration time.Time
 	value      interface{}
 	refCount   int
+	size       int64
 }
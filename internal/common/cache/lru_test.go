@@ -183,3 +183,61 @@ func TestClear(t *testing.T) {
 		t.Error("Clear did not send true on channel ch")
 	}
 }
+
+func TestLRUWithMaxTotalSize(t *testing.T) {
+	cache := New(5, &Options{
+		MaxTotalSize: 10,
+		SizeFunc: func(i interface{}) int64 {
+			return int64(len(i.(string)))
+		},
+	})
+
+	cache.Put("A", "abc")
+	cache.Put("B", "defg")
+	assert.Equal(t, 2, cache.Size())
+
+	// Exceeds the total size, A is the least recently used
+	cache.Put("C", "hijk")
+	assert.Equal(t, 2, cache.Size())
+	assert.Nil(t, cache.Get("A"))
+	assert.Equal(t, "defg", cache.Get("B"))
+
+	// Value larger than the total size is kept, all the others are evicted
+	cache.Put("D", "0123456789ab")
+	assert.Equal(t, 1, cache.Size())
+	assert.Equal(t, "0123456789ab", cache.Get("D"))
+
+	cache.Delete("D")
+	cache.Put("E", "0123456789")
+	assert.Equal(t, 1, cache.Size())
+}
+
+func TestLRUWithMaxTotalSizeReevaluatesSize(t *testing.T) {
+	sizes := map[string]int64{"A": 2, "B": 2, "C": 2}
+	cache := New(5, &Options{
+		Pin:          true,
+		MaxTotalSize: 10,
+		SizeFunc: func(i interface{}) int64 {
+			return sizes[i.(string)]
+		},
+	})
+
+	for _, key := range []string{"A", "B", "C"} {
+		_, err := cache.PutIfNotExist(key, key)
+		assert.NoError(t, err)
+	}
+
+	// Pinned entries are not evicted even though they grew over the limit
+	sizes["B"] = 6
+	sizes["C"] = 6
+	assert.Equal(t, "B", cache.Get("B"))
+	assert.Equal(t, 3, cache.Size())
+
+	cache.Release("A")
+	assert.Equal(t, 3, cache.Size())
+
+	// Once released, C is re-evaluated and the unpinned entries are evicted in lru order
+	cache.Release("C")
+	assert.Equal(t, 1, cache.Size())
+	assert.Equal(t, "B", cache.Get("B"))
+}
@@ -24,6 +24,12 @@
 
 package metrics
 
+import (
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
 // Metrics keys
 const (
 	TemporalMetricsPrefix = "temporal_"
@@ -33,6 +39,8 @@ const (
 	WorkflowFailedCounter        = TemporalMetricsPrefix + "workflow_failed"
 	WorkflowContinueAsNewCounter = TemporalMetricsPrefix + "workflow_continue_as_new"
 	WorkflowEndToEndLatency      = TemporalMetricsPrefix + "workflow_endtoend_latency" // measure workflow execution from start to close
+	WorkflowStartLatency         = TemporalMetricsPrefix + "workflow_start_latency"    // measure workflow start request until acknowledged by server
+	WorkflowStartFailedCounter   = TemporalMetricsPrefix + "workflow_start_failed"
 
 	WorkflowTaskReplayLatency           = TemporalMetricsPrefix + "workflow_task_replay_latency"
 	WorkflowTaskQueuePollEmptyCounter   = TemporalMetricsPrefix + "workflow_task_queue_poll_empty"
@@ -41,14 +49,25 @@ const (
 	WorkflowTaskExecutionLatency        = TemporalMetricsPrefix + "workflow_task_execution_latency"
 	WorkflowTaskExecutionFailureCounter = TemporalMetricsPrefix + "workflow_task_execution_failed"
 	WorkflowTaskNoCompletionCounter     = TemporalMetricsPrefix + "workflow_task_no_completion"
+	WorkflowTaskHistoryFetchLatency     = TemporalMetricsPrefix + "workflow_task_history_fetch_latency"  // measure fetching a page of history
+	WorkflowTaskCodeExecutionLatency    = TemporalMetricsPrefix + "workflow_task_code_execution_latency" // measure processing of new events
+	WorkflowTaskResponseSize            = TemporalMetricsPrefix + "workflow_task_response_size"          // size of completion request in bytes
+	WorkflowTaskPanicCounter            = TemporalMetricsPrefix + "workflow_task_panic"                  // workflow panics and detected non-determinism
+	DeprecatedWorkflowTypeCounter       = TemporalMetricsPrefix + "deprecated_workflow_type"             // workflows started with a deprecated alias
+	WorkflowTaskLimitExceededCounter    = TemporalMetricsPrefix + "workflow_task_limit_exceeded"         // workflow task completions over MaxWorkflowTaskCommands or MaxWorkflowTaskCompletionSize
+	ReplayOnlyExecutionCounter          = TemporalMetricsPrefix + "replay_only_execution"                // running executions replayed by a ReplayOnly worker
+	ReplayOnlyDivergenceCounter         = TemporalMetricsPrefix + "replay_only_divergence"               // running executions a ReplayOnly worker failed to replay
 
 	ActivityPollNoTaskCounter             = TemporalMetricsPrefix + "activity_poll_no_task"
 	ActivityScheduleToStartLatency        = TemporalMetricsPrefix + "activity_schedule_to_start_latency"
 	ActivityExecutionFailedCounter        = TemporalMetricsPrefix + "activity_execution_failed"
 	UnregisteredActivityInvocationCounter = TemporalMetricsPrefix + "unregistered_activity_invocation"
+	DeprecatedActivityTypeCounter         = TemporalMetricsPrefix + "deprecated_activity_type"
 	ActivityExecutionLatency              = TemporalMetricsPrefix + "activity_execution_latency"
 	ActivityEndToEndLatency               = TemporalMetricsPrefix + "activity_endtoend_latency"
 	ActivityTaskErrorCounter              = TemporalMetricsPrefix + "activity_task_error"
+	ActivityResultCacheHitCounter         = TemporalMetricsPrefix + "activity_result_cache_hit"
+	ActivityResultCacheMissCounter        = TemporalMetricsPrefix + "activity_result_cache_miss"
 
 	LocalActivityTotalCounter     = TemporalMetricsPrefix + "local_activity_total"
 	LocalActivityCanceledCounter  = TemporalMetricsPrefix + "local_activity_canceled"
@@ -57,23 +76,38 @@ const (
 	LocalActivityExecutionLatency = TemporalMetricsPrefix + "local_activity_execution_latency"
 
 	CorruptedSignalsCounter = TemporalMetricsPrefix + "corrupted_signals"
+	UnhandledSignalsCounter = TemporalMetricsPrefix + "unhandled_signals"
+
+	WorkerStartCounter       = TemporalMetricsPrefix + "worker_start"
+	PollerStartCounter       = TemporalMetricsPrefix + "poller_start"
+	WorkerTaskSlotsAvailable = TemporalMetricsPrefix + "worker_task_slots_available"
+	WorkerTaskSlotsUsed      = TemporalMetricsPrefix + "worker_task_slots_used"
+	WorkerPollerCount        = TemporalMetricsPrefix + "worker_poller_count"
+	WorkerPollLatency        = TemporalMetricsPrefix + "worker_poll_latency"
 
-	WorkerStartCounter = TemporalMetricsPrefix + "worker_start"
-	PollerStartCounter = TemporalMetricsPrefix + "poller_start"
+	NamespaceFailoverCounter = TemporalMetricsPrefix + "namespace_failover"
+	NamespaceActiveGauge     = TemporalMetricsPrefix + "namespace_active" // 1 while the namespace is active in the cluster of the worker
 
-	TemporalRequest            = TemporalMetricsPrefix + "request"
-	TemporalRequestFailure     = TemporalRequest + "_failure"
-	TemporalRequestLatency     = TemporalRequest + "_latency"
-	TemporalLongRequest        = TemporalMetricsPrefix + "long_request"
-	TemporalLongRequestFailure = TemporalLongRequest + "_failure"
-	TemporalLongRequestLatency = TemporalLongRequest + "_latency"
+	TemporalRequest                     = TemporalMetricsPrefix + "request"
+	TemporalRequestFailure              = TemporalRequest + "_failure"
+	TemporalRequestLatency              = TemporalRequest + "_latency"
+	TemporalRequestLatencyHistogram     = TemporalRequestLatency + "_histogram"
+	TemporalRequestRetry                = TemporalRequest + "_retry"
+	TemporalLongRequest                 = TemporalMetricsPrefix + "long_request"
+	TemporalLongRequestFailure          = TemporalLongRequest + "_failure"
+	TemporalLongRequestLatency          = TemporalLongRequest + "_latency"
+	TemporalLongRequestLatencyHistogram = TemporalLongRequestLatency + "_histogram"
+	TemporalLongRequestRetry            = TemporalLongRequest + "_retry"
 
 	StickyCacheHit                 = TemporalMetricsPrefix + "sticky_cache_hit"
 	StickyCacheMiss                = TemporalMetricsPrefix + "sticky_cache_miss"
 	StickyCacheTotalForcedEviction = TemporalMetricsPrefix + "sticky_cache_total_forced_eviction"
 	StickyCacheSize                = TemporalMetricsPrefix + "sticky_cache_size"
+	StickyCacheReplayHistoryBytes  = TemporalMetricsPrefix + "sticky_cache_replay_history_bytes" // history fetched to replay after a cache miss
 
 	WorkflowActiveThreadCount = TemporalMetricsPrefix + "workflow_active_thread_count"
+
+	PayloadSizeLimitExceededCounter = TemporalMetricsPrefix + "payload_size_limit_exceeded" // payloads over the converter size limit, tagged with the action
 )
 
 // Metric tag keys
@@ -85,6 +119,8 @@ const (
 	ActivityTypeNameTagName = "activity_type"
 	TaskQueueTagName        = "task_queue"
 	OperationTagName        = "operation"
+	ErrorTypeTagName        = "error_type"
+	PayloadActionTagName    = "payload_action"
 )
 
 // Metric tag values
@@ -92,3 +128,30 @@ const (
 	NoneTagValue   = "none"
 	ClientTagValue = "temporal_go"
 )
+
+var (
+	// RequestLatencyBuckets are the buckets of request latency histograms. They cover both regular requests and
+	// long polls, which can take up to a minute.
+	RequestLatencyBuckets = tally.DurationBuckets{
+		time.Millisecond,
+		2 * time.Millisecond,
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		2 * time.Second,
+		5 * time.Second,
+		10 * time.Second,
+		20 * time.Second,
+		30 * time.Second,
+		60 * time.Second,
+		90 * time.Second,
+	}
+
+	// ResponseSizeBuckets are the buckets of response size histograms in bytes, from 1KB to 4MB.
+	ResponseSizeBuckets = tally.MustMakeExponentialValueBuckets(1024, 2, 13)
+)
@@ -0,0 +1,234 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+type (
+	// Handler is a minimal metrics handler that can be used instead of tally.Scope to receive SDK metrics.
+	Handler interface {
+		// WithTags returns a new handler with the given tags added to the tags of this handler.
+		WithTags(tags map[string]string) Handler
+
+		// Counter returns the counter with the given name.
+		Counter(name string) Counter
+
+		// Gauge returns the gauge with the given name.
+		Gauge(name string) Gauge
+
+		// Timer returns the timer with the given name.
+		Timer(name string) Timer
+	}
+
+	// Counter is the interface for emitting counter type metrics.
+	Counter interface {
+		// Inc increments the counter by a delta.
+		Inc(delta int64)
+	}
+
+	// Gauge is the interface for emitting gauge metrics.
+	Gauge interface {
+		// Update sets the gauges absolute value.
+		Update(value float64)
+	}
+
+	// Timer is the interface for emitting timer metrics.
+	Timer interface {
+		// Record a specific duration.
+		Record(value time.Duration)
+	}
+
+	// HistogramHandler is optionally implemented by a Handler to receive the histograms of values which are not
+	// durations, e.g. sizes in bytes. Without it such histograms are emitted as gauges of the last recorded value.
+	HistogramHandler interface {
+		// Histogram returns the histogram with the given name and bucket upper bounds.
+		Histogram(name string, buckets []float64) Histogram
+	}
+
+	// Histogram is the interface for emitting histograms of values.
+	Histogram interface {
+		// RecordValue records a specific value.
+		RecordValue(value float64)
+	}
+
+	nopHandler struct{}
+
+	handlerScope struct {
+		handler Handler
+		prefix  string
+	}
+
+	handlerTimer struct {
+		timer Timer
+	}
+
+	handlerHistogram struct {
+		timer Timer
+	}
+
+	handlerValueHistogram struct {
+		record func(value float64)
+	}
+
+	handlerCapabilities struct{}
+)
+
+// NopHandler is a Handler that discards all metrics.
+var NopHandler Handler = nopHandler{}
+
+// NewHandlerScope returns tally.Scope which emits all metrics to the handler. Histograms with duration buckets are
+// emitted as timers, values recorded to them are treated as seconds. Histograms with value buckets are emitted as
+// histograms if the handler implements HistogramHandler, as gauges otherwise, with the values unchanged.
+func NewHandlerScope(handler Handler) tally.Scope {
+	return &handlerScope{handler: handler}
+}
+
+func (nopHandler) WithTags(map[string]string) Handler { return nopHandler{} }
+func (nopHandler) Counter(string) Counter             { return nopHandler{} }
+func (nopHandler) Gauge(string) Gauge                 { return nopHandler{} }
+func (nopHandler) Timer(string) Timer                 { return nopHandler{} }
+func (nopHandler) Inc(int64)                          {}
+func (nopHandler) Update(float64)                     {}
+func (nopHandler) Record(time.Duration)               {}
+
+// Counter returns the Counter object corresponding to the name.
+func (s *handlerScope) Counter(name string) tally.Counter {
+	return s.handler.Counter(s.fullName(name))
+}
+
+// Gauge returns the Gauge object corresponding to the name.
+func (s *handlerScope) Gauge(name string) tally.Gauge {
+	return s.handler.Gauge(s.fullName(name))
+}
+
+// Timer returns the Timer object corresponding to the name.
+func (s *handlerScope) Timer(name string) tally.Timer {
+	return &handlerTimer{timer: s.handler.Timer(s.fullName(name))}
+}
+
+// Histogram returns the Histogram object corresponding to the name. Histograms with value buckets are emitted as
+// histograms with these buckets, or as gauges when the handler doesn't support histograms. The other histograms are
+// emitted as timers, ignoring their buckets.
+func (s *handlerScope) Histogram(name string, buckets tally.Buckets) tally.Histogram {
+	values, ok := buckets.(tally.ValueBuckets)
+	if !ok {
+		return &handlerHistogram{timer: s.handler.Timer(s.fullName(name))}
+	}
+	if h, ok := s.handler.(HistogramHandler); ok {
+		return &handlerValueHistogram{record: h.Histogram(s.fullName(name), values).RecordValue}
+	}
+	return &handlerValueHistogram{record: s.handler.Gauge(s.fullName(name)).Update}
+}
+
+// Tagged returns a new child scope with the given tags and current tags.
+func (s *handlerScope) Tagged(tags map[string]string) tally.Scope {
+	return &handlerScope{handler: s.handler.WithTags(tags), prefix: s.prefix}
+}
+
+// SubScope returns a new child scope appending a further name prefix.
+func (s *handlerScope) SubScope(name string) tally.Scope {
+	return &handlerScope{handler: s.handler, prefix: s.fullName(name)}
+}
+
+// Capabilities returns a description of metrics reporting capabilities.
+func (s *handlerScope) Capabilities() tally.Capabilities {
+	return handlerCapabilities{}
+}
+
+func (s *handlerScope) fullName(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return s.prefix + "_" + name
+}
+
+// Record a specific duration.
+func (t *handlerTimer) Record(value time.Duration) {
+	t.timer.Record(value)
+}
+
+// Start gives you back a specific point in time to report via Stop.
+func (t *handlerTimer) Start() tally.Stopwatch {
+	return tally.NewStopwatch(time.Now(), t)
+}
+
+// RecordStopwatch is called when a stopwatch is stopped with Stop().
+func (t *handlerTimer) RecordStopwatch(stopwatchStart time.Time) {
+	t.timer.Record(time.Since(stopwatchStart))
+}
+
+// RecordValue records a specific value directly, the value is treated as seconds.
+func (h *handlerHistogram) RecordValue(value float64) {
+	h.timer.Record(time.Duration(value * float64(time.Second)))
+}
+
+// RecordDuration records a specific duration directly.
+func (h *handlerHistogram) RecordDuration(value time.Duration) {
+	h.timer.Record(value)
+}
+
+// Start gives you back a specific point in time to report via Stop.
+func (h *handlerHistogram) Start() tally.Stopwatch {
+	return tally.NewStopwatch(time.Now(), h)
+}
+
+// RecordStopwatch is called when a stopwatch is stopped with Stop().
+func (h *handlerHistogram) RecordStopwatch(stopwatchStart time.Time) {
+	h.timer.Record(time.Since(stopwatchStart))
+}
+
+// RecordValue records a specific value directly.
+func (h *handlerValueHistogram) RecordValue(value float64) {
+	h.record(value)
+}
+
+// RecordDuration records a specific duration directly, in seconds.
+func (h *handlerValueHistogram) RecordDuration(value time.Duration) {
+	h.record(value.Seconds())
+}
+
+// Start gives you back a specific point in time to report via Stop.
+func (h *handlerValueHistogram) Start() tally.Stopwatch {
+	return tally.NewStopwatch(time.Now(), h)
+}
+
+// RecordStopwatch is called when a stopwatch is stopped with Stop().
+func (h *handlerValueHistogram) RecordStopwatch(stopwatchStart time.Time) {
+	h.record(time.Since(stopwatchStart).Seconds())
+}
+
+// Reporting returns whether the reporter has the ability to actively report.
+func (handlerCapabilities) Reporting() bool {
+	return true
+}
+
+// Tagging returns whether the reporter has the capability for tagged metrics.
+func (handlerCapabilities) Tagging() bool {
+	return true
+}
@@ -27,8 +27,10 @@ package metrics
 import (
 	"context"
 
+	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	"github.com/uber-go/tally"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 // NewGRPCMetricsInterceptor creates new metrics scope interceptor.
@@ -44,8 +46,18 @@ func NewGRPCMetricsInterceptor(defaultScope tally.Scope, metricSuffix string) gr
 		}
 		rs := newRequestScope(scope, method, isLongPoll, metricSuffix)
 		rs.recordStart()
+		if isRetryAttempt(ctx) {
+			rs.recordRetry()
+		}
 		err := invoker(ctx, method, req, reply, cc, opts...)
 		rs.recordEnd(err)
 		return err
 	}
 }
+
+// isRetryAttempt checks if the call is a retry of a previous attempt. The gRPC retry interceptor adds the attempt
+// number to the outgoing metadata of every attempt after the first one.
+func isRetryAttempt(ctx context.Context) bool {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	return ok && len(md.Get(grpc_retry.AttemptMetadataKey)) > 0
+}
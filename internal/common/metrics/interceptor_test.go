@@ -26,14 +26,20 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"io"
 	"testing"
 	"time"
 
+	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber-go/tally"
 	"go.temporal.io/api/serviceerror"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestMetricsInterceptor(t *testing.T) {
@@ -125,6 +131,43 @@ func TestMetricsInterceptor(t *testing.T) {
 
 }
 
+func TestMetricsInterceptorErrorTypeAndRetry(t *testing.T) {
+	isReplay := false
+	scope, closer, reporter := NewMetricsScope(&isReplay)
+	interceptor := NewGRPCMetricsInterceptor(scope, "_attempt")
+
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grpc_retry.AttemptMetadataKey, "1")
+	err := interceptor(ctx, "/workflowservice.WorkflowService/StartWorkflowExecution", nil, nil, nil, invoker)
+	require.Error(t, err)
+
+	// Important: close before assert.
+	require.NoError(t, closer.Close())
+
+	counts := map[string]map[string]string{}
+	for _, counter := range reporter.Counts() {
+		counts[counter.Name()] = counter.Tags()
+	}
+	require.Contains(t, counts, TemporalRequestRetry+"_attempt")
+	require.Contains(t, counts, TemporalRequestFailure+"_attempt")
+	require.Equal(t, codes.Unavailable.String(), counts[TemporalRequestFailure+"_attempt"][ErrorTypeTagName])
+
+	require.NotEmpty(t, reporter.HistogramDurationSamples())
+	for _, h := range reporter.HistogramDurationSamples() {
+		require.Equal(t, TemporalRequestLatencyHistogram+"_attempt", h.name)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	require.Equal(t, codes.NotFound.String(), errorType(status.Error(codes.NotFound, "not found")))
+	require.Equal(t, codes.DeadlineExceeded.String(), errorType(context.DeadlineExceeded))
+	require.Equal(t, codes.Canceled.String(), errorType(context.Canceled))
+	require.Equal(t, codes.Unknown.String(), errorType(errors.New("error")))
+}
+
 func assertMetrics(assert *assert.Assertions, reporter *CapturingStatsReporter, counterNames []string) {
 	assert.Equal(len(counterNames), len(reporter.counts))
 	for _, counterName := range counterNames {
@@ -0,0 +1,361 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+const (
+	prometheusCounterType   = "counter"
+	prometheusGaugeType     = "gauge"
+	prometheusHistogramType = "histogram"
+
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+type (
+	// PrometheusHandlerOptions are optional parameters for NewPrometheusHandler.
+	PrometheusHandlerOptions struct {
+		// TimerBuckets are the upper bounds of histogram buckets used for timers.
+		// Optional: defaults to RequestLatencyBuckets.
+		TimerBuckets []time.Duration
+	}
+
+	// PrometheusHandler is a Handler which keeps metrics in memory and exposes them in Prometheus text exposition
+	// format. It implements http.Handler and can be registered as a scrape endpoint, for example:
+	//  handler := metrics.NewPrometheusHandler(metrics.PrometheusHandlerOptions{})
+	//  http.Handle("/metrics", handler)
+	// Timers are exposed as histograms in seconds.
+	PrometheusHandler struct {
+		registry *prometheusRegistry
+		tags     map[string]string
+	}
+
+	prometheusRegistry struct {
+		sync.Mutex
+		buckets  []float64
+		families map[string]*prometheusFamily
+	}
+
+	prometheusFamily struct {
+		name   string
+		typ    string
+		series map[string]*prometheusSeries
+	}
+
+	prometheusSeries struct {
+		labels  string
+		counter atomic.Int64
+		gauge   atomic.Float64
+
+		sync.Mutex
+		buckets      []float64
+		bucketCounts []uint64
+		sum          float64
+		count        uint64
+	}
+
+	prometheusCounter struct {
+		series *prometheusSeries
+	}
+
+	prometheusGauge struct {
+		series *prometheusSeries
+	}
+
+	prometheusTimer struct {
+		series  *prometheusSeries
+		buckets []float64
+	}
+
+	prometheusHistogram struct {
+		series  *prometheusSeries
+		buckets []float64
+	}
+)
+
+var _ Handler = (*PrometheusHandler)(nil)
+var _ HistogramHandler = (*PrometheusHandler)(nil)
+var _ http.Handler = (*PrometheusHandler)(nil)
+
+// NewPrometheusHandler creates a Handler which exposes metrics in Prometheus text exposition format.
+func NewPrometheusHandler(options PrometheusHandlerOptions) *PrometheusHandler {
+	timerBuckets := options.TimerBuckets
+	if len(timerBuckets) == 0 {
+		timerBuckets = RequestLatencyBuckets
+	}
+	buckets := make([]float64, len(timerBuckets))
+	for i, b := range timerBuckets {
+		buckets[i] = b.Seconds()
+	}
+	sort.Float64s(buckets)
+
+	return &PrometheusHandler{
+		registry: &prometheusRegistry{
+			buckets:  buckets,
+			families: map[string]*prometheusFamily{},
+		},
+	}
+}
+
+// WithTags returns a new handler with the given tags added to the tags of this handler.
+func (h *PrometheusHandler) WithTags(tags map[string]string) Handler {
+	merged := make(map[string]string, len(h.tags)+len(tags))
+	for k, v := range h.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return &PrometheusHandler{registry: h.registry, tags: merged}
+}
+
+// Counter returns the counter with the given name.
+func (h *PrometheusHandler) Counter(name string) Counter {
+	return &prometheusCounter{series: h.registry.series(name, prometheusCounterType, h.tags)}
+}
+
+// Gauge returns the gauge with the given name.
+func (h *PrometheusHandler) Gauge(name string) Gauge {
+	return &prometheusGauge{series: h.registry.series(name, prometheusGaugeType, h.tags)}
+}
+
+// Timer returns the timer with the given name.
+func (h *PrometheusHandler) Timer(name string) Timer {
+	return &prometheusTimer{series: h.registry.series(name, prometheusHistogramType, h.tags), buckets: h.registry.buckets}
+}
+
+// Histogram returns the histogram with the given name and bucket upper bounds.
+func (h *PrometheusHandler) Histogram(name string, buckets []float64) Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &prometheusHistogram{series: h.registry.series(name, prometheusHistogramType, h.tags), buckets: sorted}
+}
+
+// ServeHTTP writes all metrics in Prometheus text exposition format.
+func (h *PrometheusHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	_ = h.Write(w)
+}
+
+// Write writes all metrics in Prometheus text exposition format to the writer.
+func (h *PrometheusHandler) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	h.registry.write(bw)
+	return bw.Flush()
+}
+
+// Inc increments the counter by a delta.
+func (c *prometheusCounter) Inc(delta int64) {
+	c.series.counter.Add(delta)
+}
+
+// Update sets the gauges absolute value.
+func (g *prometheusGauge) Update(value float64) {
+	g.series.gauge.Store(value)
+}
+
+// Record a specific duration.
+func (t *prometheusTimer) Record(value time.Duration) {
+	t.series.observe(value.Seconds(), t.buckets)
+}
+
+// RecordValue records a specific value.
+func (h *prometheusHistogram) RecordValue(value float64) {
+	h.series.observe(value, h.buckets)
+}
+
+// observe records a value in the buckets of the series, which are set by the first value.
+func (s *prometheusSeries) observe(value float64, buckets []float64) {
+	s.Lock()
+	defer s.Unlock()
+	if s.bucketCounts == nil {
+		s.buckets = buckets
+		s.bucketCounts = make([]uint64, len(buckets))
+	}
+	for i, upperBound := range s.buckets {
+		if value <= upperBound {
+			s.bucketCounts[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (r *prometheusRegistry) series(name, typ string, tags map[string]string) *prometheusSeries {
+	name = sanitizePrometheusName(name, true)
+	labels := formatPrometheusLabels(tags)
+
+	r.Lock()
+	defer r.Unlock()
+	key := name
+	if f, ok := r.families[key]; ok && f.typ != typ {
+		// Prometheus doesn't allow different types under the same name.
+		key = name + "_" + typ
+	}
+	f, ok := r.families[key]
+	if !ok {
+		f = &prometheusFamily{name: key, typ: typ, series: map[string]*prometheusSeries{}}
+		r.families[key] = f
+	}
+	s, ok := f.series[labels]
+	if !ok {
+		s = &prometheusSeries{labels: labels}
+		f.series[labels] = s
+	}
+	return s
+}
+
+func (r *prometheusRegistry) write(w *bufio.Writer) {
+	r.Lock()
+	families := make([]*prometheusFamily, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	seriesByFamily := make(map[*prometheusFamily][]*prometheusSeries, len(families))
+	for _, f := range families {
+		series := make([]*prometheusSeries, 0, len(f.series))
+		for _, s := range f.series {
+			series = append(series, s)
+		}
+		sort.Slice(series, func(i, j int) bool { return series[i].labels < series[j].labels })
+		seriesByFamily[f] = series
+	}
+	r.Unlock()
+
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+	for _, f := range families {
+		_, _ = fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+		for _, s := range seriesByFamily[f] {
+			switch f.typ {
+			case prometheusCounterType:
+				_, _ = fmt.Fprintf(w, "%s%s %d\n", f.name, wrapPrometheusLabels(s.labels), s.counter.Load())
+			case prometheusGaugeType:
+				_, _ = fmt.Fprintf(w, "%s%s %s\n", f.name, wrapPrometheusLabels(s.labels), formatPrometheusFloat(s.gauge.Load()))
+			case prometheusHistogramType:
+				r.writeHistogram(w, f.name, s)
+			}
+		}
+	}
+}
+
+func (r *prometheusRegistry) writeHistogram(w *bufio.Writer, name string, s *prometheusSeries) {
+	s.Lock()
+	defer s.Unlock()
+	buckets := s.buckets
+	if buckets == nil {
+		buckets = r.buckets
+	}
+	for i, upperBound := range buckets {
+		var count uint64
+		if s.bucketCounts != nil {
+			count = s.bucketCounts[i]
+		}
+		le := `le="` + formatPrometheusFloat(upperBound) + `"`
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", name, wrapPrometheusLabels(joinPrometheusLabels(s.labels, le)), count)
+	}
+	_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", name, wrapPrometheusLabels(joinPrometheusLabels(s.labels, `le="+Inf"`)), s.count)
+	_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", name, wrapPrometheusLabels(s.labels), formatPrometheusFloat(s.sum))
+	_, _ = fmt.Fprintf(w, "%s_count%s %d\n", name, wrapPrometheusLabels(s.labels), s.count)
+}
+
+// formatPrometheusLabels returns labels sorted by name without surrounding braces.
+func formatPrometheusLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	labels := make([]string, len(names))
+	for i, name := range names {
+		labels[i] = sanitizePrometheusName(name, false) + `="` + escapePrometheusLabelValue(tags[name]) + `"`
+	}
+	return strings.Join(labels, ",")
+}
+
+func joinPrometheusLabels(labels, label string) string {
+	if labels == "" {
+		return label
+	}
+	return labels + "," + label
+}
+
+func wrapPrometheusLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+// sanitizePrometheusName replaces characters that are not allowed in metric or label names with underscores.
+func sanitizePrometheusName(name string, allowColon bool) string {
+	var b strings.Builder
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':' && allowColon:
+			b.WriteRune(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(c)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func escapePrometheusLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatPrometheusFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,119 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestPrometheusHandler(t *testing.T) {
+	handler := NewPrometheusHandler(PrometheusHandlerOptions{
+		TimerBuckets: []time.Duration{time.Second, 100 * time.Millisecond},
+	})
+	scope := NewHandlerScope(handler).Tagged(map[string]string{NamespaceTagName: "default"})
+
+	scope.Counter(TemporalRequest).Inc(1)
+	scope.Counter(TemporalRequest).Inc(2)
+	scope.Tagged(map[string]string{OperationTagName: "Poll\"er"}).Counter(TemporalRequest).Inc(1)
+	scope.Gauge(StickyCacheSize).Update(1.5)
+	scope.Timer(TemporalRequestLatency).Record(50 * time.Millisecond)
+	scope.Histogram(TemporalRequestLatency, nil).RecordDuration(500 * time.Millisecond)
+	scope.SubScope("sub").Counter("a.b").Inc(1)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, prometheusContentType, recorder.Header().Get("Content-Type"))
+
+	expected := strings.Join([]string{
+		`# TYPE sub_a_b counter`,
+		`sub_a_b{namespace="default"} 1`,
+		`# TYPE temporal_request counter`,
+		`temporal_request{namespace="default"} 3`,
+		`temporal_request{namespace="default",operation="Poll\"er"} 1`,
+		`# TYPE temporal_request_latency histogram`,
+		`temporal_request_latency_bucket{namespace="default",le="0.1"} 1`,
+		`temporal_request_latency_bucket{namespace="default",le="1"} 2`,
+		`temporal_request_latency_bucket{namespace="default",le="+Inf"} 2`,
+		`temporal_request_latency_sum{namespace="default"} 0.55`,
+		`temporal_request_latency_count{namespace="default"} 2`,
+		`# TYPE temporal_sticky_cache_size gauge`,
+		`temporal_sticky_cache_size{namespace="default"} 1.5`,
+		``,
+	}, "\n")
+	require.Equal(t, expected, recorder.Body.String())
+}
+
+func TestPrometheusHandlerValueHistogram(t *testing.T) {
+	handler := NewPrometheusHandler(PrometheusHandlerOptions{})
+	scope := NewHandlerScope(handler)
+	scope.Histogram(WorkflowTaskResponseSize, tally.ValueBuckets{1024, 4096}).RecordValue(2000)
+
+	var b strings.Builder
+	require.NoError(t, handler.Write(&b))
+	require.Contains(t, b.String(), strings.Join([]string{
+		`temporal_workflow_task_response_size_bucket{le="1024"} 0`,
+		`temporal_workflow_task_response_size_bucket{le="4096"} 1`,
+		`temporal_workflow_task_response_size_bucket{le="+Inf"} 1`,
+		`temporal_workflow_task_response_size_sum 2000`,
+	}, "\n"))
+}
+
+func TestHandlerScopeValueHistogramWithoutHistogramHandler(t *testing.T) {
+	handler := &testGaugeHandler{}
+	NewHandlerScope(handler).Histogram(WorkflowTaskResponseSize, tally.ValueBuckets{1024}).RecordValue(2000)
+	require.Equal(t, 2000.0, handler.value)
+}
+
+// testGaugeHandler is a Handler without histograms which keeps the last value of its gauges.
+type testGaugeHandler struct {
+	nopHandler
+	value float64
+}
+
+func (h *testGaugeHandler) Gauge(string) Gauge   { return h }
+func (h *testGaugeHandler) Update(value float64) { h.value = value }
+
+func TestPrometheusHandlerTypeConflict(t *testing.T) {
+	handler := NewPrometheusHandler(PrometheusHandlerOptions{})
+	handler.Counter("metric").Inc(1)
+	handler.Gauge("metric").Update(2)
+
+	var b strings.Builder
+	require.NoError(t, handler.Write(&b))
+	require.Contains(t, b.String(), "# TYPE metric counter\nmetric 1\n")
+	require.Contains(t, b.String(), "# TYPE metric_gauge gauge\nmetric_gauge 2\n")
+}
+
+func TestSanitizePrometheusName(t *testing.T) {
+	require.Equal(t, "temporal_request", sanitizePrometheusName("temporal.request", true))
+	require.Equal(t, "_1a:b", sanitizePrometheusName("1a:b", true))
+	require.Equal(t, "a_b", sanitizePrometheusName("a:b", false))
+}
@@ -25,10 +25,12 @@
 package metrics
 
 import (
+	"context"
 	"strings"
 	"time"
 
 	"github.com/uber-go/tally"
+	"google.golang.org/grpc/status"
 )
 
 type (
@@ -42,6 +44,10 @@ type (
 		requestLatencyMetric         string
 		longPollRequestFailureMetric string
 		requestFailureMetric         string
+		longPollRequestRetryMetric   string
+		requestRetryMetric           string
+		longPollRequestHistogram     string
+		requestHistogram             string
 	}
 )
 
@@ -61,6 +67,10 @@ func newRequestScope(scope tally.Scope, method string, isLongPoll bool, suffix s
 		requestLatencyMetric:         TemporalRequestLatency + suffix,
 		longPollRequestFailureMetric: TemporalLongRequestFailure + suffix,
 		requestFailureMetric:         TemporalRequestFailure + suffix,
+		longPollRequestRetryMetric:   TemporalLongRequestRetry + suffix,
+		requestRetryMetric:           TemporalRequestRetry + suffix,
+		longPollRequestHistogram:     TemporalLongRequestLatencyHistogram + suffix,
+		requestHistogram:             TemporalRequestLatencyHistogram + suffix,
 	}
 }
 
@@ -72,22 +82,42 @@ func (rs *requestScope) recordStart() {
 	}
 }
 
+func (rs *requestScope) recordRetry() {
+	if rs.isLongPoll {
+		rs.scope.Counter(rs.longPollRequestRetryMetric).Inc(1)
+	} else {
+		rs.scope.Counter(rs.requestRetryMetric).Inc(1)
+	}
+}
+
 func (rs *requestScope) recordEnd(err error) {
+	latency := time.Since(rs.startTime)
 	if rs.isLongPoll {
-		rs.scope.Timer(rs.longPollRequestLatencyMetric).Record(time.Since(rs.startTime))
+		rs.scope.Timer(rs.longPollRequestLatencyMetric).Record(latency)
+		rs.scope.Histogram(rs.longPollRequestHistogram, RequestLatencyBuckets).RecordDuration(latency)
 	} else {
-		rs.scope.Timer(rs.requestLatencyMetric).Record(time.Since(rs.startTime))
+		rs.scope.Timer(rs.requestLatencyMetric).Record(latency)
+		rs.scope.Histogram(rs.requestHistogram, RequestLatencyBuckets).RecordDuration(latency)
 	}
 
 	if err != nil {
+		errorScope := rs.scope.Tagged(map[string]string{ErrorTypeTagName: errorType(err)})
 		if rs.isLongPoll {
-			rs.scope.Counter(rs.longPollRequestFailureMetric).Inc(1)
+			errorScope.Counter(rs.longPollRequestFailureMetric).Inc(1)
 		} else {
-			rs.scope.Counter(rs.requestFailureMetric).Inc(1)
+			errorScope.Counter(rs.requestFailureMetric).Inc(1)
 		}
 	}
 }
 
+// errorType returns the name of the gRPC status code of the error.
+func errorType(err error) string {
+	if err == context.DeadlineExceeded || err == context.Canceled {
+		return status.FromContextError(err).Code().String()
+	}
+	return status.Code(err).String()
+}
+
 // ConvertMethodToScope extracts API name from the method string by truncating the prefix
 func ConvertMethodToScope(method string) string {
 	// method is something like "/temporal.api.workflowservice.v1.WorkflowService/RegisterNamespace"
@@ -0,0 +1,79 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/backoffutils"
+)
+
+type (
+	// BackoffStrategy randomizes the delays between the retries computed by an exponential retry policy, to avoid
+	// the synchronized retries of many clients, the thundering herd, after a failure of the server.
+	BackoffStrategy interface {
+		// NextDelay returns the delay before the retry, given the number of the retry starting with 1 and the delay
+		// computed by the retry policy, already capped by its maximum interval.
+		NextDelay(retry int, delay time.Duration) time.Duration
+	}
+
+	// BackoffStrategyFunc is an adapter to use a function as a BackoffStrategy.
+	BackoffStrategyFunc func(retry int, delay time.Duration) time.Duration
+
+	jitterBackoff struct {
+		jitter float64
+	}
+
+	fullJitterBackoff struct{}
+)
+
+// NextDelay calls f(retry, delay).
+func (f BackoffStrategyFunc) NextDelay(retry int, delay time.Duration) time.Duration {
+	return f(retry, delay)
+}
+
+// NewJitterBackoff returns a BackoffStrategy randomizing each delay by up to the jitter fraction in both directions,
+// e.g. a delay of 1s with a jitter of 0.2 becomes a delay between 800ms and 1.2s.
+func NewJitterBackoff(jitter float64) BackoffStrategy {
+	return jitterBackoff{jitter: jitter}
+}
+
+// NewFullJitterBackoff returns a BackoffStrategy replacing each delay with a random delay between zero and the delay,
+// which spreads the retries of many clients the most.
+func NewFullJitterBackoff() BackoffStrategy {
+	return fullJitterBackoff{}
+}
+
+func (b jitterBackoff) NextDelay(_ int, delay time.Duration) time.Duration {
+	return backoffutils.JitterUp(delay, b.jitter)
+}
+
+func (fullJitterBackoff) NextDelay(_ int, delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
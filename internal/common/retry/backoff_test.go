@@ -0,0 +1,66 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullJitterBackoff(t *testing.T) {
+	t.Parallel()
+	b := NewFullJitterBackoff()
+	for i := 0; i < 100; i++ {
+		delay := b.NextDelay(1, time.Second)
+		assert.True(t, delay >= 0 && delay <= time.Second, delay)
+	}
+	assert.Equal(t, time.Duration(0), b.NextDelay(1, 0))
+}
+
+func TestJitterBackoff(t *testing.T) {
+	t.Parallel()
+	b := NewJitterBackoff(0.2)
+	for i := 0; i < 100; i++ {
+		delay := b.NextDelay(1, time.Second)
+		assert.True(t, delay >= 800*time.Millisecond && delay <= 1200*time.Millisecond, delay)
+	}
+}
+
+func TestBackoff_Strategy(t *testing.T) {
+	t.Parallel()
+	var retries []int
+	config := NewGrpcRetryConfig(time.Second).withOptions(&ServiceRetryOptions{
+		BackoffStrategy: BackoffStrategyFunc(func(retry int, delay time.Duration) time.Duration {
+			retries = append(retries, retry)
+			return delay / 2
+		}),
+	})
+	assert.Equal(t, time.Second, config.backoff(1, &attemptsState{}))
+	// A retry delay requested by the server is still respected.
+	assert.Equal(t, 3*time.Second, config.backoff(1, &attemptsState{retryAfter: 3 * time.Second}))
+	assert.Equal(t, []int{1, 1}, retries)
+}
@@ -29,6 +29,8 @@ import (
 	"math"
 	"time"
 
+	"github.com/gogo/googleapis/google/rpc"
+	"github.com/gogo/protobuf/types"
 	"github.com/gogo/status"
 	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	"github.com/grpc-ecosystem/go-grpc-middleware/util/backoffutils"
@@ -52,6 +54,11 @@ const (
 	DefaultMaximumAttempts = UnlimitedMaximumAttempts
 	// DefaultJitter is a default jitter applied on the backoff interval for delay randomization.
 	DefaultJitter = 0.2
+	// DefaultServerBusyInitialInterval is default minimum delay before retrying a call rejected by an overloaded server,
+	// i.e. with the RESOURCE_EXHAUSTED code.
+	DefaultServerBusyInitialInterval = time.Second
+	// DefaultServerBusyMaximumInterval is default maximum delay between retries of calls rejected by an overloaded server.
+	DefaultServerBusyMaximumInterval = 30 * time.Second
 )
 
 type (
@@ -63,9 +70,62 @@ type (
 		expirationInterval time.Duration
 		jitter             float64
 		maximumAttempts    int
+
+		serverBusyInitialInterval time.Duration
+		serverBusyMaximumInterval time.Duration
+		retryableCodes            []codes.Code
+		backoffStrategy           BackoffStrategy
+	}
+
+	// ServiceRetryOptions customize the retries of the calls made to the server. Zero fields keep the default values.
+	ServiceRetryOptions struct {
+		// InitialInterval is the delay before the first retry.
+		// default: 200ms
+		InitialInterval time.Duration
+
+		// BackoffCoefficient is the rate at which the delay between retries grows.
+		// default: 2
+		BackoffCoefficient float64
+
+		// MaximumInterval is the maximum delay between retries.
+		// default: a tenth of the time left before the deadline of the call
+		MaximumInterval time.Duration
+
+		// MaximumAttempts is the maximum number of attempts of a call, including the first one.
+		// default: unlimited, until the deadline of the call
+		MaximumAttempts int
+
+		// NonRetryableCodes are the gRPC codes which are not retried even though they are retried by default. The codes
+		// retried by default are ABORTED, DEADLINE_EXCEEDED, INTERNAL, RESOURCE_EXHAUSTED, UNAVAILABLE and UNKNOWN.
+		// default: nil
+		NonRetryableCodes []codes.Code
+
+		// ServerBusyInitialInterval is the minimum delay before retrying a call rejected by an overloaded server with
+		// the RESOURCE_EXHAUSTED code. The minimum grows with BackoffCoefficient while the server keeps rejecting the
+		// call. A retry delay requested by the server with RetryInfo error details is always respected.
+		// default: 1s
+		ServerBusyInitialInterval time.Duration
+
+		// ServerBusyMaximumInterval is the maximum delay between retries of calls rejected by an overloaded server.
+		// default: 30s
+		ServerBusyMaximumInterval time.Duration
+
+		// BackoffStrategy randomizes the delays between retries, e.g. NewFullJitterBackoff to spread the retries of
+		// many clients after the server recovers. It also applies to the poll retries and the local activity retries
+		// of the workers created with the client.
+		// default: the delays are randomized by up to 20%
+		BackoffStrategy BackoffStrategy
 	}
 
 	contextKey struct{}
+
+	attemptsContextKey struct{}
+
+	// attemptsState collects the throttling signals of the failed attempts of a call.
+	attemptsState struct {
+		serverBusyAttempts int
+		retryAfter         time.Duration
+	}
 )
 
 func (ck contextKey) String() string {
@@ -108,9 +168,74 @@ func NewGrpcRetryConfig(initialInterval time.Duration) *GrpcRetryConfig {
 		expirationInterval: DefaultExpirationInterval,
 		jitter:             DefaultJitter,
 		maximumAttempts:    DefaultMaximumAttempts,
+
+		serverBusyInitialInterval: DefaultServerBusyInitialInterval,
+		serverBusyMaximumInterval: DefaultServerBusyMaximumInterval,
+		retryableCodes:            retryableCodes,
 	}
 }
 
+// withOptions returns a copy of the config with the values set in the options.
+func (g *GrpcRetryConfig) withOptions(options *ServiceRetryOptions) *GrpcRetryConfig {
+	config := *g
+	if options == nil {
+		return &config
+	}
+	if options.InitialInterval > 0 {
+		config.initialInterval = options.InitialInterval
+	}
+	if options.BackoffCoefficient > 0 {
+		config.backoffCoefficient = options.BackoffCoefficient
+	}
+	if options.MaximumInterval > 0 {
+		config.maximumInterval = options.MaximumInterval
+	}
+	if options.MaximumAttempts > 0 {
+		config.maximumAttempts = options.MaximumAttempts
+	}
+	if options.ServerBusyInitialInterval > 0 {
+		config.serverBusyInitialInterval = options.ServerBusyInitialInterval
+	}
+	if options.ServerBusyMaximumInterval > 0 {
+		config.serverBusyMaximumInterval = options.ServerBusyMaximumInterval
+	}
+	if options.BackoffStrategy != nil {
+		config.backoffStrategy = options.BackoffStrategy
+	}
+	if len(options.NonRetryableCodes) > 0 {
+		config.retryableCodes = nil
+		for _, code := range retryableCodes {
+			if !containsCode(options.NonRetryableCodes, code) {
+				config.retryableCodes = append(config.retryableCodes, code)
+			}
+		}
+	}
+	return &config
+}
+
+// backoff computes the delay before the attempt using the throttling signals of the previous failed attempts.
+func (g *GrpcRetryConfig) backoff(attempt uint, state *attemptsState) time.Duration {
+	next := float64(g.initialInterval) * math.Pow(g.backoffCoefficient, float64(attempt))
+	if g.maximumInterval != UnlimitedInterval {
+		next = math.Min(next, float64(g.maximumInterval))
+	}
+	if state.serverBusyAttempts > 0 {
+		serverBusy := float64(g.serverBusyInitialInterval) * math.Pow(g.backoffCoefficient, float64(state.serverBusyAttempts-1))
+		serverBusy = math.Min(serverBusy, float64(g.serverBusyMaximumInterval))
+		next = math.Max(next, serverBusy)
+	}
+	var delay time.Duration
+	if g.backoffStrategy != nil {
+		delay = g.backoffStrategy.NextDelay(int(attempt), time.Duration(next))
+	} else {
+		delay = backoffutils.JitterUp(time.Duration(next), g.jitter)
+	}
+	if delay < state.retryAfter {
+		delay = state.retryAfter
+	}
+	return delay
+}
+
 var (
 	// ConfigKey context key for GrpcRetryConfig
 	ConfigKey = contextKey{}
@@ -122,22 +247,21 @@ var (
 )
 
 // NewRetryOptionsInterceptor creates a new gRPC interceptor that populates retry options for each call based on values
-// provided in the context.
-func NewRetryOptionsInterceptor() grpc.UnaryClientInterceptor {
+// provided in the context and the options, which take precedence over the context values when set.
+func NewRetryOptionsInterceptor(options *ServiceRetryOptions) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		if rc, ok := ctx.Value(ConfigKey).(*GrpcRetryConfig); ok {
+			rc = rc.withOptions(options)
 			if _, ok := ctx.Deadline(); !ok {
 				deadlineCtx, cancel := context.WithDeadline(ctx, time.Now().Add(rc.expirationInterval))
 				defer cancel()
 				ctx = deadlineCtx
 			}
+			state := &attemptsState{}
+			ctx = context.WithValue(ctx, attemptsContextKey{}, state)
 			// Populate backoff function, which provides retrier with the delay for each attempt.
 			opts = append(opts, grpc_retry.WithBackoff(func(attempt uint) time.Duration {
-				next := float64(rc.initialInterval) * math.Pow(rc.backoffCoefficient, float64(attempt))
-				if rc.maximumInterval != UnlimitedInterval {
-					next = math.Min(next, float64(rc.maximumInterval))
-				}
-				return backoffutils.JitterUp(time.Duration(next), rc.jitter)
+				return rc.backoff(attempt, state)
 			}))
 			// Max attempts is a required parameter in grpc retry interceptor,
 			// if it's set to zero then no retries will be made.
@@ -149,7 +273,7 @@ func NewRetryOptionsInterceptor() grpc.UnaryClientInterceptor {
 			// We have to deal with plain gRPC error codes instead of service errors here as actual error translation
 			// happens after invoker is called below and invoker must have correct retry options right away in order to
 			// supply them to the gRPC retrier.
-			opts = append(opts, grpc_retry.WithCodes(retryableCodes...))
+			opts = append(opts, grpc_retry.WithCodes(rc.retryableCodes...))
 		} else {
 			// Do not retry if retry config is not set.
 			opts = append(opts, grpc_retry.Disable())
@@ -158,6 +282,50 @@ func NewRetryOptionsInterceptor() grpc.UnaryClientInterceptor {
 	}
 }
 
+// NewServerThrottlingInterceptor creates a new gRPC interceptor that collects the throttling signals of the server,
+// the RESOURCE_EXHAUSTED code and the retry delay of RetryInfo error details, from every failed attempt of a call.
+// The signals are used to compute the delay before the next attempt, so the interceptor must be chained after the
+// retry interceptor.
+func NewServerThrottlingInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if state, ok := ctx.Value(attemptsContextKey{}).(*attemptsState); ok {
+			state.record(err)
+		}
+		return err
+	}
+}
+
+func (s *attemptsState) record(err error) {
+	s.retryAfter = 0
+	if err == nil {
+		s.serverBusyAttempts = 0
+		return
+	}
+	st := status.Convert(err)
+	if st.Code() == codes.ResourceExhausted {
+		s.serverBusyAttempts++
+	} else {
+		s.serverBusyAttempts = 0
+	}
+	for _, detail := range st.Details() {
+		if retryInfo, ok := detail.(*rpc.RetryInfo); ok && retryInfo.GetRetryDelay() != nil {
+			if retryAfter, err := types.DurationFromProto(retryInfo.GetRetryDelay()); err == nil {
+				s.retryAfter = retryAfter
+			}
+		}
+	}
+}
+
+func containsCode(list []codes.Code, code codes.Code) bool {
+	for _, c := range list {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
 // IsStatusCodeRetryable returns true if error code in the status is retryable.
 func IsStatusCodeRetryable(status *status.Status) bool {
 	for _, retryable := range retryableCodes {
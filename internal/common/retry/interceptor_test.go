@@ -0,0 +1,121 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gogo/googleapis/google/rpc"
+	"github.com/gogo/protobuf/types"
+	"github.com/gogo/status"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestWithOptions(t *testing.T) {
+	t.Parallel()
+	config := NewGrpcRetryConfig(200 * time.Millisecond)
+
+	assert.Equal(t, config, config.withOptions(nil))
+
+	overridden := config.withOptions(&ServiceRetryOptions{
+		InitialInterval:           time.Second,
+		MaximumAttempts:           3,
+		NonRetryableCodes:         []codes.Code{codes.DeadlineExceeded, codes.Unknown},
+		ServerBusyInitialInterval: 5 * time.Second,
+	})
+	assert.Equal(t, time.Second, overridden.initialInterval)
+	assert.Equal(t, 3, overridden.maximumAttempts)
+	assert.Equal(t, 5*time.Second, overridden.serverBusyInitialInterval)
+	assert.Equal(t, DefaultBackoffCoefficient, overridden.backoffCoefficient)
+	assert.Equal(t, DefaultServerBusyMaximumInterval, overridden.serverBusyMaximumInterval)
+	assert.Equal(t, []codes.Code{codes.Aborted, codes.Internal, codes.ResourceExhausted, codes.Unavailable},
+		overridden.retryableCodes)
+	// The original config is not modified.
+	assert.Equal(t, 200*time.Millisecond, config.initialInterval)
+	assert.Equal(t, retryableCodes, config.retryableCodes)
+}
+
+func TestBackoff_ServerBusy(t *testing.T) {
+	t.Parallel()
+	config := NewGrpcRetryConfig(100 * time.Millisecond)
+	config.SetJitter(0)
+	state := &attemptsState{}
+
+	state.record(status.New(codes.Unavailable, "unavailable").Err())
+	assert.Equal(t, 200*time.Millisecond, config.backoff(1, state))
+
+	state.record(status.New(codes.ResourceExhausted, "busy").Err())
+	assert.Equal(t, DefaultServerBusyInitialInterval, config.backoff(2, state))
+	state.record(status.New(codes.ResourceExhausted, "busy").Err())
+	assert.Equal(t, 2*DefaultServerBusyInitialInterval, config.backoff(3, state))
+	for i := 0; i < 10; i++ {
+		state.record(status.New(codes.ResourceExhausted, "busy").Err())
+	}
+	assert.Equal(t, DefaultServerBusyMaximumInterval, config.backoff(4, state))
+
+	state.record(status.New(codes.Unavailable, "unavailable").Err())
+	assert.Equal(t, 1600*time.Millisecond, config.backoff(4, state))
+}
+
+func TestBackoff_RetryInfo(t *testing.T) {
+	t.Parallel()
+	config := NewGrpcRetryConfig(100 * time.Millisecond)
+	config.SetJitter(0)
+	state := &attemptsState{}
+
+	st, err := status.New(codes.Unavailable, "unavailable").WithDetails(&rpc.RetryInfo{RetryDelay: types.DurationProto(3 * time.Second)})
+	assert.NoError(t, err)
+	state.record(st.Err())
+	assert.Equal(t, 3*time.Second, config.backoff(1, state))
+
+	state.record(errors.New("no status"))
+	assert.Equal(t, 200*time.Millisecond, config.backoff(1, state))
+}
+
+func TestServerThrottlingInterceptor(t *testing.T) {
+	t.Parallel()
+	state := &attemptsState{}
+	ctx := context.WithValue(context.Background(), attemptsContextKey{}, state)
+	interceptor := NewServerThrottlingInterceptor()
+
+	err := interceptor(ctx, "method", "request", "reply", nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return status.New(codes.ResourceExhausted, "busy").Err()
+		})
+	assert.Error(t, err)
+	assert.Equal(t, 1, state.serverBusyAttempts)
+
+	err = interceptor(ctx, "method", "request", "reply", nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, state.serverBusyAttempts)
+}
@@ -26,11 +26,21 @@ package serializer
 
 import (
 	"bytes"
+	"sync"
 
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
 )
 
+// maxPooledBufferSize is the capacity above which encoding buffers are not returned to the pool.
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
 type (
 	// JSONPBEncoder is JSON encoder/decoder for protobuf structs and slices of protobuf structs.
 	// This is an wrapper on top of jsonpb.Marshaler which supports not only single object serialization
@@ -59,12 +69,31 @@ func NewJSONPBIndentEncoder(indent string) *JSONPBEncoder {
 
 // Encode protobuf struct to bytes.
 func (e *JSONPBEncoder) Encode(pb proto.Message) ([]byte, error) {
-	var buf bytes.Buffer
-	err := e.marshaler.Marshal(&buf, pb)
-	return buf.Bytes(), err
+	return encodeWithPooledBuffer(func(buf *bytes.Buffer) error {
+		return e.marshaler.Marshal(buf, pb)
+	})
 }
 
 // Decode bytes to protobuf struct.
 func (e *JSONPBEncoder) Decode(data []byte, pb proto.Message) error {
 	return e.unmarshaler.Unmarshal(bytes.NewReader(data), pb)
 }
+
+// encodeWithPooledBuffer encodes into a buffer from the pool and returns a copy of the encoded bytes, which remains
+// valid after the buffer is returned to the pool.
+func encodeWithPooledBuffer(encode func(buf *bytes.Buffer) error) ([]byte, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := encode(buf); err != nil {
+		return nil, err
+	}
+	data := make([]byte, buf.Len())
+	copy(data, buf.Bytes())
+	return data, nil
+}
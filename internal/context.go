@@ -203,15 +203,91 @@ func WithCancel(parent Context) (ctx Context, cancel CancelFunc) {
 //  err := workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, &activityFooResult)
 //  if err != nil && temporal.IsCanceledError(ctx.Err()) {
 //    // activity failed, and workflow context is canceled
-//    disconnectedCtx, _ := workflow.newDisconnectedContext(ctx);
+//    disconnectedCtx, _ := workflow.NewDisconnectedContext(ctx)
 //    workflow.ExecuteActivity(disconnectedCtx, handleCancellationActivity).Get(disconnectedCtx, nil)
 //    return err // workflow return CanceledError
 //  }
+//
+// The new context keeps the values of the parent, including the activity, child workflow and local activity options,
+// so the cleanup work is scheduled like the work of the parent. It is canceled only when the returned cancel function
+// is called, in which case the contexts derived from it are canceled as well. The parent keeps being canceled: code
+// using the parent context after the workflow is canceled still gets a CanceledError. Activities, timers and child
+// workflows which were started with the parent context are canceled with it, even when awaited with the new context.
 func NewDisconnectedContext(parent Context) (ctx Context, cancel CancelFunc) {
 	c := newCancelCtx(parent)
 	return c, func() { c.cancel(true, ErrCanceled) }
 }
 
+// CancellationScope groups the operations started by the code it runs so that they can be canceled together. Nested
+// scopes are canceled with their parent scope, except detached ones.
+type CancellationScope interface {
+	// Context returns the context of the scope. Activities, timers, child workflows and coroutines started with the
+	// context belong to the scope.
+	Context() Context
+
+	// Run runs f with the context of the scope and returns its error.
+	Run(f func(ctx Context) error) error
+
+	// Go starts a coroutine running f with the context of the scope.
+	Go(f func(ctx Context))
+
+	// Cancel cancels the scope, its nested scopes and the operations they started.
+	Cancel()
+
+	// IsCanceled returns true if the scope was canceled, directly or through a parent scope.
+	IsCanceled() bool
+}
+
+type cancellationScopeImpl struct {
+	ctx    Context
+	cancel CancelFunc
+}
+
+// NewCancellationScope creates a scope nested in the scope of ctx, canceled when ctx is canceled.
+//  scope := workflow.NewCancellationScope(ctx)
+//  scope.Go(func(ctx workflow.Context) {
+//    _ = workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, nil)
+//  })
+//  _ = workflow.Sleep(ctx, time.Minute)
+//  scope.Cancel() // cancels ActivityFoo if it is still running
+func NewCancellationScope(ctx Context) CancellationScope {
+	scopeCtx, cancel := WithCancel(ctx)
+	return &cancellationScopeImpl{ctx: scopeCtx, cancel: cancel}
+}
+
+// NewDetachedCancellationScope creates a scope which is not canceled when ctx is canceled, only by its own Cancel.
+// Use it to run cleanup logic after the workflow is canceled.
+//  err := workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, nil)
+//  if temporal.IsCanceledError(err) {
+//    return workflow.NewDetachedCancellationScope(ctx).Run(func(ctx workflow.Context) error {
+//      return workflow.ExecuteActivity(ctx, CleanupFoo).Get(ctx, nil)
+//    })
+//  }
+func NewDetachedCancellationScope(ctx Context) CancellationScope {
+	scopeCtx, cancel := NewDisconnectedContext(ctx)
+	return &cancellationScopeImpl{ctx: scopeCtx, cancel: cancel}
+}
+
+func (s *cancellationScopeImpl) Context() Context {
+	return s.ctx
+}
+
+func (s *cancellationScopeImpl) Run(f func(ctx Context) error) error {
+	return f(s.ctx)
+}
+
+func (s *cancellationScopeImpl) Go(f func(ctx Context)) {
+	Go(s.ctx, f)
+}
+
+func (s *cancellationScopeImpl) Cancel() {
+	s.cancel()
+}
+
+func (s *cancellationScopeImpl) IsCanceled() bool {
+	return s.ctx.Err() == ErrCanceled
+}
+
 // newCancelCtx returns an initialized cancelCtx.
 func newCancelCtx(parent Context) *cancelCtx {
 	return &cancelCtx{
@@ -0,0 +1,137 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// DeterministicKeys returns the keys of the map m sorted in ascending order. The result is a slice of the key type of
+// m, e.g. []string for map[string]int, and needs to be type asserted by the caller:
+//  keys := workflow.DeterministicKeys(m).([]string)
+// Keys must be of a boolean, numeric or string kind. DeterministicKeys panics if m is not such a map.
+func DeterministicKeys(m interface{}) interface{} {
+	mapValue, entries := sortedMapEntries("DeterministicKeys", m)
+	result := reflect.MakeSlice(reflect.SliceOf(mapValue.Type().Key()), len(entries), len(entries))
+	for i, entry := range entries {
+		result.Index(i).Set(entry.key)
+	}
+	return result.Interface()
+}
+
+// DeterministicStringKeys is DeterministicKeys for maps with keys of a string kind.
+func DeterministicStringKeys(m interface{}) []string {
+	mapValue, entries := sortedMapEntries("DeterministicStringKeys", m)
+	if mapValue.Type().Key().Kind() != reflect.String {
+		panic(fmt.Sprintf("DeterministicStringKeys: expected a map with string keys, got %T", m))
+	}
+	result := make([]string, len(entries))
+	for i, entry := range entries {
+		result[i] = entry.key.String()
+	}
+	return result
+}
+
+// DeterministicMapRange calls f for every key and value of the map m in ascending key order. Unlike the range
+// statement, the order is the same on every run, which is required for code that starts activities, timers or
+// other commands while iterating over a map. f must be a function taking the key and the value of m, e.g.
+// func(string, int) for map[string]int. If f also returns a bool, the iteration stops when it returns false:
+//  workflow.DeterministicMapRange(m, func(name string, count int) bool {
+//      ...
+//      return true
+//  })
+// Keys must be of a boolean, numeric or string kind. NaN keys come first, in no particular order among themselves.
+// DeterministicMapRange panics if m or f don't match.
+func DeterministicMapRange(m interface{}, f interface{}) {
+	mapValue, entries := sortedMapEntries("DeterministicMapRange", m)
+	fnValue := reflect.ValueOf(f)
+	if err := validateMapRangeFunction(mapValue.Type(), fnValue); err != nil {
+		panic(fmt.Sprintf("DeterministicMapRange: %v", err))
+	}
+	for _, entry := range entries {
+		result := fnValue.Call([]reflect.Value{entry.key, entry.value})
+		if len(result) == 1 && !result[0].Bool() {
+			return
+		}
+	}
+}
+
+func validateMapRangeFunction(mapType reflect.Type, fnValue reflect.Value) error {
+	if fnValue.Kind() != reflect.Func {
+		return fmt.Errorf("expected a function, got %v", fnValue.Kind())
+	}
+	fnType := fnValue.Type()
+	if fnType.NumIn() != 2 ||
+		!mapType.Key().AssignableTo(fnType.In(0)) ||
+		!mapType.Elem().AssignableTo(fnType.In(1)) {
+		return fmt.Errorf("expected a function taking (%v, %v), got %v", mapType.Key(), mapType.Elem(), fnType)
+	}
+	if fnType.NumOut() > 1 || (fnType.NumOut() == 1 && fnType.Out(0).Kind() != reflect.Bool) {
+		return fmt.Errorf("expected a function returning nothing or bool, got %v", fnType)
+	}
+	return nil
+}
+
+// mapEntry is a key of a map with its value. The value is collected together with the key, as a NaN key can't be
+// looked up in the map.
+type mapEntry struct {
+	key   reflect.Value
+	value reflect.Value
+}
+
+func sortedMapEntries(caller string, m interface{}) (reflect.Value, []mapEntry) {
+	mapValue := reflect.ValueOf(m)
+	if mapValue.Kind() != reflect.Map {
+		panic(fmt.Sprintf("%s: expected a map, got %T", caller, m))
+	}
+	entries := make([]mapEntry, 0, mapValue.Len())
+	for iter := mapValue.MapRange(); iter.Next(); {
+		entries = append(entries, mapEntry{key: iter.Key(), value: iter.Value()})
+	}
+	var less func(a, b reflect.Value) bool
+	switch mapValue.Type().Key().Kind() {
+	case reflect.Bool:
+		less = func(a, b reflect.Value) bool { return !a.Bool() && b.Bool() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		// NaN keys are never equal to each other, order them first so that the order of the other keys is defined.
+		less = func(a, b reflect.Value) bool {
+			af, bf := a.Float(), b.Float()
+			return af < bf || (math.IsNaN(af) && !math.IsNaN(bf))
+		}
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
+	default:
+		panic(fmt.Sprintf("%s: unsupported map key type %v", caller, mapValue.Type().Key()))
+	}
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i].key, entries[j].key) })
+	return mapValue, entries
+}
@@ -0,0 +1,93 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMapKey string
+
+func TestDeterministicKeys(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, DeterministicKeys(map[string]int{"c": 3, "a": 1, "b": 2}))
+	assert.Equal(t, []int64{-5, 0, 7}, DeterministicKeys(map[int64]bool{7: true, -5: true, 0: false}))
+	assert.Equal(t, []uint8{1, 2}, DeterministicKeys(map[uint8]string{2: "", 1: ""}))
+	assert.Equal(t, []bool{false, true}, DeterministicKeys(map[bool]int{true: 1, false: 0}))
+	assert.Equal(t, []testMapKey{"x", "y"}, DeterministicKeys(map[testMapKey]int{"y": 1, "x": 2}))
+	assert.Equal(t, []string{}, DeterministicKeys(map[string]int{}))
+
+	floatKeys := DeterministicKeys(map[float64]int{2.5: 1, math.NaN(): 2, -1: 3}).([]float64)
+	assert.Equal(t, 3, len(floatKeys))
+	assert.True(t, math.IsNaN(floatKeys[0]))
+	assert.Equal(t, []float64{-1, 2.5}, floatKeys[1:])
+
+	assert.Panics(t, func() { DeterministicKeys([]string{"a"}) })
+	assert.Panics(t, func() { DeterministicKeys(map[struct{}]int{{}: 1}) })
+}
+
+func TestDeterministicStringKeys(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, DeterministicStringKeys(map[string]struct{}{"b": {}, "a": {}}))
+	assert.Equal(t, []string{"x", "y"}, DeterministicStringKeys(map[testMapKey]int{"y": 1, "x": 2}))
+	assert.Panics(t, func() { DeterministicStringKeys(map[int]int{}) })
+}
+
+func TestDeterministicMapRange(t *testing.T) {
+	m := map[string]int{"d": 4, "b": 2, "a": 1, "c": 3}
+
+	var keys []string
+	var values []int
+	DeterministicMapRange(m, func(k string, v int) {
+		keys = append(keys, k)
+		values = append(values, v)
+	})
+	assert.Equal(t, []string{"a", "b", "c", "d"}, keys)
+	assert.Equal(t, []int{1, 2, 3, 4}, values)
+
+	keys = nil
+	DeterministicMapRange(m, func(k string, v interface{}) bool {
+		keys = append(keys, k)
+		return k != "b"
+	})
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	var floatKeys []float64
+	values = nil
+	DeterministicMapRange(map[float64]int{2.5: 1, math.NaN(): 2, -1: 3}, func(k float64, v int) {
+		floatKeys = append(floatKeys, k)
+		values = append(values, v)
+	})
+	assert.Equal(t, 3, len(floatKeys))
+	assert.True(t, math.IsNaN(floatKeys[0]))
+	assert.Equal(t, []float64{-1, 2.5}, floatKeys[1:])
+	assert.Equal(t, []int{2, 3, 1}, values)
+
+	assert.Panics(t, func() { DeterministicMapRange(m, "not a function") })
+	assert.Panics(t, func() { DeterministicMapRange(m, func(k int, v int) {}) })
+	assert.Panics(t, func() { DeterministicMapRange(m, func(k string) {}) })
+	assert.Panics(t, func() { DeterministicMapRange(m, func(k string, v int) int { return 0 }) })
+}
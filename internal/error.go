@@ -56,7 +56,8 @@ Below are the possible types of internal error:
 	returning NewCancelError() it would supply optional details which could be extracted by workflow code.
 3) *TimeoutError:
 	If activity was timed out (several timeout types), internal error will be an instance of *TimeoutError. The err contains
-	details about what type of timeout it was.
+	details about what type of timeout it was and the last heartbeat details recorded by the activity, which can be
+	extracted with Details() to resume the work from the last checkpoint.
 4) *PanicError:
 	If activity code panic while executing, temporal activity worker will report it as activity failure to temporal server.
 	The SDK will present that failure as *PanicError. The err contains a string	representation of the panic message and
@@ -153,8 +154,9 @@ type (
 	// PanicError contains information about panicked workflow/activity.
 	PanicError struct {
 		temporalError
-		value      interface{}
-		stackTrace string
+		value        interface{}
+		stackTrace   string
+		nonRetryable bool
 	}
 
 	// workflowPanicError contains information about panicked workflow.
@@ -174,11 +176,71 @@ type (
 		WorkflowExecutionTimeout time.Duration
 		WorkflowRunTimeout       time.Duration
 		WorkflowTaskTimeout      time.Duration
+		// Memo and SearchAttributes of the new run. The ones of the current run are carried over when nil.
+		Memo             *commonpb.Memo
+		SearchAttributes *commonpb.SearchAttributes
+	}
+
+	// ContinueAsNewOptions overrides the options of the new run created by NewContinueAsNewErrorWithOptions. Each
+	// option that is not set is inherited from the workflow context, like with NewContinueAsNewError.
+	ContinueAsNewOptions struct {
+		// TaskQueue of the new run.
+		// Optional: the task queue of the workflow context will be used if this is not provided.
+		TaskQueue string
+
+		// WorkflowRunTimeout of the new run.
+		// Optional: the run timeout of the workflow context will be used if this is not provided.
+		WorkflowRunTimeout time.Duration
+
+		// WorkflowTaskTimeout of the new run.
+		// Optional: the workflow task timeout of the workflow context will be used if this is not provided.
+		WorkflowTaskTimeout time.Duration
+
+		// Memo of the new run, replacing the memo of the current run.
+		// Optional: the memo of the current run will be carried over if this is not provided.
+		Memo map[string]interface{}
+
+		// SearchAttributes of the new run, replacing the search attributes of the current run.
+		// Optional: the search attributes of the current run will be carried over if this is not provided.
+		SearchAttributes map[string]interface{}
+
+		// CarryOverSignals drains the signals the current run received but has not handled yet, and delivers them to
+		// the signal channels of the new run before its workflow function starts, so that they are not lost at the
+		// continue-as-new boundary. The signals keep their order within a signal name. Optional.
+		CarryOverSignals bool
+
+		// TransformCarriedOverSignal is called for each signal carried over with CarryOverSignals. It returns the
+		// signal to deliver to the new run, or false to drop the signal, e.g. to rename the signals handled under a
+		// different name by a new workflow type. Optional.
+		TransformCarriedOverSignal func(signal CarriedOverSignal) (CarriedOverSignal, bool)
+	}
+
+	// CarriedOverSignal is a signal carried over to the new run with ContinueAsNewOptions.CarryOverSignals.
+	CarriedOverSignal struct {
+		Name  string
+		Input *commonpb.Payloads
 	}
 
 	// UnknownExternalWorkflowExecutionError can be returned when external workflow doesn't exist
 	UnknownExternalWorkflowExecutionError struct{}
 
+	// ChildWorkflowExecutionAlreadyStartedError is the cause of the *ChildWorkflowExecutionError returned when a child
+	// workflow fails to start because a workflow with the same workflow ID is running, or has run and the
+	// WorkflowIDReusePolicy of the child workflow doesn't allow to reuse its workflow ID.
+	ChildWorkflowExecutionAlreadyStartedError struct{}
+
+	// WorkflowExecutionAlreadyStartedError is returned by Client.ExecuteWorkflow with
+	// StartWorkflowOptions.WorkflowExecutionErrorWhenAlreadyStarted when a workflow with the same workflow ID is
+	// running, or has run and the WorkflowIDReusePolicy doesn't allow to reuse its workflow ID. It identifies the
+	// existing run, so the caller can get its result with Client.GetWorkflow instead of starting a new run.
+	// Unwrap this error to get the *serviceerror.WorkflowExecutionAlreadyStarted returned by the server.
+	WorkflowExecutionAlreadyStartedError struct {
+		workflowID string
+		runID      string
+		startTime  time.Time
+		cause      error
+	}
+
 	// ServerError can be returned from server.
 	ServerError struct {
 		temporalError
@@ -229,6 +291,37 @@ type (
 		supportedTypes []string
 	}
 
+	// WorkflowTaskLimitExceededError is the failure of a workflow task whose completion exceeds
+	// WorkerOptions.MaxWorkflowTaskCommands or WorkerOptions.MaxWorkflowTaskCompletionSize.
+	WorkflowTaskLimitExceededError struct {
+		// Limit is "commands" or "size".
+		Limit string
+		// Value is the number of commands or the size in bytes of the completion.
+		Value int
+		// MaxValue is the configured limit.
+		MaxValue int
+	}
+
+	// NondeterministicReplayError is returned by WorkflowReplayer when the commands generated by the replay don't
+	// match the events of the history, so that tools can report where the workflow code diverged. Use errors.As to
+	// get it from the error returned by a replay.
+	NondeterministicReplayError struct {
+		// Differences are the differences between the history and the commands generated by the replay, in
+		// history order, as returned by WorkflowReplayer.DiffWorkflowHistory.
+		Differences []HistoryDifference
+		// NearestVersionMarkers are the version markers recorded by GetVersion calls closest to the first
+		// difference: the last one before it and the first one after it, when they exist.
+		NearestVersionMarkers []VersionMarker
+	}
+
+	// VersionMarker is a version recorded in a history by a GetVersion call.
+	VersionMarker struct {
+		// EventID is the ID of the marker event.
+		EventID  int64
+		ChangeID string
+		Version  Version
+	}
+
 	temporalError struct {
 		messenger
 		originalFailure *failurepb.Failure
@@ -419,10 +512,19 @@ func NewContinueAsNewError(ctx Context, wfn interface{}, args ...interface{}) er
 		panic(err)
 	}
 
+	header := getWorkflowHeader(ctx, options.ContextPropagators)
+	deadlines, err := encodeDurableDeadlines(getWorkflowEnvironmentInterceptor(ctx).deadlines)
+	if err != nil {
+		panic(err)
+	}
+	if deadlines != nil {
+		header.Fields[durableDeadlinesHeaderKey] = deadlines
+	}
+
 	return &ContinueAsNewError{
 		WorkflowType:             workflowType,
 		Input:                    input,
-		Header:                   getWorkflowHeader(ctx, options.ContextPropagators),
+		Header:                   header,
 		TaskQueueName:            options.TaskQueueName,
 		WorkflowExecutionTimeout: options.WorkflowExecutionTimeout,
 		WorkflowRunTimeout:       options.WorkflowRunTimeout,
@@ -430,6 +532,66 @@ func NewContinueAsNewError(ctx Context, wfn interface{}, args ...interface{}) er
 	}
 }
 
+// NewContinueAsNewErrorWithOptions creates ContinueAsNewError instance like NewContinueAsNewError, with the options
+// of the new run overridden by continueAsNewOptions. It panics when the options are invalid.
+//  wfn - workflow function or workflow type name. It can be different from the currently running one, e.g. to
+//        migrate the workflow to a new type together with a new TaskQueue.
+func NewContinueAsNewErrorWithOptions(ctx Context, continueAsNewOptions ContinueAsNewOptions, wfn interface{}, args ...interface{}) error {
+	if err := validateContinueAsNewOptions(continueAsNewOptions); err != nil {
+		panic(err)
+	}
+	err := NewContinueAsNewError(ctx, wfn, args...).(*ContinueAsNewError)
+	if continueAsNewOptions.TaskQueue != "" {
+		err.TaskQueueName = continueAsNewOptions.TaskQueue
+	}
+	if continueAsNewOptions.WorkflowRunTimeout != 0 {
+		err.WorkflowRunTimeout = continueAsNewOptions.WorkflowRunTimeout
+	}
+	if continueAsNewOptions.WorkflowTaskTimeout != 0 {
+		err.WorkflowTaskTimeout = continueAsNewOptions.WorkflowTaskTimeout
+	}
+	memo, memoErr := getWorkflowMemo(continueAsNewOptions.Memo, getWorkflowEnvOptions(ctx).DataConverter)
+	if memoErr != nil {
+		panic(memoErr)
+	}
+	err.Memo = memo
+	searchAttributes, searchAttributesErr := serializeSearchAttributes(continueAsNewOptions.SearchAttributes)
+	if searchAttributesErr != nil {
+		panic(searchAttributesErr)
+	}
+	err.SearchAttributes = searchAttributes
+	if continueAsNewOptions.CarryOverSignals {
+		signals := getWorkflowEnvOptions(ctx).drainUnhandledSignals()
+		if transform := continueAsNewOptions.TransformCarriedOverSignal; transform != nil {
+			transformed := signals[:0]
+			for _, signal := range signals {
+				if signal, ok := transform(signal); ok {
+					transformed = append(transformed, signal)
+				}
+			}
+			signals = transformed
+		}
+		if len(signals) > 0 {
+			payload, signalsErr := encodeCarriedOverSignals(signals)
+			if signalsErr != nil {
+				panic(signalsErr)
+			}
+			err.Header.Fields[carriedOverSignalsHeaderKey] = payload
+		}
+	}
+	return err
+}
+
+func validateContinueAsNewOptions(options ContinueAsNewOptions) error {
+	if options.WorkflowRunTimeout < 0 {
+		return errors.New("negative WorkflowRunTimeout")
+	}
+	if options.WorkflowTaskTimeout < 0 {
+		return errors.New("negative WorkflowTaskTimeout")
+	}
+	return nil
+}
+
 // NewActivityNotRegisteredError creates a new ActivityNotRegisteredError.
 func NewActivityNotRegisteredError(activityType string, supportedTypes []string) error {
 	return &ActivityNotRegisteredError{activityType: activityType, supportedTypes: supportedTypes}
@@ -516,6 +678,36 @@ func (e *TimeoutError) LastHeartbeatDetails(d ...interface{}) error {
 	return e.lastHeartbeatDetails.Get(d...)
 }
 
+// HasDetails return if the activity recorded heartbeat details before it timed out. Unlike HasLastHeartbeatDetails
+// it also checks the timeout errors this error was caused by, for example the heartbeat timeout of the last attempt
+// of an activity that eventually timed out on ScheduleToClose.
+func (e *TimeoutError) HasDetails() bool {
+	return e.heartbeatDetails() != nil
+}
+
+// Details extracts the last heartbeat details recorded by the activity before it timed out. A workflow can pass
+// them to the next execution of the activity to resume from the last checkpoint. If there is no details, it will
+// return ErrNoData.
+func (e *TimeoutError) Details(d ...interface{}) error {
+	details := e.heartbeatDetails()
+	if details == nil {
+		return ErrNoData
+	}
+	return details.Get(d...)
+}
+
+// heartbeatDetails returns the first heartbeat details found in the chain of timeout errors starting with this one.
+func (e *TimeoutError) heartbeatDetails() converter.EncodedValues {
+	var err error = e
+	for err != nil {
+		if timeoutErr, ok := err.(*TimeoutError); ok && timeoutErr.HasLastHeartbeatDetails() {
+			return timeoutErr.lastHeartbeatDetails
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
+
 // Error from error interface
 func (e *CanceledError) Error() string {
 	return e.message()
@@ -542,6 +734,36 @@ func newPanicError(value interface{}, stackTrace string) error {
 	return &PanicError{value: value, stackTrace: stackTrace}
 }
 
+// newActivityPanicError creates the PanicError an activity fails with, applying the panic policy of the worker. With
+// CrashWorkerOnPanic it raises the panic again instead.
+func newActivityPanicError(value interface{}, stackTrace string, policy ActivityPanicPolicy) error {
+	switch policy {
+	case FailActivityOnPanic:
+		return &PanicError{value: value, stackTrace: stackTrace, nonRetryable: true}
+	case CrashWorkerOnPanic:
+		panic(value)
+	default:
+		return newPanicError(value, stackTrace)
+	}
+}
+
+// applyActivityRetryPredicate makes the error an activity attempt failed with non-retryable when the retry predicate
+// of the worker returns false for it. Cancellations and errors which are already non-retryable are left unchanged.
+func applyActivityRetryPredicate(err error, attempt int32, predicate func(err error, attempt int) bool) error {
+	if err == nil || predicate == nil || err == ErrActivityResultPending || !IsRetryable(err, nil) ||
+		predicate(err, int(attempt)) {
+		return err
+	}
+	switch err := err.(type) {
+	case *ApplicationError:
+		return &ApplicationError{msg: err.msg, errType: err.errType, nonRetryable: true, cause: err.cause, details: err.details}
+	case *PanicError:
+		return &PanicError{value: err.value, stackTrace: err.stackTrace, nonRetryable: true}
+	default:
+		return &ApplicationError{msg: err.Error(), errType: getErrType(err), nonRetryable: true, cause: errors.Unwrap(err)}
+	}
+}
+
 func newWorkflowPanicError(value interface{}, stackTrace string) error {
 	return &workflowPanicError{value: value, stackTrace: stackTrace}
 }
@@ -555,6 +777,11 @@ func (e *PanicError) message() string {
 	return fmt.Sprintf("%v", e.value)
 }
 
+// NonRetryable returns whether the activity failed with the panic is not retried, see FailActivityOnPanic.
+func (e *PanicError) NonRetryable() bool {
+	return e.nonRetryable
+}
+
 // StackTrace return stack trace of the panic
 func (e *PanicError) StackTrace() string {
 	return e.stackTrace
@@ -603,6 +830,41 @@ func (e *UnknownExternalWorkflowExecutionError) Error() string {
 	return "unknown external workflow execution"
 }
 
+// newChildWorkflowExecutionAlreadyStartedError creates ChildWorkflowExecutionAlreadyStartedError instance
+func newChildWorkflowExecutionAlreadyStartedError() *ChildWorkflowExecutionAlreadyStartedError {
+	return &ChildWorkflowExecutionAlreadyStartedError{}
+}
+
+// Error from error interface
+func (e *ChildWorkflowExecutionAlreadyStartedError) Error() string {
+	return "workflow execution already started"
+}
+
+// Error from error interface
+func (e *WorkflowExecutionAlreadyStartedError) Error() string {
+	return e.cause.Error()
+}
+
+// Unwrap returns the *serviceerror.WorkflowExecutionAlreadyStarted returned by the server.
+func (e *WorkflowExecutionAlreadyStartedError) Unwrap() error {
+	return e.cause
+}
+
+// WorkflowID returns the workflow ID of the existing workflow execution.
+func (e *WorkflowExecutionAlreadyStartedError) WorkflowID() string {
+	return e.workflowID
+}
+
+// RunID returns the run ID of the existing workflow execution.
+func (e *WorkflowExecutionAlreadyStartedError) RunID() string {
+	return e.runID
+}
+
+// StartTime returns the start time of the existing workflow execution, or the zero time if it couldn't be described.
+func (e *WorkflowExecutionAlreadyStartedError) StartTime() time.Time {
+	return e.startTime
+}
+
 // Error from error interface
 func (e *ServerError) Error() string {
 	msg := e.message()
@@ -703,6 +965,29 @@ func (e *ActivityNotRegisteredError) Error() string {
 	return fmt.Sprintf("unable to find activityType=%v. Supported types: [%v]", e.activityType, supported)
 }
 
+func (e *WorkflowTaskLimitExceededError) Error() string {
+	return fmt.Sprintf("workflow task exceeds %s limit: %d > %d", e.Limit, e.Value, e.MaxValue)
+}
+
+func (e *NondeterministicReplayError) Error() string {
+	var b strings.Builder
+	b.WriteString("nondeterministic workflow")
+	if len(e.Differences) > 0 {
+		b.WriteString(": " + e.Differences[0].String())
+		if len(e.Differences) > 1 {
+			fmt.Fprintf(&b, " (and %d more differences)", len(e.Differences)-1)
+		}
+	}
+	if len(e.NearestVersionMarkers) > 0 {
+		markers := make([]string, len(e.NearestVersionMarkers))
+		for i, m := range e.NearestVersionMarkers {
+			markers[i] = fmt.Sprintf("event %d: %s=%d", m.EventID, m.ChangeID, m.Version)
+		}
+		b.WriteString(", nearest version markers: " + strings.Join(markers, ", "))
+	}
+	return b.String()
+}
+
 func convertErrDetailsToPayloads(details converter.EncodedValues, dc converter.DataConverter) *commonpb.Payloads {
 	switch d := details.(type) {
 	case ErrorDetailsValues:
@@ -731,6 +1016,11 @@ func IsRetryable(err error, nonRetryableTypes []string) bool {
 		return false
 	}
 
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) && panicErr.nonRetryable {
+		return false
+	}
+
 	var timeoutErr *TimeoutError
 	if errors.As(err, &timeoutErr) {
 		return timeoutErr.timeoutType == enumspb.TIMEOUT_TYPE_START_TO_CLOSE || timeoutErr.timeoutType == enumspb.TIMEOUT_TYPE_HEARTBEAT
@@ -806,7 +1096,8 @@ func ConvertErrorToFailure(err error, dc converter.DataConverter) *failurepb.Fai
 		failure.FailureInfo = &failurepb.Failure_CanceledFailureInfo{CanceledFailureInfo: failureInfo}
 	case *PanicError:
 		failureInfo := &failurepb.ApplicationFailureInfo{
-			Type: getErrType(err),
+			Type:         getErrType(err),
+			NonRetryable: err.nonRetryable,
 		}
 		failure.FailureInfo = &failurepb.Failure_ApplicationFailureInfo{ApplicationFailureInfo: failureInfo}
 		failure.StackTrace = err.StackTrace()
@@ -854,6 +1145,12 @@ func ConvertErrorToFailure(err error, dc converter.DataConverter) *failurepb.Fai
 			RetryState:       err.retryState,
 		}
 		failure.FailureInfo = &failurepb.Failure_ChildWorkflowExecutionFailureInfo{ChildWorkflowExecutionFailureInfo: failureInfo}
+	case *ChildWorkflowExecutionAlreadyStartedError:
+		failureInfo := &failurepb.ApplicationFailureInfo{
+			Type:         getErrType(err),
+			NonRetryable: true,
+		}
+		failure.FailureInfo = &failurepb.Failure_ApplicationFailureInfo{ApplicationFailureInfo: failureInfo}
 	default: // All unknown errors are considered to be retryable ApplicationFailureInfo.
 		failureInfo := &failurepb.ApplicationFailureInfo{
 			Type:         getErrType(err),
@@ -880,7 +1177,13 @@ func ConvertFailureToError(failure *failurepb.Failure, dc converter.DataConverte
 		details := newEncodedValues(applicationFailureInfo.GetDetails(), dc)
 		switch applicationFailureInfo.GetType() {
 		case getErrType(&PanicError{}):
-			err = newPanicError(failure.GetMessage(), failure.GetStackTrace())
+			err = &PanicError{
+				value:        failure.GetMessage(),
+				stackTrace:   failure.GetStackTrace(),
+				nonRetryable: applicationFailureInfo.GetNonRetryable(),
+			}
+		case getErrType(&ChildWorkflowExecutionAlreadyStartedError{}):
+			err = newChildWorkflowExecutionAlreadyStartedError()
 		default:
 			err = NewApplicationError(
 				failure.GetMessage(),
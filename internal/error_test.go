@@ -28,6 +28,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -147,6 +148,23 @@ func Test_TimeoutError(t *testing.T) {
 	require.Equal(t, testErrorDetails1, data)
 }
 
+func Test_TimeoutError_Details(t *testing.T) {
+	err := NewTimeoutError("timeout", enumspb.TIMEOUT_TYPE_SCHEDULE_TO_CLOSE, nil)
+	var timeoutErr *TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	require.False(t, timeoutErr.HasDetails())
+	var data string
+	require.Equal(t, ErrNoData, timeoutErr.Details(&data))
+
+	err = NewTimeoutError("timeout", enumspb.TIMEOUT_TYPE_SCHEDULE_TO_CLOSE, NewHeartbeatTimeoutError(testErrorDetails1))
+	require.True(t, errors.As(err, &timeoutErr))
+	require.Equal(t, enumspb.TIMEOUT_TYPE_SCHEDULE_TO_CLOSE, timeoutErr.TimeoutType())
+	require.False(t, timeoutErr.HasLastHeartbeatDetails())
+	require.True(t, timeoutErr.HasDetails())
+	require.NoError(t, timeoutErr.Details(&data))
+	require.Equal(t, testErrorDetails1, data)
+}
+
 func Test_TimeoutError_WithDetails(t *testing.T) {
 	testTimeoutErrorDetails(t, enumspb.TIMEOUT_TYPE_HEARTBEAT)
 	testTimeoutErrorDetails(t, enumspb.TIMEOUT_TYPE_SCHEDULE_TO_CLOSE)
@@ -566,6 +584,80 @@ func Test_ContinueAsNewError(t *testing.T) {
 	require.Equal(t, header, continueAsNewErr.Header)
 }
 
+func Test_ContinueAsNewErrorWithOptions(t *testing.T) {
+	continueAsNewWorkflowFn := func(ctx Context) error {
+		return NewContinueAsNewErrorWithOptions(ctx, ContinueAsNewOptions{
+			TaskQueue:          "new-task-queue",
+			WorkflowRunTimeout: time.Hour,
+			Memo:               map[string]interface{}{"key": "value"},
+		}, "newWorkflowType", "arg")
+	}
+
+	s := &WorkflowTestSuite{}
+	wfEnv := s.NewTestWorkflowEnvironment()
+	wfEnv.RegisterWorkflow(continueAsNewWorkflowFn)
+	wfEnv.ExecuteWorkflow(continueAsNewWorkflowFn)
+
+	var continueAsNewErr *ContinueAsNewError
+	require.True(t, errors.As(wfEnv.GetWorkflowError(), &continueAsNewErr))
+	require.Equal(t, "newWorkflowType", continueAsNewErr.WorkflowType.Name)
+	require.Equal(t, "new-task-queue", continueAsNewErr.TaskQueueName)
+	require.Equal(t, time.Hour, continueAsNewErr.WorkflowRunTimeout)
+	require.Contains(t, continueAsNewErr.Memo.GetFields(), "key")
+	require.Nil(t, continueAsNewErr.SearchAttributes)
+
+	require.Error(t, validateContinueAsNewOptions(ContinueAsNewOptions{WorkflowTaskTimeout: -time.Second}))
+}
+
+func Test_ContinueAsNewErrorCarryOverSignals(t *testing.T) {
+	continueAsNewWorkflowFn := func(ctx Context) error {
+		if err := Sleep(ctx, time.Minute); err != nil {
+			return err
+		}
+		return NewContinueAsNewErrorWithOptions(ctx, ContinueAsNewOptions{
+			CarryOverSignals: true,
+			TransformCarriedOverSignal: func(signal CarriedOverSignal) (CarriedOverSignal, bool) {
+				if signal.Name == "dropped" {
+					return signal, false
+				}
+				signal.Name = "renamed-" + signal.Name
+				return signal, true
+			},
+		}, "continueAsNewWorkflowFn")
+	}
+
+	s := &WorkflowTestSuite{}
+	wfEnv := s.NewTestWorkflowEnvironment()
+	wfEnv.RegisterWorkflow(continueAsNewWorkflowFn)
+	wfEnv.RegisterDelayedCallback(func() {
+		wfEnv.SignalWorkflow("signal", "first")
+		wfEnv.SignalWorkflow("dropped", "value")
+		wfEnv.SignalWorkflow("signal", "second")
+	}, time.Second)
+	wfEnv.ExecuteWorkflow(continueAsNewWorkflowFn)
+
+	var continueAsNewErr *ContinueAsNewError
+	require.True(t, errors.As(wfEnv.GetWorkflowError(), &continueAsNewErr))
+	require.Contains(t, continueAsNewErr.Header.GetFields(), carriedOverSignalsHeaderKey)
+
+	var received []string
+	newRunWorkflowFn := func(ctx Context) error {
+		ch := GetSignalChannel(ctx, "renamed-signal")
+		var value string
+		for ch.ReceiveAsync(&value) {
+			received = append(received, value)
+		}
+		require.False(t, GetSignalChannel(ctx, "dropped").ReceiveAsync(nil))
+		return nil
+	}
+	s = &WorkflowTestSuite{header: continueAsNewErr.Header}
+	wfEnv = s.NewTestWorkflowEnvironment()
+	wfEnv.RegisterWorkflow(newRunWorkflowFn)
+	wfEnv.ExecuteWorkflow(newRunWorkflowFn)
+	require.NoError(t, wfEnv.GetWorkflowError())
+	require.Equal(t, []string{"first", "second"}, received)
+}
+
 type coolError struct{}
 
 func (e coolError) Error() string {
@@ -683,6 +775,24 @@ func Test_convertErrorToFailure_PanicError(t *testing.T) {
 	require.Equal(panicErr.StackTrace(), panicErr2.StackTrace())
 }
 
+func Test_convertErrorToFailure_ChildWorkflowExecutionAlreadyStartedError(t *testing.T) {
+	require := require.New(t)
+
+	err := NewChildWorkflowExecutionError("namespace", "wID", "rID", "wType", 0, 0, enumspb.RETRY_STATE_NON_RETRYABLE_FAILURE,
+		newChildWorkflowExecutionAlreadyStartedError())
+	f := ConvertErrorToFailure(err, converter.GetDefaultDataConverter())
+	require.Equal("workflow execution already started", f.GetCause().GetMessage())
+	require.Equal("ChildWorkflowExecutionAlreadyStartedError", f.GetCause().GetApplicationFailureInfo().GetType())
+	require.True(f.GetCause().GetApplicationFailureInfo().GetNonRetryable())
+
+	err2 := ConvertFailureToError(f, converter.GetDefaultDataConverter())
+	var childErr *ChildWorkflowExecutionError
+	require.True(errors.As(err2, &childErr))
+	var alreadyStartedErr *ChildWorkflowExecutionAlreadyStartedError
+	require.True(errors.As(err2, &alreadyStartedErr))
+	require.Equal(err.Error(), err2.Error())
+}
+
 func Test_convertErrorToFailure_TimeoutError(t *testing.T) {
 	require := require.New(t)
 
@@ -26,6 +26,8 @@ package internal
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/gogo/status"
@@ -33,11 +35,13 @@ import (
 	"github.com/uber-go/tally"
 	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/internal/common/retry"
+	"go.uber.org/atomic"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
 
 	"go.temporal.io/sdk/internal/common/metrics"
 )
@@ -49,6 +53,8 @@ type (
 		UserConnectionOptions ConnectionOptions
 		RequiredInterceptors  []grpc.UnaryClientInterceptor
 		DefaultServiceConfig  string
+		// Resolver resolves the HostPort if set.
+		Resolver resolver.Builder
 	}
 )
 
@@ -107,6 +113,9 @@ func dial(params dialParameters) (*grpc.ClientConn, error) {
 	}
 
 	opts = append(opts, securityOptions...)
+	if params.Resolver != nil {
+		opts = append(opts, grpc.WithResolvers(params.Resolver))
+	}
 	opts = append(opts, grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(maxPayloadSize)))
 	opts = append(opts, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxPayloadSize)))
 
@@ -124,16 +133,19 @@ func dial(params dialParameters) (*grpc.ClientConn, error) {
 	return grpc.Dial(params.HostPort, opts...)
 }
 
-func requiredInterceptors(metricScope tally.Scope, headersProvider HeadersProvider, controller TrafficController) []grpc.UnaryClientInterceptor {
+func requiredInterceptors(metricScope tally.Scope, headersProvider HeadersProvider, controller TrafficController,
+	retryOptions *ServiceRetryOptions) []grpc.UnaryClientInterceptor {
 	interceptors := []grpc.UnaryClientInterceptor{
 		errorInterceptor,
 		// Report aggregated metrics for the call, this is done outside of the retry loop.
 		metrics.NewGRPCMetricsInterceptor(metricScope, ""),
 		// By default the grpc retry interceptor *is disabled*, preventing accidental use of retries.
 		// We add call options for retry configuration based on the values present in the context.
-		retry.NewRetryOptionsInterceptor(),
+		retry.NewRetryOptionsInterceptor(retryOptions),
 		// Performs retries *IF* retry options are set for the call.
 		grpc_retry.UnaryClientInterceptor(),
+		// Collects the throttling signals of the server from every attempt to delay the next one accordingly.
+		retry.NewServerThrottlingInterceptor(),
 		// Report metrics for every call made to the server.
 		metrics.NewGRPCMetricsInterceptor(metricScope, attemptSuffix),
 	}
@@ -146,6 +158,73 @@ func requiredInterceptors(metricScope tally.Scope, headersProvider HeadersProvid
 	return interceptors
 }
 
+// lazyHealthCheckInterceptor checks the health of the server before the first request made through the connection.
+// Until the check passes, requests fail with the error of the check and the check is repeated on the next request.
+// Requests made while a check is in flight wait for its result instead of starting another one. The check is made
+// with the context of the request which started it.
+func lazyHealthCheckInterceptor(hostPort string, options ConnectionOptions) grpc.UnaryClientInterceptor {
+	check := &lazyHealthCheck{check: checkHealth, options: options}
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !check.healthy.Load() && method != healthCheckMethod {
+			if err := check.wait(ctx, cc); err != nil {
+				return fmt.Errorf("unable to reach server at %s: %w", hostPort, err)
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+type (
+	// lazyHealthCheck deduplicates the concurrent health checks of a connection.
+	lazyHealthCheck struct {
+		check   func(ctx context.Context, connection grpc.ClientConnInterface, options ConnectionOptions) error
+		options ConnectionOptions
+		healthy atomic.Bool
+
+		lock     sync.Mutex
+		inFlight *healthCheckCall
+	}
+
+	healthCheckCall struct {
+		done chan struct{}
+		err  error
+	}
+)
+
+// wait returns once the connection is healthy, starting a check if none is in flight. It returns the error of the
+// check, or the error of ctx if it is done before the check completes.
+func (c *lazyHealthCheck) wait(ctx context.Context, connection grpc.ClientConnInterface) error {
+	c.lock.Lock()
+	if c.healthy.Load() {
+		c.lock.Unlock()
+		return nil
+	}
+	call := c.inFlight
+	if call == nil {
+		call = &healthCheckCall{done: make(chan struct{})}
+		c.inFlight = call
+		c.lock.Unlock()
+
+		call.err = c.check(ctx, connection, c.options)
+		c.lock.Lock()
+		if call.err == nil {
+			c.healthy.Store(true)
+		}
+		c.inFlight = nil
+		c.lock.Unlock()
+		close(call.done)
+		return call.err
+	}
+	c.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func trafficControllerInterceptor(controller TrafficController) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		err := controller.CheckCallAllowed(ctx, method, req, reply)
@@ -28,6 +28,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/gogo/status"
 	"github.com/stretchr/testify/require"
@@ -107,11 +108,76 @@ func TestHeadersProvider_Error(t *testing.T) {
 }
 
 func TestHeadersProvider_NotIncludedWhenNil(t *testing.T) {
-	interceptors := requiredInterceptors(nil, nil, nil)
-	require.Equal(t, 5, len(interceptors))
+	interceptors := requiredInterceptors(nil, nil, nil, nil)
+	require.Equal(t, 6, len(interceptors))
 }
 
 func TestHeadersProvider_IncludedWithHeadersProvider(t *testing.T) {
-	interceptors := requiredInterceptors(nil, authHeadersProvider{token: "test-auth-token"}, nil)
-	require.Equal(t, 6, len(interceptors))
+	interceptors := requiredInterceptors(nil, authHeadersProvider{token: "test-auth-token"}, nil, nil)
+	require.Equal(t, 7, len(interceptors))
+}
+
+func TestLazyHealthCheck_HealthCheckDisabled(t *testing.T) {
+	interceptor := lazyHealthCheckInterceptor("host:port", ConnectionOptions{DisableHealthCheck: true})
+	calls := 0
+	for i := 0; i < 2; i++ {
+		require.NoError(t, interceptor(context.Background(), "method", "request", "reply", nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				calls++
+				return nil
+			}))
+	}
+	require.Equal(t, 2, calls)
+}
+
+func TestLazyHealthCheck_HealthCheckRequestNotChecked(t *testing.T) {
+	interceptor := lazyHealthCheckInterceptor("host:port", ConnectionOptions{})
+	// The health check request itself goes through the interceptor and must not trigger another check.
+	err := interceptor(context.Background(), healthCheckMethod, "request", "reply", nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return errors.New("unavailable")
+		})
+	require.EqualError(t, err, "unavailable")
+}
+
+type healthCheckCtxKey struct{}
+
+func TestLazyHealthCheck_ConcurrentRequestsShareCheck(t *testing.T) {
+	checkStartedCh := make(chan struct{})
+	releaseCheckCh := make(chan error)
+	var checks int
+	check := &lazyHealthCheck{
+		check: func(ctx context.Context, _ grpc.ClientConnInterface, _ ConnectionOptions) error {
+			checks++
+			// The check is made with the context of the request.
+			require.Equal(t, "first", ctx.Value(healthCheckCtxKey{}))
+			close(checkStartedCh)
+			return <-releaseCheckCh
+		},
+	}
+
+	firstErrCh := make(chan error)
+	go func() {
+		firstErrCh <- check.wait(context.WithValue(context.Background(), healthCheckCtxKey{}, "first"), nil)
+	}()
+	<-checkStartedCh
+
+	// A request whose context is done stops waiting for the check in flight.
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, check.wait(canceledCtx, nil))
+
+	secondErrCh := make(chan error)
+	go func() { secondErrCh <- check.wait(context.Background(), nil) }()
+	select {
+	case err := <-secondErrCh:
+		t.Fatalf("request did not wait for the check in flight: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseCheckCh <- nil
+	require.NoError(t, <-firstErrCh)
+	require.NoError(t, <-secondErrCh)
+	require.NoError(t, check.wait(context.Background(), nil))
+	require.Equal(t, 1, checks)
 }
@@ -0,0 +1,206 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+
+	"go.temporal.io/sdk/log"
+)
+
+const (
+	// failoverScheme is the scheme of the target of connections failing over between clusters.
+	failoverScheme = "temporal-failover"
+
+	defaultFailoverHealthCheckInterval = 10 * time.Second
+)
+
+type (
+	// FailoverOptions configure the failover of a client between clusters. The client connects to the cluster at
+	// ClientOptions.HostPort, the primary cluster, while it is healthy and fails over to the first healthy secondary
+	// cluster otherwise. The client fails back to the primary cluster as soon as it is healthy again.
+	FailoverOptions struct {
+		// HostPorts of the secondary clusters in the order of preference. Required.
+		HostPorts []string
+
+		// HealthCheckInterval is how often the health of the clusters is checked.
+		// default: 10s
+		HealthCheckInterval time.Duration
+
+		// OnClusterSwitch is called with the host:port of the previous and the new active cluster every time the
+		// client switches clusters. Optional.
+		OnClusterSwitch func(from, to string)
+	}
+
+	// failoverConnection points a gRPC connection to the most preferred healthy cluster. The health of every cluster
+	// is checked through a dedicated connection.
+	failoverConnection struct {
+		connection   *grpc.ClientConn
+		hostPorts    []string
+		healthConns  []*grpc.ClientConn
+		interval     time.Duration
+		onSwitch     func(from, to string)
+		logger       log.Logger
+		checkHealth  func(index int) error
+		updateActive func(hostPort string)
+
+		active    int
+		closeCh   chan struct{}
+		closeOnce sync.Once
+		wg        sync.WaitGroup
+	}
+)
+
+// dialFailover creates a connection to the first healthy of the primary cluster at params.HostPort and the secondary
+// clusters and starts checking the health of the clusters to switch the connection to the most preferred healthy
+// one. If no cluster is healthy, the connection points to the primary cluster and an error is returned unless lazy is
+// set, in which case the connection switches clusters as soon as one of them is healthy.
+func dialFailover(params dialParameters, options FailoverOptions, logger log.Logger, lazy bool) (*failoverConnection, error) {
+	if len(options.HostPorts) == 0 {
+		return nil, errors.New("failover host:ports are not set")
+	}
+	f := &failoverConnection{
+		hostPorts: append([]string{params.HostPort}, options.HostPorts...),
+		interval:  options.HealthCheckInterval,
+		onSwitch:  options.OnClusterSwitch,
+		logger:    logger,
+		closeCh:   make(chan struct{}),
+	}
+	if f.interval <= 0 {
+		f.interval = defaultFailoverHealthCheckInterval
+	}
+	for _, hostPort := range f.hostPorts {
+		healthParams := params
+		healthParams.HostPort = hostPort
+		healthConn, err := dial(healthParams)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		f.healthConns = append(f.healthConns, healthConn)
+	}
+
+	r := manual.NewBuilderWithScheme(failoverScheme)
+	r.InitialState(resolver.State{Addresses: []resolver.Address{failoverAddress(params.HostPort)}})
+	params.HostPort = failoverScheme + ":///" + params.HostPort
+	params.Resolver = r
+	connection, err := dial(params)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	f.connection = connection
+	f.checkHealth = func(index int) error {
+		return checkHealthOnce(context.Background(), f.healthConns[index], params.UserConnectionOptions)
+	}
+	f.updateActive = func(hostPort string) {
+		r.UpdateState(resolver.State{Addresses: []resolver.Address{failoverAddress(hostPort)}})
+	}
+
+	if err := f.selectCluster(); err != nil && !lazy {
+		_ = f.Close()
+		return nil, err
+	}
+	f.wg.Add(1)
+	go f.checkHealthLoop()
+	return f, nil
+}
+
+func failoverAddress(hostPort string) resolver.Address {
+	serverName := hostPort
+	if host, _, err := net.SplitHostPort(hostPort); err == nil {
+		serverName = host
+	}
+	return resolver.Address{Addr: hostPort, ServerName: serverName}
+}
+
+func (f *failoverConnection) checkHealthLoop() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.closeCh:
+			return
+		case <-ticker.C:
+			if err := f.selectCluster(); err != nil {
+				f.logger.Warn("No healthy cluster to fail over to.", tagError, err)
+			}
+		}
+	}
+}
+
+// selectCluster switches the connection to the most preferred healthy cluster. It returns the errors of the health
+// checks if no cluster is healthy.
+func (f *failoverConnection) selectCluster() error {
+	var errs []string
+	for i, hostPort := range f.hostPorts {
+		err := f.checkHealth(i)
+		if err != nil {
+			errs = append(errs, hostPort+": "+err.Error())
+			continue
+		}
+		if i != f.active {
+			from := f.hostPorts[f.active]
+			f.active = i
+			f.logger.Info("Switching cluster.", "From", from, "To", hostPort)
+			f.updateActive(hostPort)
+			if f.onSwitch != nil {
+				f.onSwitch(from, hostPort)
+			}
+		}
+		return nil
+	}
+	return errors.New("no healthy cluster: " + strings.Join(errs, "; "))
+}
+
+// Close stops the health checks and closes the connections.
+func (f *failoverConnection) Close() error {
+	f.closeOnce.Do(func() {
+		close(f.closeCh)
+	})
+	f.wg.Wait()
+	var err error
+	for _, healthConn := range f.healthConns {
+		if closeErr := healthConn.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	if f.connection != nil {
+		if closeErr := f.connection.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	ilog "go.temporal.io/sdk/internal/log"
+)
+
+func TestFailoverConnection_SelectCluster(t *testing.T) {
+	healthy := map[string]bool{"primary:7233": true, "secondary1:7233": true, "secondary2:7233": true}
+	var active string
+	var switches [][2]string
+	f := &failoverConnection{
+		hostPorts: []string{"primary:7233", "secondary1:7233", "secondary2:7233"},
+		logger:    ilog.NewNopLogger(),
+		onSwitch: func(from, to string) {
+			switches = append(switches, [2]string{from, to})
+		},
+		updateActive: func(hostPort string) {
+			active = hostPort
+		},
+	}
+	f.checkHealth = func(index int) error {
+		if healthy[f.hostPorts[index]] {
+			return nil
+		}
+		return errors.New("unavailable")
+	}
+
+	require.NoError(t, f.selectCluster())
+	require.Equal(t, "", active)
+	require.Empty(t, switches)
+
+	healthy["primary:7233"] = false
+	healthy["secondary1:7233"] = false
+	require.NoError(t, f.selectCluster())
+	require.Equal(t, "secondary2:7233", active)
+	require.Equal(t, [][2]string{{"primary:7233", "secondary2:7233"}}, switches)
+
+	healthy["secondary2:7233"] = false
+	require.EqualError(t, f.selectCluster(),
+		"no healthy cluster: primary:7233: unavailable; secondary1:7233: unavailable; secondary2:7233: unavailable")
+	require.Equal(t, "secondary2:7233", active)
+
+	healthy["primary:7233"] = true
+	require.NoError(t, f.selectCluster())
+	require.Equal(t, "primary:7233", active)
+	require.Equal(t, [][2]string{{"primary:7233", "secondary2:7233"}, {"secondary2:7233", "primary:7233"}}, switches)
+}
+
+func TestFailoverAddress(t *testing.T) {
+	require.Equal(t, "cluster.example.com", failoverAddress("cluster.example.com:7233").ServerName)
+	require.Equal(t, "cluster.example.com", failoverAddress("cluster.example.com").ServerName)
+}
@@ -0,0 +1,525 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	commandpb "go.temporal.io/api/command/v1"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	failurepb "go.temporal.io/api/failure/v1"
+	historypb "go.temporal.io/api/history/v1"
+
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/internal/common"
+)
+
+type (
+	// HistoryEventFilter selects the history events returned by an iterator created with
+	// NewFilteredHistoryEventIterator.
+	HistoryEventFilter struct {
+		// EventTypes are the types of the events to return. All events are returned if it is empty.
+		EventTypes []enumspb.EventType
+
+		// StartTime is the earliest time of the events to return, inclusive. Optional: no lower bound if zero.
+		StartTime time.Time
+
+		// EndTime is the time the events to return happened before, exclusive. Optional: no upper bound if zero.
+		// The iteration stops at the first event at or after EndTime, as events are ordered by time.
+		EndTime time.Time
+	}
+
+	// filteredHistoryEventIteratorImpl is the implementation of HistoryEventIterator returned by
+	// NewFilteredHistoryEventIterator
+	filteredHistoryEventIteratorImpl struct {
+		iter       HistoryEventIterator
+		filter     HistoryEventFilter
+		eventTypes map[enumspb.EventType]struct{}
+		next       *historypb.HistoryEvent
+		err        error
+		done       bool
+	}
+)
+
+// NewFilteredHistoryEventIterator wraps iter, usually returned by Client.GetWorkflowHistory, to only return the events
+// matching the filter:
+//  iter := client.NewFilteredHistoryEventIterator(c.GetWorkflowHistory(ctx, workflowID, runID, false,
+//      enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT), client.HistoryEventFilter{
+//      EventTypes: []enumspb.EventType{enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED},
+//  })
+// Errors returned by iter are passed through.
+func NewFilteredHistoryEventIterator(iter HistoryEventIterator, filter HistoryEventFilter) HistoryEventIterator {
+	var eventTypes map[enumspb.EventType]struct{}
+	if len(filter.EventTypes) > 0 {
+		eventTypes = make(map[enumspb.EventType]struct{}, len(filter.EventTypes))
+		for _, eventType := range filter.EventTypes {
+			eventTypes[eventType] = struct{}{}
+		}
+	}
+	return &filteredHistoryEventIteratorImpl{
+		iter:       iter,
+		filter:     filter,
+		eventTypes: eventTypes,
+	}
+}
+
+func (iter *filteredHistoryEventIteratorImpl) HasNext() bool {
+	if iter.next != nil || iter.err != nil {
+		return true
+	}
+	for !iter.done && iter.iter.HasNext() {
+		event, err := iter.iter.Next()
+		if err != nil {
+			iter.err = err
+			return true
+		}
+		eventTime := common.TimeValue(event.GetEventTime())
+		if !iter.filter.EndTime.IsZero() && !eventTime.Before(iter.filter.EndTime) {
+			iter.done = true
+			break
+		}
+		if !iter.filter.StartTime.IsZero() && eventTime.Before(iter.filter.StartTime) {
+			continue
+		}
+		if iter.eventTypes != nil {
+			if _, ok := iter.eventTypes[event.GetEventType()]; !ok {
+				continue
+			}
+		}
+		iter.next = event
+		return true
+	}
+	return false
+}
+
+func (iter *filteredHistoryEventIteratorImpl) Next() (*historypb.HistoryEvent, error) {
+	if !iter.HasNext() {
+		panic("HistoryEventIterator Next() called without checking HasNext()")
+	}
+	event, err := iter.next, iter.err
+	iter.next, iter.err = nil, nil
+	return event, err
+}
+
+// GetHistoryEventPayloads returns the payloads carried by a history event, e.g. the input of a workflow execution
+// started event or the result of an activity task completed event, decoded with the given data converter:
+//  var result string
+//  err := client.GetHistoryEventPayloads(event, dataConverter).Get(&result)
+// It returns nil for the event types without payloads, as well as for marker events whose details are keyed by name.
+func GetHistoryEventPayloads(event *historypb.HistoryEvent, dataConverter converter.DataConverter) converter.EncodedValues {
+	payloads := getHistoryEventPayloads(event)
+	if payloads == nil {
+		return nil
+	}
+	return newEncodedValues(payloads, dataConverter)
+}
+
+func getHistoryEventPayloads(event *historypb.HistoryEvent) *commonpb.Payloads {
+	switch event.GetEventType() {
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED:
+		return event.GetWorkflowExecutionStartedEventAttributes().GetInput()
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_COMPLETED:
+		return event.GetWorkflowExecutionCompletedEventAttributes().GetResult()
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_CANCELED:
+		return event.GetWorkflowExecutionCanceledEventAttributes().GetDetails()
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_TERMINATED:
+		return event.GetWorkflowExecutionTerminatedEventAttributes().GetDetails()
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_CONTINUED_AS_NEW:
+		return event.GetWorkflowExecutionContinuedAsNewEventAttributes().GetInput()
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED:
+		return event.GetWorkflowExecutionSignaledEventAttributes().GetInput()
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED:
+		return event.GetActivityTaskScheduledEventAttributes().GetInput()
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_COMPLETED:
+		return event.GetActivityTaskCompletedEventAttributes().GetResult()
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_CANCELED:
+		return event.GetActivityTaskCanceledEventAttributes().GetDetails()
+	case enumspb.EVENT_TYPE_START_CHILD_WORKFLOW_EXECUTION_INITIATED:
+		return event.GetStartChildWorkflowExecutionInitiatedEventAttributes().GetInput()
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_COMPLETED:
+		return event.GetChildWorkflowExecutionCompletedEventAttributes().GetResult()
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_CANCELED:
+		return event.GetChildWorkflowExecutionCanceledEventAttributes().GetDetails()
+	case enumspb.EVENT_TYPE_SIGNAL_EXTERNAL_WORKFLOW_EXECUTION_INITIATED:
+		return event.GetSignalExternalWorkflowExecutionInitiatedEventAttributes().GetInput()
+	default:
+		return nil
+	}
+}
+
+// historyJSONIndent is the indentation of the histories written by WriteHistoryJSON, which matches the files written
+// by the CLI.
+const historyJSONIndent = "  "
+
+// CollectHistory reads all the events from iter, usually returned by Client.GetWorkflowHistory, into a History which
+// can be written with WriteHistoryJSON or passed to WorkflowReplayer.ReplayWorkflowHistory.
+func CollectHistory(iter HistoryEventIterator) (*historypb.History, error) {
+	history := &historypb.History{}
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		history.Events = append(history.Events, event)
+	}
+	return history, nil
+}
+
+// WriteHistoryJSON writes the history in the JSON format used by the CLI to export histories. The result can be
+// loaded with ReadHistoryJSON or WorkflowReplayer.ReplayWorkflowHistoryFromJSONFile.
+func WriteHistoryJSON(w io.Writer, history *historypb.History) error {
+	marshaler := jsonpb.Marshaler{Indent: historyJSONIndent}
+	return marshaler.Marshal(w, history)
+}
+
+// ReadHistoryJSON reads a history in the JSON format used by the CLI to export histories, as written by
+// WriteHistoryJSON.
+func ReadHistoryJSON(r io.Reader) (*historypb.History, error) {
+	var history historypb.History
+	if err := jsonpb.Unmarshal(r, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// HistoryDifference is a difference between two histories, found by DiffHistories, or between a history and the
+// commands generated when replaying it, found by WorkflowReplayer.DiffWorkflowHistory.
+type HistoryDifference struct {
+	// EventID is the ID of the event of the expected history, zero if the difference is an extra event or command.
+	EventID int64
+	// Expected describes the event of the expected history, empty if the difference is an extra event or command.
+	Expected string
+	// Actual describes the event of the other history or the command generated by the replay, empty if it is
+	// missing.
+	Actual string
+}
+
+// String returns a human readable description of the difference.
+func (d HistoryDifference) String() string {
+	switch {
+	case d.Expected == "":
+		return fmt.Sprintf("extra %v", d.Actual)
+	case d.Actual == "":
+		return fmt.Sprintf("event %v: missing %v", d.EventID, d.Expected)
+	default:
+		return fmt.Sprintf("event %v: expected %v, got %v", d.EventID, d.Expected, d.Actual)
+	}
+}
+
+// WriteHistoryText writes the history in a human readable format, one event per line, with the payloads of the
+// events decoded with the given data converter. The default data converter is used if it is nil.
+func WriteHistoryText(w io.Writer, history *historypb.History, dataConverter converter.DataConverter) error {
+	if dataConverter == nil {
+		dataConverter = converter.GetDefaultDataConverter()
+	}
+	describer := newHistoryEventDescriber()
+	for _, event := range history.GetEvents() {
+		line := fmt.Sprintf("%5d  %v  %v", event.GetEventId(),
+			common.TimeValue(event.GetEventTime()).Format(time.RFC3339Nano), describer.describe(event))
+		if payloads := getHistoryEventPayloads(event); payloads != nil {
+			line += " [" + strings.Join(dataConverter.ToStrings(payloads), ", ") + "]"
+		}
+		if failure := getHistoryEventFailure(event); failure != nil {
+			line += fmt.Sprintf(" failure=%q", failure.GetMessage())
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiffHistories returns the semantic differences between two histories of the same workflow, e.g. a history from
+// production and the history of the same workflow run against a new version of the code. Events are compared by type
+// and identity (activity ID and type, timer ID, marker name, signal name, child workflow ID and type...), ignoring
+// times, payloads, worker identities and workflow task events, which differ between runs of the same code.
+func DiffHistories(expected, actual *historypb.History) []HistoryDifference {
+	expectedEvents := withoutWorkflowTaskEvents(expected.GetEvents())
+	actualEvents := withoutWorkflowTaskEvents(actual.GetEvents())
+	expectedDescriptions := describeHistoryEvents(expectedEvents)
+	actualDescriptions := describeHistoryEvents(actualEvents)
+
+	var differences []HistoryDifference
+	alignInOrder(len(expectedEvents), len(actualEvents), func(i, j int) bool {
+		return expectedDescriptions[i] == actualDescriptions[j]
+	}, func(i, j int) {
+		var d HistoryDifference
+		if i >= 0 {
+			d.EventID = expectedEvents[i].GetEventId()
+			d.Expected = expectedDescriptions[i]
+		}
+		if j >= 0 {
+			d.Actual = actualDescriptions[j]
+		}
+		differences = append(differences, d)
+	})
+	return differences
+}
+
+// diffReplayWithHistory returns all the differences between the replay commands and the history events, with the
+// same rules as matchReplayWithHistory, which stops at the first one.
+func diffReplayWithHistory(replayCommands []*commandpb.Command, historyEvents []*historypb.HistoryEvent) []HistoryDifference {
+	var events []*historypb.HistoryEvent
+	for i := 0; i < len(historyEvents); i++ {
+		if skipDeterministicCheckForUpsertChangeVersion(historyEvents, i) {
+			i++
+			continue
+		}
+		if !skipDeterministicCheckForEvent(historyEvents[i]) {
+			events = append(events, historyEvents[i])
+		}
+	}
+	var commands []*commandpb.Command
+	for _, d := range replayCommands {
+		if !skipDeterministicCheckForCommand(d) {
+			commands = append(commands, d)
+		}
+	}
+	descriptions := describeHistoryEvents(events)
+
+	var differences []HistoryDifference
+	alignInOrder(len(events), len(commands), func(i, j int) bool {
+		return isCommandMatchEvent(commands[j], events[i], false)
+	}, func(i, j int) {
+		var d HistoryDifference
+		if i >= 0 {
+			d.EventID = events[i].GetEventId()
+			d.Expected = descriptions[i]
+		}
+		if j >= 0 {
+			d.Actual = describeCommand(commands[j])
+		}
+		differences = append(differences, d)
+	})
+	return differences
+}
+
+// alignInOrder compares expected and actual elements in order, calling onMismatch(i, j) for each difference: with
+// j = -1 if the expected element i is missing, with i = -1 if the actual element j is extra. A single missing or
+// extra element is detected by looking one element ahead, so that it doesn't shift the rest of the comparison.
+func alignInOrder(expectedLen, actualLen int, match func(i, j int) bool, onMismatch func(i, j int)) {
+	i, j := 0, 0
+	for i < expectedLen || j < actualLen {
+		switch {
+		case i == expectedLen:
+			onMismatch(-1, j)
+			j++
+		case j == actualLen:
+			onMismatch(i, -1)
+			i++
+		case match(i, j):
+			i++
+			j++
+		case j+1 < actualLen && match(i, j+1):
+			onMismatch(-1, j)
+			j++
+		case i+1 < expectedLen && match(i+1, j):
+			onMismatch(i, -1)
+			i++
+		default:
+			onMismatch(i, j)
+			i++
+			j++
+		}
+	}
+}
+
+func withoutWorkflowTaskEvents(events []*historypb.HistoryEvent) []*historypb.HistoryEvent {
+	var result []*historypb.HistoryEvent
+	for _, event := range events {
+		switch event.GetEventType() {
+		case enumspb.EVENT_TYPE_WORKFLOW_TASK_SCHEDULED,
+			enumspb.EVENT_TYPE_WORKFLOW_TASK_STARTED,
+			enumspb.EVENT_TYPE_WORKFLOW_TASK_COMPLETED,
+			enumspb.EVENT_TYPE_WORKFLOW_TASK_TIMED_OUT,
+			enumspb.EVENT_TYPE_WORKFLOW_TASK_FAILED:
+		default:
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func describeHistoryEvents(events []*historypb.HistoryEvent) []string {
+	describer := newHistoryEventDescriber()
+	descriptions := make([]string, len(events))
+	for i, event := range events {
+		descriptions[i] = describer.describe(event)
+	}
+	return descriptions
+}
+
+// historyEventDescriber describes history events by their type and identity. The events referring to an activity or
+// a child workflow by event ID are described with the ID of the activity or the child workflow, so that descriptions
+// don't depend on the position of the events in the history.
+type historyEventDescriber struct {
+	activityIDs      map[int64]string
+	childWorkflowIDs map[int64]string
+}
+
+func newHistoryEventDescriber() *historyEventDescriber {
+	return &historyEventDescriber{
+		activityIDs:      make(map[int64]string),
+		childWorkflowIDs: make(map[int64]string),
+	}
+}
+
+func (h *historyEventDescriber) describe(event *historypb.HistoryEvent) string {
+	var fields []string
+	switch event.GetEventType() {
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED:
+		fields = []string{"workflowType", event.GetWorkflowExecutionStartedEventAttributes().GetWorkflowType().GetName()}
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED:
+		fields = []string{"signalName", event.GetWorkflowExecutionSignaledEventAttributes().GetSignalName()}
+	case enumspb.EVENT_TYPE_MARKER_RECORDED:
+		fields = []string{"markerName", event.GetMarkerRecordedEventAttributes().GetMarkerName()}
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED:
+		attributes := event.GetActivityTaskScheduledEventAttributes()
+		h.activityIDs[event.GetEventId()] = attributes.GetActivityId()
+		fields = []string{"activityId", attributes.GetActivityId(), "activityType", attributes.GetActivityType().GetName()}
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_STARTED:
+		fields = h.activityFields(event.GetActivityTaskStartedEventAttributes().GetScheduledEventId())
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_COMPLETED:
+		fields = h.activityFields(event.GetActivityTaskCompletedEventAttributes().GetScheduledEventId())
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_FAILED:
+		fields = h.activityFields(event.GetActivityTaskFailedEventAttributes().GetScheduledEventId())
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_TIMED_OUT:
+		fields = h.activityFields(event.GetActivityTaskTimedOutEventAttributes().GetScheduledEventId())
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_CANCEL_REQUESTED:
+		fields = h.activityFields(event.GetActivityTaskCancelRequestedEventAttributes().GetScheduledEventId())
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_CANCELED:
+		fields = h.activityFields(event.GetActivityTaskCanceledEventAttributes().GetScheduledEventId())
+	case enumspb.EVENT_TYPE_TIMER_STARTED:
+		fields = []string{"timerId", event.GetTimerStartedEventAttributes().GetTimerId()}
+	case enumspb.EVENT_TYPE_TIMER_FIRED:
+		fields = []string{"timerId", event.GetTimerFiredEventAttributes().GetTimerId()}
+	case enumspb.EVENT_TYPE_TIMER_CANCELED:
+		fields = []string{"timerId", event.GetTimerCanceledEventAttributes().GetTimerId()}
+	case enumspb.EVENT_TYPE_START_CHILD_WORKFLOW_EXECUTION_INITIATED:
+		attributes := event.GetStartChildWorkflowExecutionInitiatedEventAttributes()
+		h.childWorkflowIDs[event.GetEventId()] = attributes.GetWorkflowId()
+		fields = []string{"workflowId", attributes.GetWorkflowId(), "workflowType", attributes.GetWorkflowType().GetName()}
+	case enumspb.EVENT_TYPE_START_CHILD_WORKFLOW_EXECUTION_FAILED:
+		fields = h.childWorkflowFields(event.GetStartChildWorkflowExecutionFailedEventAttributes().GetInitiatedEventId())
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_STARTED:
+		fields = h.childWorkflowFields(event.GetChildWorkflowExecutionStartedEventAttributes().GetInitiatedEventId())
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_COMPLETED:
+		fields = h.childWorkflowFields(event.GetChildWorkflowExecutionCompletedEventAttributes().GetInitiatedEventId())
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_FAILED:
+		fields = h.childWorkflowFields(event.GetChildWorkflowExecutionFailedEventAttributes().GetInitiatedEventId())
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_TIMED_OUT:
+		fields = h.childWorkflowFields(event.GetChildWorkflowExecutionTimedOutEventAttributes().GetInitiatedEventId())
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_CANCELED:
+		fields = h.childWorkflowFields(event.GetChildWorkflowExecutionCanceledEventAttributes().GetInitiatedEventId())
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_TERMINATED:
+		fields = h.childWorkflowFields(event.GetChildWorkflowExecutionTerminatedEventAttributes().GetInitiatedEventId())
+	case enumspb.EVENT_TYPE_SIGNAL_EXTERNAL_WORKFLOW_EXECUTION_INITIATED:
+		attributes := event.GetSignalExternalWorkflowExecutionInitiatedEventAttributes()
+		fields = []string{"workflowId", attributes.GetWorkflowExecution().GetWorkflowId(), "signalName", attributes.GetSignalName()}
+	case enumspb.EVENT_TYPE_REQUEST_CANCEL_EXTERNAL_WORKFLOW_EXECUTION_INITIATED:
+		fields = []string{"workflowId", event.GetRequestCancelExternalWorkflowExecutionInitiatedEventAttributes().GetWorkflowExecution().GetWorkflowId()}
+	}
+	return describe(event.GetEventType().String(), fields)
+}
+
+func (h *historyEventDescriber) activityFields(scheduledEventID int64) []string {
+	if activityID, ok := h.activityIDs[scheduledEventID]; ok {
+		return []string{"activityId", activityID}
+	}
+	return []string{"scheduledEventId", strconv.FormatInt(scheduledEventID, 10)}
+}
+
+func (h *historyEventDescriber) childWorkflowFields(initiatedEventID int64) []string {
+	if workflowID, ok := h.childWorkflowIDs[initiatedEventID]; ok {
+		return []string{"workflowId", workflowID}
+	}
+	return []string{"initiatedEventId", strconv.FormatInt(initiatedEventID, 10)}
+}
+
+func describeCommand(d *commandpb.Command) string {
+	var fields []string
+	switch d.GetCommandType() {
+	case enumspb.COMMAND_TYPE_SCHEDULE_ACTIVITY_TASK:
+		attributes := d.GetScheduleActivityTaskCommandAttributes()
+		fields = []string{"activityId", attributes.GetActivityId(), "activityType", attributes.GetActivityType().GetName()}
+	case enumspb.COMMAND_TYPE_REQUEST_CANCEL_ACTIVITY_TASK:
+		fields = []string{"scheduledEventId", strconv.FormatInt(d.GetRequestCancelActivityTaskCommandAttributes().GetScheduledEventId(), 10)}
+	case enumspb.COMMAND_TYPE_START_TIMER:
+		fields = []string{"timerId", d.GetStartTimerCommandAttributes().GetTimerId()}
+	case enumspb.COMMAND_TYPE_CANCEL_TIMER:
+		fields = []string{"timerId", d.GetCancelTimerCommandAttributes().GetTimerId()}
+	case enumspb.COMMAND_TYPE_RECORD_MARKER:
+		fields = []string{"markerName", d.GetRecordMarkerCommandAttributes().GetMarkerName()}
+	case enumspb.COMMAND_TYPE_START_CHILD_WORKFLOW_EXECUTION:
+		attributes := d.GetStartChildWorkflowExecutionCommandAttributes()
+		fields = []string{"workflowId", attributes.GetWorkflowId(), "workflowType", attributes.GetWorkflowType().GetName()}
+	case enumspb.COMMAND_TYPE_SIGNAL_EXTERNAL_WORKFLOW_EXECUTION:
+		attributes := d.GetSignalExternalWorkflowExecutionCommandAttributes()
+		fields = []string{"workflowId", attributes.GetExecution().GetWorkflowId(), "signalName", attributes.GetSignalName()}
+	case enumspb.COMMAND_TYPE_REQUEST_CANCEL_EXTERNAL_WORKFLOW_EXECUTION:
+		fields = []string{"workflowId", d.GetRequestCancelExternalWorkflowExecutionCommandAttributes().GetWorkflowId()}
+	}
+	return describe(d.GetCommandType().String(), fields)
+}
+
+// describe formats the name and the key value pairs of fields as name(key=value, ...).
+func describe(name string, fields []string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteString("(")
+	for i := 0; i+1 < len(fields); i += 2 {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(fields[i])
+		b.WriteString("=")
+		b.WriteString(fields[i+1])
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func getHistoryEventFailure(event *historypb.HistoryEvent) *failurepb.Failure {
+	switch event.GetEventType() {
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_FAILED:
+		return event.GetWorkflowExecutionFailedEventAttributes().GetFailure()
+	case enumspb.EVENT_TYPE_WORKFLOW_TASK_FAILED:
+		return event.GetWorkflowTaskFailedEventAttributes().GetFailure()
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_FAILED:
+		return event.GetActivityTaskFailedEventAttributes().GetFailure()
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_TIMED_OUT:
+		return event.GetActivityTaskTimedOutEventAttributes().GetFailure()
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_FAILED:
+		return event.GetChildWorkflowExecutionFailedEventAttributes().GetFailure()
+	default:
+		return nil
+	}
+}
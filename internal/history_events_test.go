@@ -0,0 +1,255 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	commandpb "go.temporal.io/api/command/v1"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	failurepb "go.temporal.io/api/failure/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflowservice/v1"
+
+	"go.temporal.io/sdk/converter"
+)
+
+func newTestHistoryEventIterator(events []*historypb.HistoryEvent, err error) HistoryEventIterator {
+	return &historyEventIteratorImpl{
+		paginate: func(nextToken []byte) (*workflowservice.GetWorkflowExecutionHistoryResponse, error) {
+			if err != nil {
+				return nil, err
+			}
+			return &workflowservice.GetWorkflowExecutionHistoryResponse{History: &historypb.History{Events: events}}, nil
+		},
+	}
+}
+
+func collectHistoryEventIDs(t *testing.T, iter HistoryEventIterator) []int64 {
+	var eventIDs []int64
+	for iter.HasNext() {
+		event, err := iter.Next()
+		require.NoError(t, err)
+		eventIDs = append(eventIDs, event.GetEventId())
+	}
+	return eventIDs
+}
+
+func TestFilteredHistoryEventIterator(t *testing.T) {
+	t.Parallel()
+	startTime := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	events := []*historypb.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &historypb.WorkflowExecutionStartedEventAttributes{}),
+		createTestEventWorkflowTaskScheduled(2, &historypb.WorkflowTaskScheduledEventAttributes{}),
+		createTestEventWorkflowExecutionSignaled(3, "signal"),
+		createTestEventWorkflowTaskScheduled(4, &historypb.WorkflowTaskScheduledEventAttributes{}),
+		createTestEventWorkflowExecutionSignaled(5, "signal"),
+		createTestEventWorkflowExecutionSignaled(6, "signal"),
+	}
+	for i, event := range events {
+		eventTime := startTime.Add(time.Duration(i) * time.Minute)
+		event.EventTime = &eventTime
+	}
+
+	iter := NewFilteredHistoryEventIterator(newTestHistoryEventIterator(events, nil), HistoryEventFilter{})
+	require.Equal(t, []int64{1, 2, 3, 4, 5, 6}, collectHistoryEventIDs(t, iter))
+
+	iter = NewFilteredHistoryEventIterator(newTestHistoryEventIterator(events, nil), HistoryEventFilter{
+		EventTypes: []enumspb.EventType{enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED},
+	})
+	require.Equal(t, []int64{3, 5, 6}, collectHistoryEventIDs(t, iter))
+
+	iter = NewFilteredHistoryEventIterator(newTestHistoryEventIterator(events, nil), HistoryEventFilter{
+		EventTypes: []enumspb.EventType{enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED},
+		StartTime:  startTime.Add(3 * time.Minute),
+		EndTime:    startTime.Add(5 * time.Minute),
+	})
+	require.Equal(t, []int64{5}, collectHistoryEventIDs(t, iter))
+
+	iter = NewFilteredHistoryEventIterator(newTestHistoryEventIterator(nil, errors.New("history error")), HistoryEventFilter{})
+	require.True(t, iter.HasNext())
+	_, err := iter.Next()
+	require.EqualError(t, err, "history error")
+	require.False(t, iter.HasNext())
+}
+
+func TestGetHistoryEventPayloads(t *testing.T) {
+	t.Parallel()
+	dc := converter.GetDefaultDataConverter()
+	input, err := dc.ToPayloads("hello", 42)
+	require.NoError(t, err)
+
+	values := GetHistoryEventPayloads(createTestEventWorkflowExecutionSignaledWithPayload(1, "signal", input), dc)
+	require.NotNil(t, values)
+	var s string
+	var i int
+	require.NoError(t, values.Get(&s, &i))
+	require.Equal(t, "hello", s)
+	require.Equal(t, 42, i)
+
+	result, err := dc.ToPayloads("result")
+	require.NoError(t, err)
+	values = GetHistoryEventPayloads(createTestEventActivityTaskCompleted(2, &historypb.ActivityTaskCompletedEventAttributes{Result: result}), dc)
+	require.NotNil(t, values)
+	require.NoError(t, values.Get(&s))
+	require.Equal(t, "result", s)
+
+	require.Nil(t, GetHistoryEventPayloads(createTestEventWorkflowTaskStarted(3), dc))
+}
+
+func TestHistoryJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	file, err := os.Open("testdata/sampleHistory.json")
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	history, err := ReadHistoryJSON(file)
+	require.NoError(t, err)
+	require.NotEmpty(t, history.Events)
+
+	collected, err := CollectHistory(newTestHistoryEventIterator(history.Events, nil))
+	require.NoError(t, err)
+	require.True(t, proto.Equal(history, collected))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHistoryJSON(&buf, collected))
+	loaded, err := ReadHistoryJSON(&buf)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(history, loaded))
+
+	_, err = CollectHistory(newTestHistoryEventIterator(nil, errors.New("history error")))
+	require.EqualError(t, err, "history error")
+}
+
+func TestWriteHistoryText(t *testing.T) {
+	t.Parallel()
+	dc := converter.GetDefaultDataConverter()
+	input, err := dc.ToPayloads("hello")
+	require.NoError(t, err)
+	history := &historypb.History{Events: []*historypb.HistoryEvent{
+		createTestEventWorkflowExecutionSignaledWithPayload(1, "signal", input),
+		createTestEventActivityTaskScheduled(2, &historypb.ActivityTaskScheduledEventAttributes{
+			ActivityId:   "2",
+			ActivityType: &commonpb.ActivityType{Name: "testActivity"},
+		}),
+		createTestEventActivityTaskTimedOut(3, &historypb.ActivityTaskTimedOutEventAttributes{
+			ScheduledEventId: 2,
+			Failure:          &failurepb.Failure{Message: "activity timeout"},
+		}),
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHistoryText(&buf, history, nil))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "signalName=signal")
+	require.Contains(t, lines[0], `"hello"`)
+	require.Contains(t, lines[1], "activityId=2, activityType=testActivity")
+	require.Contains(t, lines[2], "activityId=2")
+	require.Contains(t, lines[2], `failure="activity timeout"`)
+}
+
+func TestDiffHistories(t *testing.T) {
+	t.Parallel()
+	scheduled := func(eventID int64, activityType string) *historypb.HistoryEvent {
+		return createTestEventActivityTaskScheduled(eventID, &historypb.ActivityTaskScheduledEventAttributes{
+			ActivityId:   fmt.Sprintf("%v", eventID),
+			ActivityType: &commonpb.ActivityType{Name: activityType},
+		})
+	}
+	expected := &historypb.History{Events: []*historypb.HistoryEvent{
+		createTestEventWorkflowTaskScheduled(1, &historypb.WorkflowTaskScheduledEventAttributes{}),
+		createTestEventTimerStarted(2, 2),
+		createTestEventTimerFired(3, 2),
+		createTestEventWorkflowExecutionSignaled(4, "signal"),
+	}}
+	require.Empty(t, DiffHistories(expected, expected))
+
+	// Workflow task events and event IDs don't matter, the activity is extra and the signal changed.
+	actual := &historypb.History{Events: []*historypb.HistoryEvent{
+		createTestEventTimerStarted(1, 2),
+		scheduled(2, "testActivity"),
+		createTestEventTimerFired(3, 2),
+		createTestEventWorkflowExecutionSignaled(4, "other-signal"),
+	}}
+	differences := DiffHistories(expected, actual)
+	require.Len(t, differences, 2)
+	require.Equal(t, HistoryDifference{Actual: "ActivityTaskScheduled(activityId=2, activityType=testActivity)"}, differences[0])
+	require.Equal(t, int64(4), differences[1].EventID)
+	require.Contains(t, differences[1].Expected, "signalName=signal")
+	require.Contains(t, differences[1].Actual, "signalName=other-signal")
+
+	differences = DiffHistories(actual, expected)
+	require.Len(t, differences, 2)
+	require.Equal(t, int64(2), differences[0].EventID)
+	require.Empty(t, differences[0].Actual)
+}
+
+func TestDiffReplayWithHistory(t *testing.T) {
+	t.Parallel()
+	events := []*historypb.HistoryEvent{
+		createTestEventActivityTaskScheduled(5, &historypb.ActivityTaskScheduledEventAttributes{
+			ActivityId:   "5",
+			ActivityType: &commonpb.ActivityType{Name: "testActivity"},
+		}),
+		createTestEventTimerStarted(6, 6),
+	}
+	commands := []*commandpb.Command{
+		{
+			CommandType: enumspb.COMMAND_TYPE_SCHEDULE_ACTIVITY_TASK,
+			Attributes: &commandpb.Command_ScheduleActivityTaskCommandAttributes{ScheduleActivityTaskCommandAttributes: &commandpb.ScheduleActivityTaskCommandAttributes{
+				ActivityId:   "5",
+				ActivityType: &commonpb.ActivityType{Name: "otherActivity"},
+			}},
+		},
+		{
+			CommandType: enumspb.COMMAND_TYPE_START_TIMER,
+			Attributes: &commandpb.Command_StartTimerCommandAttributes{StartTimerCommandAttributes: &commandpb.StartTimerCommandAttributes{
+				TimerId: "6",
+			}},
+		},
+	}
+	require.NoError(t, matchReplayWithHistory(commands[1:], events[1:]))
+	require.Empty(t, diffReplayWithHistory(commands[1:], events[1:]))
+
+	differences := diffReplayWithHistory(commands, events)
+	require.Len(t, differences, 1)
+	require.Equal(t, int64(5), differences[0].EventID)
+	require.Contains(t, differences[0].Expected, "activityType=testActivity")
+	require.Contains(t, differences[0].Actual, "activityType=otherActivity")
+	require.Contains(t, differences[0].String(), "event 5: expected ")
+
+	differences = diffReplayWithHistory(commands[1:], events)
+	require.Len(t, differences, 1)
+	require.Equal(t, "event 5: missing ActivityTaskScheduled(activityId=5, activityType=testActivity)", differences[0].String())
+}
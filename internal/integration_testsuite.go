@@ -0,0 +1,197 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+)
+
+const (
+	// integrationEnvHostPortEnvVar overrides the address of the server used by IntegrationEnv when
+	// IntegrationEnvOptions.ClientOptions.HostPort isn't set. It is the variable used by the SDK integration tests.
+	integrationEnvHostPortEnvVar = "SERVICE_ADDR"
+
+	defaultIntegrationEnvRetention    = 24 * time.Hour
+	defaultIntegrationEnvReadyTimeout = time.Minute
+	integrationEnvRetryInterval       = time.Second
+)
+
+type (
+	// IntegrationEnvOptions configure NewIntegrationEnv.
+	IntegrationEnvOptions struct {
+		// Optional: Options of the client used to connect to the server.
+		// HostPort defaults to the SERVICE_ADDR environment variable, or to localhost:7233 if it isn't set.
+		// If Namespace is empty, a temporary namespace with a random name is registered.
+		ClientOptions ClientOptions
+
+		// Optional: Workflow execution retention period of the registered namespace.
+		// default: 1 day
+		NamespaceRetention time.Duration
+
+		// Optional: How long to wait for the server to accept requests on the namespace.
+		// default: 1 minute
+		ReadyTimeout time.Duration
+	}
+
+	// IntegrationEnv attaches end-to-end tests to a running Temporal server, for example one started by docker-compose
+	// in CI. It registers the test namespace, waits until the server accepts requests on it, and provides a client and
+	// workers which are stopped by Close.
+	IntegrationEnv struct {
+		client    Client
+		namespace string
+
+		lock    sync.Mutex
+		workers []*AggregatedWorker
+	}
+)
+
+// NewIntegrationEnv connects to the Temporal server, registers the namespace if it doesn't exist yet, and waits until
+// the server serves requests on it.
+func NewIntegrationEnv(options IntegrationEnvOptions) (*IntegrationEnv, error) {
+	clientOptions := options.ClientOptions
+	if clientOptions.HostPort == "" {
+		clientOptions.HostPort = strings.TrimSpace(os.Getenv(integrationEnvHostPortEnvVar))
+	}
+	if clientOptions.HostPort == "" {
+		clientOptions.HostPort = LocalHostPort
+	}
+	if clientOptions.Namespace == "" {
+		clientOptions.Namespace = "test-" + uuid.New()
+	}
+	retention := options.NamespaceRetention
+	if retention == 0 {
+		retention = defaultIntegrationEnvRetention
+	}
+	readyTimeout := options.ReadyTimeout
+	if readyTimeout == 0 {
+		readyTimeout = defaultIntegrationEnvReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+
+	if err := registerIntegrationEnvNamespace(ctx, clientOptions, retention); err != nil {
+		return nil, err
+	}
+
+	client, err := NewClient(clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create client: %w", err)
+	}
+	// The namespace is only usable once the namespace cache of the server has been refreshed.
+	err = retryIntegrationEnvCall(ctx, func() error {
+		_, err := client.DescribeTaskQueue(ctx, "integration-env-readiness", enumspb.TASK_QUEUE_TYPE_WORKFLOW)
+		return err
+	})
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("namespace %v is not ready: %w", clientOptions.Namespace, err)
+	}
+
+	return &IntegrationEnv{client: client, namespace: clientOptions.Namespace}, nil
+}
+
+func registerIntegrationEnvNamespace(ctx context.Context, options ClientOptions, retention time.Duration) error {
+	namespaceClient, err := NewNamespaceClient(options)
+	if err != nil {
+		return fmt.Errorf("unable to create namespace client: %w", err)
+	}
+	defer namespaceClient.Close()
+
+	err = retryIntegrationEnvCall(ctx, func() error {
+		err := namespaceClient.Register(ctx, &workflowservice.RegisterNamespaceRequest{
+			Namespace:                        options.Namespace,
+			WorkflowExecutionRetentionPeriod: &retention,
+		})
+		if _, ok := err.(*serviceerror.NamespaceAlreadyExists); ok {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to register namespace %v: %w", options.Namespace, err)
+	}
+	return nil
+}
+
+// retryIntegrationEnvCall retries call while the server isn't reachable or doesn't know the namespace yet.
+func retryIntegrationEnvCall(ctx context.Context, call func() error) error {
+	for {
+		err := call()
+		var notFoundErr *serviceerror.NotFound
+		var unavailableErr *serviceerror.Unavailable
+		if err == nil || !(errors.As(err, &notFoundErr) || errors.As(err, &unavailableErr) || errors.Is(err, context.DeadlineExceeded)) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(integrationEnvRetryInterval):
+		}
+	}
+}
+
+// Client returns the client connected to the namespace of the environment.
+func (e *IntegrationEnv) Client() Client {
+	return e.client
+}
+
+// Namespace returns the namespace used by the environment.
+func (e *IntegrationEnv) Namespace() string {
+	return e.namespace
+}
+
+// NewWorker creates a worker polling the task queue on the namespace of the environment. The worker still needs to be
+// started, and must not be stopped by the test as Close stops it.
+func (e *IntegrationEnv) NewWorker(taskQueue string, options WorkerOptions) *AggregatedWorker {
+	w := NewWorker(e.client, taskQueue, options)
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.workers = append(e.workers, w)
+	return w
+}
+
+// Close stops the workers created by NewWorker and closes the client. The namespace is left to the server, its
+// workflow executions are removed after the retention period.
+func (e *IntegrationEnv) Close() {
+	e.lock.Lock()
+	workers := e.workers
+	e.workers = nil
+	e.lock.Unlock()
+	for _, w := range workers {
+		w.Stop()
+	}
+	e.client.Close()
+}
@@ -82,6 +82,7 @@ type WorkflowOutboundCallsInterceptor interface {
 	GetMetricsScope(ctx Context) tally.Scope
 	Now(ctx Context) time.Time
 	NewTimer(ctx Context, d time.Duration) Future
+	NewTimerWithOptions(ctx Context, d time.Duration, options TimerOptions) Future
 	Sleep(ctx Context, d time.Duration) (err error)
 	RequestCancelExternalWorkflow(ctx Context, workflowID, runID string) Future
 	SignalExternalWorkflow(ctx Context, workflowID, runID, signalName string, arg interface{}) Future
@@ -178,6 +179,11 @@ func (t *WorkflowOutboundCallsInterceptorBase) NewTimer(ctx Context, d time.Dura
 	return t.Next.NewTimer(ctx, d)
 }
 
+// NewTimerWithOptions forwards to t.Next
+func (t *WorkflowOutboundCallsInterceptorBase) NewTimerWithOptions(ctx Context, d time.Duration, options TimerOptions) Future {
+	return t.Next.NewTimerWithOptions(ctx, d, options)
+}
+
 // Sleep forwards to t.Next
 func (t *WorkflowOutboundCallsInterceptorBase) Sleep(ctx Context, d time.Duration) (err error) {
 	return t.Next.Sleep(ctx, d)
@@ -49,6 +49,12 @@ type (
 		GetFunction() interface{}
 	}
 
+	// dynamicActivity adapts a DynamicActivityFunc to the activity interface for a single activity type.
+	dynamicActivity struct {
+		name string
+		fn   DynamicActivityFunc
+	}
+
 	// ActivityID uniquely identifies an activity execution
 	ActivityID struct {
 		id string
@@ -70,6 +76,13 @@ type (
 		WaitForCancellation    bool
 		OriginalTaskQueueName  string
 		RetryPolicy            *commonpb.RetryPolicy
+		AffinityKey            string
+		Priority               int
+		RetryTaskQueue         string
+		// RetryStartToCloseTimeoutCoefficient and RetryMaximumAttemptsDuration are the fields of the RetryPolicy
+		// which are not part of commonpb.RetryPolicy.
+		RetryStartToCloseTimeoutCoefficient float64
+		RetryMaximumAttemptsDuration        time.Duration
 	}
 
 	// ExecuteLocalActivityOptions options for executing a local activity
@@ -143,6 +156,12 @@ type (
 		workerStopChannel  <-chan struct{}
 		contextPropagators []ContextPropagator
 		tracer             opentracing.Tracer
+		// currentAttemptScheduledTime is the time the current attempt was scheduled.
+		currentAttemptScheduledTime time.Time
+		// pauser tells whether the activity type is paused on the worker. Nil for local activities.
+		pauser *activityPauser
+		// workerIdentity is the identity of the worker running the activity.
+		workerIdentity string
 	}
 
 	// context.WithValue need this type instead of basic type string to avoid lint error
@@ -155,6 +174,18 @@ const (
 	localActivityOptionsContextKey contextKey = "localActivityOptions"
 )
 
+func (a *dynamicActivity) Execute(ctx context.Context, input *commonpb.Payloads) (*commonpb.Payloads, error) {
+	return a.fn(ctx, a.name, input)
+}
+
+func (a *dynamicActivity) ActivityType() ActivityType {
+	return ActivityType{Name: a.name}
+}
+
+func (a *dynamicActivity) GetFunction() interface{} {
+	return a.fn
+}
+
 func (i ActivityID) String() string {
 	return i.id
 }
@@ -672,6 +672,21 @@ func TestAsyncSendClosedChannel(t *testing.T) {
 	require.True(t, d.IsDone())
 }
 
+func TestTimerWithSummaryStackTrace(t *testing.T) {
+	d := createNewDispatcher(func(ctx Context) {
+		GoNamed(ctx, "anonymous", func(ctx Context) {
+			_ = Sleep(ctx, time.Minute)
+		})
+		_ = SleepWithOptions(ctx, time.Minute, TimerOptions{Summary: "grace period"})
+	})
+	defer d.Close()
+	requireNoExecuteErr(t, d.ExecuteUntilAllBlocked(defaultDeadlockDetectionTimeout))
+	require.False(t, d.IsDone())
+	stack := d.StackTrace()
+	require.Contains(t, stack, "coroutine root [blocked on Timer(grace period).Receive]:")
+	require.Contains(t, stack, "coroutine anonymous [blocked on chan-")
+}
+
 func TestDispatchClose(t *testing.T) {
 	var history []string
 	d := createNewDispatcher(func(ctx Context) {
@@ -1120,6 +1135,52 @@ func TestSelectFuture(t *testing.T) {
 	require.EqualValues(t, expected, history)
 }
 
+func TestSelectWithPriority(t *testing.T) {
+	var history []string
+	d := createNewDispatcher(func(ctx Context) {
+		low := NewBufferedChannel(ctx, 5)
+		normal := NewBufferedChannel(ctx, 5)
+		high := NewBufferedChannel(ctx, 5)
+		low.SendAsync("low")
+		normal.SendAsync("normal")
+		high.SendAsync("high1")
+		high.SendAsync("high2")
+
+		receive := func(c ReceiveChannel, more bool) {
+			var v string
+			c.Receive(ctx, &v)
+			history = append(history, v)
+		}
+		s := NewSelector(ctx)
+		s.AddReceiveWithPriority(low, -1, receive)
+		s.AddReceive(normal, receive)
+		s.AddReceiveWithPriority(high, 1, receive)
+		for s.HasPending() {
+			s.Select(ctx)
+		}
+	})
+	defer d.Close()
+	requireNoExecuteErr(t, d.ExecuteUntilAllBlocked(defaultDeadlockDetectionTimeout))
+	require.True(t, d.IsDone())
+	require.EqualValues(t, []string{"high1", "high2", "normal", "low"}, history)
+}
+
+func TestSelectorBranchLabelsStackTrace(t *testing.T) {
+	d := createNewDispatcher(func(ctx Context) {
+		c := NewChannel(ctx)
+		f, _ := NewFuture(ctx)
+		NewNamedSelector(ctx, "events").
+			AddReceiveWithOptions(c, SelectorBranchOptions{Label: "approval"}, func(c ReceiveChannel, more bool) {}).
+			AddFuture(f, func(f Future) {}).
+			AddFutureWithOptions(f, SelectorBranchOptions{Label: "deadline"}, func(f Future) {}).
+			Select(ctx)
+	})
+	defer d.Close()
+	requireNoExecuteErr(t, d.ExecuteUntilAllBlocked(defaultDeadlockDetectionTimeout))
+	require.False(t, d.IsDone())
+	require.Contains(t, d.StackTrace(), "coroutine root [blocked on events.Select(approval, deadline)]:")
+}
+
 func TestSelectDecodeFuture(t *testing.T) {
 	var history []string
 	d := createNewDispatcher(func(ctx Context) {
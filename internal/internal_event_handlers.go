@@ -288,6 +288,14 @@ func (wc *workflowEnvironmentImpl) WorkflowInfo() *WorkflowInfo {
 	return wc.workflowInfo
 }
 
+func (wc *workflowEnvironmentImpl) ChangeVersions() map[string]Version {
+	result := make(map[string]Version, len(wc.changeVersions))
+	for changeID, version := range wc.changeVersions {
+		result[changeID] = version
+	}
+	return result
+}
+
 func (wc *workflowEnvironmentImpl) Complete(result *commonpb.Payloads, err error) {
 	wc.completeHandler(result, err)
 }
@@ -448,6 +456,10 @@ func (wc *workflowEnvironmentImpl) IsReplaying() bool {
 	return wc.isReplay
 }
 
+func (wc *workflowEnvironmentImpl) replayState() (isReplay *bool, enableLoggingInReplay *bool) {
+	return &wc.isReplay, &wc.enableLoggingInReplay
+}
+
 func (wc *workflowEnvironmentImpl) GenerateSequenceID() string {
 	return getStringID(wc.GenerateSequence())
 }
@@ -969,6 +981,10 @@ func (weh *workflowExecutionEventHandlerImpl) handleWorkflowExecutionStarted(
 	if err != nil {
 		return err
 	}
+	if weh.registry.isDeprecatedWorkflowType(weh.workflowInfo.WorkflowType.Name) {
+		weh.logger.Warn("Workflow started with a deprecated workflow type name.")
+		weh.metricsScope.Counter(metrics.DeprecatedWorkflowTypeCounter).Inc(1)
+	}
 
 	// Invoke the workflow.
 	weh.workflowDefinition.Execute(weh, attributes.Header, attributes.Input)
@@ -1244,7 +1260,7 @@ func (weh *workflowExecutionEventHandlerImpl) handleStartChildWorkflowExecutionF
 			attributes.GetInitiatedEventId(),
 			0,
 			enumspb.RETRY_STATE_NON_RETRYABLE_FAILURE,
-			errors.New("workflow execution already started"),
+			newChildWorkflowExecutionAlreadyStartedError(),
 		)
 		childWorkflow.handleFailedToStart(nil, err)
 		return nil
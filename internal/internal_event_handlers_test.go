@@ -31,7 +31,9 @@ import (
 	commonpb "go.temporal.io/api/common/v1"
 
 	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/internal/common/metrics"
 	iconverter "go.temporal.io/sdk/internal/converter"
+	ilog "go.temporal.io/sdk/internal/log"
 )
 
 func testDecodeValueHelper(t *testing.T, env *workflowEnvironmentImpl) {
@@ -292,3 +294,33 @@ func Test_CreateSearchAttributesForChangeVersion(t *testing.T) {
 	require.True(t, ok, "Remember to update related key on server side")
 	require.Equal(t, []string{"cid-1"}, val)
 }
+
+func TestReplayAwareLoggerAndMetricsScope(t *testing.T) {
+	t.Parallel()
+	env := &workflowEnvironmentImpl{}
+	ctx := WithValue(Background(), workflowEnvironmentContextKey, env)
+	memoryLogger := ilog.NewMemoryLogger()
+	logger := NewReplayAwareLogger(ctx, memoryLogger)
+	scope, closer, reporter := metrics.NewTaggedMetricsScope()
+	replayAwareScope := NewReplayAwareMetricsScope(ctx, scope)
+
+	env.isReplay = true
+	logger.Info("replayed")
+	replayAwareScope.Counter("counter").Inc(1)
+
+	env.isReplay = false
+	logger.Info("executed")
+	replayAwareScope.Counter("counter").Inc(2)
+
+	require.NoError(t, closer.Close())
+	require.Len(t, memoryLogger.Lines(), 1)
+	require.Contains(t, memoryLogger.Lines()[0], "executed")
+	require.Len(t, reporter.Counts(), 1)
+	require.Equal(t, int64(2), reporter.Counts()[0].Value())
+
+	// Logging in replay can be enabled through the worker options.
+	env.isReplay = true
+	env.enableLoggingInReplay = true
+	logger.Info("replayed with logging enabled")
+	require.Len(t, memoryLogger.Lines(), 2)
+}
@@ -0,0 +1,129 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	historypb "go.temporal.io/api/history/v1"
+)
+
+type (
+	// historyPagePrefetcher fetches history pages ahead of the consumer, so fetching the next pages overlaps with the
+	// processing of the current one. Pages are fetched one at a time, as the token of a page is only known once the
+	// previous page is fetched, and at most maxPages pages are fetched ahead. Every fetch runs in its own goroutine
+	// which never blocks on the consumer, and the context of the fetches is canceled once the prefetcher is stopped.
+	historyPagePrefetcher struct {
+		fetchPage func(ctx context.Context, nextPageToken []byte) (*historypb.History, []byte, error)
+		maxPages  int
+		ctx       context.Context
+		cancel    context.CancelFunc
+
+		lock      sync.Mutex
+		pages     []chan historyPageResult
+		nextToken []byte
+		fetching  bool
+		done      bool
+		stopped   bool
+	}
+
+	historyPageResult struct {
+		history       *historypb.History
+		nextPageToken []byte
+		err           error
+	}
+)
+
+var errNoMoreHistoryPages = errors.New("no more history pages")
+
+func newHistoryPagePrefetcher(
+	fetchPage func(ctx context.Context, nextPageToken []byte) (*historypb.History, []byte, error),
+	nextPageToken []byte,
+	maxPages int,
+) *historyPagePrefetcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &historyPagePrefetcher{
+		fetchPage: fetchPage,
+		maxPages:  maxPages,
+		ctx:       ctx,
+		cancel:    cancel,
+		nextToken: nextPageToken,
+	}
+}
+
+// next returns the next page of history, waiting for it to be fetched if necessary.
+func (p *historyPagePrefetcher) next() (*historypb.History, []byte, error) {
+	p.lock.Lock()
+	p.prefetchLocked()
+	if len(p.pages) == 0 {
+		p.lock.Unlock()
+		return nil, nil, errNoMoreHistoryPages
+	}
+	pageC := p.pages[0]
+	p.pages = p.pages[1:]
+	p.prefetchLocked()
+	p.lock.Unlock()
+
+	page := <-pageC
+	return page.history, page.nextPageToken, page.err
+}
+
+// stop prevents any further pages from being fetched and cancels the fetches in flight.
+func (p *historyPagePrefetcher) stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stopped = true
+	p.pages = nil
+	p.cancel()
+}
+
+func (p *historyPagePrefetcher) prefetchLocked() {
+	if p.fetching || p.done || p.stopped || len(p.pages) >= p.maxPages {
+		return
+	}
+
+	pageC := make(chan historyPageResult, 1)
+	p.pages = append(p.pages, pageC)
+	p.fetching = true
+	token := p.nextToken
+	go func() {
+		history, nextPageToken, err := p.fetchPage(p.ctx, token)
+
+		// Queue the fetch of the next page before handing this one over, so the consumer always finds it queued.
+		p.lock.Lock()
+		p.fetching = false
+		if err != nil || len(nextPageToken) == 0 {
+			p.done = true
+		} else {
+			p.nextToken = nextPageToken
+		}
+		p.prefetchLocked()
+		p.lock.Unlock()
+
+		pageC <- historyPageResult{history: history, nextPageToken: nextPageToken, err: err}
+	}()
+}
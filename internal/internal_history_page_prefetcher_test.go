@@ -0,0 +1,147 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	historypb "go.temporal.io/api/history/v1"
+)
+
+// testHistoryPages serves numPages pages of history, page i has token "i" and a single event with ID i+1.
+type testHistoryPages struct {
+	sync.Mutex
+	numPages int
+	failAt   int
+	fetched  []string
+	fetchedC chan string
+}
+
+func (p *testHistoryPages) fetch(_ context.Context, token []byte) (*historypb.History, []byte, error) {
+	page := 0
+	if len(token) > 0 {
+		page, _ = strconv.Atoi(string(token))
+	}
+	p.Lock()
+	p.fetched = append(p.fetched, strconv.Itoa(page))
+	p.Unlock()
+	if p.fetchedC != nil {
+		p.fetchedC <- strconv.Itoa(page)
+	}
+	if p.failAt > 0 && page == p.failAt {
+		return nil, nil, errors.New("fetch failed")
+	}
+	var nextToken []byte
+	if page+1 < p.numPages {
+		nextToken = []byte(strconv.Itoa(page + 1))
+	}
+	return &historypb.History{Events: []*historypb.HistoryEvent{{EventId: int64(page + 1)}}}, nextToken, nil
+}
+
+func TestHistoryPagePrefetcher_ReturnsPagesInOrder(t *testing.T) {
+	pages := &testHistoryPages{numPages: 5}
+	prefetcher := newHistoryPagePrefetcher(pages.fetch, nil, 2)
+
+	for i := 0; i < 5; i++ {
+		history, token, err := prefetcher.next()
+		require.NoError(t, err)
+		require.Equal(t, int64(i+1), history.Events[0].GetEventId())
+		if i < 4 {
+			require.Equal(t, strconv.Itoa(i+1), string(token))
+		} else {
+			require.Nil(t, token)
+		}
+	}
+	_, _, err := prefetcher.next()
+	require.Equal(t, errNoMoreHistoryPages, err)
+	require.Equal(t, []string{"0", "1", "2", "3", "4"}, pages.fetched)
+}
+
+func TestHistoryPagePrefetcher_FetchesAhead(t *testing.T) {
+	pages := &testHistoryPages{numPages: 10, fetchedC: make(chan string, 10)}
+	prefetcher := newHistoryPagePrefetcher(pages.fetch, []byte("3"), 2)
+
+	history, _, err := prefetcher.next()
+	require.NoError(t, err)
+	require.Equal(t, int64(4), history.Events[0].GetEventId())
+
+	// Pages 4 and 5 are fetched without waiting for the consumer, but not more.
+	for _, expected := range []string{"3", "4", "5"} {
+		select {
+		case page := <-pages.fetchedC:
+			require.Equal(t, expected, page)
+		case <-time.After(time.Second):
+			t.Fatalf("page %v was not prefetched", expected)
+		}
+	}
+	select {
+	case page := <-pages.fetchedC:
+		t.Fatalf("page %v was fetched beyond the prefetch limit", page)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	prefetcher.stop()
+	_, _, err = prefetcher.next()
+	require.Equal(t, errNoMoreHistoryPages, err)
+}
+
+func TestHistoryPagePrefetcher_Error(t *testing.T) {
+	pages := &testHistoryPages{numPages: 5, failAt: 1}
+	prefetcher := newHistoryPagePrefetcher(pages.fetch, nil, 3)
+
+	_, _, err := prefetcher.next()
+	require.NoError(t, err)
+	_, _, err = prefetcher.next()
+	require.EqualError(t, err, "fetch failed")
+	_, _, err = prefetcher.next()
+	require.Equal(t, errNoMoreHistoryPages, err)
+	require.Equal(t, []string{"0", "1"}, pages.fetched)
+}
+
+func TestHistoryPagePrefetcher_StopCancelsFetch(t *testing.T) {
+	fetchStartedC := make(chan struct{})
+	fetchCanceledC := make(chan struct{})
+	prefetcher := newHistoryPagePrefetcher(func(ctx context.Context, _ []byte) (*historypb.History, []byte, error) {
+		close(fetchStartedC)
+		<-ctx.Done()
+		close(fetchCanceledC)
+		return nil, nil, ctx.Err()
+	}, nil, 1)
+
+	go func() { _, _, _ = prefetcher.next() }()
+	<-fetchStartedC
+	prefetcher.stop()
+	select {
+	case <-fetchCanceledC:
+	case <-time.After(time.Second):
+		t.Fatal("fetch was not canceled when the prefetcher was stopped")
+	}
+}
@@ -49,6 +49,7 @@ import (
 	taskqueuepb "go.temporal.io/api/taskqueue/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/internal/common/retry"
+	"go.uber.org/atomic"
 
 	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/internal/common"
@@ -117,6 +118,9 @@ type (
 		newCommands         []*commandpb.Command
 		currentWorkflowTask *workflowservice.PollWorkflowTaskQueueResponse
 		laTunnel            *localActivityTunnel
+
+		// historySize is the size in bytes of the history events applied to the current state.
+		historySize atomic.Int64
 	}
 
 	// workflowTaskHandlerImpl is the implementation of WorkflowTaskHandler
@@ -130,11 +134,18 @@ type (
 		registry                 *registry
 		laTunnel                 *localActivityTunnel
 		workflowPanicPolicy      WorkflowPanicPolicy
+		onWorkflowPanic          func(info WorkflowPanicInfo)
+		replayCommandsListener   func(commands []*commandpb.Command, events []*historypb.HistoryEvent)
 		dataConverter            converter.DataConverter
 		contextPropagators       []ContextPropagator
 		tracer                   opentracing.Tracer
 		cache                    *WorkerCache
 		deadlockDetectionTimeout time.Duration
+		binaryChecksum           string
+		backoffStrategy          retry.BackoffStrategy
+		maxCommands              int
+		maxCompletionSize        int
+		workflowAudit            *WorkflowAuditOptions
 	}
 
 	activityProvider func(name string) activity
@@ -154,6 +165,12 @@ type (
 		contextPropagators []ContextPropagator
 		tracer             opentracing.Tracer
 		namespace          string
+		resultCache        *ActivityResultCache
+		pauser             *activityPauser
+		panicPolicy        ActivityPanicPolicy
+		retryPredicate     func(err error, attempt int) bool
+		// wrapServiceInvoker, if set, wraps the service invoker of each activity task. Used by TestActivityEnvironment.
+		wrapServiceInvoker func(invoker ServiceInvoker) ServiceInvoker
 	}
 
 	// history wrapper method to help information about events.
@@ -394,11 +411,18 @@ func newWorkflowTaskHandler(params workerExecutionParameters, ppMgr pressurePoin
 		enableLoggingInReplay:    params.EnableLoggingInReplay,
 		registry:                 registry,
 		workflowPanicPolicy:      params.WorkflowPanicPolicy,
+		onWorkflowPanic:          params.OnWorkflowPanic,
+		replayCommandsListener:   params.replayCommandsListener,
 		dataConverter:            params.DataConverter,
 		contextPropagators:       params.ContextPropagators,
 		tracer:                   params.Tracer,
 		cache:                    params.cache,
 		deadlockDetectionTimeout: params.DeadlockDetectionTimeout,
+		binaryChecksum:           params.BinaryChecksum,
+		backoffStrategy:          params.backoffStrategy,
+		maxCommands:              params.MaxWorkflowTaskCommands,
+		maxCompletionSize:        params.MaxWorkflowTaskCompletionSize,
+		workflowAudit:            params.WorkflowAudit,
 	}
 }
 
@@ -466,6 +490,8 @@ func (w *workflowExecutionContextImpl) onEviction() {
 	// may be ascertained about the execution context's state,
 	// nor should any of its methods be invoked.
 	if w.shouldResetStickyOnEviction() {
+		metrics.GetMetricsScopeForWorkflow(w.wth.metricsScope, w.workflowInfo.WorkflowType.Name).
+			Counter(metrics.StickyCacheTotalForcedEviction).Inc(1)
 		w.queueResetStickinessTask()
 	}
 
@@ -473,6 +499,12 @@ func (w *workflowExecutionContextImpl) onEviction() {
 	w.mutex.Unlock()
 }
 
+// getHistorySize returns the size in bytes of the history the workflow state was built from. It is safe to call
+// without holding the lock.
+func (w *workflowExecutionContextImpl) getHistorySize() int64 {
+	return w.historySize.Load()
+}
+
 func (w *workflowExecutionContextImpl) IsDestroyed() bool {
 	return w.getEventHandler() == nil
 }
@@ -500,6 +532,7 @@ func (w *workflowExecutionContextImpl) clearState() {
 	w.err = nil
 	w.previousStartedEventID = 0
 	w.newCommands = nil
+	w.historySize.Store(0)
 
 	eventHandler := w.getEventHandler()
 	if eventHandler != nil {
@@ -582,6 +615,7 @@ func (wth *workflowTaskHandlerImpl) createWorkflowContext(task *workflowservice.
 		ParentWorkflowExecution:  parentWorkflowExecution,
 		Memo:                     attributes.Memo,
 		SearchAttributes:         attributes.SearchAttributes,
+		WorkerIdentity:           wth.identity,
 	}
 
 	return newWorkflowExecutionContext(workflowInfo, wth), nil
@@ -619,6 +653,7 @@ func (wth *workflowTaskHandlerImpl) getOrCreateWorkflowContext(
 			workflowMetricsScope.Counter(metrics.StickyCacheHit).Inc(1)
 		} else {
 			// non query task and cached state is missing events, we need to discard the cached state and rebuild one.
+			workflowMetricsScope.Counter(metrics.StickyCacheMiss).Inc(1)
 			_ = workflowContext.ResetIfStale(task, historyIterator)
 		}
 	} else {
@@ -742,10 +777,11 @@ processWorkflowLoop:
 						}
 
 						// force complete, call the workflow task heartbeat function
-						workflowTask, err = heartbeatFunc(
-							workflowContext.CompleteWorkflowTask(workflowTask, false),
-							startTime,
-						)
+						heartbeatRequest := workflowContext.CompleteWorkflowTask(workflowTask, false)
+						if errRet = wth.checkWorkflowTaskLimits(task, heartbeatRequest); errRet != nil {
+							return
+						}
+						workflowTask, err = heartbeatFunc(heartbeatRequest, startTime)
 						if err != nil {
 							errRet = &workflowTaskHeartbeatError{Message: fmt.Sprintf("error sending workflow task heartbeat %v", err)}
 							return
@@ -799,11 +835,42 @@ processWorkflowLoop:
 			break processWorkflowLoop
 		}
 	}
+	if err == nil {
+		if err = wth.checkWorkflowTaskLimits(task, response); err != nil {
+			response = nil
+		}
+	}
 	errRet = err
 	completeRequest = response
 	return
 }
 
+// checkWorkflowTaskLimits fails the workflow task on the worker when its completion exceeds the configured
+// command count or size, as the server would reject it without telling which limit was hit.
+func (wth *workflowTaskHandlerImpl) checkWorkflowTaskLimits(task *workflowservice.PollWorkflowTaskQueueResponse, response interface{}) error {
+	request, ok := response.(*workflowservice.RespondWorkflowTaskCompletedRequest)
+	if !ok || (wth.maxCommands <= 0 && wth.maxCompletionSize <= 0) {
+		return nil
+	}
+	var err *WorkflowTaskLimitExceededError
+	if commands := len(request.Commands); wth.maxCommands > 0 && commands > wth.maxCommands {
+		err = &WorkflowTaskLimitExceededError{Limit: "commands", Value: commands, MaxValue: wth.maxCommands}
+	} else if size := request.Size(); wth.maxCompletionSize > 0 && size > wth.maxCompletionSize {
+		err = &WorkflowTaskLimitExceededError{Limit: "size", Value: size, MaxValue: wth.maxCompletionSize}
+	}
+	if err == nil {
+		return nil
+	}
+	metrics.GetMetricsScopeForWorkflow(wth.metricsScope, task.WorkflowType.GetName()).
+		Counter(metrics.WorkflowTaskLimitExceededCounter).Inc(1)
+	wth.logger.Error("Workflow task exceeds limit.",
+		tagWorkflowType, task.WorkflowType.GetName(),
+		tagWorkflowID, task.WorkflowExecution.GetWorkflowId(),
+		tagRunID, task.WorkflowExecution.GetRunId(),
+		tagError, err)
+	return err
+}
+
 func (w *workflowExecutionContextImpl) ProcessWorkflowTask(workflowTask *workflowTask) (interface{}, error) {
 	task := workflowTask.task
 	historyIterator := workflowTask.historyIterator
@@ -821,6 +888,9 @@ func (w *workflowExecutionContextImpl) ProcessWorkflowTask(workflowTask *workflo
 	workflowMetricsScope := metrics.GetMetricsScopeForWorkflow(w.wth.metricsScope, task.WorkflowType.GetName())
 	replayStopWatch := workflowMetricsScope.Timer(metrics.WorkflowTaskReplayLatency).Start()
 	replayStopWatchStopped := false
+	// codeExecutionLatency is the time spent processing new events, which is when the workflow code makes progress.
+	var codeExecutionLatency time.Duration
+	hasNewEvents := false
 
 	// Process events
 ProcessEvents:
@@ -834,7 +904,7 @@ ProcessEvents:
 			break ProcessEvents
 		}
 		if binaryChecksum == "" {
-			w.workflowInfo.BinaryChecksum = getBinaryChecksum()
+			w.workflowInfo.BinaryChecksum = binaryChecksumOrDefault(w.wth.binaryChecksum)
 		} else {
 			w.workflowInfo.BinaryChecksum = binaryChecksum
 		}
@@ -875,7 +945,13 @@ ProcessEvents:
 				return nil, err
 			}
 
+			w.historySize.Add(int64(event.Size()))
+			processStartTime := time.Now()
 			err = eventHandler.ProcessEvent(event, isInReplay, isLast)
+			if !isInReplay {
+				codeExecutionLatency += time.Since(processStartTime)
+				hasNewEvents = true
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -908,6 +984,9 @@ ProcessEvents:
 	if !replayStopWatchStopped {
 		replayStopWatch.Stop()
 	}
+	if hasNewEvents {
+		workflowMetricsScope.Timer(metrics.WorkflowTaskCodeExecutionLatency).Record(codeExecutionLatency)
+	}
 
 	// Non-deterministic error could happen in 2 different places:
 	//   1) the replay commands does not match to history events. This is usually due to non backwards compatible code
@@ -918,6 +997,9 @@ ProcessEvents:
 	// with the panic error.
 	var workflowError error
 	if !skipReplayCheck && !w.isWorkflowCompleted {
+		if w.wth.replayCommandsListener != nil {
+			w.wth.replayCommandsListener(replayCommands, respondEvents)
+		}
 		// check if commands from reply matches to the history events
 		if err := matchReplayWithHistory(replayCommands, respondEvents); err != nil {
 			workflowError = err
@@ -945,6 +1027,13 @@ func (w *workflowExecutionContextImpl) applyWorkflowPanicPolicy(workflowTask *wo
 	}
 
 	if workflowError != nil {
+		panicInfo := WorkflowPanicInfo{
+			WorkflowType: task.WorkflowType.GetName(),
+			WorkflowID:   task.WorkflowExecution.GetWorkflowId(),
+			RunID:        task.WorkflowExecution.GetRunId(),
+			Attempt:      task.Attempt,
+			Error:        workflowError,
+		}
 		if panicErr, ok := w.err.(*workflowPanicError); ok {
 			w.wth.logger.Error("Workflow panic",
 				tagWorkflowType, task.WorkflowType.GetName(),
@@ -953,6 +1042,8 @@ func (w *workflowExecutionContextImpl) applyWorkflowPanicPolicy(workflowTask *wo
 				tagAttempt, task.Attempt,
 				tagError, workflowError,
 				tagStackTrace, panicErr.StackTrace())
+			panicInfo.Value = panicErr.value
+			panicInfo.StackTrace = panicErr.StackTrace()
 		} else {
 			w.wth.logger.Error("Workflow panic",
 				tagWorkflowType, task.WorkflowType.GetName(),
@@ -961,6 +1052,11 @@ func (w *workflowExecutionContextImpl) applyWorkflowPanicPolicy(workflowTask *wo
 				tagAttempt, task.Attempt,
 				tagError, workflowError)
 		}
+		metrics.GetMetricsScopeForWorkflow(w.wth.metricsScope, task.WorkflowType.GetName()).
+			Counter(metrics.WorkflowTaskPanicCounter).Inc(1)
+		if w.wth.onWorkflowPanic != nil {
+			w.wth.onWorkflowPanic(panicInfo)
+		}
 
 		switch w.wth.workflowPanicPolicy {
 		case FailWorkflow:
@@ -987,6 +1083,13 @@ func (w *workflowExecutionContextImpl) retryLocalActivity(lar *localActivityResu
 	}
 
 	retryBackoff := getRetryBackoff(lar, time.Now(), w.wth.dataConverter)
+	if retryBackoff > 0 && w.wth.backoffStrategy != nil {
+		retryBackoff = w.wth.backoffStrategy.NextDelay(int(lar.task.attempt), retryBackoff)
+		if retryBackoff <= 0 {
+			// A non-positive backoff means no retry, so retry with the shortest delay instead.
+			retryBackoff = time.Nanosecond
+		}
+	}
 	if retryBackoff > 0 && retryBackoff <= w.workflowInfo.WorkflowTaskTimeout {
 		// we need a local retry
 		time.AfterFunc(retryBackoff, func() {
@@ -1447,6 +1550,9 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 	}
 
 	metricsScope := metrics.GetMetricsScopeForWorkflow(wth.metricsScope, eventHandler.workflowEnvironmentImpl.workflowInfo.WorkflowType.Name)
+	if wth.workflowAudit != nil {
+		wth.auditWorkflowStarted(task, workflowContext.workflowInfo)
+	}
 
 	// complete workflow task
 	var closeCommand *commandpb.Command
@@ -1457,12 +1563,23 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		// Workflow canceled
 		metricsScope.Counter(metrics.WorkflowCanceledCounter).Inc(1)
 		closeCommand = createNewCommand(enumspb.COMMAND_TYPE_CANCEL_WORKFLOW_EXECUTION)
+		details := convertErrDetailsToPayloads(canceledErr.details, wth.dataConverter)
 		closeCommand.Attributes = &commandpb.Command_CancelWorkflowExecutionCommandAttributes{CancelWorkflowExecutionCommandAttributes: &commandpb.CancelWorkflowExecutionCommandAttributes{
-			Details: convertErrDetailsToPayloads(canceledErr.details, wth.dataConverter),
+			Details: details,
 		}}
+		if wth.workflowAudit != nil {
+			wth.auditWorkflow(task, workflowContext.workflowInfo, WorkflowAuditCanceled, details, nil)
+		}
 	} else if errors.As(workflowContext.err, &contErr) {
 		// Continue as new error.
 		metricsScope.Counter(metrics.WorkflowContinueAsNewCounter).Inc(1)
+		memo, searchAttributes := workflowContext.workflowInfo.Memo, workflowContext.workflowInfo.SearchAttributes
+		if contErr.Memo != nil {
+			memo = contErr.Memo
+		}
+		if contErr.SearchAttributes != nil {
+			searchAttributes = contErr.SearchAttributes
+		}
 		closeCommand = createNewCommand(enumspb.COMMAND_TYPE_CONTINUE_AS_NEW_WORKFLOW_EXECUTION)
 		closeCommand.Attributes = &commandpb.Command_ContinueAsNewWorkflowExecutionCommandAttributes{ContinueAsNewWorkflowExecutionCommandAttributes: &commandpb.ContinueAsNewWorkflowExecutionCommandAttributes{
 			WorkflowType:        &commonpb.WorkflowType{Name: contErr.WorkflowType.Name},
@@ -1471,9 +1588,12 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 			WorkflowRunTimeout:  &contErr.WorkflowRunTimeout,
 			WorkflowTaskTimeout: &contErr.WorkflowTaskTimeout,
 			Header:              contErr.Header,
-			Memo:                workflowContext.workflowInfo.Memo,
-			SearchAttributes:    workflowContext.workflowInfo.SearchAttributes,
+			Memo:                memo,
+			SearchAttributes:    searchAttributes,
 		}}
+		if wth.workflowAudit != nil {
+			wth.auditWorkflow(task, workflowContext.workflowInfo, WorkflowAuditContinuedAsNew, contErr.Input, nil)
+		}
 	} else if workflowContext.err != nil {
 		// Workflow failures
 		metricsScope.Counter(metrics.WorkflowFailedCounter).Inc(1)
@@ -1482,6 +1602,9 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		closeCommand.Attributes = &commandpb.Command_FailWorkflowExecutionCommandAttributes{FailWorkflowExecutionCommandAttributes: &commandpb.FailWorkflowExecutionCommandAttributes{
 			Failure: failure,
 		}}
+		if wth.workflowAudit != nil {
+			wth.auditWorkflow(task, workflowContext.workflowInfo, WorkflowAuditFailed, nil, workflowContext.err)
+		}
 	} else if workflowContext.isWorkflowCompleted {
 		// Workflow completion
 		metricsScope.Counter(metrics.WorkflowCompletedCounter).Inc(1)
@@ -1489,6 +1612,9 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		closeCommand.Attributes = &commandpb.Command_CompleteWorkflowExecutionCommandAttributes{CompleteWorkflowExecutionCommandAttributes: &commandpb.CompleteWorkflowExecutionCommandAttributes{
 			Result: workflowContext.result,
 		}}
+		if wth.workflowAudit != nil {
+			wth.auditWorkflow(task, workflowContext.workflowInfo, WorkflowAuditCompleted, workflowContext.result, nil)
+		}
 	}
 
 	if closeCommand != nil {
@@ -1523,20 +1649,20 @@ func (wth *workflowTaskHandlerImpl) completeWorkflow(
 		Identity:                   wth.identity,
 		ReturnNewWorkflowTask:      true,
 		ForceCreateNewWorkflowTask: forceNewWorkflowTask,
-		BinaryChecksum:             getBinaryChecksum(),
+		BinaryChecksum:             binaryChecksumOrDefault(wth.binaryChecksum),
 		QueryResults:               queryResults,
 		Namespace:                  wth.namespace,
 	}
 }
 
 func errorToFailWorkflowTask(taskToken []byte, err error, identity string, dataConverter converter.DataConverter,
-	namespace string) *workflowservice.RespondWorkflowTaskFailedRequest {
+	namespace string, binaryChecksum string) *workflowservice.RespondWorkflowTaskFailedRequest {
 	return &workflowservice.RespondWorkflowTaskFailedRequest{
 		TaskToken:      taskToken,
 		Cause:          enumspb.WORKFLOW_TASK_FAILED_CAUSE_WORKFLOW_WORKER_UNHANDLED_FAILURE,
 		Failure:        ConvertErrorToFailure(err, dataConverter),
 		Identity:       identity,
-		BinaryChecksum: getBinaryChecksum(),
+		BinaryChecksum: binaryChecksumOrDefault(binaryChecksum),
 		Namespace:      namespace,
 	}
 }
@@ -1585,6 +1711,10 @@ func newActivityTaskHandlerWithCustomProvider(
 		contextPropagators: params.ContextPropagators,
 		tracer:             params.Tracer,
 		namespace:          params.Namespace,
+		resultCache:        params.ActivityResultCache,
+		pauser:             params.activityPauser,
+		panicPolicy:        params.ActivityPanicPolicy,
+		retryPredicate:     params.ActivityRetryPredicate,
 	}
 }
 
@@ -1746,6 +1876,7 @@ func newServiceInvoker(
 
 // Execute executes an implementation of the activity.
 func (ath *activityTaskHandlerImpl) Execute(taskQueue string, t *workflowservice.PollActivityTaskQueueResponse) (result interface{}, err error) {
+	applyActivityRetryState(t)
 	traceLog(func() {
 		ath.logger.Debug("Processing new activity task",
 			tagWorkflowID, t.WorkflowExecution.GetWorkflowId(),
@@ -1765,11 +1896,15 @@ func (ath *activityTaskHandlerImpl) Execute(taskQueue string, t *workflowservice
 	invoker := newServiceInvoker(
 		t.TaskToken, ath.identity, ath.service, ath.metricsScope, cancel, common.DurationValue(t.GetHeartbeatTimeout()),
 		ath.workerStopCh, ath.namespace)
+	if ath.wrapServiceInvoker != nil {
+		invoker = ath.wrapServiceInvoker(invoker)
+	}
 
 	workflowType := t.WorkflowType.GetName()
 	activityType := t.ActivityType.GetName()
 	activityMetricsScope := metrics.GetMetricsScopeForActivity(ath.metricsScope, workflowType, activityType, ath.taskQueueName)
 	ctx := WithActivityTask(canCtx, t, taskQueue, invoker, ath.logger, activityMetricsScope, ath.dataConverter, ath.workerStopCh, ath.contextPropagators, ath.tracer)
+	getActivityEnv(ctx).workerIdentity = ath.identity
 
 	defer func() {
 		_, activityCompleted := result.(*workflowservice.RespondActivityTaskCompletedRequest)
@@ -1785,6 +1920,12 @@ func (ath *activityTaskHandlerImpl) Execute(taskQueue string, t *workflowservice
 			NewActivityNotRegisteredError(activityType, ath.getRegisteredActivityNames()),
 			ath.dataConverter, ath.namespace), nil
 	}
+	if ath.registry != nil && ath.registry.isDeprecatedActivityType(activityType) {
+		ath.logger.Warn("Activity executed with a deprecated activity type name.",
+			tagWorkflowType, workflowType,
+			tagActivityType, activityType)
+		activityMetricsScope.Counter(metrics.DeprecatedActivityTypeCounter).Inc(1)
+	}
 
 	// panic handler
 	defer func() {
@@ -1799,7 +1940,8 @@ func (ath *activityTaskHandlerImpl) Execute(taskQueue string, t *workflowservice
 				tagPanicError, fmt.Sprintf("%v", p),
 				tagPanicStack, st)
 			activityMetricsScope.Counter(metrics.ActivityTaskErrorCounter).Inc(1)
-			panicErr := newPanicError(p, st)
+			panicErr := newActivityPanicError(p, st, ath.panicPolicy)
+			panicErr = applyActivityRetryPredicate(panicErr, t.Attempt, ath.retryPredicate)
 			result = convertActivityResultToRespondRequest(ath.identity, t.TaskToken, nil, panicErr,
 				ath.dataConverter, ath.namespace)
 		}
@@ -1813,10 +1955,44 @@ func (ath *activityTaskHandlerImpl) Execute(taskQueue string, t *workflowservice
 		}
 	}
 
+	cacheKey, cacheable := ath.resultCache.key(activityType, t.Input)
+	if cacheable {
+		if output, ok := ath.resultCache.get(cacheKey); ok {
+			activityMetricsScope.Counter(metrics.ActivityResultCacheHitCounter).Inc(1)
+			return convertActivityResultToRespondRequest(ath.identity, t.TaskToken, output, nil,
+				ath.dataConverter, ath.namespace), nil
+		}
+		activityMetricsScope.Counter(metrics.ActivityResultCacheMissCounter).Inc(1)
+	}
+
 	info := ctx.Value(activityEnvContextKey).(*activityEnvironment)
 	ctx, dlCancelFunc := context.WithDeadline(ctx, info.deadline)
 	defer dlCancelFunc()
 
+	// The task of a paused activity type is held until the type is resumed rather than failed, so that the pause
+	// doesn't use up the attempts of the activity.
+	if ath.pauser.isPaused(activityType) {
+		ath.logger.Info("Activity task held while its activity type is paused.",
+			tagWorkflowID, t.WorkflowExecution.GetWorkflowId(),
+			tagRunID, t.WorkflowExecution.GetRunId(),
+			tagActivityType, activityType,
+			tagAttempt, t.Attempt,
+		)
+		if err := ath.holdWhilePaused(ctx, activityType, t, info.serviceInvoker); err != nil {
+			switch ctx.Err() {
+			case nil:
+				// The worker is stopping, the task is retried once it times out.
+				return nil, errStop
+			case context.Canceled:
+				return convertActivityResultToRespondRequest(ath.identity, t.TaskToken, nil, ctx.Err(),
+					ath.dataConverter, ath.namespace), nil
+			default:
+				return nil, ctx.Err()
+			}
+		}
+	}
+	info.pauser = ath.pauser
+
 	ctx, span := createOpenTracingActivitySpan(ctx, ath.tracer, time.Now(), activityType, t.WorkflowExecution.GetWorkflowId(), t.WorkflowExecution.GetRunId())
 	defer span.Finish()
 	output, err := activityImplementation.Execute(ctx, t.Input)
@@ -1842,16 +2018,51 @@ func (ath *activityTaskHandlerImpl) Execute(taskQueue string, t *workflowservice
 			tagError, err,
 		)
 	}
+	if cacheable && err == nil {
+		ath.resultCache.put(cacheKey, output)
+	}
+	err = applyActivityRetryPredicate(err, t.Attempt, ath.retryPredicate)
 	return convertActivityResultToRespondRequest(ath.identity, t.TaskToken, output, err,
 		ath.dataConverter, ath.namespace), nil
 }
 
+// holdWhilePaused blocks until the paused activity type is resumed, the task is canceled or times out, or the worker
+// stops. The held task heartbeats the details of its previous attempt, so that its heartbeat timeout doesn't fire and
+// its cancellation is delivered.
+func (ath *activityTaskHandlerImpl) holdWhilePaused(ctx context.Context, activityType string,
+	t *workflowservice.PollActivityTaskQueueResponse, invoker ServiceInvoker) error {
+	holdCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-ath.workerStopCh:
+			cancel()
+		case <-holdCtx.Done():
+		}
+	}()
+	if heartbeatTimeout := common.DurationValue(t.GetHeartbeatTimeout()); heartbeatTimeout > 0 {
+		go func() {
+			ticker := time.NewTicker(heartbeatTimeout / 2)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-holdCtx.Done():
+					return
+				case <-ticker.C:
+					_ = invoker.Heartbeat(holdCtx, t.GetHeartbeatDetails(), true)
+				}
+			}
+		}()
+	}
+	return ath.pauser.waitResumed(holdCtx, activityType)
+}
+
 func (ath *activityTaskHandlerImpl) getActivity(name string) activity {
 	if ath.activityProvider != nil {
 		return ath.activityProvider(name)
 	}
 
-	if a, ok := ath.registry.GetActivity(name); ok {
+	if a, ok := ath.registry.getActivityOrDynamic(name); ok {
 		return a
 	}
 
@@ -169,7 +169,7 @@ func (s *PollLayerInterfacesTestSuite) TestGetNextCommands() {
 	task := createWorkflowTaskWithQueries(testEvents[0:3], 0, "HelloWorld_Workflow", nil, false)
 
 	historyIterator := &historyIteratorImpl{
-		iteratorFunc: func(nextToken []byte) (*historypb.History, []byte, error) {
+		iteratorFunc: func(_ context.Context, nextToken []byte) (*historypb.History, []byte, error) {
 			return &historypb.History{
 				Events: testEvents[3:],
 			}, nil, nil
@@ -29,6 +29,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -49,9 +50,11 @@ import (
 	taskqueuepb "go.temporal.io/api/taskqueue/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/api/workflowservicemock/v1"
+	"go.uber.org/atomic"
 
 	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/internal/common"
+	"go.temporal.io/sdk/internal/common/metrics"
 	ilog "go.temporal.io/sdk/internal/log"
 	"go.temporal.io/sdk/log"
 )
@@ -464,6 +467,23 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_WorkflowExecutionStartedWithDat
 }
 
 func (t *TaskHandlersTestSuite) TestWorkflowTask_BinaryChecksum() {
+	params := t.getTestWorkerExecutionParams()
+	checksums, response := t.testWorkflowTaskBinaryChecksumHelper(params)
+	t.Equal(getBinaryChecksum(), checksums[2])
+	t.Equal(getBinaryChecksum(), response.BinaryChecksum)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_WorkerBinaryChecksum() {
+	params := t.getTestWorkerExecutionParams()
+	params.BinaryChecksum = "chck3"
+	checksums, response := t.testWorkflowTaskBinaryChecksumHelper(params)
+	t.Equal("chck3", checksums[2])
+	t.Equal("chck3", response.BinaryChecksum)
+}
+
+func (t *TaskHandlersTestSuite) testWorkflowTaskBinaryChecksumHelper(
+	params workerExecutionParameters,
+) ([]string, *workflowservice.RespondWorkflowTaskCompletedRequest) {
 	taskQueue := "tq1"
 	checksum1 := "chck1"
 	checksum2 := "chck2"
@@ -483,7 +503,6 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_BinaryChecksum() {
 		createTestEventWorkflowTaskStarted(13),
 	}
 	task := createWorkflowTask(testEvents, 8, "BinaryChecksumWorkflow")
-	params := t.getTestWorkerExecutionParams()
 	taskHandler := newWorkflowTaskHandler(params, nil, t.registry)
 	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
 	response := request.(*workflowservice.RespondWorkflowTaskCompletedRequest)
@@ -498,7 +517,41 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_BinaryChecksum() {
 	t.Equal(3, len(checksums))
 	t.Equal("chck1", checksums[0])
 	t.Equal("chck2", checksums[1])
-	t.Equal(getBinaryChecksum(), checksums[2])
+	return checksums, response
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_ReplayAndCodeExecutionMetrics() {
+	taskQueue := "tq1"
+	testEvents := []*historypb.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &historypb.WorkflowExecutionStartedEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: taskQueue}}),
+		createTestEventWorkflowTaskScheduled(2, &historypb.WorkflowTaskScheduledEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: taskQueue}}),
+		createTestEventWorkflowTaskStarted(3),
+		createTestEventWorkflowTaskCompleted(4, &historypb.WorkflowTaskCompletedEventAttributes{ScheduledEventId: 2}),
+		createTestEventTimerStarted(5, 5),
+		createTestEventTimerFired(6, 5),
+		createTestEventWorkflowTaskScheduled(7, &historypb.WorkflowTaskScheduledEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: taskQueue}}),
+		createTestEventWorkflowTaskStarted(8),
+		createTestEventWorkflowTaskCompleted(9, &historypb.WorkflowTaskCompletedEventAttributes{ScheduledEventId: 7}),
+		createTestEventTimerStarted(10, 10),
+		createTestEventTimerFired(11, 10),
+		createTestEventWorkflowTaskScheduled(12, &historypb.WorkflowTaskScheduledEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: taskQueue}}),
+		createTestEventWorkflowTaskStarted(13),
+	}
+	task := createWorkflowTask(testEvents, 8, "BinaryChecksumWorkflow")
+	scope, closer, reporter := metrics.NewTaggedMetricsScope()
+	params := t.getTestWorkerExecutionParams()
+	params.MetricsScope = scope
+	taskHandler := newWorkflowTaskHandler(params, nil, t.registry)
+	_, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.NoError(closer.Close())
+
+	timers := map[string]string{}
+	for _, timer := range reporter.Timers() {
+		timers[timer.Name()] = timer.Tags()[metrics.WorkflowTypeNameTagName]
+	}
+	t.Equal("BinaryChecksumWorkflow", timers[metrics.WorkflowTaskReplayLatency])
+	t.Equal("BinaryChecksumWorkflow", timers[metrics.WorkflowTaskCodeExecutionLatency])
 }
 
 func (t *TaskHandlersTestSuite) TestWorkflowTask_ActivityTaskScheduled() {
@@ -541,6 +594,33 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_ActivityTaskScheduled() {
 	t.NotNil(response.Commands[0].GetCompleteWorkflowExecutionCommandAttributes())
 }
 
+func (t *TaskHandlersTestSuite) TestWorkflowTask_LimitExceeded() {
+	taskQueue := "tq1"
+	testEvents := []*historypb.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &historypb.WorkflowExecutionStartedEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: taskQueue}}),
+		createTestEventWorkflowTaskScheduled(2, &historypb.WorkflowTaskScheduledEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: taskQueue}}),
+		createTestEventWorkflowTaskStarted(3),
+	}
+	params := t.getTestWorkerExecutionParams()
+	params.MaxWorkflowTaskCompletionSize = 10
+	taskHandler := newWorkflowTaskHandler(params, nil, t.registry)
+	task := createWorkflowTask(testEvents, 0, "HelloWorld_Workflow")
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.Nil(request)
+	var limitErr *WorkflowTaskLimitExceededError
+	t.True(errors.As(err, &limitErr))
+	t.Equal("size", limitErr.Limit)
+	t.Equal(10, limitErr.MaxValue)
+
+	params = t.getTestWorkerExecutionParams()
+	params.MaxWorkflowTaskCommands = 1
+	taskHandler = newWorkflowTaskHandler(params, nil, t.registry)
+	task = createWorkflowTask(testEvents, 0, "HelloWorld_Workflow")
+	request, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Len(request.(*workflowservice.RespondWorkflowTaskCompletedRequest).Commands, 1)
+}
+
 func (t *TaskHandlersTestSuite) TestWorkflowTask_QueryWorkflow_Sticky() {
 	// Schedule an activity and see if we complete workflow.
 	taskQueue := "sticky-tq"
@@ -807,7 +887,7 @@ func (t *TaskHandlersTestSuite) testSideEffectDeferHelper(cacheSize int) {
 	}
 
 	params := t.getTestWorkerExecutionParams()
-	params.cache = newWorkerCache(myWorkerCachePtr, &myWorkerCacheLock, cacheSize)
+	params.cache = newWorkerCache(myWorkerCachePtr, &myWorkerCacheLock, cacheSize, 0)
 
 	taskHandler := newWorkflowTaskHandler(params, nil, t.registry)
 	task := createWorkflowTask(testEvents, 0, workflowName)
@@ -928,6 +1008,163 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_WorkflowPanics() {
 	t.True(ok)
 }
 
+func (t *TaskHandlersTestSuite) TestWorkflowTask_WorkflowPanicsCallsHook() {
+	taskQueue := "taskQueue"
+	testEvents := []*historypb.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &historypb.WorkflowExecutionStartedEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: taskQueue}}),
+	}
+	task := createWorkflowTask(testEvents, 3, "PanicWorkflow")
+	params := t.getTestWorkerExecutionParams()
+	params.WorkflowPanicPolicy = FailWorkflow
+	var panics []WorkflowPanicInfo
+	params.OnWorkflowPanic = func(info WorkflowPanicInfo) {
+		panics = append(panics, info)
+	}
+
+	taskHandler := newWorkflowTaskHandler(params, nil, t.registry)
+	request, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.NotNil(request)
+	t.Len(panics, 1)
+	t.Equal("PanicWorkflow", panics[0].WorkflowType)
+	t.Equal("panicError", panics[0].Value)
+	t.Contains(panics[0].StackTrace, "panicWorkflowFunc")
+	_, ok := panics[0].Error.(*workflowPanicError)
+	t.True(ok)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTaskPoller_OnWorkflowStuck() {
+	mockCtrl := gomock.NewController(t.T())
+	mockService := workflowservicemock.NewMockWorkflowServiceClient(mockCtrl)
+	params := t.getTestWorkerExecutionParams()
+	params.WorkflowStuckAttempts = 3
+	var stuck []WorkflowStuckInfo
+	params.OnWorkflowStuck = func(info WorkflowStuckInfo) {
+		stuck = append(stuck, info)
+	}
+	ensureRequiredParams(&params)
+	poller := newWorkflowTaskPoller(newWorkflowTaskHandler(params, nil, t.registry), mockService, params)
+
+	testEvents := []*historypb.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &historypb.WorkflowExecutionStartedEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: testWorkflowTaskTaskqueue}}),
+	}
+	task := createWorkflowTask(testEvents, 0, "StuckWorkflow")
+	task.Attempt = 2
+	_, err := poller.RespondTaskCompletedWithMetrics(nil, errors.New("workflow error"), task, time.Now())
+	t.NoError(err)
+	t.Empty(stuck)
+
+	task.Attempt = 3
+	_, err = poller.RespondTaskCompletedWithMetrics(nil, errors.New("workflow error"), task, time.Now())
+	t.NoError(err)
+	t.Len(stuck, 1)
+	t.Equal("StuckWorkflow", stuck[0].WorkflowType)
+	t.Equal(int32(3), stuck[0].Attempt)
+	t.Equal(enumspb.WORKFLOW_TASK_FAILED_CAUSE_WORKFLOW_WORKER_UNHANDLED_FAILURE, stuck[0].Cause)
+	t.False(stuck[0].TimedOut)
+	t.EqualError(stuck[0].LastFailure, "workflow error")
+
+	mockService.EXPECT().RespondWorkflowTaskCompleted(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, serviceerror.NewNotFound("workflow task not found"))
+	task.Attempt = 4
+	_, err = poller.RespondTaskCompletedWithMetrics(&workflowservice.RespondWorkflowTaskCompletedRequest{}, nil, task, time.Now())
+	t.Error(err)
+	t.Len(stuck, 2)
+	t.Equal(int32(4), stuck[1].Attempt)
+	t.True(stuck[1].TimedOut)
+	t.Equal(enumspb.WORKFLOW_TASK_FAILED_CAUSE_UNSPECIFIED, stuck[1].Cause)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTaskPoller_ResponseSizeMetric() {
+	mockCtrl := gomock.NewController(t.T())
+	mockService := workflowservicemock.NewMockWorkflowServiceClient(mockCtrl)
+	mockService.EXPECT().RespondWorkflowTaskCompleted(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&workflowservice.RespondWorkflowTaskCompletedResponse{}, nil)
+	handler := metrics.NewPrometheusHandler(metrics.PrometheusHandlerOptions{})
+	params := t.getTestWorkerExecutionParams()
+	params.MetricsScope = metrics.NewHandlerScope(handler)
+	ensureRequiredParams(&params)
+	poller := newWorkflowTaskPoller(newWorkflowTaskHandler(params, nil, t.registry), mockService, params)
+
+	testEvents := []*historypb.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &historypb.WorkflowExecutionStartedEventAttributes{TaskQueue: &taskqueuepb.TaskQueue{Name: testWorkflowTaskTaskqueue}}),
+	}
+	task := createWorkflowTask(testEvents, 0, "SizedWorkflow")
+	// Between the 2KB and the 4KB buckets.
+	request := &workflowservice.RespondWorkflowTaskCompletedRequest{Identity: strings.Repeat("x", 3000)}
+	size := request.Size()
+	t.True(size > 2048 && size <= 4096)
+	_, err := poller.RespondTaskCompleted(request, task)
+	t.NoError(err)
+
+	var b strings.Builder
+	t.NoError(handler.Write(&b))
+	buckets := map[string]string{}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if !strings.HasPrefix(line, "temporal_workflow_task_response_size_bucket{") {
+			continue
+		}
+		le := line[strings.Index(line, `le="`)+4:]
+		buckets[le[:strings.Index(le, `"`)]] = line[strings.LastIndex(line, " ")+1:]
+	}
+	t.Equal("0", buckets["2048"])
+	t.Equal("1", buckets["4096"])
+	t.Equal("1", buckets["8192"])
+}
+
+type testWorkflowAuditor []WorkflowAuditEvent
+
+func (a *testWorkflowAuditor) AuditWorkflow(event WorkflowAuditEvent) {
+	*a = append(*a, event)
+}
+
+func (t *TaskHandlersTestSuite) TestWorkflowTask_WorkflowAudit() {
+	input, err := converter.GetDefaultDataConverter().ToPayloads("lastCompletionData")
+	t.NoError(err)
+	testEvents := []*historypb.HistoryEvent{
+		createTestEventWorkflowExecutionStarted(1, &historypb.WorkflowExecutionStartedEventAttributes{
+			Input:                input,
+			LastCompletionResult: input,
+			TaskQueue:            &taskqueuepb.TaskQueue{Name: testWorkflowTaskTaskqueue},
+		}),
+	}
+	var auditor testWorkflowAuditor
+	params := t.getTestWorkerExecutionParams()
+	params.WorkflowAudit = &WorkflowAuditOptions{Auditor: &auditor, PayloadSampleRate: 1}
+	taskHandler := newWorkflowTaskHandler(params, nil, t.registry)
+
+	task := createWorkflowTask(testEvents, 0, "GetWorkflowInfoWorkflow")
+	_, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Len(auditor, 2)
+	t.Equal(WorkflowAuditStarted, auditor[0].Type)
+	t.Equal("GetWorkflowInfoWorkflow", auditor[0].WorkflowType)
+	t.Equal(testWorkflowTaskTaskqueue, auditor[0].TaskQueue)
+	t.True(auditor[0].Sampled)
+	var startInput string
+	t.NoError(auditor[0].Payload.Get(&startInput))
+	t.Equal("lastCompletionData", startInput)
+	t.Equal(WorkflowAuditCompleted, auditor[1].Type)
+	var result WorkflowInfo
+	t.NoError(auditor[1].Payload.Get(&result))
+	t.Equal(testWorkflowTaskTaskqueue, result.TaskQueueName)
+
+	// Not the first workflow task and no payloads.
+	auditor = nil
+	params.WorkflowAudit.PayloadSampleRate = 0
+	task = createWorkflowTask(testEvents, 0, "PanicWorkflow")
+	task.PreviousStartedEventId = 3
+	params.WorkflowPanicPolicy = FailWorkflow
+	taskHandler = newWorkflowTaskHandler(params, nil, t.registry)
+	_, err = taskHandler.ProcessWorkflowTask(&workflowTask{task: task}, nil)
+	t.NoError(err)
+	t.Len(auditor, 1)
+	t.Equal(WorkflowAuditFailed, auditor[0].Type)
+	t.False(auditor[0].Sampled)
+	t.Nil(auditor[0].Payload)
+	t.Error(auditor[0].Error)
+}
+
 func (t *TaskHandlersTestSuite) TestGetWorkflowInfo() {
 	parentID := "parentID"
 	parentRunID := "parentRun"
@@ -1129,7 +1366,7 @@ func (t *TaskHandlersTestSuite) TestWorkflowTask_PageToken() {
 	}
 
 	historyIterator := &historyIteratorImpl{
-		iteratorFunc: func(nextToken []byte) (*historypb.History, []byte, error) {
+		iteratorFunc: func(_ context.Context, nextToken []byte) (*historypb.History, []byte, error) {
 			return &historypb.History{Events: nextEvents}, nil, nil
 		},
 	}
@@ -1529,6 +1766,132 @@ func (t *TaskHandlersTestSuite) TestActivityExecutionWorkerStop() {
 	t.NotNil(r)
 }
 
+func (t *TaskHandlersTestSuite) TestActivityResultCache() {
+	executions := 0
+	registry := t.registry
+	registry.RegisterActivityWithOptions(func(ctx context.Context, name string) (string, error) {
+		executions++
+		return fmt.Sprintf("Hello %v %v!", name, executions), nil
+	}, RegisterActivityOptions{Name: "CachedActivity", DisableAlreadyRegisteredCheck: true})
+
+	mockCtrl := gomock.NewController(t.T())
+	mockService := workflowservicemock.NewMockWorkflowServiceClient(mockCtrl)
+	resultCache := NewActivityResultCache(ActivityResultCacheOptions{
+		ActivityTypes: []string{"CachedActivity"},
+		TTL:           time.Minute,
+	})
+	wep := t.getTestWorkerExecutionParams()
+	wep.ActivityResultCache = resultCache
+	activityHandler := newActivityTaskHandler(mockService, wep, registry)
+	execute := func(name string) string {
+		input, err := encodeArg(converter.GetDefaultDataConverter(), name)
+		t.NoError(err)
+		now := time.Now()
+		r, err := activityHandler.Execute(taskqueue, &workflowservice.PollActivityTaskQueueResponse{
+			Attempt:   1,
+			TaskToken: []byte("token"),
+			WorkflowExecution: &commonpb.WorkflowExecution{
+				WorkflowId: "wID",
+				RunId:      "rID"},
+			ActivityType:           &commonpb.ActivityType{Name: "CachedActivity"},
+			ActivityId:             uuid.New(),
+			Input:                  input,
+			ScheduledTime:          &now,
+			ScheduleToCloseTimeout: common.DurationPtr(time.Second),
+			StartedTime:            &now,
+			StartToCloseTimeout:    common.DurationPtr(time.Second),
+			WorkflowType: &commonpb.WorkflowType{
+				Name: "wType",
+			},
+			WorkflowNamespace: "namespace",
+		})
+		t.NoError(err)
+		var result string
+		t.NoError(converter.GetDefaultDataConverter().FromPayloads(
+			r.(*workflowservice.RespondActivityTaskCompletedRequest).GetResult(), &result))
+		return result
+	}
+
+	t.Equal("Hello Temporal 1!", execute("Temporal"))
+	t.Equal("Hello Temporal 1!", execute("Temporal"))
+	t.Equal("Hello World 2!", execute("World"))
+	resultCache.Invalidate("CachedActivity")
+	t.Equal("Hello Temporal 3!", execute("Temporal"))
+	t.Equal("Hello Temporal 3!", execute("Temporal"))
+	resultCache.InvalidateAll()
+	t.Equal("Hello World 4!", execute("World"))
+	t.Equal(4, executions)
+}
+
+func (t *TaskHandlersTestSuite) TestActivityPausedActivityType() {
+	var executed atomic.Bool
+	pauser := newActivityPauser()
+	registry := t.registry
+	registry.RegisterActivityWithOptions(func(ctx context.Context) (bool, error) {
+		executed.Store(true)
+		pauser.pause("PausedActivity")
+		return GetActivityInfo(ctx).Paused, nil
+	}, RegisterActivityOptions{Name: "PausedActivity", DisableAlreadyRegisteredCheck: true})
+
+	mockCtrl := gomock.NewController(t.T())
+	mockService := workflowservicemock.NewMockWorkflowServiceClient(mockCtrl)
+	wep := t.getTestWorkerExecutionParams()
+	wep.DataConverter = converter.GetDefaultDataConverter()
+	wep.activityPauser = pauser
+	activityHandler := newActivityTaskHandler(mockService, wep, registry)
+	newTask := func() *workflowservice.PollActivityTaskQueueResponse {
+		now := time.Now()
+		return &workflowservice.PollActivityTaskQueueResponse{
+			Attempt:   1,
+			TaskToken: []byte("token"),
+			WorkflowExecution: &commonpb.WorkflowExecution{
+				WorkflowId: "wID",
+				RunId:      "rID"},
+			ActivityType:           &commonpb.ActivityType{Name: "PausedActivity"},
+			ActivityId:             uuid.New(),
+			ScheduledTime:          &now,
+			ScheduleToCloseTimeout: common.DurationPtr(time.Minute),
+			StartedTime:            &now,
+			StartToCloseTimeout:    common.DurationPtr(time.Minute),
+			WorkflowType: &commonpb.WorkflowType{
+				Name: "wType",
+			},
+			WorkflowNamespace: "namespace",
+		}
+	}
+
+	// The task of a paused activity type is held without running the activity until the type is resumed, then the
+	// activity runs and sees the type being paused while it runs.
+	pauser.pause("PausedActivity")
+	done := make(chan interface{})
+	go func() {
+		r, err := activityHandler.Execute(taskqueue, newTask())
+		t.NoError(err)
+		done <- r
+	}()
+	select {
+	case <-done:
+		t.Fail("the task of the paused activity type is not held")
+	case <-time.After(100 * time.Millisecond):
+	}
+	t.False(executed.Load())
+
+	pauser.resume("PausedActivity")
+	r := <-done
+	t.True(executed.Load())
+	var paused bool
+	t.NoError(converter.GetDefaultDataConverter().FromPayloads(
+		r.(*workflowservice.RespondActivityTaskCompletedRequest).GetResult(), &paused))
+	t.True(paused)
+
+	// The held task times out like a running activity.
+	task := newTask()
+	task.StartToCloseTimeout = common.DurationPtr(100 * time.Millisecond)
+	task.ScheduleToCloseTimeout = common.DurationPtr(100 * time.Millisecond)
+	_, err := activityHandler.Execute(taskqueue, task)
+	t.Equal(context.DeadlineExceeded, err)
+}
+
 func Test_NonDeterministicCheck(t *testing.T) {
 	commandTypes := enumspb.CommandType_name
 	delete(commandTypes, 0) // Ignore "Unspecified".
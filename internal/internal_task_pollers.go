@@ -40,6 +40,7 @@ import (
 	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
 	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/serviceerror"
 	taskqueuepb "go.temporal.io/api/taskqueue/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/converter"
@@ -94,6 +95,12 @@ type (
 		stickyBacklog           int64
 		requestLock             sync.Mutex
 		stickyCacheSize         int
+
+		historyPagePrefetchCount int
+		binaryChecksum           string
+
+		onWorkflowStuck       func(info WorkflowStuckInfo)
+		workflowStuckAttempts int32
 	}
 
 	// activityTaskPoller implements polling/processing a workflow task
@@ -109,7 +116,7 @@ type (
 	}
 
 	historyIteratorImpl struct {
-		iteratorFunc  func(nextPageToken []byte) (*historypb.History, []byte, error)
+		iteratorFunc  func(ctx context.Context, nextPageToken []byte) (*historypb.History, []byte, error)
 		execution     *commonpb.WorkflowExecution
 		nextPageToken []byte
 		namespace     string
@@ -117,6 +124,13 @@ type (
 		maxEventID    int64
 		metricsScope  tally.Scope
 		taskQueue     string
+		workflowType  string
+
+		// isReplayFetch is set once the iterator is reset to fetch the full history after a sticky cache miss.
+		isReplayFetch bool
+		// prefetchPages is the number of history pages fetched ahead of the processing, 0 disables prefetching.
+		prefetchPages int
+		prefetcher    *historyPagePrefetcher
 	}
 
 	localActivityTaskPoller struct {
@@ -133,6 +147,7 @@ type (
 		dataConverter      converter.DataConverter
 		contextPropagators []ContextPropagator
 		tracer             opentracing.Tracer
+		panicPolicy        ActivityPanicPolicy
 	}
 
 	localActivityResult struct {
@@ -232,6 +247,10 @@ func newWorkflowTaskPoller(taskHandler WorkflowTaskHandler, service workflowserv
 		stickyUUID:                   uuid.New(),
 		StickyScheduleToStartTimeout: params.StickyScheduleToStartTimeout,
 		stickyCacheSize:              params.cache.MaxWorkflowCacheSize(),
+		historyPagePrefetchCount:     params.HistoryPagePrefetchCount,
+		binaryChecksum:               params.BinaryChecksum,
+		onWorkflowStuck:              params.OnWorkflowStuck,
+		workflowStuckAttempts:        params.WorkflowStuckAttempts,
 	}
 }
 
@@ -283,6 +302,7 @@ func (wtp *workflowTaskPoller) processWorkflowTask(task *workflowTask) error {
 		task.doneCh = doneCh
 		task.laResultCh = laResultCh
 		task.laRetryCh = laRetryCh
+		processedTasks := []*workflowTask{task}
 		completedRequest, err := wtp.taskHandler.ProcessWorkflowTask(
 			task,
 			func(response interface{}, startTime time.Time) (*workflowTask, error) {
@@ -298,9 +318,16 @@ func (wtp *workflowTaskPoller) processWorkflowTask(task *workflowTask) error {
 				task.doneCh = doneCh
 				task.laResultCh = laResultCh
 				task.laRetryCh = laRetryCh
+				processedTasks = append(processedTasks, task)
 				return task, nil
 			},
 		)
+		// The history of the processed tasks is not read anymore, stop fetching it ahead.
+		for _, processedTask := range processedTasks {
+			if historyIterator, ok := processedTask.historyIterator.(*historyIteratorImpl); ok {
+				historyIterator.stopPrefetch()
+			}
+		}
 		if completedRequest == nil && err == nil {
 			return nil
 		}
@@ -324,8 +351,6 @@ func (wtp *workflowTaskPoller) processWorkflowTask(task *workflowTask) error {
 func (wtp *workflowTaskPoller) processResetStickinessTask(rst *resetStickinessTask) error {
 	grpcCtx, cancel := newGRPCContext(context.Background())
 	defer cancel()
-	// WorkflowType information is not available on reset sticky task.  Emit using base scope.
-	wtp.metricsScope.Counter(metrics.StickyCacheTotalForcedEviction).Inc(1)
 	if _, err := wtp.service.ResetStickyTaskQueue(grpcCtx, rst.task); err != nil {
 		wtp.logger.Warn("ResetStickyTaskQueue failed",
 			tagWorkflowID, rst.task.Execution.GetWorkflowId(),
@@ -354,15 +379,45 @@ func (wtp *workflowTaskPoller) RespondTaskCompletedWithMetrics(
 			tagAttempt, task.Attempt,
 			tagError, taskErr)
 		// convert err to WorkflowTaskFailed
-		completedRequest = errorToFailWorkflowTask(task.TaskToken, taskErr, wtp.identity, wtp.dataConverter, wtp.namespace)
+		completedRequest = errorToFailWorkflowTask(task.TaskToken, taskErr, wtp.identity, wtp.dataConverter, wtp.namespace,
+			wtp.binaryChecksum)
 	}
 
 	workflowMetricsScope.Timer(metrics.WorkflowTaskExecutionLatency).Record(time.Since(startTime))
 
 	response, err = wtp.RespondTaskCompleted(completedRequest, task)
+	if failedRequest, ok := completedRequest.(*workflowservice.RespondWorkflowTaskFailedRequest); ok && taskErr != nil {
+		wtp.reportIfStuck(task, failedRequest.GetCause(), false, taskErr)
+	} else if _, ok := err.(*serviceerror.NotFound); ok {
+		// The workflow task timed out before it was completed.
+		wtp.reportIfStuck(task, enumspb.WORKFLOW_TASK_FAILED_CAUSE_UNSPECIFIED, true, err)
+	}
 	return
 }
 
+// reportIfStuck calls the OnWorkflowStuck worker option if the failed workflow task has been attempted enough times.
+func (wtp *workflowTaskPoller) reportIfStuck(task *workflowservice.PollWorkflowTaskQueueResponse,
+	cause enumspb.WorkflowTaskFailedCause, timedOut bool, failure error) {
+	if wtp.onWorkflowStuck == nil || task.GetAttempt() < wtp.workflowStuckAttempts {
+		return
+	}
+	wtp.logger.Warn("Workflow task failed repeatedly.",
+		tagWorkflowType, task.WorkflowType.GetName(),
+		tagWorkflowID, task.WorkflowExecution.GetWorkflowId(),
+		tagRunID, task.WorkflowExecution.GetRunId(),
+		tagAttempt, task.Attempt,
+		tagError, failure)
+	wtp.onWorkflowStuck(WorkflowStuckInfo{
+		WorkflowType: task.WorkflowType.GetName(),
+		WorkflowID:   task.WorkflowExecution.GetWorkflowId(),
+		RunID:        task.WorkflowExecution.GetRunId(),
+		Attempt:      task.GetAttempt(),
+		Cause:        cause,
+		TimedOut:     timedOut,
+		LastFailure:  failure,
+	})
+}
+
 func (wtp *workflowTaskPoller) RespondTaskCompleted(completedRequest interface{}, task *workflowservice.PollWorkflowTaskQueueResponse) (response *workflowservice.RespondWorkflowTaskCompletedResponse, err error) {
 	ctx := context.Background()
 	// Respond task completion.
@@ -395,6 +450,8 @@ func (wtp *workflowTaskPoller) RespondTaskCompleted(completedRequest interface{}
 		} else {
 			request.ReturnNewWorkflowTask = false
 		}
+		metrics.GetMetricsScopeForWorkflow(wtp.metricsScope, task.WorkflowType.GetName()).
+			Histogram(metrics.WorkflowTaskResponseSize, metrics.ResponseSizeBuckets).RecordValue(float64(request.Size()))
 		response, err = wtp.service.RespondWorkflowTaskCompleted(grpcCtx, request)
 		if err != nil {
 			traceLog(func() {
@@ -423,6 +480,7 @@ func newLocalActivityPoller(params workerExecutionParameters, laTunnel *localAct
 		dataConverter:      params.DataConverter,
 		contextPropagators: params.ContextPropagators,
 		tracer:             params.Tracer,
+		panicPolicy:        params.ActivityPanicPolicy,
 	}
 	return &localActivityTaskPoller{
 		basePoller: basePoller{metricsScope: params.MetricsScope, stopC: params.WorkerStopChannel},
@@ -471,7 +529,7 @@ func (lath *localActivityTaskHandler) executeLocalActivityTask(task *localActivi
 			tagAttempt, task.attempt,
 		)
 	})
-	ctx := WithLocalActivityTask(lath.userContext, task, lath.logger, lath.metricsScope, lath.dataConverter)
+	ctx := WithLocalActivityTask(lath.userContext, task, lath.logger, activityMetricsScope, lath.dataConverter)
 
 	// propagate context information into the local activity activity context from the headers
 	for _, ctxProp := range lath.contextPropagators {
@@ -499,7 +557,7 @@ func (lath *localActivityTaskHandler) executeLocalActivityTask(task *localActivi
 				tagPanicError, fmt.Sprintf("%v", p),
 				tagPanicStack, st)
 			activityMetricsScope.Counter(metrics.LocalActivityErrorCounter).Inc(1)
-			panicErr := newPanicError(p, st)
+			panicErr := newActivityPanicError(p, st, lath.panicPolicy)
 			result = &localActivityResult{
 				task:   task,
 				result: nil,
@@ -521,6 +579,7 @@ func (lath *localActivityTaskHandler) executeLocalActivityTask(task *localActivi
 		// this is attempt and expire time is before SCHEDULE_TO_CLOSE timeout
 		deadline = task.expireTime
 	}
+	getActivityEnv(ctx).deadline = deadline
 
 	ctx, cancel := context.WithDeadline(ctx, deadline)
 	defer cancel()
@@ -636,7 +695,7 @@ func (wtp *workflowTaskPoller) getNextPollRequest() (request *workflowservice.Po
 		Namespace:      wtp.namespace,
 		TaskQueue:      taskQueue,
 		Identity:       wtp.identity,
-		BinaryChecksum: getBinaryChecksum(),
+		BinaryChecksum: binaryChecksumOrDefault(wtp.binaryChecksum),
 	}
 }
 
@@ -694,6 +753,8 @@ func (wtp *workflowTaskPoller) toWorkflowTask(response *workflowservice.PollWork
 		maxEventID:    response.GetStartedEventId(),
 		metricsScope:  wtp.metricsScope,
 		taskQueue:     wtp.taskQueueName,
+		workflowType:  response.WorkflowType.GetName(),
+		prefetchPages: wtp.historyPagePrefetchCount,
 	}
 	task := &workflowTask{
 		task:            response,
@@ -705,7 +766,6 @@ func (wtp *workflowTaskPoller) toWorkflowTask(response *workflowservice.PollWork
 func (h *historyIteratorImpl) GetNextPage() (*historypb.History, error) {
 	if h.iteratorFunc == nil {
 		h.iteratorFunc = newGetHistoryPageFunc(
-			context.Background(),
 			h.service,
 			h.namespace,
 			h.execution,
@@ -715,16 +775,53 @@ func (h *historyIteratorImpl) GetNextPage() (*historypb.History, error) {
 		)
 	}
 
-	history, token, err := h.iteratorFunc(h.nextPageToken)
+	var history *historypb.History
+	var token []byte
+	var err error
+	if h.prefetchPages > 0 {
+		if h.prefetcher == nil {
+			h.prefetcher = newHistoryPagePrefetcher(h.fetchPage, h.nextPageToken, h.prefetchPages)
+		}
+		history, token, err = h.prefetcher.next()
+	} else {
+		history, token, err = h.fetchPage(context.Background(), h.nextPageToken)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if h.isReplayFetch {
+		metrics.GetMetricsScopeForWorkflow(h.metricsScope, h.workflowType).
+			Counter(metrics.StickyCacheReplayHistoryBytes).Inc(int64(history.Size()))
+	}
 	h.nextPageToken = token
 	return history, nil
 }
 
+// fetchPage fetches a single page of history. It may be called concurrently with processing of the previous pages
+// when prefetching is enabled.
+func (h *historyIteratorImpl) fetchPage(ctx context.Context, nextPageToken []byte) (*historypb.History, []byte, error) {
+	startTime := time.Now()
+	history, token, err := h.iteratorFunc(ctx, nextPageToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	metrics.GetMetricsScopeForWorkflow(h.metricsScope, h.workflowType).
+		Timer(metrics.WorkflowTaskHistoryFetchLatency).Record(time.Since(startTime))
+	return history, token, nil
+}
+
 func (h *historyIteratorImpl) Reset() {
 	h.nextPageToken = nil
+	h.isReplayFetch = true
+	h.stopPrefetch()
+}
+
+// stopPrefetch stops fetching history pages ahead, it must be called once the iterator is not used anymore.
+func (h *historyIteratorImpl) stopPrefetch() {
+	if h.prefetcher != nil {
+		h.prefetcher.stop()
+		h.prefetcher = nil
+	}
 }
 
 func (h *historyIteratorImpl) HasNextPage() bool {
@@ -732,15 +829,14 @@ func (h *historyIteratorImpl) HasNextPage() bool {
 }
 
 func newGetHistoryPageFunc(
-	ctx context.Context,
 	service workflowservice.WorkflowServiceClient,
 	namespace string,
 	execution *commonpb.WorkflowExecution,
 	atWorkflowTaskCompletedEventID int64,
 	metricsScope tally.Scope,
 	taskQueue string,
-) func(nextPageToken []byte) (*historypb.History, []byte, error) {
-	return func(nextPageToken []byte) (*historypb.History, []byte, error) {
+) func(ctx context.Context, nextPageToken []byte) (*historypb.History, []byte, error) {
+	return func(ctx context.Context, nextPageToken []byte) (*historypb.History, []byte, error) {
 		var resp *workflowservice.GetWorkflowExecutionHistoryResponse
 		grpcCtx, cancel := newGRPCContext(ctx, grpcMetricsScope(
 			metrics.GetMetricsScopeForRPC(metricsScope, metrics.NoneTagValue, metrics.NoneTagValue, taskQueue)),
@@ -31,6 +31,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -212,3 +213,11 @@ func InterruptCh() <-chan interface{} {
 func getStringID(intID int64) string {
 	return fmt.Sprintf("%d", intID)
 }
+
+var visibilityQueryValueEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// quoteVisibilityQueryValue returns the value as a quoted string of a visibility query, escaping the quotes and
+// backslashes it contains so that it cannot change the query.
+func quoteVisibilityQueryValue(value string) string {
+	return "'" + visibilityQueryValueEscaper.Replace(value) + "'"
+}
@@ -163,3 +163,10 @@ func TestConvertFailureToError_TimeoutError(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, testErrorDetails1, detailValue)
 }
+
+func TestQuoteVisibilityQueryValue(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, `'workflow-id'`, quoteVisibilityQueryValue("workflow-id"))
+	require.Equal(t, `'id\' or WorkflowId = \'other'`, quoteVisibilityQueryValue("id' or WorkflowId = 'other"))
+	require.Equal(t, `'id\\\''`, quoteVisibilityQueryValue(`id\'`))
+}
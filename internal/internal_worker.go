@@ -42,12 +42,12 @@ import (
 	"sync"
 	"time"
 
-	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/mock/gomock"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pborman/uuid"
 	"github.com/uber-go/tally"
+	commandpb "go.temporal.io/api/command/v1"
 	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
 	historypb "go.temporal.io/api/history/v1"
@@ -55,6 +55,7 @@ import (
 	"go.temporal.io/api/workflowservicemock/v1"
 
 	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/internal/common/retry"
 	"go.temporal.io/sdk/internal/common/serializer"
 	"go.temporal.io/sdk/internal/common/util"
 	ilog "go.temporal.io/sdk/internal/log"
@@ -65,6 +66,8 @@ const (
 	// Set to 2 pollers for now, can adjust later if needed. The typical RTT (round-trip time) is below 1ms within data
 	// center. And the poll API latency is about 5ms. With 2 poller, we could achieve around 300~400 RPS.
 	defaultConcurrentPollRoutineSize = 2
+	// Workers polling a single kind of task get the pollers of both kinds.
+	defaultSingleKindConcurrentPollRoutineSize = 2 * defaultConcurrentPollRoutineSize
 
 	defaultMaxConcurrentActivityExecutionSize = 1000   // Large concurrent activity execution size (1k)
 	defaultWorkerActivitiesPerSecond          = 100000 // Large activity executions/sec (unlimited)
@@ -81,6 +84,10 @@ const (
 
 	defaultMaxConcurrentSessionExecutionSize = 1000 // Large concurrent session execution size (1k)
 
+	defaultWorkflowStuckAttempts = 3 // The first failure and two retries.
+
+	defaultMaxWorkflowTaskCompletionSize = 4 * 1024 * 1024 // Default maximum gRPC message size of the server.
+
 	defaultDeadlockDetectionTimeout = time.Second // By default kill workflow tasks that are running more than 1 sec.
 	// Unlimited deadlock detection timeout is used when we want to allow workflow tasks to run indefinitely, such
 	// as during debugging.
@@ -164,6 +171,9 @@ type (
 		// a default option.
 		Identity string
 
+		// Checksum identifying the binary of the worker. Empty means the process wide checksum.
+		BinaryChecksum string
+
 		MetricsScope tally.Scope
 
 		Logger log.Logger
@@ -185,6 +195,34 @@ type (
 		// The default behavior is to block workflow execution until the problem is fixed.
 		WorkflowPanicPolicy WorkflowPanicPolicy
 
+		// ActivityPanicPolicy is used for configuring how the activity task handlers deal with panics raised from
+		// activity code.
+		ActivityPanicPolicy ActivityPanicPolicy
+
+		// ActivityRetryPredicate decides if the errors activities fail with are retryable. Optional.
+		ActivityRetryPredicate func(err error, attempt int) bool
+
+		// OnWorkflowPanic is called when workflow code panics or non-determinism is detected. Optional.
+		OnWorkflowPanic func(info WorkflowPanicInfo)
+
+		// MaxWorkflowTaskCommands limits the number of commands of a workflow task completion. Non positive means no limit.
+		MaxWorkflowTaskCommands int
+
+		// MaxWorkflowTaskCompletionSize limits the size in bytes of a workflow task completion. Non positive means no limit.
+		MaxWorkflowTaskCompletionSize int
+
+		// ActivityTaskPrefetchSize is the number of activity tasks polled in addition to the executing ones, executed by
+		// priority when an execution slot is released. Non positive disables the priority of activities.
+		ActivityTaskPrefetchSize int
+
+		// WorkflowAudit configures the auditing of the workflow executions. Optional.
+		WorkflowAudit *WorkflowAuditOptions
+
+		// OnWorkflowStuck is called when a workflow task fails from the attempt WorkflowStuckAttempts. Optional.
+		OnWorkflowStuck func(info WorkflowStuckInfo)
+
+		WorkflowStuckAttempts int32
+
 		DataConverter converter.DataConverter
 
 		// WorkerStopTimeout is the time delay before hard terminate worker
@@ -203,8 +241,31 @@ type (
 		// DeadlockDetectionTimeout specifies workflow task timeout.
 		DeadlockDetectionTimeout time.Duration
 
+		// HistoryPagePrefetchCount is the number of history pages fetched ahead while replaying.
+		HistoryPagePrefetchCount int
+
 		// Pointer to the shared worker cache
 		cache *WorkerCache
+
+		// Cache of the results of idempotent activities. Optional.
+		ActivityResultCache *ActivityResultCache
+
+		// Tracks the activity types paused on the worker. Optional.
+		activityPauser *activityPauser
+
+		// Called with the commands generated while replaying a workflow task and the history events they are
+		// checked against. Only set by WorkflowReplayer.DiffWorkflowHistory.
+		replayCommandsListener func(commands []*commandpb.Command, events []*historypb.HistoryEvent)
+
+		// Slots shared with other workers limiting the workflow and activity tasks executed at once. Optional.
+		sharedWorkflowTaskSlots *sharedTaskSlots
+		sharedActivityTaskSlots *sharedTaskSlots
+
+		// Pauses the pollers of the worker while its namespace is passive. Optional.
+		pollGate *pollGate
+
+		// Randomizes the delays between poll and local activity retries instead of the default jitter. Optional.
+		backoffStrategy retry.BackoffStrategy
 	}
 )
 
@@ -271,7 +332,10 @@ func newWorkflowTaskWorkerInternal(taskHandler WorkflowTaskHandler, service work
 		taskWorker:        poller,
 		identity:          params.Identity,
 		workerType:        "WorkflowWorker",
-		stopTimeout:       params.WorkerStopTimeout},
+		stopTimeout:       params.WorkerStopTimeout,
+		sharedTaskSlots:   params.sharedWorkflowTaskSlots,
+		pollGate:          params.pollGate,
+		backoffStrategy:   params.backoffStrategy},
 		params.Logger,
 		params.MetricsScope,
 		nil,
@@ -397,17 +461,26 @@ func newActivityTaskWorker(taskHandler ActivityTaskHandler, service workflowserv
 
 	poller := newActivityTaskPoller(taskHandler, service, workerParams)
 
+	baseOptions := baseWorkerOptions{
+		pollerCount:       workerParams.MaxConcurrentActivityTaskQueuePollers,
+		pollerRate:        defaultPollerRate,
+		maxConcurrentTask: workerParams.ConcurrentActivityExecutionSize,
+		maxTaskPerSecond:  workerParams.WorkerActivitiesPerSecond,
+		taskWorker:        poller,
+		identity:          workerParams.Identity,
+		workerType:        "ActivityWorker",
+		stopTimeout:       workerParams.WorkerStopTimeout,
+		userContextCancel: workerParams.UserContextCancel,
+		sharedTaskSlots:   workerParams.sharedActivityTaskSlots,
+		pollGate:          workerParams.pollGate,
+		backoffStrategy:   workerParams.backoffStrategy,
+	}
+	if workerParams.ActivityTaskPrefetchSize > 0 {
+		baseOptions.taskPriority = activityTaskPriority
+		baseOptions.prefetchCount = workerParams.ActivityTaskPrefetchSize
+	}
 	base := newBaseWorker(
-		baseWorkerOptions{
-			pollerCount:       workerParams.MaxConcurrentActivityTaskQueuePollers,
-			pollerRate:        defaultPollerRate,
-			maxConcurrentTask: workerParams.ConcurrentActivityExecutionSize,
-			maxTaskPerSecond:  workerParams.WorkerActivitiesPerSecond,
-			taskWorker:        poller,
-			identity:          workerParams.Identity,
-			workerType:        "ActivityWorker",
-			stopTimeout:       workerParams.WorkerStopTimeout,
-			userContextCancel: workerParams.UserContextCancel},
+		baseOptions,
 		workerParams.Logger,
 		workerParams.MetricsScope,
 		sessionTokenBucket,
@@ -445,7 +518,16 @@ type registry struct {
 	workflowAliasMap     map[string]string
 	activityFuncMap      map[string]activity
 	activityAliasMap     map[string]string
+	dynamicWorkflow      DynamicWorkflowFunc
+	dynamicActivity      DynamicActivityFunc
 	workflowInterceptors []WorkflowInterceptor
+	taskQueueRoutes      *TaskQueueRoutes
+	// deprecatedWorkflowTypes and deprecatedActivityTypes are the names registered as deprecated aliases.
+	deprecatedWorkflowTypes map[string]struct{}
+	deprecatedActivityTypes map[string]struct{}
+	// parent holds the registrations shared with other workers, looked up when a type isn't registered in this
+	// registry. Set from WorkerOptions.Registry.
+	parent *registry
 }
 
 func (r *registry) WorkflowInterceptors() []WorkflowInterceptor {
@@ -456,6 +538,10 @@ func (r *registry) SetWorkflowInterceptors(workflowInterceptors []WorkflowInterc
 	r.workflowInterceptors = workflowInterceptors
 }
 
+func (r *registry) SetTaskQueueRoutes(routes *TaskQueueRoutes) {
+	r.taskQueueRoutes = routes
+}
+
 func (r *registry) RegisterWorkflow(af interface{}) {
 	r.RegisterWorkflowWithOptions(af, RegisterWorkflowOptions{})
 }
@@ -479,26 +565,83 @@ func (r *registry) RegisterWorkflowWithOptions(
 		panic(err)
 	}
 	fnName, _ := getFunctionName(wf)
+	if options.Version != 0 {
+		r.registerWorkflowVersion(wf, fnName, options)
+		return
+	}
 	alias := options.Name
 	registerName := fnName
 	if len(alias) > 0 {
 		registerName = alias
 	}
 
+	names := append([]string{registerName}, options.Aliases...)
+	names = append(names, options.DeprecatedAliases...)
+
 	r.Lock()
 	defer r.Unlock()
 
 	if !options.DisableAlreadyRegisteredCheck {
-		if _, ok := r.workflowFuncMap[registerName]; ok {
-			panic(fmt.Sprintf("workflow name \"%v\" is already registered", registerName))
+		for _, name := range names {
+			if _, ok := r.workflowFuncMap[name]; ok {
+				panic(fmt.Sprintf("workflow name \"%v\" is already registered", name))
+			}
 		}
 	}
-	r.workflowFuncMap[registerName] = wf
+	for _, name := range names {
+		r.workflowFuncMap[name] = wf
+	}
+	for _, name := range options.DeprecatedAliases {
+		r.deprecatedWorkflowTypes[name] = struct{}{}
+	}
 	if len(alias) > 0 {
 		r.workflowAliasMap[fnName] = alias
 	}
 }
 
+func (r *registry) registerWorkflowVersion(wf interface{}, fnName string, options RegisterWorkflowOptions) {
+	if len(options.Name) == 0 {
+		panic("versioned workflow must be registered with a name")
+	}
+	if len(options.Aliases) > 0 || len(options.DeprecatedAliases) > 0 {
+		panic("versioned workflow cannot be registered with aliases")
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	versions := make(map[Version]interface{})
+	if existing, ok := r.workflowFuncMap[options.Name]; ok {
+		versioned, ok := existing.(*versionedWorkflow)
+		if !ok {
+			panic(fmt.Sprintf("workflow name \"%v\" is already registered without a version", options.Name))
+		}
+		if _, ok := versioned.versions[options.Version]; ok {
+			panic(fmt.Sprintf("workflow name \"%v\" is already registered with version %v", options.Name, options.Version))
+		}
+		for version, fn := range versioned.versions {
+			versions[version] = fn
+		}
+	}
+	versions[options.Version] = wf
+	r.workflowFuncMap[options.Name] = &versionedWorkflow{versions: versions}
+	r.workflowAliasMap[fnName] = options.Name
+}
+
+// RegisterDynamicWorkflow registers the workflow function that handles the workflow types without a registered
+// workflow. Only one dynamic workflow can be registered.
+func (r *registry) RegisterDynamicWorkflow(wf DynamicWorkflowFunc) {
+	if wf == nil {
+		panic("dynamic workflow function cannot be nil")
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.dynamicWorkflow != nil {
+		panic("dynamic workflow is already registered")
+	}
+	r.dynamicWorkflow = wf
+}
+
 func (r *registry) RegisterActivity(af interface{}) {
 	r.RegisterActivityWithOptions(af, RegisterActivityOptions{})
 }
@@ -514,11 +657,23 @@ func (r *registry) RegisterActivityWithOptions(
 			panic("registration of activity interface requires name")
 		}
 		r.addActivityWithLock(options.Name, a)
+		for _, name := range options.Aliases {
+			r.addActivityWithLock(name, a)
+		}
+		for _, name := range options.DeprecatedAliases {
+			r.addActivityWithLock(name, a)
+			r.Lock()
+			r.deprecatedActivityTypes[name] = struct{}{}
+			r.Unlock()
+		}
 		return
 	}
 	// Validate that it is a function
 	fnType := reflect.TypeOf(af)
 	if fnType.Kind() == reflect.Ptr && fnType.Elem().Kind() == reflect.Struct {
+		if len(options.Aliases) > 0 || len(options.DeprecatedAliases) > 0 {
+			panic("activity aliases are not supported when registering a structure")
+		}
 		registerErr := r.registerActivityStructWithOptions(af, options)
 		if registerErr != nil {
 			panic(registerErr)
@@ -535,20 +690,44 @@ func (r *registry) RegisterActivityWithOptions(
 		registerName = alias
 	}
 
+	names := append([]string{registerName}, options.Aliases...)
+	names = append(names, options.DeprecatedAliases...)
+
 	r.Lock()
 	defer r.Unlock()
 
 	if !options.DisableAlreadyRegisteredCheck {
-		if _, ok := r.activityFuncMap[registerName]; ok {
-			panic(fmt.Sprintf("activity type \"%v\" is already registered", registerName))
+		for _, name := range names {
+			if _, ok := r.activityFuncMap[name]; ok {
+				panic(fmt.Sprintf("activity type \"%v\" is already registered", name))
+			}
 		}
 	}
-	r.activityFuncMap[registerName] = &activityExecutor{registerName, af}
+	for _, name := range names {
+		r.activityFuncMap[name] = &activityExecutor{name, af}
+	}
+	for _, name := range options.DeprecatedAliases {
+		r.deprecatedActivityTypes[name] = struct{}{}
+	}
 	if len(alias) > 0 {
 		r.activityAliasMap[fnName] = alias
 	}
 }
 
+// RegisterDynamicActivity registers the activity function that handles the activity types without a registered
+// activity. Only one dynamic activity can be registered.
+func (r *registry) RegisterDynamicActivity(af DynamicActivityFunc) {
+	if af == nil {
+		panic("dynamic activity function cannot be nil")
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.dynamicActivity != nil {
+		panic("dynamic activity is already registered")
+	}
+	r.dynamicActivity = af
+}
+
 func (r *registry) registerActivityStructWithOptions(aStruct interface{}, options RegisterActivityOptions) error {
 	r.Lock()
 	defer r.Unlock()
@@ -588,23 +767,79 @@ func (r *registry) registerActivityStructWithOptions(aStruct interface{}, option
 
 func (r *registry) getWorkflowAlias(fnName string) (string, bool) {
 	r.Lock()
-	defer r.Unlock()
 	alias, ok := r.workflowAliasMap[fnName]
+	r.Unlock()
+	if !ok && r.parent != nil {
+		return r.parent.getWorkflowAlias(fnName)
+	}
 	return alias, ok
 }
 
 func (r *registry) getWorkflowFn(fnName string) (interface{}, bool) {
 	r.Lock()
-	defer r.Unlock()
 	fn, ok := r.workflowFuncMap[fnName]
+	r.Unlock()
+	if !ok && r.parent != nil {
+		return r.parent.getWorkflowFn(fnName)
+	}
 	return fn, ok
 }
 
+// isDeprecatedWorkflowType returns whether the workflow type is a deprecated alias of a registered workflow.
+func (r *registry) isDeprecatedWorkflowType(workflowType string) bool {
+	r.Lock()
+	_, ok := r.deprecatedWorkflowTypes[workflowType]
+	r.Unlock()
+	if !ok && r.parent != nil {
+		return r.parent.isDeprecatedWorkflowType(workflowType)
+	}
+	return ok
+}
+
+// isDeprecatedActivityType returns whether the activity type is a deprecated alias of a registered activity.
+func (r *registry) isDeprecatedActivityType(activityType string) bool {
+	r.Lock()
+	_, ok := r.deprecatedActivityTypes[activityType]
+	r.Unlock()
+	if !ok && r.parent != nil {
+		return r.parent.isDeprecatedActivityType(activityType)
+	}
+	return ok
+}
+
+func (r *registry) getDynamicWorkflow() DynamicWorkflowFunc {
+	r.Lock()
+	dynamicWorkflow := r.dynamicWorkflow
+	r.Unlock()
+	if dynamicWorkflow == nil && r.parent != nil {
+		return r.parent.getDynamicWorkflow()
+	}
+	return dynamicWorkflow
+}
+
+func (r *registry) getDynamicActivity() DynamicActivityFunc {
+	r.Lock()
+	dynamicActivity := r.dynamicActivity
+	r.Unlock()
+	if dynamicActivity == nil && r.parent != nil {
+		return r.parent.getDynamicActivity()
+	}
+	return dynamicActivity
+}
+
 func (r *registry) getRegisteredWorkflowTypes() []string {
+	var result []string
+	if r.parent != nil {
+		result = r.parent.getRegisteredWorkflowTypes()
+	}
 	r.Lock()
 	defer r.Unlock()
-	var result []string
 	for t := range r.workflowFuncMap {
+		if r.parent != nil {
+			if _, ok := r.parent.getWorkflowFn(t); ok {
+				continue
+			}
+		}
 		result = append(result, t)
 	}
 	return result
@@ -612,8 +847,11 @@ func (r *registry) getRegisteredWorkflowTypes() []string {
 
 func (r *registry) getActivityAlias(fnName string) (string, bool) {
 	r.Lock()
-	defer r.Unlock()
 	alias, ok := r.activityAliasMap[fnName]
+	r.Unlock()
+	if !ok && r.parent != nil {
+		return r.parent.getActivityAlias(fnName)
+	}
 	return alias, ok
 }
 
@@ -625,31 +863,67 @@ func (r *registry) addActivityWithLock(fnName string, a activity) {
 
 func (r *registry) GetActivity(fnName string) (activity, bool) {
 	r.Lock()
-	defer r.Unlock()
 	a, ok := r.activityFuncMap[fnName]
+	r.Unlock()
+	if !ok && r.parent != nil {
+		return r.parent.GetActivity(fnName)
+	}
 	return a, ok
 }
 
+// getActivityOrDynamic returns the registered activity or, if there is none, the dynamic activity bound to the
+// activity type.
+func (r *registry) getActivityOrDynamic(fnName string) (activity, bool) {
+	if a, ok := r.GetActivity(fnName); ok {
+		return a, true
+	}
+	if dynamic := r.getDynamicActivity(); dynamic != nil {
+		return &dynamicActivity{name: fnName, fn: dynamic}, true
+	}
+	return nil, false
+}
+
 func (r *registry) getActivityNoLock(fnName string) (activity, bool) {
 	a, ok := r.activityFuncMap[fnName]
 	return a, ok
 }
 
 func (r *registry) getRegisteredActivities() []activity {
+	var activities []activity
+	if r.parent != nil {
+		for _, a := range r.parent.getRegisteredActivities() {
+			if _, ok := r.getOwnActivity(a.ActivityType().Name); !ok {
+				activities = append(activities, a)
+			}
+		}
+	}
 	r.Lock()
 	defer r.Unlock()
-	activities := make([]activity, 0, len(r.activityFuncMap))
 	for _, a := range r.activityFuncMap {
 		activities = append(activities, a)
 	}
 	return activities
 }
 
-func (r *registry) getRegisteredActivityTypes() []string {
+func (r *registry) getOwnActivity(fnName string) (activity, bool) {
 	r.Lock()
 	defer r.Unlock()
+	return r.getActivityNoLock(fnName)
+}
+
+func (r *registry) getRegisteredActivityTypes() []string {
 	var result []string
+	if r.parent != nil {
+		result = r.parent.getRegisteredActivityTypes()
+	}
+	r.Lock()
+	defer r.Unlock()
 	for name := range r.activityFuncMap {
+		if r.parent != nil {
+			if _, ok := r.parent.GetActivity(name); ok {
+				continue
+			}
+		}
 		result = append(result, name)
 	}
 	return result
@@ -662,6 +936,10 @@ func (r *registry) getWorkflowDefinition(wt WorkflowType) (WorkflowDefinition, e
 	}
 	wf, ok := r.getWorkflowFn(lookup)
 	if !ok {
+		if dynamic := r.getDynamicWorkflow(); dynamic != nil {
+			executor := &workflowExecutor{workflowType: wt.Name, fn: dynamic, interceptors: r.getInterceptors(), isDynamic: true}
+			return newSyncWorkflowDefinition(executor), nil
+		}
 		supported := strings.Join(r.getRegisteredWorkflowTypes(), ", ")
 		return nil, fmt.Errorf("unable to find workflow type: %v. Supported types: [%v]", lookup, supported)
 	}
@@ -722,6 +1000,9 @@ func newRegistry() *registry {
 		workflowAliasMap: make(map[string]string),
 		activityFuncMap:  make(map[string]activity),
 		activityAliasMap: make(map[string]string),
+
+		deprecatedWorkflowTypes: make(map[string]struct{}),
+		deprecatedActivityTypes: make(map[string]struct{}),
 	}
 }
 
@@ -730,25 +1011,70 @@ type workflowExecutor struct {
 	workflowType string
 	fn           interface{}
 	interceptors []WorkflowInterceptor
+	// isDynamic is true if fn is a DynamicWorkflowFunc that receives the workflow type and the encoded arguments.
+	isDynamic bool
 }
 
 func (we *workflowExecutor) Execute(ctx Context, input *commonpb.Payloads) (*commonpb.Payloads, error) {
 	var args []interface{}
 	dataConverter := WithWorkflowContext(ctx, getWorkflowEnvOptions(ctx).DataConverter)
-	fnType := reflect.TypeOf(we.fn)
+	fn := we.fn
+	if versioned, ok := fn.(*versionedWorkflow); ok {
+		fn = versioned.selectVersion(ctx)
+	}
 
-	decoded, err := decodeArgsToValues(dataConverter, fnType, input)
-	if err != nil {
-		return nil, fmt.Errorf(
-			"unable to decode the workflow function input payload with error: %w, function name: %v",
-			err, we.workflowType)
+	if we.isDynamic {
+		// Arguments are passed as pointers to match the values produced by decodeArgsToValues.
+		workflowType := we.workflowType
+		encodedArgs := newEncodedValues(input, dataConverter)
+		args = append(args, &workflowType, &encodedArgs)
+	} else {
+		fnType := reflect.TypeOf(fn)
+		decoded, err := decodeArgsToValues(dataConverter, fnType, input)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"unable to decode the workflow function input payload with error: %w, function name: %v",
+				err, we.workflowType)
+		}
+		args = append(args, decoded...)
 	}
-	args = append(args, decoded...)
 
 	envInterceptor := getWorkflowEnvironmentInterceptor(ctx)
-	envInterceptor.fn = we.fn
+	envInterceptor.fn = fn
 	results := envInterceptor.inboundInterceptor.ExecuteWorkflow(ctx, we.workflowType, args...)
-	return serializeResults(we.fn, results, dataConverter)
+	return serializeResults(fn, results, dataConverter)
+}
+
+// workflowDefinitionVersionChangeID is the change ID of the version marker selecting the implementation of a
+// versioned workflow.
+const workflowDefinitionVersionChangeID = "temporal-workflow-definition-version"
+
+// versionedWorkflow holds side-by-side implementations of a workflow type registered with
+// RegisterWorkflowOptions.Version.
+type versionedWorkflow struct {
+	versions map[Version]interface{}
+}
+
+// selectVersion returns the implementation for the version recorded in the history of the workflow, or the highest
+// registered version for new workflows.
+func (v *versionedWorkflow) selectVersion(ctx Context) interface{} {
+	minSupported, maxSupported := Version(math.MaxInt32), Version(math.MinInt32)
+	for version := range v.versions {
+		if version < minSupported {
+			minSupported = version
+		}
+		if version > maxSupported {
+			maxSupported = version
+		}
+	}
+	selected := GetVersion(ctx, workflowDefinitionVersionChangeID, minSupported, maxSupported)
+	// Versions between registered ones run the closest older implementation.
+	for ; selected > minSupported; selected-- {
+		if fn, ok := v.versions[selected]; ok {
+			return fn
+		}
+	}
+	return v.versions[minSupported]
 }
 
 // Wrapper to execute activity functions.
@@ -850,10 +1176,28 @@ func getActivityEnvironmentFromCtx(ctx context.Context) *activityEnvironment {
 type AggregatedWorker struct {
 	workflowWorker *workflowWorker
 	activityWorker *activityWorker
+	affinityWorker *activityWorker
 	sessionWorker  *sessionWorker
 	logger         log.Logger
 	registry       *registry
 	stopC          chan struct{}
+	cache          *WorkerCache
+	binaryChecksum string
+	activityPauser *activityPauser
+	// Follows the failovers of the namespace, optional.
+	failoverWatcher *namespaceFailoverWatcher
+	// Replays the running executions instead of polling workflow tasks, only set with WorkerOptions.ReplayOnly.
+	replayOnlyWorker *replayOnlyWorker
+}
+
+// EvictWorkflowExecution removes the workflow execution from the sticky cache of the worker. The next workflow task
+// of the execution is dispatched to any worker of the task queue and the workflow state is rebuilt by replaying its
+// history. Returns true if the workflow execution was cached.
+func (aw *AggregatedWorker) EvictWorkflowExecution(workflowID, runID string) bool {
+	if aw.cache == nil {
+		return false
+	}
+	return aw.cache.evictWorkflowContext(workflowID, runID)
 }
 
 // RegisterWorkflow registers workflow implementation with the AggregatedWorker
@@ -866,6 +1210,12 @@ func (aw *AggregatedWorker) RegisterWorkflowWithOptions(w interface{}, options R
 	aw.registry.RegisterWorkflowWithOptions(w, options)
 }
 
+// RegisterDynamicWorkflow registers the workflow function that handles the workflow types without a registered
+// workflow with the AggregatedWorker
+func (aw *AggregatedWorker) RegisterDynamicWorkflow(w DynamicWorkflowFunc) {
+	aw.registry.RegisterDynamicWorkflow(w)
+}
+
 // RegisterActivity registers activity implementation with the AggregatedWorker
 func (aw *AggregatedWorker) RegisterActivity(a interface{}) {
 	aw.registry.RegisterActivity(a)
@@ -876,11 +1226,37 @@ func (aw *AggregatedWorker) RegisterActivityWithOptions(a interface{}, options R
 	aw.registry.RegisterActivityWithOptions(a, options)
 }
 
+// RegisterDynamicActivity registers the activity function that handles the activity types without a registered
+// activity with the AggregatedWorker
+func (aw *AggregatedWorker) RegisterDynamicActivity(a DynamicActivityFunc) {
+	aw.registry.RegisterDynamicActivity(a)
+}
+
+// RegisteredWorkflowTypes returns the sorted names of the workflow types registered with the worker or its
+// WorkerOptions.Registry.
+func (aw *AggregatedWorker) RegisteredWorkflowTypes() []string {
+	return sortedStrings(aw.registry.getRegisteredWorkflowTypes())
+}
+
+// RegisteredActivityTypes returns the sorted names of the activity types registered with the worker or its
+// WorkerOptions.Registry.
+func (aw *AggregatedWorker) RegisteredActivityTypes() []string {
+	return sortedStrings(aw.registry.getRegisteredActivityTypes())
+}
+
 // Start the worker in a non-blocking fashion.
 func (aw *AggregatedWorker) Start() error {
 	aw.assertNotStopped()
-	if err := initBinaryChecksum(); err != nil {
-		return fmt.Errorf("failed to get executable checksum: %v", err)
+	if aw.binaryChecksum == "" {
+		if err := initBinaryChecksum(); err != nil {
+			return fmt.Errorf("failed to get executable checksum: %v", err)
+		}
+	}
+	if aw.failoverWatcher != nil {
+		aw.failoverWatcher.start()
+	}
+	if aw.replayOnlyWorker != nil {
+		aw.replayOnlyWorker.start()
 	}
 
 	if !util.IsInterfaceNil(aw.workflowWorker) {
@@ -891,23 +1267,39 @@ func (aw *AggregatedWorker) Start() error {
 	if !util.IsInterfaceNil(aw.activityWorker) {
 		if err := aw.activityWorker.Start(); err != nil {
 			// stop workflow worker.
-			if aw.workflowWorker.worker.isWorkerStarted {
+			if !util.IsInterfaceNil(aw.workflowWorker) && aw.workflowWorker.worker.isWorkerStarted {
 				aw.workflowWorker.Stop()
 			}
 			return err
 		}
 	}
 
+	if !util.IsInterfaceNil(aw.affinityWorker) {
+		if err := aw.affinityWorker.Start(); err != nil {
+			// stop workflow worker and activity worker.
+			if !util.IsInterfaceNil(aw.workflowWorker) && aw.workflowWorker.worker.isWorkerStarted {
+				aw.workflowWorker.Stop()
+			}
+			if aw.activityWorker.worker.isWorkerStarted {
+				aw.activityWorker.Stop()
+			}
+			return err
+		}
+	}
+
 	if !util.IsInterfaceNil(aw.sessionWorker) && len(aw.registry.getRegisteredActivities()) > 0 {
 		aw.logger.Info("Starting session worker")
 		if err := aw.sessionWorker.Start(); err != nil {
 			// stop workflow worker and activity worker.
-			if aw.workflowWorker.worker.isWorkerStarted {
+			if !util.IsInterfaceNil(aw.workflowWorker) && aw.workflowWorker.worker.isWorkerStarted {
 				aw.workflowWorker.Stop()
 			}
 			if aw.activityWorker.worker.isWorkerStarted {
 				aw.activityWorker.Stop()
 			}
+			if !util.IsInterfaceNil(aw.affinityWorker) && aw.affinityWorker.worker.isWorkerStarted {
+				aw.affinityWorker.Stop()
+			}
 			return err
 		}
 	}
@@ -980,6 +1372,29 @@ func initBinaryChecksumLocked() error {
 	return nil
 }
 
+// BinaryChecksum returns the checksum identifying the binary of the worker.
+func (aw *AggregatedWorker) BinaryChecksum() string {
+	return binaryChecksumOrDefault(aw.binaryChecksum)
+}
+
+// PauseActivityType stops the worker from running the activities of the activity type. The activity tasks of the type
+// are held until the type is resumed, so the pause uses up no attempt of the activities.
+func (aw *AggregatedWorker) PauseActivityType(activityType string) {
+	aw.logger.Info("Pausing activity type.", tagActivityType, activityType)
+	aw.activityPauser.pause(activityType)
+}
+
+// ResumeActivityType runs the activities of the paused activity type again.
+func (aw *AggregatedWorker) ResumeActivityType(activityType string) {
+	aw.logger.Info("Resuming activity type.", tagActivityType, activityType)
+	aw.activityPauser.resume(activityType)
+}
+
+// IsActivityTypePaused returns whether the activity type is paused on the worker.
+func (aw *AggregatedWorker) IsActivityTypePaused(activityType string) bool {
+	return aw.activityPauser.isPaused(activityType)
+}
+
 func getBinaryChecksum() string {
 	binaryChecksumLock.Lock()
 	defer binaryChecksumLock.Unlock()
@@ -994,6 +1409,14 @@ func getBinaryChecksum() string {
 	return binaryChecksum
 }
 
+// binaryChecksumOrDefault returns checksum unless empty, the process wide binary checksum otherwise.
+func binaryChecksumOrDefault(checksum string) string {
+	if checksum != "" {
+		return checksum
+	}
+	return getBinaryChecksum()
+}
+
 // Run the worker in a blocking fashion. Stop the worker when interruptCh receives signal.
 // Pass worker.InterruptCh() to stop the worker with SIGINT or SIGTERM.
 // Pass nil to stop the worker with external Stop() call.
@@ -1015,6 +1438,12 @@ func (aw *AggregatedWorker) Run(interruptCh <-chan interface{}) error {
 // Stop the worker.
 func (aw *AggregatedWorker) Stop() {
 	close(aw.stopC)
+	if aw.failoverWatcher != nil {
+		aw.failoverWatcher.stop()
+	}
+	if aw.replayOnlyWorker != nil {
+		aw.replayOnlyWorker.stop()
+	}
 
 	if !util.IsInterfaceNil(aw.workflowWorker) {
 		aw.workflowWorker.Stop()
@@ -1022,6 +1451,9 @@ func (aw *AggregatedWorker) Stop() {
 	if !util.IsInterfaceNil(aw.activityWorker) {
 		aw.activityWorker.Stop()
 	}
+	if !util.IsInterfaceNil(aw.affinityWorker) {
+		aw.affinityWorker.Stop()
+	}
 	if !util.IsInterfaceNil(aw.sessionWorker) {
 		aw.sessionWorker.Stop()
 	}
@@ -1049,8 +1481,15 @@ func (aw *WorkflowReplayer) RegisterWorkflowWithOptions(w interface{}, options R
 	aw.registry.RegisterWorkflowWithOptions(w, options)
 }
 
+// RegisterDynamicWorkflow registers the workflow function that replays the workflow types without a registered
+// workflow
+func (aw *WorkflowReplayer) RegisterDynamicWorkflow(w DynamicWorkflowFunc) {
+	aw.registry.RegisterDynamicWorkflow(w)
+}
+
 // ReplayWorkflowHistory executes a single workflow task for the given history.
 // Use for testing the backwards compatibility of code changes and troubleshooting workflows in a debugger.
+// When the replay diverges from the history, the error is a *NondeterministicReplayError describing where.
 // The logger is an optional parameter. Defaults to the noop logger.
 func (aw *WorkflowReplayer) ReplayWorkflowHistory(logger log.Logger, history *historypb.History) error {
 	if logger == nil {
@@ -1060,7 +1499,28 @@ func (aw *WorkflowReplayer) ReplayWorkflowHistory(logger log.Logger, history *hi
 	controller := gomock.NewController(ilog.NewTestReporter(logger))
 	service := workflowservicemock.NewMockWorkflowServiceClient(controller)
 
-	return aw.replayWorkflowHistory(logger, service, ReplayNamespace, history)
+	return aw.replayWorkflowHistory(logger, service, ReplayNamespace, history, nil)
+}
+
+// DiffWorkflowHistory replays the given history like ReplayWorkflowHistory and returns the differences between the
+// commands generated by the replay and the events of the history, instead of stopping at the first non-deterministic
+// one. It returns no difference and no error for deterministic workflows. When the replay fails, the error is returned
+// along with the differences found so far; if the failure is caused by them, it is a *NondeterministicReplayError.
+// The logger is an optional parameter. Defaults to the noop logger.
+func (aw *WorkflowReplayer) DiffWorkflowHistory(logger log.Logger, history *historypb.History) ([]HistoryDifference, error) {
+	if logger == nil {
+		logger = ilog.NewDefaultLogger()
+	}
+
+	controller := gomock.NewController(ilog.NewTestReporter(logger))
+	service := workflowservicemock.NewMockWorkflowServiceClient(controller)
+
+	var differences []HistoryDifference
+	err := aw.replayWorkflowHistory(logger, service, ReplayNamespace, history,
+		func(commands []*commandpb.Command, events []*historypb.HistoryEvent) {
+			differences = append(differences, diffReplayWithHistory(commands, events)...)
+		})
+	return differences, err
 }
 
 // ReplayWorkflowHistoryFromJSONFile executes a single workflow task for the given json history file.
@@ -1088,7 +1548,7 @@ func (aw *WorkflowReplayer) ReplayPartialWorkflowHistoryFromJSONFile(loger log.L
 	controller := gomock.NewController(ilog.NewTestReporter(loger))
 	service := workflowservicemock.NewMockWorkflowServiceClient(controller)
 
-	return aw.replayWorkflowHistory(loger, service, ReplayNamespace, history)
+	return aw.replayWorkflowHistory(loger, service, ReplayNamespace, history, nil)
 }
 
 // ReplayWorkflowExecution replays workflow execution loading it from Temporal service.
@@ -1097,6 +1557,50 @@ func (aw *WorkflowReplayer) ReplayWorkflowExecution(ctx context.Context, service
 		logger = ilog.NewDefaultLogger()
 	}
 
+	history, err := getReplayWorkflowExecutionHistory(ctx, service, namespace, execution)
+	if err != nil {
+		return err
+	}
+
+	return aw.replayWorkflowHistory(logger, service, namespace, history, nil)
+}
+
+// ReplayWorkflowHistoryChain replays the runs of a continue-as-new chain one after the other, starting with the
+// history of the first run, so that bugs in the state carried over from a run to the next one are caught. When a run
+// continued as new, nextRun is called with the run ID of the next run to get its history, and the next run must start
+// with the input the previous run continued as new with. The replay stops at the first run which fails to replay.
+// The logger is an optional parameter. Defaults to the noop logger.
+func (aw *WorkflowReplayer) ReplayWorkflowHistoryChain(logger log.Logger, history *historypb.History,
+	nextRun func(runID string) (*historypb.History, error)) error {
+	if logger == nil {
+		logger = ilog.NewDefaultLogger()
+	}
+
+	controller := gomock.NewController(ilog.NewTestReporter(logger))
+	service := workflowservicemock.NewMockWorkflowServiceClient(controller)
+
+	return aw.replayWorkflowHistoryChain(logger, service, ReplayNamespace, history, nextRun)
+}
+
+// ReplayWorkflowExecutionChain replays the continue-as-new chain starting with the workflow execution like
+// ReplayWorkflowHistoryChain, loading the history of every run from Temporal service.
+// The logger is the only optional parameter. Defaults to the noop logger.
+func (aw *WorkflowReplayer) ReplayWorkflowExecutionChain(ctx context.Context, service workflowservice.WorkflowServiceClient, logger log.Logger, namespace string, execution WorkflowExecution) error {
+	if logger == nil {
+		logger = ilog.NewDefaultLogger()
+	}
+
+	history, err := getReplayWorkflowExecutionHistory(ctx, service, namespace, execution)
+	if err != nil {
+		return err
+	}
+
+	return aw.replayWorkflowHistoryChain(logger, service, namespace, history, func(runID string) (*historypb.History, error) {
+		return getReplayWorkflowExecutionHistory(ctx, service, namespace, WorkflowExecution{ID: execution.ID, RunID: runID})
+	})
+}
+
+func getReplayWorkflowExecutionHistory(ctx context.Context, service workflowservice.WorkflowServiceClient, namespace string, execution WorkflowExecution) (*historypb.History, error) {
 	sharedExecution := &commonpb.WorkflowExecution{
 		RunId:      execution.RunID,
 		WorkflowId: execution.ID,
@@ -1107,22 +1611,121 @@ func (aw *WorkflowReplayer) ReplayWorkflowExecution(ctx context.Context, service
 	}
 	hResponse, err := service.GetWorkflowExecutionHistory(ctx, request)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if hResponse.RawHistory != nil {
 		history, err := serializer.DeserializeBlobDataToHistoryEvents(hResponse.RawHistory, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		hResponse.History = history
 	}
 
-	return aw.replayWorkflowHistory(logger, service, namespace, hResponse.History)
+	return hResponse.History, nil
+}
+
+func (aw *WorkflowReplayer) replayWorkflowHistoryChain(logger log.Logger, service workflowservice.WorkflowServiceClient, namespace string, history *historypb.History,
+	nextRun func(runID string) (*historypb.History, error)) error {
+	for run := 1; ; run++ {
+		if err := aw.replayWorkflowHistory(logger, service, namespace, history, nil); err != nil {
+			return fmt.Errorf("run %v of the continue-as-new chain: %w", run, err)
+		}
+		events := history.GetEvents()
+		continuedAsNew := events[len(events)-1].GetWorkflowExecutionContinuedAsNewEventAttributes()
+		if continuedAsNew == nil {
+			return nil
+		}
+
+		runID := continuedAsNew.GetNewExecutionRunId()
+		next, err := nextRun(runID)
+		if err != nil {
+			return fmt.Errorf("unable to get the history of run %v of the continue-as-new chain with run ID %v: %w", run+1, runID, err)
+		}
+		started := next.GetEvents()
+		if len(started) == 0 || started[0].GetWorkflowExecutionStartedEventAttributes() == nil {
+			return fmt.Errorf("run %v of the continue-as-new chain: first event is not WorkflowExecutionStarted", run+1)
+		}
+		if !proto.Equal(started[0].GetWorkflowExecutionStartedEventAttributes().GetInput(), continuedAsNew.GetInput()) {
+			return fmt.Errorf("run %v of the continue-as-new chain: the input doesn't match the input run %v continued as new with", run+1, run)
+		}
+		history = next
+	}
+}
+
+// GetChangeVersions returns the versions recorded by GetVersion calls in the given history, by change ID.
+// A change ID missing from the result was never recorded by the execution, i.e. it uses DefaultVersion for it if the
+// GetVersion call was reached. The data converter must match the one of the worker which recorded the history,
+// nil means the default data converter.
+func GetChangeVersions(history *historypb.History, dataConverter converter.DataConverter) (map[string]Version, error) {
+	markers, err := getVersionMarkers(history, dataConverter)
+	if err != nil {
+		return nil, err
+	}
+	changeVersions := make(map[string]Version)
+	for _, marker := range markers {
+		changeVersions[marker.ChangeID] = marker.Version
+	}
+	return changeVersions, nil
 }
 
-func (aw *WorkflowReplayer) replayWorkflowHistory(loger log.Logger, service workflowservice.WorkflowServiceClient, namespace string, history *historypb.History) error {
+func getVersionMarkers(history *historypb.History, dataConverter converter.DataConverter) ([]VersionMarker, error) {
+	if dataConverter == nil {
+		dataConverter = converter.GetDefaultDataConverter()
+	}
+	var markers []VersionMarker
+	for _, event := range history.GetEvents() {
+		attributes := event.GetMarkerRecordedEventAttributes()
+		if attributes == nil || attributes.GetMarkerName() != versionMarkerName {
+			continue
+		}
+		marker := VersionMarker{EventID: event.GetEventId()}
+		if err := dataConverter.FromPayloads(attributes.GetDetails()[versionMarkerChangeIDName], &marker.ChangeID); err != nil {
+			return nil, fmt.Errorf("event %v: unable to decode change ID: %w", event.GetEventId(), err)
+		}
+		if err := dataConverter.FromPayloads(attributes.GetDetails()[versionMarkerDataName], &marker.Version); err != nil {
+			return nil, fmt.Errorf("event %v: unable to decode version: %w", event.GetEventId(), err)
+		}
+		markers = append(markers, marker)
+	}
+	return markers, nil
+}
+
+// newNondeterministicReplayError returns the error of a replay of the history which generated commands with the
+// given differences, with the version markers closest to the first difference which has an event. Extra commands
+// have no event, so when all the differences are extra commands they are located at the end of the history.
+func newNondeterministicReplayError(history *historypb.History, differences []HistoryDifference) *NondeterministicReplayError {
+	err := &NondeterministicReplayError{Differences: differences}
+	events := history.GetEvents()
+	eventID := events[len(events)-1].GetEventId() + 1
+	for _, d := range differences {
+		if d.EventID != 0 {
+			eventID = d.EventID
+			break
+		}
+	}
+	// Markers recorded with another data converter can't be decoded, the differences are still worth returning.
+	markers, _ := getVersionMarkers(history, nil)
+	var before, after *VersionMarker
+	for i := range markers {
+		if markers[i].EventID < eventID {
+			before = &markers[i]
+		} else if markers[i].EventID > eventID && after == nil {
+			after = &markers[i]
+		}
+	}
+	if before != nil {
+		err.NearestVersionMarkers = append(err.NearestVersionMarkers, *before)
+	}
+	if after != nil {
+		err.NearestVersionMarkers = append(err.NearestVersionMarkers, *after)
+	}
+	return err
+}
+
+func (aw *WorkflowReplayer) replayWorkflowHistory(loger log.Logger, service workflowservice.WorkflowServiceClient, namespace string, history *historypb.History,
+	replayCommandsListener func(commands []*commandpb.Command, events []*historypb.HistoryEvent)) error {
 	taskQueue := "ReplayTaskQueue"
 	events := history.Events
 	if events == nil {
@@ -1169,20 +1772,34 @@ func (aw *WorkflowReplayer) replayWorkflowHistory(loger log.Logger, service work
 		taskQueue:     taskQueue,
 	}
 	cache := NewWorkerCache()
+	var differences []HistoryDifference
 	params := workerExecutionParameters{
 		Namespace: namespace,
 		TaskQueue: taskQueue,
 		Identity:  "replayID",
 		Logger:    loger,
 		cache:     cache,
+
+		replayCommandsListener: func(commands []*commandpb.Command, events []*historypb.HistoryEvent) {
+			if replayCommandsListener != nil {
+				replayCommandsListener(commands, events)
+			}
+			differences = append(differences, diffReplayWithHistory(commands, events)...)
+		},
 	}
 	taskHandler := newWorkflowTaskHandler(params, nil, aw.registry)
 	resp, err := taskHandler.ProcessWorkflowTask(&workflowTask{task: task, historyIterator: iterator}, nil)
 	if err != nil {
+		if len(differences) > 0 {
+			return newNondeterministicReplayError(history, differences)
+		}
 		return err
 	}
 
 	if failedReq, ok := resp.(*workflowservice.RespondWorkflowTaskFailedRequest); ok {
+		if len(differences) > 0 {
+			return newNondeterministicReplayError(history, differences)
+		}
 		return fmt.Errorf("replay workflow failed with failure: %v", failedReq.GetFailure())
 	}
 
@@ -1223,16 +1840,15 @@ func extractHistoryFromFile(jsonfileName string, lastEventID int64) (*historypb.
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = reader.Close() }()
 
-	var deserializedHistory historypb.History
-	err = jsonpb.Unmarshal(reader, &deserializedHistory)
-
+	deserializedHistory, err := ReadHistoryJSON(reader)
 	if err != nil {
 		return nil, err
 	}
 
 	if lastEventID <= 0 {
-		return &deserializedHistory, nil
+		return deserializedHistory, nil
 	}
 
 	// Caller is potentially asking for subset of history instead of all history events
@@ -1251,15 +1867,40 @@ func extractHistoryFromFile(jsonfileName string, lastEventID int64) (*historypb.
 
 // NewAggregatedWorker returns an instance to manage both activity and workflow workers
 func NewAggregatedWorker(client *WorkflowClient, taskQueue string, options WorkerOptions) *AggregatedWorker {
+	return newAggregatedWorker(client, taskQueue, options, nil, workflowAndActivityWorker)
+}
+
+// workerKind is the kind of the tasks polled by an AggregatedWorker.
+type workerKind int
+
+const (
+	workflowAndActivityWorker workerKind = iota
+	workflowOnlyWorker
+	activityOnlyWorker
+)
+
+func newAggregatedWorker(client *WorkflowClient, taskQueue string, options WorkerOptions, group *WorkerGroup, kind workerKind) *AggregatedWorker {
 	setClientDefaults(client)
+	setWorkerKindDefaults(&options, kind)
 	setWorkerOptionsDefaults(&options)
 	ctx := options.BackgroundActivityContext
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	// Local activities resolving the task queues of activities with an affinity key describe the task queues of the
+	// namespace of the worker.
+	ctx = context.WithValue(ctx, affinityResolverContextKey, newAffinityResolver(client.workflowService, client.namespace))
 	backgroundActivityContext, backgroundActivityContextCancel := context.WithCancel(ctx)
 
-	cache := NewWorkerCache()
+	// Activity only workers have no workflow to cache.
+	var cache *WorkerCache
+	if kind != activityOnlyWorker {
+		if options.StickyWorkflowCacheSize > 0 {
+			cache = newPrivateWorkerCache(options.StickyWorkflowCacheSize, options.StickyWorkflowCacheMaxBytes)
+		} else {
+			cache = NewWorkerCache()
+		}
+	}
 	workerParams := workerExecutionParameters{
 		Namespace:                             client.namespace,
 		TaskQueue:                             taskQueue,
@@ -1271,6 +1912,7 @@ func NewAggregatedWorker(client *WorkflowClient, taskQueue string, options Worke
 		ConcurrentWorkflowTaskExecutionSize:   options.MaxConcurrentWorkflowTaskExecutionSize,
 		MaxConcurrentWorkflowTaskQueuePollers: options.MaxConcurrentWorkflowTaskPollers,
 		Identity:                              client.identity,
+		BinaryChecksum:                        options.BinaryChecksum,
 		MetricsScope:                          client.metricsScope,
 		Logger:                                client.logger,
 		EnableLoggingInReplay:                 options.EnableLoggingInReplay,
@@ -1279,17 +1921,40 @@ func NewAggregatedWorker(client *WorkflowClient, taskQueue string, options Worke
 		StickyScheduleToStartTimeout:          options.StickyScheduleToStartTimeout,
 		TaskQueueActivitiesPerSecond:          options.TaskQueueActivitiesPerSecond,
 		WorkflowPanicPolicy:                   options.WorkflowPanicPolicy,
+		ActivityPanicPolicy:                   options.ActivityPanicPolicy,
+		ActivityRetryPredicate:                options.ActivityRetryPredicate,
+		OnWorkflowPanic:                       options.OnWorkflowPanic,
+		OnWorkflowStuck:                       options.OnWorkflowStuck,
+		WorkflowStuckAttempts:                 options.WorkflowStuckAttempts,
+		MaxWorkflowTaskCommands:               options.MaxWorkflowTaskCommands,
+		MaxWorkflowTaskCompletionSize:         options.MaxWorkflowTaskCompletionSize,
+		WorkflowAudit:                         options.WorkflowAudit,
+		ActivityTaskPrefetchSize:              options.ActivityTaskPrefetchSize,
 		DataConverter:                         client.dataConverter,
 		WorkerStopTimeout:                     options.WorkerStopTimeout,
 		ContextPropagators:                    client.contextPropagators,
 		Tracer:                                client.tracer,
 		DeadlockDetectionTimeout:              options.DeadlockDetectionTimeout,
+		HistoryPagePrefetchCount:              options.HistoryPagePrefetchCount,
 		cache:                                 cache,
+		ActivityResultCache:                   options.ActivityResultCache,
+		activityPauser:                        newActivityPauser(),
+		backoffStrategy:                       client.backoffStrategy,
+	}
+	if group != nil {
+		workerParams.sharedWorkflowTaskSlots = group.workflowTaskSlots
+		workerParams.sharedActivityTaskSlots = group.activityTaskSlots
+	}
+	if options.NamespaceFailover != nil {
+		workerParams.pollGate = &pollGate{}
 	}
 
 	if options.Identity != "" {
 		workerParams.Identity = options.Identity
 	}
+	if identity := options.IdentityDetails; identity != nil && identity.String() != "" {
+		workerParams.Identity = identity.String()
+	}
 
 	ensureRequiredParams(&workerParams)
 	workerParams.Logger = log.With(workerParams.Logger,
@@ -1300,43 +1965,82 @@ func NewAggregatedWorker(client *WorkflowClient, taskQueue string, options Worke
 
 	processTestTags(&options, &workerParams)
 
-	// worker specific registry
-	registry := newRegistry()
-	registry.SetWorkflowInterceptors(options.WorkflowInterceptorChainFactories)
+	// worker specific registry unless the worker is part of a group
+	var registry *registry
+	if group != nil {
+		registry = group.registry
+	} else {
+		registry = newRegistry()
+		registry.SetWorkflowInterceptors(options.WorkflowInterceptorChainFactories)
+		registry.SetTaskQueueRoutes(options.TaskQueueRoutes)
+		if options.Registry != nil {
+			registry.parent = options.Registry.registry
+		}
+	}
 
 	// workflow factory.
 	var workflowWorker *workflowWorker
 	testTags := getTestTags(options.BackgroundActivityContext)
-	if len(testTags) > 0 {
-		workflowWorker = newWorkflowWorkerWithPressurePoints(client.workflowService, workerParams, testTags, registry)
-	} else {
-		workflowWorker = newWorkflowWorker(client.workflowService, workerParams, nil, registry)
+	if kind != activityOnlyWorker && !options.ReplayOnly {
+		if len(testTags) > 0 {
+			workflowWorker = newWorkflowWorkerWithPressurePoints(client.workflowService, workerParams, testTags, registry)
+		} else {
+			workflowWorker = newWorkflowWorker(client.workflowService, workerParams, nil, registry)
+		}
 	}
 
 	// activity types.
-	var activityWorker *activityWorker
-	if !options.LocalActivityWorkerOnly {
-		activityWorker = newActivityWorker(client.workflowService, workerParams, nil, registry, nil)
+	var actWorker *activityWorker
+	if !options.LocalActivityWorkerOnly && !options.ReplayOnly {
+		actWorker = newActivityWorker(client.workflowService, workerParams, nil, registry, nil)
+	}
+
+	var affinityWorker *activityWorker
+	if options.EnableActivityAffinity && !options.LocalActivityWorkerOnly && !options.ReplayOnly {
+		affinityParams := workerParams
+		affinityParams.TaskQueue = getAffinityTaskQueue(taskQueue, workerParams.Identity)
+		affinityWorker = newActivityWorker(client.workflowService, affinityParams, nil, registry, nil)
 	}
 
 	var sessionWorker *sessionWorker
-	if options.EnableSessionWorker && !options.LocalActivityWorkerOnly {
+	if options.EnableSessionWorker && !options.LocalActivityWorkerOnly && !options.ReplayOnly {
 		sessionWorker = newSessionWorker(client.workflowService, workerParams, nil, registry, options.MaxConcurrentSessionExecutionSize)
+		// The activities are already registered if another worker of the group has sessions enabled.
 		registry.RegisterActivityWithOptions(sessionCreationActivity, RegisterActivityOptions{
-			Name: sessionCreationActivityName,
+			Name:                          sessionCreationActivityName,
+			DisableAlreadyRegisteredCheck: group != nil,
 		})
 		registry.RegisterActivityWithOptions(sessionCompletionActivity, RegisterActivityOptions{
-			Name: sessionCompletionActivityName,
+			Name:                          sessionCompletionActivityName,
+			DisableAlreadyRegisteredCheck: group != nil,
 		})
 	}
 
+	var failoverWatcher *namespaceFailoverWatcher
+	if options.NamespaceFailover != nil {
+		failoverWatcher = newNamespaceFailoverWatcher(client.workflowService, client.namespace, *options.NamespaceFailover,
+			workerParams.pollGate, workerParams.Logger, workerParams.MetricsScope)
+	}
+
+	var replayOnly *replayOnlyWorker
+	if options.ReplayOnly {
+		replayOnly = newReplayOnlyWorker(client.workflowService, client.namespace, taskQueue, registry,
+			workerParams.Logger, workerParams.MetricsScope)
+	}
+
 	return &AggregatedWorker{
-		workflowWorker: workflowWorker,
-		activityWorker: activityWorker,
-		sessionWorker:  sessionWorker,
-		logger:         workerParams.Logger,
-		registry:       registry,
-		stopC:          make(chan struct{}),
+		workflowWorker:   workflowWorker,
+		activityWorker:   actWorker,
+		affinityWorker:   affinityWorker,
+		sessionWorker:    sessionWorker,
+		logger:           workerParams.Logger,
+		registry:         registry,
+		stopC:            make(chan struct{}),
+		cache:            cache,
+		binaryChecksum:   workerParams.BinaryChecksum,
+		activityPauser:   workerParams.activityPauser,
+		failoverWatcher:  failoverWatcher,
+		replayOnlyWorker: replayOnly,
 	}
 }
 
@@ -1428,6 +2132,24 @@ func getReadOnlyChannel(c chan struct{}) <-chan struct{} {
 	return c
 }
 
+// setWorkerKindDefaults sets the defaults of the workers polling a single kind of task, which differ from the defaults
+// of the workers polling both kinds.
+func setWorkerKindDefaults(options *WorkerOptions, kind workerKind) {
+	switch kind {
+	case workflowOnlyWorker:
+		options.LocalActivityWorkerOnly = true
+		if options.MaxConcurrentWorkflowTaskPollers <= 0 {
+			options.MaxConcurrentWorkflowTaskPollers = defaultSingleKindConcurrentPollRoutineSize
+		}
+	case activityOnlyWorker:
+		options.LocalActivityWorkerOnly = false
+		options.ReplayOnly = false
+		if options.MaxConcurrentActivityTaskPollers <= 0 {
+			options.MaxConcurrentActivityTaskPollers = defaultSingleKindConcurrentPollRoutineSize
+		}
+	}
+}
+
 func setWorkerOptionsDefaults(options *WorkerOptions) {
 	if options.MaxConcurrentActivityExecutionSize == 0 {
 		options.MaxConcurrentActivityExecutionSize = defaultMaxConcurrentActivityExecutionSize
@@ -1459,6 +2181,12 @@ func setWorkerOptionsDefaults(options *WorkerOptions) {
 	if options.MaxConcurrentSessionExecutionSize == 0 {
 		options.MaxConcurrentSessionExecutionSize = defaultMaxConcurrentSessionExecutionSize
 	}
+	if options.WorkflowStuckAttempts <= 0 {
+		options.WorkflowStuckAttempts = defaultWorkflowStuckAttempts
+	}
+	if options.MaxWorkflowTaskCompletionSize == 0 {
+		options.MaxWorkflowTaskCompletionSize = defaultMaxWorkflowTaskCompletionSize
+	}
 	if options.DeadlockDetectionTimeout == 0 {
 		if debugMode {
 			options.DeadlockDetectionTimeout = unlimitedDeadlockDetectionTimeout
@@ -38,6 +38,7 @@ import (
 	commonpb "go.temporal.io/api/common/v1"
 	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/internal/common/retry"
+	"go.uber.org/atomic"
 	"golang.org/x/time/rate"
 
 	"go.temporal.io/sdk/converter"
@@ -79,6 +80,8 @@ type (
 		WorkflowTimerClient
 		SideEffect(f func() (*commonpb.Payloads, error), callback ResultHandler)
 		GetVersion(changeID string, minSupported, maxSupported Version) Version
+		// ChangeVersions returns a copy of the versions recorded by GetVersion so far.
+		ChangeVersions() map[string]Version
 		WorkflowInfo() *WorkflowInfo
 		Complete(result *commonpb.Payloads, err error)
 		RegisterCancelHandler(handler func())
@@ -133,6 +136,24 @@ type (
 		workerType        string
 		stopTimeout       time.Duration
 		userContextCancel context.CancelFunc
+		// sharedTaskSlots limits the number of tasks executed at once by this worker together with other workers.
+		sharedTaskSlots *sharedTaskSlots
+		// pollGate pauses the pollers of this worker, e.g. while its namespace is active in another cluster.
+		pollGate *pollGate
+		// backoffStrategy randomizes the delays between poll retries instead of the default jitter. Optional.
+		backoffStrategy retry.BackoffStrategy
+		// taskPriority returns the priority of a polled task, and false if the poll returned no task. The polled tasks
+		// waiting for an execution slot are executed by descending priority. Optional.
+		taskPriority func(task interface{}) (int, bool)
+		// prefetchCount is the number of tasks polled in addition to the executing ones, waiting for an execution slot
+		// to be executed by priority. Only used with taskPriority.
+		prefetchCount int
+	}
+
+	// sharedTaskSlots limits the number of tasks executed at once by several workers. A poller takes a slot before
+	// polling and the slot is released when the poll returns no task or when the polled task is processed.
+	sharedTaskSlots struct {
+		slots chan struct{}
 	}
 
 	// baseWorker that wraps worker activities.
@@ -151,7 +172,14 @@ type (
 
 		pollerRequestCh    chan struct{}
 		taskQueueCh        chan interface{}
+		taskDoneCh         chan struct{} // notifies the dispatcher of the executed tasks when tasks have a priority
 		sessionTokenBucket *sessionTokenBucket
+
+		// Number of execution slots taken by polled tasks. Pollers only poll when a slot is available, so a worker
+		// with all slots taken stops polling instead of queueing tasks it cannot execute.
+		taskSlotsUsed atomic.Int32
+		// Number of pollers waiting for a poll request to return.
+		pollersPolling atomic.Int32
 	}
 
 	polledTask struct {
@@ -159,7 +187,7 @@ type (
 	}
 )
 
-func createPollRetryPolicy() backoff.RetryPolicy {
+func createPollRetryPolicy() *backoff.ExponentialRetryPolicy {
 	policy := backoff.NewExponentialRetryPolicy(retryPollOperationInitialInterval)
 	policy.SetMaximumInterval(retryPollOperationMaxInterval)
 
@@ -171,13 +199,37 @@ func createPollRetryPolicy() backoff.RetryPolicy {
 	return policy
 }
 
+func newSharedTaskSlots(size int) *sharedTaskSlots {
+	return &sharedTaskSlots{slots: make(chan struct{}, size)}
+}
+
+// acquire blocks until a slot is available and takes it. Returns false if stopCh is closed before.
+func (s *sharedTaskSlots) acquire(stopCh <-chan struct{}) bool {
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	case <-stopCh:
+		return false
+	}
+}
+
+func (s *sharedTaskSlots) release() {
+	<-s.slots
+}
+
 func newBaseWorker(options baseWorkerOptions, logger log.Logger, metricsScope tally.Scope, sessionTokenBucket *sessionTokenBucket) *baseWorker {
 	ctx, cancel := context.WithCancel(context.Background())
+	pollRetryPolicy := pollOperationRetryPolicy
+	if options.backoffStrategy != nil {
+		policy := createPollRetryPolicy()
+		policy.SetBackoffStrategy(options.backoffStrategy)
+		pollRetryPolicy = policy
+	}
 	bw := &baseWorker{
 		options:         options,
 		stopCh:          make(chan struct{}),
 		taskLimiter:     rate.NewLimiter(rate.Limit(options.maxTaskPerSecond), 1),
-		retrier:         backoff.NewConcurrentRetrier(pollOperationRetryPolicy),
+		retrier:         backoff.NewConcurrentRetrier(pollRetryPolicy),
 		logger:          log.With(logger, tagWorkerType, options.workerType),
 		metricsScope:    metrics.GetWorkerScope(metricsScope, options.workerType),
 		pollerRequestCh: make(chan struct{}, options.maxConcurrentTask),
@@ -190,6 +242,10 @@ func newBaseWorker(options baseWorkerOptions, logger log.Logger, metricsScope ta
 	if options.pollerRate > 0 {
 		bw.pollLimiter = rate.NewLimiter(rate.Limit(options.pollerRate), 1)
 	}
+	if options.taskPriority != nil {
+		bw.pollerRequestCh = make(chan struct{}, options.maxConcurrentTask+options.prefetchCount)
+		bw.taskDoneCh = make(chan struct{})
+	}
 
 	return bw
 }
@@ -201,6 +257,8 @@ func (bw *baseWorker) Start() {
 	}
 
 	bw.metricsScope.Counter(metrics.WorkerStartCounter).Inc(1)
+	bw.updateTaskSlotsMetrics(0)
+	bw.updatePollerCountMetric(0)
 
 	for i := 0; i < bw.options.pollerCount; i++ {
 		bw.stopWG.Add(1)
@@ -238,6 +296,9 @@ func (bw *baseWorker) runPoller() {
 		case <-bw.stopCh:
 			return
 		case <-bw.pollerRequestCh:
+			if !bw.options.pollGate.wait(bw.stopCh) {
+				return
+			}
 			if bw.sessionTokenBucket != nil {
 				bw.sessionTokenBucket.waitForAvailableToken()
 			}
@@ -249,9 +310,13 @@ func (bw *baseWorker) runPoller() {
 func (bw *baseWorker) runTaskDispatcher() {
 	defer bw.stopWG.Done()
 
-	for i := 0; i < bw.options.maxConcurrentTask; i++ {
+	for i := 0; i < cap(bw.pollerRequestCh); i++ {
 		bw.pollerRequestCh <- struct{}{}
 	}
+	if bw.options.taskPriority != nil {
+		bw.dispatchTasksByPriority()
+		return
+	}
 
 	for {
 		// wait for new task or worker stop
@@ -266,6 +331,9 @@ func (bw *baseWorker) runTaskDispatcher() {
 					return
 				}
 			}
+			if isPolledTask {
+				bw.updateTaskSlotsMetrics(bw.taskSlotsUsed.Inc())
+			}
 			bw.stopWG.Add(1)
 			go bw.processTask(task)
 		}
@@ -276,8 +344,17 @@ func (bw *baseWorker) pollTask() {
 	var err error
 	var task interface{}
 	bw.retrier.Throttle()
+	// The shared slot is taken before polling like the slot of the worker, so that no task is polled without a slot
+	// to process it, and it is released when the poll returns no task.
+	if bw.options.sharedTaskSlots != nil && !bw.options.sharedTaskSlots.acquire(bw.stopCh) {
+		return
+	}
 	if bw.pollLimiter == nil || bw.pollLimiter.Wait(bw.limiterContext) == nil {
+		bw.updatePollerCountMetric(bw.pollersPolling.Inc())
+		pollStartTime := time.Now()
 		task, err = bw.options.taskWorker.PollTask()
+		bw.metricsScope.Timer(metrics.WorkerPollLatency).Record(time.Since(pollStartTime))
+		bw.updatePollerCountMetric(bw.pollersPolling.Dec())
 		if err != nil && enableVerboseLogging {
 			bw.logger.Debug("Failed to poll for task.", tagError, err)
 		}
@@ -289,6 +366,7 @@ func (bw *baseWorker) pollTask() {
 				} else {
 					_ = p.Signal(os.Interrupt)
 				}
+				bw.releaseSharedTaskSlot()
 				return
 			}
 			bw.retrier.Failed()
@@ -301,12 +379,29 @@ func (bw *baseWorker) pollTask() {
 		select {
 		case bw.taskQueueCh <- &polledTask{task}:
 		case <-bw.stopCh:
+			bw.releaseSharedTaskSlot()
 		}
 	} else {
+		bw.releaseSharedTaskSlot()
 		bw.pollerRequestCh <- struct{}{} // poll failed, trigger a new poll
 	}
 }
 
+func (bw *baseWorker) releaseSharedTaskSlot() {
+	if bw.options.sharedTaskSlots != nil {
+		bw.options.sharedTaskSlots.release()
+	}
+}
+
+func (bw *baseWorker) updateTaskSlotsMetrics(used int32) {
+	bw.metricsScope.Gauge(metrics.WorkerTaskSlotsUsed).Update(float64(used))
+	bw.metricsScope.Gauge(metrics.WorkerTaskSlotsAvailable).Update(float64(int32(bw.options.maxConcurrentTask) - used))
+}
+
+func (bw *baseWorker) updatePollerCountMetric(polling int32) {
+	bw.metricsScope.Gauge(metrics.WorkerPollerCount).Update(float64(polling))
+}
+
 func isNonRetriableError(err error) bool {
 	if err == nil {
 		return false
@@ -337,7 +432,15 @@ func (bw *baseWorker) processTask(task interface{}) {
 		}
 
 		if isPolledTask {
+			bw.updateTaskSlotsMetrics(bw.taskSlotsUsed.Dec())
+			bw.releaseSharedTaskSlot()
 			bw.pollerRequestCh <- struct{}{}
+			if bw.taskDoneCh != nil {
+				select {
+				case bw.taskDoneCh <- struct{}{}:
+				case <-bw.stopCh:
+				}
+			}
 		}
 	}()
 	err := bw.options.taskWorker.ProcessTask(task)
@@ -0,0 +1,215 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.temporal.io/sdk/internal/common/metrics"
+	"go.uber.org/atomic"
+)
+
+type blockingTaskPoller struct {
+	startedCh chan interface{}
+	releaseCh chan struct{}
+}
+
+func (p *blockingTaskPoller) PollTask() (interface{}, error) {
+	return "task", nil
+}
+
+func (p *blockingTaskPoller) ProcessTask(task interface{}) error {
+	select {
+	case p.startedCh <- task:
+	default:
+	}
+	<-p.releaseCh
+	return nil
+}
+
+func TestBaseWorker_TaskSlotsMetrics(t *testing.T) {
+	scope, closer, reporter := metrics.NewTaggedMetricsScope()
+	poller := &blockingTaskPoller{startedCh: make(chan interface{}, 10), releaseCh: make(chan struct{})}
+	bw := newBaseWorker(baseWorkerOptions{
+		pollerCount:       2,
+		maxConcurrentTask: 3,
+		maxTaskPerSecond:  1000,
+		taskWorker:        poller,
+		workerType:        "TestWorker",
+		stopTimeout:       time.Second,
+	}, getLogger(), scope, nil)
+	bw.Start()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-poller.startedCh:
+		case <-time.After(time.Second):
+			t.Fatal("task was not dispatched")
+		}
+	}
+	// All slots are taken, no more tasks are polled until one is released.
+	select {
+	case <-poller.startedCh:
+		t.Fatal("task was dispatched without an available slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, closer.Close())
+
+	gauges := map[string]float64{}
+	for _, gauge := range reporter.Gauges() {
+		require.Equal(t, "TestWorker", gauge.Tags()[metrics.WorkerTypeTagName])
+		gauges[gauge.Name()] = gauge.Value()
+	}
+	require.Equal(t, float64(3), gauges[metrics.WorkerTaskSlotsUsed])
+	require.Equal(t, float64(0), gauges[metrics.WorkerTaskSlotsAvailable])
+
+	close(poller.releaseCh)
+	bw.Stop()
+}
+
+type countingTaskPoller struct {
+	blockingTaskPoller
+	polls atomic.Int32
+}
+
+func (p *countingTaskPoller) PollTask() (interface{}, error) {
+	p.polls.Inc()
+	return "task", nil
+}
+
+func TestBaseWorker_PollerMetrics(t *testing.T) {
+	scope, closer, reporter := metrics.NewTaggedMetricsScope()
+	poller := &countingTaskPoller{
+		blockingTaskPoller: blockingTaskPoller{startedCh: make(chan interface{}, 10), releaseCh: make(chan struct{})},
+	}
+	bw := newBaseWorker(baseWorkerOptions{
+		pollerCount:       4,
+		maxConcurrentTask: 2,
+		maxTaskPerSecond:  1000,
+		taskWorker:        poller,
+		workerType:        "TestWorker",
+		stopTimeout:       time.Second,
+	}, getLogger(), scope, nil)
+	bw.Start()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-poller.startedCh:
+		case <-time.After(time.Second):
+			t.Fatal("task was not dispatched")
+		}
+	}
+	// Pollers stop polling while every execution slot is busy.
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(2), poller.polls.Load())
+	require.NoError(t, closer.Close())
+
+	var pollLatencies int
+	for _, timer := range reporter.Timers() {
+		if timer.Name() == metrics.WorkerPollLatency {
+			require.Equal(t, "TestWorker", timer.Tags()[metrics.WorkerTypeTagName])
+			pollLatencies++
+		}
+	}
+	require.Equal(t, 2, pollLatencies)
+	gauges := map[string]float64{}
+	for _, gauge := range reporter.Gauges() {
+		gauges[gauge.Name()] = gauge.Value()
+	}
+	require.Equal(t, float64(0), gauges[metrics.WorkerPollerCount])
+
+	close(poller.releaseCh)
+	bw.Stop()
+}
+
+type testPriorityTask struct {
+	name     string
+	priority int
+}
+
+type priorityTaskPoller struct {
+	blockingTaskPoller
+	tasksCh chan interface{}
+}
+
+func (p *priorityTaskPoller) PollTask() (interface{}, error) {
+	select {
+	case task := <-p.tasksCh:
+		return task, nil
+	case <-time.After(10 * time.Millisecond):
+		return nil, nil
+	}
+}
+
+func TestBaseWorker_TaskPriority(t *testing.T) {
+	poller := &priorityTaskPoller{
+		blockingTaskPoller: blockingTaskPoller{startedCh: make(chan interface{}, 10), releaseCh: make(chan struct{})},
+		tasksCh:            make(chan interface{}, 10),
+	}
+	poller.tasksCh <- testPriorityTask{name: "bulk-0"}
+	bw := newBaseWorker(baseWorkerOptions{
+		pollerCount:       2,
+		maxConcurrentTask: 1,
+		maxTaskPerSecond:  1000,
+		taskWorker:        poller,
+		workerType:        "TestWorker",
+		stopTimeout:       time.Second,
+		taskPriority: func(task interface{}) (int, bool) {
+			return task.(testPriorityTask).priority, true
+		},
+		prefetchCount: 3,
+	}, getLogger(), tally.NoopScope, nil)
+	bw.Start()
+	defer bw.Stop()
+
+	started := func() string {
+		select {
+		case task := <-poller.startedCh:
+			return task.(testPriorityTask).name
+		case <-time.After(time.Second):
+			t.Fatal("task was not dispatched")
+			return ""
+		}
+	}
+	require.Equal(t, "bulk-0", started())
+
+	// The tasks polled while the only execution slot is taken wait for it.
+	poller.tasksCh <- testPriorityTask{name: "bulk-1"}
+	poller.tasksCh <- testPriorityTask{name: "bulk-2"}
+	poller.tasksCh <- testPriorityTask{name: "urgent", priority: 10}
+	require.Eventually(t, func() bool { return len(poller.tasksCh) == 0 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	var order []string
+	for i := 0; i < 3; i++ {
+		poller.releaseCh <- struct{}{}
+		order = append(order, started())
+	}
+	require.Equal(t, []string{"urgent", "bulk-1", "bulk-2"}, order)
+	close(poller.releaseCh)
+}
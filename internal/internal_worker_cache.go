@@ -57,18 +57,34 @@ var sharedWorkerCacheLock sync.Mutex
 
 // Must be set before spawning any workers
 var desiredWorkflowCacheSize = defaultStickyCacheSize
+var desiredWorkflowCacheMaxBytes int64
 
 // SetStickyWorkflowCacheSize sets the cache size for sticky workflow cache. Sticky workflow execution is the affinity
 // between workflow tasks of a specific workflow execution to a specific worker. The benefit of sticky execution is that
 // the workflow does not have to reconstruct state by replaying history from the beginning. The cache is shared between
 // workers running within same process. This must be called before any worker is started. If not called, the default
-// size of 10K (which may change) will be used.
+// size of 10K (which may change) will be used. Workers with Options.StickyWorkflowCacheSize set use their own cache
+// instead of the shared one.
 func SetStickyWorkflowCacheSize(cacheSize int) {
 	sharedWorkerCacheLock.Lock()
 	defer sharedWorkerCacheLock.Unlock()
 	desiredWorkflowCacheSize = cacheSize
 }
 
+// SetStickyWorkflowCacheMaxBytes limits the sticky workflow cache shared by workers running within the same process
+// by the total size of the history of the cached workflow executions, in bytes. When the limit is exceeded, the least
+// recently used workflow executions are evicted. This allows caching many small workflow executions while preventing a
+// few executions with huge histories from taking over the memory of the worker, and is an alternative to shrinking
+// the cache size, which causes the remaining executions to be evicted and fully replayed more often. No part of the
+// history is retained for evicted workflow executions, so their next workflow task always replays the full history.
+// This must be called before any worker is started. If not called or set to 0, only the number of cached workflow
+// executions is limited.
+func SetStickyWorkflowCacheMaxBytes(maxBytes int64) {
+	sharedWorkerCacheLock.Lock()
+	defer sharedWorkerCacheLock.Unlock()
+	desiredWorkflowCacheMaxBytes = maxBytes
+}
+
 // PurgeStickyWorkflowCache resets the sticky workflow cache. This must be called only when all workers are stopped.
 func PurgeStickyWorkflowCache() {
 	sharedWorkerCacheLock.Lock()
@@ -83,11 +99,16 @@ func PurgeStickyWorkflowCache() {
 // a hook to runtime.SetFinalizer (ie: When they are freed by the GC). When there are no reachable instances of
 // WorkerCache, shared caches will be cleared
 func NewWorkerCache() *WorkerCache {
-	return newWorkerCache(sharedWorkerCachePtr, &sharedWorkerCacheLock, desiredWorkflowCacheSize)
+	return newWorkerCache(sharedWorkerCachePtr, &sharedWorkerCacheLock, desiredWorkflowCacheSize, desiredWorkflowCacheMaxBytes)
+}
+
+// newPrivateWorkerCache creates a new WorkerCache which is not shared with other workers.
+func newPrivateWorkerCache(cacheSize int, maxBytes int64) *WorkerCache {
+	return newWorkerCache(&sharedWorkerCache{}, &sync.Mutex{}, cacheSize, maxBytes)
 }
 
 // This private version allows us to test functionality without affecting the global shared cache
-func newWorkerCache(storeIn *sharedWorkerCache, lock *sync.Mutex, cacheSize int) *WorkerCache {
+func newWorkerCache(storeIn *sharedWorkerCache, lock *sync.Mutex, cacheSize int, maxBytes int64) *WorkerCache {
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -101,6 +122,10 @@ func newWorkerCache(storeIn *sharedWorkerCache, lock *sync.Mutex, cacheSize int)
 				wc := cachedEntity.(*workflowExecutionContextImpl)
 				wc.onEviction()
 			},
+			MaxTotalSize: maxBytes,
+			SizeFunc: func(cachedEntity interface{}) int64 {
+				return cachedEntity.(*workflowExecutionContextImpl).getHistorySize()
+			},
 		})
 		*storeIn = sharedWorkerCache{workflowCache: &newcache, workerRefcount: 0, maxWorkflowCacheSize: cacheSize}
 	}
@@ -150,6 +175,25 @@ func (wc *WorkerCache) removeWorkflowContext(runID string) {
 	(*wc.sharedCache.workflowCache).Delete(runID)
 }
 
+// evictWorkflowContext removes the cached workflow execution if it belongs to the workflowID.
+// Returns true if the workflow execution was found in the cache.
+func (wc *WorkerCache) evictWorkflowContext(workflowID, runID string) bool {
+	wec := wc.getWorkflowContext(runID)
+	if wec == nil {
+		return false
+	}
+	// The workflow task being processed, if any, completes before the workflow info is read. The lock is released
+	// before the removal, which locks the context again on eviction.
+	wec.mutex.Lock()
+	matches := wec.workflowInfo != nil && wec.workflowInfo.WorkflowExecution.ID == workflowID
+	wec.mutex.Unlock()
+	if !matches {
+		return false
+	}
+	wc.removeWorkflowContext(runID)
+	return true
+}
+
 // MaxWorkflowCacheSize returns the maximum allowed size of the sticky cache
 func (wc *WorkerCache) MaxWorkflowCacheSize() int {
 	if wc == nil {
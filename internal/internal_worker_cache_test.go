@@ -45,12 +45,12 @@ func (s *WorkerCacheSuite) TestCreateAndFree() {
 	cachePtr := &sharedWorkerCache{}
 	var lock sync.Mutex
 
-	cache := newWorkerCache(cachePtr, &lock, 10)
+	cache := newWorkerCache(cachePtr, &lock, 10, 0)
 	s.NotNil(cache)
 	s.NotNil(cachePtr)
 	s.NotNil(cachePtr.workflowCache)
 	s.Equal(cachePtr.workerRefcount, 1)
-	cache2 := newWorkerCache(cachePtr, &lock, 10)
+	cache2 := newWorkerCache(cachePtr, &lock, 10, 0)
 	s.NotNil(cache2)
 	s.NotNil(cachePtr.workflowCache)
 	s.Equal(cachePtr.workerRefcount, 2)
@@ -61,3 +61,69 @@ func (s *WorkerCacheSuite) TestCreateAndFree() {
 	s.Equal(cachePtr.workerRefcount, 0)
 	s.Nil(cachePtr.workflowCache)
 }
+
+func (s *WorkerCacheSuite) TestPrivateCache() {
+	cache := newPrivateWorkerCache(5, 0)
+	cache2 := newPrivateWorkerCache(7, 0)
+	s.Equal(5, cache.MaxWorkflowCacheSize())
+	s.Equal(7, cache2.MaxWorkflowCacheSize())
+	s.NotEqual(cache.sharedCache, cache2.sharedCache)
+
+	_, err := cache.putWorkflowContext("rid", &workflowExecutionContextImpl{})
+	s.NoError(err)
+	s.Equal(1, cache.getWorkflowCache().Size())
+	s.Equal(0, cache2.getWorkflowCache().Size())
+}
+
+func (s *WorkerCacheSuite) TestEvictWorkflowContext() {
+	cache := newPrivateWorkerCache(5, 0)
+	resultCh := make(chan interface{}, 1)
+	wec := &workflowExecutionContextImpl{
+		workflowInfo: &WorkflowInfo{
+			WorkflowExecution: WorkflowExecution{ID: "wid", RunID: "rid"},
+			WorkflowType:      WorkflowType{Name: "wt"},
+			Namespace:         "ns",
+		},
+		wth:      &workflowTaskHandlerImpl{},
+		laTunnel: &localActivityTunnel{resultCh: resultCh},
+	}
+	_, err := cache.putWorkflowContext("rid", wec)
+	s.NoError(err)
+
+	s.False(cache.evictWorkflowContext("wid", "unknown-rid"))
+	s.False(cache.evictWorkflowContext("other-wid", "rid"))
+	s.True(cache.evictWorkflowContext("wid", "rid"))
+	s.Nil(cache.getWorkflowContext("rid"))
+
+	// Eviction resets stickiness of the evicted workflow execution.
+	task := (<-resultCh).(*resetStickinessTask)
+	s.Equal("wid", task.task.GetExecution().GetWorkflowId())
+	s.Equal("rid", task.task.GetExecution().GetRunId())
+}
+
+func (s *WorkerCacheSuite) TestCacheMaxBytes() {
+	cache := newPrivateWorkerCache(5, 100)
+	newContext := func(runID string, historySize int64) *workflowExecutionContextImpl {
+		wec := &workflowExecutionContextImpl{
+			workflowInfo: &WorkflowInfo{
+				WorkflowExecution: WorkflowExecution{ID: "wid", RunID: runID},
+				WorkflowType:      WorkflowType{Name: "wt"},
+			},
+			wth: &workflowTaskHandlerImpl{},
+		}
+		wec.historySize.Store(historySize)
+		return wec
+	}
+
+	_, err := cache.putWorkflowContext("rid1", newContext("rid1", 40))
+	s.NoError(err)
+	_, err = cache.putWorkflowContext("rid2", newContext("rid2", 40))
+	s.NoError(err)
+	s.Equal(2, cache.getWorkflowCache().Size())
+
+	// History of rid2 grows over the limit, rid1 is the least recently used.
+	cache.getWorkflowContext("rid2").historySize.Store(70)
+	s.NotNil(cache.getWorkflowContext("rid2"))
+	s.Equal(1, cache.getWorkflowCache().Size())
+	s.Nil(cache.getWorkflowContext("rid1"))
+}
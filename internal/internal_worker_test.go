@@ -267,6 +267,13 @@ func testActivity(context.Context) error {
 	return nil
 }
 
+func testReplayChainWorkflow(ctx Context, remaining int) error {
+	if remaining > 0 {
+		return NewContinueAsNewError(ctx, testReplayChainWorkflow, remaining-1)
+	}
+	return nil
+}
+
 func (s *internalWorkerTestSuite) TestReplayWorkflowHistory() {
 	taskQueue := "taskQueue1"
 	testEvents := []*historypb.HistoryEvent{
@@ -309,6 +316,76 @@ func (s *internalWorkerTestSuite) TestReplayWorkflowHistory() {
 	require.NoError(s.T(), err)
 }
 
+func (s *internalWorkerTestSuite) TestReplayWorkflowHistoryChain() {
+	taskQueue := "taskQueue1"
+	dc := converter.GetDefaultDataConverter()
+	encode := func(remaining int) *commonpb.Payloads {
+		payloads, err := dc.ToPayloads(remaining)
+		s.NoError(err)
+		return payloads
+	}
+	newRun := func(remaining int, last *historypb.HistoryEvent) *historypb.History {
+		return &historypb.History{Events: []*historypb.HistoryEvent{
+			createTestEventWorkflowExecutionStarted(1, &historypb.WorkflowExecutionStartedEventAttributes{
+				WorkflowType: &commonpb.WorkflowType{Name: "testReplayChainWorkflow"},
+				TaskQueue:    &taskqueuepb.TaskQueue{Name: taskQueue},
+				Input:        encode(remaining),
+			}),
+			createTestEventWorkflowTaskScheduled(2, &historypb.WorkflowTaskScheduledEventAttributes{}),
+			createTestEventWorkflowTaskStarted(3),
+			createTestEventWorkflowTaskCompleted(4, &historypb.WorkflowTaskCompletedEventAttributes{
+				ScheduledEventId: 2,
+				StartedEventId:   3,
+			}),
+			last,
+		}}
+	}
+	continuedAsNew := func(remaining int, runID string) *historypb.HistoryEvent {
+		return &historypb.HistoryEvent{
+			EventId:   5,
+			EventType: enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_CONTINUED_AS_NEW,
+			Attributes: &historypb.HistoryEvent_WorkflowExecutionContinuedAsNewEventAttributes{
+				WorkflowExecutionContinuedAsNewEventAttributes: &historypb.WorkflowExecutionContinuedAsNewEventAttributes{
+					NewExecutionRunId:            runID,
+					WorkflowType:                 &commonpb.WorkflowType{Name: "testReplayChainWorkflow"},
+					TaskQueue:                    &taskqueuepb.TaskQueue{Name: taskQueue},
+					Input:                        encode(remaining),
+					WorkflowTaskCompletedEventId: 4,
+				},
+			},
+		}
+	}
+	completed := createTestEventWorkflowExecutionCompleted(5, &historypb.WorkflowExecutionCompletedEventAttributes{
+		WorkflowTaskCompletedEventId: 4,
+	})
+
+	runs := map[string]*historypb.History{
+		"run-2": newRun(1, continuedAsNew(0, "run-3")),
+		"run-3": newRun(0, completed),
+	}
+	var requestedRuns []string
+	nextRun := func(runID string) (*historypb.History, error) {
+		requestedRuns = append(requestedRuns, runID)
+		history, ok := runs[runID]
+		if !ok {
+			return nil, fmt.Errorf("unknown run %v", runID)
+		}
+		return history, nil
+	}
+
+	replayer := NewWorkflowReplayer()
+	replayer.RegisterWorkflow(testReplayChainWorkflow)
+	err := replayer.ReplayWorkflowHistoryChain(getLogger(), newRun(2, continuedAsNew(1, "run-2")), nextRun)
+	s.NoError(err)
+	s.Equal([]string{"run-2", "run-3"}, requestedRuns)
+
+	// The second run doesn't start with the input the first run continued as new with.
+	runs["run-2"] = newRun(5, continuedAsNew(4, "run-3"))
+	err = replayer.ReplayWorkflowHistoryChain(getLogger(), newRun(2, continuedAsNew(1, "run-2")), nextRun)
+	s.Error(err)
+	s.Contains(err.Error(), "run 2 of the continue-as-new chain")
+}
+
 func (s *internalWorkerTestSuite) TestReplayWorkflowHistory_LocalActivity() {
 	taskQueue := "taskQueue1"
 	testEvents := []*historypb.HistoryEvent{
@@ -384,6 +461,51 @@ func (s *internalWorkerTestSuite) TestReplayWorkflowHistory_GetVersion() {
 	require.NoError(s.T(), err)
 }
 
+func (s *internalWorkerTestSuite) TestGetChangeVersions() {
+	history := &historypb.History{Events: createHistoryForGetVersionTests("testReplayWorkflowGetVersion")}
+	changeVersions, err := GetChangeVersions(history, nil)
+	s.NoError(err)
+	s.Equal(map[string]Version{"change_id_A": 3}, changeVersions)
+
+	changeVersions, err = GetChangeVersions(&historypb.History{}, nil)
+	s.NoError(err)
+	s.Empty(changeVersions)
+}
+
+func (s *internalWorkerTestSuite) TestReplayWorkflowHistory_NondeterministicReplayError() {
+	testEvents := createHistoryForGetVersionTests("testReplayWorkflowGetVersion")
+	testEvents[12].GetActivityTaskScheduledEventAttributes().ActivityType.Name = "otherActivity"
+	// The commands of a workflow which completes during the replay are not checked, leave out the last activity result.
+	history := &historypb.History{Events: testEvents[:19]}
+	replayer := NewWorkflowReplayer()
+	replayer.RegisterWorkflow(testReplayWorkflowGetVersion)
+	err := replayer.ReplayWorkflowHistory(getLogger(), history)
+
+	var replayErr *NondeterministicReplayError
+	s.True(errors.As(err, &replayErr))
+	s.Len(replayErr.Differences, 1)
+	s.Equal(int64(13), replayErr.Differences[0].EventID)
+	s.Contains(replayErr.Differences[0].Expected, "activityType=otherActivity")
+	s.Contains(replayErr.Differences[0].Actual, "activityType=testActivity")
+	s.Equal([]VersionMarker{{EventID: 5, ChangeID: "change_id_A", Version: 3}}, replayErr.NearestVersionMarkers)
+	s.Contains(err.Error(), "event 5: change_id_A=3")
+}
+
+func (s *internalWorkerTestSuite) TestDiffWorkflowHistory_ReturnsErrorWithDifferences() {
+	testEvents := createHistoryForGetVersionTests("testReplayWorkflowGetVersion")
+	testEvents[12].GetActivityTaskScheduledEventAttributes().ActivityType.Name = "otherActivity"
+	history := &historypb.History{Events: testEvents[:19]}
+	replayer := NewWorkflowReplayer()
+	replayer.RegisterWorkflow(testReplayWorkflowGetVersion)
+	differences, err := replayer.DiffWorkflowHistory(getLogger(), history)
+
+	s.Len(differences, 1)
+	s.Equal(int64(13), differences[0].EventID)
+	var replayErr *NondeterministicReplayError
+	s.True(errors.As(err, &replayErr))
+	s.Equal(differences, replayErr.Differences)
+}
+
 func testReplayWorkflowLocalAndRemoteActivity(ctx Context) error {
 	version := GetVersion(ctx, "change_id_A", Version(3), Version(3))
 	if version != Version(3) {
@@ -607,6 +729,21 @@ func (s *internalWorkerTestSuite) TestReplayWorkflowHistory_GetVersion_AddNewBef
 	require.NoError(s.T(), err)
 }
 
+func testReplayWorkflowDeprecatePatch(ctx Context) error {
+	DeprecatePatch(ctx, "patch_id")
+	return testReplayWorkflowGetVersion(ctx)
+}
+
+func (s *internalWorkerTestSuite) TestReplayWorkflowHistory_DeprecatePatchWithoutMarker() {
+	testEvents := createHistoryForGetVersionTests("testReplayWorkflowDeprecatePatch")
+	history := &historypb.History{Events: testEvents}
+	logger := getLogger()
+	replayer := NewWorkflowReplayer()
+	replayer.RegisterWorkflow(testReplayWorkflowDeprecatePatch)
+	err := replayer.ReplayWorkflowHistory(logger, history)
+	require.NoError(s.T(), err)
+}
+
 func createHistoryForGetVersionTests(workflowType string) []*historypb.HistoryEvent {
 	taskQueue := "taskQueue1"
 	return []*historypb.HistoryEvent{
@@ -972,7 +1109,8 @@ func testReplayFailedToStartChildWorkflow(ctx Context) error {
 	ctx = WithChildWorkflowOptions(ctx, opts)
 	err := ExecuteChildWorkflow(ctx, "testWorkflow").GetChildWorkflowExecution().Get(ctx, nil)
 	if err != nil {
-		if strings.HasSuffix(err.Error(), "workflow execution already started") {
+		var alreadyStartedErr *ChildWorkflowExecutionAlreadyStartedError
+		if errors.As(err, &alreadyStartedErr) {
 			return nil
 		}
 		return err
@@ -1697,6 +1835,40 @@ func (s *internalWorkerTestSuite) TestCompleteActivityById() {
 	require.NotNil(t, failedRequest)
 }
 
+func (s *internalWorkerTestSuite) TestCompleteActivities() {
+	wfClient := NewServiceClient(s.service, nil, ClientOptions{Namespace: "testNamespace"})
+	var byIDRequests []*workflowservice.RespondActivityTaskCompletedByIdRequest
+	var lock sync.Mutex
+	s.service.EXPECT().RespondActivityTaskCompleted(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.RespondActivityTaskCompletedResponse{}, nil).Times(1)
+	s.service.EXPECT().RespondActivityTaskCompletedById(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.RespondActivityTaskCompletedByIdResponse{}, nil).Times(2).Do(
+		func(ctx context.Context, request *workflowservice.RespondActivityTaskCompletedByIdRequest, opts ...grpc.CallOption) {
+			lock.Lock()
+			defer lock.Unlock()
+			byIDRequests = append(byIDRequests, request)
+		})
+
+	errs := wfClient.CompleteActivities(context.Background(), []ActivityCompletion{
+		{TaskToken: []byte("task-token"), Result: "done"},
+		{WorkflowID: "wid", ActivityID: "aid1", Result: "done"},
+		{Namespace: "otherNamespace", WorkflowID: "wid", ActivityID: "aid2"},
+	})
+	s.Nil(errs)
+	s.Len(byIDRequests, 2)
+	namespaces := map[string]string{}
+	for _, r := range byIDRequests {
+		namespaces[r.GetActivityId()] = r.GetNamespace()
+	}
+	s.Equal(map[string]string{"aid1": "testNamespace", "aid2": "otherNamespace"}, namespaces)
+
+	errs = wfClient.CompleteActivities(context.Background(), []ActivityCompletion{
+		{WorkflowID: "wid"},
+		{TaskToken: []byte("task-token"), Err: ErrActivityResultPending},
+	})
+	s.Len(errs, 2)
+	s.Error(errs[0])
+	s.NoError(errs[1])
+}
+
 func (s *internalWorkerTestSuite) TestCompleteActivityByIDWithContextAwareDataConverter() {
 	dc := NewContextAwareDataConverter(converter.GetDefaultDataConverter())
 	client := NewServiceClient(s.service, nil, ClientOptions{DataConverter: dc})
@@ -2100,6 +2272,79 @@ func TestRegisterVariousWorkflowTypes(t *testing.T) {
 	r.RegisterWorkflow(testWorkflowReturnStructPtrPtr)
 }
 
+func TestSharedRegistry(t *testing.T) {
+	shared := NewSharedRegistry()
+	shared.RegisterWorkflowWithOptions(testWorkflowSample, RegisterWorkflowOptions{Name: "sharedWorkflow"})
+	shared.RegisterActivityWithOptions(testActivityReturnString, RegisterActivityOptions{Name: "sharedActivity"})
+	require.Equal(t, []string{"sharedWorkflow"}, shared.RegisteredWorkflowTypes())
+	require.Equal(t, []string{"sharedActivity"}, shared.RegisteredActivityTypes())
+
+	// Two workers using the shared registry don't see each other's registrations, and can register the same names.
+	r1 := newRegistry()
+	r1.parent = shared.registry
+	r2 := newRegistry()
+	r2.parent = shared.registry
+	r1.RegisterWorkflowWithOptions(testWorkflowNoArgs, RegisterWorkflowOptions{Name: "workerWorkflow"})
+	r2.RegisterWorkflowWithOptions(testWorkflowReturnInt, RegisterWorkflowOptions{Name: "workerWorkflow"})
+	r2.RegisterActivityWithOptions(testActivityNoResult, RegisterActivityOptions{Name: "sharedActivity"})
+
+	require.ElementsMatch(t, []string{"sharedWorkflow", "workerWorkflow"}, r1.getRegisteredWorkflowTypes())
+	require.ElementsMatch(t, []string{"sharedActivity"}, r2.getRegisteredActivityTypes())
+	_, err := r1.getWorkflowDefinition(WorkflowType{Name: "sharedWorkflow"})
+	require.NoError(t, err)
+	fn, ok := r2.getWorkflowFn("workerWorkflow")
+	require.True(t, ok)
+	require.Equal(t, reflect.ValueOf(testWorkflowReturnInt).Pointer(), reflect.ValueOf(fn).Pointer())
+
+	// The registrations of the worker take precedence over the shared ones.
+	a, ok := r1.GetActivity("sharedActivity")
+	require.True(t, ok)
+	require.Equal(t, reflect.ValueOf(testActivityReturnString).Pointer(), reflect.ValueOf(a.GetFunction()).Pointer())
+	a, ok = r2.GetActivity("sharedActivity")
+	require.True(t, ok)
+	require.Equal(t, reflect.ValueOf(testActivityNoResult).Pointer(), reflect.ValueOf(a.GetFunction()).Pointer())
+	require.Len(t, r2.getRegisteredActivities(), 1)
+	_, ok = shared.registry.getWorkflowFn("workerWorkflow")
+	require.False(t, ok)
+}
+
+func TestRegisterAliases(t *testing.T) {
+	r := newRegistry()
+	r.RegisterWorkflowWithOptions(testWorkflowSample, RegisterWorkflowOptions{
+		Name:              "newWorkflow",
+		Aliases:           []string{"oldWorkflow"},
+		DeprecatedAliases: []string{"legacyWorkflow"},
+	})
+	for _, name := range []string{"newWorkflow", "oldWorkflow", "legacyWorkflow"} {
+		_, err := r.getWorkflowDefinition(WorkflowType{Name: name})
+		require.NoError(t, err, name)
+	}
+	require.False(t, r.isDeprecatedWorkflowType("newWorkflow"))
+	require.False(t, r.isDeprecatedWorkflowType("oldWorkflow"))
+	require.True(t, r.isDeprecatedWorkflowType("legacyWorkflow"))
+	fnName, _ := getFunctionName(testWorkflowSample)
+	alias, ok := r.getWorkflowAlias(fnName)
+	require.True(t, ok)
+	require.Equal(t, "newWorkflow", alias)
+	require.Panics(t, func() {
+		r.RegisterWorkflowWithOptions(testWorkflowNoArgs, RegisterWorkflowOptions{Name: "otherWorkflow", Aliases: []string{"oldWorkflow"}})
+	})
+
+	r.RegisterActivityWithOptions(testActivityReturnString, RegisterActivityOptions{
+		Name:              "newActivity",
+		DeprecatedAliases: []string{"oldActivity"},
+	})
+	a, ok := r.GetActivity("oldActivity")
+	require.True(t, ok)
+	require.Equal(t, "oldActivity", a.ActivityType().Name)
+	require.True(t, r.isDeprecatedActivityType("oldActivity"))
+	require.False(t, r.isDeprecatedActivityType("newActivity"))
+	require.ElementsMatch(t, []string{"newActivity", "oldActivity"}, r.getRegisteredActivityTypes())
+	require.Panics(t, func() {
+		r.RegisterActivityWithOptions(&testActivityStruct{}, RegisterActivityOptions{Aliases: []string{"alias"}})
+	})
+}
+
 type testErrorDetails struct {
 	T string
 }
@@ -2428,6 +2673,49 @@ func TestLocalActivityWorkerOnly(t *testing.T) {
 	require.Nil(t, sessionWorker)
 }
 
+func TestWorkflowWorkerAndActivityWorker(t *testing.T) {
+	taskQueue := "worker-options-tq"
+	workflowOnly := NewWorkflowWorker(&WorkflowClient{}, taskQueue, WorkerOptions{EnableSessionWorker: true})
+	require.NotNil(t, workflowOnly.workflowWorker)
+	require.Nil(t, workflowOnly.activityWorker)
+	require.Nil(t, workflowOnly.sessionWorker)
+	require.NotNil(t, workflowOnly.cache)
+	require.Equal(t, defaultSingleKindConcurrentPollRoutineSize,
+		workflowOnly.workflowWorker.executionParameters.MaxConcurrentWorkflowTaskQueuePollers)
+
+	activityOnly := NewActivityWorker(&WorkflowClient{}, taskQueue, WorkerOptions{
+		LocalActivityWorkerOnly: true,
+		StickyWorkflowCacheSize: 10,
+	})
+	require.Nil(t, activityOnly.workflowWorker)
+	require.NotNil(t, activityOnly.activityWorker)
+	require.Nil(t, activityOnly.cache)
+	require.Equal(t, defaultSingleKindConcurrentPollRoutineSize,
+		activityOnly.activityWorker.executionParameters.MaxConcurrentActivityTaskQueuePollers)
+
+	activityOnly = NewActivityWorker(&WorkflowClient{}, taskQueue, WorkerOptions{MaxConcurrentActivityTaskPollers: 7})
+	require.Equal(t, 7, activityOnly.activityWorker.executionParameters.MaxConcurrentActivityTaskQueuePollers)
+}
+
+func TestWorkerIdentityDetails(t *testing.T) {
+	identity := &WorkerIdentity{Host: "node-3", Pod: "orders-7f9c", Build: "v1.2.3"}
+	require.Equal(t, "host=node-3,pod=orders-7f9c,build=v1.2.3", identity.String())
+	require.Equal(t, "pod=orders-7f9c", (&WorkerIdentity{Pod: "orders-7f9c"}).String())
+
+	aggWorker := NewAggregatedWorker(&WorkflowClient{}, "worker-options-tq", WorkerOptions{
+		Identity:        "ignored",
+		IdentityDetails: identity,
+	})
+	require.Equal(t, identity.String(), aggWorker.workflowWorker.executionParameters.Identity)
+	require.Equal(t, identity.String(), aggWorker.activityWorker.executionParameters.Identity)
+
+	aggWorker = NewAggregatedWorker(&WorkflowClient{}, "worker-options-tq", WorkerOptions{
+		Identity:        "worker-1",
+		IdentityDetails: &WorkerIdentity{},
+	})
+	require.Equal(t, "worker-1", aggWorker.workflowWorker.executionParameters.Identity)
+}
+
 func assertWorkerExecutionParamsEqual(t *testing.T, paramsA workerExecutionParameters, paramsB workerExecutionParameters) {
 	require.Equal(t, paramsA.TaskQueue, paramsA.TaskQueue)
 	require.Equal(t, paramsA.Identity, paramsB.Identity)
@@ -31,6 +31,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -38,6 +39,7 @@ import (
 
 	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
 	"go.uber.org/atomic"
 
 	"go.temporal.io/sdk/converter"
@@ -47,6 +49,12 @@ import (
 const (
 	defaultSignalChannelSize = 100000 // really large buffering size(100K)
 
+	defaultFinalizerTimeout = time.Minute
+
+	// carriedOverSignalsHeaderKey is the header field of a new run holding the signals carried over from the
+	// previous run with ContinueAsNewOptions.CarryOverSignals.
+	carriedOverSignalsHeaderKey = "temporal-carried-over-signals"
+
 	panicIllegalAccessCoroutinueState = "getState: illegal access from outside of workflow context"
 )
 
@@ -135,6 +143,9 @@ type (
 		sendValue  *interface{}    // value to send to the channel. Used only for send case.
 		future     asyncFuture     // Used for future case
 		futureFunc *func(f Future) // function to call when Future is ready
+
+		priority int    // cases with higher priority are checked first
+		label    string // human readable name of the case, shown in stack traces
 	}
 
 	// Implements Selector interface
@@ -192,6 +203,13 @@ type (
 		ParentClosePolicy        enumspb.ParentClosePolicy
 		signalChannels           map[string]Channel
 		queryHandlers            map[string]func(*commonpb.Payloads) (*commonpb.Payloads, error)
+		finalizers               *workflowFinalizers
+	}
+
+	// workflowFinalizers are shared by the copies of the workflow options of a workflow.
+	workflowFinalizers struct {
+		finalizers []func(ctx Context)
+		timeout    time.Duration
 	}
 
 	// ExecuteWorkflowParams parameters of the workflow invocation
@@ -216,6 +234,12 @@ type (
 		executionFuture   *futureImpl // for child workflow execution future
 	}
 
+	externalWorkflowHandleImpl struct {
+		workflowID string
+		runID      string
+		namespace  string
+	}
+
 	asyncFuture interface {
 		Future
 		// Used by selectorImpl
@@ -292,6 +316,11 @@ type workflowEnvironmentInterceptor struct {
 	inboundInterceptor  WorkflowInboundCallsInterceptor
 	fn                  interface{}
 	outboundInterceptor WorkflowOutboundCallsInterceptor
+	values              map[string]converter.EncodedValue // set with SetValue
+	observables         map[string]func() interface{}     // registered with RegisterObservableVariable
+	operations          *workflowOperations               // called with ExecuteOperation and set with SetOperationHandler
+	callbacks           *workflowCallbacks                // created with NewCallback
+	deadlines           *workflowDeadlines                // set with SetDeadline or carried over from the previous run
 }
 
 func (wc *workflowEnvironmentInterceptor) Go(ctx Context, name string, f func(ctx Context)) Context {
@@ -489,6 +518,9 @@ func (d *syncWorkflowDefinition) Execute(env WorkflowEnvironment, header *common
 
 			// TODO: @shreyassrivatsan - add workflow trace span here
 			r.workflowResult, r.error = d.workflow.Execute(d.rootCtx, input)
+			if r.error != nil {
+				runFinalizers(d.rootCtx, r.error)
+			}
 			rpp := getWorkflowResultPointerPointer(ctx)
 			*rpp = r
 		})
@@ -511,7 +543,7 @@ func (d *syncWorkflowDefinition) Execute(env WorkflowEnvironment, header *common
 		d.cancel()
 	})
 
-	getWorkflowEnvironment(d.rootCtx).RegisterSignalHandler(func(name string, input *commonpb.Payloads) {
+	signalHandler := func(name string, input *commonpb.Payloads) {
 		eo := getWorkflowEnvOptions(d.rootCtx)
 		// Notify interceptor, note that because channel send operation below is non-blocking, we can not pass a full closure
 		// that would encapsulate signal processing. Hence this call is just a "forked" notification that signal has been received.
@@ -522,22 +554,109 @@ func (d *syncWorkflowDefinition) Execute(env WorkflowEnvironment, header *common
 		if !ok {
 			panic(fmt.Sprintf("Exceeded channel buffer size for signal: %v", name))
 		}
-	})
+	}
+	getWorkflowEnvironment(d.rootCtx).RegisterSignalHandler(signalHandler)
+
+	// Deliver the signals carried over from the previous run before the signals of this run.
+	if payload, ok := header.GetFields()[carriedOverSignalsHeaderKey]; ok {
+		signals, err := decodeCarriedOverSignals(payload)
+		if err != nil {
+			panic(err)
+		}
+		for _, signal := range signals {
+			signalHandler(signal.Name, signal.Input)
+		}
+	}
+
+	// Restore the deadlines set in the previous runs, their timers are armed by GetDeadline.
+	if payload, ok := header.GetFields()[durableDeadlinesHeaderKey]; ok {
+		deadlines, err := decodeDurableDeadlines(payload)
+		if err != nil {
+			panic(err)
+		}
+		envInterceptor.deadlines = deadlines
+	}
 
 	getWorkflowEnvironment(d.rootCtx).RegisterQueryHandler(func(queryType string, queryArgs *commonpb.Payloads) (*commonpb.Payloads, error) {
 		eo := getWorkflowEnvOptions(d.rootCtx)
+		switch queryType {
+		case QueryTypeQueryTypes:
+			return encodeArg(getWorkflowEnvironment(d.rootCtx).GetDataConverter(), eo.getQueryTypes())
+		case QueryTypeWorkflowMetadata:
+			return encodeArg(getWorkflowEnvironment(d.rootCtx).GetDataConverter(), d.getWorkflowMetadata())
+		case QueryTypeWorkflowState:
+			return encodeArg(getWorkflowEnvironment(d.rootCtx).GetDataConverter(), d.getWorkflowState())
+		}
 		handler, ok := eo.queryHandlers[queryType]
 		if !ok {
-			keys := []string{QueryTypeStackTrace, QueryTypeOpenSessions}
-			for k := range eo.queryHandlers {
-				keys = append(keys, k)
-			}
-			return nil, fmt.Errorf("unknown queryType %v. KnownQueryTypes=%v", queryType, keys)
+			return nil, fmt.Errorf("unknown queryType %v. KnownQueryTypes=%v", queryType, eo.getQueryTypes())
 		}
 		return envInterceptor.inboundInterceptor.HandleQuery(d.rootCtx, queryType, queryArgs, handler)
 	})
 }
 
+// runFinalizers runs the finalizers registered with Defer, in reverse order of registration, when the workflow fails
+// or is canceled. The finalizers run in a context disconnected from the workflow cancellation, which is canceled when
+// the finalizer timeout expires.
+func runFinalizers(ctx Context, workflowErr error) {
+	finalizers := getWorkflowEnvOptions(ctx).finalizers
+	var continueAsNewErr *ContinueAsNewError
+	if len(finalizers.finalizers) == 0 || errors.As(workflowErr, &continueAsNewErr) {
+		return
+	}
+	finalizerCtx, cancel := NewDisconnectedContext(ctx)
+	timerCtx, cancelTimer := WithCancel(finalizerCtx)
+	timeout := finalizers.timeout
+	if timeout == 0 {
+		timeout = defaultFinalizerTimeout
+	}
+	Go(timerCtx, func(ctx Context) {
+		if err := NewTimer(ctx, timeout).Get(ctx, nil); err == nil {
+			GetLogger(ctx).Warn("Workflow finalizers timed out.", "Timeout", timeout)
+			cancel()
+		}
+	})
+	for i := len(finalizers.finalizers) - 1; i >= 0; i-- {
+		finalizers.finalizers[i](finalizerCtx)
+	}
+	cancelTimer()
+}
+
+func (d *syncWorkflowDefinition) getWorkflowMetadata() *WorkflowMetadata {
+	env := getWorkflowEnvironment(d.rootCtx)
+	eo := getWorkflowEnvOptions(d.rootCtx)
+	signalChannels := make([]string, 0, len(eo.signalChannels))
+	for name := range eo.signalChannels {
+		signalChannels = append(signalChannels, name)
+	}
+	sort.Strings(signalChannels)
+	return &WorkflowMetadata{
+		WorkflowType:   env.WorkflowInfo().WorkflowType.Name,
+		QueryTypes:     eo.getQueryTypes(),
+		SignalChannels: signalChannels,
+		ChangeVersions: env.ChangeVersions(),
+	}
+}
+
+func (d *syncWorkflowDefinition) getWorkflowState() *WorkflowState {
+	wc := getWorkflowEnvironmentInterceptor(d.rootCtx)
+	state := &WorkflowState{
+		Values:    make(map[string]interface{}, len(wc.values)),
+		Variables: make(map[string]interface{}, len(wc.observables)),
+	}
+	for key, encodedValue := range wc.values {
+		var value interface{}
+		if err := encodedValue.Get(&value); err != nil {
+			value = fmt.Sprintf("unable to decode value: %v", err)
+		}
+		state.Values[key] = value
+	}
+	for name, get := range wc.observables {
+		state.Variables[name] = get()
+	}
+	return state
+}
+
 func (d *syncWorkflowDefinition) OnWorkflowTaskStarted(deadlockDetectionTimeout time.Duration) {
 	executeDispatcher(d.rootCtx, d.dispatcher, deadlockDetectionTimeout)
 }
@@ -578,9 +697,15 @@ func executeDispatcher(ctx Context, dispatcher dispatcher, timeout time.Duration
 		return
 	}
 
-	us := getWorkflowEnvOptions(ctx).getUnhandledSignals()
+	options := getWorkflowEnvOptions(ctx)
+	us := options.getUnhandledSignals()
 	if len(us) > 0 {
-		env.GetLogger().Info("Workflow has unhandled signals", "SignalNames", us)
+		signalCount := 0
+		for _, signalName := range us {
+			signalCount += options.signalChannels[signalName].Len()
+		}
+		env.GetLogger().Warn("Workflow has unhandled signals", "SignalNames", us, "SignalCount", signalCount)
+		env.GetMetricsScope().Counter(metrics.UnhandledSignalsCounter).Inc(int64(signalCount))
 	}
 
 	env.Complete(rp.workflowResult, rp.error)
@@ -659,6 +784,14 @@ func (c *channelImpl) ReceiveAsync(valuePtr interface{}) (ok bool) {
 	return ok
 }
 
+func (c *channelImpl) Len() int {
+	result := len(c.buffer)
+	if c.recValue != nil {
+		result++
+	}
+	return result
+}
+
 func (c *channelImpl) ReceiveAsyncWithMoreFlag(valuePtr interface{}) (ok bool, more bool) {
 	for {
 		v, ok, more := c.receiveAsyncImpl(nil)
@@ -804,6 +937,13 @@ func (c *channelImpl) Close() {
 
 // Takes a value and assigns that 'to' value. logs a metric if it is unable to deserialize
 func (c *channelImpl) assignValue(from interface{}, to interface{}) error {
+	// Signals can be received as an EncodedValue to defer decoding to the caller, see DrainSignalChannel.
+	if encodedValuePtr, ok := to.(*converter.EncodedValue); ok {
+		if payloads, ok := from.(*commonpb.Payloads); ok {
+			*encodedValuePtr = newEncodedValue(payloads, c.dataConverter)
+			return nil
+		}
+	}
 	err := decodeAndAssignValue(c.dataConverter, from, to)
 	// add to metrics
 	if err != nil {
@@ -1041,24 +1181,62 @@ func (d *dispatcherImpl) StackTrace() string {
 }
 
 func (s *selectorImpl) AddReceive(c ReceiveChannel, f func(c ReceiveChannel, more bool)) Selector {
-	s.cases = append(s.cases, &selectCase{channel: c.(*channelImpl), receiveFunc: &f})
+	return s.AddReceiveWithOptions(c, SelectorBranchOptions{}, f)
+}
+
+func (s *selectorImpl) AddReceiveWithPriority(c ReceiveChannel, priority int, f func(c ReceiveChannel, more bool)) Selector {
+	return s.AddReceiveWithOptions(c, SelectorBranchOptions{Priority: priority}, f)
+}
+
+func (s *selectorImpl) AddReceiveWithOptions(c ReceiveChannel, options SelectorBranchOptions, f func(c ReceiveChannel, more bool)) Selector {
+	s.addCase(&selectCase{channel: c.(*channelImpl), receiveFunc: &f, priority: options.Priority, label: options.Label})
 	return s
 }
 
 func (s *selectorImpl) AddSend(c SendChannel, v interface{}, f func()) Selector {
-	s.cases = append(s.cases, &selectCase{channel: c.(*channelImpl), sendFunc: &f, sendValue: &v})
+	s.addCase(&selectCase{channel: c.(*channelImpl), sendFunc: &f, sendValue: &v})
 	return s
 }
 
 func (s *selectorImpl) AddFuture(future Future, f func(future Future)) Selector {
+	return s.AddFutureWithOptions(future, SelectorBranchOptions{}, f)
+}
+
+func (s *selectorImpl) AddFutureWithOptions(future Future, options SelectorBranchOptions, f func(future Future)) Selector {
 	asyncF, ok := future.(asyncFuture)
 	if !ok {
 		panic("cannot chain Future that wasn't created with workflow.NewFuture")
 	}
-	s.cases = append(s.cases, &selectCase{future: asyncF, futureFunc: &f})
+	s.addCase(&selectCase{future: asyncF, futureFunc: &f, priority: options.Priority, label: options.Label})
 	return s
 }
 
+// addCase inserts the case after all the cases with the same or higher priority.
+func (s *selectorImpl) addCase(c *selectCase) {
+	i := len(s.cases)
+	for i > 0 && s.cases[i-1].priority < c.priority {
+		i--
+	}
+	s.cases = append(s.cases, nil)
+	copy(s.cases[i+1:], s.cases[i:])
+	s.cases[i] = c
+}
+
+// blockedStatus returns the status of a coroutine blocked on Select, which includes the labels of the pending
+// branches.
+func (s *selectorImpl) blockedStatus() string {
+	var labels []string
+	for _, pair := range s.cases {
+		if pair.label != "" && (pair.receiveFunc != nil || pair.sendFunc != nil || pair.futureFunc != nil) {
+			labels = append(labels, pair.label)
+		}
+	}
+	if len(labels) == 0 {
+		return fmt.Sprintf("blocked on %s.Select", s.name)
+	}
+	return fmt.Sprintf("blocked on %s.Select(%s)", s.name, strings.Join(labels, ", "))
+}
+
 func (s *selectorImpl) AddDefault(f func()) {
 	s.defaultFunc = &f
 }
@@ -1196,7 +1374,7 @@ func (s *selectorImpl) Select(ctx Context) {
 			state.unblocked()
 			return
 		}
-		state.yield(fmt.Sprintf("blocked on %s.Select", s.name))
+		state.yield(s.blockedStatus())
 	}
 }
 
@@ -1241,6 +1419,7 @@ func setWorkflowEnvOptionsIfNotExist(ctx Context) Context {
 	} else {
 		newOptions.signalChannels = make(map[string]Channel)
 		newOptions.queryHandlers = make(map[string]func(*commonpb.Payloads) (*commonpb.Payloads, error))
+		newOptions.finalizers = &workflowFinalizers{}
 	}
 	if newOptions.DataConverter == nil {
 		newOptions.DataConverter = converter.GetDefaultDataConverter()
@@ -1293,7 +1472,19 @@ func (w *WorkflowOptions) getSignalChannel(ctx Context, signalName string) Recei
 	return ch
 }
 
+// getQueryTypes returns the sorted types of the built-in and the registered queries.
+func (w *WorkflowOptions) getQueryTypes() []string {
+	queryTypes := []string{QueryTypeStackTrace, QueryTypeOpenSessions, QueryTypeQueryTypes, QueryTypeWorkflowMetadata,
+		QueryTypeWorkflowState}
+	for k := range w.queryHandlers {
+		queryTypes = append(queryTypes, k)
+	}
+	sort.Strings(queryTypes)
+	return queryTypes
+}
+
 // getUnhandledSignals checks if there are any signal channels that have data to be consumed.
+// The signal names are sorted, so that the result is deterministic.
 func (w *WorkflowOptions) getUnhandledSignals() []string {
 	var unhandledSignals []string
 	for k, c := range w.signalChannels {
@@ -1304,9 +1495,61 @@ func (w *WorkflowOptions) getUnhandledSignals() []string {
 			ch.recValue = &v
 		}
 	}
+	sort.Strings(unhandledSignals)
 	return unhandledSignals
 }
 
+// drainUnhandledSignals receives all the signals buffered in the signal channels, ordered by signal name and then by
+// the order they were received in.
+func (w *WorkflowOptions) drainUnhandledSignals() []CarriedOverSignal {
+	var signals []CarriedOverSignal
+	for _, name := range w.getUnhandledSignals() {
+		ch := w.signalChannels[name].(*channelImpl)
+		for {
+			v, ok, _ := ch.receiveAsyncImpl(nil)
+			if !ok {
+				break
+			}
+			input, _ := v.(*commonpb.Payloads)
+			signals = append(signals, CarriedOverSignal{Name: name, Input: input})
+		}
+	}
+	return signals
+}
+
+// encodeCarriedOverSignals encodes the signals into a header payload, as the WorkflowExecutionSignaled events they
+// would have been recorded as.
+func encodeCarriedOverSignals(signals []CarriedOverSignal) (*commonpb.Payload, error) {
+	history := &historypb.History{}
+	for _, signal := range signals {
+		history.Events = append(history.Events, &historypb.HistoryEvent{
+			EventType: enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED,
+			Attributes: &historypb.HistoryEvent_WorkflowExecutionSignaledEventAttributes{WorkflowExecutionSignaledEventAttributes: &historypb.WorkflowExecutionSignaledEventAttributes{
+				SignalName: signal.Name,
+				Input:      signal.Input,
+			}},
+		})
+	}
+	payload, err := converter.NewProtoPayloadConverter().ToPayload(history)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode carried over signals: %w", err)
+	}
+	return payload, nil
+}
+
+func decodeCarriedOverSignals(payload *commonpb.Payload) ([]CarriedOverSignal, error) {
+	var history historypb.History
+	if err := converter.NewProtoPayloadConverter().FromPayload(payload, &history); err != nil {
+		return nil, fmt.Errorf("unable to decode carried over signals: %w", err)
+	}
+	signals := make([]CarriedOverSignal, 0, len(history.Events))
+	for _, event := range history.Events {
+		attributes := event.GetWorkflowExecutionSignaledEventAttributes()
+		signals = append(signals, CarriedOverSignal{Name: attributes.GetSignalName(), Input: attributes.GetInput()})
+	}
+	return signals, nil
+}
+
 func (d *decodeFutureImpl) Get(ctx Context, valuePtr interface{}) error {
 	more := d.futureImpl.channel.Receive(ctx, nil)
 	if more {
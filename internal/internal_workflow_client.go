@@ -30,6 +30,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sync"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -38,13 +39,18 @@ import (
 	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
 	historypb "go.temporal.io/api/history/v1"
+	namespacepb "go.temporal.io/api/namespace/v1"
 	querypb "go.temporal.io/api/query/v1"
 	"go.temporal.io/api/serviceerror"
 	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
 	"go.temporal.io/api/workflowservice/v1"
+	"go.uber.org/atomic"
 
 	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/internal/common"
 	"go.temporal.io/sdk/internal/common/metrics"
+	"go.temporal.io/sdk/internal/common/retry"
 	"go.temporal.io/sdk/internal/common/serializer"
 	"go.temporal.io/sdk/internal/common/util"
 	"go.temporal.io/sdk/log"
@@ -56,6 +62,12 @@ var _ NamespaceClient = (*namespaceClient)(nil)
 
 const (
 	defaultGetHistoryTimeout = 65 * time.Second
+
+	// maxConcurrentActivityCompletions is the maximum number of completions CompleteActivities reports concurrently.
+	maxConcurrentActivityCompletions = 10
+
+	// defaultArchivedWorkflowPageSize is the page size of the ListArchivedWorkflow calls of ClientOptions.ArchivalFallback.
+	defaultArchivedWorkflowPageSize = 100
 )
 
 var (
@@ -75,6 +87,18 @@ type (
 		dataConverter      converter.DataConverter
 		contextPropagators []ContextPropagator
 		tracer             opentracing.Tracer
+		interceptor        ClientOutboundInterceptor
+		archivalFallback   bool
+		backoffStrategy    retry.BackoffStrategy
+		// searchAttributeKeys caches the search attributes of the cluster, nil unless
+		// ClientOptions.ValidateSearchAttributes is set.
+		searchAttributeKeys *searchAttributeKeys
+	}
+
+	// searchAttributeKeys are the search attributes registered on the cluster, with their types.
+	searchAttributeKeys struct {
+		sync.Mutex
+		keys map[string]enumspb.IndexedValueType
 	}
 
 	// namespaceClient is the client for managing namespaces.
@@ -162,6 +186,13 @@ func (wc *WorkflowClient) StartWorkflow(
 	workflowFunc interface{},
 	args ...interface{},
 ) (*WorkflowExecution, error) {
+	if err := ValidateStartWorkflowOptions(options); err != nil {
+		return nil, err
+	}
+	if err := wc.validateSearchAttributes(ctx, options.SearchAttributes); err != nil {
+		return nil, err
+	}
+
 	workflowID := options.ID
 	if len(workflowID) == 0 {
 		workflowID = uuid.NewRandom().String()
@@ -223,12 +254,13 @@ func (wc *WorkflowClient) StartWorkflow(
 
 	var response *workflowservice.StartWorkflowExecutionResponse
 
-	grpcCtx, cancel := newGRPCContext(ctx, grpcMetricsScope(
-		metrics.GetMetricsScopeForRPC(wc.metricsScope, workflowType.Name, metrics.NoneTagValue, options.TaskQueue)),
-		defaultGrpcRetryParameters(ctx))
+	rpcScope := metrics.GetMetricsScopeForRPC(wc.metricsScope, workflowType.Name, metrics.NoneTagValue, options.TaskQueue)
+	grpcCtx, cancel := newGRPCContext(ctx, grpcMetricsScope(rpcScope), defaultGrpcRetryParameters(ctx))
 	defer cancel()
 
+	startTime := time.Now()
 	response, err = wc.workflowService.StartWorkflowExecution(grpcCtx, startRequest)
+	recordWorkflowStart(rpcScope, startTime, err)
 
 	if err != nil {
 		return nil, err
@@ -240,6 +272,20 @@ func (wc *WorkflowClient) StartWorkflow(
 	return executionInfo, nil
 }
 
+// newWorkflowExecutionAlreadyStartedError describes the existing run to return its start time with the error. The
+// error is returned without the start time when the existing run can't be described.
+func (wc *WorkflowClient) newWorkflowExecutionAlreadyStartedError(ctx context.Context, workflowID string, err *serviceerror.WorkflowExecutionAlreadyStarted) error {
+	alreadyStartedErr := &WorkflowExecutionAlreadyStartedError{
+		workflowID: workflowID,
+		runID:      err.RunId,
+		cause:      err,
+	}
+	if response, describeErr := wc.DescribeWorkflowExecution(ctx, workflowID, err.RunId); describeErr == nil {
+		alreadyStartedErr.startTime = common.TimeValue(response.GetWorkflowExecutionInfo().GetStartTime())
+	}
+	return alreadyStartedErr
+}
+
 // ExecuteWorkflow starts a workflow execution and returns a WorkflowRun that will allow you to wait until this workflow
 // reaches the end state, such as workflow finished successfully or timeout.
 // The user can use this to start using a functor like below and get the workflow execution result, as EncodedValue
@@ -251,6 +297,10 @@ func (wc *WorkflowClient) StartWorkflow(
 // subjected to change in the future.
 // NOTE: the context.Context should have a fairly large timeout, since workflow execution may take a while to be finished
 func (wc *WorkflowClient) ExecuteWorkflow(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (WorkflowRun, error) {
+	return wc.interceptor.ExecuteWorkflow(ctx, options, workflow, args...)
+}
+
+func (wc *WorkflowClient) executeWorkflow(ctx context.Context, options StartWorkflowOptions, workflow interface{}, args ...interface{}) (WorkflowRun, error) {
 	// start the workflow execution
 	var runID string
 	var workflowID string
@@ -258,7 +308,7 @@ func (wc *WorkflowClient) ExecuteWorkflow(ctx context.Context, options StartWork
 	if err != nil {
 		if e, ok := err.(*serviceerror.WorkflowExecutionAlreadyStarted); ok {
 			if options.WorkflowExecutionErrorWhenAlreadyStarted {
-				return nil, err
+				return nil, wc.newWorkflowExecutionAlreadyStartedError(ctx, options.ID, e)
 			}
 			runID = e.RunId
 			workflowID = options.ID
@@ -332,6 +382,10 @@ func (wc *WorkflowClient) GetWorkflow(ctx context.Context, workflowID string, ru
 
 // SignalWorkflow signals a workflow in execution.
 func (wc *WorkflowClient) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	return wc.interceptor.SignalWorkflow(ctx, workflowID, runID, signalName, arg)
+}
+
+func (wc *WorkflowClient) signalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
 	dataConverter := WithContext(ctx, wc.dataConverter)
 	input, err := encodeArg(dataConverter, arg)
 	if err != nil {
@@ -359,6 +413,18 @@ func (wc *WorkflowClient) SignalWorkflow(ctx context.Context, workflowID string,
 // If the workflow is not running or not found, it starts the workflow and then sends the signal in transaction.
 func (wc *WorkflowClient) SignalWithStartWorkflow(ctx context.Context, workflowID string, signalName string, signalArg interface{},
 	options StartWorkflowOptions, workflowFunc interface{}, workflowArgs ...interface{}) (WorkflowRun, error) {
+	return wc.interceptor.SignalWithStartWorkflow(ctx, workflowID, signalName, signalArg, options, workflowFunc, workflowArgs...)
+}
+
+func (wc *WorkflowClient) signalWithStartWorkflow(ctx context.Context, workflowID string, signalName string, signalArg interface{},
+	options StartWorkflowOptions, workflowFunc interface{}, workflowArgs ...interface{}) (WorkflowRun, error) {
+
+	if err := ValidateStartWorkflowOptions(options); err != nil {
+		return nil, err
+	}
+	if err := wc.validateSearchAttributes(ctx, options.SearchAttributes); err != nil {
+		return nil, err
+	}
 
 	dataConverter := WithContext(ctx, wc.dataConverter)
 	signalInput, err := encodeArg(dataConverter, signalArg)
@@ -421,16 +487,21 @@ func (wc *WorkflowClient) SignalWithStartWorkflow(ctx context.Context, workflowI
 	var response *workflowservice.SignalWithStartWorkflowExecutionResponse
 
 	// Start creating workflow request.
-	grpcCtx, cancel := newGRPCContext(ctx, defaultGrpcRetryParameters(ctx))
+	rpcScope := metrics.GetMetricsScopeForRPC(wc.metricsScope, workflowType.Name, metrics.NoneTagValue, options.TaskQueue)
+	grpcCtx, cancel := newGRPCContext(ctx, grpcMetricsScope(rpcScope), defaultGrpcRetryParameters(ctx))
 	defer cancel()
 
+	startTime := time.Now()
 	response, err = wc.workflowService.SignalWithStartWorkflowExecution(grpcCtx, signalWithStartRequest)
+	recordWorkflowStart(rpcScope, startTime, err)
 	if err != nil {
+		if e, ok := err.(*serviceerror.WorkflowExecutionAlreadyStarted); ok {
+			return nil, wc.newWorkflowExecutionAlreadyStartedError(ctx, workflowID, e)
+		}
 		return nil, err
 	}
 
 	iterFn := func(fnCtx context.Context, fnRunID string) HistoryEventIterator {
-		rpcScope := metrics.GetMetricsScopeForRPC(wc.metricsScope, workflowType.Name, metrics.NoneTagValue, options.TaskQueue)
 		return wc.getWorkflowHistory(fnCtx, workflowID, fnRunID, true, enumspb.HISTORY_EVENT_FILTER_TYPE_CLOSE_EVENT, rpcScope)
 	}
 
@@ -446,10 +517,23 @@ func (wc *WorkflowClient) SignalWithStartWorkflow(ctx context.Context, workflowI
 	}, nil
 }
 
+// recordWorkflowStart records the latency of a workflow start request acknowledged by the server or its failure.
+func recordWorkflowStart(scope tally.Scope, startTime time.Time, err error) {
+	if err != nil {
+		scope.Counter(metrics.WorkflowStartFailedCounter).Inc(1)
+		return
+	}
+	scope.Timer(metrics.WorkflowStartLatency).Record(time.Since(startTime))
+}
+
 // CancelWorkflow cancels a workflow in execution.  It allows workflow to properly clean up and gracefully close.
 // workflowID is required, other parameters are optional.
 // If runID is omit, it will terminate currently running workflow (if there is one) based on the workflowID.
 func (wc *WorkflowClient) CancelWorkflow(ctx context.Context, workflowID string, runID string) error {
+	return wc.interceptor.CancelWorkflow(ctx, workflowID, runID)
+}
+
+func (wc *WorkflowClient) cancelWorkflow(ctx context.Context, workflowID string, runID string) error {
 	request := &workflowservice.RequestCancelWorkflowExecutionRequest{
 		Namespace: wc.namespace,
 		WorkflowExecution: &commonpb.WorkflowExecution{
@@ -468,6 +552,10 @@ func (wc *WorkflowClient) CancelWorkflow(ctx context.Context, workflowID string,
 // workflowID is required, other parameters are optional.
 // If runID is omit, it will terminate currently running workflow (if there is one) based on the workflowID.
 func (wc *WorkflowClient) TerminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details ...interface{}) error {
+	return wc.interceptor.TerminateWorkflow(ctx, workflowID, runID, reason, details...)
+}
+
+func (wc *WorkflowClient) terminateWorkflow(ctx context.Context, workflowID string, runID string, reason string, details ...interface{}) error {
 	datailsPayload, err := wc.dataConverter.ToPayloads(details...)
 	if err != nil {
 		return err
@@ -529,6 +617,15 @@ func (wc *WorkflowClient) getWorkflowHistory(
 		for {
 			response, err = wc.getWorkflowExecutionHistory(ctx, rpcMetricsScope, isLongPoll, request, filterType)
 			if err != nil {
+				var notFound *serviceerror.NotFound
+				if wc.archivalFallback && !isLongPoll && runID == "" && errors.As(err, &notFound) {
+					// The server only reads the archived history of a run with its run ID.
+					if info, archivedErr := wc.getArchivedWorkflowExecution(ctx, workflowID, ""); archivedErr == nil {
+						runID = info.GetExecution().GetRunId()
+						request.Execution.RunId = runID
+						continue Loop
+					}
+				}
 				return nil, err
 			}
 			if isLongPoll && len(response.History.Events) == 0 && len(response.NextPageToken) != 0 {
@@ -616,6 +713,45 @@ func (wc *WorkflowClient) CompleteActivityByID(ctx context.Context, namespace, w
 	return reportActivityCompleteByID(ctx, wc.workflowService, request, wc.metricsScope)
 }
 
+// CompleteActivities reports completion of many activities at once. Completions are reported concurrently,
+// the returned slice holds the error of each completion and is nil when all of them succeeded.
+func (wc *WorkflowClient) CompleteActivities(ctx context.Context, completions []ActivityCompletion) []error {
+	errs := make([]error, len(completions))
+	failed := atomic.NewBool(false)
+	sem := make(chan struct{}, maxConcurrentActivityCompletions)
+	var wg sync.WaitGroup
+	for i := range completions {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if errs[i] = wc.completeActivity(ctx, completions[i]); errs[i] != nil {
+				failed.Store(true)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if !failed.Load() {
+		return nil
+	}
+	return errs
+}
+
+func (wc *WorkflowClient) completeActivity(ctx context.Context, completion ActivityCompletion) error {
+	if completion.TaskToken != nil {
+		return wc.CompleteActivity(ctx, completion.TaskToken, completion.Result, completion.Err)
+	}
+	namespace := completion.Namespace
+	if namespace == "" {
+		namespace = wc.namespace
+	}
+	return wc.CompleteActivityByID(ctx, namespace, completion.WorkflowID, completion.RunID, completion.ActivityID,
+		completion.Result, completion.Err)
+}
+
 // RecordActivityHeartbeat records heartbeat for an activity.
 func (wc *WorkflowClient) RecordActivityHeartbeat(ctx context.Context, taskToken []byte, details ...interface{}) error {
 	dataConverter := WithContext(ctx, wc.dataConverter)
@@ -752,6 +888,32 @@ func (wc *WorkflowClient) GetSearchAttributes(ctx context.Context) (*workflowser
 	return response, nil
 }
 
+// validateSearchAttributes validates the search attributes of a workflow to start against the search attributes of the
+// cluster when ClientOptions.ValidateSearchAttributes is set.
+func (wc *WorkflowClient) validateSearchAttributes(ctx context.Context, attributes map[string]interface{}) error {
+	if wc.searchAttributeKeys == nil || len(attributes) == 0 {
+		return nil
+	}
+	wc.searchAttributeKeys.Lock()
+	defer wc.searchAttributeKeys.Unlock()
+
+	refresh := wc.searchAttributeKeys.keys == nil
+	for name := range attributes {
+		if _, ok := wc.searchAttributeKeys.keys[name]; !ok {
+			refresh = true
+			break
+		}
+	}
+	if refresh {
+		response, err := wc.GetSearchAttributes(ctx)
+		if err != nil {
+			return err
+		}
+		wc.searchAttributeKeys.keys = response.GetKeys()
+	}
+	return ValidateSearchAttributes(attributes, wc.searchAttributeKeys.keys)
+}
+
 // DescribeWorkflowExecution returns information about the specified workflow execution.
 // The errors it can return:
 //  - BadRequestError
@@ -774,6 +936,109 @@ func (wc *WorkflowClient) DescribeWorkflowExecution(ctx context.Context, workflo
 	return response, nil
 }
 
+// DescribeWorkflow is DescribeWorkflowExecution with the pending activities and child workflows decoded.
+// The errors it can return:
+//  - BadRequestError
+//  - InternalServiceError
+//  - EntityNotExistError
+func (wc *WorkflowClient) DescribeWorkflow(ctx context.Context, workflowID, runID string) (*WorkflowExecutionDescription, error) {
+	response, err := wc.DescribeWorkflowExecution(ctx, workflowID, runID)
+	var notFound *serviceerror.NotFound
+	if wc.archivalFallback && errors.As(err, &notFound) {
+		info, archivedErr := wc.getArchivedWorkflowExecution(ctx, workflowID, runID)
+		if archivedErr != nil {
+			return nil, err
+		}
+		description := newWorkflowExecutionDescription(
+			&workflowservice.DescribeWorkflowExecutionResponse{WorkflowExecutionInfo: info}, wc.dataConverter)
+		description.Archived = true
+		return description, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newWorkflowExecutionDescription(response, wc.dataConverter), nil
+}
+
+// getArchivedWorkflowExecution returns the archived visibility record of the workflow run, or of the most recently
+// closed run of the workflow if runID is empty.
+func (wc *WorkflowClient) getArchivedWorkflowExecution(ctx context.Context, workflowID, runID string) (*workflowpb.WorkflowExecutionInfo, error) {
+	query := "WorkflowId = " + quoteVisibilityQueryValue(workflowID)
+	if runID != "" {
+		query += " and RunId = " + quoteVisibilityQueryValue(runID)
+	}
+	var latest *workflowpb.WorkflowExecutionInfo
+	var nextPageToken []byte
+	for {
+		response, err := wc.ListArchivedWorkflow(ctx, &workflowservice.ListArchivedWorkflowExecutionsRequest{
+			PageSize:      defaultArchivedWorkflowPageSize,
+			NextPageToken: nextPageToken,
+			Query:         query,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range response.GetExecutions() {
+			if latest == nil || common.TimeValue(info.GetCloseTime()).After(common.TimeValue(latest.GetCloseTime())) {
+				latest = info
+			}
+		}
+		nextPageToken = response.GetNextPageToken()
+		if len(nextPageToken) == 0 {
+			break
+		}
+	}
+	if latest == nil {
+		return nil, serviceerror.NewNotFound(fmt.Sprintf("archived workflow execution not found: %v", workflowID))
+	}
+	return latest, nil
+}
+
+func newWorkflowExecutionDescription(response *workflowservice.DescribeWorkflowExecutionResponse, dc converter.DataConverter) *WorkflowExecutionDescription {
+	info := response.GetWorkflowExecutionInfo()
+	description := &WorkflowExecutionDescription{
+		WorkflowID:    info.GetExecution().GetWorkflowId(),
+		RunID:         info.GetExecution().GetRunId(),
+		WorkflowType:  info.GetType().GetName(),
+		Status:        info.GetStatus(),
+		StartTime:     common.TimeValue(info.GetStartTime()),
+		CloseTime:     common.TimeValue(info.GetCloseTime()),
+		HistoryLength: info.GetHistoryLength(),
+		Response:      response,
+	}
+	for _, activity := range response.GetPendingActivities() {
+		pendingActivity := PendingActivityDescription{
+			ActivityID:         activity.GetActivityId(),
+			ActivityType:       activity.GetActivityType().GetName(),
+			State:              activity.GetState(),
+			Attempt:            activity.GetAttempt(),
+			MaximumAttempts:    activity.GetMaximumAttempts(),
+			ScheduledTime:      common.TimeValue(activity.GetScheduledTime()),
+			LastStartedTime:    common.TimeValue(activity.GetLastStartedTime()),
+			LastHeartbeatTime:  common.TimeValue(activity.GetLastHeartbeatTime()),
+			ExpirationTime:     common.TimeValue(activity.GetExpirationTime()),
+			LastWorkerIdentity: activity.GetLastWorkerIdentity(),
+		}
+		if activity.GetHeartbeatDetails() != nil {
+			pendingActivity.HeartbeatDetails = newEncodedValues(activity.GetHeartbeatDetails(), dc)
+		}
+		if activity.GetLastFailure() != nil {
+			pendingActivity.LastFailure = ConvertFailureToError(activity.GetLastFailure(), dc)
+		}
+		description.PendingActivities = append(description.PendingActivities, pendingActivity)
+	}
+	for _, child := range response.GetPendingChildren() {
+		description.PendingChildren = append(description.PendingChildren, PendingChildWorkflowDescription{
+			WorkflowID:        child.GetWorkflowId(),
+			RunID:             child.GetRunId(),
+			WorkflowType:      child.GetWorkflowTypeName(),
+			InitiatedEventID:  child.GetInitiatedId(),
+			ParentClosePolicy: child.GetParentClosePolicy(),
+		})
+	}
+	return description
+}
+
 // QueryWorkflow queries a given workflow execution
 // workflowID and queryType are required, other parameters are optional.
 // - workflow ID of the workflow.
@@ -835,6 +1100,25 @@ type QueryWorkflowWithOptionsResponse struct {
 	QueryRejected *querypb.QueryRejected
 }
 
+// GetWorkflowStackTrace issues the QueryTypeStackTrace query and parses its result.
+// - runID can be default(empty string). if empty string then it will pick the running execution of that workflow ID.
+// The errors it can return:
+//  - BadRequestError
+//  - InternalServiceError
+//  - EntityNotExistError
+//  - QueryFailError
+func (wc *WorkflowClient) GetWorkflowStackTrace(ctx context.Context, workflowID string, runID string) (*WorkflowStackTrace, error) {
+	value, err := wc.QueryWorkflow(ctx, workflowID, runID, QueryTypeStackTrace)
+	if err != nil {
+		return nil, err
+	}
+	var stackTrace string
+	if err := value.Get(&stackTrace); err != nil {
+		return nil, err
+	}
+	return ParseWorkflowStackTrace(stackTrace), nil
+}
+
 // QueryWorkflowWithOptions queries a given workflow execution and returns the query result synchronously.
 // See QueryWorkflowWithOptionsRequest and QueryWorkflowWithOptionsResult for more information.
 // The errors it can return:
@@ -843,6 +1127,10 @@ type QueryWorkflowWithOptionsResponse struct {
 //  - EntityNotExistError
 //  - QueryFailError
 func (wc *WorkflowClient) QueryWorkflowWithOptions(ctx context.Context, request *QueryWorkflowWithOptionsRequest) (*QueryWorkflowWithOptionsResponse, error) {
+	return wc.interceptor.QueryWorkflow(ctx, request)
+}
+
+func (wc *WorkflowClient) queryWorkflowWithOptions(ctx context.Context, request *QueryWorkflowWithOptionsRequest) (*QueryWorkflowWithOptionsResponse, error) {
 	var input *commonpb.Payloads
 	if len(request.Args) > 0 {
 		var err error
@@ -993,6 +1281,55 @@ func (nc *namespaceClient) Update(ctx context.Context, request *workflowservice.
 	return err
 }
 
+// MarkBadBinary marks the binary with the given checksum as bad in the namespace.
+// The errors it can throw:
+//	- EntityNotExistsError
+//	- BadRequestError
+//	- InternalServiceError
+func (nc *namespaceClient) MarkBadBinary(ctx context.Context, namespace string, binaryChecksum string, reason string) error {
+	if binaryChecksum == "" {
+		return errors.New("binary checksum is required")
+	}
+	return nc.Update(ctx, &workflowservice.UpdateNamespaceRequest{
+		Namespace: namespace,
+		Config: &namespacepb.NamespaceConfig{
+			BadBinaries: &namespacepb.BadBinaries{
+				Binaries: map[string]*namespacepb.BadBinaryInfo{
+					binaryChecksum: {Reason: reason, Operator: nc.identity},
+				},
+			},
+		},
+	})
+}
+
+// DeleteBadBinary removes the binary with the given checksum from the bad binaries of the namespace.
+// The errors it can throw:
+//	- EntityNotExistsError
+//	- BadRequestError
+//	- InternalServiceError
+func (nc *namespaceClient) DeleteBadBinary(ctx context.Context, namespace string, binaryChecksum string) error {
+	if binaryChecksum == "" {
+		return errors.New("binary checksum is required")
+	}
+	return nc.Update(ctx, &workflowservice.UpdateNamespaceRequest{
+		Namespace:       namespace,
+		DeleteBadBinary: binaryChecksum,
+	})
+}
+
+// GetBadBinaries returns the bad binaries of the namespace by binary checksum.
+// The errors it can throw:
+//	- EntityNotExistsError
+//	- BadRequestError
+//	- InternalServiceError
+func (nc *namespaceClient) GetBadBinaries(ctx context.Context, namespace string) (map[string]*namespacepb.BadBinaryInfo, error) {
+	response, err := nc.Describe(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return response.GetConfig().GetBadBinaries().GetBinaries(), nil
+}
+
 // Close client and clean up underlying resources.
 func (nc *namespaceClient) Close() {
 	if nc.connectionCloser == nil {
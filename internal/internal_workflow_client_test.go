@@ -43,6 +43,7 @@ import (
 	enumspb "go.temporal.io/api/enums/v1"
 	historypb "go.temporal.io/api/history/v1"
 	"go.temporal.io/api/serviceerror"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/api/workflowservicemock/v1"
 
@@ -278,6 +279,33 @@ func (s *historyEventIteratorSuite) TestIterator_NoError_EmptyPage() {
 	s.Equal(2, len(events))
 }
 
+func (s *historyEventIteratorSuite) TestIterator_ArchivalFallback() {
+	s.wfClient.archivalFallback = true
+	filterType := enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT
+	request := getGetWorkflowExecutionHistoryRequest(filterType)
+	request.Execution.RunId = ""
+	request.WaitNewEvent, request.SkipArchival = false, false
+	archivedRequest := getGetWorkflowExecutionHistoryRequest(filterType)
+	archivedRequest.WaitNewEvent, archivedRequest.SkipArchival = false, false
+	response := &workflowservice.GetWorkflowExecutionHistoryResponse{
+		History: &historypb.History{Events: []*historypb.HistoryEvent{{EventId: 1}}},
+	}
+	gomock.InOrder(
+		s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), request, gomock.Any()).Return(nil, serviceerror.NewNotFound("")),
+		s.workflowServiceClient.EXPECT().ListArchivedWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.ListArchivedWorkflowExecutionsResponse{
+			Executions: []*workflowpb.WorkflowExecutionInfo{{Execution: &commonpb.WorkflowExecution{WorkflowId: workflowID, RunId: runID}}},
+		}, nil),
+		s.workflowServiceClient.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), archivedRequest, gomock.Any()).Return(response, nil),
+	)
+
+	iter := s.wfClient.GetWorkflowHistory(context.Background(), workflowID, "", false, filterType)
+	s.True(iter.HasNext())
+	event, err := iter.Next()
+	s.NoError(err)
+	s.Equal(int64(1), event.GetEventId())
+	s.False(iter.HasNext())
+}
+
 func (s *historyEventIteratorSuite) TestIteratorError() {
 	filterType := enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT
 	request1 := getGetWorkflowExecutionHistoryRequest(filterType)
@@ -447,6 +475,15 @@ func (s *workflowRunSuite) TestExecuteWorkflowWorkflowExecutionAlreadyStartedErr
 	mockerr := serviceerror.NewWorkflowExecutionAlreadyStarted("Already Started", "", runID)
 	s.workflowServiceClient.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(nil, mockerr).Times(1)
+	startTime := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	describeResp := &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: workflowID, RunId: runID},
+			StartTime: &startTime,
+		},
+	}
+	s.workflowServiceClient.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(describeResp, nil).Times(1)
 
 	_, err := s.workflowClient.ExecuteWorkflow(
 		context.Background(),
@@ -460,7 +497,14 @@ func (s *workflowRunSuite) TestExecuteWorkflowWorkflowExecutionAlreadyStartedErr
 		}, workflowType,
 	)
 	s.Error(err)
-	s.Equal(mockerr, err)
+	var alreadyStartedErr *WorkflowExecutionAlreadyStartedError
+	s.True(errors.As(err, &alreadyStartedErr))
+	s.Equal(workflowID, alreadyStartedErr.WorkflowID())
+	s.Equal(runID, alreadyStartedErr.RunID())
+	s.Equal(startTime, alreadyStartedErr.StartTime())
+	var serviceErr *serviceerror.WorkflowExecutionAlreadyStarted
+	s.True(errors.As(err, &serviceErr))
+	s.Equal(mockerr, serviceErr)
 }
 
 func (s *workflowRunSuite) TestExecuteWorkflowWorkflowExecutionAlreadyStartedErrorAllowStarted() {
@@ -1035,6 +1079,38 @@ func (s *workflowClientTestSuite) TestSignalWithStartWorkflow() {
 	s.Equal(startResponse.GetRunId(), resp.GetRunID())
 }
 
+func (s *workflowClientTestSuite) TestSignalWithStartWorkflow_AlreadyStarted() {
+	options := StartWorkflowOptions{
+		ID:                    workflowID,
+		TaskQueue:             taskqueue,
+		WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+	}
+	s.service.EXPECT().SignalWithStartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, serviceerror.NewWorkflowExecutionAlreadyStarted("Workflow execution already finished", "", runID))
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, serviceerror.NewNotFound(""))
+
+	_, err := s.client.SignalWithStartWorkflow(context.Background(), workflowID, "my signal", nil, options, workflowType)
+	var alreadyStartedErr *WorkflowExecutionAlreadyStartedError
+	s.True(errors.As(err, &alreadyStartedErr))
+	s.Equal(workflowID, alreadyStartedErr.WorkflowID())
+	s.Equal(runID, alreadyStartedErr.RunID())
+	s.True(alreadyStartedErr.StartTime().IsZero())
+}
+
+func (s *workflowClientTestSuite) TestSignalWithStartWorkflow_InvalidOptions() {
+	options := StartWorkflowOptions{
+		ID:                    workflowID,
+		TaskQueue:             taskqueue,
+		WorkflowIDReusePolicy: enumspb.WorkflowIdReusePolicy(42),
+	}
+
+	_, err := s.client.SignalWithStartWorkflow(context.Background(), workflowID, "my signal", nil, options, workflowType)
+	var invalidOptionsErr *InvalidOptionsError
+	s.True(errors.As(err, &invalidOptionsErr))
+	s.Equal("WorkflowIDReusePolicy", invalidOptionsErr.Problems[0].Field)
+}
+
 func (s *workflowClientTestSuite) TestSignalWithStartWorkflowWithContextAwareDataConverter() {
 	dc := NewContextAwareDataConverter(converter.GetDefaultDataConverter())
 	s.client = NewServiceClient(s.service, nil, ClientOptions{DataConverter: dc})
@@ -1382,6 +1458,338 @@ func (s *workflowClientTestSuite) TestGetSearchAttributes() {
 	s.IsType(&serviceerror.InvalidArgument{}, err)
 }
 
+func (s *workflowClientTestSuite) TestDescribeWorkflow() {
+	startTime := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	heartbeatTime := startTime.Add(time.Minute)
+	heartbeatDetails, err := s.dataConverter.ToPayloads("progress", 42)
+	s.NoError(err)
+	response := &workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+			Execution:     &commonpb.WorkflowExecution{WorkflowId: workflowID, RunId: runID},
+			Type:          &commonpb.WorkflowType{Name: workflowType},
+			Status:        enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING,
+			StartTime:     &startTime,
+			HistoryLength: 12,
+		},
+		PendingActivities: []*workflowpb.PendingActivityInfo{{
+			ActivityId:        "activity-1",
+			ActivityType:      &commonpb.ActivityType{Name: "activity-type"},
+			State:             enumspb.PENDING_ACTIVITY_STATE_STARTED,
+			Attempt:           3,
+			HeartbeatDetails:  heartbeatDetails,
+			LastHeartbeatTime: &heartbeatTime,
+			LastFailure:       ConvertErrorToFailure(NewApplicationError("boom", "BoomError", false, nil), s.dataConverter),
+		}},
+		PendingChildren: []*workflowpb.PendingChildExecutionInfo{{
+			WorkflowId:       "child-workflow-id",
+			RunId:            "child-run-id",
+			WorkflowTypeName: "child-workflow-type",
+			InitiatedId:      5,
+		}},
+	}
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(response, nil).
+		Do(func(_ interface{}, req *workflowservice.DescribeWorkflowExecutionRequest, _ ...interface{}) {
+			s.Equal(workflowID, req.GetExecution().GetWorkflowId())
+			s.Equal(runID, req.GetExecution().GetRunId())
+		})
+
+	description, err := s.client.DescribeWorkflow(context.Background(), workflowID, runID)
+	s.NoError(err)
+	s.Equal(workflowID, description.WorkflowID)
+	s.Equal(runID, description.RunID)
+	s.Equal(workflowType, description.WorkflowType)
+	s.Equal(enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING, description.Status)
+	s.Equal(startTime, description.StartTime)
+	s.True(description.CloseTime.IsZero())
+	s.Equal(int64(12), description.HistoryLength)
+	s.Equal(response, description.Response)
+
+	s.Len(description.PendingActivities, 1)
+	activity := description.PendingActivities[0]
+	s.Equal("activity-1", activity.ActivityID)
+	s.Equal("activity-type", activity.ActivityType)
+	s.Equal(enumspb.PENDING_ACTIVITY_STATE_STARTED, activity.State)
+	s.Equal(int32(3), activity.Attempt)
+	s.Equal(heartbeatTime, activity.LastHeartbeatTime)
+	var progress string
+	var count int
+	s.NoError(activity.HeartbeatDetails.Get(&progress, &count))
+	s.Equal("progress", progress)
+	s.Equal(42, count)
+	var applicationErr *ApplicationError
+	s.True(errors.As(activity.LastFailure, &applicationErr))
+	s.Equal("BoomError", applicationErr.Type())
+
+	s.Equal([]PendingChildWorkflowDescription{{
+		WorkflowID:       "child-workflow-id",
+		RunID:            "child-run-id",
+		WorkflowType:     "child-workflow-type",
+		InitiatedEventID: 5,
+	}}, description.PendingChildren)
+
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, serviceerror.NewNotFound(""))
+	_, err = s.client.DescribeWorkflow(context.Background(), workflowID, runID)
+	s.IsType(&serviceerror.NotFound{}, err)
+}
+
+func (s *workflowClientTestSuite) TestDescribeWorkflow_ArchivalFallback() {
+	s.client.(*WorkflowClient).archivalFallback = true
+	closeTime := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, serviceerror.NewNotFound(""))
+	s.service.EXPECT().ListArchivedWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.ListArchivedWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: workflowID, RunId: runID},
+			Type:      &commonpb.WorkflowType{Name: workflowType},
+			Status:    enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED,
+			CloseTime: &closeTime,
+		}},
+	}, nil).Do(func(_ interface{}, req *workflowservice.ListArchivedWorkflowExecutionsRequest, _ ...interface{}) {
+		s.Equal("WorkflowId = '"+workflowID+"' and RunId = '"+runID+"'", req.GetQuery())
+	})
+
+	description, err := s.client.DescribeWorkflow(context.Background(), workflowID, runID)
+	s.NoError(err)
+	s.True(description.Archived)
+	s.Equal(runID, description.RunID)
+	s.Equal(enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED, description.Status)
+	s.Equal(closeTime, description.CloseTime)
+
+	notFound := serviceerror.NewNotFound("")
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, notFound)
+	s.service.EXPECT().ListArchivedWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, serviceerror.NewInvalidArgument("archival disabled"))
+	_, err = s.client.DescribeWorkflow(context.Background(), workflowID, runID)
+	s.Equal(notFound, err)
+
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, notFound)
+	s.service.EXPECT().ListArchivedWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.ListArchivedWorkflowExecutionsResponse{}, nil).
+		Do(func(_ interface{}, req *workflowservice.ListArchivedWorkflowExecutionsRequest, _ ...interface{}) {
+			s.Equal(`WorkflowId = 'id\' or WorkflowId = \'other'`, req.GetQuery())
+		})
+	_, err = s.client.DescribeWorkflow(context.Background(), "id' or WorkflowId = 'other", "")
+	s.Error(err)
+}
+
+func (s *workflowClientTestSuite) TestScheduleClient_UpdateRestoresScheduleWhenStartFails() {
+	previousStart := &historypb.WorkflowExecutionStartedEventAttributes{
+		WorkflowType: &commonpb.WorkflowType{Name: workflowType},
+		TaskQueue:    &taskqueuepb.TaskQueue{Name: taskqueue},
+		CronSchedule: "0 * * * *",
+	}
+	s.service.EXPECT().DescribeWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.DescribeWorkflowExecutionResponse{
+		WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: workflowID, RunId: runID},
+			Type:      &commonpb.WorkflowType{Name: workflowType},
+			Status:    enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING,
+		},
+	}, nil)
+	s.service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.GetWorkflowExecutionHistoryResponse{
+		History: &historypb.History{Events: []*historypb.HistoryEvent{{
+			EventId:    1,
+			EventType:  enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED,
+			Attributes: &historypb.HistoryEvent_WorkflowExecutionStartedEventAttributes{WorkflowExecutionStartedEventAttributes: previousStart},
+		}}},
+	}, nil)
+	s.service.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.TerminateWorkflowExecutionResponse{}, nil)
+	startErr := serviceerror.NewInvalidArgument("invalid start")
+	gomock.InOrder(
+		s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, startErr),
+		s.service.EXPECT().StartWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.StartWorkflowExecutionResponse{RunId: "restored"}, nil).
+			Do(func(_ interface{}, req *workflowservice.StartWorkflowExecutionRequest, _ ...interface{}) {
+				s.Equal(workflowID, req.GetWorkflowId())
+				s.Equal("0 * * * *", req.GetCronSchedule())
+				s.Equal(taskqueue, req.GetTaskQueue().GetName())
+			}),
+	)
+
+	err := NewScheduleClient(s.client).Update(context.Background(), ScheduleOptions{
+		ID:           workflowID,
+		CronSchedule: "*/5 * * * *",
+		Workflow:     workflowType,
+		TaskQueue:    taskqueue,
+	})
+	s.Equal(startErr, err)
+}
+
+func (s *workflowClientTestSuite) TestRunBatchOperation() {
+	query := "WorkflowType='" + workflowType + "'"
+	page1 := &workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{
+			{Execution: &commonpb.WorkflowExecution{WorkflowId: "wid1", RunId: "rid1"}},
+			{Execution: &commonpb.WorkflowExecution{WorkflowId: "wid2", RunId: "rid2"}},
+		},
+		NextPageToken: []byte("token"),
+	}
+	page2 := &workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{
+			{Execution: &commonpb.WorkflowExecution{WorkflowId: "wid3", RunId: "rid3"}},
+		},
+	}
+	gomock.InOrder(
+		s.service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Return(page1, nil).
+			Do(func(_ interface{}, req *workflowservice.ListWorkflowExecutionsRequest, _ ...interface{}) {
+				s.Equal(query, req.GetQuery())
+				s.Empty(req.GetNextPageToken())
+			}),
+		s.service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Return(page2, nil).
+			Do(func(_ interface{}, req *workflowservice.ListWorkflowExecutionsRequest, _ ...interface{}) {
+				s.Equal([]byte("token"), req.GetNextPageToken())
+			}),
+	)
+	var terminated []string
+	s.service.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(_ interface{}, req *workflowservice.TerminateWorkflowExecutionRequest, _ ...interface{}) (*workflowservice.TerminateWorkflowExecutionResponse, error) {
+			s.Equal("cleanup", req.GetReason())
+			terminated = append(terminated, req.GetWorkflowExecution().GetWorkflowId())
+			if req.GetWorkflowExecution().GetWorkflowId() == "wid2" {
+				return nil, serviceerror.NewNotFound("workflow completed")
+			}
+			return &workflowservice.TerminateWorkflowExecutionResponse{}, nil
+		})
+
+	var progress []int
+	result, err := RunBatchOperation(context.Background(), s.client, BatchOperationOptions{
+		Query:     query,
+		Operation: BatchOperationTerminate,
+		Reason:    "cleanup",
+		RPS:       1000,
+		Progress: func(processed, total int) {
+			s.Equal(3, total)
+			progress = append(progress, processed)
+		},
+	})
+	s.NoError(err)
+	s.Equal(3, result.Total)
+	s.Equal(2, result.Succeeded)
+	s.Len(result.Failures, 1)
+	s.Equal(WorkflowExecution{ID: "wid2", RunID: "rid2"}, result.Failures[0].Execution)
+	s.IsType(&serviceerror.NotFound{}, result.Failures[0].Err)
+	s.Equal([]string{"wid1", "wid2", "wid3"}, terminated)
+	s.Equal([]int{1, 2, 3}, progress)
+}
+
+func (s *workflowClientTestSuite) TestRunBatchOperation_CanceledWhileWaitingForConcurrency() {
+	s.service.EXPECT().ListWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&workflowservice.ListWorkflowExecutionsResponse{
+			Executions: []*workflowpb.WorkflowExecutionInfo{
+				{Execution: &commonpb.WorkflowExecution{WorkflowId: "wid1", RunId: "rid1"}},
+				{Execution: &commonpb.WorkflowExecution{WorkflowId: "wid2", RunId: "rid2"}},
+			},
+		}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	// The first termination holds the only slot until after the batch is canceled, so the second one is never sent.
+	s.service.EXPECT().TerminateWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, req *workflowservice.TerminateWorkflowExecutionRequest, _ ...interface{}) (*workflowservice.TerminateWorkflowExecutionResponse, error) {
+			s.Equal("wid1", req.GetWorkflowExecution().GetWorkflowId())
+			cancel()
+			time.Sleep(50 * time.Millisecond)
+			return &workflowservice.TerminateWorkflowExecutionResponse{}, nil
+		})
+
+	result, err := RunBatchOperation(ctx, s.client, BatchOperationOptions{
+		Query:       "WorkflowType='" + workflowType + "'",
+		Operation:   BatchOperationTerminate,
+		RPS:         1000,
+		Concurrency: 1,
+	})
+	s.Equal(context.Canceled, err)
+	s.Equal(2, result.Total)
+	s.Equal(1, result.Succeeded)
+}
+
+func (s *workflowClientTestSuite) TestRunBatchOperation_InvalidOptions() {
+	_, err := RunBatchOperation(context.Background(), s.client, BatchOperationOptions{Operation: BatchOperationCancel})
+	s.Error(err)
+	_, err = RunBatchOperation(context.Background(), s.client, BatchOperationOptions{Query: "query", Operation: BatchOperationSignal})
+	s.Error(err)
+	_, err = RunBatchOperation(context.Background(), s.client, BatchOperationOptions{Query: "query"})
+	s.Error(err)
+}
+
+type recordingClientInterceptor struct {
+	ClientOutboundInterceptorBase
+	name  string
+	calls *[]string
+}
+
+func (r *recordingClientInterceptor) InterceptClient(next ClientOutboundInterceptor) ClientOutboundInterceptor {
+	return &recordingClientInterceptor{ClientOutboundInterceptorBase{Next: next}, r.name, r.calls}
+}
+
+func (r *recordingClientInterceptor) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	*r.calls = append(*r.calls, r.name+":"+signalName)
+	return r.Next.SignalWorkflow(ctx, workflowID, runID, signalName, r.name+"-"+arg.(string))
+}
+
+func (s *workflowClientTestSuite) TestClientInterceptors() {
+	var calls []string
+	client := NewServiceClient(s.service, nil, ClientOptions{Interceptors: []ClientInterceptor{
+		&recordingClientInterceptor{name: "first", calls: &calls},
+		&recordingClientInterceptor{name: "second", calls: &calls},
+	}})
+
+	s.service.EXPECT().SignalWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).
+		Do(func(_ interface{}, req *workflowservice.SignalWorkflowExecutionRequest, _ ...interface{}) {
+			var arg string
+			s.NoError(s.dataConverter.FromPayloads(req.GetInput(), &arg))
+			s.Equal("second-first-arg", arg)
+		})
+	s.NoError(client.SignalWorkflow(context.Background(), workflowID, runID, "signal", "arg"))
+	s.Equal([]string{"first:signal", "second:signal"}, calls)
+
+	// Calls which are not overridden by the interceptors are forwarded by ClientOutboundInterceptorBase.
+	s.service.EXPECT().RequestCancelWorkflowExecution(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	s.NoError(client.CancelWorkflow(context.Background(), workflowID, runID))
+}
+
+func (s *workflowClientTestSuite) TestEncodeDecodeTaskToken() {
+	taskToken := []byte{0, 1, 2, 0xfb, 0xff, 'a'}
+	encoded := EncodeTaskToken(taskToken)
+	s.NotContains(encoded, "+")
+	s.NotContains(encoded, "/")
+	decoded, err := DecodeTaskToken(encoded)
+	s.NoError(err)
+	s.Equal(taskToken, decoded)
+
+	_, err = DecodeTaskToken("")
+	s.Error(err)
+	_, err = DecodeTaskToken("not a token!")
+	s.Error(err)
+}
+
+func (s *workflowClientTestSuite) TestCompleteActivityWithDecodedTaskToken() {
+	var request *workflowservice.RespondActivityTaskCompletedRequest
+	s.service.EXPECT().RespondActivityTaskCompleted(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.RespondActivityTaskCompletedResponse{}, nil).
+		Do(func(_ interface{}, req *workflowservice.RespondActivityTaskCompletedRequest, _ ...interface{}) {
+			request = req
+		})
+
+	taskToken, err := DecodeTaskToken(EncodeTaskToken([]byte("task-token")))
+	s.NoError(err)
+	var completionClient ActivityCompletionClient = &activityCompletionClient{client: s.client.(*WorkflowClient)}
+	s.NoError(completionClient.Complete(context.Background(), taskToken, "result"))
+	s.Equal([]byte("task-token"), request.GetTaskToken())
+}
+
+func (s *workflowClientTestSuite) TestActivityCompletionClient_FailAndReportCanceled() {
+	var failed *workflowservice.RespondActivityTaskFailedRequest
+	s.service.EXPECT().RespondActivityTaskFailed(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.RespondActivityTaskFailedResponse{}, nil).
+		Do(func(_ interface{}, req *workflowservice.RespondActivityTaskFailedRequest, _ ...interface{}) {
+			failed = req
+		})
+	var canceled *workflowservice.RespondActivityTaskCanceledRequest
+	s.service.EXPECT().RespondActivityTaskCanceled(gomock.Any(), gomock.Any(), gomock.Any()).Return(&workflowservice.RespondActivityTaskCanceledResponse{}, nil).
+		Do(func(_ interface{}, req *workflowservice.RespondActivityTaskCanceledRequest, _ ...interface{}) {
+			canceled = req
+		})
+
+	completionClient := &activityCompletionClient{client: s.client.(*WorkflowClient)}
+	s.NoError(completionClient.Fail(context.Background(), []byte("task-token"), errors.New("activity error")))
+	s.Equal("activity error", failed.GetFailure().GetMessage())
+	s.NoError(completionClient.ReportCanceled(context.Background(), []byte("task-token"), "details"))
+	s.Equal([]byte("task-token"), canceled.GetTaskToken())
+	s.Error(completionClient.Fail(context.Background(), []byte("task-token"), nil))
+}
+
 func serializeEvents(events []*historypb.HistoryEvent) *commonpb.DataBlob {
 	blob, _ := serializer.SerializeBatchEvents(events, enumspb.ENCODING_TYPE_PROTO3)
 
@@ -1375,3 +1375,59 @@ func (t *tracingOutboundCallsInterceptor) ExecuteActivity(ctx Context, activityT
 	t.inbound.trace = append(t.inbound.trace, "ExecuteActivity "+activityType)
 	return t.Next.ExecuteActivity(ctx, activityType, args...)
 }
+
+func TestDrainSignalChannel(t *testing.T) {
+	workflowFn := func(ctx Context) ([]string, error) {
+		if err := Sleep(ctx, 2*time.Minute); err != nil {
+			return nil, err
+		}
+		require.Equal(t, []string{"signal-a", "signal-b", "signal-c"}, GetUnhandledSignalNames(ctx))
+		require.Equal(t, 3, GetSignalChannel(ctx, "signal-a").Len())
+
+		var result []string
+		for _, max := range []int{2, 0} {
+			for _, signal := range DrainSignalChannel(ctx, "signal-a", max) {
+				var value string
+				if err := signal.Get(&value); err != nil {
+					return nil, err
+				}
+				result = append(result, value)
+			}
+			result = append(result, "|")
+		}
+		require.Equal(t, 0, GetSignalChannel(ctx, "signal-a").Len())
+		require.Equal(t, []string{"signal-b", "signal-c"}, GetUnhandledSignalNames(ctx))
+		return result, nil
+	}
+
+	scope, closer, reporter := metrics.NewTaggedMetricsScope()
+	s := WorkflowTestSuite{}
+	s.SetMetricsScope(scope)
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("signal-a", "a1")
+		env.SignalWorkflow("signal-a", "a2")
+		env.SignalWorkflow("signal-b", "b1")
+		env.SignalWorkflow("signal-a", "a3")
+		env.SignalWorkflow("signal-c", "c1")
+		env.SignalWorkflow("signal-c", "c2")
+	}, time.Minute)
+	env.ExecuteWorkflow(workflowFn)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result []string
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, []string{"a1", "a2", "|", "a3", "|"}, result)
+
+	// The signals which are left unhandled are reported when the workflow completes.
+	require.NoError(t, closer.Close())
+	var unhandledSignals int64
+	for _, counter := range reporter.Counts() {
+		if counter.Name() == metrics.UnhandledSignalsCounter {
+			unhandledSignals += counter.Value()
+		}
+	}
+	require.Equal(t, int64(3), unhandledSignals)
+}
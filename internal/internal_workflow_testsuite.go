@@ -47,6 +47,7 @@ import (
 	taskqueuepb "go.temporal.io/api/taskqueue/v1"
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/api/workflowservicemock/v1"
+	"go.uber.org/atomic"
 	"google.golang.org/grpc"
 
 	"go.temporal.io/sdk/converter"
@@ -84,9 +85,12 @@ type (
 	}
 
 	testActivityHandle struct {
-		callback         ResultHandler
-		activityType     string
-		heartbeatDetails *commonpb.Payloads
+		callback               ResultHandler
+		activityType           string
+		heartbeatDetails       *commonpb.Payloads
+		scheduleToStartTimeout time.Duration
+		startToCloseTimeout    time.Duration
+		heartbeatTimeout       time.Duration
 	}
 
 	testWorkflowHandle struct {
@@ -145,9 +149,10 @@ type (
 		mockClock *clock.Mock
 		wallClock clock.Clock
 
-		callbackChannel chan testCallbackHandle
-		testTimeout     time.Duration
-		header          *commonpb.Header
+		callbackChannel     chan testCallbackHandle
+		testTimeout         time.Duration
+		header              *commonpb.Header
+		sessionFailureAfter time.Duration
 
 		counterID        int64
 		activities       map[string]*testActivityHandle
@@ -156,6 +161,8 @@ type (
 		runningWorkflows map[string]*testWorkflowHandle
 
 		runningCount int
+		// realTimeWindows is the number of windows opened with BeginRealTime which are not ended yet.
+		realTimeWindows atomic.Int32
 
 		expectedMockCalls map[string]struct{}
 
@@ -202,6 +209,17 @@ type (
 
 		workerStopChannel  chan struct{}
 		sessionEnvironment *testSessionEnvironmentImpl
+
+		// The following are used by TestActivityEnvironment, guarded by locker.
+		activityHeartbeats     []converter.EncodedValues
+		activityServiceInvoker ServiceInvoker
+		cancelActivity         context.CancelFunc
+	}
+
+	// testServiceInvoker records the heartbeats of an activity executed by TestActivityEnvironment.
+	testServiceInvoker struct {
+		ServiceInvoker
+		env *testWorkflowEnvironmentImpl
 	}
 
 	testSessionEnvironmentImpl struct {
@@ -332,6 +350,21 @@ func (env *testWorkflowEnvironmentImpl) setStartTime(startTime time.Time) {
 
 }
 
+// newChildWorkflowAlreadyStartedError returns the error the workflow worker reports for a child workflow which failed
+// to start because its workflow ID is already used.
+func newChildWorkflowAlreadyStartedError(params *ExecuteWorkflowParams) error {
+	return NewChildWorkflowExecutionError(
+		params.Namespace,
+		params.WorkflowID,
+		"",
+		params.WorkflowType.Name,
+		0,
+		0,
+		enumspb.RETRY_STATE_NON_RETRYABLE_FAILURE,
+		newChildWorkflowExecutionAlreadyStartedError(),
+	)
+}
+
 func (env *testWorkflowEnvironmentImpl) newTestWorkflowEnvironmentForChild(params *ExecuteWorkflowParams, callback ResultHandler, startedHandler func(r WorkflowExecution, e error)) (*testWorkflowEnvironmentImpl, error) {
 	// create a new test env
 	childEnv := newTestWorkflowEnvironmentImpl(env.testSuite, env.registry)
@@ -356,6 +389,7 @@ func (env *testWorkflowEnvironmentImpl) newTestWorkflowEnvironmentForChild(param
 	// set workflow info data for child workflow
 	childEnv.header = params.Header
 	childEnv.workflowInfo.Attempt = params.attempt
+	childEnv.workflowInfo.WorkerIdentity = env.workflowInfo.WorkerIdentity
 	childEnv.workflowInfo.WorkflowExecution.ID = params.WorkflowID
 	childEnv.workflowInfo.WorkflowExecution.RunID = params.WorkflowID + "_RunID"
 	childEnv.workflowInfo.Namespace = params.Namespace
@@ -371,13 +405,13 @@ func (env *testWorkflowEnvironmentImpl) newTestWorkflowEnvironmentForChild(param
 	if workflowHandler, ok := env.runningWorkflows[params.WorkflowID]; ok {
 		// duplicate workflow ID
 		if !workflowHandler.handled {
-			return nil, serviceerror.NewWorkflowExecutionAlreadyStarted("Workflow execution already started", "", "")
+			return nil, newChildWorkflowAlreadyStartedError(params)
 		}
 		if params.WorkflowIDReusePolicy == enumspb.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE {
-			return nil, serviceerror.NewWorkflowExecutionAlreadyStarted("Workflow execution already started", "", "")
+			return nil, newChildWorkflowAlreadyStartedError(params)
 		}
 		if workflowHandler.err == nil && params.WorkflowIDReusePolicy == enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY {
-			return nil, serviceerror.NewWorkflowExecutionAlreadyStarted("Workflow execution already started", "", "")
+			return nil, newChildWorkflowAlreadyStartedError(params)
 		}
 	}
 
@@ -389,6 +423,7 @@ func (env *testWorkflowEnvironmentImpl) newTestWorkflowEnvironmentForChild(param
 func (env *testWorkflowEnvironmentImpl) setWorkerOptions(options WorkerOptions) {
 	env.workerOptions = options
 	env.registry.SetWorkflowInterceptors(options.WorkflowInterceptorChainFactories)
+	env.registry.SetTaskQueueRoutes(options.TaskQueueRoutes)
 	if env.workerOptions.EnableSessionWorker && env.sessionEnvironment == nil {
 		env.registry.RegisterActivityWithOptions(sessionCreationActivity, RegisterActivityOptions{
 			Name:                          sessionCreationActivityName,
@@ -403,6 +438,7 @@ func (env *testWorkflowEnvironmentImpl) setWorkerOptions(options WorkerOptions)
 
 func (env *testWorkflowEnvironmentImpl) setIdentity(identity string) {
 	env.identity = identity
+	env.workflowInfo.WorkerIdentity = identity
 }
 
 func (env *testWorkflowEnvironmentImpl) setDataConverter(dataConverter converter.DataConverter) {
@@ -504,6 +540,13 @@ func (env *testWorkflowEnvironmentImpl) executeWorkflowInternal(delayStart time.
 func (env *testWorkflowEnvironmentImpl) getWorkflowDefinition(wt WorkflowType) (WorkflowDefinition, error) {
 	wf, ok := env.registry.getWorkflowFn(wt.Name)
 	if !ok {
+		if dynamic := env.registry.getDynamicWorkflow(); dynamic != nil {
+			wd := &workflowExecutorWrapper{
+				workflowExecutor: &workflowExecutor{workflowType: wt.Name, fn: dynamic, interceptors: env.registry.WorkflowInterceptors(), isDynamic: true},
+				env:              env,
+			}
+			return newSyncWorkflowDefinition(wd), nil
+		}
 		supported := strings.Join(env.registry.getRegisteredWorkflowTypes(), ", ")
 		return nil, fmt.Errorf("unable to find workflow type: %v. Supported types: [%v]", wt.Name, supported)
 	}
@@ -566,6 +609,23 @@ func (env *testWorkflowEnvironmentImpl) executeActivity(
 
 	// ensure activityFn is registered to defaultTestTaskQueue
 	taskHandler := env.newTestActivityTaskHandler(defaultTestTaskQueue, env.GetDataConverter())
+	ath := taskHandler.(*activityTaskHandlerImpl)
+	activityCtx, cancel := context.WithCancel(ath.userContext)
+	defer cancel()
+	ath.userContext = activityCtx
+	ath.wrapServiceInvoker = func(invoker ServiceInvoker) ServiceInvoker {
+		env.locker.Lock()
+		defer env.locker.Unlock()
+		if env.activityServiceInvoker != nil {
+			invoker = env.activityServiceInvoker
+		}
+		return &testServiceInvoker{ServiceInvoker: invoker, env: env}
+	}
+	env.locker.Lock()
+	env.activityHeartbeats = nil
+	env.cancelActivity = cancel
+	env.locker.Unlock()
+
 	activityHandle := &testActivityHandle{callback: func(result *commonpb.Payloads, err error) {}, activityType: parameters.ActivityType.Name}
 	activityID := ActivityID{id: scheduleTaskAttr.GetActivityId()}
 	env.setActivityHandle(activityID, activityHandle)
@@ -599,6 +659,45 @@ func (env *testWorkflowEnvironmentImpl) executeActivity(
 	}
 }
 
+// Heartbeat records the heartbeat and calls the heartbeat listener before reporting it.
+func (i *testServiceInvoker) Heartbeat(ctx context.Context, details *commonpb.Payloads, skipBatching bool) error {
+	values := newEncodedValues(details, getDataConverterFromActivityCtx(ctx))
+	i.env.locker.Lock()
+	i.env.activityHeartbeats = append(i.env.activityHeartbeats, values)
+	listener := i.env.onActivityHeartbeatListener
+	i.env.locker.Unlock()
+	if listener != nil {
+		activityInfo := GetActivityInfo(ctx)
+		listener(&activityInfo, values)
+	}
+	return i.ServiceInvoker.Heartbeat(ctx, details, skipBatching)
+}
+
+func (env *testWorkflowEnvironmentImpl) getActivityHeartbeats() []converter.EncodedValues {
+	env.locker.Lock()
+	defer env.locker.Unlock()
+	return append([]converter.EncodedValues(nil), env.activityHeartbeats...)
+}
+
+func (env *testWorkflowEnvironmentImpl) requestCancelTestActivity() {
+	env.locker.Lock()
+	defer env.locker.Unlock()
+	if env.cancelActivity != nil {
+		env.cancelActivity()
+	}
+}
+
+func (env *testWorkflowEnvironmentImpl) stopWorker() {
+	env.locker.Lock()
+	defer env.locker.Unlock()
+	select {
+	case <-env.workerStopChannel:
+		// already stopped
+	default:
+		close(env.workerStopChannel)
+	}
+}
+
 func (env *testWorkflowEnvironmentImpl) executeLocalActivity(
 	activityFn interface{},
 	args ...interface{},
@@ -753,8 +852,8 @@ func (env *testWorkflowEnvironmentImpl) autoFireNextTimer() bool {
 		env.mockClock.Add(skipDuration)
 	}
 
-	// fire timer if there is no running activity
-	if env.runningCount == 0 {
+	// fire timer if there is no running activity and no real time window
+	if env.runningCount == 0 && env.realTimeWindows.Load() == 0 {
 		if nextTimer.wallTimer != nil {
 			nextTimer.wallTimer.Stop()
 			nextTimer.wallTimer = nil
@@ -792,6 +891,18 @@ func (env *testWorkflowEnvironmentImpl) autoFireNextTimer() bool {
 	return false
 }
 
+func (env *testWorkflowEnvironmentImpl) beginRealTime() func() {
+	env.realTimeWindows.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			env.realTimeWindows.Dec()
+			// Wake up the main loop so that it skips time again if it is blocked.
+			env.postCallback(func() {}, false)
+		})
+	}
+}
+
 func (env *testWorkflowEnvironmentImpl) postCallback(cb func(), startWorkflowTask bool) {
 	env.callbackChannel <- testCallbackHandle{callback: cb, startWorkflowTask: startWorkflowTask, env: env}
 }
@@ -1063,7 +1174,13 @@ func (env *testWorkflowEnvironmentImpl) ExecuteActivity(parameters ExecuteActivi
 	)
 
 	taskHandler := env.newTestActivityTaskHandler(parameters.TaskQueueName, parameters.DataConverter)
-	activityHandle := &testActivityHandle{callback: callback, activityType: parameters.ActivityType.Name}
+	activityHandle := &testActivityHandle{
+		callback:               callback,
+		activityType:           parameters.ActivityType.Name,
+		scheduleToStartTimeout: common.DurationValue(scheduleTaskAttr.GetScheduleToStartTimeout()),
+		startToCloseTimeout:    common.DurationValue(scheduleTaskAttr.GetStartToCloseTimeout()),
+		heartbeatTimeout:       common.DurationValue(scheduleTaskAttr.GetHeartbeatTimeout()),
+	}
 
 	env.setActivityHandle(activityID, activityHandle)
 	env.runningCount++
@@ -1297,6 +1414,8 @@ func (env *testWorkflowEnvironmentImpl) executeActivityWithRetryForTest(
 				env.registerDelayedCallback(func() {
 					env.runningCount++
 					task.Attempt = task.GetAttempt() + 1
+					currentAttemptScheduledTime := time.Now()
+					task.CurrentAttemptScheduledTime = &currentAttemptScheduledTime
 					activityID := ActivityID{id: string(task.TaskToken)}
 					if ah, ok := env.getActivityHandle(activityID); ok {
 						task.HeartbeatDetails = ah.heartbeatDetails
@@ -1382,6 +1501,7 @@ func (env *testWorkflowEnvironmentImpl) ExecuteLocalActivity(params ExecuteLocal
 		dataConverter:      env.dataConverter,
 		tracer:             env.tracer,
 		contextPropagators: env.contextPropagators,
+		panicPolicy:        env.workerOptions.ActivityPanicPolicy,
 	}
 
 	env.localActivities[activityID] = task
@@ -1458,11 +1578,16 @@ func (env *testWorkflowEnvironmentImpl) handleActivityResult(activityID Activity
 		activityHandle.callback(blob, nil)
 	default:
 		if result == context.DeadlineExceeded {
+			// Like the server, deliver the last recorded heartbeat details to the workflow with the timeout.
+			var lastHeartbeatDetails []interface{}
+			if activityHandle.heartbeatDetails != nil {
+				lastHeartbeatDetails = append(lastHeartbeatDetails, newEncodedValues(activityHandle.heartbeatDetails, dataConverter))
+			}
 			err = env.wrapActivityError(
 				activityID,
 				activityType,
 				enumspb.RETRY_STATE_TIMEOUT,
-				NewTimeoutError("Activity timeout", enumspb.TIMEOUT_TYPE_START_TO_CLOSE, context.DeadlineExceeded),
+				NewTimeoutError("Activity timeout", enumspb.TIMEOUT_TYPE_START_TO_CLOSE, context.DeadlineExceeded, lastHeartbeatDetails...),
 			)
 			activityHandle.callback(nil, err)
 		} else {
@@ -1540,7 +1665,7 @@ func (env *testWorkflowEnvironmentImpl) handleLocalActivityResult(result *localA
 			env.onLocalActivityCanceledListener(activityInfo)
 		}
 	} else if env.onLocalActivityCompletedListener != nil {
-		env.onLocalActivityCompletedListener(activityInfo, newEncodedValue(result.result, env.GetDataConverter()), nil)
+		env.onLocalActivityCompletedListener(activityInfo, newEncodedValue(result.result, env.GetDataConverter()), lar.Err)
 	}
 	env.startWorkflowTask()
 }
@@ -1554,17 +1679,7 @@ func (env *testWorkflowEnvironmentImpl) runBeforeMockCallReturns(call *MockCallW
 	}
 	if waitDuration > 0 {
 		// we want this mock call to block until the wait duration is elapsed (on workflow clock).
-		waitCh := make(chan time.Time)
-		env.registerDelayedCallback(func() {
-			env.runningCount++  // increase runningCount as the mock call is ready to resume.
-			waitCh <- env.Now() // this will unblock mock call
-		}, waitDuration)
-
-		// make sure decrease runningCount after delayed callback is posted
-		env.postCallback(func() {
-			env.runningCount-- // reduce runningCount, since this mock call is about to be blocked.
-		}, false)
-		<-waitCh // this will block until mock clock move forward by waitDuration
+		env.waitOnMockClock(waitDuration)
 	}
 
 	// run the actual runFn if it was setup
@@ -1573,6 +1688,60 @@ func (env *testWorkflowEnvironmentImpl) runBeforeMockCallReturns(call *MockCallW
 	}
 }
 
+// waitOnMockClock blocks the calling goroutine, which must not be the workflow dispatcher, until the duration has
+// elapsed on the workflow clock.
+func (env *testWorkflowEnvironmentImpl) waitOnMockClock(d time.Duration) {
+	waitCh := make(chan time.Time)
+	env.registerDelayedCallback(func() {
+		env.runningCount++  // increase runningCount as the mock call is ready to resume.
+		waitCh <- env.Now() // this will unblock mock call
+	}, d)
+
+	// make sure decrease runningCount after delayed callback is posted
+	env.postCallback(func() {
+		env.runningCount-- // reduce runningCount, since this mock call is about to be blocked.
+	}, false)
+	<-waitCh // this will block until mock clock move forward by d
+}
+
+// simulateActivityTimeout waits for the timeout requested by a mocked activity to elapse on the workflow clock and
+// returns the TimeoutError the server would have failed the activity attempt with.
+func (env *testWorkflowEnvironmentImpl) simulateActivityTimeout(ctx context.Context, name string, timeout *mockActivityTimeoutError) error {
+	activityID := ActivityID{id: GetActivityInfo(ctx).ActivityID}
+	env.locker.Lock() // need lock as this is running in activity worker's goroutinue
+	handle, ok := env.getActivityHandle(activityID)
+	env.locker.Unlock()
+	if !ok {
+		return NewCanceledError()
+	}
+
+	var d time.Duration
+	switch timeout.timeoutType {
+	case enumspb.TIMEOUT_TYPE_SCHEDULE_TO_START:
+		d = handle.scheduleToStartTimeout
+	case enumspb.TIMEOUT_TYPE_START_TO_CLOSE:
+		d = handle.startToCloseTimeout
+	case enumspb.TIMEOUT_TYPE_HEARTBEAT:
+		d = handle.heartbeatTimeout
+		if len(timeout.details) > 0 {
+			data, err := encodeArgs(getDataConverterFromActivityCtx(ctx), timeout.details)
+			if err != nil {
+				panic(fmt.Sprintf("encode heartbeat details from mock of %v failed: %v", name, err))
+			}
+			env.locker.Lock()
+			handle.heartbeatDetails = data
+			env.locker.Unlock()
+		}
+	}
+	if d <= 0 {
+		panic(fmt.Sprintf("mock of %v returned a %v timeout, but the activity has no such timeout set",
+			name, timeout.timeoutType))
+	}
+
+	env.waitOnMockClock(d)
+	return NewTimeoutError(timeout.Error(), timeout.timeoutType, nil, timeout.details...)
+}
+
 // Execute executes the activity code.
 func (a *activityExecutorWrapper) Execute(ctx context.Context, input *commonpb.Payloads) (*commonpb.Payloads, error) {
 	activityInfo := GetActivityInfo(ctx)
@@ -1588,7 +1757,11 @@ func (a *activityExecutorWrapper) Execute(ctx context.Context, input *commonpb.P
 
 	m := &mockWrapper{env: a.env, name: a.name, fn: a.fn, isWorkflow: false, dataConverter: dc}
 	if mockRet := m.getMockReturn(ctx, input); mockRet != nil {
-		return m.executeMock(ctx, input, mockRet)
+		result, err := m.executeMock(ctx, input, mockRet)
+		if timeoutErr, ok := err.(*mockActivityTimeoutError); ok {
+			return nil, a.env.simulateActivityTimeout(ctx, a.name, timeoutErr)
+		}
+		return result, err
 	}
 
 	return a.activityExecutor.Execute(ctx, input)
@@ -1628,7 +1801,12 @@ func (w *workflowExecutorWrapper) Execute(ctx Context, input *commonpb.Payloads)
 		env.runningCount++
 	}
 
-	m := &mockWrapper{env: env, name: w.workflowType, fn: w.fn, isWorkflow: true,
+	fn := w.fn
+	if versioned, ok := fn.(*versionedWorkflow); ok {
+		// Mocks are matched against the implementation selected for this run.
+		fn = versioned.selectVersion(ctx)
+	}
+	m := &mockWrapper{env: env, name: w.workflowType, fn: fn, isWorkflow: true,
 		dataConverter: env.GetDataConverter(), interceptors: env.GetRegistry().WorkflowInterceptors()}
 	// This method is called by workflow's dispatcher. In this test suite, it is run in the main loop. We cannot block
 	// the main loop, but the mock could block if it is configured to wait. So we need to use a separate goroutinue to
@@ -1838,15 +2016,17 @@ func (m *mockWrapper) executeMockWithActualArgs(ctx interface{}, inputArgs []int
 func (env *testWorkflowEnvironmentImpl) newTestActivityTaskHandler(taskQueue string, dataConverter converter.DataConverter) ActivityTaskHandler {
 	setWorkerOptionsDefaults(&env.workerOptions)
 	params := workerExecutionParameters{
-		TaskQueue:          taskQueue,
-		Identity:           env.identity,
-		MetricsScope:       env.metricsScope,
-		Logger:             env.logger,
-		UserContext:        env.workerOptions.BackgroundActivityContext,
-		DataConverter:      dataConverter,
-		WorkerStopChannel:  env.workerStopChannel,
-		ContextPropagators: env.contextPropagators,
-		Tracer:             env.tracer,
+		TaskQueue:              taskQueue,
+		Identity:               env.identity,
+		MetricsScope:           env.metricsScope,
+		Logger:                 env.logger,
+		UserContext:            env.workerOptions.BackgroundActivityContext,
+		DataConverter:          dataConverter,
+		WorkerStopChannel:      env.workerStopChannel,
+		ContextPropagators:     env.contextPropagators,
+		Tracer:                 env.tracer,
+		ActivityPanicPolicy:    env.workerOptions.ActivityPanicPolicy,
+		ActivityRetryPredicate: env.workerOptions.ActivityRetryPredicate,
 	}
 	ensureRequiredParams(&params)
 	if params.UserContext == nil {
@@ -1857,7 +2037,7 @@ func (env *testWorkflowEnvironmentImpl) newTestActivityTaskHandler(taskQueue str
 	}
 	params.UserContext = context.WithValue(params.UserContext, sessionEnvironmentContextKey, env.sessionEnvironment)
 	registry := env.registry
-	if len(registry.getRegisteredActivities()) == 0 {
+	if len(registry.getRegisteredActivities()) == 0 && registry.getDynamicActivity() == nil {
 		panic(fmt.Sprintf("no activity is registered for taskqueue '%v'", taskQueue))
 	}
 
@@ -1873,6 +2053,9 @@ func (env *testWorkflowEnvironmentImpl) newTestActivityTaskHandler(taskQueue str
 
 		activity, ok := registry.GetActivity(name)
 		if !ok {
+			if dynamic := registry.getDynamicActivity(); dynamic != nil {
+				return &dynamicActivity{name: name, fn: dynamic}
+			}
 			return nil
 		}
 		ae := &activityExecutor{name: activity.ActivityType().Name, fn: activity.GetFunction()}
@@ -1918,6 +2101,8 @@ func newTestActivityTask(workflowID, runID, workflowTypeName, namespace string,
 		},
 		WorkflowNamespace: namespace,
 		Header:            attr.GetHeader(),
+
+		CurrentAttemptScheduledTime: &now,
 	}
 	return task
 }
@@ -1961,6 +2146,14 @@ func (env *testWorkflowEnvironmentImpl) WorkflowInfo() *WorkflowInfo {
 	return env.workflowInfo
 }
 
+func (env *testWorkflowEnvironmentImpl) ChangeVersions() map[string]Version {
+	result := make(map[string]Version, len(env.changeVersions))
+	for changeID, version := range env.changeVersions {
+		result[changeID] = version
+	}
+	return result
+}
+
 func (env *testWorkflowEnvironmentImpl) RegisterWorkflow(w interface{}) {
 	env.registry.RegisterWorkflow(w)
 }
@@ -1969,6 +2162,14 @@ func (env *testWorkflowEnvironmentImpl) RegisterWorkflowWithOptions(w interface{
 	env.registry.RegisterWorkflowWithOptions(w, options)
 }
 
+func (env *testWorkflowEnvironmentImpl) RegisterDynamicWorkflow(w DynamicWorkflowFunc) {
+	env.registry.RegisterDynamicWorkflow(w)
+}
+
+func (env *testWorkflowEnvironmentImpl) RegisterDynamicActivity(a DynamicActivityFunc) {
+	env.registry.RegisterDynamicActivity(a)
+}
+
 func (env *testWorkflowEnvironmentImpl) RegisterActivity(a interface{}) {
 	env.registry.RegisterActivityWithOptions(a, RegisterActivityOptions{DisableAlreadyRegisteredCheck: true})
 }
@@ -2201,6 +2402,29 @@ func (env *testWorkflowEnvironmentImpl) MutableSideEffect(_ string, f func() int
 
 func (env *testWorkflowEnvironmentImpl) AddSession(sessionInfo *SessionInfo) {
 	env.openSessions[sessionInfo.SessionID] = sessionInfo
+	if env.sessionFailureAfter > 0 {
+		sessionID := sessionInfo.SessionID
+		env.newTimer(env.sessionFailureAfter, func(result *commonpb.Payloads, err error) {
+			env.failSession(sessionID)
+		}, false)
+	}
+}
+
+// failSession simulates the loss of the session worker, the same way the failure of the session creation activity
+// is handled by createSession.
+func (env *testWorkflowEnvironmentImpl) failSession(sessionID string) {
+	sessionInfo, ok := env.openSessions[sessionID]
+	if !ok || sessionInfo.sessionState != sessionStateOpen {
+		return
+	}
+	env.logger.Debug("Simulated session failure", "sessionID", sessionID)
+	env.RemoveSession(sessionID)
+	sessionInfo.sessionState = sessionStateFailed
+	sessionInfo.sessionCancelFunc()
+	if env.sessionEnvironment != nil {
+		env.sessionEnvironment.CompleteSession(sessionID)
+		env.sessionEnvironment.AddSessionToken()
+	}
 }
 
 func (env *testWorkflowEnvironmentImpl) RemoveSession(sessionID string) {
@@ -33,6 +33,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	commonpb "go.temporal.io/api/common/v1"
@@ -255,6 +256,34 @@ func (s *WorkflowTestSuiteUnitTest) Test_TimerWorkflow_ClockAutoFastForward() {
 	s.Equal([]string{"t2", "t3", "t1", "t4"}, firedTimerRecord)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_SleepUntil() {
+	var wokeUpAt []time.Time
+	workflowFn := func(ctx Context) error {
+		start := Now(ctx)
+		if err := SleepUntil(ctx, start.Add(time.Hour)); err != nil {
+			return err
+		}
+		wokeUpAt = append(wokeUpAt, Now(ctx))
+		// Deadline in the past returns immediately.
+		if err := SleepUntil(ctx, start); err != nil {
+			return err
+		}
+		wokeUpAt = append(wokeUpAt, Now(ctx))
+		return nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	start := env.Now()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.Len(wokeUpAt, 2)
+	s.False(wokeUpAt[0].Before(start.Add(time.Hour)))
+	s.Equal(wokeUpAt[0], wokeUpAt[1])
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_WorkflowAutoForwardClock() {
 	workflowFn := func(ctx Context) (string, error) {
 		// Schedule a timer with long duration. In this test, we won't actually wait for that long, because the test suite
@@ -678,6 +707,97 @@ func (s *WorkflowTestSuiteUnitTest) Test_SideEffect_WithVersion() {
 	s.Nil(env.GetWorkflowError())
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_WorkflowMetadataQuery() {
+	workflowFn := func(ctx Context) error {
+		if err := SetQueryHandler(ctx, "status", func() (string, error) { return "ok", nil }); err != nil {
+			return err
+		}
+		GetVersion(ctx, "change-1", DefaultVersion, 2)
+		GetSignalChannel(ctx, "approve")
+		return nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflowWithOptions(workflowFn, RegisterWorkflowOptions{Name: "metadata-workflow"})
+	env.ExecuteWorkflow("metadata-workflow")
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+
+	expectedQueryTypes := []string{QueryTypeOpenSessions, QueryTypeQueryTypes, QueryTypeStackTrace, QueryTypeWorkflowMetadata,
+		QueryTypeWorkflowState, "status"}
+	encoded, err := env.QueryWorkflow(QueryTypeQueryTypes)
+	s.NoError(err)
+	var queryTypes []string
+	s.NoError(encoded.Get(&queryTypes))
+	s.Equal(expectedQueryTypes, queryTypes)
+
+	encoded, err = env.QueryWorkflow(QueryTypeWorkflowMetadata)
+	s.NoError(err)
+	var metadata WorkflowMetadata
+	s.NoError(encoded.Get(&metadata))
+	s.Equal(WorkflowMetadata{
+		WorkflowType:   "metadata-workflow",
+		QueryTypes:     expectedQueryTypes,
+		SignalChannels: []string{"approve"},
+		ChangeVersions: map[string]Version{"change-1": 2},
+	}, metadata)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_WorkflowStateQuery() {
+	workflowFn := func(ctx Context) error {
+		if err := SetValue(ctx, "owner", "team-a"); err != nil {
+			return err
+		}
+		progress := 0
+		if err := RegisterObservableVariable(ctx, "progress", func() interface{} { return progress }); err != nil {
+			return err
+		}
+		progress = 42
+		return nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+
+	encoded, err := env.QueryWorkflow(QueryTypeWorkflowState)
+	s.NoError(err)
+	var state WorkflowState
+	s.NoError(encoded.Get(&state))
+	s.Equal(WorkflowState{
+		Values:    map[string]interface{}{"owner": "team-a"},
+		Variables: map[string]interface{}{"progress": float64(42)},
+	}, state)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_RandAndNewUUID() {
+	workflowFn := func(ctx Context) ([]string, error) {
+		r := Rand(ctx)
+		n := r.Intn(100)
+		if n < 0 || n >= 100 {
+			return nil, fmt.Errorf("unexpected random number %d", n)
+		}
+		id1 := NewUUID(ctx)
+		id2 := NewUUID(ctx)
+		return []string{id1, id2}, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var ids []string
+	s.NoError(env.GetWorkflowResult(&ids))
+	s.Len(ids, 2)
+	s.NotNil(uuid.Parse(ids[0]))
+	s.NotNil(uuid.Parse(ids[1]))
+	s.NotEqual(ids[0], ids[1])
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_ChildWorkflow_Basic() {
 	workflowFn := func(ctx Context) (string, error) {
 		ctx = WithActivityOptions(ctx, s.activityOptions)
@@ -1602,6 +1722,80 @@ func (s *WorkflowTestSuiteUnitTest) Test_MockGetVersion() {
 	env.AssertExpectations(s.T())
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_Patched() {
+	workflowFn := func(ctx Context) ([]bool, error) {
+		patched1 := Patched(ctx, "patch_1")
+		patched2 := Patched(ctx, "patch_2")
+		DeprecatePatch(ctx, "patch_3")
+
+		wfInfo := GetWorkflowInfo(ctx)
+		s.NotNil(wfInfo.SearchAttributes)
+		changeVersionsBytes, ok := wfInfo.SearchAttributes.IndexedFields[TemporalChangeVersion]
+		s.True(ok)
+		var changeVersions []string
+		err := converter.GetDefaultDataConverter().FromPayload(changeVersionsBytes, &changeVersions)
+		s.NoError(err)
+		s.ElementsMatch([]string{"patch_1--1", "patch_2-1", "patch_3-1"}, changeVersions)
+
+		return []bool{patched1, patched2}, err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.OnGetVersion("patch_1", DefaultVersion, 1).Return(DefaultVersion)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.Nil(env.GetWorkflowError())
+	var ret []bool
+	s.NoError(env.GetWorkflowResult(&ret))
+	s.Equal([]bool{false, true}, ret)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_TypedSearchAttributes() {
+	customerKey := NewSearchAttributeKeyKeyword("CustomKeywordField")
+	countKey := NewSearchAttributeKeyInt64("CustomIntField")
+	startKey := NewSearchAttributeKeyTime("CustomDatetimeField")
+	doneKey := NewSearchAttributeKeyBool("CustomBoolField")
+	startTime := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	workflowFn := func(ctx Context) error {
+		attributes := GetTypedSearchAttributes(ctx)
+		s.Equal(0, attributes.Size())
+		_, ok := attributes.GetKeyword(customerKey)
+		s.False(ok)
+
+		err := UpsertTypedSearchAttributes(ctx, customerKey.ValueSet("customer-1"), countKey.ValueSet(3),
+			startKey.ValueSet(startTime))
+		s.NoError(err)
+		s.NoError(UpsertTypedSearchAttributes(ctx, countKey.ValueSet(4)))
+		s.Equal(errSearchAttributeKeyNameNotSet, UpsertTypedSearchAttributes(ctx, SearchAttributeKeyBool{}.ValueSet(true)))
+
+		attributes = GetTypedSearchAttributes(ctx)
+		s.Equal(3, attributes.Size())
+		customer, ok := attributes.GetKeyword(customerKey)
+		s.True(ok)
+		s.Equal("customer-1", customer)
+		count, ok := attributes.GetInt64(countKey)
+		s.True(ok)
+		s.Equal(int64(4), count)
+		start, ok := attributes.GetTime(startKey)
+		s.True(ok)
+		s.True(startTime.Equal(start))
+		s.False(attributes.ContainsKey(doneKey.GetName()))
+		// The value of a search attribute read with a key of another type isn't decoded.
+		_, ok = attributes.GetBool(NewSearchAttributeKeyBool(customerKey.GetName()))
+		s.False(ok)
+		return nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_UpsertSearchAttributes_ReservedKey() {
 	workflowFn := func(ctx Context) error {
 		attr := map[string]interface{}{
@@ -1791,6 +1985,32 @@ func (s *WorkflowTestSuiteUnitTest) Test_WorkflowRegistration() {
 	env.ExecuteWorkflow(workflowAlias)
 }
 
+func (s *WorkflowTestSuiteUnitTest) Test_VersionedWorkflowRegistration() {
+	workflowV1 := func(ctx Context, name string) (string, error) {
+		return "v1_" + name, nil
+	}
+	workflowV2 := func(ctx Context, name string) (string, error) {
+		return "v2_" + name, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflowWithOptions(workflowV1, RegisterWorkflowOptions{Name: "versioned", Version: DefaultVersion})
+	env.RegisterWorkflowWithOptions(workflowV2, RegisterWorkflowOptions{Name: "versioned", Version: 2})
+	s.Panics(func() {
+		env.RegisterWorkflowWithOptions(workflowV1, RegisterWorkflowOptions{Name: "versioned", Version: 2})
+	})
+	s.Panics(func() {
+		env.RegisterWorkflowWithOptions(workflowV1, RegisterWorkflowOptions{Name: "versioned"})
+	})
+
+	env.ExecuteWorkflow("versioned", "world")
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("v2_world", result)
+}
+
 func (s *WorkflowTestSuiteUnitTest) Test_ActivityFriendlyName() {
 	activityFn := func(msg string) (string, error) {
 		return "hello_" + msg, nil
@@ -2338,1352 +2558,2641 @@ func (s *WorkflowTestSuiteUnitTest) Test_SignalExternalWorkflow() {
 	s.NoError(env.GetWorkflowError())
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_CancelChildWorkflow() {
-	childWorkflowFn := func(ctx Context) error {
-		var err error
-		selector := NewSelector(ctx)
-		timer := NewTimer(ctx, 10*time.Second)
-		selector.AddFuture(timer, func(f Future) {
-			err = f.Get(ctx, nil)
-		}).Select(ctx)
-
-		fmt.Println("####")
-		fmt.Println(err)
-		fmt.Println("####")
-		return err
+func (s *WorkflowTestSuiteUnitTest) Test_SignalExternalWorkflows() {
+	signalName := "test-signal-name"
+	signalData := "test-signal-data"
+	var targets []WorkflowExecution
+	for i := 0; i < 7; i++ {
+		targets = append(targets, WorkflowExecution{ID: fmt.Sprintf("test-workflow-id%d", i)})
+	}
+	workflowFn := func(ctx Context) ([]string, error) {
+		ctx = WithWorkflowNamespace(ctx, "test-namespace")
+		errs := SignalExternalWorkflows(ctx, targets, signalName, signalData, SignalExternalWorkflowsOptions{
+			MaxConcurrent:             2,
+			MaxSignalsPerWorkflowTask: 3,
+		})
+		var failed []string
+		for i, err := range errs {
+			if err != nil {
+				failed = append(failed, targets[i].ID)
+			}
+		}
+		return failed, nil
 	}
 
-	workflowFn := func(ctx Context) error {
-
-		cwo := ChildWorkflowOptions{
-			Namespace:          "test-namespace",
-			WorkflowRunTimeout: time.Minute,
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	for _, target := range targets {
+		if target.ID == "test-workflow-id4" {
+			env.OnSignalExternalWorkflow("test-namespace", target.ID, "", signalName, signalData).
+				Return(errors.New("unknown external workflow")).Once()
+			continue
 		}
+		env.OnSignalExternalWorkflow("test-namespace", target.ID, "", signalName, signalData).
+			Return(nil).Once()
+	}
 
-		childCtx := WithChildWorkflowOptions(ctx, cwo)
-		childCtx, cancel := WithCancel(childCtx)
-		childFuture := ExecuteChildWorkflow(childCtx, childWorkflowFn)
-		_ = Sleep(ctx, 2*time.Second)
-		cancel()
+	env.ExecuteWorkflow(workflowFn)
+	env.AssertExpectations(s.T())
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var failed []string
+	s.NoError(env.GetWorkflowResult(&failed))
+	s.Equal([]string{"test-workflow-id4"}, failed)
+}
 
-		err := childFuture.Get(childCtx, nil)
-		var childWorkflowErr *ChildWorkflowExecutionError
-		if !errors.As(err, &childWorkflowErr) {
-			return fmt.Errorf("cancel child workflow should receive ChildWorkflowExecutionError, instead got: %v", err)
+func (s *WorkflowTestSuiteUnitTest) Test_WorkflowValues() {
+	helper := func(ctx Context) int {
+		var count int
+		if _, err := GetValue(ctx, "count", &count); err != nil {
+			panic(err)
 		}
-
-		err = errors.Unwrap(childWorkflowErr)
-		var err1 *CanceledError
-		if !errors.As(err, &err1) {
-			return fmt.Errorf("cancel child workflow cause should be CanceledError, instead got: %v", err)
+		count++
+		if err := SetValue(ctx, "count", count); err != nil {
+			panic(err)
 		}
-		return nil
+		return count
+	}
+	workflowFn := func(ctx Context) (int, error) {
+		var missing string
+		if ok, err := GetValue(ctx, "missing", &missing); ok || err != nil {
+			return 0, errors.New("missing value should not be found")
+		}
+		if err := SetValue(ctx, "", 1); err == nil {
+			return 0, errors.New("empty key should be rejected")
+		}
+		if err := SetValue(ctx, "owner", "team-a"); err != nil {
+			return 0, err
+		}
+		if err := SetValue(ctx, "owner", 1); err == nil {
+			return 0, errors.New("value of another type should be rejected")
+		}
+		helper(ctx)
+		_ = Sleep(ctx, time.Minute)
+		helper(ctx)
+		return helper(ctx), nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(childWorkflowFn)
 	env.RegisterWorkflow(workflowFn)
 	env.ExecuteWorkflow(workflowFn)
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
+	var result int
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal(3, result)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_CancelExternalWorkflow() {
-	workflowFn := func(ctx Context) error {
-		// set namespace to be more specific
-		ctx = WithWorkflowNamespace(ctx, "test-namespace")
-		f1 := RequestCancelExternalWorkflow(ctx, "test-workflow-id1", "test-runid1")
-		f2 := RequestCancelExternalWorkflow(ctx, "test-workflow-id2", "test-runid2")
-
-		// cancellation 1 succeed
-		err1 := f1.Get(ctx, nil)
-		if err1 != nil {
-			return err1
+func (s *WorkflowTestSuiteUnitTest) Test_ExecuteOperation() {
+	workflowFn := func(ctx Context) (int, error) {
+		var sum int
+		err := ExecuteOperation(ctx, OperationOptions{Namespace: "service-namespace", WorkflowID: "service"}, "Add", 1, 2).
+			Get(ctx, &sum)
+		if err != nil {
+			return 0, err
 		}
-
-		// cancellation 2 failed
-		err2 := f2.Get(ctx, nil)
-		if err2 == nil {
-			return errors.New("cancellation 2 should fail")
+		err = ExecuteOperation(ctx, OperationOptions{Namespace: "service-namespace", WorkflowID: "service"}, "Sub", 1, 2).
+			Get(ctx, nil)
+		var applicationErr *ApplicationError
+		if !errors.As(err, &applicationErr) || applicationErr.Type() != "OperationNotFound" {
+			return 0, fmt.Errorf("expected OperationNotFound error, got %v", err)
 		}
-
-		return nil
+		return sum, nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
-
-	// cancellation 1 should succeed
-	env.OnRequestCancelExternalWorkflow("test-namespace", "test-workflow-id1", "test-runid1").Return(nil).Once()
-
-	// cancellation 2 should fail
-	env.OnRequestCancelExternalWorkflow("test-namespace", "test-workflow-id2", "test-runid2").Return(
-		func(namespace, workflowID, runID string) error {
-			return errors.New("unknown external workflow")
-		}).Once()
+	env.OnSignalExternalWorkflow("service-namespace", "service", "", operationRequestSignalName, mock.Anything).Return(
+		func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+			request := arg.(operationRequest)
+			response := operationResponse{ID: request.ID}
+			if request.Operation == "Add" {
+				response.Result, _ = converter.GetDefaultDataConverter().ToPayloads(3)
+			} else {
+				response.Error = "unknown operation " + request.Operation
+				response.ErrorType = "OperationNotFound"
+				response.NonRetryable = true
+			}
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(operationResponseSignalName, response)
+			}, time.Minute)
+			return nil
+		}).Twice()
 
 	env.ExecuteWorkflow(workflowFn)
 	env.AssertExpectations(s.T())
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
+	var sum int
+	s.NoError(env.GetWorkflowResult(&sum))
+	s.Equal(3, sum)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_DisconnectedContext() {
-	childWorkflowFn := func(ctx Context) (string, error) {
-		err := NewTimer(ctx, time.Minute*10).Get(ctx, nil)
-		if _, ok := err.(*CanceledError); ok {
-			dCtx, _ := NewDisconnectedContext(ctx)
-			dCtx = WithActivityOptions(dCtx, s.activityOptions)
-			var cleanupResult string
-			err := ExecuteActivity(dCtx, testActivityHello, "cleanup").Get(dCtx, &cleanupResult)
-			return cleanupResult, err
+func (s *WorkflowTestSuiteUnitTest) Test_ExecuteOperation_TimeoutAndCancellation() {
+	options := OperationOptions{WorkflowID: "service", Timeout: time.Hour}
+	workflowFn := func(ctx Context) error {
+		var sum int
+		if err := ExecuteOperation(ctx, options, "Add", 1, 2).Get(ctx, &sum); err != nil {
+			return err
 		}
-
-		// unexpected
-		return "", errors.New("should not reach here")
-	}
-
-	workflowFn := func(ctx Context) (string, error) {
-		cwo := ChildWorkflowOptions{
-			WorkflowRunTimeout:  time.Hour,
-			WaitForCancellation: true,
+		if sum != 3 {
+			return fmt.Errorf("unexpected sum %v", sum)
 		}
-		ctx = WithChildWorkflowOptions(ctx, cwo)
-		childCtx, cancelChild := WithCancel(ctx)
-		childFuture := ExecuteChildWorkflow(childCtx, childWorkflowFn) // execute child workflow which in turn execute another child
-		NewSelector(ctx).AddFuture(childFuture, func(f Future) {
-			s.Fail("f1 should not complete before t1")
-		}).AddFuture(NewTimer(ctx, time.Minute), func(f Future) {
-			cancelChild() // child workflow takes too long, cancel child workflow
-		}).Select(ctx)
 
-		var result string
-		err := childFuture.Get(childCtx, &result)
-		return result, err
+		for _, timeout := range []time.Duration{0, time.Hour} {
+			canceledOptions := options
+			canceledOptions.Timeout = timeout
+			cancelCtx, cancel := WithCancel(ctx)
+			future := ExecuteOperation(cancelCtx, canceledOptions, "Hang")
+			Go(ctx, func(ctx Context) {
+				_ = Sleep(ctx, time.Minute)
+				cancel()
+			})
+			err := future.Get(ctx, nil)
+			var canceledErr *CanceledError
+			if !errors.As(err, &canceledErr) {
+				return fmt.Errorf("expected CanceledError with timeout %v, got %v", timeout, err)
+			}
+		}
+		return nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(childWorkflowFn)
 	env.RegisterWorkflow(workflowFn)
-	env.RegisterActivity(testActivityHello)
+	env.OnSignalExternalWorkflow(mock.Anything, "service", "", operationRequestSignalName, mock.Anything).Return(
+		func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+			request := arg.(operationRequest)
+			if request.Operation == "Add" {
+				response := operationResponse{ID: request.ID}
+				response.Result, _ = converter.GetDefaultDataConverter().ToPayloads(3)
+				env.RegisterDelayedCallback(func() {
+					env.SignalWorkflow(operationResponseSignalName, response)
+				}, time.Minute)
+			}
+			return nil
+		}).Times(3)
+	// Both canceled calls are canceled on the target workflow.
+	var canceledIDs []string
+	env.OnSignalExternalWorkflow(mock.Anything, "service", "", operationCancelSignalName, mock.Anything).Return(
+		func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+			canceledIDs = append(canceledIDs, arg.(operationCancel).ID)
+			return nil
+		}).Times(2)
+	var canceledTimers int
+	env.SetOnTimerCanceledListener(func(timerID string) {
+		canceledTimers++
+	})
 
 	env.ExecuteWorkflow(workflowFn)
-
+	env.AssertExpectations(s.T())
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
-	var workflowResult string
-	err := env.GetWorkflowResult(&workflowResult)
-	s.NoError(err)
-	s.Equal("hello_cleanup", workflowResult)
+	s.Len(canceledIDs, 2)
+	s.NotEqual(canceledIDs[0], canceledIDs[1])
+	// The timeout timers of the answered and of the canceled operation are both canceled.
+	s.Equal(2, canceledTimers)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_WorkflowIDReusePolicy() {
-	workflowFn := func(ctx Context) (string, error) {
-		cwo := ChildWorkflowOptions{
-			WorkflowRunTimeout:    time.Minute,
-			WorkflowID:            "test-child-workflow-id",
-			WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
-		}
-		ctx = WithChildWorkflowOptions(ctx, cwo)
-		var helloWorkflowResult string
-		f := ExecuteChildWorkflow(ctx, testWorkflowHello)
-		err := f.GetChildWorkflowExecution().Get(ctx, nil)
-		s.NoError(err)
-		err = f.Get(ctx, &helloWorkflowResult)
-		s.NoError(err)
-
-		// start child with duplicate workflow id, but with policy that won't allow duplicate
-		f = ExecuteChildWorkflow(ctx, testWorkflowHello)
-		err = f.GetChildWorkflowExecution().Get(ctx, nil)
-		s.Error(err)
-		err = f.Get(ctx, &helloWorkflowResult)
-		s.Error(err)
-
-		// now with policy allow duplicate
-		cwo.WorkflowIDReusePolicy = enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE
-		ctx = WithChildWorkflowOptions(ctx, cwo)
-		f = ExecuteChildWorkflow(ctx, testWorkflowHello)
-		err = f.GetChildWorkflowExecution().Get(ctx, nil)
-		s.NoError(err)
-		err = f.Get(ctx, &helloWorkflowResult)
-		s.NoError(err)
-
-		return helloWorkflowResult, nil
-	}
-
-	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(workflowFn)
-	env.RegisterWorkflow(testWorkflowHello)
-	env.RegisterActivity(testActivityHello)
-	env.ExecuteWorkflow(workflowFn)
-	var actualResult string
-	s.NoError(env.GetWorkflowResult(&actualResult))
-	s.Equal("hello_world", actualResult)
-}
-
-func (s *WorkflowTestSuiteUnitTest) Test_Channel() {
+func (s *WorkflowTestSuiteUnitTest) Test_SetOperationHandler_Canceled() {
 	workflowFn := func(ctx Context) error {
-
-		signalCh := GetSignalChannel(ctx, "test-signal")
-		doneCh := NewBufferedChannel(ctx, 100)
-		selector := NewSelector(ctx)
-
-		selector.AddReceive(signalCh, func(c ReceiveChannel, more bool) {
-		}).AddReceive(doneCh, func(c ReceiveChannel, more bool) {
-			var doneSignal string
-			c.Receive(ctx, &doneSignal)
+		err := SetOperationHandler(ctx, "Hang", func(ctx Context) (int, error) {
+			return 0, Sleep(ctx, time.Hour)
 		})
-
-		fanoutChs := []Channel{NewBufferedChannel(ctx, 100), NewBufferedChannel(ctx, 100)}
-
-		processedCount := 0
-		runningCount := 0
-
-	mainLoop:
-		for {
-			selector.Select(ctx)
-			var signal string
-			if !signalCh.ReceiveAsync(&signal) {
-				if runningCount > 0 {
-					continue mainLoop
-				}
-
-				if processedCount < 4 {
-					continue mainLoop
-				}
-
-				// continue as new
-				return NewContinueAsNewError(ctx, "this-workflow")
-			}
-
-			for i := range fanoutChs {
-				ch := fanoutChs[i]
-				ch.SendAsync(signal)
-				processedCount++
-				runningCount++
-				Go(ctx, func(ctx Context) {
-					doneCh.SendAsync("done")
-					runningCount--
-				})
-			}
+		if err != nil {
+			return err
 		}
+		return Sleep(ctx, 2*time.Hour)
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
-
+	startTime := env.Now()
 	env.RegisterDelayedCallback(func() {
-		env.SignalWorkflow("test-signal", "s1")
-		env.SignalWorkflow("test-signal", "s2")
+		env.SignalWorkflow(operationRequestSignalName, operationRequest{
+			ID:               "call-1",
+			Operation:        "Hang",
+			CallerNamespace:  "caller-namespace",
+			CallerWorkflowID: "caller",
+		})
 	}, time.Minute)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(operationCancelSignalName, operationCancel{ID: "call-1"})
+	}, 2*time.Minute)
+	var response operationResponse
+	env.OnSignalExternalWorkflow("caller-namespace", "caller", "", operationResponseSignalName, mock.Anything).Return(
+		func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+			response = arg.(operationResponse)
+			s.Equal(startTime.Add(2*time.Minute), env.Now())
+			return nil
+		}).Once()
 
 	env.ExecuteWorkflow(workflowFn)
-
+	env.AssertExpectations(s.T())
 	s.True(env.IsWorkflowCompleted())
-	err := env.GetWorkflowError()
-	s.Error(err)
-	var workflowErr *WorkflowExecutionError
-	s.True(errors.As(err, &workflowErr))
-
-	err = errors.Unwrap(workflowErr)
-	var err1 *ContinueAsNewError
-	s.True(errors.As(err, &err1))
+	s.NoError(env.GetWorkflowError())
+	// The handler returns as soon as it is canceled, with the error of its canceled timer.
+	s.Equal("call-1", response.ID)
+	s.Equal("canceled", response.Error)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ContextMisuse() {
+func (s *WorkflowTestSuiteUnitTest) Test_SetOperationHandler() {
 	workflowFn := func(ctx Context) error {
-		ch := NewChannel(ctx)
-
-		Go(ctx, func(shouldUseThisCtx Context) {
-			_ = Sleep(ctx, time.Hour)
-			ch.Send(ctx, "done")
+		err := SetOperationHandler(ctx, "Add", func(ctx Context, a, b int) (int, error) {
+			if err := Sleep(ctx, time.Second); err != nil {
+				return 0, err
+			}
+			return a + b, nil
 		})
-
-		var done string
-		ch.Receive(ctx, &done)
-
-		return nil
+		if err != nil {
+			return err
+		}
+		return Sleep(ctx, time.Hour)
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
-	env.ExecuteWorkflow(workflowFn)
+	input, _ := converter.GetDefaultDataConverter().ToPayloads(1, 2)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(operationRequestSignalName, operationRequest{
+			ID:               "call-1",
+			Operation:        "Add",
+			CallerNamespace:  "caller-namespace",
+			CallerWorkflowID: "caller",
+			Input:            input,
+		})
+	}, time.Minute)
+	var response operationResponse
+	env.OnSignalExternalWorkflow("caller-namespace", "caller", "", operationResponseSignalName, mock.Anything).Return(
+		func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+			response = arg.(operationResponse)
+			return nil
+		}).Once()
 
+	env.ExecuteWorkflow(workflowFn)
+	env.AssertExpectations(s.T())
 	s.True(env.IsWorkflowCompleted())
-	s.Error(env.GetWorkflowError())
-	s.Contains(env.GetWorkflowError().Error(), "block on coroutine which is already blocked")
+	s.NoError(env.GetWorkflowError())
+	s.Equal("call-1", response.ID)
+	s.Empty(response.Error)
+	var sum int
+	s.NoError(converter.GetDefaultDataConverter().FromPayloads(response.Result, &sum))
+	s.Equal(3, sum)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_DrainSignalChannel() {
+func (s *WorkflowTestSuiteUnitTest) Test_NewCallback() {
+	var callbackID string
 	workflowFn := func(ctx Context) (string, error) {
-
-		signalCh := GetSignalChannel(ctx, "test-signal")
-		var s1, s2, s3 string
-		signalCh.Receive(ctx, &s1)
-		if !signalCh.ReceiveAsync(&s2) {
-			return "", errors.New("expected signal")
-		}
-		if signalCh.ReceiveAsync(&s3) {
-			return "", errors.New("unexpected signal")
+		callback := NewCallback(ctx)
+		callbackID = callback.ID()
+		var request CallbackRequest
+		if err := callback.Get(ctx, &request); err != nil {
+			return "", err
 		}
-		return s1 + s2, nil
+		return string(request.Body), nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
-
 	env.RegisterDelayedCallback(func() {
-		env.SignalWorkflowSkippingWorkflowTask("test-signal", "s1")
-		env.SignalWorkflow("test-signal", "s2")
+		env.SignalWorkflow(callbackSignalName, CallbackRequest{CallbackID: "unknown", Body: []byte("ignored")})
+		env.SignalWorkflow(callbackSignalName, CallbackRequest{CallbackID: callbackID, Body: []byte("done")})
 	}, time.Minute)
-
 	env.ExecuteWorkflow(workflowFn)
-
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
-	var result string
-	_ = env.GetWorkflowResult(&result)
-	s.Equal("s1s2", result)
+	var body string
+	s.NoError(env.GetWorkflowResult(&body))
+	s.Equal("done", body)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry() {
-	attempt1Count := 1
-	activityFailedFn := func(ctx context.Context) (string, error) {
-		attempt1Count++
-		return "", NewApplicationError("bad-bug", "", true, nil)
+func (s *WorkflowTestSuiteUnitTest) Test_DurableDeadlines() {
+	startTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiration := startTime.Add(90 * 24 * time.Hour)
+	firstRunFn := func(ctx Context) error {
+		if _, err := SetDeadline(ctx, "expiration", expiration); err != nil {
+			return err
+		}
+		if _, err := SetDeadline(ctx, "removed", expiration); err != nil {
+			return err
+		}
+		RemoveDeadline(ctx, "removed")
+		if err := Sleep(ctx, 30*24*time.Hour); err != nil {
+			return err
+		}
+		return NewContinueAsNewError(ctx, "second-run")
 	}
 
-	attempt2Count := 1
-	activityFn := func(ctx context.Context) (string, error) {
-		attempt2Count++
-		info := GetActivityInfo(ctx)
-		if info.Attempt < 3 {
-			return "", NewApplicationError("bad-luck", "", false, nil)
+	env := s.NewTestWorkflowEnvironment()
+	env.SetStartTime(startTime)
+	env.RegisterWorkflow(firstRunFn)
+	env.ExecuteWorkflow(firstRunFn)
+	var continueAsNewErr *ContinueAsNewError
+	s.True(errors.As(env.GetWorkflowError(), &continueAsNewErr))
+	s.Contains(continueAsNewErr.Header.GetFields(), durableDeadlinesHeaderKey)
+
+	var expiredAt time.Time
+	secondRunFn := func(ctx Context) error {
+		_, _, ok := GetDeadline(ctx, "removed")
+		s.False(ok)
+		deadline, future, ok := GetDeadline(ctx, "expiration")
+		s.True(ok)
+		s.Equal(expiration, deadline.UTC())
+		if err := future.Get(ctx, nil); err != nil {
+			return err
 		}
-		return "retry-done", nil
+		expiredAt = Now(ctx)
+		return nil
 	}
+	env = (&WorkflowTestSuite{header: continueAsNewErr.Header}).NewTestWorkflowEnvironment()
+	env.SetStartTime(startTime.Add(30 * 24 * time.Hour))
+	env.RegisterWorkflowWithOptions(secondRunFn, RegisterWorkflowOptions{Name: "second-run"})
+	env.ExecuteWorkflow("second-run")
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.False(expiredAt.Before(expiration))
+	s.True(expiredAt.Before(expiration.Add(time.Minute)))
+}
 
+func (s *WorkflowTestSuiteUnitTest) Test_BeginRealTime() {
 	workflowFn := func(ctx Context) (string, error) {
-		ao := ActivityOptions{
-			ScheduleToStartTimeout: time.Minute,
-			StartToCloseTimeout:    time.Minute,
-			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:    5,
-				InitialInterval:    time.Second,
-				MaximumInterval:    time.Second * 10,
-				BackoffCoefficient: 2,
-			},
-		}
-		ctx = WithActivityOptions(ctx, ao)
-
-		err := ExecuteActivity(ctx, activityFailedFn).Get(ctx, nil)
-
-		s.Error(err)
-		var activityErr *ActivityError
-		s.True(errors.As(err, &activityErr))
-
-		err = errors.Unwrap(activityErr)
-		var badBug *ApplicationError
-		s.True(errors.As(err, &badBug))
-		s.Equal("bad-bug", badBug.Error())
-
 		var result string
-		err = ExecuteActivity(ctx, activityFn).Get(ctx, &result)
-		if err != nil {
-			return "", err
-		}
+		NewSelector(ctx).
+			AddReceive(GetSignalChannel(ctx, "approval"), func(c ReceiveChannel, more bool) {
+				c.Receive(ctx, &result)
+			}).
+			AddFuture(NewTimer(ctx, time.Hour), func(f Future) {
+				result = "timeout"
+			}).
+			Select(ctx)
 		return result, nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
-	env.RegisterActivity(activityFailedFn)
-	env.RegisterActivity(activityFn)
-
-	// set a workflow timeout timer to test
-	// if the timer will fire during activity retry
-	env.SetWorkflowRunTimeout(10 * time.Second)
+	end := env.BeginRealTime()
+	go func() {
+		defer end()
+		time.Sleep(50 * time.Millisecond)
+		env.SignalWorkflow("approval", "approved")
+	}()
 	env.ExecuteWorkflow(workflowFn)
 
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
 	var result string
 	s.NoError(env.GetWorkflowResult(&result))
-	s.Equal("retry-done", result)
-	s.Equal(2, attempt1Count)
-	s.Equal(4, attempt2Count)
+	s.Equal("approved", result)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry_DefaultRetry() {
-	attemptCount1 := 0
-	activityFn := func(ctx context.Context) (string, error) {
-		attemptCount1++
-		info := GetActivityInfo(ctx)
-		fmt.Printf("attempt %d\n", info.Attempt)
-		if info.Attempt <= 2 {
-			return "", NewApplicationError("bad-luck", "", false, nil)
-		}
-		return "done1", nil
+func (s *WorkflowTestSuiteUnitTest) Test_ExecuteWorkflowByName() {
+	type greeting struct {
+		Name string
 	}
-	attemptCount2 := 0
-	activityFn2 := func(ctx context.Context) (string, error) {
-		attemptCount2++
-		info := GetActivityInfo(ctx)
-		fmt.Printf("attempt %d\n", info.Attempt)
-		if info.Attempt <= 2 {
-			return "", errors.New("some error")
-		}
-		return "done2", nil
+	workflowFn := func(ctx Context, g greeting) (string, error) {
+		return "hello " + g.Name, nil
 	}
+	// A payload encoded by a client in another language.
+	input := &commonpb.Payloads{Payloads: []*commonpb.Payload{{
+		Metadata: map[string][]byte{converter.MetadataEncoding: []byte(converter.MetadataEncodingJSON)},
+		Data:     []byte(`{"Name":"world"}`),
+	}}}
 
-	workflowFn := func(ctx Context) (string, error) {
-		ao := ActivityOptions{
-			ScheduleToStartTimeout: time.Minute,
-			StartToCloseTimeout:    time.Minute,
-			// no retry policy, will use default one.
-		}
-		ctx = WithActivityOptions(ctx, ao)
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflowWithOptions(workflowFn, RegisterWorkflowOptions{Name: "greet"})
+	env.ExecuteWorkflowByName("greet", input)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("hello world", result)
 
-		var result string
-		err := ExecuteActivity(ctx, activityFn).Get(ctx, &result)
-		if err != nil {
-			return "", err
+	env = s.NewTestWorkflowEnvironment()
+	env.RegisterDynamicWorkflow(func(ctx Context, workflowType string, args converter.EncodedValues) (interface{}, error) {
+		var g greeting
+		if err := args.Get(&g); err != nil {
+			return nil, err
 		}
+		return workflowType + " " + g.Name, nil
+	})
+	env.ExecuteWorkflowByName("dsl-greet", input)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("dsl-greet world", result)
+}
 
-		var result2 string
-		ao2 := ActivityOptions{
-			ScheduleToStartTimeout: time.Minute,
-			StartToCloseTimeout:    time.Minute,
-			RetryPolicy:            &RetryPolicy{}, // empty retry policy, will use default values
+func (s *WorkflowTestSuiteUnitTest) Test_WorkerIdentity() {
+	activityFn := func(ctx context.Context) (string, error) {
+		return GetActivityInfo(ctx).WorkerIdentity, nil
+	}
+
+	workflowFn := func(ctx Context) ([]string, error) {
+		identities := []string{GetWorkflowInfo(ctx).WorkerIdentity}
+		var identity string
+		ctx = WithActivityOptions(ctx, ActivityOptions{StartToCloseTimeout: time.Minute})
+		if err := ExecuteActivity(ctx, activityFn).Get(ctx, &identity); err != nil {
+			return nil, err
 		}
-		ctx = WithActivityOptions(ctx, ao2)
-		err = ExecuteActivity(ctx, activityFn2).Get(ctx, &result2)
-		if err != nil {
-			return "", err
+		identities = append(identities, identity)
+		ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{ScheduleToCloseTimeout: time.Minute})
+		if err := ExecuteLocalActivity(ctx, activityFn).Get(ctx, &identity); err != nil {
+			return nil, err
 		}
-
-		return result + result2, nil
+		return append(identities, identity), nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
+	env.SetIdentity("host=node-3,pod=orders-7f9c")
 	env.RegisterWorkflow(workflowFn)
 	env.RegisterActivity(activityFn)
-	env.RegisterActivity(activityFn2)
-
-	env.SetWorkflowRunTimeout(10 * time.Minute)
 	env.ExecuteWorkflow(workflowFn)
 
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
-	var result string
-	s.NoError(env.GetWorkflowResult(&result))
-	s.Equal("done1done2", result)
-	s.Equal(3, attemptCount1)
-	s.Equal(3, attemptCount2)
+	var identities []string
+	s.NoError(env.GetWorkflowResult(&identities))
+	s.Equal([]string{"host=node-3,pod=orders-7f9c", "host=node-3,pod=orders-7f9c", "host=node-3,pod=orders-7f9c"}, identities)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry_NoInitialInterval() {
-	var attempts = 0
+func (s *WorkflowTestSuiteUnitTest) Test_CancelChildWorkflow() {
+	childWorkflowFn := func(ctx Context) error {
+		var err error
+		selector := NewSelector(ctx)
+		timer := NewTimer(ctx, 10*time.Second)
+		selector.AddFuture(timer, func(f Future) {
+			err = f.Get(ctx, nil)
+		}).Select(ctx)
 
-	activityFn := func(ctx context.Context) (bool, error) {
-		info := GetActivityInfo(ctx)
-		if info.Attempt < 2 {
-			attempts++
-			return false, errors.New("first attempt fails")
-		}
-		attempts++
-		return true, nil
+		fmt.Println("####")
+		fmt.Println(err)
+		fmt.Println("####")
+		return err
 	}
 
-	workflowFn := func(ctx Context) (bool, error) {
-		ctx = WithActivityOptions(
-			ctx,
-			ActivityOptions{
-				StartToCloseTimeout:    10 * time.Second,
-				ScheduleToCloseTimeout: 10 * time.Second,
-				RetryPolicy: &RetryPolicy{
-					BackoffCoefficient: 2,
-					MaximumAttempts:    3,
-				},
-			})
-		activityExecution := ExecuteActivity(ctx, activityFn)
-		var result bool
-		err := activityExecution.Get(ctx, &result)
-		println(result)
-		return result, err
-	}
+	workflowFn := func(ctx Context) error {
 
-	env := s.NewTestWorkflowEnvironment()
-	env.RegisterActivity(activityFn)
-	env.ExecuteWorkflow(workflowFn)
-	s.NoError(env.GetWorkflowError())
-	s.True(env.IsWorkflowCompleted())
-	s.Equal(2, attempts)
+		cwo := ChildWorkflowOptions{
+			Namespace:          "test-namespace",
+			WorkflowRunTimeout: time.Minute,
+		}
 
-}
+		childCtx := WithChildWorkflowOptions(ctx, cwo)
+		childCtx, cancel := WithCancel(childCtx)
+		childFuture := ExecuteChildWorkflow(childCtx, childWorkflowFn)
+		_ = Sleep(ctx, 2*time.Second)
+		cancel()
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry_NoRetries() {
-	maxRetryAttempts := int32(1)
-	fakeError := fmt.Errorf("fake network error")
-	activityFailedFn := func(ctx context.Context) (string, error) {
-		info := GetActivityInfo(ctx)
-		if info.Attempt <= maxRetryAttempts {
-			return "", fakeError
+		err := childFuture.Get(childCtx, nil)
+		var childWorkflowErr *ChildWorkflowExecutionError
+		if !errors.As(err, &childWorkflowErr) {
+			return fmt.Errorf("cancel child workflow should receive ChildWorkflowExecutionError, instead got: %v", err)
 		}
-		return "", nil
-	}
 
-	workflowFn := func(ctx Context) (string, error) {
-		ao := ActivityOptions{
-			ScheduleToStartTimeout: time.Minute,
-			StartToCloseTimeout:    time.Minute,
-			RetryPolicy: &RetryPolicy{
-				InitialInterval:    time.Second,
-				MaximumAttempts:    maxRetryAttempts,
-				BackoffCoefficient: 0,
-			},
+		err = errors.Unwrap(childWorkflowErr)
+		var err1 *CanceledError
+		if !errors.As(err, &err1) {
+			return fmt.Errorf("cancel child workflow cause should be CanceledError, instead got: %v", err)
 		}
-		ctx = WithActivityOptions(ctx, ao)
-		err := ExecuteActivity(ctx, activityFailedFn).Get(ctx, nil)
-		s.Error(err)
-		return "", err
+		return nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.SetTestTimeout(time.Hour)
+	env.RegisterWorkflow(childWorkflowFn)
 	env.RegisterWorkflow(workflowFn)
-	env.RegisterActivity(activityFailedFn)
 	env.ExecuteWorkflow(workflowFn)
-
 	s.True(env.IsWorkflowCompleted())
-	err := env.GetWorkflowError()
-	s.Error(err)
-	var workflowErr *WorkflowExecutionError
-	s.True(errors.As(err, &workflowErr))
-	s.True(errors.As(err, &fakeError))
+	s.NoError(env.GetWorkflowError())
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityHeartbeatRetry() {
-	var startedFrom []int
-	activityHeartBeatFn := func(ctx context.Context, firstTaskID, taskCount int) error {
-		i := firstTaskID
-		if HasHeartbeatDetails(ctx) {
-			var lastProcessed int
-			if err := GetHeartbeatDetails(ctx, &lastProcessed); err == nil {
-				i = lastProcessed + 1
-			}
-		}
-
-		startedFrom = append(startedFrom, i)
+func (s *WorkflowTestSuiteUnitTest) Test_CancelExternalWorkflow() {
+	workflowFn := func(ctx Context) error {
+		// set namespace to be more specific
+		ctx = WithWorkflowNamespace(ctx, "test-namespace")
+		f1 := RequestCancelExternalWorkflow(ctx, "test-workflow-id1", "test-runid1")
+		f2 := RequestCancelExternalWorkflow(ctx, "test-workflow-id2", "test-runid2")
 
-		for j := 0; i < firstTaskID+taskCount; i, j = i+1, j+1 {
-			// process task i
-			RecordActivityHeartbeat(ctx, i)
-			if j == 2 && i < firstTaskID+taskCount-1 { // simulate failure after processing 3 tasks
-				return NewApplicationError("bad-luck", "", false, nil)
-			}
+		// cancellation 1 succeed
+		err1 := f1.Get(ctx, nil)
+		if err1 != nil {
+			return err1
 		}
 
-		return nil
-	}
-
-	workflowFn := func(ctx Context) error {
-		ao := ActivityOptions{
-			ScheduleToStartTimeout: time.Minute,
-			StartToCloseTimeout:    time.Minute,
-			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:        3,
-				InitialInterval:        time.Second,
-				MaximumInterval:        time.Second * 10,
-				BackoffCoefficient:     2,
-				NonRetryableErrorTypes: []string{"bad-bug"},
-			},
+		// cancellation 2 failed
+		err2 := f2.Get(ctx, nil)
+		if err2 == nil {
+			return errors.New("cancellation 2 should fail")
 		}
-		ctx = WithActivityOptions(ctx, ao)
 
-		err := ExecuteActivity(ctx, activityHeartBeatFn, 0, 9).Get(ctx, nil)
-		if err != nil {
-			return err
-		}
 		return nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.SetTestTimeout(time.Hour)
 	env.RegisterWorkflow(workflowFn)
-	env.RegisterActivity(activityHeartBeatFn)
-	env.ExecuteWorkflow(workflowFn)
 
+	// cancellation 1 should succeed
+	env.OnRequestCancelExternalWorkflow("test-namespace", "test-workflow-id1", "test-runid1").Return(nil).Once()
+
+	// cancellation 2 should fail
+	env.OnRequestCancelExternalWorkflow("test-namespace", "test-workflow-id2", "test-runid2").Return(
+		func(namespace, workflowID, runID string) error {
+			return errors.New("unknown external workflow")
+		}).Once()
+
+	env.ExecuteWorkflow(workflowFn)
+	env.AssertExpectations(s.T())
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
-	s.Equal(3, len(startedFrom))
-	s.Equal([]int{0, 3, 6}, startedFrom)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_LocalActivityRetry() {
-
-	localActivityFn := func(ctx context.Context) (int32, error) {
-		info := GetActivityInfo(ctx)
-		if info.Attempt < 3 {
-			return int32(-1), NewApplicationError("bad-luck", "", false, nil)
+func (s *WorkflowTestSuiteUnitTest) Test_ExternalWorkflowHandle() {
+	signalName := "test-signal-name"
+	signalData := "test-signal-data"
+	workflowFn := func(ctx Context) error {
+		handle1 := GetExternalWorkflowHandle(WithWorkflowNamespace(ctx, "test-namespace"), "test-workflow-id1", "test-runid1")
+		s.Equal("test-workflow-id1", handle1.GetID())
+		s.Equal("test-runid1", handle1.GetRunID())
+		s.Equal("test-namespace", handle1.GetNamespace())
+		handle2 := GetExternalWorkflowHandle(ctx, "test-workflow-id2", "")
+		s.Equal(defaultTestNamespace, handle2.GetNamespace())
+
+		// the namespace of the handle takes precedence over the one of the context passed to the call
+		if err := handle1.Signal(ctx, signalName, signalData).Get(ctx, nil); err != nil {
+			return err
 		}
-		return info.Attempt, nil
-	}
-
-	var localActivityDuration time.Duration
-	workflowFn := func(ctx Context) (int32, error) {
-		lao := LocalActivityOptions{
-			ScheduleToCloseTimeout: time.Minute,
-			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:        3,
-				InitialInterval:        time.Second,
-				MaximumInterval:        time.Second * 10,
-				BackoffCoefficient:     2,
-				NonRetryableErrorTypes: []string{"bad-bug"},
-			},
+		if err := handle2.Signal(WithWorkflowNamespace(ctx, "other-namespace"), signalName, signalData).Get(ctx, nil); err != nil {
+			return err
 		}
-		ctx = WithLocalActivityOptions(ctx, lao)
-
-		var result int32
-		startTime := Now(ctx)
-		err := ExecuteLocalActivity(ctx, localActivityFn).Get(ctx, &result)
-		// local activity completes in 3 attempts
-		// 1st attempt executes immediately
-		// 2nd attempt backoff by 1s (InitialInterval)
-		// 3rd attempt backoff by 2s (BackoffCoefficient: 2)
-		localActivityDuration = Now(ctx).Sub(startTime)
-		if err != nil {
-			return int32(-1), err
+		if err := handle1.RequestCancel(ctx).Get(ctx, nil); err != nil {
+			return err
 		}
-		return result, nil
+		return handle2.RequestCancel(ctx).Get(ctx, nil)
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
-	env.ExecuteWorkflow(workflowFn)
+	env.OnSignalExternalWorkflow("test-namespace", "test-workflow-id1", "test-runid1", signalName, signalData).Return(nil).Once()
+	env.OnSignalExternalWorkflow(defaultTestNamespace, "test-workflow-id2", "", signalName, signalData).Return(nil).Once()
+	env.OnRequestCancelExternalWorkflow("test-namespace", "test-workflow-id1", "test-runid1").Return(nil).Once()
+	env.OnRequestCancelExternalWorkflow(defaultTestNamespace, "test-workflow-id2", "").Return(nil).Once()
 
+	env.ExecuteWorkflow(workflowFn)
+	env.AssertExpectations(s.T())
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
-	var result int32
-	s.NoError(env.GetWorkflowResult(&result))
-	s.Equal(int32(3), result)
-	s.Equal(3*time.Second, localActivityDuration)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_LocalActivityRetry_MaxAttempts_Respected() {
-	const maxAttempts = 5
+func (s *WorkflowTestSuiteUnitTest) Test_DisconnectedContext() {
+	childWorkflowFn := func(ctx Context) (string, error) {
+		err := NewTimer(ctx, time.Minute*10).Get(ctx, nil)
+		if _, ok := err.(*CanceledError); ok {
+			dCtx, _ := NewDisconnectedContext(ctx)
+			dCtx = WithActivityOptions(dCtx, s.activityOptions)
+			var cleanupResult string
+			err := ExecuteActivity(dCtx, testActivityHello, "cleanup").Get(dCtx, &cleanupResult)
+			return cleanupResult, err
+		}
 
-	timesRan := 0
-	localActivityFn := func(ctx context.Context) (int32, error) {
-		timesRan++
-		return int32(-1), NewApplicationError("i always fail", "", false, nil)
+		// unexpected
+		return "", errors.New("should not reach here")
 	}
 
-	workflowFn := func(ctx Context) (int32, error) {
-		lao := LocalActivityOptions{
-			ScheduleToCloseTimeout: time.Minute,
-			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:    maxAttempts,
-				InitialInterval:    time.Second,
-				MaximumInterval:    time.Second * 10,
-				BackoffCoefficient: 2,
-			},
+	workflowFn := func(ctx Context) (string, error) {
+		cwo := ChildWorkflowOptions{
+			WorkflowRunTimeout:  time.Hour,
+			WaitForCancellation: true,
 		}
-		ctx = WithLocalActivityOptions(ctx, lao)
+		ctx = WithChildWorkflowOptions(ctx, cwo)
+		childCtx, cancelChild := WithCancel(ctx)
+		childFuture := ExecuteChildWorkflow(childCtx, childWorkflowFn) // execute child workflow which in turn execute another child
+		NewSelector(ctx).AddFuture(childFuture, func(f Future) {
+			s.Fail("f1 should not complete before t1")
+		}).AddFuture(NewTimer(ctx, time.Minute), func(f Future) {
+			cancelChild() // child workflow takes too long, cancel child workflow
+		}).Select(ctx)
 
-		var result int32
-		err := ExecuteLocalActivity(ctx, localActivityFn).Get(ctx, &result)
-		if err != nil {
-			return int32(-1), err
-		}
-		return result, nil
+		var result string
+		err := childFuture.Get(childCtx, &result)
+		return result, err
 	}
 
 	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(childWorkflowFn)
 	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(testActivityHello)
+
 	env.ExecuteWorkflow(workflowFn)
 
 	s.True(env.IsWorkflowCompleted())
-	s.Error(env.GetWorkflowError())
-	var result int32
-	s.Error(env.GetWorkflowResult(&result))
-	s.Equal(maxAttempts, timesRan)
+	s.NoError(env.GetWorkflowError())
+	var workflowResult string
+	err := env.GetWorkflowResult(&workflowResult)
+	s.NoError(err)
+	s.Equal("hello_cleanup", workflowResult)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_LocalActivityRetryOnCancel() {
-	attempts := 1
-	localActivityFn := func(ctx context.Context) (int32, error) {
-		attempts++
-		info := GetActivityInfo(ctx)
-		if info.Attempt < 3 {
-			return int32(-1), NewCanceledError("details")
+func (s *WorkflowTestSuiteUnitTest) Test_WorkflowIDReusePolicy() {
+	workflowFn := func(ctx Context) (string, error) {
+		cwo := ChildWorkflowOptions{
+			WorkflowRunTimeout:    time.Minute,
+			WorkflowID:            "test-child-workflow-id",
+			WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
 		}
-		return info.Attempt, nil
-	}
+		ctx = WithChildWorkflowOptions(ctx, cwo)
+		var helloWorkflowResult string
+		f := ExecuteChildWorkflow(ctx, testWorkflowHello)
+		err := f.GetChildWorkflowExecution().Get(ctx, nil)
+		s.NoError(err)
+		err = f.Get(ctx, &helloWorkflowResult)
+		s.NoError(err)
 
-	workflowFn := func(ctx Context) (int32, error) {
-		lao := LocalActivityOptions{
-			ScheduleToCloseTimeout: time.Minute,
-			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:        3,
-				InitialInterval:        time.Second,
-				MaximumInterval:        time.Second * 10,
-				BackoffCoefficient:     2,
-				NonRetryableErrorTypes: []string{"bad-bug"},
-			},
-		}
-		ctx = WithLocalActivityOptions(ctx, lao)
+		// start child with duplicate workflow id, but with policy that won't allow duplicate
+		f = ExecuteChildWorkflow(ctx, testWorkflowHello)
+		err = f.GetChildWorkflowExecution().Get(ctx, nil)
+		s.Error(err)
+		err = f.Get(ctx, &helloWorkflowResult)
+		s.Error(err)
 
-		var result int32
-		err := ExecuteLocalActivity(ctx, localActivityFn).Get(ctx, &result)
-		if err != nil {
-			return int32(-1), err
-		}
-		return result, nil
+		// now with policy allow duplicate
+		cwo.WorkflowIDReusePolicy = enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE
+		ctx = WithChildWorkflowOptions(ctx, cwo)
+		f = ExecuteChildWorkflow(ctx, testWorkflowHello)
+		err = f.GetChildWorkflowExecution().Get(ctx, nil)
+		s.NoError(err)
+		err = f.Get(ctx, &helloWorkflowResult)
+		s.NoError(err)
+
+		return helloWorkflowResult, nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
+	env.RegisterWorkflow(testWorkflowHello)
+	env.RegisterActivity(testActivityHello)
 	env.ExecuteWorkflow(workflowFn)
-
-	s.True(env.IsWorkflowCompleted())
-	s.Error(env.GetWorkflowError())
-	s.True(IsCanceledError(env.GetWorkflowError()))
-	s.Equal(2, attempts)
+	var actualResult string
+	s.NoError(env.GetWorkflowResult(&actualResult))
+	s.Equal("hello_world", actualResult)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetryOnCancel() {
-	workflowFn := func(ctx Context) (int32, error) {
-		ao := ActivityOptions{
-			ScheduleToStartTimeout: time.Minute,
-			StartToCloseTimeout:    time.Minute,
-			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:        3,
-				InitialInterval:        time.Second,
-				MaximumInterval:        time.Second * 10,
-				BackoffCoefficient:     2,
-				NonRetryableErrorTypes: []string{"bad-bug"},
-			},
-		}
-		ctx = WithActivityOptions(ctx, ao)
+func (s *WorkflowTestSuiteUnitTest) Test_Channel() {
+	workflowFn := func(ctx Context) error {
 
-		var result int32
-		err := ExecuteActivity(ctx, testActivityCanceled).Get(ctx, &result)
-		if err != nil {
-			return int32(-1), err
+		signalCh := GetSignalChannel(ctx, "test-signal")
+		doneCh := NewBufferedChannel(ctx, 100)
+		selector := NewSelector(ctx)
+
+		selector.AddReceive(signalCh, func(c ReceiveChannel, more bool) {
+		}).AddReceive(doneCh, func(c ReceiveChannel, more bool) {
+			var doneSignal string
+			c.Receive(ctx, &doneSignal)
+		})
+
+		fanoutChs := []Channel{NewBufferedChannel(ctx, 100), NewBufferedChannel(ctx, 100)}
+
+		processedCount := 0
+		runningCount := 0
+
+	mainLoop:
+		for {
+			selector.Select(ctx)
+			var signal string
+			if !signalCh.ReceiveAsync(&signal) {
+				if runningCount > 0 {
+					continue mainLoop
+				}
+
+				if processedCount < 4 {
+					continue mainLoop
+				}
+
+				// continue as new
+				return NewContinueAsNewError(ctx, "this-workflow")
+			}
+
+			for i := range fanoutChs {
+				ch := fanoutChs[i]
+				ch.SendAsync(signal)
+				processedCount++
+				runningCount++
+				Go(ctx, func(ctx Context) {
+					doneCh.SendAsync("done")
+					runningCount--
+				})
+			}
 		}
-		return result, nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
-	env.RegisterActivity(testActivityCanceled)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("test-signal", "s1")
+		env.SignalWorkflow("test-signal", "s2")
+	}, time.Minute)
 
 	env.ExecuteWorkflow(workflowFn)
 
 	s.True(env.IsWorkflowCompleted())
-	s.Error(env.GetWorkflowError())
-	s.True(IsCanceledError(env.GetWorkflowError()))
+	err := env.GetWorkflowError()
+	s.Error(err)
+	var workflowErr *WorkflowExecutionError
+	s.True(errors.As(err, &workflowErr))
+
+	err = errors.Unwrap(workflowErr)
+	var err1 *ContinueAsNewError
+	s.True(errors.As(err, &err1))
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ChildWorkflowRetry() {
+func (s *WorkflowTestSuiteUnitTest) Test_ContextMisuse() {
+	workflowFn := func(ctx Context) error {
+		ch := NewChannel(ctx)
 
-	childWorkflowFn := func(ctx Context) (string, error) {
-		info := GetWorkflowInfo(ctx)
-		if info.Attempt < 3 {
-			return "", NewApplicationError("bad-luck", "", false, nil)
-		}
-		return "retry-done", nil
+		Go(ctx, func(shouldUseThisCtx Context) {
+			_ = Sleep(ctx, time.Hour)
+			ch.Send(ctx, "done")
+		})
+
+		var done string
+		ch.Receive(ctx, &done)
+
+		return nil
 	}
 
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.Error(env.GetWorkflowError())
+	s.Contains(env.GetWorkflowError().Error(), "block on coroutine which is already blocked")
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_DrainSignalChannel() {
 	workflowFn := func(ctx Context) (string, error) {
-		cwo := ChildWorkflowOptions{
-			WorkflowRunTimeout: time.Minute,
-			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:        3,
-				InitialInterval:        time.Second,
-				MaximumInterval:        time.Second * 10,
-				BackoffCoefficient:     2,
-				NonRetryableErrorTypes: []string{"bad-bug"},
-			},
+
+		signalCh := GetSignalChannel(ctx, "test-signal")
+		var s1, s2, s3 string
+		signalCh.Receive(ctx, &s1)
+		if !signalCh.ReceiveAsync(&s2) {
+			return "", errors.New("expected signal")
 		}
-		ctx = WithChildWorkflowOptions(ctx, cwo)
-		var childResult string
-		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, &childResult)
-		if err != nil {
-			return "", err
+		if signalCh.ReceiveAsync(&s3) {
+			return "", errors.New("unexpected signal")
 		}
-
-		return childResult, nil
+		return s1 + s2, nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(childWorkflowFn)
 	env.RegisterWorkflow(workflowFn)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflowSkippingWorkflowTask("test-signal", "s1")
+		env.SignalWorkflow("test-signal", "s2")
+	}, time.Minute)
+
 	env.ExecuteWorkflow(workflowFn)
 
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
 	var result string
-	s.NoError(env.GetWorkflowResult(&result))
-	s.Equal("retry-done", result)
+	_ = env.GetWorkflowResult(&result)
+	s.Equal("s1s2", result)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_SignalChildWorkflowRetry() {
-	childWorkflowFn := func(ctx Context) (string, error) {
-		info := GetWorkflowInfo(ctx)
-		if info.Attempt < 3 {
-			return "", NewApplicationError("bad-luck", "", false, nil)
-		}
-
-		ch := GetSignalChannel(ctx, "test-signal-name")
-		timeout := NewTimer(ctx, time.Second*3)
-		s := NewSelector(ctx)
-		var signal string
-		s.AddFuture(timeout, func(f Future) {
-			signal = "timeout"
-		}).AddReceive(ch, func(c ReceiveChannel, more bool) {
-			c.Receive(ctx, &signal)
-		}).Select(ctx)
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry() {
+	attempt1Count := 1
+	activityFailedFn := func(ctx context.Context) (string, error) {
+		attempt1Count++
+		return "", NewApplicationError("bad-bug", "", true, nil)
+	}
 
-		return signal, nil
+	attempt2Count := 1
+	activityFn := func(ctx context.Context) (string, error) {
+		attempt2Count++
+		info := GetActivityInfo(ctx)
+		if info.Attempt < 3 {
+			return "", NewApplicationError("bad-luck", "", false, nil)
+		}
+		return "retry-done", nil
 	}
 
 	workflowFn := func(ctx Context) (string, error) {
-		cwo := ChildWorkflowOptions{
-			WorkflowID:         "test-retry-signal-child-workflow",
-			WorkflowRunTimeout: time.Minute,
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
 			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:        3,
-				InitialInterval:        time.Second * 3,
-				MaximumInterval:        time.Second * 3,
-				BackoffCoefficient:     1,
-				NonRetryableErrorTypes: []string{"bad-bug"},
+				MaximumAttempts:    5,
+				InitialInterval:    time.Second,
+				MaximumInterval:    time.Second * 10,
+				BackoffCoefficient: 2,
 			},
 		}
-		ctx = WithChildWorkflowOptions(ctx, cwo)
-		var childResult string
-		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, &childResult)
+		ctx = WithActivityOptions(ctx, ao)
+
+		err := ExecuteActivity(ctx, activityFailedFn).Get(ctx, nil)
+
+		s.Error(err)
+		var activityErr *ActivityError
+		s.True(errors.As(err, &activityErr))
+
+		err = errors.Unwrap(activityErr)
+		var badBug *ApplicationError
+		s.True(errors.As(err, &badBug))
+		s.Equal("bad-bug", badBug.Error())
+
+		var result string
+		err = ExecuteActivity(ctx, activityFn).Get(ctx, &result)
 		if err != nil {
 			return "", err
 		}
-
-		return childResult, nil
+		return result, nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(childWorkflowFn)
 	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFailedFn)
+	env.RegisterActivity(activityFn)
 
-	env.RegisterDelayedCallback(func() {
-		_ = env.SignalWorkflowByID("test-retry-signal-child-workflow", "test-signal-name", "test-signal-data")
-	}, time.Second*7 /* after 2nd attempt failed, but before 3rd attempt starts */)
-
+	// set a workflow timeout timer to test
+	// if the timer will fire during activity retry
+	env.SetWorkflowRunTimeout(10 * time.Second)
 	env.ExecuteWorkflow(workflowFn)
 
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
 	var result string
 	s.NoError(env.GetWorkflowResult(&result))
-	s.Equal("test-signal-data", result)
+	s.Equal("retry-done", result)
+	s.Equal(2, attempt1Count)
+	s.Equal(4, attempt2Count)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_TestWorkflowTimeoutInBusyLoop() {
-	neverEndingWorkflow := func(ctx Context) error {
-		for {
-			_ = Sleep(ctx, time.Hour)
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetryTaskQueue() {
+	var taskQueues []string
+	var attempts []int32
+	var progress []int
+	activityFn := func(ctx context.Context) (string, error) {
+		info := GetActivityInfo(ctx)
+		taskQueues = append(taskQueues, info.TaskQueue)
+		attempts = append(attempts, info.Attempt)
+		var lastProgress int
+		if HasHeartbeatDetails(ctx) {
+			s.NoError(GetHeartbeatDetails(ctx, &lastProgress))
+		}
+		progress = append(progress, lastProgress)
+		switch len(taskQueues) {
+		case 1:
+			// The heartbeat details of an attempt which timed out are passed to the next attempts.
+			return "", NewTimeoutError("heartbeat timeout", enumspb.TIMEOUT_TYPE_HEARTBEAT, nil, 10)
+		case 2:
+			return "", NewApplicationError("bad-luck", "", false, nil)
 		}
+		return "retry-done", nil
+	}
+
+	workflowFn := func(ctx Context) (string, error) {
+		ao := ActivityOptions{
+			TaskQueue:           "primary-tq",
+			StartToCloseTimeout: time.Minute,
+			RetryTaskQueue:      "retry-tq",
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:    5,
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 2,
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+
+		var result string
+		err := ExecuteActivity(ctx, activityFn).Get(ctx, &result)
+		return result, err
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.SetWorkflowRunTimeout((time.Hour * 10) + time.Minute)
-	timerFiredCount := 0
-	env.SetOnTimerFiredListener(func(timerID string) {
-		timerFiredCount++
-	})
-	env.RegisterWorkflow(neverEndingWorkflow)
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
 
-	env.ExecuteWorkflow(neverEndingWorkflow)
-	s.Equal(10, timerFiredCount)
-	err := env.GetWorkflowError()
-	s.Error(err)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("retry-done", result)
+	s.Equal([]string{"primary-tq", "retry-tq", "retry-tq"}, taskQueues)
+	s.Equal([]int32{1, 2, 3}, attempts)
+	s.Equal([]int{0, 10, 10}, progress)
+}
 
-	var workflowErr *WorkflowExecutionError
-	s.True(errors.As(err, &workflowErr))
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetryTimeoutScaling() {
+	var startToCloseTimeouts []time.Duration
+	var attempts []int32
+	activityFn := func(ctx context.Context) error {
+		info := GetActivityInfo(ctx)
+		startToCloseTimeouts = append(startToCloseTimeouts, info.Deadline.Sub(info.StartedTime))
+		attempts = append(attempts, info.Attempt)
+		return NewApplicationError("bad-luck", "", false, nil)
+	}
 
-	err = errors.Unwrap(workflowErr)
-	var err1 *TimeoutError
-	s.True(errors.As(err, &err1))
+	workflowFn := func(ctx Context) error {
+		ao := ActivityOptions{
+			ScheduleToCloseTimeout: time.Hour,
+			StartToCloseTimeout:    time.Minute,
+			RetryPolicy: &RetryPolicy{
+				InitialInterval:                time.Second,
+				BackoffCoefficient:             2,
+				StartToCloseTimeoutCoefficient: 2,
+				MaximumAttemptsDuration:        10 * time.Second,
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		s.Equal(ao.RetryPolicy, GetActivityOptions(ctx).RetryPolicy)
+
+		return ExecuteActivity(ctx, activityFn).Get(ctx, nil)
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	var applicationErr *ApplicationError
+	s.True(errors.As(env.GetWorkflowError(), &applicationErr))
+	s.Equal("bad-luck", applicationErr.Error())
+	// The retries are scheduled after 1s, 3s and 7s, the next one after 15s would exceed MaximumAttemptsDuration.
+	s.Equal([]time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}, startToCloseTimeouts)
+	s.Equal([]int32{1, 2, 3, 4}, attempts)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_TestChildWorkflowTimeout() {
-	childWorkflowFn := func(ctx Context) error {
-		_ = Sleep(ctx, time.Hour*5)
-		return nil
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetryPredicate() {
+	var attempts []int32
+	activityFn := func(ctx context.Context) error {
+		attempt := GetActivityInfo(ctx).Attempt
+		attempts = append(attempts, attempt)
+		if attempt == 1 {
+			return NewApplicationError("throttled", "", false, nil, "retry")
+		}
+		return NewApplicationError("rejected", "", false, nil, "permanent")
 	}
 
 	workflowFn := func(ctx Context) error {
-		cwo := ChildWorkflowOptions{
-			WorkflowRunTimeout: time.Hour * 3, // less than 5h that child workflow would take.
+		ao := ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:    5,
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 2,
+			},
 		}
-		ctx = WithChildWorkflowOptions(ctx, cwo)
-		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, nil)
+		ctx = WithActivityOptions(ctx, ao)
+		return ExecuteActivity(ctx, activityFn).Get(ctx, nil)
+	}
+
+	var predicateAttempts []int
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(WorkerOptions{ActivityRetryPredicate: func(err error, attempt int) bool {
+		predicateAttempts = append(predicateAttempts, attempt)
+		var applicationErr *ApplicationError
+		var reason string
+		return !errors.As(err, &applicationErr) || applicationErr.Details(&reason) != nil || reason != "permanent"
+	}})
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	var applicationErr *ApplicationError
+	s.True(errors.As(env.GetWorkflowError(), &applicationErr))
+	s.Equal("rejected", applicationErr.Error())
+	s.True(applicationErr.NonRetryable())
+	s.Equal([]int32{1, 2}, attempts)
+	s.Equal([]int{1, 2}, predicateAttempts)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry_DefaultRetry() {
+	attemptCount1 := 0
+	activityFn := func(ctx context.Context) (string, error) {
+		attemptCount1++
+		info := GetActivityInfo(ctx)
+		fmt.Printf("attempt %d\n", info.Attempt)
+		if info.Attempt <= 2 {
+			return "", NewApplicationError("bad-luck", "", false, nil)
+		}
+		return "done1", nil
+	}
+	attemptCount2 := 0
+	activityFn2 := func(ctx context.Context) (string, error) {
+		attemptCount2++
+		info := GetActivityInfo(ctx)
+		fmt.Printf("attempt %d\n", info.Attempt)
+		if info.Attempt <= 2 {
+			return "", errors.New("some error")
+		}
+		return "done2", nil
+	}
+
+	workflowFn := func(ctx Context) (string, error) {
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			// no retry policy, will use default one.
+		}
+		ctx = WithActivityOptions(ctx, ao)
+
+		var result string
+		err := ExecuteActivity(ctx, activityFn).Get(ctx, &result)
+		if err != nil {
+			return "", err
+		}
+
+		var result2 string
+		ao2 := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			RetryPolicy:            &RetryPolicy{}, // empty retry policy, will use default values
+		}
+		ctx = WithActivityOptions(ctx, ao2)
+		err = ExecuteActivity(ctx, activityFn2).Get(ctx, &result2)
+		if err != nil {
+			return "", err
+		}
+
+		return result + result2, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.RegisterActivity(activityFn2)
+
+	env.SetWorkflowRunTimeout(10 * time.Minute)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("done1done2", result)
+	s.Equal(3, attemptCount1)
+	s.Equal(3, attemptCount2)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry_NoInitialInterval() {
+	var attempts = 0
+
+	activityFn := func(ctx context.Context) (bool, error) {
+		info := GetActivityInfo(ctx)
+		if info.Attempt < 2 {
+			attempts++
+			return false, errors.New("first attempt fails")
+		}
+		attempts++
+		return true, nil
+	}
+
+	workflowFn := func(ctx Context) (bool, error) {
+		ctx = WithActivityOptions(
+			ctx,
+			ActivityOptions{
+				StartToCloseTimeout:    10 * time.Second,
+				ScheduleToCloseTimeout: 10 * time.Second,
+				RetryPolicy: &RetryPolicy{
+					BackoffCoefficient: 2,
+					MaximumAttempts:    3,
+				},
+			})
+		activityExecution := ExecuteActivity(ctx, activityFn)
+		var result bool
+		err := activityExecution.Get(ctx, &result)
+		println(result)
+		return result, err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.NoError(env.GetWorkflowError())
+	s.True(env.IsWorkflowCompleted())
+	s.Equal(2, attempts)
+
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetry_NoRetries() {
+	maxRetryAttempts := int32(1)
+	fakeError := fmt.Errorf("fake network error")
+	activityFailedFn := func(ctx context.Context) (string, error) {
+		info := GetActivityInfo(ctx)
+		if info.Attempt <= maxRetryAttempts {
+			return "", fakeError
+		}
+		return "", nil
+	}
+
+	workflowFn := func(ctx Context) (string, error) {
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			RetryPolicy: &RetryPolicy{
+				InitialInterval:    time.Second,
+				MaximumAttempts:    maxRetryAttempts,
+				BackoffCoefficient: 0,
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		err := ExecuteActivity(ctx, activityFailedFn).Get(ctx, nil)
+		s.Error(err)
+		return "", err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.SetTestTimeout(time.Hour)
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFailedFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
+	s.Error(err)
+	var workflowErr *WorkflowExecutionError
+	s.True(errors.As(err, &workflowErr))
+	s.True(errors.As(err, &fakeError))
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityHeartbeatRetry() {
+	var startedFrom []int
+	activityHeartBeatFn := func(ctx context.Context, firstTaskID, taskCount int) error {
+		i := firstTaskID
+		if HasHeartbeatDetails(ctx) {
+			var lastProcessed int
+			if err := GetHeartbeatDetails(ctx, &lastProcessed); err == nil {
+				i = lastProcessed + 1
+			}
+		}
+
+		startedFrom = append(startedFrom, i)
+
+		for j := 0; i < firstTaskID+taskCount; i, j = i+1, j+1 {
+			// process task i
+			RecordActivityHeartbeat(ctx, i)
+			if j == 2 && i < firstTaskID+taskCount-1 { // simulate failure after processing 3 tasks
+				return NewApplicationError("bad-luck", "", false, nil)
+			}
+		}
+
+		return nil
+	}
+
+	workflowFn := func(ctx Context) error {
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:        3,
+				InitialInterval:        time.Second,
+				MaximumInterval:        time.Second * 10,
+				BackoffCoefficient:     2,
+				NonRetryableErrorTypes: []string{"bad-bug"},
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+
+		err := ExecuteActivity(ctx, activityHeartBeatFn, 0, 9).Get(ctx, nil)
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.SetTestTimeout(time.Hour)
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityHeartBeatFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.Equal(3, len(startedFrom))
+	s.Equal([]int{0, 3, 6}, startedFrom)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetryAttemptInfo() {
+	var attempts []int32
+	var hasDetails []bool
+	activityFn := func(ctx context.Context) error {
+		info := GetActivityInfo(ctx)
+		s.False(info.CurrentAttemptScheduledTime.IsZero())
+		s.False(info.CurrentAttemptScheduledTime.Before(info.ScheduledTime))
+		attempts = append(attempts, info.Attempt)
+		hasDetails = append(hasDetails, HasHeartbeatDetails(ctx))
+		if !HasHeartbeatDetails(ctx) {
+			var progress int
+			s.Equal(ErrNoData, GetHeartbeatDetails(ctx, &progress))
+		}
+		if info.Attempt == 2 {
+			RecordActivityHeartbeat(ctx, 1)
+		}
+		if info.Attempt < 3 {
+			return NewApplicationError("bad-luck", "", false, nil)
+		}
+		return nil
+	}
+
+	workflowFn := func(ctx Context) error {
+		ao := ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:    3,
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 1,
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		return ExecuteActivity(ctx, activityFn).Get(ctx, nil)
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.Equal([]int32{1, 2, 3}, attempts)
+	s.Equal([]bool{false, false, true}, hasDetails)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_LocalActivityRetry() {
+
+	localActivityFn := func(ctx context.Context) (int32, error) {
+		info := GetActivityInfo(ctx)
+		if info.Attempt < 3 {
+			return int32(-1), NewApplicationError("bad-luck", "", false, nil)
+		}
+		return info.Attempt, nil
+	}
+
+	var localActivityDuration time.Duration
+	workflowFn := func(ctx Context) (int32, error) {
+		lao := LocalActivityOptions{
+			ScheduleToCloseTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:        3,
+				InitialInterval:        time.Second,
+				MaximumInterval:        time.Second * 10,
+				BackoffCoefficient:     2,
+				NonRetryableErrorTypes: []string{"bad-bug"},
+			},
+		}
+		ctx = WithLocalActivityOptions(ctx, lao)
+
+		var result int32
+		startTime := Now(ctx)
+		err := ExecuteLocalActivity(ctx, localActivityFn).Get(ctx, &result)
+		// local activity completes in 3 attempts
+		// 1st attempt executes immediately
+		// 2nd attempt backoff by 1s (InitialInterval)
+		// 3rd attempt backoff by 2s (BackoffCoefficient: 2)
+		localActivityDuration = Now(ctx).Sub(startTime)
+		if err != nil {
+			return int32(-1), err
+		}
+		return result, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result int32
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal(int32(3), result)
+	s.Equal(3*time.Second, localActivityDuration)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_LocalActivityRetry_MaxAttempts_Respected() {
+	const maxAttempts = 5
+
+	timesRan := 0
+	localActivityFn := func(ctx context.Context) (int32, error) {
+		timesRan++
+		return int32(-1), NewApplicationError("i always fail", "", false, nil)
+	}
+
+	workflowFn := func(ctx Context) (int32, error) {
+		lao := LocalActivityOptions{
+			ScheduleToCloseTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:    maxAttempts,
+				InitialInterval:    time.Second,
+				MaximumInterval:    time.Second * 10,
+				BackoffCoefficient: 2,
+			},
+		}
+		ctx = WithLocalActivityOptions(ctx, lao)
+
+		var result int32
+		err := ExecuteLocalActivity(ctx, localActivityFn).Get(ctx, &result)
+		if err != nil {
+			return int32(-1), err
+		}
+		return result, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.Error(env.GetWorkflowError())
+	var result int32
+	s.Error(env.GetWorkflowResult(&result))
+	s.Equal(maxAttempts, timesRan)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_LocalActivityRetryOnCancel() {
+	attempts := 1
+	localActivityFn := func(ctx context.Context) (int32, error) {
+		attempts++
+		info := GetActivityInfo(ctx)
+		if info.Attempt < 3 {
+			return int32(-1), NewCanceledError("details")
+		}
+		return info.Attempt, nil
+	}
+
+	workflowFn := func(ctx Context) (int32, error) {
+		lao := LocalActivityOptions{
+			ScheduleToCloseTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:        3,
+				InitialInterval:        time.Second,
+				MaximumInterval:        time.Second * 10,
+				BackoffCoefficient:     2,
+				NonRetryableErrorTypes: []string{"bad-bug"},
+			},
+		}
+		ctx = WithLocalActivityOptions(ctx, lao)
+
+		var result int32
+		err := ExecuteLocalActivity(ctx, localActivityFn).Get(ctx, &result)
+		if err != nil {
+			return int32(-1), err
+		}
+		return result, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.Error(env.GetWorkflowError())
+	s.True(IsCanceledError(env.GetWorkflowError()))
+	s.Equal(2, attempts)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityRetryOnCancel() {
+	workflowFn := func(ctx Context) (int32, error) {
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:        3,
+				InitialInterval:        time.Second,
+				MaximumInterval:        time.Second * 10,
+				BackoffCoefficient:     2,
+				NonRetryableErrorTypes: []string{"bad-bug"},
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+
+		var result int32
+		err := ExecuteActivity(ctx, testActivityCanceled).Get(ctx, &result)
+		if err != nil {
+			return int32(-1), err
+		}
+		return result, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(testActivityCanceled)
+
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.Error(env.GetWorkflowError())
+	s.True(IsCanceledError(env.GetWorkflowError()))
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ChildWorkflowRetry() {
+
+	childWorkflowFn := func(ctx Context) (string, error) {
+		info := GetWorkflowInfo(ctx)
+		if info.Attempt < 3 {
+			return "", NewApplicationError("bad-luck", "", false, nil)
+		}
+		return "retry-done", nil
+	}
+
+	workflowFn := func(ctx Context) (string, error) {
+		cwo := ChildWorkflowOptions{
+			WorkflowRunTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:        3,
+				InitialInterval:        time.Second,
+				MaximumInterval:        time.Second * 10,
+				BackoffCoefficient:     2,
+				NonRetryableErrorTypes: []string{"bad-bug"},
+			},
+		}
+		ctx = WithChildWorkflowOptions(ctx, cwo)
+		var childResult string
+		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, &childResult)
+		if err != nil {
+			return "", err
+		}
+
+		return childResult, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(childWorkflowFn)
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("retry-done", result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_SignalChildWorkflowRetry() {
+	childWorkflowFn := func(ctx Context) (string, error) {
+		info := GetWorkflowInfo(ctx)
+		if info.Attempt < 3 {
+			return "", NewApplicationError("bad-luck", "", false, nil)
+		}
+
+		ch := GetSignalChannel(ctx, "test-signal-name")
+		timeout := NewTimer(ctx, time.Second*3)
+		s := NewSelector(ctx)
+		var signal string
+		s.AddFuture(timeout, func(f Future) {
+			signal = "timeout"
+		}).AddReceive(ch, func(c ReceiveChannel, more bool) {
+			c.Receive(ctx, &signal)
+		}).Select(ctx)
+
+		return signal, nil
+	}
+
+	workflowFn := func(ctx Context) (string, error) {
+		cwo := ChildWorkflowOptions{
+			WorkflowID:         "test-retry-signal-child-workflow",
+			WorkflowRunTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:        3,
+				InitialInterval:        time.Second * 3,
+				MaximumInterval:        time.Second * 3,
+				BackoffCoefficient:     1,
+				NonRetryableErrorTypes: []string{"bad-bug"},
+			},
+		}
+		ctx = WithChildWorkflowOptions(ctx, cwo)
+		var childResult string
+		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, &childResult)
+		if err != nil {
+			return "", err
+		}
+
+		return childResult, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(childWorkflowFn)
+	env.RegisterWorkflow(workflowFn)
+
+	env.RegisterDelayedCallback(func() {
+		_ = env.SignalWorkflowByID("test-retry-signal-child-workflow", "test-signal-name", "test-signal-data")
+	}, time.Second*7 /* after 2nd attempt failed, but before 3rd attempt starts */)
+
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("test-signal-data", result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_TestWorkflowTimeoutInBusyLoop() {
+	neverEndingWorkflow := func(ctx Context) error {
+		for {
+			_ = Sleep(ctx, time.Hour)
+		}
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkflowRunTimeout((time.Hour * 10) + time.Minute)
+	timerFiredCount := 0
+	env.SetOnTimerFiredListener(func(timerID string) {
+		timerFiredCount++
+	})
+	env.RegisterWorkflow(neverEndingWorkflow)
+
+	env.ExecuteWorkflow(neverEndingWorkflow)
+	s.Equal(10, timerFiredCount)
+	err := env.GetWorkflowError()
+	s.Error(err)
+
+	var workflowErr *WorkflowExecutionError
+	s.True(errors.As(err, &workflowErr))
+
+	err = errors.Unwrap(workflowErr)
+	var err1 *TimeoutError
+	s.True(errors.As(err, &err1))
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_TestChildWorkflowTimeout() {
+	childWorkflowFn := func(ctx Context) error {
+		_ = Sleep(ctx, time.Hour*5)
+		return nil
+	}
+
+	workflowFn := func(ctx Context) error {
+		cwo := ChildWorkflowOptions{
+			WorkflowRunTimeout: time.Hour * 3, // less than 5h that child workflow would take.
+		}
+		ctx = WithChildWorkflowOptions(ctx, cwo)
+		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, nil)
+
+		s.Error(err)
+		var childWorkflowErr *ChildWorkflowExecutionError
+		if !errors.As(err, &childWorkflowErr) {
+			return fmt.Errorf("error should be *ChildWorkflowExecutionError but got: %v", err)
+		}
+
+		err = errors.Unwrap(childWorkflowErr)
+		var err1 *TimeoutError
+		if !errors.As(err, &err1) {
+			return fmt.Errorf("error cause should be *TimeoutError but got: %v", err)
+		}
+
+		return nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkflowRunTimeout(time.Hour * 10)
+	timerFiredCount := 0
+	env.SetOnTimerFiredListener(func(timerID string) {
+		timerFiredCount++
+	})
+	env.RegisterWorkflow(childWorkflowFn)
+	env.RegisterWorkflow(workflowFn)
+
+	env.ExecuteWorkflow(workflowFn)
+	s.NoError(env.GetWorkflowError())
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_SameActivityIDFromDifferentChildWorkflow() {
+	childWorkflowFn := func(ctx Context) (string, error) {
+		ao := ActivityOptions{
+			ActivityID:             "per_workflow_unique_activity_id",
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+		}
+		ctx = WithActivityOptions(ctx, ao)
+
+		info := GetWorkflowInfo(ctx)
+
+		var result string
+		err := ExecuteActivity(ctx, testActivityHello, info.WorkflowExecution.ID).Get(ctx, &result)
+		if err != nil {
+			return "", err
+		}
+
+		return result, nil
+	}
+
+	workflowFn := func(ctx Context) (string, error) {
+		ctx1 := WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+			WorkflowID:         "child_1",
+			WorkflowRunTimeout: time.Minute,
+		})
+		f1 := ExecuteChildWorkflow(ctx1, childWorkflowFn)
+
+		ctx2 := WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+			WorkflowID:         "child_2",
+			WorkflowRunTimeout: time.Minute,
+		})
+		f2 := ExecuteChildWorkflow(ctx2, childWorkflowFn)
+
+		var result1, result2 string
+		if err := f1.Get(ctx1, &result1); err != nil {
+			return "", err
+		}
+		if err := f2.Get(ctx1, &result2); err != nil {
+			return "", err
+		}
+
+		return result1 + " " + result2, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterWorkflow(childWorkflowFn)
+	env.RegisterActivity(testActivityHello)
+
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var actualResult string
+	s.NoError(env.GetWorkflowResult(&actualResult))
+	s.Equal("hello_child_1 hello_child_2", actualResult)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_MockChildWorkflowAlreadyRunning() {
+	childWorkflowFn := func(ctx Context) error {
+		return nil
+	}
+
+	runID := "run-id"
+	workflowFn := func(ctx Context) error {
+		cwo := ChildWorkflowOptions{
+			WorkflowExecutionTimeout: time.Minute,
+		}
+		ctx = WithChildWorkflowOptions(ctx, cwo)
+		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, nil)
+		s.Error(err)
+
+		var alreadySytartedErr *serviceerror.WorkflowExecutionAlreadyStarted
+		s.True(errors.As(err, &alreadySytartedErr))
+		s.Equal(runID, alreadySytartedErr.RunId)
+
+		return nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(childWorkflowFn)
+	env.RegisterWorkflow(workflowFn)
+
+	env.OnWorkflow(childWorkflowFn, mock.Anything).
+		Return(serviceerror.NewWorkflowExecutionAlreadyStarted("", "", runID))
+
+	env.ExecuteWorkflow(workflowFn)
+	s.NoError(env.GetWorkflowError())
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ChildWorkflowAlreadyRunning() {
+	workflowFn := func(ctx Context) (string, error) {
+		ctx1 := WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+			WorkflowID:            "Test_ChildWorkflowAlreadyRunning",
+			WorkflowRunTimeout:    time.Minute,
+			WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY,
+		})
+
+		var result1, result2 string
+		err := ExecuteChildWorkflow(ctx1, testWorkflowHeartbeat, "child1", time.Millisecond).Get(ctx1, &result1)
+		s.NoError(err)
+
+		f2 := ExecuteChildWorkflow(ctx1, testWorkflowHeartbeat, "child2", time.Second)
+
+		err = f2.Get(ctx1, &result2)
+		s.Error(err)
+		var childErr *ChildWorkflowExecutionError
+		s.True(errors.As(err, &childErr))
+		var alreadyStartedErr *ChildWorkflowExecutionAlreadyStartedError
+		s.True(errors.As(err, &alreadyStartedErr))
+
+		return result1 + " " + result2, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterWorkflow(testWorkflowHeartbeat)
+	env.RegisterActivity(testActivityHeartbeat)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
+	s.NoError(err)
+
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("heartbeat_child1 ", result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_CronWorkflow() {
+
+	failedCount, successCount, lastCompletionResult := 0, 0, 0
+	cronWorkflow := func(ctx Context) (int, error) {
+		info := GetWorkflowInfo(ctx)
+		var result int
+		if HasLastCompletionResult(ctx) {
+			_ = GetLastCompletionResult(ctx, &result)
+		}
+		_ = Sleep(ctx, time.Second*3)
+		if info.Attempt == 1 {
+			failedCount++
+			return 0, errors.New("please-retry")
+		}
+		successCount++
+		result++
+		lastCompletionResult = result
+		return result, nil
+	}
+
+	testWorkflow := func(ctx Context) error {
+		ctx1 := WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
+			WorkflowRunTimeout: time.Minute * 10,
+			RetryPolicy: &RetryPolicy{
+				MaximumAttempts:        5,
+				InitialInterval:        time.Second,
+				MaximumInterval:        time.Second * 10,
+				BackoffCoefficient:     2,
+				NonRetryableErrorTypes: []string{"bad-bug"},
+			},
+			CronSchedule: "0 * * * *", // hourly
+		})
+
+		cronFuture := ExecuteChildWorkflow(ctx1, cronWorkflow) // cron never stop so this future won't return
+
+		timeoutTimer := NewTimer(ctx, time.Hour*3)
+		selector := NewSelector(ctx)
+		var err error
+		selector.AddFuture(cronFuture, func(f Future) {
+			err = errors.New("cron workflow returns, this is not expected")
+		}).AddFuture(timeoutTimer, func(f Future) {
+			// err will be nil
+		}).Select(ctx)
+
+		return err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(cronWorkflow)
+	env.RegisterWorkflow(testWorkflow)
+
+	startTime, _ := time.Parse(time.RFC3339, "2018-12-20T16:30:00+08:00")
+	env.SetStartTime(startTime)
+	env.ExecuteWorkflow(testWorkflow)
+
+	s.True(env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
+	s.NoError(err)
+
+	s.Equal(4, failedCount)
+	s.Equal(4, successCount)
+	s.Equal(4, lastCompletionResult)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_CronHasLastResult() {
+	cronWorkflow := func(ctx Context) (int, error) {
+		var result int
+		if HasLastCompletionResult(ctx) {
+			_ = GetLastCompletionResult(ctx, &result)
+		}
+
+		return result + 1, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(cronWorkflow)
+	lastResult := 3
+	env.SetLastCompletionResult(lastResult)
+	env.ExecuteWorkflow(cronWorkflow)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+
+	var result int
+	err := env.GetWorkflowResult(&result)
+	s.NoError(err)
+
+	s.Equal(lastResult+1, result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_CronGetLastFailure() {
+	const failstr = "some previous failure"
+	cronWorkflow := func(ctx Context) (int, error) {
+		var lastfail = GetLastError(ctx)
+		if lastfail == nil || lastfail.Error() != failstr {
+			return 1, errors.New("last failure did not contain expected message")
+		}
+
+		return 0, nil
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(cronWorkflow)
+	env.SetLastError(errors.New(failstr))
+	env.ExecuteWorkflow(cronWorkflow)
+
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityWithProgress() {
+	activityFn := func(ctx context.Context) (int, error) {
+		var progress int
+		if HasHeartbeatDetails(ctx) {
+			_ = GetHeartbeatDetails(ctx, &progress)
+		}
+
+		return progress + 1, nil
+	}
+
+	env := s.NewTestActivityEnvironment()
+	env.RegisterActivity(activityFn)
+	lastProgress := 3
+	env.SetHeartbeatDetails(lastProgress)
+	result, err := env.ExecuteActivity(activityFn)
+
+	s.NoError(err)
+
+	var newProgress int
+	err = result.Get(&newProgress)
+	s.NoError(err)
+
+	s.Equal(lastProgress+1, newProgress)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityHeartbeat() {
+	env := s.NewTestActivityEnvironment()
+	env.RegisterActivity(testActivityHeartbeat)
+	v, err := env.ExecuteActivity(testActivityHeartbeat, "result", 1*time.Millisecond)
+	s.NoError(err)
+	var result string
+	err = v.Get(&result)
+	s.NoError(err)
+	s.Equal("heartbeat_result", result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityGoexit() {
+	fn := func(ctx context.Context) error {
+		runtime.Goexit() // usually this is called by t.FailNow(), but can't call FailNow here since that would mark the test as failed.
+		return nil
+	}
+
+	wf := func(ctx Context) error {
+		ao := ActivityOptions{
+			StartToCloseTimeout: 5 * time.Second,
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		err := ExecuteActivity(ctx, fn).Get(ctx, nil)
+		return err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(fn)
+	env.RegisterWorkflow(wf)
+	env.ExecuteWorkflow(wf)
+	err := env.GetWorkflowError()
+	s.Error(err)
+	var workflowErr *WorkflowExecutionError
+	s.True(errors.As(err, &workflowErr))
+
+	err = errors.Unwrap(workflowErr)
+	var activityErr *ActivityError
+	s.True(errors.As(err, &activityErr))
+
+	err = errors.Unwrap(activityErr)
+	var err1 *ApplicationError
+	s.True(errors.As(err, &err1))
+
+	s.EqualError(err1, "activity called runtime.Goexit")
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_SetWorkerStopChannel() {
+	env := newTestWorkflowEnvironmentImpl(&s.WorkflowTestSuite, nil)
+	c := make(chan struct{})
+	env.setWorkerStopChannel(c)
+	s.NotNil(env.workerStopChannel)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityTimeoutWithDetails() {
+	count := 0
+	timeoutFn := func() error {
+		count++
+		return NewTimeoutError("Activity timeout", enumspb.TIMEOUT_TYPE_START_TO_CLOSE, nil, testErrorDetails1)
+	}
+
+	timeoutWf := func(ctx Context) error {
+		ao := ActivityOptions{
+			StartToCloseTimeout: 5 * time.Second,
+			RetryPolicy: &RetryPolicy{
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 1.1,
+				MaximumAttempts:    3,
+			},
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		err := ExecuteActivity(ctx, timeoutFn).Get(ctx, nil)
+		return err
+	}
+
+	wfEnv := s.NewTestWorkflowEnvironment()
+	wfEnv.RegisterWorkflow(timeoutWf)
+	wfEnv.RegisterActivity(timeoutFn)
+
+	wfEnv.ExecuteWorkflow(timeoutWf)
+	err := wfEnv.GetWorkflowError()
+	s.Error(err)
+
+	var workflowErr *WorkflowExecutionError
+	s.True(errors.As(err, &workflowErr))
+
+	err = errors.Unwrap(workflowErr)
+	var activityErr *ActivityError
+	s.True(errors.As(err, &activityErr))
+
+	err = errors.Unwrap(activityErr)
+	var timeoutErr *TimeoutError
+	s.True(errors.As(err, &timeoutErr))
+
+	s.Equal(enumspb.TIMEOUT_TYPE_START_TO_CLOSE, timeoutErr.TimeoutType())
+	s.True(timeoutErr.HasLastHeartbeatDetails())
+	var details string
+	err = timeoutErr.LastHeartbeatDetails(&details)
+	s.NoError(err)
+	s.Equal(testErrorDetails1, details)
+	s.Equal(3, count)
+
+	activityEnv := s.NewTestActivityEnvironment()
+	activityEnv.RegisterActivity(timeoutFn)
+
+	_, err = activityEnv.ExecuteActivity(timeoutFn)
+	s.Error(err)
+	s.True(errors.As(err, &activityErr))
+
+	err = errors.Unwrap(activityErr)
+	s.True(errors.As(err, &timeoutErr))
+
+	s.Equal(enumspb.TIMEOUT_TYPE_START_TO_CLOSE, timeoutErr.TimeoutType())
+	s.True(timeoutErr.HasLastHeartbeatDetails())
+	err = timeoutErr.LastHeartbeatDetails(&details)
+	s.NoError(err)
+	s.Equal(testErrorDetails1, details)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityDeadlineExceeded() {
+	timeoutFn := func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}
+
+	timeoutWf := func(ctx Context) error {
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    1 * time.Second,
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		err := ExecuteActivity(ctx, timeoutFn).Get(ctx, nil)
+		return err
+	}
+
+	wfEnv := s.NewTestWorkflowEnvironment()
+	wfEnv.RegisterActivity(timeoutFn)
+	wfEnv.RegisterWorkflow(timeoutWf)
+	wfEnv.ExecuteWorkflow(timeoutWf)
+	err := wfEnv.GetWorkflowError()
+	s.Error(err)
+
+	var workflowErr *WorkflowExecutionError
+	s.True(errors.As(err, &workflowErr))
+
+	err = errors.Unwrap(workflowErr)
+	var activityErr *ActivityError
+	s.True(errors.As(err, &activityErr))
+
+	err = errors.Unwrap(activityErr)
+	var timeoutErr *TimeoutError
+	s.True(errors.As(err, &timeoutErr))
+	s.Equal(enumspb.TIMEOUT_TYPE_START_TO_CLOSE, timeoutErr.TimeoutType())
+	s.Equal("context deadline exceeded", timeoutErr.cause.Error())
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeoutTimeout() {
+	workflowFn := func(ctx Context) (bool, error) {
+		return AwaitWithTimeout(ctx, time.Second, func() bool { return false })
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	result := true
+	_ = env.GetWorkflowResult(&result)
+	s.False(result)
+}
+
+func (s *WorkflowTestSuiteUnitTest) Test_DynamicWorkflow() {
+	dynamicFn := func(ctx Context, workflowType string, args converter.EncodedValues) (interface{}, error) {
+		var name string
+		if err := args.Get(&name); err != nil {
+			return nil, err
+		}
+		return workflowType + ": " + name, nil
+	}
+	workflowFn := func(ctx Context) (string, error) {
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{})
+		var result string
+		err := ExecuteChildWorkflow(ctx, "renamedWorkflow", "child").Get(ctx, &result)
+		return result, err
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterDynamicWorkflow(dynamicFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("renamedWorkflow: child", result)
 
-		s.Error(err)
-		var childWorkflowErr *ChildWorkflowExecutionError
-		if !errors.As(err, &childWorkflowErr) {
-			return fmt.Errorf("error should be *ChildWorkflowExecutionError but got: %v", err)
-		}
+	env = s.NewTestWorkflowEnvironment()
+	env.RegisterDynamicWorkflow(dynamicFn)
+	s.Panics(func() { env.RegisterDynamicWorkflow(dynamicFn) })
+	env.ExecuteWorkflow("unknownWorkflow", "world")
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("unknownWorkflow: world", result)
+}
 
-		err = errors.Unwrap(childWorkflowErr)
-		var err1 *TimeoutError
-		if !errors.As(err, &err1) {
-			return fmt.Errorf("error cause should be *TimeoutError but got: %v", err)
+func (s *WorkflowTestSuiteUnitTest) Test_DynamicActivity() {
+	dynamicFn := func(ctx context.Context, activityType string, input *commonpb.Payloads) (*commonpb.Payloads, error) {
+		var name string
+		if err := converter.GetDefaultDataConverter().FromPayloads(input, &name); err != nil {
+			return nil, err
 		}
-
-		return nil
+		return converter.GetDefaultDataConverter().ToPayloads(activityType + ": " + name)
+	}
+	workflowFn := func(ctx Context) (string, error) {
+		ctx = WithActivityOptions(ctx, s.activityOptions)
+		var result string
+		err := ExecuteActivity(ctx, "proxiedActivity", "input").Get(ctx, &result)
+		return result, err
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.SetWorkflowRunTimeout(time.Hour * 10)
-	timerFiredCount := 0
-	env.SetOnTimerFiredListener(func(timerID string) {
-		timerFiredCount++
-	})
-	env.RegisterWorkflow(childWorkflowFn)
 	env.RegisterWorkflow(workflowFn)
-
+	env.RegisterDynamicActivity(dynamicFn)
 	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("proxiedActivity: input", result)
+
+	activityEnv := s.NewTestActivityEnvironment()
+	activityEnv.RegisterDynamicActivity(dynamicFn)
+	value, err := activityEnv.ExecuteActivity("otherActivity", "direct")
+	s.NoError(err)
+	s.NoError(value.Get(&result))
+	s.Equal("otherActivity: direct", result)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_SameActivityIDFromDifferentChildWorkflow() {
-	childWorkflowFn := func(ctx Context) (string, error) {
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityDeadlineExceededWithHeartbeatDetails() {
+	timeoutFn := func(ctx context.Context) error {
+		RecordActivityHeartbeat(ctx, "checkpoint")
+		<-ctx.Done()
+		return nil
+	}
+
+	timeoutWf := func(ctx Context) (string, error) {
 		ao := ActivityOptions{
-			ActivityID:             "per_workflow_unique_activity_id",
-			ScheduleToStartTimeout: time.Minute,
-			StartToCloseTimeout:    time.Minute,
+			StartToCloseTimeout: 1 * time.Second,
 		}
 		ctx = WithActivityOptions(ctx, ao)
-
-		info := GetWorkflowInfo(ctx)
-
-		var result string
-		err := ExecuteActivity(ctx, testActivityHello, info.WorkflowExecution.ID).Get(ctx, &result)
-		if err != nil {
+		err := ExecuteActivity(ctx, timeoutFn).Get(ctx, nil)
+		var timeoutErr *TimeoutError
+		if !errors.As(err, &timeoutErr) || !timeoutErr.HasDetails() {
 			return "", err
 		}
-
-		return result, nil
+		var checkpoint string
+		err = timeoutErr.Details(&checkpoint)
+		return checkpoint, err
 	}
 
-	workflowFn := func(ctx Context) (string, error) {
-		ctx1 := WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
-			WorkflowID:         "child_1",
-			WorkflowRunTimeout: time.Minute,
-		})
-		f1 := ExecuteChildWorkflow(ctx1, childWorkflowFn)
-
-		ctx2 := WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
-			WorkflowID:         "child_2",
-			WorkflowRunTimeout: time.Minute,
-		})
-		f2 := ExecuteChildWorkflow(ctx2, childWorkflowFn)
+	wfEnv := s.NewTestWorkflowEnvironment()
+	wfEnv.RegisterActivity(timeoutFn)
+	wfEnv.RegisterWorkflow(timeoutWf)
+	wfEnv.ExecuteWorkflow(timeoutWf)
+	s.True(wfEnv.IsWorkflowCompleted())
+	s.NoError(wfEnv.GetWorkflowError())
+	var checkpoint string
+	s.NoError(wfEnv.GetWorkflowResult(&checkpoint))
+	s.Equal("checkpoint", checkpoint)
+}
 
-		var result1, result2 string
-		if err := f1.Get(ctx1, &result1); err != nil {
-			return "", err
+func (s *WorkflowTestSuiteUnitTest) Test_TaskQueueRoutes() {
+	activityFn := func(ctx context.Context) (string, error) {
+		return GetActivityInfo(ctx).TaskQueue, nil
+	}
+	childWorkflowFn := func(ctx Context) (string, error) {
+		return GetWorkflowInfo(ctx).TaskQueueName, nil
+	}
+	workflowFn := func(ctx Context) ([]string, error) {
+		ctx = WithActivityOptions(ctx, s.activityOptions)
+		ctx = WithChildWorkflowOptions(ctx, ChildWorkflowOptions{WorkflowRunTimeout: time.Minute})
+		var taskQueues []string
+		for _, activityCtx := range []Context{ctx, WithTaskQueue(ctx, "explicit-activity-tq")} {
+			for _, activityType := range []string{"RoutedActivity", "OtherActivity"} {
+				var taskQueue string
+				if err := ExecuteActivity(activityCtx, activityType).Get(ctx, &taskQueue); err != nil {
+					return nil, err
+				}
+				taskQueues = append(taskQueues, taskQueue)
+			}
 		}
-		if err := f2.Get(ctx1, &result2); err != nil {
-			return "", err
+		for _, childType := range []string{"RoutedChild", "OtherChild"} {
+			var taskQueue string
+			if err := ExecuteChildWorkflow(ctx, childType).Get(ctx, &taskQueue); err != nil {
+				return nil, err
+			}
+			taskQueues = append(taskQueues, taskQueue)
 		}
-
-		return result1 + " " + result2, nil
+		return taskQueues, nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(WorkerOptions{TaskQueueRoutes: &TaskQueueRoutes{
+		Activities: map[string]string{"RoutedActivity": "activity-tq"},
+		Workflows:  map[string]string{"RoutedChild": "child-tq"},
+		Router: func(typeName string, isWorkflow bool) string {
+			if isWorkflow {
+				return ""
+			}
+			return "router-tq"
+		},
+	}})
 	env.RegisterWorkflow(workflowFn)
-	env.RegisterWorkflow(childWorkflowFn)
-	env.RegisterActivity(testActivityHello)
+	env.RegisterWorkflowWithOptions(childWorkflowFn, RegisterWorkflowOptions{Name: "RoutedChild"})
+	env.RegisterWorkflowWithOptions(childWorkflowFn, RegisterWorkflowOptions{Name: "OtherChild"})
+	env.RegisterActivityWithOptions(activityFn, RegisterActivityOptions{Name: "RoutedActivity"})
+	env.RegisterActivityWithOptions(activityFn, RegisterActivityOptions{Name: "OtherActivity"})
 
 	env.ExecuteWorkflow(workflowFn)
-
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
-	var actualResult string
-	s.NoError(env.GetWorkflowResult(&actualResult))
-	s.Equal("hello_child_1 hello_child_2", actualResult)
+	var taskQueues []string
+	s.NoError(env.GetWorkflowResult(&taskQueues))
+	s.Equal([]string{"activity-tq", "router-tq", "explicit-activity-tq", "explicit-activity-tq", "child-tq", defaultTestTaskQueue}, taskQueues)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_MockChildWorkflowAlreadyRunning() {
-	childWorkflowFn := func(ctx Context) error {
-		return nil
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityAffinityKey() {
+	activityFn := func(ctx context.Context, name string) (string, error) {
+		return name + "@" + GetActivityInfo(ctx).TaskQueue, nil
 	}
-
-	runID := "run-id"
-	workflowFn := func(ctx Context) error {
-		cwo := ChildWorkflowOptions{
-			WorkflowExecutionTimeout: time.Minute,
-		}
-		ctx = WithChildWorkflowOptions(ctx, cwo)
-		err := ExecuteChildWorkflow(ctx, childWorkflowFn).Get(ctx, nil)
-		s.Error(err)
-
-		var alreadySytartedErr *serviceerror.WorkflowExecutionAlreadyStarted
-		s.True(errors.As(err, &alreadySytartedErr))
-		s.Equal(runID, alreadySytartedErr.RunId)
-
-		return nil
+	workflowFn := func(ctx Context) (string, error) {
+		options := s.activityOptions
+		options.AffinityKey = "customer"
+		ctx = WithActivityOptions(ctx, options)
+		var result string
+		err := ExecuteActivity(ctx, activityFn, "hello").Get(ctx, &result)
+		return result, err
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(childWorkflowFn)
 	env.RegisterWorkflow(workflowFn)
-
-	env.OnWorkflow(childWorkflowFn, mock.Anything).
-		Return(serviceerror.NewWorkflowExecutionAlreadyStarted("", "", runID))
-
+	env.RegisterActivity(activityFn)
 	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	// Without workers serving affinity task queues the activity runs on the task queue of the workflow.
+	s.Equal("hello@"+defaultTestTaskQueue, result)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ChildWorkflowAlreadyRunning() {
-	workflowFn := func(ctx Context) (string, error) {
-		ctx1 := WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
-			WorkflowID:            "Test_ChildWorkflowAlreadyRunning",
-			WorkflowRunTimeout:    time.Minute,
-			WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY,
+func (s *WorkflowTestSuiteUnitTest) Test_ExecuteActivityWithOptions() {
+	activityFn := func(ctx context.Context) (ActivityInfo, error) {
+		return GetActivityInfo(ctx), nil
+	}
+	workflowFn := func(ctx Context) ([]ActivityInfo, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			HeartbeatTimeout:    20 * time.Second,
 		})
+		var infos []ActivityInfo
+		for _, options := range []ActivityOptions{{}, {ActivityID: "custom-id", HeartbeatTimeout: 5 * time.Second}, {}} {
+			var info ActivityInfo
+			if err := ExecuteActivityWithOptions(ctx, options, activityFn).Get(ctx, &info); err != nil {
+				return nil, err
+			}
+			infos = append(infos, info)
+		}
+		return infos, nil
+	}
 
-		var result1, result2 string
-		err := ExecuteChildWorkflow(ctx1, testWorkflowHeartbeat, "child1", time.Millisecond).Get(ctx1, &result1)
-		s.NoError(err)
-
-		f2 := ExecuteChildWorkflow(ctx1, testWorkflowHeartbeat, "child2", time.Second)
-
-		err = f2.Get(ctx1, &result2)
-		s.Error(err)
-		s.IsType(&serviceerror.WorkflowExecutionAlreadyStarted{}, err)
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(activityFn)
+	env.ExecuteWorkflow(workflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var infos []ActivityInfo
+	s.NoError(env.GetWorkflowResult(&infos))
+	s.Len(infos, 3)
+	s.Equal(20*time.Second, infos[0].HeartbeatTimeout)
+	s.Equal("custom-id", infos[1].ActivityID)
+	s.Equal(5*time.Second, infos[1].HeartbeatTimeout)
+	// The override applies to a single call.
+	s.NotEqual("custom-id", infos[2].ActivityID)
+	s.Equal(20*time.Second, infos[2].HeartbeatTimeout)
+}
 
-		return result1 + " " + result2, nil
+func (s *WorkflowTestSuiteUnitTest) Test_InvalidActivityOptions() {
+	workflowFn := func(ctx Context) error {
+		ctx = WithActivityOptions(ctx, ActivityOptions{StartToCloseTimeout: -time.Minute})
+		return ExecuteActivity(ctx, testActivityHello, "invalid").Get(ctx, nil)
 	}
 
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterWorkflow(workflowFn)
-	env.RegisterWorkflow(testWorkflowHeartbeat)
-	env.RegisterActivity(testActivityHeartbeat)
+	env.RegisterActivity(testActivityHello)
 	env.ExecuteWorkflow(workflowFn)
-
 	s.True(env.IsWorkflowCompleted())
 	err := env.GetWorkflowError()
-	s.NoError(err)
-
-	var result string
-	s.NoError(env.GetWorkflowResult(&result))
-	s.Equal("heartbeat_child1 ", result)
+	s.Error(err)
+	s.Contains(err.Error(), "invalid ActivityOptions: StartToCloseTimeout: negative duration -1m0s")
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_CronWorkflow() {
-
-	failedCount, successCount, lastCompletionResult := 0, 0, 0
-	cronWorkflow := func(ctx Context) (int, error) {
-		info := GetWorkflowInfo(ctx)
-		var result int
-		if HasLastCompletionResult(ctx) {
-			_ = GetLastCompletionResult(ctx, &result)
-		}
-		_ = Sleep(ctx, time.Second*3)
-		if info.Attempt == 1 {
-			failedCount++
-			return 0, errors.New("please-retry")
+func (s *WorkflowTestSuiteUnitTest) Test_CancellationScopes() {
+	var events []string
+	workflowFn := func(ctx Context) error {
+		scope := NewCancellationScope(ctx)
+		scope.Go(func(ctx Context) {
+			if err := Sleep(ctx, time.Hour); err != nil {
+				events = append(events, "scope sleep canceled")
+			}
+		})
+		nested := NewCancellationScope(scope.Context())
+		if err := Sleep(ctx, time.Minute); err != nil {
+			return err
 		}
-		successCount++
-		result++
-		lastCompletionResult = result
-		return result, nil
-	}
+		scope.Cancel()
+		s.True(scope.IsCanceled())
+		s.True(nested.IsCanceled())
 
-	testWorkflow := func(ctx Context) error {
-		ctx1 := WithChildWorkflowOptions(ctx, ChildWorkflowOptions{
-			WorkflowRunTimeout: time.Minute * 10,
-			RetryPolicy: &RetryPolicy{
-				MaximumAttempts:        5,
-				InitialInterval:        time.Second,
-				MaximumInterval:        time.Second * 10,
-				BackoffCoefficient:     2,
-				NonRetryableErrorTypes: []string{"bad-bug"},
-			},
-			CronSchedule: "0 * * * *", // hourly
+		err := Sleep(ctx, 24*time.Hour)
+		events = append(events, "workflow canceled")
+		detached := NewDetachedCancellationScope(ctx)
+		s.False(detached.IsCanceled())
+		cleanupErr := detached.Run(func(ctx Context) error {
+			return Sleep(ctx, time.Minute)
 		})
-
-		cronFuture := ExecuteChildWorkflow(ctx1, cronWorkflow) // cron never stop so this future won't return
-
-		timeoutTimer := NewTimer(ctx, time.Hour*3)
-		selector := NewSelector(ctx)
-		var err error
-		selector.AddFuture(cronFuture, func(f Future) {
-			err = errors.New("cron workflow returns, this is not expected")
-		}).AddFuture(timeoutTimer, func(f Future) {
-			// err will be nil
-		}).Select(ctx)
-
+		s.NoError(cleanupErr)
+		events = append(events, "cleaned up")
 		return err
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(cronWorkflow)
-	env.RegisterWorkflow(testWorkflow)
-
-	startTime, _ := time.Parse(time.RFC3339, "2018-12-20T16:30:00+08:00")
-	env.SetStartTime(startTime)
-	env.ExecuteWorkflow(testWorkflow)
+	env.RegisterDelayedCallback(func() {
+		env.CancelWorkflow()
+	}, time.Hour*2)
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn)
 
 	s.True(env.IsWorkflowCompleted())
-	err := env.GetWorkflowError()
-	s.NoError(err)
-
-	s.Equal(4, failedCount)
-	s.Equal(4, successCount)
-	s.Equal(4, lastCompletionResult)
+	var canceledErr *CanceledError
+	s.True(errors.As(env.GetWorkflowError(), &canceledErr))
+	s.Equal([]string{"scope sleep canceled", "workflow canceled", "cleaned up"}, events)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_CronHasLastResult() {
-	cronWorkflow := func(ctx Context) (int, error) {
-		var result int
-		if HasLastCompletionResult(ctx) {
-			_ = GetLastCompletionResult(ctx, &result)
+func (s *WorkflowTestSuiteUnitTest) Test_WorkflowFinalizers() {
+	var events []string
+	workflowFn := func(ctx Context, fail bool) error {
+		SetFinalizerTimeout(ctx, time.Hour)
+		Defer(ctx, func(ctx Context) {
+			s.NoError(ctx.Err())
+			events = append(events, "first finalizer")
+		})
+		Defer(ctx, func(ctx Context) {
+			err := Sleep(ctx, time.Minute)
+			s.NoError(err)
+			events = append(events, "second finalizer")
+		})
+		if fail {
+			return errors.New("workflow failed")
 		}
-
-		return result + 1, nil
+		return Sleep(ctx, 24*time.Hour)
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(cronWorkflow)
-	lastResult := 3
-	env.SetLastCompletionResult(lastResult)
-	env.ExecuteWorkflow(cronWorkflow)
-
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn, true)
 	s.True(env.IsWorkflowCompleted())
-	s.NoError(env.GetWorkflowError())
+	s.Error(env.GetWorkflowError())
+	s.Equal([]string{"second finalizer", "first finalizer"}, events)
 
-	var result int
-	err := env.GetWorkflowResult(&result)
-	s.NoError(err)
+	events = nil
+	env = s.NewTestWorkflowEnvironment()
+	env.RegisterDelayedCallback(func() {
+		env.CancelWorkflow()
+	}, time.Hour)
+	env.RegisterWorkflow(workflowFn)
+	env.ExecuteWorkflow(workflowFn, false)
+	s.True(env.IsWorkflowCompleted())
+	var canceledErr *CanceledError
+	s.True(errors.As(env.GetWorkflowError(), &canceledErr))
+	s.Equal([]string{"second finalizer", "first finalizer"}, events)
 
-	s.Equal(lastResult+1, result)
+	events = nil
+	env = s.NewTestWorkflowEnvironment()
+	completingWorkflowFn := func(ctx Context) error {
+		Defer(ctx, func(ctx Context) {
+			events = append(events, "finalizer")
+		})
+		return nil
+	}
+	env.RegisterWorkflow(completingWorkflowFn)
+	env.ExecuteWorkflow(completingWorkflowFn)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	s.Empty(events)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_CronGetLastFailure() {
-	const failstr = "some previous failure"
-	cronWorkflow := func(ctx Context) (int, error) {
-		var lastfail = GetLastError(ctx)
-		if lastfail == nil || lastfail.Error() != failstr {
-			return 1, errors.New("last failure did not contain expected message")
+func (s *WorkflowTestSuiteUnitTest) Test_DisconnectedContextAfterCancellation() {
+	workflowFn := func(ctx Context) (string, error) {
+		ctx = WithActivityOptions(ctx, s.activityOptions)
+		err := Sleep(ctx, time.Hour)
+		s.True(IsCanceledError(err))
+
+		disconnectedCtx, cancel := NewDisconnectedContext(ctx)
+		s.NoError(disconnectedCtx.Err())
+		var cleanupResult string
+		if err := ExecuteActivity(disconnectedCtx, testActivityHello, "cleanup").Get(disconnectedCtx, &cleanupResult); err != nil {
+			return "", err
 		}
+		s.True(IsCanceledError(ctx.Err()))
 
-		return 0, nil
+		cancel()
+		s.True(IsCanceledError(disconnectedCtx.Err()))
+		childCtx, _ := WithCancel(disconnectedCtx)
+		s.True(IsCanceledError(childCtx.Err()))
+		return cleanupResult, nil
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterWorkflow(cronWorkflow)
-	env.SetLastError(errors.New(failstr))
-	env.ExecuteWorkflow(cronWorkflow)
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(testActivityHello)
+	env.RegisterDelayedCallback(func() {
+		env.CancelWorkflow()
+	}, time.Minute)
+	env.ExecuteWorkflow(workflowFn)
 
 	s.True(env.IsWorkflowCompleted())
 	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("hello_cleanup", result)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityWithProgress() {
-	activityFn := func(ctx context.Context) (int, error) {
-		var progress int
-		if HasHeartbeatDetails(ctx) {
-			_ = GetHeartbeatDetails(ctx, &progress)
-		}
-
-		return progress + 1, nil
+func (s *WorkflowTestSuiteUnitTest) Test_LocalActivityInfo() {
+	localActivityFn := func(ctx context.Context) (ActivityInfo, error) {
+		return GetActivityInfo(ctx), nil
 	}
 
 	env := s.NewTestActivityEnvironment()
-	env.RegisterActivity(activityFn)
-	lastProgress := 3
-	env.SetHeartbeatDetails(lastProgress)
-	result, err := env.ExecuteActivity(activityFn)
-
-	s.NoError(err)
-
-	var newProgress int
-	err = result.Get(&newProgress)
-	s.NoError(err)
-
-	s.Equal(lastProgress+1, newProgress)
-}
-
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityHeartbeat() {
-	env := s.NewTestActivityEnvironment()
-	env.RegisterActivity(testActivityHeartbeat)
-	v, err := env.ExecuteActivity(testActivityHeartbeat, "result", 1*time.Millisecond)
-	s.NoError(err)
-	var result string
-	err = v.Get(&result)
+	result, err := env.ExecuteLocalActivity(localActivityFn)
 	s.NoError(err)
-	s.Equal("heartbeat_result", result)
+	var info ActivityInfo
+	s.NoError(result.Get(&info))
+	s.True(info.IsLocalActivity)
+	s.Equal(int32(1), info.Attempt)
+	s.False(info.StartedTime.IsZero())
+	s.True(info.Deadline.After(info.StartedTime))
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityGoexit() {
-	fn := func(ctx context.Context) error {
-		runtime.Goexit() // usually this is called by t.FailNow(), but can't call FailNow here since that would mark the test as failed.
-		return nil
+func (s *WorkflowTestSuiteUnitTest) Test_MockedLocalActivityRetry() {
+	localActivityFn := func(ctx context.Context, name string) (string, error) {
+		return "", errors.New("not mocked")
 	}
-
-	wf := func(ctx Context) error {
-		ao := ActivityOptions{
-			StartToCloseTimeout: 5 * time.Second,
-		}
-		ctx = WithActivityOptions(ctx, ao)
-		err := ExecuteActivity(ctx, fn).Get(ctx, nil)
-		return err
+	workflowFn := func(ctx Context) (string, error) {
+		ctx = WithLocalActivityOptions(ctx, LocalActivityOptions{
+			ScheduleToCloseTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 2,
+				MaximumAttempts:    3,
+			},
+		})
+		var result string
+		err := ExecuteLocalActivity(ctx, localActivityFn, "local_activity").Get(ctx, &result)
+		return result, err
 	}
 
 	env := s.NewTestWorkflowEnvironment()
-	env.RegisterActivity(fn)
-	env.RegisterWorkflow(wf)
-	env.ExecuteWorkflow(wf)
-	err := env.GetWorkflowError()
-	s.Error(err)
-	var workflowErr *WorkflowExecutionError
-	s.True(errors.As(err, &workflowErr))
-
-	err = errors.Unwrap(workflowErr)
-	var activityErr *ActivityError
-	s.True(errors.As(err, &activityErr))
-
-	err = errors.Unwrap(activityErr)
-	var err1 *ApplicationError
-	s.True(errors.As(err, &err1))
-
-	s.EqualError(err1, "activity called runtime.Goexit")
-}
+	env.RegisterWorkflow(workflowFn)
+	env.OnActivity(localActivityFn, mock.Anything, "local_activity").Return("", errors.New("transient")).Twice()
+	env.OnActivity(localActivityFn, mock.Anything, "local_activity").Return("hello mock", nil).Once()
+	var completionErrors []error
+	env.SetOnLocalActivityCompletedListener(func(activityInfo *ActivityInfo, result converter.EncodedValue, err error) {
+		completionErrors = append(completionErrors, err)
+	})
+	env.ExecuteWorkflow(workflowFn)
 
-func (s *WorkflowTestSuiteUnitTest) Test_SetWorkerStopChannel() {
-	env := newTestWorkflowEnvironmentImpl(&s.WorkflowTestSuite, nil)
-	c := make(chan struct{})
-	env.setWorkerStopChannel(c)
-	s.NotNil(env.workerStopChannel)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("hello mock", result)
+	env.AssertExpectations(s.T())
+	s.Len(completionErrors, 3)
+	s.Error(completionErrors[0])
+	s.Error(completionErrors[1])
+	s.NoError(completionErrors[2])
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityTimeoutWithDetails() {
-	count := 0
-	timeoutFn := func() error {
-		count++
-		return NewTimeoutError("Activity timeout", enumspb.TIMEOUT_TYPE_START_TO_CLOSE, nil, testErrorDetails1)
+func (s *WorkflowTestSuiteUnitTest) Test_MockedActivityTimeouts() {
+	timeoutActivityFn := func(ctx context.Context, name string) (string, error) {
+		return "", errors.New("not mocked")
 	}
-
-	timeoutWf := func(ctx Context) error {
-		ao := ActivityOptions{
-			StartToCloseTimeout: 5 * time.Second,
+	var elapsed time.Duration
+	workflowFn := func(ctx Context) (string, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Hour,
+			HeartbeatTimeout:       10 * time.Second,
 			RetryPolicy: &RetryPolicy{
 				InitialInterval:    time.Second,
-				BackoffCoefficient: 1.1,
+				BackoffCoefficient: 1,
 				MaximumAttempts:    3,
 			},
-		}
-		ctx = WithActivityOptions(ctx, ao)
-		err := ExecuteActivity(ctx, timeoutFn).Get(ctx, nil)
-		return err
+		})
+		start := Now(ctx)
+		var result string
+		err := ExecuteActivity(ctx, timeoutActivityFn, "timeout").Get(ctx, &result)
+		elapsed = Now(ctx).Sub(start)
+		return result, err
 	}
 
-	wfEnv := s.NewTestWorkflowEnvironment()
-	wfEnv.RegisterWorkflow(timeoutWf)
-	wfEnv.RegisterActivity(timeoutFn)
-
-	wfEnv.ExecuteWorkflow(timeoutWf)
-	err := wfEnv.GetWorkflowError()
-	s.Error(err)
-
-	var workflowErr *WorkflowExecutionError
-	s.True(errors.As(err, &workflowErr))
-
-	err = errors.Unwrap(workflowErr)
-	var activityErr *ActivityError
-	s.True(errors.As(err, &activityErr))
-
-	err = errors.Unwrap(activityErr)
-	var timeoutErr *TimeoutError
-	s.True(errors.As(err, &timeoutErr))
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.OnActivity(timeoutActivityFn, mock.Anything, "timeout").Return("", TimeoutHeartbeat("progress")).Once()
+	env.OnActivity(timeoutActivityFn, mock.Anything, "timeout").Return(func(ctx context.Context, name string) (string, error) {
+		var progress string
+		if err := GetHeartbeatDetails(ctx, &progress); err != nil {
+			return "", err
+		}
+		return progress, nil
+	}).Once()
+	env.ExecuteWorkflow(workflowFn)
 
-	s.Equal(enumspb.TIMEOUT_TYPE_START_TO_CLOSE, timeoutErr.TimeoutType())
-	s.True(timeoutErr.HasLastHeartbeatDetails())
-	var details string
-	err = timeoutErr.LastHeartbeatDetails(&details)
-	s.NoError(err)
-	s.Equal(testErrorDetails1, details)
-	s.Equal(3, count)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var result string
+	s.NoError(env.GetWorkflowResult(&result))
+	s.Equal("progress", result)
+	s.Equal(11*time.Second, elapsed)
+	env.AssertExpectations(s.T())
 
-	activityEnv := s.NewTestActivityEnvironment()
-	activityEnv.RegisterActivity(timeoutFn)
+	env = s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.OnActivity(timeoutActivityFn, mock.Anything, "timeout").Return("", TimeoutScheduleToStart()).Once()
+	env.ExecuteWorkflow(workflowFn)
 
-	_, err = activityEnv.ExecuteActivity(timeoutFn)
+	s.True(env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
 	s.Error(err)
-	s.True(errors.As(err, &activityErr))
-
-	err = errors.Unwrap(activityErr)
+	var timeoutErr *TimeoutError
 	s.True(errors.As(err, &timeoutErr))
-
-	s.Equal(enumspb.TIMEOUT_TYPE_START_TO_CLOSE, timeoutErr.TimeoutType())
-	s.True(timeoutErr.HasLastHeartbeatDetails())
-	err = timeoutErr.LastHeartbeatDetails(&details)
-	s.NoError(err)
-	s.Equal(testErrorDetails1, details)
+	s.Equal(enumspb.TIMEOUT_TYPE_SCHEDULE_TO_START, timeoutErr.TimeoutType())
+	s.Equal(time.Minute, elapsed)
+	env.AssertExpectations(s.T())
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_ActivityDeadlineExceeded() {
-	timeoutFn := func(ctx context.Context) error {
-		<-ctx.Done()
+func (s *WorkflowTestSuiteUnitTest) Test_QueryWorkflowByTime() {
+	workflowFn := func(ctx Context) error {
+		step := "started"
+		if err := SetQueryHandler(ctx, "step", func() (string, error) { return step, nil }); err != nil {
+			return err
+		}
+		_ = Sleep(ctx, time.Minute)
+		step = "slept"
+		_ = Sleep(ctx, time.Minute)
+		step = "done"
 		return nil
 	}
 
-	timeoutWf := func(ctx Context) error {
-		ao := ActivityOptions{
-			ScheduleToStartTimeout: time.Minute,
-			StartToCloseTimeout:    1 * time.Second,
-		}
-		ctx = WithActivityOptions(ctx, ao)
-		err := ExecuteActivity(ctx, timeoutFn).Get(ctx, nil)
-		return err
-	}
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	first := env.QueryWorkflowByTime(30*time.Second, "step")
+	second := env.QueryWorkflowByTime(90*time.Second, "step")
+	unknown := env.QueryWorkflowByTime(100*time.Second, "unknown")
+	late := env.QueryWorkflowByTime(time.Hour, "step")
+	env.ExecuteWorkflow(workflowFn)
 
-	wfEnv := s.NewTestWorkflowEnvironment()
-	wfEnv.RegisterActivity(timeoutFn)
-	wfEnv.RegisterWorkflow(timeoutWf)
-	wfEnv.ExecuteWorkflow(timeoutWf)
-	err := wfEnv.GetWorkflowError()
-	s.Error(err)
+	s.True(env.IsWorkflowCompleted())
+	s.NoError(env.GetWorkflowError())
+	var step string
+	s.NoError(first.Get(&step))
+	s.Equal("started", step)
+	s.NoError(second.Get(&step))
+	s.Equal("slept", step)
+	s.True(unknown.Issued())
+	s.Error(unknown.Get(&step))
+	s.False(late.Issued())
+	s.Error(late.Get(&step))
+}
 
-	var workflowErr *WorkflowExecutionError
-	s.True(errors.As(err, &workflowErr))
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityEnvironmentHeartbeats() {
+	heartbeatingActivityFn := func(ctx context.Context) (int, error) {
+		for i := 1; i <= 10; i++ {
+			RecordActivityHeartbeat(ctx, i)
+			select {
+			case <-ctx.Done():
+				return i, ctx.Err()
+			case <-GetWorkerStopChannel(ctx):
+				return i, errors.New("worker stopping")
+			default:
+			}
+		}
+		return 10, nil
+	}
 
-	err = errors.Unwrap(workflowErr)
-	var activityErr *ActivityError
-	s.True(errors.As(err, &activityErr))
+	env := s.NewTestActivityEnvironment()
+	env.RegisterActivity(heartbeatingActivityFn)
+	result, err := env.ExecuteActivity(heartbeatingActivityFn)
+	s.NoError(err)
+	var count int
+	s.NoError(result.Get(&count))
+	s.Equal(10, count)
+	s.Len(env.GetHeartbeats(), 10)
 
-	err = errors.Unwrap(activityErr)
-	var timeoutErr *TimeoutError
-	s.True(errors.As(err, &timeoutErr))
-	s.Equal(enumspb.TIMEOUT_TYPE_START_TO_CLOSE, timeoutErr.TimeoutType())
-	s.Equal("context deadline exceeded", timeoutErr.cause.Error())
+	env.SetOnActivityHeartbeatListener(func(activityInfo *ActivityInfo, details converter.EncodedValues) {
+		var progress int
+		s.NoError(details.Get(&progress))
+		if progress == 3 {
+			env.RequestCancelActivity()
+		}
+	})
+	_, err = env.ExecuteActivity(heartbeatingActivityFn)
+	var canceledErr *CanceledError
+	s.True(errors.As(err, &canceledErr))
+	heartbeats := env.GetHeartbeats()
+	s.Len(heartbeats, 3)
+	var progress int
+	s.NoError(heartbeats[2].Get(&progress))
+	s.Equal(3, progress)
+
+	env.SetOnActivityHeartbeatListener(func(activityInfo *ActivityInfo, details converter.EncodedValues) {
+		env.StopWorker()
+	})
+	_, err = env.ExecuteActivity(heartbeatingActivityFn)
+	s.Error(err)
+	s.Contains(err.Error(), "worker stopping")
+	s.Len(env.GetHeartbeats(), 1)
 }
 
-func (s *WorkflowTestSuiteUnitTest) Test_AwaitWithTimeoutTimeout() {
-	workflowFn := func(ctx Context) (bool, error) {
-		return AwaitWithTimeout(ctx, time.Second, func() bool { return false })
+func (s *WorkflowTestSuiteUnitTest) Test_ActivityPanicPolicy() {
+	attempts := 0
+	panickingActivityFn := func(ctx context.Context) error {
+		attempts++
+		panic("activity bug")
+	}
+	workflowFn := func(ctx Context) error {
+		ctx = WithActivityOptions(ctx, ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			RetryPolicy: &RetryPolicy{
+				InitialInterval:    time.Second,
+				BackoffCoefficient: 1,
+				MaximumAttempts:    3,
+			},
+		})
+		return ExecuteActivity(ctx, panickingActivityFn).Get(ctx, nil)
 	}
 
 	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(WorkerOptions{ActivityPanicPolicy: FailActivityOnPanic})
 	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(panickingActivityFn)
 	env.ExecuteWorkflow(workflowFn)
+
 	s.True(env.IsWorkflowCompleted())
-	s.NoError(env.GetWorkflowError())
-	result := true
-	_ = env.GetWorkflowResult(&result)
-	s.False(result)
+	var panicErr *PanicError
+	s.True(errors.As(env.GetWorkflowError(), &panicErr))
+	s.True(panicErr.NonRetryable())
+	s.Contains(panicErr.StackTrace(), "panic")
+	s.Equal(1, attempts)
+
+	attempts = 0
+	env = s.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(panickingActivityFn)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	s.True(errors.As(env.GetWorkflowError(), &panicErr))
+	s.False(panicErr.NonRetryable())
+	s.Equal(3, attempts)
 }
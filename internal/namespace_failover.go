@@ -0,0 +1,248 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+	"go.temporal.io/api/workflowservice/v1"
+
+	"go.temporal.io/sdk/internal/common/metrics"
+	"go.temporal.io/sdk/log"
+)
+
+const (
+	defaultNamespaceFailoverCheckInterval = 10 * time.Second
+
+	// namespaceFailoverCheckTimeout bounds each check, including its retries, so that an unresponsive server
+	// doesn't stall the checks.
+	namespaceFailoverCheckTimeout = 10 * time.Second
+)
+
+type (
+	// NamespaceFailoverOptions configure how a worker follows the failovers of its namespace between clusters, see
+	// WorkerOptions.NamespaceFailover.
+	NamespaceFailoverOptions struct {
+		// CurrentCluster is the name of the cluster the worker is connected to. Required.
+		CurrentCluster string
+
+		// CheckInterval is how often the active cluster of the namespace is checked.
+		// default: 10s
+		CheckInterval time.Duration
+
+		// PausePollingWhenPassive stops the worker from polling tasks while the namespace is active in another
+		// cluster, instead of spending poll requests on a cluster which doesn't dispatch tasks. Polling resumes when
+		// the namespace fails over back to the CurrentCluster.
+		// default: false
+		PausePollingWhenPassive bool
+
+		// OnActiveClusterChange is called every time the worker sees the active cluster of the namespace change.
+		// Optional.
+		OnActiveClusterChange func(info NamespaceFailoverInfo)
+	}
+
+	// NamespaceFailoverInfo describes a change of the active cluster of the namespace of a worker.
+	NamespaceFailoverInfo struct {
+		Namespace             string
+		PreviousActiveCluster string
+		ActiveCluster         string
+		// Active is true when the namespace is active in the cluster the worker is connected to.
+		Active bool
+	}
+
+	// pollGate stops the pollers of the workers sharing it while it is paused.
+	pollGate struct {
+		sync.Mutex
+		// resumed is closed when the gate is resumed, nil while it is not paused.
+		resumed chan struct{}
+	}
+
+	// namespaceFailoverWatcher checks the active cluster of the namespace of a worker, pausing the poll gate of the
+	// worker while the namespace is passive if configured to.
+	namespaceFailoverWatcher struct {
+		service      workflowservice.WorkflowServiceClient
+		namespace    string
+		options      NamespaceFailoverOptions
+		gate         *pollGate
+		logger       log.Logger
+		metricsScope tally.Scope
+
+		activeCluster string
+		// ctx is canceled on stop, aborting the check in flight.
+		ctx      context.Context
+		cancel   context.CancelFunc
+		stopOnce sync.Once
+		wg       sync.WaitGroup
+	}
+)
+
+func (g *pollGate) pause() {
+	g.Lock()
+	defer g.Unlock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+}
+
+func (g *pollGate) resume() {
+	g.Lock()
+	defer g.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+}
+
+// wait blocks while the gate is paused. Returns false if stopCh is closed before the gate is resumed.
+func (g *pollGate) wait(stopCh <-chan struct{}) bool {
+	if g == nil {
+		return true
+	}
+	g.Lock()
+	resumed := g.resumed
+	g.Unlock()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-stopCh:
+		return false
+	}
+}
+
+func newNamespaceFailoverWatcher(
+	service workflowservice.WorkflowServiceClient,
+	namespace string,
+	options NamespaceFailoverOptions,
+	gate *pollGate,
+	logger log.Logger,
+	metricsScope tally.Scope,
+) *namespaceFailoverWatcher {
+	if options.CurrentCluster == "" {
+		panic("NamespaceFailoverOptions.CurrentCluster is not set")
+	}
+	if options.CheckInterval <= 0 {
+		options.CheckInterval = defaultNamespaceFailoverCheckInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &namespaceFailoverWatcher{
+		service:      service,
+		namespace:    namespace,
+		options:      options,
+		gate:         gate,
+		logger:       logger,
+		metricsScope: metricsScope,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// start checks the active cluster in the background, first right away so that a worker started in the passive
+// cluster stops polling soon, and then every CheckInterval. It doesn't wait for the first check, so a slow server
+// doesn't block Worker.Start.
+func (w *namespaceFailoverWatcher) start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.check()
+		ticker := time.NewTicker(w.options.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+func (w *namespaceFailoverWatcher) stop() {
+	w.stopOnce.Do(func() {
+		w.cancel()
+		// Pollers waiting for the gate are stopped by their own stop channels.
+		w.wg.Wait()
+	})
+}
+
+func (w *namespaceFailoverWatcher) check() {
+	ctx, cancel := context.WithTimeout(w.ctx, namespaceFailoverCheckTimeout)
+	defer cancel()
+	grpcCtx, grpcCancel := newGRPCContext(ctx, defaultGrpcRetryParameters(ctx))
+	defer grpcCancel()
+	response, err := w.service.DescribeNamespace(grpcCtx, &workflowservice.DescribeNamespaceRequest{Namespace: w.namespace})
+	if w.ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		w.logger.Warn("Unable to check the active cluster of the namespace.", tagError, err)
+		return
+	}
+	w.update(response.GetReplicationConfig().GetActiveClusterName())
+}
+
+func (w *namespaceFailoverWatcher) update(activeCluster string) {
+	active := activeCluster == w.options.CurrentCluster
+	if active {
+		w.metricsScope.Gauge(metrics.NamespaceActiveGauge).Update(1)
+	} else {
+		w.metricsScope.Gauge(metrics.NamespaceActiveGauge).Update(0)
+	}
+	if w.options.PausePollingWhenPassive {
+		if active {
+			w.gate.resume()
+		} else {
+			w.gate.pause()
+		}
+	}
+	previous := w.activeCluster
+	w.activeCluster = activeCluster
+	if previous == activeCluster {
+		return
+	}
+	if previous == "" {
+		if !active {
+			w.logger.Info("Namespace is active in another cluster.", "ActiveCluster", activeCluster)
+		}
+		return
+	}
+
+	w.logger.Info("Namespace failed over.", "PreviousActiveCluster", previous, "ActiveCluster", activeCluster)
+	w.metricsScope.Counter(metrics.NamespaceFailoverCounter).Inc(1)
+	if w.options.OnActiveClusterChange != nil {
+		w.options.OnActiveClusterChange(NamespaceFailoverInfo{
+			Namespace:             w.namespace,
+			PreviousActiveCluster: previous,
+			ActiveCluster:         activeCluster,
+			Active:                active,
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/api/workflowservicemock/v1"
+	"google.golang.org/grpc"
+
+	ilog "go.temporal.io/sdk/internal/log"
+)
+
+func TestPollGate(t *testing.T) {
+	var nilGate *pollGate
+	require.True(t, nilGate.wait(nil))
+
+	gate := &pollGate{}
+	require.True(t, gate.wait(nil))
+
+	gate.pause()
+	stopCh := make(chan struct{})
+	close(stopCh)
+	require.False(t, gate.wait(stopCh))
+
+	waited := make(chan bool)
+	go func() {
+		waited <- gate.wait(make(chan struct{}))
+	}()
+	select {
+	case <-waited:
+		t.Fatal("wait returned while the gate is paused")
+	case <-time.After(10 * time.Millisecond):
+	}
+	gate.resume()
+	require.True(t, <-waited)
+}
+
+func TestNamespaceFailoverWatcher(t *testing.T) {
+	var infos []NamespaceFailoverInfo
+	gate := &pollGate{}
+	w := newNamespaceFailoverWatcher(nil, "namespace", NamespaceFailoverOptions{
+		CurrentCluster:          "east",
+		PausePollingWhenPassive: true,
+		OnActiveClusterChange: func(info NamespaceFailoverInfo) {
+			infos = append(infos, info)
+		},
+	}, gate, ilog.NewNopLogger(), tally.NoopScope)
+
+	// A worker started in the passive cluster doesn't poll.
+	w.update("west")
+	require.NotNil(t, gate.resumed)
+	require.Empty(t, infos)
+
+	w.update("east")
+	require.Nil(t, gate.resumed)
+	w.update("east")
+	require.Equal(t, []NamespaceFailoverInfo{{
+		Namespace:             "namespace",
+		PreviousActiveCluster: "west",
+		ActiveCluster:         "east",
+		Active:                true,
+	}}, infos)
+
+	require.Panics(t, func() {
+		newNamespaceFailoverWatcher(nil, "namespace", NamespaceFailoverOptions{}, gate, ilog.NewNopLogger(), tally.NoopScope)
+	})
+}
+
+func TestNamespaceFailoverWatcher_StartDoesNotWaitForCheck(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	service := workflowservicemock.NewMockWorkflowServiceClient(mockCtrl)
+	checking := make(chan struct{})
+	service.EXPECT().DescribeNamespace(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *workflowservice.DescribeNamespaceRequest, _ ...grpc.CallOption) (*workflowservice.DescribeNamespaceResponse, error) {
+			close(checking)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	w := newNamespaceFailoverWatcher(service, "namespace", NamespaceFailoverOptions{
+		CurrentCluster: "east",
+		CheckInterval:  time.Hour,
+	}, &pollGate{}, ilog.NewNopLogger(), tally.NoopScope)
+
+	// The hanging check neither blocks start nor stop.
+	w.start()
+	<-checking
+	w.stop()
+	require.Empty(t, w.activeCluster)
+}
@@ -0,0 +1,261 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	enumspb "go.temporal.io/api/enums/v1"
+)
+
+type (
+	// InvalidOptionsError is returned for ActivityOptions or ChildWorkflowOptions rejected by ValidateActivityOptions
+	// or ValidateChildWorkflowOptions. An activity or child workflow executed with invalid options fails with this
+	// error without being scheduled.
+	InvalidOptionsError struct {
+		// Options is the name of the options type, like "ActivityOptions".
+		Options string
+		// Problems lists the problems of the options, one per invalid field.
+		Problems []OptionsProblem
+	}
+
+	// OptionsProblem describes the problem of an invalid field of options.
+	OptionsProblem struct {
+		// Field is the name of the invalid field, like "RetryPolicy.MaximumInterval".
+		Field string
+		// Problem describes what is wrong with the value of the field.
+		Problem string
+		// Fix suggests how to fix the field.
+		Fix string
+	}
+
+	optionsValidator struct {
+		problems []OptionsProblem
+	}
+)
+
+func (e *InvalidOptionsError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		problems = append(problems, fmt.Sprintf("%v: %v, %v", p.Field, p.Problem, p.Fix))
+	}
+	return fmt.Sprintf("invalid %v: %v", e.Options, strings.Join(problems, "; "))
+}
+
+// ValidateActivityOptions returns an *InvalidOptionsError when the server would reject scheduling an activity with
+// the options. ExecuteActivity validates the options of its context before scheduling the activity, use this function
+// to check options in unit tests.
+func ValidateActivityOptions(options ActivityOptions) error {
+	v := &optionsValidator{}
+	v.taskQueue("TaskQueue", options.TaskQueue)
+	v.nonNegative("ScheduleToCloseTimeout", options.ScheduleToCloseTimeout)
+	v.nonNegative("ScheduleToStartTimeout", options.ScheduleToStartTimeout)
+	v.nonNegative("StartToCloseTimeout", options.StartToCloseTimeout)
+	v.nonNegative("HeartbeatTimeout", options.HeartbeatTimeout)
+	v.retryPolicy(options.RetryPolicy)
+	return v.err("ActivityOptions")
+}
+
+// ValidateChildWorkflowOptions returns an *InvalidOptionsError when the server would reject starting a child workflow
+// with the options. ExecuteChildWorkflow validates the options of its context before starting the child workflow, use
+// this function to check options in unit tests.
+func ValidateChildWorkflowOptions(options ChildWorkflowOptions) error {
+	v := &optionsValidator{}
+	if options.Namespace != strings.TrimSpace(options.Namespace) {
+		v.add("Namespace", fmt.Sprintf("namespace %q has leading or trailing spaces", options.Namespace),
+			"remove the spaces, or leave it unset to start the child workflow in the namespace of the parent")
+	}
+	v.taskQueue("TaskQueue", options.TaskQueue)
+	v.nonNegative("WorkflowExecutionTimeout", options.WorkflowExecutionTimeout)
+	v.nonNegative("WorkflowRunTimeout", options.WorkflowRunTimeout)
+	v.nonNegative("WorkflowTaskTimeout", options.WorkflowTaskTimeout)
+	v.cronSchedule(options.CronSchedule)
+	if _, ok := enumspb.ParentClosePolicy_name[int32(options.ParentClosePolicy)]; !ok {
+		v.add("ParentClosePolicy", fmt.Sprintf("unknown parent close policy %v", options.ParentClosePolicy),
+			"use one of the enumspb.PARENT_CLOSE_POLICY_* values")
+	}
+	v.workflowIDReusePolicy(options.WorkflowIDReusePolicy)
+	v.retryPolicy(options.RetryPolicy)
+	return v.err("ChildWorkflowOptions")
+}
+
+// ValidateStartWorkflowOptions returns an *InvalidOptionsError when the server would reject starting a workflow with
+// the options. Client.ExecuteWorkflow and Client.SignalWithStartWorkflow validate their options before starting the
+// workflow.
+func ValidateStartWorkflowOptions(options StartWorkflowOptions) error {
+	v := &optionsValidator{}
+	v.taskQueue("TaskQueue", options.TaskQueue)
+	v.nonNegative("WorkflowExecutionTimeout", options.WorkflowExecutionTimeout)
+	v.nonNegative("WorkflowRunTimeout", options.WorkflowRunTimeout)
+	v.nonNegative("WorkflowTaskTimeout", options.WorkflowTaskTimeout)
+	v.cronSchedule(options.CronSchedule)
+	v.workflowIDReusePolicy(options.WorkflowIDReusePolicy)
+	v.retryPolicy(options.RetryPolicy)
+	return v.err("StartWorkflowOptions")
+}
+
+// ValidateSearchAttributes returns an *InvalidOptionsError when StartWorkflowOptions.SearchAttributes has attributes
+// which aren't in keys, the search attributes registered on the cluster as returned by Client.GetSearchAttributes, or
+// values which don't match the type of their search attribute. Client.ExecuteWorkflow and
+// Client.SignalWithStartWorkflow validate the search attributes with the keys of the cluster when
+// ClientOptions.ValidateSearchAttributes is set.
+func ValidateSearchAttributes(attributes map[string]interface{}, keys map[string]enumspb.IndexedValueType) error {
+	v := &optionsValidator{}
+	v.searchAttributes("SearchAttributes", attributes, keys)
+	return v.err("StartWorkflowOptions")
+}
+
+func (v *optionsValidator) add(field, problem, fix string) {
+	v.problems = append(v.problems, OptionsProblem{Field: field, Problem: problem, Fix: fix})
+}
+
+func (v *optionsValidator) nonNegative(field string, d time.Duration) {
+	if d < 0 {
+		v.add(field, fmt.Sprintf("negative duration %v", d), "set a positive duration or leave it unset")
+	}
+}
+
+func (v *optionsValidator) taskQueue(field string, taskQueue string) {
+	if strings.HasPrefix(taskQueue, reservedTaskQueuePrefix) {
+		v.add(field, fmt.Sprintf("task queue %q starts with the reserved prefix %q", taskQueue, reservedTaskQueuePrefix),
+			"use a task queue without the prefix")
+	}
+}
+
+func (v *optionsValidator) cronSchedule(schedule string) {
+	if schedule == "" {
+		return
+	}
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		v.add("CronSchedule", fmt.Sprintf("invalid cron schedule %q: %v", schedule, err),
+			"use the standard 5 fields cron format, like \"0 * * * *\" to run every hour")
+	}
+}
+
+func (v *optionsValidator) workflowIDReusePolicy(policy enumspb.WorkflowIdReusePolicy) {
+	if _, ok := enumspb.WorkflowIdReusePolicy_name[int32(policy)]; !ok {
+		v.add("WorkflowIDReusePolicy", fmt.Sprintf("unknown workflow ID reuse policy %v", policy),
+			"use one of the enumspb.WORKFLOW_ID_REUSE_POLICY_* values")
+	}
+}
+
+func (v *optionsValidator) retryPolicy(policy *RetryPolicy) {
+	if policy == nil {
+		return
+	}
+	v.nonNegative("RetryPolicy.InitialInterval", policy.InitialInterval)
+	v.nonNegative("RetryPolicy.MaximumInterval", policy.MaximumInterval)
+	if policy.MaximumInterval > 0 && policy.MaximumInterval < policy.InitialInterval {
+		v.add("RetryPolicy.MaximumInterval",
+			fmt.Sprintf("MaximumInterval %v is less than InitialInterval %v", policy.MaximumInterval, policy.InitialInterval),
+			"set MaximumInterval to at least InitialInterval")
+	}
+	if policy.BackoffCoefficient != 0 && policy.BackoffCoefficient < 1 {
+		v.add("RetryPolicy.BackoffCoefficient", fmt.Sprintf("coefficient %v is less than 1", policy.BackoffCoefficient),
+			"set a coefficient of at least 1, or leave it unset to use 2")
+	}
+	if policy.MaximumAttempts < 0 {
+		v.add("RetryPolicy.MaximumAttempts", fmt.Sprintf("negative number of attempts %v", policy.MaximumAttempts),
+			"set a positive number of attempts, or leave it unset for unlimited attempts")
+	}
+}
+
+func (v *optionsValidator) searchAttributes(field string, attributes map[string]interface{}, keys map[string]enumspb.IndexedValueType) {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		valueType, ok := keys[name]
+		if !ok {
+			v.add(field+"."+name, fmt.Sprintf("search attribute %q isn't registered on the cluster", name),
+				"register the search attribute or fix its name")
+			continue
+		}
+		if value := attributes[name]; !isSearchAttributeValueOfType(value, valueType) {
+			v.add(field+"."+name, fmt.Sprintf("value %v of type %T doesn't match the search attribute type %v",
+				value, value, valueType), "set a value of the type of the search attribute")
+		}
+	}
+}
+
+// isSearchAttributeValueOfType returns true if the value, or every element of the value if it is a slice, can be
+// stored in a search attribute of the type.
+func isSearchAttributeValueOfType(value interface{}, valueType enumspb.IndexedValueType) bool {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < rv.Len(); i++ {
+			if !isSearchAttributeValueOfType(rv.Index(i).Interface(), valueType) {
+				return false
+			}
+		}
+		return true
+	}
+	switch valueType {
+	case enumspb.INDEXED_VALUE_TYPE_STRING, enumspb.INDEXED_VALUE_TYPE_KEYWORD:
+		return rv.Kind() == reflect.String
+	case enumspb.INDEXED_VALUE_TYPE_INT:
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		}
+		return false
+	case enumspb.INDEXED_VALUE_TYPE_DOUBLE:
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		}
+		return false
+	case enumspb.INDEXED_VALUE_TYPE_BOOL:
+		return rv.Kind() == reflect.Bool
+	case enumspb.INDEXED_VALUE_TYPE_DATETIME:
+		switch value := value.(type) {
+		case time.Time:
+			return true
+		case string:
+			_, err := time.Parse(time.RFC3339Nano, value)
+			return err == nil
+		}
+		return false
+	default:
+		// Types added to the server after this version of the SDK aren't validated.
+		return true
+	}
+}
+
+func (v *optionsValidator) err(options string) error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &InvalidOptionsError{Options: options, Problems: v.problems}
+}
@@ -0,0 +1,161 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+)
+
+func TestValidateActivityOptions(t *testing.T) {
+	require.NoError(t, ValidateActivityOptions(ActivityOptions{StartToCloseTimeout: time.Minute}))
+	require.NoError(t, ValidateActivityOptions(ActivityOptions{ScheduleToStartTimeout: time.Minute}))
+	require.NoError(t, ValidateActivityOptions(ActivityOptions{
+		ScheduleToCloseTimeout: time.Hour,
+		RetryPolicy:            &RetryPolicy{InitialInterval: time.Second, MaximumInterval: time.Minute},
+	}))
+
+	err := ValidateActivityOptions(ActivityOptions{
+		TaskQueue:              "/__temporal_sys/tq",
+		ScheduleToStartTimeout: -time.Second,
+		RetryPolicy: &RetryPolicy{
+			InitialInterval:    time.Minute,
+			MaximumInterval:    time.Second,
+			BackoffCoefficient: 0.5,
+			MaximumAttempts:    -1,
+		},
+	})
+	var invalidOptionsErr *InvalidOptionsError
+	require.True(t, errors.As(err, &invalidOptionsErr))
+	require.Equal(t, "ActivityOptions", invalidOptionsErr.Options)
+	var fields []string
+	for _, p := range invalidOptionsErr.Problems {
+		fields = append(fields, p.Field)
+		require.NotEmpty(t, p.Fix)
+	}
+	require.Equal(t, []string{
+		"TaskQueue",
+		"ScheduleToStartTimeout",
+		"RetryPolicy.MaximumInterval",
+		"RetryPolicy.BackoffCoefficient",
+		"RetryPolicy.MaximumAttempts",
+	}, fields)
+	require.Contains(t, err.Error(), "invalid ActivityOptions: TaskQueue: ")
+	require.Contains(t, err.Error(), "; ScheduleToStartTimeout: negative duration -1s, set a positive duration or leave it unset;")
+}
+
+func TestValidateChildWorkflowOptions(t *testing.T) {
+	require.NoError(t, ValidateChildWorkflowOptions(ChildWorkflowOptions{}))
+	require.NoError(t, ValidateChildWorkflowOptions(ChildWorkflowOptions{
+		WorkflowRunTimeout: time.Hour,
+		CronSchedule:       "0 * * * *",
+	}))
+
+	err := ValidateChildWorkflowOptions(ChildWorkflowOptions{
+		WorkflowTaskTimeout: -time.Second,
+		CronSchedule:        "every hour",
+	})
+	var invalidOptionsErr *InvalidOptionsError
+	require.True(t, errors.As(err, &invalidOptionsErr))
+	require.Equal(t, "ChildWorkflowOptions", invalidOptionsErr.Options)
+	require.Len(t, invalidOptionsErr.Problems, 2)
+	require.Equal(t, "WorkflowTaskTimeout", invalidOptionsErr.Problems[0].Field)
+	require.Equal(t, "CronSchedule", invalidOptionsErr.Problems[1].Field)
+}
+
+func TestValidateChildWorkflowOptions_NamespaceAndPolicies(t *testing.T) {
+	require.NoError(t, ValidateChildWorkflowOptions(ChildWorkflowOptions{
+		Namespace:             "other-namespace",
+		ParentClosePolicy:     enumspb.PARENT_CLOSE_POLICY_ABANDON,
+		WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+	}))
+
+	err := ValidateChildWorkflowOptions(ChildWorkflowOptions{
+		Namespace:             " other-namespace",
+		ParentClosePolicy:     enumspb.ParentClosePolicy(42),
+		WorkflowIDReusePolicy: enumspb.WorkflowIdReusePolicy(42),
+	})
+	var invalidOptionsErr *InvalidOptionsError
+	require.True(t, errors.As(err, &invalidOptionsErr))
+	require.Len(t, invalidOptionsErr.Problems, 3)
+	require.Equal(t, "Namespace", invalidOptionsErr.Problems[0].Field)
+	require.Equal(t, "ParentClosePolicy", invalidOptionsErr.Problems[1].Field)
+	require.Equal(t, "WorkflowIDReusePolicy", invalidOptionsErr.Problems[2].Field)
+}
+
+func TestValidateStartWorkflowOptions(t *testing.T) {
+	require.NoError(t, ValidateStartWorkflowOptions(StartWorkflowOptions{
+		TaskQueue:             "task-queue",
+		WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE_FAILED_ONLY,
+		CronSchedule:          "0 * * * *",
+	}))
+
+	err := ValidateStartWorkflowOptions(StartWorkflowOptions{
+		TaskQueue:             "task-queue",
+		WorkflowRunTimeout:    -time.Minute,
+		WorkflowIDReusePolicy: enumspb.WorkflowIdReusePolicy(42),
+		CronSchedule:          "every hour",
+	})
+	var invalidOptionsErr *InvalidOptionsError
+	require.True(t, errors.As(err, &invalidOptionsErr))
+	require.Equal(t, "StartWorkflowOptions", invalidOptionsErr.Options)
+	require.Len(t, invalidOptionsErr.Problems, 3)
+	require.Equal(t, "WorkflowRunTimeout", invalidOptionsErr.Problems[0].Field)
+	require.Equal(t, "CronSchedule", invalidOptionsErr.Problems[1].Field)
+	require.Equal(t, "WorkflowIDReusePolicy", invalidOptionsErr.Problems[2].Field)
+}
+
+func TestValidateSearchAttributes(t *testing.T) {
+	keys := map[string]enumspb.IndexedValueType{
+		"CustomKeywordField":  enumspb.INDEXED_VALUE_TYPE_KEYWORD,
+		"CustomIntField":      enumspb.INDEXED_VALUE_TYPE_INT,
+		"CustomDoubleField":   enumspb.INDEXED_VALUE_TYPE_DOUBLE,
+		"CustomDatetimeField": enumspb.INDEXED_VALUE_TYPE_DATETIME,
+	}
+	require.NoError(t, ValidateSearchAttributes(map[string]interface{}{
+		"CustomKeywordField":  []string{"a", "b"},
+		"CustomIntField":      3,
+		"CustomDoubleField":   3,
+		"CustomDatetimeField": "2021-03-01T12:00:00Z",
+	}, keys))
+
+	err := ValidateSearchAttributes(map[string]interface{}{
+		"CustomKeywordField":  42,
+		"CustomIntField":      1.5,
+		"CustomDatetimeField": time.Now(),
+		"CustomBoolField":     true,
+	}, keys)
+	var invalidOptionsErr *InvalidOptionsError
+	require.True(t, errors.As(err, &invalidOptionsErr))
+	require.Equal(t, "StartWorkflowOptions", invalidOptionsErr.Options)
+	require.Len(t, invalidOptionsErr.Problems, 3)
+	require.Equal(t, "SearchAttributes.CustomBoolField", invalidOptionsErr.Problems[0].Field)
+	require.Equal(t, "SearchAttributes.CustomIntField", invalidOptionsErr.Problems[1].Field)
+	require.Equal(t, "SearchAttributes.CustomKeywordField", invalidOptionsErr.Problems[2].Field)
+}
@@ -0,0 +1,203 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+	filterpb "go.temporal.io/api/filter/v1"
+	"go.temporal.io/api/workflowservice/v1"
+
+	"go.temporal.io/sdk/internal/common/metrics"
+	"go.temporal.io/sdk/log"
+)
+
+const (
+	// replayOnlyInterval is how often a ReplayOnly worker replays the running executions of its workflow types.
+	replayOnlyInterval = time.Minute
+	// replayOnlyExecutionsPerType is the number of most recently started executions replayed per workflow type.
+	replayOnlyExecutionsPerType = 10
+	// replayOnlyPageSize is the page size of the listing of the running executions of a workflow type.
+	replayOnlyPageSize = 100
+	// replayOnlyTimeout bounds each call to the server, so that an unresponsive server doesn't stall the replays.
+	replayOnlyTimeout = 10 * time.Second
+)
+
+// replayOnlyWorker replays the histories of the running executions of the workflow types registered with a ReplayOnly
+// worker which are processed on its task queue. The histories are fetched with GetWorkflowExecutionHistory, so no workflow task is taken off the task queue
+// and the workflows keep being processed by the other workers.
+type replayOnlyWorker struct {
+	service      workflowservice.WorkflowServiceClient
+	namespace    string
+	taskQueue    string
+	replayer     *WorkflowReplayer
+	logger       log.Logger
+	metricsScope tally.Scope
+
+	// ctx is canceled on stop, aborting the call in flight.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func newReplayOnlyWorker(
+	service workflowservice.WorkflowServiceClient,
+	namespace string,
+	taskQueue string,
+	registry *registry,
+	logger log.Logger,
+	metricsScope tally.Scope,
+) *replayOnlyWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &replayOnlyWorker{
+		service:      service,
+		namespace:    namespace,
+		taskQueue:    taskQueue,
+		replayer:     &WorkflowReplayer{registry: registry},
+		logger:       logger,
+		metricsScope: metricsScope,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// start replays the running executions in the background, right away and then every replayOnlyInterval.
+func (w *replayOnlyWorker) start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.replayAll()
+		ticker := time.NewTicker(replayOnlyInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-ticker.C:
+				w.replayAll()
+			}
+		}
+	}()
+}
+
+func (w *replayOnlyWorker) stop() {
+	w.stopOnce.Do(func() {
+		w.cancel()
+		w.wg.Wait()
+	})
+}
+
+func (w *replayOnlyWorker) replayAll() {
+	for _, workflowType := range sortedStrings(w.replayer.registry.getRegisteredWorkflowTypes()) {
+		executions, err := w.listRunningExecutions(workflowType)
+		if err != nil {
+			if w.ctx.Err() == nil {
+				w.logger.Warn("Unable to list the running executions to replay.", tagWorkflowType, workflowType, tagError, err)
+			}
+			continue
+		}
+		for _, execution := range executions {
+			if w.ctx.Err() != nil {
+				return
+			}
+			w.replay(workflowType, execution)
+		}
+	}
+}
+
+// listRunningExecutions returns the most recently started running executions of the workflow type on the task queue
+// of the worker. The open executions can't be filtered by task queue on the server, so they are listed page by page
+// until enough of them are found.
+func (w *replayOnlyWorker) listRunningExecutions(workflowType string) ([]WorkflowExecution, error) {
+	var executions []WorkflowExecution
+	var nextPageToken []byte
+	for {
+		response, err := w.listRunningExecutionsPage(workflowType, nextPageToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range response.GetExecutions() {
+			if info.GetTaskQueue() != w.taskQueue {
+				continue
+			}
+			executions = append(executions, WorkflowExecution{
+				ID:    info.GetExecution().GetWorkflowId(),
+				RunID: info.GetExecution().GetRunId(),
+			})
+			if len(executions) == replayOnlyExecutionsPerType {
+				return executions, nil
+			}
+		}
+		nextPageToken = response.GetNextPageToken()
+		if len(nextPageToken) == 0 {
+			return executions, nil
+		}
+	}
+}
+
+func (w *replayOnlyWorker) listRunningExecutionsPage(workflowType string, nextPageToken []byte) (*workflowservice.ListOpenWorkflowExecutionsResponse, error) {
+	ctx, cancel := context.WithTimeout(w.ctx, replayOnlyTimeout)
+	defer cancel()
+	grpcCtx, grpcCancel := newGRPCContext(ctx, defaultGrpcRetryParameters(ctx))
+	defer grpcCancel()
+	return w.service.ListOpenWorkflowExecutions(grpcCtx, &workflowservice.ListOpenWorkflowExecutionsRequest{
+		Namespace:       w.namespace,
+		MaximumPageSize: replayOnlyPageSize,
+		NextPageToken:   nextPageToken,
+		Filters: &workflowservice.ListOpenWorkflowExecutionsRequest_TypeFilter{
+			TypeFilter: &filterpb.WorkflowTypeFilter{Name: workflowType},
+		},
+	})
+}
+
+// replay replays the history of the execution up to its last event, counting the executions which fail to replay.
+func (w *replayOnlyWorker) replay(workflowType string, execution WorkflowExecution) {
+	ctx, cancel := context.WithTimeout(w.ctx, replayOnlyTimeout)
+	defer cancel()
+	history, err := getReplayWorkflowExecutionHistory(ctx, w.service, w.namespace, execution)
+	if err != nil {
+		if w.ctx.Err() == nil {
+			w.logger.Warn("Unable to get the history of the execution to replay.",
+				tagWorkflowType, workflowType, tagWorkflowID, execution.ID, tagRunID, execution.RunID, tagError, err)
+		}
+		return
+	}
+	if len(history.GetEvents()) < 3 {
+		// No workflow task has been processed yet, there is nothing to replay.
+		return
+	}
+
+	workflowMetricsScope := metrics.GetMetricsScopeForWorkflow(w.metricsScope, workflowType)
+	workflowMetricsScope.Counter(metrics.ReplayOnlyExecutionCounter).Inc(1)
+	if err := w.replayer.replayWorkflowHistory(w.logger, w.service, w.namespace, history, nil); err != nil {
+		workflowMetricsScope.Counter(metrics.ReplayOnlyDivergenceCounter).Inc(1)
+		w.logger.Warn("Failed to replay workflow execution.",
+			tagWorkflowType, workflowType, tagWorkflowID, execution.ID, tagRunID, execution.RunID, tagError, err)
+	}
+}
@@ -0,0 +1,101 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	commonpb "go.temporal.io/api/common/v1"
+	historypb "go.temporal.io/api/history/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/api/workflowservicemock/v1"
+	"google.golang.org/grpc"
+
+	"go.temporal.io/sdk/internal/common/metrics"
+	ilog "go.temporal.io/sdk/internal/log"
+)
+
+func TestReplayOnlyWorker(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	service := workflowservicemock.NewMockWorkflowServiceClient(mockCtrl)
+
+	divergingEvents := createHistoryForGetVersionTests("testReplayWorkflowGetVersion")
+	divergingEvents[12].GetActivityTaskScheduledEventAttributes().ActivityType.Name = "otherActivity"
+	histories := map[string]*historypb.History{
+		"deterministic": {Events: createHistoryForGetVersionTests("testReplayWorkflowGetVersion")[:19]},
+		"diverging":     {Events: divergingEvents[:19]},
+		"new":           {Events: createHistoryForGetVersionTests("testReplayWorkflowGetVersion")[:2]},
+	}
+	// The executions on other task queues are skipped and the pages are listed until the last one.
+	pages := map[string]*workflowservice.ListOpenWorkflowExecutionsResponse{
+		"": {
+			Executions: []*workflowpb.WorkflowExecutionInfo{
+				{Execution: &commonpb.WorkflowExecution{WorkflowId: "workflow", RunId: "deterministic"}, TaskQueue: "taskQueue"},
+				{Execution: &commonpb.WorkflowExecution{WorkflowId: "workflow", RunId: "other"}, TaskQueue: "otherTaskQueue"},
+			},
+			NextPageToken: []byte("page2"),
+		},
+		"page2": {
+			Executions: []*workflowpb.WorkflowExecutionInfo{
+				{Execution: &commonpb.WorkflowExecution{WorkflowId: "workflow", RunId: "diverging"}, TaskQueue: "taskQueue"},
+				{Execution: &commonpb.WorkflowExecution{WorkflowId: "workflow", RunId: "new"}, TaskQueue: "taskQueue"},
+			},
+		},
+	}
+	service.EXPECT().ListOpenWorkflowExecutions(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(_ context.Context, req *workflowservice.ListOpenWorkflowExecutionsRequest, _ ...grpc.CallOption) (*workflowservice.ListOpenWorkflowExecutionsResponse, error) {
+			require.Equal(t, "testReplayWorkflowGetVersion", req.GetTypeFilter().GetName())
+			return pages[string(req.GetNextPageToken())], nil
+		})
+	// The histories are read, no workflow task is polled nor responded to.
+	service.EXPECT().GetWorkflowExecutionHistory(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(_ context.Context, req *workflowservice.GetWorkflowExecutionHistoryRequest, _ ...grpc.CallOption) (*workflowservice.GetWorkflowExecutionHistoryResponse, error) {
+			return &workflowservice.GetWorkflowExecutionHistoryResponse{History: histories[req.GetExecution().GetRunId()]}, nil
+		})
+
+	registry := newRegistry()
+	registry.RegisterWorkflow(testReplayWorkflowGetVersion)
+	scope := tally.NewTestScope("", nil)
+	w := newReplayOnlyWorker(service, "namespace", "taskQueue", registry, ilog.NewNopLogger(), scope)
+	w.replayAll()
+
+	replayed, diverged := int64(0), int64(0)
+	for _, counter := range scope.Snapshot().Counters() {
+		switch counter.Name() {
+		case metrics.ReplayOnlyExecutionCounter:
+			replayed += counter.Value()
+		case metrics.ReplayOnlyDivergenceCounter:
+			diverged += counter.Value()
+		}
+	}
+	require.Equal(t, int64(2), replayed)
+	require.Equal(t, int64(1), diverged)
+}
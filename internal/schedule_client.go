@@ -0,0 +1,386 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/robfig/cron"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+
+	"go.temporal.io/sdk/internal/common"
+)
+
+const (
+	schedulePausedReason  = "schedule paused"
+	scheduleDeletedReason = "schedule deleted"
+
+	defaultScheduleNextRunCount   = 5
+	defaultScheduleRecentRunCount = 10
+
+	scheduleRestoreTimeout = 10 * time.Second
+)
+
+type (
+	// ScheduleClient manages schedules, which run a workflow on a cron schedule. A schedule is backed by a cron
+	// workflow with the ID of the schedule, so the workflows run by the schedule can be found and inspected like any
+	// other cron workflow. Create it with NewScheduleClient.
+	ScheduleClient interface {
+		// Create creates the schedule by starting its backing cron workflow. It returns
+		// WorkflowExecutionAlreadyStartedError if the schedule already exists and is not paused.
+		Create(ctx context.Context, options ScheduleOptions) error
+
+		// Update replaces the backing cron workflow of the schedule with one started with the new options, resuming
+		// the schedule if it was paused. The run in progress, if any, is terminated. If the new workflow fails to
+		// start, the terminated one is started again with its previous options so the schedule is not lost.
+		Update(ctx context.Context, options ScheduleOptions) error
+
+		// Pause stops the schedule by terminating its backing cron workflow with the note as the reason. Resume it
+		// with Update.
+		Pause(ctx context.Context, scheduleID string, note string) error
+
+		// Trigger runs the workflow of the schedule once immediately, outside of the cron schedule, as a separate
+		// workflow with the ID "<schedule ID>-triggered-<unix nanoseconds>". The options must be the ones the
+		// schedule was created or last updated with.
+		Trigger(ctx context.Context, options ScheduleOptions) (WorkflowRun, error)
+
+		// Delete stops the schedule by terminating its backing cron workflow. The histories of the past runs are kept
+		// for the retention period of the namespace.
+		Delete(ctx context.Context, scheduleID string) error
+
+		// Describe returns the state of the schedule with its next and recent runs.
+		Describe(ctx context.Context, scheduleID string) (*ScheduleDescription, error)
+	}
+
+	// ScheduleOptions configure a schedule created or updated with ScheduleClient.
+	ScheduleOptions struct {
+		// ID of the schedule, which is also the workflow ID of its backing cron workflow. Required.
+		ID string
+
+		// CronSchedule the workflow is run on, see StartWorkflowOptions.CronSchedule. Required.
+		CronSchedule string
+
+		// Workflow function or workflow type name run by the schedule, with its Args. Required.
+		Workflow interface{}
+		Args     []interface{}
+
+		// TaskQueue the workflow runs on. Required.
+		TaskQueue string
+
+		// Timeouts of each run, see StartWorkflowOptions. Optional.
+		WorkflowRunTimeout  time.Duration
+		WorkflowTaskTimeout time.Duration
+
+		// RetryPolicy of each run. Optional.
+		RetryPolicy *RetryPolicy
+
+		// Memo and SearchAttributes of the backing cron workflow. Optional.
+		Memo             map[string]interface{}
+		SearchAttributes map[string]interface{}
+	}
+
+	// ScheduleDescription is the state of a schedule returned by ScheduleClient.Describe.
+	ScheduleDescription struct {
+		ID           string
+		CronSchedule string
+		WorkflowType string
+		TaskQueue    string
+
+		// Paused is true when the backing cron workflow is not running, after ScheduleClient.Pause or Delete.
+		Paused bool
+
+		// NextRunTimes are the next times the workflow is scheduled to run at, empty when Paused.
+		NextRunTimes []time.Time
+
+		// RecentRuns are the most recent closed runs of the workflow, most recent first.
+		RecentRuns []ScheduleRun
+	}
+
+	// ScheduleRun describes a closed run of the workflow of a schedule.
+	ScheduleRun struct {
+		RunID     string
+		StartTime time.Time
+		CloseTime time.Time
+		Status    enumspb.WorkflowExecutionStatus
+	}
+
+	scheduleClient struct {
+		client Client
+	}
+
+	// workflowRestarter is implemented by the clients able to start a workflow again from the started event of one
+	// of its runs, which the schedule client uses to restore a schedule after a failed update.
+	workflowRestarter interface {
+		restartWorkflow(ctx context.Context, workflowID string, start *historypb.WorkflowExecutionStartedEventAttributes) error
+	}
+)
+
+// NewScheduleClient creates a ScheduleClient managing schedules with the client. Schedules are implemented with
+// cron workflows, so they work with any server version supporting cron workflows.
+func NewScheduleClient(c Client) ScheduleClient {
+	return &scheduleClient{client: c}
+}
+
+func (s *scheduleClient) Create(ctx context.Context, options ScheduleOptions) error {
+	if err := validateScheduleOptions(options); err != nil {
+		return err
+	}
+	_, err := s.client.ExecuteWorkflow(ctx, scheduleStartWorkflowOptions(options), options.Workflow, options.Args...)
+	return err
+}
+
+func (s *scheduleClient) Update(ctx context.Context, options ScheduleOptions) error {
+	if err := validateScheduleOptions(options); err != nil {
+		return err
+	}
+	// The new workflow has the ID of the schedule, so it can only be started once the current one is terminated.
+	// The start of the current one is read first to restart it if the new one fails to start.
+	previous, err := s.runningScheduleStart(ctx, options.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.terminate(ctx, options.ID, "schedule updated"); err != nil {
+		return err
+	}
+	_, err = s.client.ExecuteWorkflow(ctx, scheduleStartWorkflowOptions(options), options.Workflow, options.Args...)
+	if err != nil && previous != nil {
+		if restoreErr := s.restore(options.ID, previous); restoreErr != nil {
+			return fmt.Errorf("failed to update schedule %q: %w, and failed to restore it: %v", options.ID, err, restoreErr)
+		}
+	}
+	return err
+}
+
+func (s *scheduleClient) Pause(ctx context.Context, scheduleID string, note string) error {
+	reason := schedulePausedReason
+	if note != "" {
+		reason = fmt.Sprintf("%s: %s", schedulePausedReason, note)
+	}
+	return s.terminate(ctx, scheduleID, reason)
+}
+
+func (s *scheduleClient) Trigger(ctx context.Context, options ScheduleOptions) (WorkflowRun, error) {
+	if err := validateScheduleOptions(options); err != nil {
+		return nil, err
+	}
+	startOptions := scheduleStartWorkflowOptions(options)
+	startOptions.ID = fmt.Sprintf("%s-triggered-%d", options.ID, time.Now().UnixNano())
+	startOptions.CronSchedule = ""
+	return s.client.ExecuteWorkflow(ctx, startOptions, options.Workflow, options.Args...)
+}
+
+func (s *scheduleClient) Delete(ctx context.Context, scheduleID string) error {
+	return s.terminate(ctx, scheduleID, scheduleDeletedReason)
+}
+
+func (s *scheduleClient) Describe(ctx context.Context, scheduleID string) (*ScheduleDescription, error) {
+	execution, err := s.client.DescribeWorkflow(ctx, scheduleID, "")
+	if err != nil {
+		return nil, err
+	}
+	description := &ScheduleDescription{
+		ID:           scheduleID,
+		WorkflowType: execution.WorkflowType,
+		Paused:       execution.Status != enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING,
+	}
+
+	// The cron schedule and the task queue are only recorded in the first event of the run.
+	iter := s.client.GetWorkflowHistory(ctx, scheduleID, execution.RunID, false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	if iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		attributes := event.GetWorkflowExecutionStartedEventAttributes()
+		description.CronSchedule = attributes.GetCronSchedule()
+		description.TaskQueue = attributes.GetTaskQueue().GetName()
+	}
+	if !description.Paused && description.CronSchedule != "" {
+		description.NextRunTimes, err = nextScheduleRunTimes(description.CronSchedule, time.Now(), defaultScheduleNextRunCount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := s.client.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		PageSize: defaultScheduleRecentRunCount + 1,
+		Query:    "WorkflowId = " + quoteVisibilityQueryValue(scheduleID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range response.GetExecutions() {
+		if info.GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING {
+			continue
+		}
+		description.RecentRuns = append(description.RecentRuns, ScheduleRun{
+			RunID:     info.GetExecution().GetRunId(),
+			StartTime: common.TimeValue(info.GetStartTime()),
+			CloseTime: common.TimeValue(info.GetCloseTime()),
+			Status:    info.GetStatus(),
+		})
+	}
+	sort.Slice(description.RecentRuns, func(i, j int) bool {
+		return description.RecentRuns[i].StartTime.After(description.RecentRuns[j].StartTime)
+	})
+	if len(description.RecentRuns) > defaultScheduleRecentRunCount {
+		description.RecentRuns = description.RecentRuns[:defaultScheduleRecentRunCount]
+	}
+	return description, nil
+}
+
+// terminate terminates the backing cron workflow of the schedule, ignoring a schedule which is already paused.
+func (s *scheduleClient) terminate(ctx context.Context, scheduleID string, reason string) error {
+	err := s.client.TerminateWorkflow(ctx, scheduleID, "", reason)
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+// runningScheduleStart returns the started event attributes of the running backing cron workflow of the schedule, or
+// nil if the schedule is paused or does not exist.
+func (s *scheduleClient) runningScheduleStart(ctx context.Context, scheduleID string) (*historypb.WorkflowExecutionStartedEventAttributes, error) {
+	execution, err := s.client.DescribeWorkflow(ctx, scheduleID, "")
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if execution.Status != enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		return nil, nil
+	}
+	iter := s.client.GetWorkflowHistory(ctx, scheduleID, execution.RunID, false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	if !iter.HasNext() {
+		return nil, nil
+	}
+	event, err := iter.Next()
+	if err != nil {
+		return nil, err
+	}
+	return event.GetWorkflowExecutionStartedEventAttributes(), nil
+}
+
+// restore starts the backing cron workflow of the schedule again as it was started before a failed update. It does
+// not use the context of the update, which may be the reason the update failed.
+func (s *scheduleClient) restore(scheduleID string, start *historypb.WorkflowExecutionStartedEventAttributes) error {
+	restarter, ok := s.client.(workflowRestarter)
+	if !ok {
+		return errors.New("client cannot restart workflows")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), scheduleRestoreTimeout)
+	defer cancel()
+	return restarter.restartWorkflow(ctx, scheduleID, start)
+}
+
+// restartWorkflow starts the workflow again with the type, input and options recorded in the started event of one of
+// its runs, which must be closed.
+func (wc *WorkflowClient) restartWorkflow(ctx context.Context, workflowID string, start *historypb.WorkflowExecutionStartedEventAttributes) error {
+	grpcCtx, cancel := newGRPCContext(ctx, defaultGrpcRetryParameters(ctx))
+	defer cancel()
+	_, err := wc.workflowService.StartWorkflowExecution(grpcCtx, &workflowservice.StartWorkflowExecutionRequest{
+		Namespace:                wc.namespace,
+		RequestId:                uuid.New(),
+		WorkflowId:               workflowID,
+		WorkflowType:             start.GetWorkflowType(),
+		TaskQueue:                start.GetTaskQueue(),
+		Input:                    start.GetInput(),
+		WorkflowExecutionTimeout: start.GetWorkflowExecutionTimeout(),
+		WorkflowRunTimeout:       start.GetWorkflowRunTimeout(),
+		WorkflowTaskTimeout:      start.GetWorkflowTaskTimeout(),
+		Identity:                 wc.identity,
+		WorkflowIdReusePolicy:    enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE,
+		RetryPolicy:              start.GetRetryPolicy(),
+		CronSchedule:             start.GetCronSchedule(),
+		Memo:                     start.GetMemo(),
+		SearchAttributes:         start.GetSearchAttributes(),
+		Header:                   start.GetHeader(),
+	})
+	return err
+}
+
+func scheduleStartWorkflowOptions(options ScheduleOptions) StartWorkflowOptions {
+	return StartWorkflowOptions{
+		ID:                                       options.ID,
+		TaskQueue:                                options.TaskQueue,
+		WorkflowRunTimeout:                       options.WorkflowRunTimeout,
+		WorkflowTaskTimeout:                      options.WorkflowTaskTimeout,
+		WorkflowIDReusePolicy:                    enumspb.WORKFLOW_ID_REUSE_POLICY_ALLOW_DUPLICATE,
+		RetryPolicy:                              options.RetryPolicy,
+		CronSchedule:                             options.CronSchedule,
+		Memo:                                     options.Memo,
+		SearchAttributes:                         options.SearchAttributes,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}
+}
+
+func validateScheduleOptions(options ScheduleOptions) error {
+	if options.ID == "" {
+		return errors.New("schedule ID is not set")
+	}
+	if options.Workflow == nil {
+		return errors.New("schedule workflow is not set")
+	}
+	if options.TaskQueue == "" {
+		return errors.New("schedule task queue is not set")
+	}
+	if options.CronSchedule == "" {
+		return errors.New("schedule cron schedule is not set")
+	}
+	if _, err := cron.ParseStandard(options.CronSchedule); err != nil {
+		return fmt.Errorf("invalid cron schedule %q: %w", options.CronSchedule, err)
+	}
+	return nil
+}
+
+// nextScheduleRunTimes returns the next count times the cron schedule fires at after now, in UTC like the server
+// evaluates cron schedules.
+func nextScheduleRunTimes(cronSchedule string, now time.Time, count int) ([]time.Time, error) {
+	schedule, err := cron.ParseStandard(cronSchedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron schedule %q: %w", cronSchedule, err)
+	}
+	times := make([]time.Time, 0, count)
+	next := now.UTC()
+	for i := 0; i < count; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		times = append(times, next)
+	}
+	return times, nil
+}
@@ -0,0 +1,65 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextScheduleRunTimes(t *testing.T) {
+	now := time.Date(2021, 8, 1, 10, 30, 0, 0, time.UTC)
+	times, err := nextScheduleRunTimes("0 * * * *", now, 3)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2021, 8, 1, 11, 0, 0, 0, time.UTC),
+		time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2021, 8, 1, 13, 0, 0, 0, time.UTC),
+	}, times)
+
+	_, err = nextScheduleRunTimes("every hour", now, 3)
+	require.Error(t, err)
+}
+
+func TestValidateScheduleOptions(t *testing.T) {
+	options := ScheduleOptions{
+		ID:           "schedule",
+		CronSchedule: "*/5 * * * *",
+		Workflow:     "workflow",
+		TaskQueue:    "task-queue",
+	}
+	require.NoError(t, validateScheduleOptions(options))
+	require.Equal(t, "*/5 * * * *", scheduleStartWorkflowOptions(options).CronSchedule)
+
+	options.CronSchedule = "* *"
+	require.Error(t, validateScheduleOptions(options))
+	options.CronSchedule = ""
+	require.Error(t, validateScheduleOptions(options))
+	options.CronSchedule = "* * * * *"
+	options.TaskQueue = ""
+	require.Error(t, validateScheduleOptions(options))
+}
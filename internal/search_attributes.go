@@ -0,0 +1,259 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+
+	"go.temporal.io/sdk/converter"
+)
+
+type (
+	// SearchAttributeKeyString is the key of a search attribute of type Text, searched with full text search.
+	SearchAttributeKeyString struct {
+		name string
+	}
+
+	// SearchAttributeKeyKeyword is the key of a search attribute of type Keyword, searched by exact value.
+	SearchAttributeKeyKeyword struct {
+		name string
+	}
+
+	// SearchAttributeKeyInt64 is the key of a search attribute of type Int.
+	SearchAttributeKeyInt64 struct {
+		name string
+	}
+
+	// SearchAttributeKeyFloat64 is the key of a search attribute of type Double.
+	SearchAttributeKeyFloat64 struct {
+		name string
+	}
+
+	// SearchAttributeKeyBool is the key of a search attribute of type Bool.
+	SearchAttributeKeyBool struct {
+		name string
+	}
+
+	// SearchAttributeKeyTime is the key of a search attribute of type Datetime.
+	SearchAttributeKeyTime struct {
+		name string
+	}
+
+	// SearchAttributeUpdate is a value of a search attribute to upsert with UpsertTypedSearchAttributes, created with
+	// the ValueSet method of the key of the search attribute so that the type of the value is checked at compile time.
+	SearchAttributeUpdate struct {
+		name  string
+		value interface{}
+	}
+
+	// SearchAttributes are the search attributes of a workflow, read with the keys of the search attributes so that
+	// the type of the values is checked at compile time.
+	SearchAttributes struct {
+		fields map[string]*commonpb.Payload
+	}
+)
+
+var errSearchAttributeKeyNameNotSet = errors.New("search attribute key name is not set")
+
+// NewSearchAttributeKeyString creates the key of a search attribute of type Text.
+func NewSearchAttributeKeyString(name string) SearchAttributeKeyString {
+	return SearchAttributeKeyString{name: name}
+}
+
+// NewSearchAttributeKeyKeyword creates the key of a search attribute of type Keyword.
+func NewSearchAttributeKeyKeyword(name string) SearchAttributeKeyKeyword {
+	return SearchAttributeKeyKeyword{name: name}
+}
+
+// NewSearchAttributeKeyInt64 creates the key of a search attribute of type Int.
+func NewSearchAttributeKeyInt64(name string) SearchAttributeKeyInt64 {
+	return SearchAttributeKeyInt64{name: name}
+}
+
+// NewSearchAttributeKeyFloat64 creates the key of a search attribute of type Double.
+func NewSearchAttributeKeyFloat64(name string) SearchAttributeKeyFloat64 {
+	return SearchAttributeKeyFloat64{name: name}
+}
+
+// NewSearchAttributeKeyBool creates the key of a search attribute of type Bool.
+func NewSearchAttributeKeyBool(name string) SearchAttributeKeyBool {
+	return SearchAttributeKeyBool{name: name}
+}
+
+// NewSearchAttributeKeyTime creates the key of a search attribute of type Datetime.
+func NewSearchAttributeKeyTime(name string) SearchAttributeKeyTime {
+	return SearchAttributeKeyTime{name: name}
+}
+
+// GetName returns the name of the search attribute.
+func (k SearchAttributeKeyString) GetName() string {
+	return k.name
+}
+
+// ValueSet creates the update setting the search attribute to value.
+func (k SearchAttributeKeyString) ValueSet(value string) SearchAttributeUpdate {
+	return SearchAttributeUpdate{name: k.name, value: value}
+}
+
+// GetName returns the name of the search attribute.
+func (k SearchAttributeKeyKeyword) GetName() string {
+	return k.name
+}
+
+// ValueSet creates the update setting the search attribute to value.
+func (k SearchAttributeKeyKeyword) ValueSet(value string) SearchAttributeUpdate {
+	return SearchAttributeUpdate{name: k.name, value: value}
+}
+
+// GetName returns the name of the search attribute.
+func (k SearchAttributeKeyInt64) GetName() string {
+	return k.name
+}
+
+// ValueSet creates the update setting the search attribute to value.
+func (k SearchAttributeKeyInt64) ValueSet(value int64) SearchAttributeUpdate {
+	return SearchAttributeUpdate{name: k.name, value: value}
+}
+
+// GetName returns the name of the search attribute.
+func (k SearchAttributeKeyFloat64) GetName() string {
+	return k.name
+}
+
+// ValueSet creates the update setting the search attribute to value.
+func (k SearchAttributeKeyFloat64) ValueSet(value float64) SearchAttributeUpdate {
+	return SearchAttributeUpdate{name: k.name, value: value}
+}
+
+// GetName returns the name of the search attribute.
+func (k SearchAttributeKeyBool) GetName() string {
+	return k.name
+}
+
+// ValueSet creates the update setting the search attribute to value.
+func (k SearchAttributeKeyBool) ValueSet(value bool) SearchAttributeUpdate {
+	return SearchAttributeUpdate{name: k.name, value: value}
+}
+
+// GetName returns the name of the search attribute.
+func (k SearchAttributeKeyTime) GetName() string {
+	return k.name
+}
+
+// ValueSet creates the update setting the search attribute to value.
+func (k SearchAttributeKeyTime) ValueSet(value time.Time) SearchAttributeUpdate {
+	return SearchAttributeUpdate{name: k.name, value: value}
+}
+
+// GetName returns the name of the updated search attribute.
+func (u SearchAttributeUpdate) GetName() string {
+	return u.name
+}
+
+// GetValue returns the value the search attribute is set to.
+func (u SearchAttributeUpdate) GetValue() interface{} {
+	return u.value
+}
+
+func newSearchAttributes(attributes *commonpb.SearchAttributes) SearchAttributes {
+	return SearchAttributes{fields: attributes.GetIndexedFields()}
+}
+
+// Size returns the number of search attributes.
+func (s SearchAttributes) Size() int {
+	return len(s.fields)
+}
+
+// ContainsKey returns true if the search attribute with the name is set.
+func (s SearchAttributes) ContainsKey(name string) bool {
+	_, ok := s.fields[name]
+	return ok
+}
+
+// GetString returns the value of the search attribute, false if it isn't set or isn't a string.
+func (s SearchAttributes) GetString(key SearchAttributeKeyString) (string, bool) {
+	var value string
+	return value, s.get(key.name, &value)
+}
+
+// GetKeyword returns the value of the search attribute, false if it isn't set or isn't a string.
+func (s SearchAttributes) GetKeyword(key SearchAttributeKeyKeyword) (string, bool) {
+	var value string
+	return value, s.get(key.name, &value)
+}
+
+// GetInt64 returns the value of the search attribute, false if it isn't set or isn't an integer.
+func (s SearchAttributes) GetInt64(key SearchAttributeKeyInt64) (int64, bool) {
+	var value int64
+	return value, s.get(key.name, &value)
+}
+
+// GetFloat64 returns the value of the search attribute, false if it isn't set or isn't a number.
+func (s SearchAttributes) GetFloat64(key SearchAttributeKeyFloat64) (float64, bool) {
+	var value float64
+	return value, s.get(key.name, &value)
+}
+
+// GetBool returns the value of the search attribute, false if it isn't set or isn't a bool.
+func (s SearchAttributes) GetBool(key SearchAttributeKeyBool) (bool, bool) {
+	var value bool
+	return value, s.get(key.name, &value)
+}
+
+// GetTime returns the value of the search attribute, false if it isn't set or isn't a time.
+func (s SearchAttributes) GetTime(key SearchAttributeKeyTime) (time.Time, bool) {
+	var value time.Time
+	return value, s.get(key.name, &value)
+}
+
+func (s SearchAttributes) get(name string, valuePtr interface{}) bool {
+	payload, ok := s.fields[name]
+	if !ok {
+		return false
+	}
+	return converter.GetDefaultDataConverter().FromPayload(payload, valuePtr) == nil
+}
+
+// GetTypedSearchAttributes returns the search attributes of the workflow, the ones it was started with merged with
+// the ones upserted since, read with the keys of the search attributes.
+func GetTypedSearchAttributes(ctx Context) SearchAttributes {
+	return newSearchAttributes(GetWorkflowInfo(ctx).SearchAttributes)
+}
+
+// UpsertTypedSearchAttributes adds or updates search attributes of the workflow like UpsertSearchAttributes, with the
+// updates created with the ValueSet method of the keys of the search attributes.
+func UpsertTypedSearchAttributes(ctx Context, updates ...SearchAttributeUpdate) error {
+	attributes := make(map[string]interface{}, len(updates))
+	for _, update := range updates {
+		if update.name == "" {
+			return errSearchAttributeKeyNameNotSet
+		}
+		attributes[update.name] = update.value
+	}
+	return UpsertSearchAttributes(ctx, attributes)
+}
@@ -532,6 +532,43 @@ func (s *SessionTestSuite) TestExecuteActivityInFailedSession() {
 	s.Equal(ErrSessionFailed.Error(), err1.Error())
 }
 
+func (s *SessionTestSuite) TestSimulatedSessionFailure() {
+	workflowFn := func(ctx Context) error {
+		ao := ActivityOptions{
+			ScheduleToStartTimeout: time.Minute,
+			StartToCloseTimeout:    time.Minute,
+			HeartbeatTimeout:       time.Second * 20,
+		}
+		ctx = WithActivityOptions(ctx, ao)
+		sessionCtx, err := CreateSession(ctx, s.sessionOptions)
+		if err != nil {
+			return err
+		}
+		defer CompleteSession(sessionCtx)
+
+		if err := ExecuteActivity(sessionCtx, testSessionActivity, "a").Get(sessionCtx, nil); err != nil {
+			return err
+		}
+		_ = Sleep(ctx, time.Minute)
+		if GetSessionInfo(sessionCtx).sessionState != sessionStateFailed {
+			return errors.New("session state should be failed")
+		}
+		return ExecuteActivity(sessionCtx, testSessionActivity, "b").Get(sessionCtx, nil)
+	}
+
+	env := s.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(WorkerOptions{EnableSessionWorker: true})
+	env.SetSessionFailureAfter(time.Second * 30)
+	env.RegisterWorkflow(workflowFn)
+	env.RegisterActivity(testSessionActivity)
+	env.ExecuteWorkflow(workflowFn)
+
+	s.True(env.IsWorkflowCompleted())
+	err := env.GetWorkflowError()
+	s.Error(err)
+	s.Contains(err.Error(), ErrSessionFailed.Error())
+}
+
 func (s *SessionTestSuite) TestExecuteActivityInClosedSession() {
 	workflowFn := func(ctx Context) error {
 		ao := ActivityOptions{
@@ -0,0 +1,127 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	// WorkflowStackTrace is the result of the QueryTypeStackTrace query parsed into the stacks of the workflow
+	// coroutines, see Client.GetWorkflowStackTrace.
+	WorkflowStackTrace struct {
+		Coroutines []CoroutineStackTrace
+		// Raw is the unparsed result of the query.
+		Raw string
+	}
+
+	// CoroutineStackTrace is the stack of a workflow coroutine, the root workflow function or a function started
+	// with workflow.Go.
+	CoroutineStackTrace struct {
+		// Name is the name of the coroutine, "root" for the workflow function and the name given to workflow.GoNamed.
+		Name string
+		// Status is where the coroutine yielded, e.g. "blocked on chan-1.Receive" or "Await".
+		Status string
+		// BlockedOn is the channel or selector operation the coroutine is blocked on, e.g. "chan-1.Receive", or the
+		// Status if the coroutine is blocked on something else.
+		BlockedOn string
+		// Frames are the calls of the stack, the innermost first.
+		Frames []StackFrame
+	}
+
+	// StackFrame is a call of a CoroutineStackTrace.
+	StackFrame struct {
+		// Function is the fully qualified name of the function, without its arguments.
+		Function string
+		File     string
+		Line     int
+	}
+)
+
+var stackTraceHeaderRegexp = regexp.MustCompile(`^(?:coroutine|goroutine) (.+) \[(.*)\]:$`)
+
+// ParseWorkflowStackTrace parses the result of the QueryTypeStackTrace query. Lines it doesn't recognize are ignored,
+// and the coroutines are returned in the order of the query result.
+func ParseWorkflowStackTrace(stackTrace string) *WorkflowStackTrace {
+	result := &WorkflowStackTrace{Raw: stackTrace}
+	var current *CoroutineStackTrace
+	var function string
+	for _, line := range strings.Split(stackTrace, "\n") {
+		if match := stackTraceHeaderRegexp.FindStringSubmatch(line); match != nil {
+			result.Coroutines = append(result.Coroutines, CoroutineStackTrace{
+				Name:      match[1],
+				Status:    match[2],
+				BlockedOn: strings.TrimPrefix(match[2], "blocked on "),
+			})
+			current = &result.Coroutines[len(result.Coroutines)-1]
+			function = ""
+			continue
+		}
+		if current == nil || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") {
+			function = parseStackFunction(line)
+			continue
+		}
+		if function == "" {
+			continue
+		}
+		file, lineNumber := parseStackLocation(strings.TrimPrefix(line, "\t"))
+		current.Frames = append(current.Frames, StackFrame{Function: function, File: file, Line: lineNumber})
+		function = ""
+	}
+	return result
+}
+
+// parseStackFunction strips the arguments from a function line of a stack trace, e.g.
+// "go.temporal.io/sdk/internal.(*futureImpl).Get(0xc000010000, ...)".
+func parseStackFunction(line string) string {
+	line = strings.TrimPrefix(line, "created by ")
+	if strings.HasSuffix(line, ")") {
+		if i := strings.LastIndex(line, "("); i > 0 {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseStackLocation parses a location line of a stack trace, e.g. "/src/workflow.go:42 +0x1d".
+func parseStackLocation(location string) (string, int) {
+	if i := strings.LastIndex(location, " +0x"); i >= 0 {
+		location = location[:i]
+	}
+	i := strings.LastIndex(location, ":")
+	if i < 0 {
+		return location, 0
+	}
+	lineNumber, err := strconv.Atoi(location[i+1:])
+	if err != nil {
+		return location, 0
+	}
+	return location[:i], lineNumber
+}
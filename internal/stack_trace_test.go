@@ -0,0 +1,96 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorkflowStackTrace(t *testing.T) {
+	stackTrace := `coroutine root [blocked on events.Select(approval, deadline)]:
+go.temporal.io/sdk/internal.(*selectorImpl).Select(0xc0001a2000, {0x1a2b3c0, 0xc000123450})
+	/go/src/sdk/internal/internal_workflow.go:1367 +0x4d
+main.OrderWorkflow({0x1a2b3c0, 0xc000123450}, {0xc000010000, 0x5})
+	/app/order.go:42 +0x1d
+
+coroutine anonymous [Await]:
+go.temporal.io/sdk/internal.Await(...)
+	/go/src/sdk/internal/workflow.go:369`
+
+	parsed := ParseWorkflowStackTrace(stackTrace)
+	require.Equal(t, stackTrace, parsed.Raw)
+	require.Equal(t, []CoroutineStackTrace{
+		{
+			Name:      "root",
+			Status:    "blocked on events.Select(approval, deadline)",
+			BlockedOn: "events.Select(approval, deadline)",
+			Frames: []StackFrame{
+				{Function: "go.temporal.io/sdk/internal.(*selectorImpl).Select", File: "/go/src/sdk/internal/internal_workflow.go", Line: 1367},
+				{Function: "main.OrderWorkflow", File: "/app/order.go", Line: 42},
+			},
+		},
+		{
+			Name:      "anonymous",
+			Status:    "Await",
+			BlockedOn: "Await",
+			Frames: []StackFrame{
+				{Function: "go.temporal.io/sdk/internal.Await", File: "/go/src/sdk/internal/workflow.go", Line: 369},
+			},
+		},
+	}, parsed.Coroutines)
+
+	require.Empty(t, ParseWorkflowStackTrace("").Coroutines)
+}
+
+func TestParseWorkflowStackTraceOfDispatcher(t *testing.T) {
+	d := createNewDispatcher(func(ctx Context) {
+		c := NewNamedChannel(ctx, "forever_blocked")
+		GoNamed(ctx, "child", func(ctx Context) {
+			c.Receive(ctx, nil)
+		})
+		c.Receive(ctx, nil)
+	})
+	defer d.Close()
+	requireNoExecuteErr(t, d.ExecuteUntilAllBlocked(defaultDeadlockDetectionTimeout))
+
+	parsed := ParseWorkflowStackTrace(d.StackTrace())
+	require.Len(t, parsed.Coroutines, 2)
+	for i, name := range []string{"root", "child"} {
+		coroutine := parsed.Coroutines[i]
+		require.Equal(t, name, coroutine.Name)
+		require.Equal(t, "forever_blocked.Receive", coroutine.BlockedOn)
+		require.NotEmpty(t, coroutine.Frames)
+		inWorkflowFunction := false
+		for _, frame := range coroutine.Frames {
+			require.True(t, strings.HasSuffix(frame.File, ".go"), frame.File)
+			require.Positive(t, frame.Line)
+			inWorkflowFunction = inWorkflowFunction || strings.Contains(frame.Function, "TestParseWorkflowStackTraceOfDispatcher")
+		}
+		require.True(t, inWorkflowFunction, parsed.Raw)
+	}
+}
@@ -0,0 +1,292 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type (
+	// ValidationInterceptorOptions are the options of the interceptor created with NewValidationInterceptor.
+	ValidationInterceptorOptions struct {
+		// Optional: Validates a value, returns the reason why the value is invalid or nil. The value is a pointer for
+		// the inputs of workflows and the results of activities and child workflows, and the value as passed by the
+		// workflow for the inputs of activities and child workflows. It can call a JSON schema or struct tag
+		// validation library.
+		// default: calls Validate of the values implementing Validatable, directly or through a pointer
+		Validator func(value interface{}) error
+	}
+
+	// Validatable is implemented by values validated by their Validate method by the validation interceptor.
+	Validatable interface {
+		Validate() error
+	}
+
+	// ValidationError is returned when a value is rejected by the validation interceptor.
+	ValidationError struct {
+		// Target is what was validated: "workflow input", "workflow result", "activity input", "activity result",
+		// "child workflow input" or "child workflow result".
+		Target string
+		// Name is the type of the workflow or activity.
+		Name string
+		// Index is the index of the input argument, -1 for results.
+		Index int
+		// Cause is the error returned by the validator.
+		Cause error
+	}
+
+	validationInterceptor struct {
+		validator func(value interface{}) error
+	}
+
+	validationInboundCallsInterceptor struct {
+		WorkflowInboundCallsInterceptorBase
+		validator func(value interface{}) error
+	}
+
+	validationOutboundCallsInterceptor struct {
+		WorkflowOutboundCallsInterceptorBase
+		validator func(value interface{}) error
+	}
+
+	// validatedFuture validates the value decoded by Get.
+	validatedFuture struct {
+		asyncFuture
+		validate func(valuePtr interface{}) error
+	}
+
+	// validatedChildWorkflowFuture validates the result of the child workflow decoded by Get.
+	validatedChildWorkflowFuture struct {
+		asyncFuture
+		child    ChildWorkflowFuture
+		validate func(valuePtr interface{}) error
+	}
+)
+
+var _ WorkflowInterceptor = (*validationInterceptor)(nil)
+
+const (
+	validationTargetWorkflowInput       = "workflow input"
+	validationTargetWorkflowResult      = "workflow result"
+	validationTargetActivityInput       = "activity input"
+	validationTargetActivityResult      = "activity result"
+	validationTargetChildWorkflowInput  = "child workflow input"
+	validationTargetChildWorkflowResult = "child workflow result"
+)
+
+// NewValidationInterceptor creates a workflow interceptor validating the inputs and results of workflows, and the
+// inputs and results of the activities and child workflows they execute, so that bad payloads fail fast with a
+// *ValidationError instead of deep inside business logic:
+//  - a workflow with an invalid input fails without running, and a workflow returning an invalid result fails,
+//    with a non-retryable ApplicationError of type "ValidationError",
+//  - an activity or child workflow with an invalid input isn't scheduled, its future fails with the *ValidationError,
+//  - the Get of the future of an activity or child workflow fails with the *ValidationError when the result is
+//    invalid. Activities don't run in workflow interceptors, so their results are validated once recorded, when the
+//    workflow gets them.
+func NewValidationInterceptor(options ValidationInterceptorOptions) WorkflowInterceptor {
+	validator := options.Validator
+	if validator == nil {
+		validator = validateValidatable
+	}
+	return &validationInterceptor{validator: validator}
+}
+
+// Error from error interface
+func (e *ValidationError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("invalid %s of %s: %v", e.Target, e.Name, e.Cause)
+	}
+	return fmt.Sprintf("invalid %s %d of %s: %v", e.Target, e.Index, e.Name, e.Cause)
+}
+
+// Unwrap returns the error returned by the validator.
+func (e *ValidationError) Unwrap() error {
+	return e.Cause
+}
+
+func (v *validationInterceptor) InterceptWorkflow(_ *WorkflowInfo, next WorkflowInboundCallsInterceptor) WorkflowInboundCallsInterceptor {
+	return &validationInboundCallsInterceptor{
+		WorkflowInboundCallsInterceptorBase: WorkflowInboundCallsInterceptorBase{Next: next},
+		validator:                           v.validator,
+	}
+}
+
+func (v *validationInboundCallsInterceptor) Init(outbound WorkflowOutboundCallsInterceptor) error {
+	return v.Next.Init(&validationOutboundCallsInterceptor{
+		WorkflowOutboundCallsInterceptorBase: WorkflowOutboundCallsInterceptorBase{Next: outbound},
+		validator:                            v.validator,
+	})
+}
+
+func (v *validationInboundCallsInterceptor) ExecuteWorkflow(ctx Context, workflowType string, args ...interface{}) []interface{} {
+	if err := validateArgs(v.validator, validationTargetWorkflowInput, workflowType, args); err != nil {
+		return validationFailedWorkflowResults(getWorkflowEnvironmentInterceptor(ctx).fn, err)
+	}
+	results := v.Next.ExecuteWorkflow(ctx, workflowType, args...)
+	if len(results) < 2 || results[len(results)-1] != nil {
+		return results
+	}
+	result := results[0]
+	if rv := reflect.ValueOf(result); rv.IsValid() && rv.Kind() != reflect.Ptr {
+		// Validate through a pointer like the other values, for validators implemented on pointers.
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		result = ptr.Interface()
+	}
+	if err := v.validator(result); err != nil {
+		validationErr := &ValidationError{Target: validationTargetWorkflowResult, Name: workflowType, Index: -1, Cause: err}
+		results[len(results)-1] = newValidationApplicationError(validationErr)
+	}
+	return results
+}
+
+func (v *validationOutboundCallsInterceptor) ExecuteActivity(ctx Context, activityType string, args ...interface{}) Future {
+	if err := validateArgs(v.validator, validationTargetActivityInput, activityType, args); err != nil {
+		future, settable := NewFuture(ctx)
+		settable.SetError(err)
+		return future
+	}
+	return v.validateFuture(v.Next.ExecuteActivity(ctx, activityType, args...), validationTargetActivityResult, activityType)
+}
+
+func (v *validationOutboundCallsInterceptor) ExecuteLocalActivity(ctx Context, activityType string, args ...interface{}) Future {
+	if err := validateArgs(v.validator, validationTargetActivityInput, activityType, args); err != nil {
+		future, settable := NewFuture(ctx)
+		settable.SetError(err)
+		return future
+	}
+	return v.validateFuture(v.Next.ExecuteLocalActivity(ctx, activityType, args...), validationTargetActivityResult, activityType)
+}
+
+func (v *validationOutboundCallsInterceptor) ExecuteChildWorkflow(ctx Context, childWorkflowType string, args ...interface{}) ChildWorkflowFuture {
+	if err := validateArgs(v.validator, validationTargetChildWorkflowInput, childWorkflowType, args); err != nil {
+		mainFuture, mainSettable := newDecodeFuture(ctx, childWorkflowType)
+		executionFuture, executionSettable := NewFuture(ctx)
+		executionSettable.Set(nil, err)
+		mainSettable.Set(nil, err)
+		return &childWorkflowFutureImpl{
+			decodeFutureImpl: mainFuture.(*decodeFutureImpl),
+			executionFuture:  executionFuture.(*futureImpl),
+		}
+	}
+	future := v.Next.ExecuteChildWorkflow(ctx, childWorkflowType, args...)
+	asyncF, ok := future.(asyncFuture)
+	if !ok {
+		return future
+	}
+	return &validatedChildWorkflowFuture{
+		asyncFuture: asyncF,
+		child:       future,
+		validate:    v.resultValidator(validationTargetChildWorkflowResult, childWorkflowType),
+	}
+}
+
+// validateFuture wraps the future to validate its value. Futures which can't be used in a Selector once wrapped are
+// returned as is.
+func (v *validationOutboundCallsInterceptor) validateFuture(future Future, target, name string) Future {
+	asyncF, ok := future.(asyncFuture)
+	if !ok {
+		return future
+	}
+	return &validatedFuture{asyncFuture: asyncF, validate: v.resultValidator(target, name)}
+}
+
+func (v *validationOutboundCallsInterceptor) resultValidator(target, name string) func(valuePtr interface{}) error {
+	return func(valuePtr interface{}) error {
+		if err := v.validator(valuePtr); err != nil {
+			return &ValidationError{Target: target, Name: name, Index: -1, Cause: err}
+		}
+		return nil
+	}
+}
+
+func (f *validatedFuture) Get(ctx Context, valuePtr interface{}) error {
+	if err := f.asyncFuture.Get(ctx, valuePtr); err != nil || valuePtr == nil {
+		return err
+	}
+	return f.validate(valuePtr)
+}
+
+func (f *validatedChildWorkflowFuture) Get(ctx Context, valuePtr interface{}) error {
+	if err := f.asyncFuture.Get(ctx, valuePtr); err != nil || valuePtr == nil {
+		return err
+	}
+	return f.validate(valuePtr)
+}
+
+func (f *validatedChildWorkflowFuture) GetChildWorkflowExecution() Future {
+	return f.child.GetChildWorkflowExecution()
+}
+
+func (f *validatedChildWorkflowFuture) SignalChildWorkflow(ctx Context, signalName string, data interface{}) Future {
+	return f.child.SignalChildWorkflow(ctx, signalName, data)
+}
+
+func validateArgs(validator func(value interface{}) error, target, name string, args []interface{}) *ValidationError {
+	for i, arg := range args {
+		if err := validator(arg); err != nil {
+			return &ValidationError{Target: target, Name: name, Index: i, Cause: err}
+		}
+	}
+	return nil
+}
+
+// validateValidatable is the default validator, calling Validate of the values implementing Validatable, directly or
+// through a pointer.
+func validateValidatable(value interface{}) error {
+	if v, ok := value.(Validatable); ok {
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil
+		}
+		return v.Validate()
+	}
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr {
+		return nil
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	if v, ok := ptr.Interface().(Validatable); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// validationFailedWorkflowResults returns the results of the workflow function failing with the validation error.
+func validationFailedWorkflowResults(fn interface{}, err *ValidationError) []interface{} {
+	fnType := reflect.TypeOf(fn)
+	results := make([]interface{}, fnType.NumOut())
+	for i := 0; i < len(results)-1; i++ {
+		results[i] = reflect.Zero(fnType.Out(i)).Interface()
+	}
+	results[len(results)-1] = newValidationApplicationError(err)
+	return results
+}
+
+// newValidationApplicationError converts the validation error to the non-retryable error a workflow fails with.
+func newValidationApplicationError(err *ValidationError) error {
+	return NewApplicationError(err.Error(), getErrType(err), true, err.Cause)
+}
@@ -0,0 +1,101 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type validatedOrder struct {
+	ID       string
+	Quantity int
+}
+
+func (o *validatedOrder) Validate() error {
+	if o.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	return nil
+}
+
+func TestValidationInterceptor(t *testing.T) {
+	reserveActivity := func(ctx context.Context, order validatedOrder) (validatedOrder, error) {
+		if order.ID == "bad-result" {
+			order.Quantity = 0
+		}
+		return order, nil
+	}
+	workflowFn := func(ctx Context, order validatedOrder) (validatedOrder, error) {
+		ctx = WithActivityOptions(ctx, ActivityOptions{StartToCloseTimeout: time.Minute})
+
+		err := ExecuteActivity(ctx, reserveActivity, validatedOrder{ID: "bad-input"}).Get(ctx, nil)
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		require.Equal(t, "activity input", validationErr.Target)
+		require.Equal(t, 0, validationErr.Index)
+
+		var reserved validatedOrder
+		err = ExecuteActivity(ctx, reserveActivity, validatedOrder{ID: "bad-result", Quantity: 1}).Get(ctx, &reserved)
+		require.True(t, errors.As(err, &validationErr))
+		require.Equal(t, "activity result", validationErr.Target)
+		require.Equal(t, -1, validationErr.Index)
+
+		if err := ExecuteActivity(ctx, reserveActivity, order).Get(ctx, &reserved); err != nil {
+			return validatedOrder{}, err
+		}
+		return reserved, nil
+	}
+
+	newEnv := func() *TestWorkflowEnvironment {
+		env := (&WorkflowTestSuite{}).NewTestWorkflowEnvironment()
+		env.SetWorkerOptions(WorkerOptions{WorkflowInterceptorChainFactories: []WorkflowInterceptor{
+			NewValidationInterceptor(ValidationInterceptorOptions{}),
+		}})
+		env.RegisterWorkflow(workflowFn)
+		env.RegisterActivity(reserveActivity)
+		return env
+	}
+
+	env := newEnv()
+	env.ExecuteWorkflow(workflowFn, validatedOrder{ID: "order-1", Quantity: 2})
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result validatedOrder
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Equal(t, validatedOrder{ID: "order-1", Quantity: 2}, result)
+
+	env = newEnv()
+	env.ExecuteWorkflow(workflowFn, validatedOrder{ID: "order-2"})
+	require.True(t, env.IsWorkflowCompleted())
+	var applicationErr *ApplicationError
+	require.True(t, errors.As(env.GetWorkflowError(), &applicationErr))
+	require.Equal(t, "ValidationError", applicationErr.Type())
+	require.True(t, applicationErr.NonRetryable())
+}
@@ -26,7 +26,11 @@ package internal
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
 )
 
 type (
@@ -114,6 +118,26 @@ type (
 		// default: 5s
 		StickyScheduleToStartTimeout time.Duration
 
+		// Optional: Sets the size of the sticky workflow cache of this worker.
+		// If set, the worker keeps workflow executions in its own cache instead of the cache shared by all workers
+		// within the process, which is configured by SetStickyWorkflowCacheSize. This allows workers of the same
+		// process to use different cache sizes.
+		// default: 0, which means the shared cache is used.
+		StickyWorkflowCacheSize int
+
+		// Optional: Limits the own sticky workflow cache of this worker by the total size of the history of the cached
+		// workflow executions, in bytes. Only used together with StickyWorkflowCacheSize, see
+		// SetStickyWorkflowCacheMaxBytes for the shared cache.
+		// default: 0, which means only the number of cached workflow executions is limited.
+		StickyWorkflowCacheMaxBytes int64
+
+		// Optional: Sets the number of history pages fetched ahead concurrently with the processing of the current
+		// page when a workflow task requires the history to be fetched, e.g. when replaying a workflow execution that
+		// is not in the sticky cache. This speeds up the replay of long histories, at the cost of holding up to this
+		// many additional history pages in memory.
+		// default: 0, which means history pages are fetched one at a time when needed.
+		HistoryPagePrefetchCount int
+
 		// Optional: sets root context for all activities. The context can be used to pass external dependencies
 		// like DB connections to activity functions.
 		// Note that this method of passing dependencies is not recommended anymore.
@@ -127,6 +151,64 @@ type (
 		// default: BlockWorkflow, which just logs error but doesn't fail workflow.
 		WorkflowPanicPolicy WorkflowPanicPolicy
 
+		// Optional: Sets how the worker deals with panics raised from activity and local activity code. The panic
+		// is always reported with its stack trace, which the workflow gets from PanicError.StackTrace.
+		// default: RetryActivityOnPanic, which fails the activity attempt with a retryable PanicError.
+		ActivityPanicPolicy ActivityPanicPolicy
+
+		// Optional: Called with the error an activity attempt executed by the worker failed with, and the number of the
+		// attempt starting from 1, to decide if the activity is retried, e.g. when retryability depends on details
+		// parsed from the error. When it returns false, the attempt fails with a non-retryable error and the activity
+		// isn't retried, whatever its RetryPolicy. When it returns true, the RetryPolicy of the activity applies.
+		// It isn't called for cancellations and for errors which are already non-retryable. Not applied to local
+		// activities.
+		// default: nil, the RetryPolicy of the activity applies to all the errors
+		ActivityRetryPredicate func(err error, attempt int) bool
+
+		// Optional: Called when workflow code panics or non-determinism is detected, before WorkflowPanicPolicy is
+		// applied, to alert on it. With BlockWorkflow, it is called again on every retry of the failing workflow task.
+		// The temporal_workflow_task_panic counter is incremented at the same time.
+		// default: nil
+		OnWorkflowPanic func(info WorkflowPanicInfo)
+
+		// Optional: Called when a workflow task processed by the worker fails again after WorkflowStuckAttempts - 1
+		// failed or timed out attempts, e.g. because of a panic, a non-determinism or a deadlock, or because it timed
+		// out before the worker completed it. Workflow tasks are retried until the problem is fixed, so this is meant
+		// to alert the owners of the workflow instead of discovering it stuck later. It is called on every subsequent
+		// failed attempt processed by the worker.
+		// default: nil
+		OnWorkflowStuck func(info WorkflowStuckInfo)
+
+		// Optional: The attempt of a failing workflow task from which OnWorkflowStuck is called.
+		// default: 3
+		WorkflowStuckAttempts int32
+
+		// Optional: The maximum number of commands, such as scheduled activities, timers, signals and markers, that a
+		// single workflow task may complete with. A workflow task exceeding it is failed with a
+		// WorkflowTaskLimitExceededError and retried like a panicking one, and the temporal_workflow_task_limit_exceeded
+		// counter is incremented, instead of being rejected by the server with an opaque error.
+		// Use workflow.SignalExternalWorkflows or wait on part of the futures to spread large fan-outs over several
+		// workflow tasks.
+		// default: 0, no limit
+		MaxWorkflowTaskCommands int
+
+		// Optional: The maximum size in bytes of a workflow task completion, which includes the commands and their
+		// payloads. A workflow task exceeding it is failed with a WorkflowTaskLimitExceededError like one exceeding
+		// MaxWorkflowTaskCommands. Set a negative value to disable the check.
+		// default: 4MB, the default maximum gRPC message size of the server
+		MaxWorkflowTaskCompletionSize int
+
+		// Optional: Calls an auditor on the start, completion, failure, cancellation and continue as new of the
+		// workflow executions processed by the worker, with access to the inputs and results of a sample of them.
+		// default: nil
+		WorkflowAudit *WorkflowAuditOptions
+
+		// Optional: Makes the worker follow the failovers of its namespace between clusters, reporting the changes of
+		// the active cluster with a callback and the temporal_namespace_failover and temporal_namespace_active metrics,
+		// and optionally pausing polling while the namespace is active in another cluster.
+		// default: nil
+		NamespaceFailover *NamespaceFailoverOptions
+
 		// Optional: worker graceful stop timeout
 		// default: 0s
 		WorkerStopTimeout time.Duration
@@ -147,24 +229,131 @@ type (
 		// default: 1000
 		MaxConcurrentSessionExecutionSize int
 
+		// Optional: Enables the priority of activities, see ActivityOptions.Priority. The worker polls up to this number
+		// of activity tasks in addition to the MaxConcurrentActivityExecutionSize executing ones, and executes the
+		// waiting tasks by descending priority when an execution slot is released, so latency sensitive activities jump
+		// ahead of the bulk ones polled from the same task queue. The waiting tasks are started from the point of view
+		// of the server, so their StartToCloseTimeout and HeartbeatTimeout run while they wait: keep it small.
+		// default: 0, activities are executed in the order they are polled and their priority is ignored
+		ActivityTaskPrefetchSize int
+
+		// Optional: Enable executing the activities routed to the worker by ActivityOptions.AffinityKey. The worker
+		// polls an additional task queue specific to the worker identity, so the identity must be unique.
+		// default: false
+		EnableActivityAffinity bool
+
+		// Optional: Returns cached results instead of executing the idempotent activities the cache is configured
+		// for, see NewActivityResultCache. Keep a reference to the cache to invalidate results.
+		// default: no activity results are cached
+		ActivityResultCache *ActivityResultCache
+
 		// Optional: Specifies factories used to instantiate workflow interceptor chain
 		// The chain is instantiated per each replay of a workflow execution
 		WorkflowInterceptorChainFactories []WorkflowInterceptor
 
+		// Optional: Sets the task queues of the activities and child workflows scheduled by the workflows of this
+		// worker without a task queue other than the task queue of the workflow.
+		// default: activities and child workflows use the task queue of the workflow
+		TaskQueueRoutes *TaskQueueRoutes
+
+		// Optional: Registrations shared with other workers, e.g. all the workflows and activities of the process
+		// registered once at start up. The workflows and activities registered with the worker itself take
+		// precedence over the shared ones, and are not visible to the other workers.
+		// default: only the workflows and activities registered with the worker
+		Registry *SharedRegistry
+
 		// Optional: If set to true worker would only handle workflow tasks and local activities.
 		// Non-local activities will not be executed by this worker.
 		// default: false
 		LocalActivityWorkerOnly bool
 
+		// Optional: If set to true the worker polls no task and instead replays the running executions of its
+		// registered workflow types, to canary new workflow code against live executions before rolling it out.
+		// Every minute, the histories of the 10 most recently started running executions of each workflow type on the
+		// task queue of the worker are fetched with GetWorkflowExecutionHistory and replayed like
+		// WorkflowReplayer.ReplayWorkflowExecution does, so the executions keep being processed by the other workers
+		// of the task queue.
+		// Executions failing to replay, e.g. because of a panic or a non-determinism, are logged and counted by the
+		// temporal_replay_only_divergence counter, and all the replayed executions by the
+		// temporal_replay_only_execution counter. The worker executes no activities.
+		// default: false
+		ReplayOnly bool
+
 		// Optional: If set overwrites the client level Identify value.
 		// default: client identity
 		Identity string
 
+		// Optional: Describes where the worker runs, e.g. its host, pod and build. Overwrites Identity with the
+		// string form of the details, so that the history events and heartbeats recorded by the server tell which
+		// pod ran a task. The identity is also available as WorkflowInfo.WorkerIdentity and
+		// ActivityInfo.WorkerIdentity.
+		// default: Identity
+		IdentityDetails *WorkerIdentity
+
+		// Optional: Identifies the binary of the worker for auto-reset points and bad binary detection, see
+		// NamespaceClient.MarkBadBinary. Set it to a build or release identifier to get the same checksum for
+		// identical builds deployed to different machines.
+		// default: checksum set by SetBinaryChecksum, otherwise the MD5 checksum of the executable
+		BinaryChecksum string
+
 		// Optional: If set defines maximum amount of time that workflow task will be allowed to run. Defaults to 1 sec.
 		DeadlockDetectionTimeout time.Duration
 	}
+
+	// WorkerIdentity describes where a worker runs, see WorkerOptions.IdentityDetails.
+	WorkerIdentity struct {
+		// Host is the name of the machine or node running the worker.
+		Host string
+		// Pod is the name of the pod or container running the worker.
+		Pod string
+		// Build identifies the build or release of the worker code.
+		Build string
+	}
+
+	// TaskQueueRoutes maps activity and workflow types to task queues, centralizing where activities and child
+	// workflows run instead of setting the task queue at every call site. The routes apply to activities and child
+	// workflows scheduled without a task queue other than the task queue of the workflow. Changing the routes of
+	// activities or child workflows scheduled by open workflows breaks the determinism of the workflows.
+	TaskQueueRoutes struct {
+		// Activities maps activity types to their task queues.
+		Activities map[string]string
+
+		// Workflows maps child workflow types to their task queues.
+		Workflows map[string]string
+
+		// Router resolves the task queue of the types without a mapping. It is called with the activity or
+		// workflow type and whether it is a workflow type and returns "" to use the task queue of the workflow.
+		// It must be deterministic. Optional.
+		Router func(typeName string, isWorkflow bool) string
+	}
 )
 
+func (r *TaskQueueRoutes) activityTaskQueue(activityType string) string {
+	if r == nil {
+		return ""
+	}
+	if taskQueue, ok := r.Activities[activityType]; ok {
+		return taskQueue
+	}
+	if r.Router != nil {
+		return r.Router(activityType, false)
+	}
+	return ""
+}
+
+func (r *TaskQueueRoutes) workflowTaskQueue(workflowType string) string {
+	if r == nil {
+		return ""
+	}
+	if taskQueue, ok := r.Workflows[workflowType]; ok {
+		return taskQueue
+	}
+	if r.Router != nil {
+		return r.Router(workflowType, true)
+	}
+	return ""
+}
+
 // WorkflowPanicPolicy is used for configuring how worker deals with workflow
 // code panicking which includes non backwards compatible changes to the workflow code without appropriate
 // versioning (see workflow.GetVersion).
@@ -183,6 +372,131 @@ const (
 	FailWorkflow
 )
 
+// WorkflowPanicInfo describes a workflow panic or a non-determinism detected by the worker, see
+// WorkerOptions.OnWorkflowPanic.
+type WorkflowPanicInfo struct {
+	WorkflowType string
+	WorkflowID   string
+	RunID        string
+	// Attempt of the workflow task, starting from 1.
+	Attempt int32
+	// Error is the panic, or the non-determinism error.
+	Error error
+	// Value is the value the workflow code panicked with, nil for non-determinism detected on replay.
+	Value interface{}
+	// StackTrace is the stack trace of the panic, empty for non-determinism detected on replay.
+	StackTrace string
+}
+
+// WorkflowStuckInfo describes a workflow which workflow tasks repeatedly fail, see WorkerOptions.OnWorkflowStuck.
+type WorkflowStuckInfo struct {
+	WorkflowType string
+	WorkflowID   string
+	RunID        string
+	// Attempt of the workflow task which failed, starting from 1.
+	Attempt int32
+	// Cause is the cause the workflow task is failed with, unspecified if it timed out.
+	Cause enumspb.WorkflowTaskFailedCause
+	// TimedOut is set if the workflow task timed out before the worker completed it.
+	TimedOut bool
+	// LastFailure is the error the workflow task failed with, or the error returned by the service when completing
+	// the timed out workflow task.
+	LastFailure error
+}
+
+// ActivityPanicPolicy is used for configuring how worker deals with activity code panicking.
+// The default behavior is to fail the activity attempt with a retryable error.
+type ActivityPanicPolicy int
+
+const (
+	// RetryActivityOnPanic is the default policy for handling activity panics. The activity attempt fails with a
+	// retryable PanicError, and the activity is retried according to its retry policy.
+	RetryActivityOnPanic ActivityPanicPolicy = iota
+	// FailActivityOnPanic fails the activity with a non-retryable PanicError, for activities whose panics are bugs
+	// which retries won't fix.
+	FailActivityOnPanic
+	// CrashWorkerOnPanic logs the panic and raises it again, which crashes the worker process. The activity times out
+	// and is retried by another worker according to its retry policy.
+	CrashWorkerOnPanic
+)
+
+// SharedRegistry holds workflow and activity registrations shared by several workers through WorkerOptions.Registry.
+// Each registry detects the name collisions of its own registrations only, so independent workers of a process, or of
+// a test binary, don't interfere with each other.
+type SharedRegistry struct {
+	registry *registry
+}
+
+// NewSharedRegistry creates an empty SharedRegistry.
+func NewSharedRegistry() *SharedRegistry {
+	return &SharedRegistry{registry: newRegistry()}
+}
+
+// RegisterWorkflow registers the workflow function, see AggregatedWorker.RegisterWorkflow.
+func (r *SharedRegistry) RegisterWorkflow(w interface{}) {
+	r.registry.RegisterWorkflow(w)
+}
+
+// RegisterWorkflowWithOptions registers the workflow function with options, see
+// AggregatedWorker.RegisterWorkflowWithOptions.
+func (r *SharedRegistry) RegisterWorkflowWithOptions(w interface{}, options RegisterWorkflowOptions) {
+	r.registry.RegisterWorkflowWithOptions(w, options)
+}
+
+// RegisterDynamicWorkflow registers the workflow function handling the workflow types without a registered workflow,
+// see AggregatedWorker.RegisterDynamicWorkflow.
+func (r *SharedRegistry) RegisterDynamicWorkflow(w DynamicWorkflowFunc) {
+	r.registry.RegisterDynamicWorkflow(w)
+}
+
+// RegisterActivity registers the activity function or struct pointer, see AggregatedWorker.RegisterActivity.
+func (r *SharedRegistry) RegisterActivity(a interface{}) {
+	r.registry.RegisterActivity(a)
+}
+
+// RegisterActivityWithOptions registers the activity function or struct pointer with options, see
+// AggregatedWorker.RegisterActivityWithOptions.
+func (r *SharedRegistry) RegisterActivityWithOptions(a interface{}, options RegisterActivityOptions) {
+	r.registry.RegisterActivityWithOptions(a, options)
+}
+
+// RegisterDynamicActivity registers the activity function handling the activity types without a registered activity,
+// see AggregatedWorker.RegisterDynamicActivity.
+func (r *SharedRegistry) RegisterDynamicActivity(a DynamicActivityFunc) {
+	r.registry.RegisterDynamicActivity(a)
+}
+
+// RegisteredWorkflowTypes returns the sorted names of the registered workflow types.
+func (r *SharedRegistry) RegisteredWorkflowTypes() []string {
+	return sortedStrings(r.registry.getRegisteredWorkflowTypes())
+}
+
+// RegisteredActivityTypes returns the sorted names of the registered activity types.
+func (r *SharedRegistry) RegisteredActivityTypes() []string {
+	return sortedStrings(r.registry.getRegisteredActivityTypes())
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+	return s
+}
+
+// String returns the identity sent by a worker with these details, e.g. "host=node-3,pod=orders-7f9c,build=v1.2.3".
+// The empty fields are omitted.
+func (w *WorkerIdentity) String() string {
+	var fields []string
+	if w.Host != "" {
+		fields = append(fields, "host="+w.Host)
+	}
+	if w.Pod != "" {
+		fields = append(fields, "pod="+w.Pod)
+	}
+	if w.Build != "" {
+		fields = append(fields, "build="+w.Build)
+	}
+	return strings.Join(fields, ",")
+}
+
 // ReplayNamespace is namespace for replay because startEvent doesn't contain it
 const ReplayNamespace = "ReplayNamespace"
 
@@ -208,3 +522,34 @@ func NewWorker(
 	}
 	return NewAggregatedWorker(workflowClient, taskQueue, options)
 }
+
+// NewWorkflowWorker creates an instance of worker which only executes workflows and local activities, like NewWorker
+// with WorkerOptions.LocalActivityWorkerOnly set. It polls with more workflow task pollers by default, since it polls
+// no activity task. The activity options of WorkerOptions are ignored.
+func NewWorkflowWorker(
+	client Client,
+	taskQueue string,
+	options WorkerOptions,
+) *AggregatedWorker {
+	workflowClient, ok := client.(*WorkflowClient)
+	if !ok {
+		panic("Client must be created with client.NewClient()")
+	}
+	return newAggregatedWorker(workflowClient, taskQueue, options, nil, workflowOnlyWorker)
+}
+
+// NewActivityWorker creates an instance of worker which only executes activities. It polls with more activity task
+// pollers by default, since it polls no workflow task, and has no sticky workflow cache. The workflow options of
+// WorkerOptions, as well as LocalActivityWorkerOnly and ReplayOnly, are ignored, and the workflows registered with
+// the worker are never executed.
+func NewActivityWorker(
+	client Client,
+	taskQueue string,
+	options WorkerOptions,
+) *AggregatedWorker {
+	workflowClient, ok := client.(*WorkflowClient)
+	if !ok {
+		panic("Client must be created with client.NewClient()")
+	}
+	return newAggregatedWorker(workflowClient, taskQueue, options, nil, activityOnlyWorker)
+}
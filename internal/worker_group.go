@@ -0,0 +1,183 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"sync"
+)
+
+type (
+	// WorkerGroupOptions configure a WorkerGroup.
+	WorkerGroupOptions struct {
+		// Optional: Sets the maximum number of workflow tasks executed at once by all the workers of the group
+		// together. The limits of every worker set with WorkerOptions.MaxConcurrentWorkflowTaskExecutionSize still
+		// apply.
+		// default: no limit shared by the workers
+		MaxConcurrentWorkflowTaskExecutionSize int
+
+		// Optional: Sets the maximum number of activities executed at once by all the workers of the group together.
+		// The limits of every worker set with WorkerOptions.MaxConcurrentActivityExecutionSize still apply.
+		// default: no limit shared by the workers
+		MaxConcurrentActivityExecutionSize int
+
+		// Optional: Sets the factories used to instantiate the workflow interceptor chain of all the workers of the
+		// group. WorkerOptions.WorkflowInterceptorChainFactories of the workers are ignored.
+		WorkflowInterceptorChainFactories []WorkflowInterceptor
+
+		// Optional: Sets the task queue routes of all the workers of the group. WorkerOptions.TaskQueueRoutes of the
+		// workers are ignored.
+		TaskQueueRoutes *TaskQueueRoutes
+	}
+
+	// WorkerGroup runs workers polling several task queues, possibly in different namespaces, with a single registry
+	// of workflows and activities and concurrency limits shared by all the workers. Workers of the group also share the
+	// sticky workflow cache unless WorkerOptions.StickyWorkflowCacheSize is set.
+	WorkerGroup struct {
+		registry          *registry
+		workflowTaskSlots *sharedTaskSlots
+		activityTaskSlots *sharedTaskSlots
+
+		lock     sync.Mutex
+		workers  []*AggregatedWorker
+		started  bool
+		stopC    chan struct{}
+		stopOnce sync.Once
+	}
+)
+
+// NewWorkerGroup creates a WorkerGroup. Add workers with WorkerGroup.AddWorker.
+func NewWorkerGroup(options WorkerGroupOptions) *WorkerGroup {
+	g := &WorkerGroup{
+		registry: newRegistry(),
+		stopC:    make(chan struct{}),
+	}
+	g.registry.SetWorkflowInterceptors(options.WorkflowInterceptorChainFactories)
+	g.registry.SetTaskQueueRoutes(options.TaskQueueRoutes)
+	if options.MaxConcurrentWorkflowTaskExecutionSize > 0 {
+		g.workflowTaskSlots = newSharedTaskSlots(options.MaxConcurrentWorkflowTaskExecutionSize)
+	}
+	if options.MaxConcurrentActivityExecutionSize > 0 {
+		g.activityTaskSlots = newSharedTaskSlots(options.MaxConcurrentActivityExecutionSize)
+	}
+	return g
+}
+
+// AddWorker adds a worker polling the task queue in the namespace of the client. The worker executes the workflows
+// and activities registered with the group. Workers can't be added once the group is started.
+func (g *WorkerGroup) AddWorker(client Client, taskQueue string, options WorkerOptions) error {
+	// TODO: refactor and remove this downcast: https://github.com/temporalio/go-sdk/issues/70
+	workflowClient, ok := client.(*WorkflowClient)
+	if !ok {
+		panic("Client must be created with client.NewClient()")
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.started {
+		return errors.New("workers can't be added to a started worker group")
+	}
+	g.workers = append(g.workers, newAggregatedWorker(workflowClient, taskQueue, options, g, workflowAndActivityWorker))
+	return nil
+}
+
+// RegisterWorkflow registers workflow implementation with all the workers of the WorkerGroup
+func (g *WorkerGroup) RegisterWorkflow(w interface{}) {
+	g.registry.RegisterWorkflow(w)
+}
+
+// RegisterWorkflowWithOptions registers workflow implementation with all the workers of the WorkerGroup
+func (g *WorkerGroup) RegisterWorkflowWithOptions(w interface{}, options RegisterWorkflowOptions) {
+	g.registry.RegisterWorkflowWithOptions(w, options)
+}
+
+// RegisterDynamicWorkflow registers the workflow function that handles the workflow types without a registered
+// workflow with all the workers of the WorkerGroup
+func (g *WorkerGroup) RegisterDynamicWorkflow(w DynamicWorkflowFunc) {
+	g.registry.RegisterDynamicWorkflow(w)
+}
+
+// RegisterActivity registers activity implementation with all the workers of the WorkerGroup
+func (g *WorkerGroup) RegisterActivity(a interface{}) {
+	g.registry.RegisterActivity(a)
+}
+
+// RegisterActivityWithOptions registers activity implementation with all the workers of the WorkerGroup
+func (g *WorkerGroup) RegisterActivityWithOptions(a interface{}, options RegisterActivityOptions) {
+	g.registry.RegisterActivityWithOptions(a, options)
+}
+
+// RegisterDynamicActivity registers the activity function that handles the activity types without a registered
+// activity with all the workers of the WorkerGroup
+func (g *WorkerGroup) RegisterDynamicActivity(a DynamicActivityFunc) {
+	g.registry.RegisterDynamicActivity(a)
+}
+
+// Start all the workers of the group in a non-blocking fashion. If a worker fails to start, the workers already
+// started are stopped.
+func (g *WorkerGroup) Start() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if len(g.workers) == 0 {
+		return errors.New("worker group has no workers")
+	}
+	for i, w := range g.workers {
+		if err := w.Start(); err != nil {
+			for _, started := range g.workers[:i] {
+				started.Stop()
+			}
+			return err
+		}
+	}
+	g.started = true
+	return nil
+}
+
+// Run the workers of the group in a blocking fashion. Stop the workers when interruptCh receives signal.
+// Pass worker.InterruptCh() to stop the workers with SIGINT or SIGTERM.
+// Pass nil to stop the workers with external Stop() call.
+// Returns error only if a worker fails to start.
+func (g *WorkerGroup) Run(interruptCh <-chan interface{}) error {
+	if err := g.Start(); err != nil {
+		return err
+	}
+	select {
+	case <-interruptCh:
+		g.Stop()
+	case <-g.stopC:
+	}
+	return nil
+}
+
+// Stop all the workers of the group. Calls after the first one have no effect.
+func (g *WorkerGroup) Stop() {
+	g.stopOnce.Do(func() {
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		close(g.stopC)
+		for _, w := range g.workers {
+			w.Stop()
+		}
+	})
+}
@@ -0,0 +1,150 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/atomic"
+)
+
+func TestSharedTaskSlots(t *testing.T) {
+	slots := newSharedTaskSlots(2)
+	stopCh := make(chan struct{})
+	require.True(t, slots.acquire(stopCh))
+	require.True(t, slots.acquire(stopCh))
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- slots.acquire(stopCh)
+	}()
+	select {
+	case <-acquired:
+		require.Fail(t, "slot acquired while all slots are taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+	slots.release()
+	require.True(t, <-acquired)
+
+	go func() {
+		acquired <- slots.acquire(stopCh)
+	}()
+	close(stopCh)
+	require.False(t, <-acquired)
+}
+
+func TestWorkerGroup_SharedResources(t *testing.T) {
+	group := NewWorkerGroup(WorkerGroupOptions{
+		MaxConcurrentWorkflowTaskExecutionSize: 10,
+		MaxConcurrentActivityExecutionSize:     20,
+	})
+	group.RegisterWorkflow(sampleWorkflowExecute)
+	group.RegisterActivity(testActivityReturnString)
+
+	require.NoError(t, group.AddWorker(&WorkflowClient{namespace: "namespace1"}, "task-queue-1", WorkerOptions{EnableSessionWorker: true}))
+	require.NoError(t, group.AddWorker(&WorkflowClient{namespace: "namespace2"}, "task-queue-2", WorkerOptions{EnableSessionWorker: true}))
+	require.Len(t, group.workers, 2)
+
+	for i, w := range group.workers {
+		require.Same(t, group.registry, w.registry)
+		require.Same(t, group.workflowTaskSlots, w.workflowWorker.worker.options.sharedTaskSlots)
+		require.Same(t, group.activityTaskSlots, w.activityWorker.worker.options.sharedTaskSlots)
+		require.Equal(t, []string{"namespace1", "namespace2"}[i], w.workflowWorker.executionParameters.Namespace)
+	}
+	workflowName, _ := getFunctionName(sampleWorkflowExecute)
+	_, ok := group.registry.getWorkflowFn(workflowName)
+	require.True(t, ok)
+
+	group.started = true
+	require.Error(t, group.AddWorker(&WorkflowClient{}, "task-queue-3", WorkerOptions{}))
+}
+
+type emptyTaskPoller struct {
+	polls atomic.Int32
+}
+
+func (p *emptyTaskPoller) PollTask() (interface{}, error) {
+	p.polls.Inc()
+	time.Sleep(time.Millisecond)
+	return nil, nil
+}
+
+func (p *emptyTaskPoller) ProcessTask(interface{}) error {
+	return nil
+}
+
+func TestSharedTaskSlots_AcquiredBeforePolling(t *testing.T) {
+	slots := newSharedTaskSlots(1)
+	busyPoller := &blockingTaskPoller{startedCh: make(chan interface{}, 10), releaseCh: make(chan struct{})}
+	busyWorker := newBaseWorker(baseWorkerOptions{
+		pollerCount:       2,
+		maxConcurrentTask: 2,
+		maxTaskPerSecond:  1000,
+		taskWorker:        busyPoller,
+		workerType:        "BusyWorker",
+		stopTimeout:       time.Second,
+		sharedTaskSlots:   slots,
+	}, getLogger(), tally.NoopScope, nil)
+	emptyPoller := &emptyTaskPoller{}
+	emptyWorker := newBaseWorker(baseWorkerOptions{
+		pollerCount:       2,
+		maxConcurrentTask: 2,
+		maxTaskPerSecond:  1000,
+		taskWorker:        emptyPoller,
+		workerType:        "EmptyWorker",
+		stopTimeout:       time.Second,
+		sharedTaskSlots:   slots,
+	}, getLogger(), tally.NoopScope, nil)
+	busyWorker.Start()
+
+	select {
+	case <-busyPoller.startedCh:
+	case <-time.After(time.Second):
+		t.Fatal("task was not dispatched")
+	}
+	// The only shared slot is taken by the executing task, so neither worker polls.
+	emptyWorker.Start()
+	select {
+	case <-busyPoller.startedCh:
+		t.Fatal("task was dispatched without an available shared slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.Equal(t, int32(0), emptyPoller.polls.Load())
+
+	// The pollers of the other worker poll once the slot is released, and release it on each empty poll.
+	close(busyPoller.releaseCh)
+	require.Eventually(t, func() bool { return emptyPoller.polls.Load() > 1 }, time.Second, 10*time.Millisecond)
+	busyWorker.Stop()
+	emptyWorker.Stop()
+}
+
+func TestWorkerGroup_StopTwice(t *testing.T) {
+	group := NewWorkerGroup(WorkerGroupOptions{})
+	group.Stop()
+	require.NotPanics(t, group.Stop)
+}
@@ -25,18 +25,24 @@
 package internal
 
 import (
+	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math/rand"
 	"reflect"
 	"strings"
 	"time"
 
+	"github.com/pborman/uuid"
 	"github.com/uber-go/tally"
 	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
 	failurepb "go.temporal.io/api/failure/v1"
 
 	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/internal/common/metrics"
+	ilog "go.temporal.io/sdk/internal/log"
 	"go.temporal.io/sdk/log"
 )
 
@@ -75,6 +81,9 @@ type (
 		// ReceiveAsyncWithMoreFlag is same as ReceiveAsync with extra return value more to indicate if there could be
 		// more value from the Channel. The more is false when Channel is closed.
 		ReceiveAsyncWithMoreFlag(valuePtr interface{}) (ok bool, more bool)
+
+		// Len returns the number of buffered values which can be received from the Channel without blocking.
+		Len() int
 	}
 
 	// Channel must be used instead of native go channel by workflow code.
@@ -93,6 +102,10 @@ type (
 		// The branch is automatically removed after the channel is closed and callback function is called once
 		// with more parameter set to false.
 		AddReceive(c ReceiveChannel, f func(c ReceiveChannel, more bool)) Selector
+		// AddReceiveWithPriority is AddReceive with a priority, see SelectorBranchOptions.Priority.
+		AddReceiveWithPriority(c ReceiveChannel, priority int, f func(c ReceiveChannel, more bool)) Selector
+		// AddReceiveWithOptions is AddReceive with additional options, see SelectorBranchOptions.
+		AddReceiveWithOptions(c ReceiveChannel, options SelectorBranchOptions, f func(c ReceiveChannel, more bool)) Selector
 		// AddSend registers a callback function to be called when sending message to channel is not going to block.
 		// The callback is called when Select(ctx) is called.
 		// The sending message to the channel is expected to be done by the callback function
@@ -102,19 +115,37 @@ type (
 		// The callback is called once per ready future even if Select is called multiple times for the same
 		// Selector instance.
 		AddFuture(future Future, f func(f Future)) Selector
+		// AddFutureWithOptions is AddFuture with additional options, see SelectorBranchOptions.
+		AddFutureWithOptions(future Future, options SelectorBranchOptions, f func(f Future)) Selector
 		// AddDefault register callback function to be called if none of other branches matched.
 		// The callback is called when Select(ctx) is called.
 		// When the default branch is registered Select never blocks.
 		AddDefault(f func())
 		// Select checks if any of the registered branches satisfies its condition blocking if necessary.
 		// When a branch becomes eligible its callback is invoked.
-		// If multiple branches are eligible only one of them (picked randomly) is invoked per Select call.
+		// If multiple branches are eligible only one of them is invoked per Select call. Branches are checked in
+		// the order of their priority and then in the order they were added.
 		// It is OK to call Select multiple times for the same Selector instance.
 		Select(ctx Context)
 		// HasPending returns true if call to Select is guaranteed to not block.
 		HasPending() bool
 	}
 
+	// SelectorBranchOptions are options for a Selector branch, see Selector.AddReceiveWithOptions and
+	// Selector.AddFutureWithOptions.
+	SelectorBranchOptions struct {
+		// Priority of the branch. When multiple branches are eligible at the time Select is called, the callback of
+		// the one with the highest priority is invoked; branches with equal priority are checked in the order they
+		// were added. Branches added without a priority have priority 0. When Select blocks, the callback of the
+		// first branch which becomes eligible is invoked regardless of priorities.
+		// Use a loop with HasPending and Select to drain a high priority channel before handling other branches.
+		Priority int
+
+		// Label is a short human readable name of the branch. The labels of the branches a Select is blocked on
+		// appear in the stack trace of the workflow returned by the "__stack_trace" query.
+		Label string
+	}
+
 	// WaitGroup must be used instead of native go sync.WaitGroup by
 	// workflow code.  Use workflow.NewWaitGroup(ctx) method to create
 	// a new WaitGroup instance
@@ -169,6 +200,24 @@ type (
 		SignalChildWorkflow(ctx Context, signalName string, data interface{}) Future
 	}
 
+	// ExternalWorkflowHandle is a handle to a workflow execution which is not a child of the current workflow.
+	// Use workflow.GetExternalWorkflowHandle(ctx, workflowID, runID) to create an instance.
+	ExternalWorkflowHandle interface {
+		// GetID returns the workflow ID of the target workflow.
+		GetID() string
+		// GetRunID returns the run ID of the target workflow. An empty run ID targets the currently running
+		// instance of the workflow ID.
+		GetRunID() string
+		// GetNamespace returns the namespace of the target workflow.
+		GetNamespace() string
+		// Signal sends a signal to the target workflow. The returned Future is ready once the signal is delivered
+		// and returns a failure if the delivery failed.
+		Signal(ctx Context, signalName string, arg interface{}) Future
+		// RequestCancel requests cancellation of the target workflow. The returned Future is ready once the request
+		// is delivered and returns a failure if the delivery failed.
+		RequestCancel(ctx Context) Future
+	}
+
 	// WorkflowType identifies a workflow type.
 	WorkflowType struct {
 		Name string
@@ -193,7 +242,8 @@ type (
 	// subjected to change in the future.
 	ChildWorkflowOptions struct {
 		// Namespace of the child workflow.
-		// Optional: the current workflow (parent)'s namespace will be used if this is not provided.
+		// Optional: the current workflow (parent)'s namespace will be used if this is not provided. Starting a child
+		// workflow in another namespace requires the namespace to exist on the same cluster as the parent's namespace.
 		Namespace string
 
 		// WorkflowID of the child workflow to be scheduled.
@@ -227,7 +277,10 @@ type (
 		WaitForCancellation bool
 
 		// WorkflowIDReusePolicy - Whether server allow reuse of workflow ID, can be useful
-		// for dedup logic if set to WorkflowIdReusePolicyRejectDuplicate
+		// for dedup logic if set to WorkflowIdReusePolicyRejectDuplicate. It has the same semantics as
+		// StartWorkflowOptions.WorkflowIDReusePolicy, and a child workflow which fails to start because of it fails
+		// with a ChildWorkflowExecutionAlreadyStartedError.
+		// Optional: defaulted to AllowDuplicate.
 		WorkflowIDReusePolicy enumspb.WorkflowIdReusePolicy
 
 		// RetryPolicy specify how to retry child workflow if error happens.
@@ -259,8 +312,11 @@ type (
 		// Use GetSearchAttributes API to get valid key and corresponding value type.
 		SearchAttributes map[string]interface{}
 
-		// ParentClosePolicy - Optional policy to decide what to do for the child.
-		// Default is Terminate (if onboarded to this feature)
+		// ParentClosePolicy - Optional policy to decide what the server does with the child when the parent workflow
+		// closes, for any reason including continue as new:
+		// PARENT_CLOSE_POLICY_TERMINATE terminates the child, PARENT_CLOSE_POLICY_REQUEST_CANCEL requests the
+		// cancellation of the child and PARENT_CLOSE_POLICY_ABANDON lets the child run.
+		// Default is PARENT_CLOSE_POLICY_TERMINATE, which the server uses when the policy is unspecified.
 		ParentClosePolicy enumspb.ParentClosePolicy
 	}
 
@@ -268,8 +324,28 @@ type (
 	RegisterWorkflowOptions struct {
 		Name                          string
 		DisableAlreadyRegisteredCheck bool
+
+		// Aliases are additional workflow type names the workflow is registered under, e.g. its former names after a
+		// rename, so that the workflows started with them keep running. Optional.
+		Aliases []string
+
+		// DeprecatedAliases are additional workflow type names the workflow is registered under like Aliases, which
+		// log a warning and increment the temporal_deprecated_workflow_type counter when a workflow is started with
+		// them, to find the starters still using them. Optional.
+		DeprecatedAliases []string
+
+		// Version registers the workflow as one of several side-by-side implementations of the workflow type Name,
+		// requiring Name to be set. Each workflow execution picks the implementation once, using a version marker
+		// recorded in its history: new executions run the highest registered version, replays run the version they
+		// started with. Register the implementation used before the type was versioned with DefaultVersion. Zero
+		// registers an unversioned workflow. Cannot be combined with Aliases or DeprecatedAliases. Optional.
+		Version Version
 	}
 
+	// DynamicWorkflowFunc is a workflow function that handles all workflow types that don't have a registered
+	// workflow. It receives the name of the workflow type and the encoded workflow arguments.
+	DynamicWorkflowFunc func(ctx Context, workflowType string, args converter.EncodedValues) (interface{}, error)
+
 	localActivityContext struct {
 		fn       interface{}
 		isMethod bool
@@ -449,6 +525,48 @@ func ExecuteActivity(ctx Context, activity interface{}, args ...interface{}) Fut
 	return i.ExecuteActivity(ctx, activityType, args...)
 }
 
+// ExecuteActivityWithOptions requests activity execution like ExecuteActivity, with the activity options of the context
+// overridden by the options set in options for this call only. This saves deriving a context with
+// WithActivityOptions when a single call needs, for example, a different timeout. Zero fields of options keep the
+// value of the context, WaitForCancellation only overrides the context when set to true.
+func ExecuteActivityWithOptions(ctx Context, options ActivityOptions, activity interface{}, args ...interface{}) Future {
+	merged := GetActivityOptions(ctx)
+	if options.TaskQueue != "" {
+		merged.TaskQueue = options.TaskQueue
+	}
+	if options.ScheduleToCloseTimeout != 0 {
+		merged.ScheduleToCloseTimeout = options.ScheduleToCloseTimeout
+	}
+	if options.ScheduleToStartTimeout != 0 {
+		merged.ScheduleToStartTimeout = options.ScheduleToStartTimeout
+	}
+	if options.StartToCloseTimeout != 0 {
+		merged.StartToCloseTimeout = options.StartToCloseTimeout
+	}
+	if options.HeartbeatTimeout != 0 {
+		merged.HeartbeatTimeout = options.HeartbeatTimeout
+	}
+	if options.WaitForCancellation {
+		merged.WaitForCancellation = true
+	}
+	if options.ActivityID != "" {
+		merged.ActivityID = options.ActivityID
+	}
+	if options.RetryPolicy != nil {
+		merged.RetryPolicy = options.RetryPolicy
+	}
+	if options.AffinityKey != "" {
+		merged.AffinityKey = options.AffinityKey
+	}
+	if options.Priority != 0 {
+		merged.Priority = options.Priority
+	}
+	if options.RetryTaskQueue != "" {
+		merged.RetryTaskQueue = options.RetryTaskQueue
+	}
+	return ExecuteActivity(WithActivityOptions(ctx, merged), activity, args...)
+}
+
 func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName string, args ...interface{}) Future {
 	// Validate type and its arguments.
 	dataConverter := getDataConverterFromWorkflowContext(ctx)
@@ -460,9 +578,14 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 		return future
 	}
 	// Validate context options.
+	if err := ValidateActivityOptions(GetActivityOptions(ctx)); err != nil {
+		settable.Set(nil, err)
+		return future
+	}
 	options := getActivityOptions(ctx)
 
 	// Validate session state.
+	inSession := false
 	if sessionInfo := getSessionInfo(ctx); sessionInfo != nil {
 		isCreationActivity := isSessionCreationActivity(typeName)
 		if sessionInfo.sessionState == sessionStateFailed && !isCreationActivity {
@@ -471,6 +594,7 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 		}
 		if sessionInfo.sessionState == sessionStateOpen && !isCreationActivity {
 			// Use session taskqueue
+			inSession = true
 			oldTaskQueueName := options.TaskQueueName
 			options.TaskQueueName = sessionInfo.taskqueue
 			defer func() {
@@ -481,6 +605,12 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 
 	// Retrieve headers from context to pass them on
 	header := getHeadersFromContext(ctx)
+	if options.Priority != 0 {
+		if err := setActivityPriority(header, options.Priority); err != nil {
+			settable.Set(nil, err)
+			return future
+		}
+	}
 
 	input, err := encodeArgs(dataConverter, args)
 	if err != nil {
@@ -494,11 +624,31 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 		DataConverter:          dataConverter,
 		Header:                 header,
 	}
+	if params.TaskQueueName == params.OriginalTaskQueueName {
+		if taskQueue := registry.taskQueueRoutes.activityTaskQueue(activityType.Name); taskQueue != "" {
+			params.TaskQueueName = taskQueue
+		}
+	}
 
+	if params.AffinityKey != "" && !inSession {
+		wc.executeActivityWithAffinity(ctx, params, settable)
+		return future
+	}
+	if params.hasWorkflowRetries() && !inSession {
+		wc.executeActivityWithWorkflowRetries(ctx, params, settable)
+		return future
+	}
+	wc.scheduleActivity(ctx, params, func(r *commonpb.Payloads, e error) {
+		settable.Set(r, e)
+	})
+	return future
+}
+
+func (wc *workflowEnvironmentInterceptor) scheduleActivity(ctx Context, params ExecuteActivityParams, callback ResultHandler) {
 	ctxDone, cancellable := ctx.Done().(*channelImpl)
 	cancellationCallback := &receiveCallback{}
 	a := getWorkflowEnvironment(ctx).ExecuteActivity(params, func(r *commonpb.Payloads, e error) {
-		settable.Set(r, e)
+		callback(r, e)
 		if cancellable {
 			// future is done, we don't need the cancellation callback anymore.
 			ctxDone.removeReceiveCallback(cancellationCallback)
@@ -517,7 +667,6 @@ func (wc *workflowEnvironmentInterceptor) ExecuteActivity(ctx Context, typeName
 			cancellationCallback.fn(nil, more)
 		}
 	}
-	return future
 }
 
 // ExecuteLocalActivity requests to run a local activity. A local activity is like a regular activity with some key
@@ -751,6 +900,12 @@ func (wc *workflowEnvironmentInterceptor) ExecuteChildWorkflow(ctx Context, chil
 		return result
 	}
 
+	if err := ValidateChildWorkflowOptions(GetChildWorkflowOptions(ctx)); err != nil {
+		executionSettable.Set(nil, err)
+		mainSettable.Set(nil, err)
+		return result
+	}
+
 	workflowOptionsFromCtx := getWorkflowEnvOptions(ctx)
 	dc := WithWorkflowContext(ctx, workflowOptionsFromCtx.DataConverter)
 	env := getWorkflowEnvironment(ctx)
@@ -775,6 +930,11 @@ func (wc *workflowEnvironmentInterceptor) ExecuteChildWorkflow(ctx Context, chil
 		scheduledTime:   Now(ctx), /* this is needed for test framework, and is not send to server */
 		attempt:         1,
 	}
+	if params.TaskQueueName == env.WorkflowInfo().TaskQueueName {
+		if taskQueue := env.GetRegistry().taskQueueRoutes.workflowTaskQueue(wfType.Name); taskQueue != "" {
+			params.TaskQueueName = taskQueue
+		}
+	}
 
 	ctxDone, cancellable := ctx.Done().(*channelImpl)
 	cancellationCallback := &receiveCallback{}
@@ -843,6 +1003,32 @@ type WorkflowInfo struct {
 	Memo                    *commonpb.Memo             // Value can be decoded using data converter (defaultDataConverter, or custom one if set).
 	SearchAttributes        *commonpb.SearchAttributes // Value can be decoded using defaultDataConverter.
 	BinaryChecksum          string
+	// WorkerIdentity is the identity of the worker running the workflow, see WorkerOptions.Identity and
+	// WorkerOptions.IdentityDetails. The workflow tasks of an execution can be processed by different workers, so
+	// it must only be used for logging or metrics, never to make decisions, which would break the determinism.
+	WorkerIdentity string
+}
+
+// WorkflowMetadata is the result of the QueryTypeWorkflowMetadata query, which describes a workflow execution for
+// generic tooling.
+type WorkflowMetadata struct {
+	// WorkflowType is the name the workflow definition is registered with.
+	WorkflowType string
+	// QueryTypes are the sorted types of the built-in queries and the queries registered with SetQueryHandler.
+	QueryTypes []string
+	// SignalChannels are the sorted names of the signal channels used by the workflow or which received a signal.
+	SignalChannels []string
+	// ChangeVersions are the versions recorded by GetVersion calls so far, by change ID.
+	ChangeVersions map[string]Version
+}
+
+// WorkflowState is the result of the QueryTypeWorkflowState query, which dumps the declared state of a workflow
+// execution for support tooling.
+type WorkflowState struct {
+	// Values are the values stored with SetValue, by key.
+	Values map[string]interface{}
+	// Variables are the current values of the variables registered with RegisterObservableVariable, by name.
+	Variables map[string]interface{}
 }
 
 // GetBinaryChecksum return binary checksum.
@@ -906,7 +1092,32 @@ func NewTimer(ctx Context, d time.Duration) Future {
 }
 
 func (wc *workflowEnvironmentInterceptor) NewTimer(ctx Context, d time.Duration) Future {
-	future, settable := NewFuture(ctx)
+	return wc.NewTimerWithOptions(ctx, d, TimerOptions{})
+}
+
+// TimerOptions are options for NewTimerWithOptions and SleepWithOptions.
+type TimerOptions struct {
+	// Summary is a short human readable description of what the timer is waiting for, e.g. "payment grace period".
+	// It names the timer in the stack trace of the workflow returned by the "__stack_trace" query, so pending timers
+	// can be told apart when debugging workflows with many timers.
+	Summary string
+}
+
+// NewTimerWithOptions is NewTimer with additional options, see TimerOptions.
+func NewTimerWithOptions(ctx Context, d time.Duration, options TimerOptions) Future {
+	i := getWorkflowOutboundCallsInterceptor(ctx)
+	return i.NewTimerWithOptions(ctx, d, options)
+}
+
+func (wc *workflowEnvironmentInterceptor) NewTimerWithOptions(ctx Context, d time.Duration, options TimerOptions) Future {
+	var future Future
+	var settable Settable
+	if options.Summary != "" {
+		impl := &futureImpl{channel: NewNamedChannel(ctx, fmt.Sprintf("Timer(%v)", options.Summary)).(*channelImpl)}
+		future, settable = impl, impl
+	} else {
+		future, settable = NewFuture(ctx)
+	}
 	if d <= 0 {
 		settable.Set(true, nil)
 		return future
@@ -956,6 +1167,64 @@ func (wc *workflowEnvironmentInterceptor) Sleep(ctx Context, d time.Duration) (e
 	return
 }
 
+// SleepUntil pauses the current workflow until the workflow time returned by Now reaches t. If t is not after the
+// current workflow time, SleepUntil returns immediately without starting a timer. The duration is computed from the
+// workflow time, which is deterministic, so the same timer is scheduled when the workflow is replayed.
+// See Sleep for cancellation.
+func SleepUntil(ctx Context, t time.Time) (err error) {
+	return Sleep(ctx, t.Sub(Now(ctx)))
+}
+
+// SleepWithOptions is Sleep with additional options, see TimerOptions.
+func SleepWithOptions(ctx Context, d time.Duration, options TimerOptions) (err error) {
+	t := NewTimerWithOptions(ctx, d, options)
+	err = t.Get(ctx, nil)
+	return
+}
+
+// GetExternalWorkflowHandle returns a handle to the workflow execution with the given workflowID and runID. The runID
+// is optional (default is ""), an empty runID targets the currently running instance of the workflowID. The namespace
+// of the target workflow is fixed when the handle is created: it is the namespace set on the context with
+// WithWorkflowNamespace, or the current workflow's namespace otherwise:
+//  handle := workflow.GetExternalWorkflowHandle(workflow.WithWorkflowNamespace(ctx, "namespace"), workflowID, "")
+//  err := handle.Signal(ctx, "signal-name", arg).Get(ctx, nil)
+// Prefer the handle to SignalExternalWorkflow and RequestCancelExternalWorkflow, where the target namespace depends
+// on the context passed to every call.
+func GetExternalWorkflowHandle(ctx Context, workflowID, runID string) ExternalWorkflowHandle {
+	namespace := ""
+	if options := getWorkflowEnvOptions(ctx); options != nil {
+		namespace = options.Namespace
+	}
+	if namespace == "" {
+		namespace = GetWorkflowInfo(ctx).Namespace
+	}
+	return &externalWorkflowHandleImpl{
+		workflowID: workflowID,
+		runID:      runID,
+		namespace:  namespace,
+	}
+}
+
+func (h *externalWorkflowHandleImpl) GetID() string {
+	return h.workflowID
+}
+
+func (h *externalWorkflowHandleImpl) GetRunID() string {
+	return h.runID
+}
+
+func (h *externalWorkflowHandleImpl) GetNamespace() string {
+	return h.namespace
+}
+
+func (h *externalWorkflowHandleImpl) Signal(ctx Context, signalName string, arg interface{}) Future {
+	return SignalExternalWorkflow(WithWorkflowNamespace(ctx, h.namespace), h.workflowID, h.runID, signalName, arg)
+}
+
+func (h *externalWorkflowHandleImpl) RequestCancel(ctx Context) Future {
+	return RequestCancelExternalWorkflow(WithWorkflowNamespace(ctx, h.namespace), h.workflowID, h.runID)
+}
+
 // RequestCancelExternalWorkflow can be used to request cancellation of an external workflow.
 // Input workflowID is the workflow ID of target workflow.
 // Input runID indicates the instance of a workflow. Input runID is optional (default is ""). When runID is not specified,
@@ -1006,6 +1275,69 @@ func SignalExternalWorkflow(ctx Context, workflowID, runID, signalName string, a
 	return i.SignalExternalWorkflow(ctx, workflowID, runID, signalName, arg)
 }
 
+// SignalExternalWorkflowsOptions configures SignalExternalWorkflows.
+type SignalExternalWorkflowsOptions struct {
+	// MaxConcurrent limits the number of signals that are in flight at the same time.
+	// Optional: default is 10.
+	MaxConcurrent int
+
+	// MaxSignalsPerWorkflowTask limits the number of signal commands issued from a single workflow task. Once
+	// reached, all pending signals are awaited before more are issued, which spreads a large batch over several
+	// workflow tasks and keeps each workflow task completion below the server size limits.
+	// Optional: default is 100.
+	MaxSignalsPerWorkflowTask int
+}
+
+const (
+	defaultSignalExternalWorkflowsMaxConcurrent             = 10
+	defaultSignalExternalWorkflowsMaxSignalsPerWorkflowTask = 100
+)
+
+// SignalExternalWorkflows sends the same signal to every workflow in targets, keeping at most
+// options.MaxConcurrent signals in flight and issuing at most options.MaxSignalsPerWorkflowTask
+// signals per workflow task. It blocks until all signals are delivered or failed.
+// The returned slice has the same length as targets; the error at index i is the result of signaling targets[i].
+// The namespace of the targets can be set on the context with WithWorkflowNamespace, as with SignalExternalWorkflow.
+func SignalExternalWorkflows(ctx Context, targets []WorkflowExecution, signalName string, arg interface{}, options SignalExternalWorkflowsOptions) []error {
+	maxConcurrent := options.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultSignalExternalWorkflowsMaxConcurrent
+	}
+	maxPerTask := options.MaxSignalsPerWorkflowTask
+	if maxPerTask <= 0 {
+		maxPerTask = defaultSignalExternalWorkflowsMaxSignalsPerWorkflowTask
+	}
+
+	errs := make([]error, len(targets))
+	selector := NewSelector(ctx)
+	pending, issued := 0, 0
+	for i, target := range targets {
+		// Results of the signals issued in this workflow task are only delivered in a later one,
+		// so draining everything pending forces the next batch into a new workflow task.
+		if issued >= maxPerTask {
+			for ; pending > 0; pending-- {
+				selector.Select(ctx)
+			}
+			issued = 0
+		}
+		for ; pending >= maxConcurrent; pending-- {
+			selector.Select(ctx)
+		}
+
+		index := i
+		f := SignalExternalWorkflow(ctx, target.ID, target.RunID, signalName, arg)
+		selector.AddFuture(f, func(f Future) {
+			errs[index] = f.Get(ctx, nil)
+		})
+		pending++
+		issued++
+	}
+	for ; pending > 0; pending-- {
+		selector.Select(ctx)
+	}
+	return errs
+}
+
 func (wc *workflowEnvironmentInterceptor) SignalExternalWorkflow(ctx Context, workflowID, runID, signalName string, arg interface{}) Future {
 	const childWorkflowOnly = false // this means we are not limited to child workflow
 	return signalExternalWorkflow(ctx, workflowID, runID, signalName, arg, childWorkflowOnly)
@@ -1202,6 +1534,35 @@ func (wc *workflowEnvironmentInterceptor) GetSignalChannel(ctx Context, signalNa
 	return getWorkflowEnvOptions(ctx).getSignalChannel(ctx, signalName)
 }
 
+// DrainSignalChannel receives up to max signals with the given name which are buffered on the signal channel without
+// blocking, or all of them if max is not positive. The signals are returned in the order they were received by the
+// workflow, as EncodedValue so that they can be decoded by the caller. Use it before the workflow completes or
+// continues as new to process signals which would be dropped otherwise:
+//  for _, signal := range workflow.DrainSignalChannel(ctx, "signal-name", 0) {
+//      var value string
+//      err := signal.Get(&value)
+//      ...
+//  }
+func DrainSignalChannel(ctx Context, signalName string, max int) []converter.EncodedValue {
+	ch := GetSignalChannel(ctx, signalName)
+	var result []converter.EncodedValue
+	for max <= 0 || len(result) < max {
+		var value converter.EncodedValue
+		if !ch.ReceiveAsync(&value) {
+			break
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+// GetUnhandledSignalNames returns the sorted names of the signals which were received by the workflow but not
+// consumed from their signal channels yet. Signals which are still unhandled when the workflow completes or continues
+// as new are dropped, which is reported with a warning and the temporal_unhandled_signals metric.
+func GetUnhandledSignalNames(ctx Context) []string {
+	return getWorkflowEnvOptions(ctx).getUnhandledSignals()
+}
+
 func newEncodedValue(value *commonpb.Payloads, dc converter.DataConverter) converter.EncodedValue {
 	if dc == nil {
 		dc = converter.GetDefaultDataConverter()
@@ -1281,6 +1642,42 @@ func (wc *workflowEnvironmentInterceptor) SideEffect(ctx Context, f func(ctx Con
 	return encoded
 }
 
+// Rand returns a pseudo-random number generator which produces the same sequence of numbers when the workflow is
+// replayed. The generator is seeded with a random value recorded through SideEffect, so every call to Rand adds a
+// marker to the workflow history. Create the generator once and reuse it instead of calling Rand for every number:
+//  r := workflow.Rand(ctx)
+//  if r.Intn(100) < 50 {
+//         ....
+//  }
+// The returned generator must not be shared between workflow executions.
+func Rand(ctx Context) *rand.Rand {
+	encodedSeed := SideEffect(ctx, func(ctx Context) interface{} {
+		var seed int64
+		if err := binary.Read(crand.Reader, binary.BigEndian, &seed); err != nil {
+			panic(err)
+		}
+		return seed
+	})
+	var seed int64
+	if err := encodedSeed.Get(&seed); err != nil {
+		panic(err)
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// NewUUID returns a random UUID in its string form. The value is recorded through SideEffect, so the same UUID is
+// returned when the workflow is replayed.
+func NewUUID(ctx Context) string {
+	encodedUUID := SideEffect(ctx, func(ctx Context) interface{} {
+		return uuid.New()
+	})
+	var id string
+	if err := encodedUUID.Get(&id); err != nil {
+		panic(err)
+	}
+	return id
+}
+
 // MutableSideEffect executes the provided function once, then it looks up the history for the value with the given id.
 // If there is no existing value, then it records the function result as a value with the given id on history;
 // otherwise, it compares whether the existing value from history has changed from the new function result by calling the
@@ -1308,6 +1705,69 @@ func (wc *workflowEnvironmentInterceptor) MutableSideEffect(ctx Context, id stri
 	return wc.env.MutableSideEffect(id, wrapperFunc, equals)
 }
 
+// valueMutableSideEffectIDPrefix prefixes the MutableSideEffect IDs used by SetValue.
+const valueMutableSideEffectIDPrefix = "temporal-value-"
+
+// SetValue stores a small value under the given key in the workflow execution. The value is recorded in the workflow
+// history with a MutableSideEffect marker when it changes, and restored from it on replay, so helper libraries can
+// keep state across the calls of a workflow without threading it through every function signature.
+// The value must be serializable by the DataConverter of the context and compared with reflect.DeepEqual to the
+// previous one, decoded into the type of the new value. An error is returned if the previous value can't be decoded
+// into that type, so keep storing values of a single type under a key. Values are not carried over to the run started
+// by continue-as-new.
+func SetValue(ctx Context, key string, value interface{}) error {
+	if key == "" {
+		return errors.New("key is empty")
+	}
+	if _, err := encodeArg(getDataConverterFromWorkflowContext(ctx), value); err != nil {
+		return err
+	}
+	wc := getWorkflowEnvironmentInterceptor(ctx)
+	// MutableSideEffect panics if the previous value can't be decoded to compare it, so the decoding is checked first.
+	if previous, ok := wc.values[key]; ok && value != nil {
+		if err := previous.Get(reflect.New(reflect.TypeOf(value)).Interface()); err != nil {
+			return fmt.Errorf("previous value of key %q can't be decoded into %T: %w", key, value, err)
+		}
+	}
+	encodedValue := MutableSideEffect(ctx, valueMutableSideEffectIDPrefix+key, func(ctx Context) interface{} {
+		return value
+	}, reflect.DeepEqual)
+	if wc.values == nil {
+		wc.values = make(map[string]converter.EncodedValue)
+	}
+	wc.values[key] = encodedValue
+	return nil
+}
+
+// GetValue decodes the value stored with SetValue under the given key into valuePtr. It returns false if no value is
+// stored under the key.
+func GetValue(ctx Context, key string, valuePtr interface{}) (bool, error) {
+	encodedValue, ok := getWorkflowEnvironmentInterceptor(ctx).values[key]
+	if !ok {
+		return false, nil
+	}
+	return true, encodedValue.Get(valuePtr)
+}
+
+// RegisterObservableVariable exposes a workflow variable in the result of the QueryTypeWorkflowState query under the
+// given name. The get function is called when the query is handled, so it must not block nor change the workflow state,
+// and its result must be serializable by the DataConverter of the workflow. Registering a name again replaces the
+// previous function.
+func RegisterObservableVariable(ctx Context, name string, get func() interface{}) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if get == nil {
+		return errors.New("get function is nil")
+	}
+	wc := getWorkflowEnvironmentInterceptor(ctx)
+	if wc.observables == nil {
+		wc.observables = make(map[string]func() interface{})
+	}
+	wc.observables[name] = get
+	return nil
+}
+
 // DefaultVersion is a version returned by GetVersion for code that wasn't versioned before
 const DefaultVersion Version = -1
 
@@ -1371,6 +1831,11 @@ const TemporalChangeVersion = "TemporalChangeVersion"
 //  } else {
 //    err = workflow.ExecuteActivity(ctx, qux, data).Get(ctx, nil)
 //  }
+//
+// To find out whether a GetVersion() call can be retired, list the executions which still record an older version
+// using the TemporalChangeVersion search attribute, e.g. TemporalChangeVersion="fooChange-1", or inspect their
+// histories with GetChangeVersions. Then replay the histories of the open executions against the code without
+// the call using WorkflowReplayer to verify that the removal doesn't break determinism.
 func GetVersion(ctx Context, changeID string, minSupported, maxSupported Version) Version {
 	i := getWorkflowOutboundCallsInterceptor(ctx)
 	return i.GetVersion(ctx, changeID, minSupported, maxSupported)
@@ -1380,6 +1845,36 @@ func (wc *workflowEnvironmentInterceptor) GetVersion(ctx Context, changeID strin
 	return wc.env.GetVersion(changeID, minSupported, maxSupported)
 }
 
+// patchedVersion is the version recorded by Patched and DeprecatePatch.
+const patchedVersion Version = 1
+
+// Patched is a simpler alternative to GetVersion for the common case of a single change with two branches. It
+// returns true if the workflow execution should run the new code and false if it should keep running the code
+// that existed before the change:
+//  if workflow.Patched(ctx, "fooChange") {
+//      err = workflow.ExecuteActivity(ctx, bar).Get(ctx, nil)
+//  } else {
+//      err = workflow.ExecuteActivity(ctx, foo).Get(ctx, nil)
+//  }
+//
+// The marker for the patchID is only recorded when the new branch is taken. An execution which is replayed from a
+// history that reached this point without the marker gets false. Patched(ctx, patchID) is equivalent to
+// GetVersion(ctx, patchID, DefaultVersion, 1) == 1, so the same patchID must not be used with GetVersion.
+//
+// Once there are no executions running the old branch, replace the call with DeprecatePatch and remove the old
+// branch. When all executions which could have called Patched are completed, DeprecatePatch can be removed too.
+func Patched(ctx Context, patchID string) bool {
+	return GetVersion(ctx, patchID, DefaultVersion, patchedVersion) == patchedVersion
+}
+
+// DeprecatePatch marks the patch introduced with Patched as deprecated after its old branch has been removed. It
+// keeps recording the marker, so that workers still running the code with Patched take the new branch when they
+// replay executions started on the newer code. Executions which reached this point without the marker still replay,
+// as DeprecatePatch doesn't check the recorded marker, so only remove the old branch once no execution can take it.
+func DeprecatePatch(ctx Context, patchID string) {
+	_ = GetVersion(ctx, patchID, DefaultVersion, patchedVersion)
+}
+
 // SetQueryHandler sets the query handler to handle workflow query. The queryType specify which query type this handler
 // should handle. The handler must be a function that returns 2 values. The first return value must be a serializable
 // result. The second return value must be an error. The handler function could receive any number of input parameters.
@@ -1430,6 +1925,24 @@ func (wc *workflowEnvironmentInterceptor) SetQueryHandler(ctx Context, queryType
 	return setQueryHandler(ctx, queryType, handler)
 }
 
+// Defer registers a finalizer run when the workflow function returns an error, which includes the workflow being
+// canceled, but not continuing as new. Finalizers run in reverse order of registration after the workflow function
+// returned, with a context which isn't canceled with the workflow, so they can execute activities to compensate or
+// clean up. The context of the finalizers is canceled when they run longer than the finalizer timeout, see
+// SetFinalizerTimeout.
+//  workflow.Defer(ctx, func(ctx workflow.Context) {
+//    _ = workflow.ExecuteActivity(ctx, ReleaseReservation, reservationID).Get(ctx, nil)
+//  })
+func Defer(ctx Context, finalizer func(ctx Context)) {
+	finalizers := getWorkflowEnvOptions(ctx).finalizers
+	finalizers.finalizers = append(finalizers.finalizers, finalizer)
+}
+
+// SetFinalizerTimeout sets the time all the finalizers registered with Defer may run for. Defaults to 1 minute.
+func SetFinalizerTimeout(ctx Context, timeout time.Duration) {
+	getWorkflowEnvOptions(ctx).finalizers.timeout = timeout
+}
+
 // IsReplaying returns whether the current workflow code is replaying.
 //
 // Warning! Never make commands, like schedule activity/childWorkflow/timer or send/wait on future/channel, based on
@@ -1437,7 +1950,9 @@ func (wc *workflowEnvironmentInterceptor) SetQueryHandler(ctx Context, queryType
 // The only reasonable use case for this flag is to avoid some external actions during replay, like custom logging or
 // metric reporting. Please note that Temporal already provide standard logging/metric via workflow.GetLogger(ctx) and
 // workflow.GetMetricsScope(ctx), and those standard mechanism are replay-aware and it will automatically suppress during
-// replay. Only use this flag if you need custom logging/metrics reporting, for example if you want to log to kafka.
+// replay. Custom loggers and metrics scopes can be made replay-aware the same way with NewReplayAwareLogger and
+// NewReplayAwareMetricsScope. Only use this flag if you need other custom reporting, for example if you want to log to
+// kafka.
 //
 // Warning! Any action protected by this flag should not fail or if it does fail should ignore that failure or panic
 // on the failure. If workflow don't want to be blocked on those failure, it should ignore those failure; if workflow do
@@ -1448,6 +1963,35 @@ func IsReplaying(ctx Context) bool {
 	return i.IsReplaying(ctx)
 }
 
+// replayStateProvider is implemented by workflow environments which replay history, to share their replay state with
+// replay-aware loggers and metrics scopes.
+type replayStateProvider interface {
+	replayState() (isReplay *bool, enableLoggingInReplay *bool)
+}
+
+// NewReplayAwareLogger returns a logger which forwards to the given logger only when the workflow is not replaying, the
+// same way the logger returned by GetLogger does. Like GetLogger, it still logs during replay if the worker is started
+// with WorkerOptions.EnableLoggingInReplay. The returned logger must only be used from the workflow that created it.
+func NewReplayAwareLogger(ctx Context, logger log.Logger) log.Logger {
+	if provider, ok := getWorkflowEnvironment(ctx).(replayStateProvider); ok {
+		isReplay, enableLoggingInReplay := provider.replayState()
+		return ilog.NewReplayLogger(logger, isReplay, enableLoggingInReplay)
+	}
+	return logger
+}
+
+// NewReplayAwareMetricsScope returns a metrics scope which reports to the given scope only when the workflow is not
+// replaying, the same way the scope returned by GetMetricsScope does. Timers and histograms are measured with the
+// workflow time. The returned scope must only be used from the workflow that created it.
+func NewReplayAwareMetricsScope(ctx Context, scope tally.Scope) tally.Scope {
+	env := getWorkflowEnvironment(ctx)
+	if provider, ok := env.(replayStateProvider); ok {
+		isReplay, _ := provider.replayState()
+		return metrics.WrapScope(isReplay, scope, env)
+	}
+	return scope
+}
+
 func (wc *workflowEnvironmentInterceptor) IsReplaying(ctx Context) bool {
 	return wc.env.IsReplaying()
 }
@@ -1517,7 +2061,10 @@ func WithActivityOptions(ctx Context, options ActivityOptions) Context {
 	eap.HeartbeatTimeout = options.HeartbeatTimeout
 	eap.WaitForCancellation = options.WaitForCancellation
 	eap.ActivityID = options.ActivityID
-	eap.RetryPolicy = convertToPBRetryPolicy(options.RetryPolicy)
+	setActivityRetryPolicy(eap, options.RetryPolicy)
+	eap.AffinityKey = options.AffinityKey
+	eap.Priority = options.Priority
+	eap.RetryTaskQueue = options.RetryTaskQueue
 	return ctx1
 }
 
@@ -1555,7 +2102,10 @@ func GetActivityOptions(ctx Context) ActivityOptions {
 		HeartbeatTimeout:       opts.HeartbeatTimeout,
 		WaitForCancellation:    opts.WaitForCancellation,
 		ActivityID:             opts.ActivityID,
-		RetryPolicy:            convertFromPBRetryPolicy(opts.RetryPolicy),
+		RetryPolicy:            getActivityOptionsRetryPolicy(opts),
+		AffinityKey:            opts.AffinityKey,
+		Priority:               opts.Priority,
+		RetryTaskQueue:         opts.RetryTaskQueue,
 	}
 }
 
@@ -1618,7 +2168,7 @@ func WithWaitForCancellation(ctx Context, wait bool) Context {
 // WithRetryPolicy adds retry policy to the copy of the context
 func WithRetryPolicy(ctx Context, retryPolicy RetryPolicy) Context {
 	ctx1 := setActivityParametersIfNotExist(ctx)
-	getActivityOptions(ctx1).RetryPolicy = convertToPBRetryPolicy(&retryPolicy)
+	setActivityRetryPolicy(getActivityOptions(ctx1), &retryPolicy)
 	return ctx1
 }
 
@@ -0,0 +1,146 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"hash/fnv"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/workflowservice/v1"
+
+	"go.temporal.io/sdk/converter"
+)
+
+// WorkflowAuditEventType is the type of a WorkflowAuditEvent.
+type WorkflowAuditEventType int
+
+const (
+	// WorkflowAuditStarted is reported when the first workflow task of a workflow execution is completed.
+	WorkflowAuditStarted WorkflowAuditEventType = iota
+	// WorkflowAuditCompleted is reported when a workflow execution completes successfully.
+	WorkflowAuditCompleted
+	// WorkflowAuditFailed is reported when a workflow execution fails.
+	WorkflowAuditFailed
+	// WorkflowAuditCanceled is reported when a workflow execution is canceled.
+	WorkflowAuditCanceled
+	// WorkflowAuditContinuedAsNew is reported when a workflow execution continues as new.
+	WorkflowAuditContinuedAsNew
+)
+
+type (
+	// WorkflowAuditor is called by a worker on the lifecycle events of the workflow executions it processes, to
+	// implement compliance auditing centrally instead of inside each workflow, see WorkerOptions.WorkflowAudit.
+	WorkflowAuditor interface {
+		// AuditWorkflow is called from the workflow task processing, right before the workflow task completion is
+		// sent to the server, so it must not block. An event is reported again if the workflow task completion fails
+		// and the workflow task is retried.
+		AuditWorkflow(event WorkflowAuditEvent)
+	}
+
+	// WorkflowAuditOptions configure the auditing of the workflow executions processed by a worker.
+	WorkflowAuditOptions struct {
+		// Auditor is called on the lifecycle events of the workflow executions. Required.
+		Auditor WorkflowAuditor
+
+		// PayloadSampleRate is the fraction, between 0 and 1, of the workflow executions which events give access to
+		// the workflow input and result. The sampling is based on the workflow ID, so all the events of a sampled
+		// workflow execution, and of the runs it continues as new, carry their payloads.
+		// default: 0, no payloads
+		PayloadSampleRate float64
+	}
+
+	// WorkflowAuditEvent describes a lifecycle event of a workflow execution, passed to WorkflowAuditor.
+	WorkflowAuditEvent struct {
+		Type         WorkflowAuditEventType
+		Namespace    string
+		TaskQueue    string
+		WorkflowType string
+		WorkflowID   string
+		RunID        string
+		Attempt      int32
+		Time         time.Time
+		// Sampled is true when the workflow execution is sampled according to WorkflowAuditOptions.PayloadSampleRate.
+		Sampled bool
+		// Payload is the input of the workflow for WorkflowAuditStarted and WorkflowAuditContinuedAsNew, the result
+		// for WorkflowAuditCompleted and the details for WorkflowAuditCanceled, decoded with the DataConverter of the
+		// worker. It is nil when the workflow execution is not sampled.
+		Payload converter.EncodedValues
+		// Error is the error failing the workflow for WorkflowAuditFailed.
+		Error error
+	}
+)
+
+// isSampled tells whether the payloads of the workflow are sampled.
+func (o *WorkflowAuditOptions) isSampled(workflowID string) bool {
+	if o.PayloadSampleRate <= 0 {
+		return false
+	}
+	if o.PayloadSampleRate >= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(workflowID))
+	return float64(h.Sum32()%10000) < o.PayloadSampleRate*10000
+}
+
+// auditWorkflow reports a lifecycle event of the workflow execution of the task to the configured auditor.
+func (wth *workflowTaskHandlerImpl) auditWorkflow(
+	task *workflowservice.PollWorkflowTaskQueueResponse,
+	workflowInfo *WorkflowInfo,
+	eventType WorkflowAuditEventType,
+	payload *commonpb.Payloads,
+	err error,
+) {
+	event := WorkflowAuditEvent{
+		Type:         eventType,
+		Namespace:    wth.namespace,
+		TaskQueue:    workflowInfo.TaskQueueName,
+		WorkflowType: task.WorkflowType.GetName(),
+		WorkflowID:   task.WorkflowExecution.GetWorkflowId(),
+		RunID:        task.WorkflowExecution.GetRunId(),
+		Attempt:      workflowInfo.Attempt,
+		Time:         time.Now(),
+		Sampled:      wth.workflowAudit.isSampled(task.WorkflowExecution.GetWorkflowId()),
+		Error:        err,
+	}
+	if event.Sampled {
+		event.Payload = newEncodedValues(payload, wth.dataConverter)
+	}
+	wth.workflowAudit.Auditor.AuditWorkflow(event)
+}
+
+// auditWorkflowStarted reports the start of the workflow execution if the task is its first workflow task.
+func (wth *workflowTaskHandlerImpl) auditWorkflowStarted(task *workflowservice.PollWorkflowTaskQueueResponse,
+	workflowInfo *WorkflowInfo) {
+	events := task.History.GetEvents()
+	if task.GetPreviousStartedEventId() != 0 || len(events) == 0 ||
+		events[0].GetEventType() != enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED {
+		return
+	}
+	input := events[0].GetWorkflowExecutionStartedEventAttributes().GetInput()
+	wth.auditWorkflow(task, workflowInfo, WorkflowAuditStarted, input, nil)
+}
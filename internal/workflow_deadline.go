@@ -0,0 +1,143 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+
+	"go.temporal.io/sdk/converter"
+)
+
+// durableDeadlinesHeaderKey is the header field of a new run holding the deadlines set with SetDeadline in the
+// previous runs.
+const durableDeadlinesHeaderKey = "temporal-durable-deadlines"
+
+type (
+	// workflowDeadlines holds the deadlines of a workflow execution, including the ones carried over from the
+	// previous runs.
+	workflowDeadlines struct {
+		times  map[string]time.Time
+		timers map[string]*deadlineTimer
+	}
+
+	// deadlineTimer is the timer armed in the current run for a deadline.
+	deadlineTimer struct {
+		future Future
+		cancel CancelFunc
+	}
+)
+
+// SetDeadline records an absolute deadline under the given name and returns a Future which is ready when the deadline
+// is reached, e.g. the expiration of an order 90 days after it is placed. Unlike a timer, the deadline is carried over
+// to the run started by continue-as-new, where GetDeadline re-arms its timer for the remaining time, so long business
+// deadlines don't need to be passed from run to run by the workflow. Setting a deadline again under the same name
+// replaces it, canceling the timer of the previous one.
+// The future fails with a CanceledError if ctx is canceled or the deadline is removed before it is reached.
+func SetDeadline(ctx Context, name string, deadline time.Time) (Future, error) {
+	if name == "" {
+		return nil, errors.New("deadline name is empty")
+	}
+	if deadline.IsZero() {
+		return nil, errors.New("deadline is zero")
+	}
+	deadlines := getWorkflowDeadlines(ctx)
+	deadlines.cancelTimer(name)
+	deadlines.times[name] = deadline
+	return deadlines.armTimer(ctx, name), nil
+}
+
+// GetDeadline returns the deadline recorded under the given name with SetDeadline, in this run or in a previous run
+// continued as new, and a Future which is ready when the deadline is reached. The timer of a deadline set in a
+// previous run is armed on the first call, so the future is ready right away if the deadline has already passed.
+// It returns false if no deadline is recorded under the name.
+func GetDeadline(ctx Context, name string) (time.Time, Future, bool) {
+	deadlines := getWorkflowDeadlines(ctx)
+	deadline, ok := deadlines.times[name]
+	if !ok {
+		return time.Time{}, nil, false
+	}
+	if timer, ok := deadlines.timers[name]; ok {
+		return deadline, timer.future, true
+	}
+	return deadline, deadlines.armTimer(ctx, name), true
+}
+
+// RemoveDeadline removes the deadline recorded under the given name, canceling its timer, so that it isn't carried
+// over to the next run anymore.
+func RemoveDeadline(ctx Context, name string) {
+	deadlines := getWorkflowDeadlines(ctx)
+	deadlines.cancelTimer(name)
+	delete(deadlines.times, name)
+}
+
+func getWorkflowDeadlines(ctx Context) *workflowDeadlines {
+	wc := getWorkflowEnvironmentInterceptor(ctx)
+	if wc.deadlines == nil {
+		wc.deadlines = &workflowDeadlines{times: make(map[string]time.Time)}
+	}
+	if wc.deadlines.timers == nil {
+		wc.deadlines.timers = make(map[string]*deadlineTimer)
+	}
+	return wc.deadlines
+}
+
+func (d *workflowDeadlines) armTimer(ctx Context, name string) Future {
+	timerCtx, cancel := WithCancel(ctx)
+	remaining := d.times[name].Sub(Now(ctx))
+	future := NewTimerWithOptions(timerCtx, remaining, TimerOptions{Summary: "deadline " + name})
+	d.timers[name] = &deadlineTimer{future: future, cancel: cancel}
+	return future
+}
+
+func (d *workflowDeadlines) cancelTimer(name string) {
+	if timer, ok := d.timers[name]; ok {
+		timer.cancel()
+		delete(d.timers, name)
+	}
+}
+
+// encodeDurableDeadlines encodes the deadlines into a header payload of the next run, nil if there is no deadline.
+func encodeDurableDeadlines(deadlines *workflowDeadlines) (*commonpb.Payload, error) {
+	if deadlines == nil || len(deadlines.times) == 0 {
+		return nil, nil
+	}
+	payload, err := converter.GetDefaultDataConverter().ToPayload(deadlines.times)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode deadlines: %w", err)
+	}
+	return payload, nil
+}
+
+func decodeDurableDeadlines(payload *commonpb.Payload) (*workflowDeadlines, error) {
+	times := make(map[string]time.Time)
+	if err := converter.GetDefaultDataConverter().FromPayload(payload, &times); err != nil {
+		return nil, fmt.Errorf("unable to decode deadlines: %w", err)
+	}
+	return &workflowDeadlines{times: times}, nil
+}
@@ -0,0 +1,332 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+)
+
+const (
+	// operationRequestSignalName is the signal sent by ExecuteOperation to the workflow exposing the operation.
+	operationRequestSignalName = "__temporal_operation_request"
+	// operationResponseSignalName is the signal sent back to the caller of ExecuteOperation with the result.
+	operationResponseSignalName = "__temporal_operation_response"
+	// operationCancelSignalName is the signal sent by ExecuteOperation to the workflow exposing the operation when
+	// the call is canceled or times out, canceling the context of the handler.
+	operationCancelSignalName = "__temporal_operation_cancel"
+)
+
+type (
+	// OperationOptions configure the call of an operation exposed by a workflow with SetOperationHandler, possibly
+	// in another namespace.
+	OperationOptions struct {
+		// Optional: Namespace of the workflow exposing the operation.
+		// default: the namespace of the calling workflow
+		Namespace string
+
+		// Required: ID of the workflow exposing the operation.
+		WorkflowID string
+
+		// Optional: Run ID of the workflow exposing the operation.
+		// default: the current run of WorkflowID
+		RunID string
+
+		// Optional: Time after which the future of the operation fails with a TimeoutError if no response was
+		// received. A late response is ignored.
+		// default: no timeout
+		Timeout time.Duration
+	}
+
+	operationRequest struct {
+		ID               string
+		Operation        string
+		CallerNamespace  string
+		CallerWorkflowID string
+		CallerRunID      string
+		Input            *commonpb.Payloads
+	}
+
+	operationCancel struct {
+		ID string
+	}
+
+	operationResponse struct {
+		ID           string
+		Result       *commonpb.Payloads
+		Error        string
+		ErrorType    string
+		NonRetryable bool
+	}
+
+	// workflowOperations is the state of the operations called and exposed by a workflow execution.
+	workflowOperations struct {
+		seq             int
+		pending         map[string]Settable
+		handlers        map[string]interface{}
+		running         map[string]CancelFunc
+		receivingResult bool
+		receivingCalls  bool
+	}
+)
+
+// ExecuteOperation calls an operation exposed with SetOperationHandler by another workflow, possibly in another
+// namespace, and returns a Future which is ready with the result of the operation handler.
+// The call is a request signal sent to the target workflow, which signals the response back to the calling workflow
+// when the handler returns, so both workflows must be able to signal each other.
+// A failure of the handler is returned as an ApplicationError with the message, type and retryability of the original
+// error. When ctx is canceled before the response is received, the future fails with a CanceledError once the
+// cancellation is signaled to the target workflow, which cancels the context of the handler. The context of the
+// handler is canceled as well when the call times out. A late response is ignored.
+func ExecuteOperation(ctx Context, options OperationOptions, operation string, args ...interface{}) Future {
+	future, settable := NewFuture(ctx)
+	if options.WorkflowID == "" {
+		settable.Set(nil, errWorkflowIDNotSet)
+		return future
+	}
+	if operation == "" {
+		settable.Set(nil, errors.New("operation is empty"))
+		return future
+	}
+	input, err := encodeArgs(getDataConverterFromWorkflowContext(ctx), args)
+	if err != nil {
+		settable.Set(nil, err)
+		return future
+	}
+
+	ops := getWorkflowOperations(ctx)
+	if !ops.receivingResult {
+		ops.receivingResult = true
+		Go(ctx, ops.receiveResults)
+	}
+	info := GetWorkflowInfo(ctx)
+	ops.seq++
+	request := operationRequest{
+		ID:               fmt.Sprintf("%s-%d", info.WorkflowExecution.RunID, ops.seq),
+		Operation:        operation,
+		CallerNamespace:  info.Namespace,
+		CallerWorkflowID: info.WorkflowExecution.ID,
+		CallerRunID:      info.WorkflowExecution.RunID,
+		Input:            input,
+	}
+	ops.pending[request.ID] = settable
+
+	signalCtx := ctx
+	if options.Namespace != "" {
+		signalCtx = WithWorkflowNamespace(ctx, options.Namespace)
+	}
+	signalFuture := SignalExternalWorkflow(signalCtx, options.WorkflowID, options.RunID, operationRequestSignalName, request)
+	Go(ctx, func(ctx Context) {
+		if err := signalFuture.Get(ctx, nil); err != nil {
+			ops.complete(request.ID, nil, err)
+			return
+		}
+		// Wait for the response, the cancellation of ctx or the timeout, whichever comes first.
+		var abortErr error
+		selector := NewSelector(ctx)
+		selector.AddFuture(future, func(Future) {})
+		selector.AddReceive(ctx.Done(), func(ReceiveChannel, bool) {
+			abortErr = ErrCanceled
+		})
+		if options.Timeout > 0 {
+			timerCtx, cancelTimer := WithCancel(ctx)
+			defer cancelTimer()
+			selector.AddFuture(NewTimer(timerCtx, options.Timeout), func(f Future) {
+				if err := f.Get(ctx, nil); err != nil {
+					// The timer is canceled along with ctx.
+					abortErr = ErrCanceled
+					return
+				}
+				abortErr = NewTimeoutError(
+					fmt.Sprintf("operation %s timed out", operation), enumspb.TIMEOUT_TYPE_SCHEDULE_TO_CLOSE, nil)
+			})
+		}
+		selector.Select(ctx)
+		if abortErr == nil {
+			return
+		}
+
+		// ctx may be canceled already, so the handler is canceled from a disconnected context.
+		cancelCtx, _ := NewDisconnectedContext(ctx)
+		if options.Namespace != "" {
+			cancelCtx = WithWorkflowNamespace(cancelCtx, options.Namespace)
+		}
+		err := SignalExternalWorkflow(cancelCtx, options.WorkflowID, options.RunID, operationCancelSignalName,
+			operationCancel{ID: request.ID}).Get(cancelCtx, nil)
+		if err != nil {
+			GetLogger(ctx).Warn("Unable to signal operation cancellation.",
+				"Operation", operation, "WorkflowID", options.WorkflowID, tagError, err)
+		}
+		ops.complete(request.ID, nil, abortErr)
+	})
+	return future
+}
+
+// SetOperationHandler exposes an operation of the workflow which other workflows can call with ExecuteOperation.
+// The handler must be a function taking a Context and the operation arguments and returning a serializable result
+// and an error. Each call runs the handler in its own coroutine of the workflow, so it can block, e.g. to execute
+// activities, and the result is signaled back to the caller when it returns. The context of the handler is canceled
+// when the call is canceled or times out on the caller side.
+// Calls of operations without a handler fail with an ApplicationError of type "OperationNotFound".
+func SetOperationHandler(ctx Context, operation string, handler interface{}) error {
+	if operation == "" {
+		return errors.New("operation is empty")
+	}
+	fnType := reflect.TypeOf(handler)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return fmt.Errorf("operation handler must be function but was %v", fnType)
+	}
+	if fnType.NumIn() < 1 || !isWorkflowContext(fnType.In(0)) {
+		return errors.New("first argument of operation handler must be workflow.Context")
+	}
+	if fnType.NumOut() != 2 || !isValidResultType(fnType.Out(0)) || !isError(fnType.Out(1)) {
+		return errors.New("operation handler must return 2 values (serializable result and error)")
+	}
+
+	ops := getWorkflowOperations(ctx)
+	ops.handlers[operation] = handler
+	if !ops.receivingCalls {
+		ops.receivingCalls = true
+		Go(ctx, ops.receiveCalls)
+		Go(ctx, ops.receiveCancels)
+	}
+	return nil
+}
+
+func getWorkflowOperations(ctx Context) *workflowOperations {
+	wc := getWorkflowEnvironmentInterceptor(ctx)
+	if wc.operations == nil {
+		wc.operations = &workflowOperations{
+			pending:  make(map[string]Settable),
+			handlers: make(map[string]interface{}),
+			running:  make(map[string]CancelFunc),
+		}
+	}
+	return wc.operations
+}
+
+// complete sets the future of a pending operation, unless it is already completed.
+func (ops *workflowOperations) complete(id string, result *commonpb.Payloads, err error) {
+	settable, ok := ops.pending[id]
+	if !ok {
+		return
+	}
+	delete(ops.pending, id)
+	settable.Set(result, err)
+}
+
+func (ops *workflowOperations) receiveResults(ctx Context) {
+	ch := GetSignalChannel(ctx, operationResponseSignalName)
+	for {
+		var response operationResponse
+		ch.Receive(ctx, &response)
+		var err error
+		if response.Error != "" {
+			err = NewApplicationError(response.Error, response.ErrorType, response.NonRetryable, nil)
+		}
+		ops.complete(response.ID, response.Result, err)
+	}
+}
+
+func (ops *workflowOperations) receiveCalls(ctx Context) {
+	ch := GetSignalChannel(ctx, operationRequestSignalName)
+	for {
+		var request operationRequest
+		ch.Receive(ctx, &request)
+		callCtx, cancel := WithCancel(ctx)
+		ops.running[request.ID] = cancel
+		Go(callCtx, func(ctx Context) {
+			response := ops.handle(ctx, request)
+			cancel()
+			delete(ops.running, request.ID)
+			// The response of a canceled handler is signaled as well, from a disconnected context.
+			ctx, _ = NewDisconnectedContext(ctx)
+			ctx = WithWorkflowNamespace(ctx, request.CallerNamespace)
+			err := SignalExternalWorkflow(ctx, request.CallerWorkflowID, request.CallerRunID, operationResponseSignalName,
+				response).Get(ctx, nil)
+			if err != nil {
+				GetLogger(ctx).Warn("Unable to signal operation response.",
+					"Operation", request.Operation, "CallerWorkflowID", request.CallerWorkflowID, tagError, err)
+			}
+		})
+	}
+}
+
+func (ops *workflowOperations) receiveCancels(ctx Context) {
+	ch := GetSignalChannel(ctx, operationCancelSignalName)
+	for {
+		var request operationCancel
+		ch.Receive(ctx, &request)
+		if cancel, ok := ops.running[request.ID]; ok {
+			cancel()
+		}
+	}
+}
+
+func (ops *workflowOperations) handle(ctx Context, request operationRequest) operationResponse {
+	response := operationResponse{ID: request.ID}
+	handler, ok := ops.handlers[request.Operation]
+	if !ok {
+		response.Error = fmt.Sprintf("unknown operation %v", request.Operation)
+		response.ErrorType = "OperationNotFound"
+		response.NonRetryable = true
+		return response
+	}
+
+	dc := getDataConverterFromWorkflowContext(ctx)
+	fnType := reflect.TypeOf(handler)
+	args, err := decodeArgs(dc, fnType, request.Input)
+	if err != nil {
+		response.Error = fmt.Sprintf("unable to decode the input of operation %v: %v", request.Operation, err)
+		response.NonRetryable = true
+		return response
+	}
+	retValues := reflect.ValueOf(handler).Call(append([]reflect.Value{reflect.ValueOf(ctx)}, args...))
+
+	if errValue := retValues[1]; !errValue.IsNil() {
+		err = errValue.Interface().(error)
+		response.Error = err.Error()
+		response.ErrorType = getErrType(err)
+		var applicationErr *ApplicationError
+		if errors.As(err, &applicationErr) {
+			response.Error = applicationErr.message()
+			response.ErrorType = applicationErr.Type()
+			response.NonRetryable = applicationErr.NonRetryable()
+		}
+		return response
+	}
+	if retValue := retValues[0]; retValue.Kind() != reflect.Ptr || !retValue.IsNil() {
+		response.Result, err = encodeArg(dc, retValue.Interface())
+		if err != nil {
+			response.Error = err.Error()
+		}
+	}
+	return response
+}
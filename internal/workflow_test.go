@@ -61,8 +61,13 @@ func TestGetChildWorkflowOptions(t *testing.T) {
 	// Require test options to have non-zero value for each field. This ensures that we update tests (and the
 	// GetChildWorkflowOptions implementation) when new fields are added to the ChildWorkflowOptions struct.
 	assertNonZero(t, opts)
-	// Check that the same opts set on context are also extracted from context
-	assert.Equal(t, opts, GetChildWorkflowOptions(WithChildWorkflowOptions(newTestWorkflowContext(), opts)))
+	// Check that the same opts set on context are also extracted from context, except the retry options which only
+	// apply to activities.
+	expected := opts
+	expected.RetryPolicy = newTestRetryPolicy()
+	expected.RetryPolicy.StartToCloseTimeoutCoefficient = 0
+	expected.RetryPolicy.MaximumAttemptsDuration = 0
+	assert.Equal(t, expected, GetChildWorkflowOptions(WithChildWorkflowOptions(newTestWorkflowContext(), opts)))
 }
 
 func TestGetActivityOptions(t *testing.T) {
@@ -75,6 +80,9 @@ func TestGetActivityOptions(t *testing.T) {
 		WaitForCancellation:    true,
 		ActivityID:             "bar",
 		RetryPolicy:            newTestRetryPolicy(),
+		AffinityKey:            "baz",
+		Priority:               5,
+		RetryTaskQueue:         "qux",
 	}
 
 	assertNonZero(t, opts)
@@ -119,11 +127,13 @@ func newTestWorkflowContext() Context {
 
 func newTestRetryPolicy() *RetryPolicy {
 	return &RetryPolicy{
-		InitialInterval:        1,
-		BackoffCoefficient:     2,
-		MaximumInterval:        3,
-		MaximumAttempts:        4,
-		NonRetryableErrorTypes: []string{"my_error"},
+		InitialInterval:                1,
+		BackoffCoefficient:             2,
+		MaximumInterval:                3,
+		MaximumAttempts:                4,
+		NonRetryableErrorTypes:         []string{"my_error"},
+		StartToCloseTimeoutCoefficient: 1.5,
+		MaximumAttemptsDuration:        5,
 	}
 }
 
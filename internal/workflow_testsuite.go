@@ -26,6 +26,7 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -79,8 +80,19 @@ type (
 		runFn        func(args mock.Arguments)
 		waitDuration func() time.Duration
 	}
+
+	// TestQueryResult is the result of a query registered with TestWorkflowEnvironment.QueryWorkflowByTime. It is
+	// available once ExecuteWorkflow has returned.
+	TestQueryResult struct {
+		issued bool
+		value  converter.EncodedValue
+		err    error
+	}
 )
 
+// errQueryNotIssued is returned by TestQueryResult.Get when the workflow completed before the query was due.
+var errQueryNotIssued = errors.New("query was not issued, the workflow completed before the query time")
+
 func newEncodedValues(values *commonpb.Payloads, dc converter.DataConverter) converter.EncodedValues {
 	if dc == nil {
 		dc = converter.GetDefaultDataConverter()
@@ -171,6 +183,12 @@ func (t *TestActivityEnvironment) RegisterActivityWithOptions(a interface{}, opt
 	t.impl.RegisterActivityWithOptions(a, options)
 }
 
+// RegisterDynamicActivity registers the activity function that handles the activity types without a registered
+// activity with TestActivityEnvironment
+func (t *TestActivityEnvironment) RegisterDynamicActivity(a DynamicActivityFunc) {
+	t.impl.RegisterDynamicActivity(a)
+}
+
 // ExecuteActivity executes an activity. The tested activity will be executed synchronously in the calling goroutinue.
 // Caller should use EncodedValue.Get() to extract strong typed result value.
 func (t *TestActivityEnvironment) ExecuteActivity(activityFn interface{}, args ...interface{}) (converter.EncodedValue, error) {
@@ -240,6 +258,44 @@ func (t *TestActivityEnvironment) SetWorkerStopChannel(c chan struct{}) {
 	t.impl.setWorkerStopChannel(c)
 }
 
+// StopWorker closes the worker stop channel returned from activity.GetWorkerStopChannel(context), as if the worker
+// was stopping. It can be called from the tested activity, a heartbeat listener or another goroutine.
+func (t *TestActivityEnvironment) StopWorker() {
+	t.impl.stopWorker()
+}
+
+// RequestCancelActivity cancels the context of the activity being executed by ExecuteActivity, as happens when the
+// activity is canceled and the cancellation is delivered to the activity. It can be called from the tested activity,
+// a heartbeat listener or another goroutine.
+func (t *TestActivityEnvironment) RequestCancelActivity() {
+	t.impl.requestCancelTestActivity()
+}
+
+// SetOnActivityHeartbeatListener sets a listener that is called synchronously on every activity.RecordHeartbeat call
+// made by the activity executed by ExecuteActivity.
+// Note: ActivityInfo is defined in internal package, use public type activity.Info instead.
+func (t *TestActivityEnvironment) SetOnActivityHeartbeatListener(
+	listener func(activityInfo *ActivityInfo, details converter.EncodedValues)) *TestActivityEnvironment {
+	t.impl.onActivityHeartbeatListener = listener
+	return t
+}
+
+// GetHeartbeats returns the details of every activity.RecordHeartbeat call made by the last activity executed by
+// ExecuteActivity, in order. Heartbeats with no details have no values.
+func (t *TestActivityEnvironment) GetHeartbeats() []converter.EncodedValues {
+	return t.impl.getActivityHeartbeats()
+}
+
+// SetServiceInvoker sets the ServiceInvoker used by the activity executed by ExecuteActivity to report its heartbeats
+// and to get a client, in place of the mocked Temporal service. Heartbeats are still recorded and passed to the
+// heartbeat listener. Use RequestCancelActivity to simulate the cancellation of the activity.
+func (t *TestActivityEnvironment) SetServiceInvoker(invoker ServiceInvoker) *TestActivityEnvironment {
+	t.impl.locker.Lock()
+	defer t.impl.locker.Unlock()
+	t.impl.activityServiceInvoker = invoker
+	return t
+}
+
 // RegisterWorkflow registers workflow implementation with the TestWorkflowEnvironment
 func (e *TestWorkflowEnvironment) RegisterWorkflow(w interface{}) {
 	e.impl.RegisterWorkflow(w)
@@ -253,6 +309,12 @@ func (e *TestWorkflowEnvironment) RegisterWorkflowWithOptions(w interface{}, opt
 	e.impl.RegisterWorkflowWithOptions(w, options)
 }
 
+// RegisterDynamicWorkflow registers the workflow function that handles the workflow types without a registered
+// workflow with the TestWorkflowEnvironment
+func (e *TestWorkflowEnvironment) RegisterDynamicWorkflow(w DynamicWorkflowFunc) {
+	e.impl.RegisterDynamicWorkflow(w)
+}
+
 // RegisterActivity registers activity implementation with TestWorkflowEnvironment
 func (e *TestWorkflowEnvironment) RegisterActivity(a interface{}) {
 	e.impl.RegisterActivity(a)
@@ -266,6 +328,12 @@ func (e *TestWorkflowEnvironment) RegisterActivityWithOptions(a interface{}, opt
 	e.impl.RegisterActivityWithOptions(a, options)
 }
 
+// RegisterDynamicActivity registers the activity function that handles the activity types without a registered
+// activity with TestWorkflowEnvironment
+func (e *TestWorkflowEnvironment) RegisterDynamicActivity(a DynamicActivityFunc) {
+	e.impl.RegisterDynamicActivity(a)
+}
+
 // SetStartTime sets the start time of the workflow. This is optional, default start time will be the wall clock time when
 // workflow starts. Start time is the workflow.Now(ctx) time at the beginning of the workflow.
 func (e *TestWorkflowEnvironment) SetStartTime(startTime time.Time) {
@@ -318,6 +386,49 @@ func (e *TestWorkflowEnvironment) OnActivity(activity interface{}, args ...inter
 // This error is also exposed as public as testsuite.ErrMockStartChildWorkflowFailed
 var ErrMockStartChildWorkflowFailed = fmt.Errorf("start child workflow failed: %v", enumspb.START_CHILD_WORKFLOW_EXECUTION_FAILED_CAUSE_WORKFLOW_ALREADY_EXISTS)
 
+// mockActivityTimeoutError is returned by a mocked activity to simulate an activity timeout.
+type mockActivityTimeoutError struct {
+	timeoutType enumspb.TimeoutType
+	details     []interface{}
+}
+
+func (e *mockActivityTimeoutError) Error() string {
+	switch e.timeoutType {
+	case enumspb.TIMEOUT_TYPE_SCHEDULE_TO_START:
+		return "schedule-to-start timeout"
+	case enumspb.TIMEOUT_TYPE_START_TO_CLOSE:
+		return "start-to-close timeout"
+	default:
+		return "heartbeat timeout"
+	}
+}
+
+// TimeoutHeartbeat is returned as the error of a mocked activity to simulate a heartbeat timeout of the activity attempt.
+// The attempt fails with a heartbeat TimeoutError once the activity's HeartbeatTimeout has elapsed on the workflow clock,
+// and is retried according to the activity's RetryPolicy. The details, if any, are recorded as the last heartbeat details,
+// so they are available to the next attempt and to the TimeoutError.
+// Example:
+//   env.OnActivity(MyActivity, mock.Anything).Return(TimeoutHeartbeat("progress")).Once()
+// This function is also exposed as public as testsuite.TimeoutHeartbeat
+func TimeoutHeartbeat(details ...interface{}) error {
+	return &mockActivityTimeoutError{timeoutType: enumspb.TIMEOUT_TYPE_HEARTBEAT, details: details}
+}
+
+// TimeoutStartToClose is returned as the error of a mocked activity to simulate a start-to-close timeout of the activity
+// attempt, after the activity's StartToCloseTimeout has elapsed on the workflow clock. The attempt is retried according
+// to the activity's RetryPolicy.
+// This function is also exposed as public as testsuite.TimeoutStartToClose
+func TimeoutStartToClose() error {
+	return &mockActivityTimeoutError{timeoutType: enumspb.TIMEOUT_TYPE_START_TO_CLOSE}
+}
+
+// TimeoutScheduleToStart is returned as the error of a mocked activity to simulate a schedule-to-start timeout, after
+// the activity's ScheduleToStartTimeout has elapsed on the workflow clock. Schedule-to-start timeouts are not retried.
+// This function is also exposed as public as testsuite.TimeoutScheduleToStart
+func TimeoutScheduleToStart() error {
+	return &mockActivityTimeoutError{timeoutType: enumspb.TIMEOUT_TYPE_SCHEDULE_TO_START}
+}
+
 // OnWorkflow setup a mock call for workflow. Parameter workflow must be workflow function (func) or workflow name (string).
 // You must call Return() with appropriate parameters on the returned *MockCallWrapper instance. The supplied parameters to
 // the Return() call should either be a function that has exact same signature as the mocked workflow, or it should be
@@ -502,11 +613,35 @@ func (e *TestWorkflowEnvironment) ExecuteWorkflow(workflowFn interface{}, args .
 	e.impl.executeWorkflow(workflowFn, args...)
 }
 
+// ExecuteWorkflowByName executes the workflow registered with the name, or the dynamic workflow, with input arguments
+// which are already encoded, like ExecuteWorkflow. Use it to test DSL and dynamic workflows, or workflows started by
+// clients in other languages with fixtures of their payloads. The payloads are decoded with the data converter set
+// with SetDataConverter.
+func (e *TestWorkflowEnvironment) ExecuteWorkflowByName(workflowType string, input *commonpb.Payloads) {
+	e.impl.mock = &e.mock
+	e.impl.executeWorkflowInternal(0, workflowType, input)
+}
+
 // Now returns the current workflow time (a.k.a workflow.Now() time) of this TestWorkflowEnvironment.
 func (e *TestWorkflowEnvironment) Now() time.Time {
 	return e.impl.Now()
 }
 
+// BeginRealTime opens a real time window, ended by calling the returned function. While a window is open the mock
+// clock isn't skipped to the next timer when the workflow is blocked: timers fire once their duration elapsed in wall
+// time, like while activities are running. Open a window when the test does real asynchronous work the workflow
+// waits for, like a goroutine sending a signal with SignalWorkflow or completing an activity with CompleteActivity,
+// so that the timers of the workflow don't fire before the work is done. SetTestTimeout bounds the wall clock wait.
+//  end := env.BeginRealTime()
+//  go func() {
+//      defer end()
+//      env.SignalWorkflow("approval", callExternalService())
+//  }()
+//  env.ExecuteWorkflow(ApprovalWorkflow)
+func (e *TestWorkflowEnvironment) BeginRealTime() (end func()) {
+	return e.impl.beginRealTime()
+}
+
 // SetWorkerOptions sets the WorkerOptions that will be use by TestActivityEnvironment. TestActivityEnvironment will
 // use options of BackgroundActivityContext, MaxConcurrentSessionExecutionSize, and WorkflowInterceptorChainFactories on the WorkerOptions.
 // Other options are ignored.
@@ -568,6 +703,15 @@ func (e *TestWorkflowEnvironment) SetTestTimeout(idleTimeout time.Duration) *Tes
 	return e
 }
 
+// SetSessionFailureAfter makes every session created by the tested workflow fail after the given duration of
+// workflow time, as if the session worker was lost. Activities executing in the session are canceled and new ones
+// fail with ErrSessionFailed, so the workflow can be tested to recreate the session. Zero, the default, means sessions
+// never fail. Sessions are only available when WorkerOptions.EnableSessionWorker is set, see SetWorkerOptions.
+func (e *TestWorkflowEnvironment) SetSessionFailureAfter(d time.Duration) *TestWorkflowEnvironment {
+	e.impl.sessionFailureAfter = d
+	return e
+}
+
 // SetWorkflowRunTimeout sets the run timeout for this tested workflow. This test framework uses mock clock internally
 // and when workflow is blocked on timer, it will auto forward the mock clock. Use SetWorkflowRunTimeout() to enforce a
 // workflow run timeout to return timeout error when the workflow mock clock is moved head of the timeout.
@@ -756,6 +900,24 @@ func (e *TestWorkflowEnvironment) QueryWorkflowByID(workflowID, queryType string
 	return e.impl.queryWorkflowByID(workflowID, queryType, args...)
 }
 
+// QueryWorkflowByTime issues a query to the test workflow once delayDuration has elapsed on the workflow clock, and
+// returns a TestQueryResult holding its result after ExecuteWorkflow returns. Use it to assert the state exposed by
+// query handlers at intermediate points of the workflow execution. As with RegisterDelayedCallback, a 0 delayDuration
+// does not work, as the workflow will not have had a chance to register its query handlers.
+// Example:
+//   status := env.QueryWorkflowByTime(time.Hour, "status")
+//   env.ExecuteWorkflow(MyWorkflow)
+//   var s string
+//   err := status.Get(&s)
+func (e *TestWorkflowEnvironment) QueryWorkflowByTime(delayDuration time.Duration, queryType string, args ...interface{}) *TestQueryResult {
+	result := &TestQueryResult{}
+	e.impl.registerDelayedCallback(func() {
+		result.value, result.err = e.impl.queryWorkflow(queryType, args...)
+		result.issued = true
+	}, delayDuration)
+	return result
+}
+
 // RegisterDelayedCallback creates a new timer with specified delayDuration using workflow clock (not wall clock). When
 // the timer fires, the callback will be called. By default, this test suite uses mock clock which automatically move
 // forward to fire next timer when workflow is blocked. Use this API to make some event (like activity completion,
@@ -809,3 +971,19 @@ func (e *TestWorkflowEnvironment) SetSearchAttributesOnStart(searchAttributes ma
 func (e *TestWorkflowEnvironment) AssertExpectations(t mock.TestingT) bool {
 	return e.mock.AssertExpectations(t)
 }
+
+// Issued returns whether the query was issued, which is not the case if the workflow completed before the query time.
+func (r *TestQueryResult) Issued() bool {
+	return r.issued
+}
+
+// Get extracts the query result into valuePtr, or returns the error the query failed with.
+func (r *TestQueryResult) Get(valuePtr interface{}) error {
+	if !r.issued {
+		return errQueryNotIssued
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return r.value.Get(valuePtr)
+}
@@ -0,0 +1,115 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package log
+
+import "fmt"
+
+// Level is the severity of a log entry passed to the function of NewFuncLogger.
+type Level int
+
+const (
+	// LevelDebug is the level of Logger.Debug entries.
+	LevelDebug Level = iota
+	// LevelInfo is the level of Logger.Info entries.
+	LevelInfo
+	// LevelWarn is the level of Logger.Warn entries.
+	LevelWarn
+	// LevelError is the level of Logger.Error entries.
+	LevelError
+)
+
+// LogFunc writes a single log entry with alternating keys and values.
+type LogFunc func(level Level, msg string, keyvals ...interface{})
+
+// String returns the name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+// NewFuncLogger returns Logger that writes every log entry with logFunc. It allows to use structured loggers
+// which don't match Logger interface, for example logr:
+//  logger := log.NewFuncLogger(func(level log.Level, msg string, keyvals ...interface{}) {
+//  	switch level {
+//  	case log.LevelError:
+//  		logrLogger.Error(nil, msg, keyvals...)
+//  	case log.LevelDebug:
+//  		logrLogger.V(1).Info(msg, keyvals...)
+//  	default:
+//  		logrLogger.Info(msg, keyvals...)
+//  	}
+//  })
+func NewFuncLogger(logFunc LogFunc) Logger {
+	return &funcLogger{logFunc: logFunc}
+}
+
+type funcLogger struct {
+	logFunc LogFunc
+	keyvals []interface{}
+}
+
+// Debug writes message to the log.
+func (l *funcLogger) Debug(msg string, keyvals ...interface{}) {
+	l.logFunc(LevelDebug, msg, l.appendKeyvals(keyvals)...)
+}
+
+// Info writes message to the log.
+func (l *funcLogger) Info(msg string, keyvals ...interface{}) {
+	l.logFunc(LevelInfo, msg, l.appendKeyvals(keyvals)...)
+}
+
+// Warn writes message to the log.
+func (l *funcLogger) Warn(msg string, keyvals ...interface{}) {
+	l.logFunc(LevelWarn, msg, l.appendKeyvals(keyvals)...)
+}
+
+// Error writes message to the log.
+func (l *funcLogger) Error(msg string, keyvals ...interface{}) {
+	l.logFunc(LevelError, msg, l.appendKeyvals(keyvals)...)
+}
+
+// With returns Logger that prepends every log entry with keyvals.
+func (l *funcLogger) With(keyvals ...interface{}) Logger {
+	return &funcLogger{logFunc: l.logFunc, keyvals: l.appendKeyvals(keyvals)}
+}
+
+func (l *funcLogger) appendKeyvals(keyvals []interface{}) []interface{} {
+	if len(l.keyvals) == 0 {
+		return keyvals
+	}
+	result := make([]interface{}, 0, len(l.keyvals)+len(keyvals))
+	result = append(result, l.keyvals...)
+	return append(result, keyvals...)
+}
@@ -0,0 +1,60 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logEntry struct {
+	level   Level
+	msg     string
+	keyvals []interface{}
+}
+
+func TestFuncLogger(t *testing.T) {
+	var entries []logEntry
+	logger := NewFuncLogger(func(level Level, msg string, keyvals ...interface{}) {
+		entries = append(entries, logEntry{level: level, msg: msg, keyvals: keyvals})
+	})
+
+	logger.Debug("debug", "k1", 1)
+	logger.Info("info")
+	withLogger := With(logger, "p1", "v1")
+	withLogger.Warn("warn", "k2", 2)
+	With(withLogger, "p2", "v2").Error("error")
+	logger.Info("info again")
+
+	assert.Equal(t, []logEntry{
+		{level: LevelDebug, msg: "debug", keyvals: []interface{}{"k1", 1}},
+		{level: LevelInfo, msg: "info"},
+		{level: LevelWarn, msg: "warn", keyvals: []interface{}{"p1", "v1", "k2", 2}},
+		{level: LevelError, msg: "error", keyvals: []interface{}{"p1", "v1", "p2", "v2"}},
+		{level: LevelInfo, msg: "info again"},
+	}, entries)
+	assert.Equal(t, "WARN", LevelWarn.String())
+}
@@ -26,6 +26,9 @@ package log
 
 type (
 	// Logger is an interface that can be passed to ClientOptions.Logger.
+	// Every log call has a message and a list of alternating keys and values, the same way as log/slog does,
+	// so *slog.Logger can be used as Logger directly. Other structured loggers, such as logr or zerolog,
+	// can be adapted with NewFuncLogger.
 	Logger interface {
 		Debug(msg string, keyvals ...interface{})
 		Info(msg string, keyvals ...interface{})
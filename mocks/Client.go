@@ -86,6 +86,22 @@ func (_m *Client) CompleteActivityByID(ctx context.Context, namespace string, wo
 	return r0
 }
 
+// CompleteActivities provides a mock function with given fields: ctx, completions
+func (_m *Client) CompleteActivities(ctx context.Context, completions []client.ActivityCompletion) []error {
+	ret := _m.Called(ctx, completions)
+
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []client.ActivityCompletion) []error); ok {
+		r0 = rf(ctx, completions)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	return r0
+}
+
 // CountWorkflow provides a mock function with given fields: ctx, request
 func (_m *Client) CountWorkflow(ctx context.Context, request *workflowservice.CountWorkflowExecutionsRequest) (*workflowservice.CountWorkflowExecutionsResponse, error) {
 	ret := _m.Called(ctx, request)
@@ -155,6 +171,52 @@ func (_m *Client) DescribeWorkflowExecution(ctx context.Context, workflowID stri
 	return r0, r1
 }
 
+// DescribeWorkflow provides a mock function with given fields: ctx, workflowID, runID
+func (_m *Client) DescribeWorkflow(ctx context.Context, workflowID string, runID string) (*client.WorkflowExecutionDescription, error) {
+	ret := _m.Called(ctx, workflowID, runID)
+
+	var r0 *client.WorkflowExecutionDescription
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *client.WorkflowExecutionDescription); ok {
+		r0 = rf(ctx, workflowID, runID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.WorkflowExecutionDescription)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, workflowID, runID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWorkflowStackTrace provides a mock function with given fields: ctx, workflowID, runID
+func (_m *Client) GetWorkflowStackTrace(ctx context.Context, workflowID string, runID string) (*client.WorkflowStackTrace, error) {
+	ret := _m.Called(ctx, workflowID, runID)
+
+	var r0 *client.WorkflowStackTrace
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *client.WorkflowStackTrace); ok {
+		r0 = rf(ctx, workflowID, runID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*client.WorkflowStackTrace)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, workflowID, runID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ExecuteWorkflow provides a mock function with given fields: ctx, options, workflow, args
 func (_m *Client) ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error) {
 	var _ca []interface{}
@@ -30,6 +30,7 @@ import (
 
 	"github.com/stretchr/testify/mock"
 
+	namespacepb "go.temporal.io/api/namespace/v1"
 	"go.temporal.io/api/workflowservice/v1"
 )
 
@@ -89,6 +90,57 @@ func (_m *NamespaceClient) Update(ctx context.Context, request *workflowservice.
 	return r0
 }
 
+// MarkBadBinary provides a mock function with given fields: ctx, namespace, binaryChecksum, reason
+func (_m *NamespaceClient) MarkBadBinary(ctx context.Context, namespace string, binaryChecksum string, reason string) error {
+	ret := _m.Called(ctx, namespace, binaryChecksum, reason)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, namespace, binaryChecksum, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteBadBinary provides a mock function with given fields: ctx, namespace, binaryChecksum
+func (_m *NamespaceClient) DeleteBadBinary(ctx context.Context, namespace string, binaryChecksum string) error {
+	ret := _m.Called(ctx, namespace, binaryChecksum)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, namespace, binaryChecksum)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetBadBinaries provides a mock function with given fields: ctx, namespace
+func (_m *NamespaceClient) GetBadBinaries(ctx context.Context, namespace string) (map[string]*namespacepb.BadBinaryInfo, error) {
+	ret := _m.Called(ctx, namespace)
+
+	var r0 map[string]*namespacepb.BadBinaryInfo
+	if rf, ok := ret.Get(0).(func(context.Context, string) map[string]*namespacepb.BadBinaryInfo); ok {
+		r0 = rf(ctx, namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]*namespacepb.BadBinaryInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Close provides a mock function without given fields
 func (_m *NamespaceClient) Close() {
 	ret := _m.Called()
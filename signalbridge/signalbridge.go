@@ -0,0 +1,278 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package signalbridge routes messages consumed from a message broker, such as Kafka, to workflows as signals or
+// SignalWithStart calls, so event-driven workflows don't need a bespoke bridge service.
+//
+// The broker is abstracted by the Consumer interface, which a thin adapter implements on top of the Kafka client of
+// choice, and a Mapper function decides which workflow each message is delivered to. For example:
+//  b, err := signalbridge.New(signalbridge.Options{
+//  	Client:   c,
+//  	Consumer: kafkaConsumer,
+//  	Mapper: func(m signalbridge.Message) (*signalbridge.Route, error) {
+//  		var order Order
+//  		if err := json.Unmarshal(m.Value, &order); err != nil {
+//  			return nil, err
+//  		}
+//  		return &signalbridge.Route{
+//  			WorkflowID:   "order-" + order.ID,
+//  			SignalName:   "order-event",
+//  			SignalArg:    order,
+//  			StartOptions: &client.StartWorkflowOptions{TaskQueue: "orders"},
+//  			Workflow:     "OrderWorkflow",
+//  		}, nil
+//  	},
+//  	DeadLetterQueue: dlq,
+//  })
+//  if err != nil {
+//  	return err
+//  }
+//  err = b.Run(ctx)
+//
+// Messages are delivered at least once: a message is committed after its signal is accepted by the server or after
+// it is published to the dead letter queue.
+package signalbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/log"
+)
+
+const (
+	defaultMaxAttempts        = 5
+	defaultInitialInterval    = time.Second
+	defaultMaxInterval        = time.Minute
+	retryBackoffCoefficient   = 2
+	defaultRetryJitterPercent = 0.2
+)
+
+type (
+	// Message is a message consumed from the broker.
+	Message struct {
+		Topic     string
+		Partition int32
+		Offset    int64
+		Key       []byte
+		Value     []byte
+		Headers   map[string][]byte
+	}
+
+	// Consumer consumes the messages of the broker, e.g. a Kafka consumer group.
+	Consumer interface {
+		// Fetch blocks until the next message is available or the ctx is done.
+		Fetch(ctx context.Context) (Message, error)
+		// Commit marks the message and the ones before it in its partition as processed.
+		Commit(ctx context.Context, message Message) error
+	}
+
+	// DeadLetterQueue receives the messages which couldn't be routed to a workflow.
+	DeadLetterQueue interface {
+		// Publish stores the message with the error that prevented its delivery.
+		Publish(ctx context.Context, message Message, cause error) error
+	}
+
+	// Route describes the signal a message is delivered as.
+	Route struct {
+		// WorkflowID is the ID of the workflow to signal.
+		WorkflowID string
+		// RunID is the run of the workflow to signal. Ignored with StartOptions.
+		RunID string
+		// SignalName is the name of the signal.
+		SignalName string
+		// SignalArg is the argument of the signal.
+		SignalArg interface{}
+
+		// StartOptions makes the bridge use SignalWithStartWorkflow, starting Workflow with WorkflowArgs if it is not
+		// running. The ID of the options is set to WorkflowID.
+		StartOptions *client.StartWorkflowOptions
+		// Workflow is the workflow function or type name started with StartOptions.
+		Workflow interface{}
+		// WorkflowArgs are the arguments of the workflow started with StartOptions.
+		WorkflowArgs []interface{}
+	}
+
+	// Mapper returns the route of a message, or nil to skip it. An error sends the message to the dead letter queue.
+	Mapper func(message Message) (*Route, error)
+
+	// Options configure a Bridge.
+	Options struct {
+		// Required: The client used to signal the workflows.
+		Client client.Client
+
+		// Required: The consumer of the messages.
+		Consumer Consumer
+
+		// Required: The function routing the messages to workflows.
+		Mapper Mapper
+
+		// Optional: The queue of the messages which couldn't be delivered. Without it, Run returns the error of the
+		// first message which couldn't be delivered, without committing it.
+		// default: nil
+		DeadLetterQueue DeadLetterQueue
+
+		// Optional: The number of attempts to deliver a message before sending it to the dead letter queue. Errors
+		// which can't succeed on retry, like an invalid argument, are not retried.
+		// default: 5
+		MaxAttempts int
+
+		// Optional: The delay before the first retry, doubled for each retry up to MaxInterval.
+		// default: 1s
+		InitialInterval time.Duration
+
+		// Optional: The maximum delay between retries.
+		// default: 1m
+		MaxInterval time.Duration
+
+		// Optional: Randomizes the delays between retries.
+		// default: client.NewJitterBackoff(0.2)
+		BackoffStrategy client.BackoffStrategy
+
+		// Optional: Logger of the messages sent to the dead letter queue.
+		// default: no logs
+		Logger log.Logger
+	}
+
+	// Bridge routes the messages of a Consumer to workflows.
+	Bridge struct {
+		options Options
+	}
+)
+
+// New creates a Bridge with the options.
+func New(options Options) (*Bridge, error) {
+	if options.Client == nil || options.Consumer == nil || options.Mapper == nil {
+		return nil, errors.New("client, consumer and mapper are required")
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = defaultMaxAttempts
+	}
+	if options.InitialInterval <= 0 {
+		options.InitialInterval = defaultInitialInterval
+	}
+	if options.MaxInterval <= 0 {
+		options.MaxInterval = defaultMaxInterval
+	}
+	if options.BackoffStrategy == nil {
+		options.BackoffStrategy = client.NewJitterBackoff(defaultRetryJitterPercent)
+	}
+	return &Bridge{options: options}, nil
+}
+
+// Run consumes and routes the messages until the ctx is done, in which case it returns nil, or the consumer, the
+// commit or the dead letter queue fail.
+func (b *Bridge) Run(ctx context.Context) error {
+	for {
+		message, err := b.options.Consumer.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("unable to fetch message: %w", err)
+		}
+		if err := b.process(ctx, message); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := b.options.Consumer.Commit(ctx, message); err != nil {
+			return fmt.Errorf("unable to commit message %s/%d/%d: %w", message.Topic, message.Partition, message.Offset, err)
+		}
+	}
+}
+
+func (b *Bridge) process(ctx context.Context, message Message) error {
+	route, err := b.options.Mapper(message)
+	if err == nil && route != nil {
+		err = b.deliver(ctx, route)
+	}
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	if b.options.DeadLetterQueue == nil {
+		return fmt.Errorf("unable to deliver message %s/%d/%d: %w", message.Topic, message.Partition, message.Offset, err)
+	}
+	if b.options.Logger != nil {
+		b.options.Logger.Warn("Sending message to dead letter queue.",
+			"Topic", message.Topic, "Partition", message.Partition, "Offset", message.Offset, "Error", err)
+	}
+	if err := b.options.DeadLetterQueue.Publish(ctx, message, err); err != nil {
+		return fmt.Errorf("unable to publish message %s/%d/%d to dead letter queue: %w",
+			message.Topic, message.Partition, message.Offset, err)
+	}
+	return nil
+}
+
+func (b *Bridge) deliver(ctx context.Context, route *Route) error {
+	if route.WorkflowID == "" || route.SignalName == "" {
+		return errors.New("route requires a workflow ID and a signal name")
+	}
+	interval := b.options.InitialInterval
+	for attempt := 1; ; attempt++ {
+		err := b.signal(ctx, route)
+		if err == nil || attempt >= b.options.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(b.options.BackoffStrategy.NextDelay(attempt, interval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		interval *= retryBackoffCoefficient
+		if interval > b.options.MaxInterval {
+			interval = b.options.MaxInterval
+		}
+	}
+}
+
+func (b *Bridge) signal(ctx context.Context, route *Route) error {
+	if route.StartOptions == nil {
+		return b.options.Client.SignalWorkflow(ctx, route.WorkflowID, route.RunID, route.SignalName, route.SignalArg)
+	}
+	options := *route.StartOptions
+	options.ID = route.WorkflowID
+	_, err := b.options.Client.SignalWithStartWorkflow(ctx, route.WorkflowID, route.SignalName, route.SignalArg,
+		options, route.Workflow, route.WorkflowArgs...)
+	return err
+}
+
+// isRetryable returns false for the errors which fail again on retry.
+func isRetryable(err error) bool {
+	switch err.(type) {
+	case *serviceerror.InvalidArgument, *serviceerror.NotFound, *serviceerror.PermissionDenied:
+		return false
+	}
+	return true
+}
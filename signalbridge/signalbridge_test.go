@@ -0,0 +1,149 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package signalbridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/serviceerror"
+
+	"go.temporal.io/sdk/client"
+)
+
+type testConsumer struct {
+	messages  []Message
+	committed []int64
+	cancel    context.CancelFunc
+}
+
+func (c *testConsumer) Fetch(ctx context.Context) (Message, error) {
+	if len(c.messages) == 0 {
+		c.cancel()
+		<-ctx.Done()
+		return Message{}, ctx.Err()
+	}
+	message := c.messages[0]
+	c.messages = c.messages[1:]
+	return message, nil
+}
+
+func (c *testConsumer) Commit(_ context.Context, message Message) error {
+	c.committed = append(c.committed, message.Offset)
+	return nil
+}
+
+type testClient struct {
+	client.Client
+	errs    []error
+	signals []string
+	started []string
+}
+
+func (c *testClient) SignalWorkflow(_ context.Context, workflowID string, _ string, signalName string, _ interface{}) error {
+	if workflowID == "completed" {
+		return serviceerror.NewNotFound("workflow execution already completed")
+	}
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return err
+	}
+	c.signals = append(c.signals, workflowID+"/"+signalName)
+	return nil
+}
+
+func (c *testClient) SignalWithStartWorkflow(_ context.Context, workflowID string, signalName string, _ interface{},
+	options client.StartWorkflowOptions, _ interface{}, _ ...interface{}) (client.WorkflowRun, error) {
+	c.started = append(c.started, options.ID+"/"+signalName)
+	return nil, nil
+}
+
+type testDeadLetterQueue struct {
+	offsets []int64
+}
+
+func (q *testDeadLetterQueue) Publish(_ context.Context, message Message, _ error) error {
+	q.offsets = append(q.offsets, message.Offset)
+	return nil
+}
+
+func TestBridge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	consumer := &testConsumer{cancel: cancel, messages: []Message{
+		{Offset: 1, Key: []byte("signal")},
+		{Offset: 2, Key: []byte("bad")},
+		{Offset: 3, Key: []byte("start")},
+		{Offset: 4, Key: []byte("skip")},
+		{Offset: 5, Key: []byte("completed")},
+	}}
+	c := &testClient{errs: []error{serviceerror.NewUnavailable("unavailable")}}
+	dlq := &testDeadLetterQueue{}
+	b, err := New(Options{
+		Client:   c,
+		Consumer: consumer,
+		Mapper: func(message Message) (*Route, error) {
+			switch string(message.Key) {
+			case "bad":
+				return nil, errors.New("unable to parse message")
+			case "skip":
+				return nil, nil
+			case "completed":
+				return &Route{WorkflowID: "completed", SignalName: "signal"}, nil
+			case "start":
+				return &Route{WorkflowID: "wf", SignalName: "start", StartOptions: &client.StartWorkflowOptions{}}, nil
+			}
+			return &Route{WorkflowID: "wf", SignalName: "signal"}, nil
+		},
+		DeadLetterQueue: dlq,
+		InitialInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, b.Run(ctx))
+	// The first message is retried after the unavailable error, the last one fails with a non retryable error.
+	require.Equal(t, []string{"wf/signal"}, c.signals)
+	require.Equal(t, []string{"wf/start"}, c.started)
+	require.Equal(t, []int64{2, 5}, dlq.offsets)
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, consumer.committed)
+}
+
+func TestBridge_NoDeadLetterQueue(t *testing.T) {
+	consumer := &testConsumer{messages: []Message{{Offset: 1}}}
+	b, err := New(Options{
+		Client:   &testClient{},
+		Consumer: consumer,
+		Mapper: func(message Message) (*Route, error) {
+			return nil, errors.New("unable to parse message")
+		},
+	})
+	require.NoError(t, err)
+	require.Error(t, b.Run(context.Background()))
+	require.Empty(t, consumer.committed)
+}
@@ -0,0 +1,69 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Command stubgen generates typed workflow and activity stubs for the annotated interfaces of a Go source file.
+// See the stubgen package for the supported annotations.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"go.temporal.io/sdk/stubgen"
+)
+
+func main() {
+	filename := flag.String("file", os.Getenv("GOFILE"), "Go source file declaring the annotated interfaces")
+	output := flag.String("out", "", "output file, defaults to <file>_temporal.gen.go")
+	flag.Parse()
+
+	if *filename == "" {
+		fmt.Fprintln(os.Stderr, "stubgen: -file is required")
+		os.Exit(2)
+	}
+	if err := run(*filename, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "stubgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(filename, output string) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	out, err := stubgen.Generate(filename, src)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return fmt.Errorf("no annotated interfaces found in %s", filename)
+	}
+	if output == "" {
+		output = stubgen.OutputFileName(filename)
+	}
+	return ioutil.WriteFile(output, out, 0644)
+}
@@ -0,0 +1,288 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package stubgen generates typed stubs for workflows and activities declared as Go interfaces.
+//
+// An interface annotated with "//temporal:workflows" declares workflows. Every method must accept a workflow.Context
+// as the first parameter and return either an error or a result and an error. An interface annotated with
+// "//temporal:activities" declares activities, which may accept a context.Context as the first parameter and follow
+// the same rules for results.
+//
+// For every annotated interface the generator emits:
+//   - constants holding the workflow and activity type names,
+//   - a Register function that registers an implementation of the interface with a worker,
+//   - for workflows, a typed client that starts the workflows and typed child workflow helpers,
+//   - for activities, typed ExecuteActivity wrappers returning typed futures.
+//
+// Generated identifiers are derived from the method names, so method names must be unique across all annotated
+// interfaces of a package. The generator is usually invoked through go generate:
+//
+//	//go:generate go run go.temporal.io/sdk/stubgen/cmd/stubgen -file=$GOFILE
+package stubgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/imports"
+)
+
+const (
+	workflowsDirective  = "//temporal:workflows"
+	activitiesDirective = "//temporal:activities"
+)
+
+// stubImports are the packages always imported by the generated stubs.
+var stubImports = map[string]bool{
+	`"context"`:                     true,
+	`"go.temporal.io/sdk/activity"`: true,
+	`"go.temporal.io/sdk/client"`:   true,
+	`"go.temporal.io/sdk/worker"`:   true,
+	`"go.temporal.io/sdk/workflow"`: true,
+}
+
+type (
+	// file is the model the stub template is rendered from.
+	file struct {
+		Package    string
+		Imports    []string
+		Workflows  []*iface
+		Activities []*iface
+	}
+
+	// iface is an annotated interface.
+	iface struct {
+		Name    string
+		Methods []*method
+	}
+
+	// method is a workflow or an activity declared by an annotated interface.
+	method struct {
+		Name string
+		// HasContext is true if the first parameter is a context. It is always true for workflows.
+		HasContext bool
+		Params     []param
+		// Result is the type of the result or an empty string if the method returns only an error.
+		Result string
+	}
+
+	param struct {
+		Name string
+		Type string
+	}
+)
+
+// OutputFileName returns the name of the file the stubs for the given source file are written to.
+func OutputFileName(filename string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + "_temporal.gen.go"
+}
+
+// Generate parses the given Go source and returns the generated stubs for all annotated interfaces. It returns nil
+// if the source doesn't contain any annotated interfaces.
+func Generate(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &file{Package: f.Name.Name}
+	for _, imp := range f.Imports {
+		spec := imp.Path.Value
+		if imp.Name != nil {
+			spec = imp.Name.Name + " " + spec
+		} else if stubImports[spec] {
+			continue
+		}
+		model.Imports = append(model.Imports, spec)
+	}
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			interfaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			doc := typeSpec.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+			isWorkflows, isActivities := hasDirective(doc, workflowsDirective), hasDirective(doc, activitiesDirective)
+			if !isWorkflows && !isActivities {
+				continue
+			}
+			if isWorkflows && isActivities {
+				return nil, fmt.Errorf("interface %s cannot declare both workflows and activities", typeSpec.Name.Name)
+			}
+			parsed, err := parseInterface(fset, typeSpec.Name.Name, interfaceType, isWorkflows)
+			if err != nil {
+				return nil, err
+			}
+			if isWorkflows {
+				model.Workflows = append(model.Workflows, parsed)
+			} else {
+				model.Activities = append(model.Activities, parsed)
+			}
+		}
+	}
+	if len(model.Workflows) == 0 && len(model.Activities) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	if err := stubTemplate.Execute(&buf, model); err != nil {
+		return nil, err
+	}
+	// imports.Process drops the imports of the source file that the stubs don't use and formats the result.
+	out, err := imports.Process(OutputFileName(filename), buf.Bytes(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to format generated stubs: %w", err)
+	}
+	return out, nil
+}
+
+func hasDirective(doc *ast.CommentGroup, directive string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if strings.HasPrefix(comment.Text, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseInterface(fset *token.FileSet, name string, interfaceType *ast.InterfaceType, isWorkflows bool) (*iface, error) {
+	result := &iface{Name: name}
+	for _, field := range interfaceType.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			return nil, fmt.Errorf("interface %s: embedded interfaces are not supported", name)
+		}
+		m, err := parseMethod(fset, field.Names[0].Name, funcType, isWorkflows)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s: %w", name, err)
+		}
+		result.Methods = append(result.Methods, m)
+	}
+	return result, nil
+}
+
+func parseMethod(fset *token.FileSet, name string, funcType *ast.FuncType, isWorkflow bool) (*method, error) {
+	m := &method{Name: name}
+
+	var params []*ast.Field
+	for _, field := range funcType.Params.List {
+		// Expand grouped parameters like "a, b string" into one field per parameter.
+		if len(field.Names) <= 1 {
+			params = append(params, field)
+			continue
+		}
+		for _, n := range field.Names {
+			params = append(params, &ast.Field{Names: []*ast.Ident{n}, Type: field.Type})
+		}
+	}
+
+	if len(params) > 0 {
+		if isWorkflow && isContext(params[0].Type, "workflow") || !isWorkflow && isContext(params[0].Type, "context") {
+			m.HasContext = true
+			params = params[1:]
+		}
+	}
+	if isWorkflow && !m.HasContext {
+		return nil, fmt.Errorf("workflow %s must accept workflow.Context as the first parameter", name)
+	}
+
+	for i, field := range params {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return nil, fmt.Errorf("method %s: variadic parameters are not supported", name)
+		}
+		typ, err := exprString(fset, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		paramName := fmt.Sprintf("arg%d", i)
+		if len(field.Names) == 1 && field.Names[0].Name != "_" {
+			paramName = field.Names[0].Name
+		}
+		m.Params = append(m.Params, param{Name: paramName, Type: typ})
+	}
+
+	var results []ast.Expr
+	if funcType.Results != nil {
+		for _, field := range funcType.Results.List {
+			for i := 0; i < len(field.Names) || i == 0; i++ {
+				results = append(results, field.Type)
+			}
+		}
+	}
+	if len(results) < 1 || len(results) > 2 {
+		return nil, fmt.Errorf("method %s must return either error or (result, error)", name)
+	}
+	if ident, ok := results[len(results)-1].(*ast.Ident); !ok || ident.Name != "error" {
+		return nil, fmt.Errorf("method %s: last result must be an error", name)
+	}
+	if len(results) == 2 {
+		typ, err := exprString(fset, results[0])
+		if err != nil {
+			return nil, err
+		}
+		m.Result = typ
+	}
+	return m, nil
+}
+
+// isContext returns true if the expression is a Context type from a package imported with the given name.
+func isContext(expr ast.Expr, pkg string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Context" {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && x.Name == pkg
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) (string, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, expr); err != nil {
+		return "", err
+	}
+	if buf.Len() == 0 {
+		return "", errors.New("empty type expression")
+	}
+	return buf.String(), nil
+}
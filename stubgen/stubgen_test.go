@@ -0,0 +1,120 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stubgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSource = `package sample
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+type Order struct {
+	ID string
+}
+
+// OrderWorkflows are the workflows of the order service.
+//temporal:workflows
+type OrderWorkflows interface {
+	ProcessOrder(ctx workflow.Context, order Order, timeout time.Duration) (string, error)
+	CancelOrder(ctx workflow.Context, id string) error
+}
+
+//temporal:activities
+type OrderActivities interface {
+	ChargeCard(ctx context.Context, order Order) (int, error)
+	Notify(id, message string) error
+}
+
+type notAnnotated interface {
+	Foo() error
+}
+`
+
+func TestGenerate(t *testing.T) {
+	out, err := Generate("sample.go", []byte(testSource))
+	require.NoError(t, err)
+	generated := string(out)
+
+	require.Contains(t, generated, "// Code generated by stubgen. DO NOT EDIT.")
+	require.Contains(t, generated, `"time"`)
+	require.Contains(t, generated, `ProcessOrderWorkflowName = "ProcessOrder"`)
+	require.Contains(t, generated, "func RegisterOrderWorkflows(r worker.WorkflowRegistry, impl OrderWorkflows) {")
+	require.Contains(t, generated, "r.RegisterWorkflowWithOptions(impl.CancelOrder, workflow.RegisterOptions{Name: CancelOrderWorkflowName})")
+	require.Contains(t, generated, "func (c *OrderWorkflowsClient) ExecuteProcessOrder(ctx context.Context, options client.StartWorkflowOptions, order Order, timeout time.Duration) (*ProcessOrderRun, error) {")
+	require.Contains(t, generated, "func (r *ProcessOrderRun) Get(ctx context.Context) (string, error) {")
+	require.Contains(t, generated, "func (r *CancelOrderRun) Get(ctx context.Context) error {")
+	require.Contains(t, generated, "func ExecuteProcessOrderChild(ctx workflow.Context, order Order, timeout time.Duration) ProcessOrderChildFuture {")
+
+	require.Contains(t, generated, `ChargeCardActivityName = "ChargeCard"`)
+	require.Contains(t, generated, "func RegisterOrderActivities(r worker.ActivityRegistry, impl OrderActivities) {")
+	require.Contains(t, generated, "func ExecuteChargeCard(ctx workflow.Context, order Order) ChargeCardFuture {")
+	require.Contains(t, generated, "func (f ChargeCardFuture) Get(ctx workflow.Context) (int, error) {")
+	require.Contains(t, generated, "func ExecuteNotify(ctx workflow.Context, id string, message string) NotifyFuture {")
+	require.Contains(t, generated, "workflow.ExecuteActivity(ctx, NotifyActivityName, id, message)")
+
+	require.NotContains(t, generated, "notAnnotated")
+	require.NotContains(t, generated, "Foo")
+}
+
+func TestGenerate_NoAnnotatedInterfaces(t *testing.T) {
+	out, err := Generate("sample.go", []byte("package sample\n\ntype Foo interface{}\n"))
+	require.NoError(t, err)
+	require.Nil(t, out)
+}
+
+func TestGenerate_InvalidWorkflow(t *testing.T) {
+	_, err := Generate("sample.go", []byte(`package sample
+
+//temporal:workflows
+type Workflows interface {
+	NoContext(id string) error
+}
+`))
+	require.EqualError(t, err, "interface Workflows: workflow NoContext must accept workflow.Context as the first parameter")
+
+	_, err = Generate("sample.go", []byte(`package sample
+
+import "go.temporal.io/sdk/workflow"
+
+//temporal:workflows
+type Workflows interface {
+	NoError(ctx workflow.Context) string
+}
+`))
+	require.EqualError(t, err, "interface Workflows: method NoError: last result must be an error")
+}
+
+func TestOutputFileName(t *testing.T) {
+	require.Equal(t, "dir/orders_temporal.gen.go", OutputFileName("dir/orders.go"))
+}
@@ -0,0 +1,177 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stubgen
+
+import (
+	"strings"
+	"text/template"
+)
+
+var stubTemplate = template.Must(template.New("stubs").Funcs(template.FuncMap{
+	"params": func(params []param) string {
+		var parts []string
+		for _, p := range params {
+			parts = append(parts, p.Name+" "+p.Type)
+		}
+		return strings.Join(parts, ", ")
+	},
+	"args": func(params []param) string {
+		var parts []string
+		for _, p := range params {
+			parts = append(parts, ", "+p.Name)
+		}
+		return strings.Join(parts, "")
+	},
+}).Parse(`// Code generated by stubgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+{{range .Imports}}	{{.}}
+{{end}})
+
+{{range $iface := .Workflows}}
+// Workflow type names of {{$iface.Name}}.
+const (
+{{- range .Methods}}
+	{{.Name}}WorkflowName = "{{.Name}}"
+{{- end}}
+)
+
+// Register{{$iface.Name}} registers all workflows of {{$iface.Name}} implemented by impl.
+func Register{{$iface.Name}}(r worker.WorkflowRegistry, impl {{$iface.Name}}) {
+{{- range .Methods}}
+	r.RegisterWorkflowWithOptions(impl.{{.Name}}, workflow.RegisterOptions{Name: {{.Name}}WorkflowName})
+{{- end}}
+}
+
+// {{$iface.Name}}Client starts the workflows of {{$iface.Name}}.
+type {{$iface.Name}}Client struct {
+	client client.Client
+}
+
+// New{{$iface.Name}}Client creates a typed client for the workflows of {{$iface.Name}}.
+func New{{$iface.Name}}Client(c client.Client) *{{$iface.Name}}Client {
+	return &{{$iface.Name}}Client{client: c}
+}
+{{range .Methods}}
+// Execute{{.Name}} starts the {{.Name}} workflow.
+func (c *{{$iface.Name}}Client) Execute{{.Name}}(ctx context.Context, options client.StartWorkflowOptions{{if .Params}}, {{params .Params}}{{end}}) (*{{.Name}}Run, error) {
+	run, err := c.client.ExecuteWorkflow(ctx, options, {{.Name}}WorkflowName{{args .Params}})
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Name}}Run{WorkflowRun: run}, nil
+}
+
+// Get{{.Name}} returns a handle to a {{.Name}} workflow execution. If runID is empty the latest run is used.
+func (c *{{$iface.Name}}Client) Get{{.Name}}(ctx context.Context, workflowID string, runID string) *{{.Name}}Run {
+	return &{{.Name}}Run{WorkflowRun: c.client.GetWorkflow(ctx, workflowID, runID)}
+}
+
+// {{.Name}}Run is a typed handle to a {{.Name}} workflow execution.
+type {{.Name}}Run struct {
+	client.WorkflowRun
+}
+
+// Get blocks until the workflow completes and returns its result.
+func (r *{{.Name}}Run) Get(ctx context.Context) {{if .Result}}({{.Result}}, error){{else}}error{{end}} {
+{{- if .Result}}
+	var result {{.Result}}
+	err := r.WorkflowRun.Get(ctx, &result)
+	return result, err
+{{- else}}
+	return r.WorkflowRun.Get(ctx, nil)
+{{- end}}
+}
+
+// Execute{{.Name}}Child starts the {{.Name}} workflow as a child of the current workflow.
+func Execute{{.Name}}Child(ctx workflow.Context{{if .Params}}, {{params .Params}}{{end}}) {{.Name}}ChildFuture {
+	return {{.Name}}ChildFuture{ChildWorkflowFuture: workflow.ExecuteChildWorkflow(ctx, {{.Name}}WorkflowName{{args .Params}})}
+}
+
+// {{.Name}}ChildFuture is a typed future for a {{.Name}} child workflow.
+type {{.Name}}ChildFuture struct {
+	workflow.ChildWorkflowFuture
+}
+
+// Get blocks until the child workflow completes and returns its result.
+func (f {{.Name}}ChildFuture) Get(ctx workflow.Context) {{if .Result}}({{.Result}}, error){{else}}error{{end}} {
+{{- if .Result}}
+	var result {{.Result}}
+	err := f.ChildWorkflowFuture.Get(ctx, &result)
+	return result, err
+{{- else}}
+	return f.ChildWorkflowFuture.Get(ctx, nil)
+{{- end}}
+}
+{{end}}{{end}}
+{{- range $iface := .Activities}}
+// Activity type names of {{$iface.Name}}.
+const (
+{{- range .Methods}}
+	{{.Name}}ActivityName = "{{.Name}}"
+{{- end}}
+)
+
+// Register{{$iface.Name}} registers all activities of {{$iface.Name}} implemented by impl.
+func Register{{$iface.Name}}(r worker.ActivityRegistry, impl {{$iface.Name}}) {
+{{- range .Methods}}
+	r.RegisterActivityWithOptions(impl.{{.Name}}, activity.RegisterOptions{Name: {{.Name}}ActivityName})
+{{- end}}
+}
+{{range .Methods}}
+// Execute{{.Name}} schedules the {{.Name}} activity with the activity options of ctx.
+func Execute{{.Name}}(ctx workflow.Context{{if .Params}}, {{params .Params}}{{end}}) {{.Name}}Future {
+	return {{.Name}}Future{Future: workflow.ExecuteActivity(ctx, {{.Name}}ActivityName{{args .Params}})}
+}
+
+// Execute{{.Name}}Local executes the {{.Name}} activity as a local activity with the local activity options of ctx.
+func Execute{{.Name}}Local(ctx workflow.Context{{if .Params}}, {{params .Params}}{{end}}) {{.Name}}Future {
+	return {{.Name}}Future{Future: workflow.ExecuteLocalActivity(ctx, {{.Name}}ActivityName{{args .Params}})}
+}
+
+// {{.Name}}Future is a typed future for the result of the {{.Name}} activity.
+type {{.Name}}Future struct {
+	workflow.Future
+}
+
+// Get blocks until the activity completes and returns its result.
+func (f {{.Name}}Future) Get(ctx workflow.Context) {{if .Result}}({{.Result}}, error){{else}}error{{end}} {
+{{- if .Result}}
+	var result {{.Result}}
+	err := f.Future.Get(ctx, &result)
+	return result, err
+{{- else}}
+	return f.Future.Get(ctx, nil)
+{{- end}}
+}
+{{end}}{{end}}`))
@@ -143,6 +143,14 @@ type (
 
 	// UnknownExternalWorkflowExecutionError can be returned when external workflow doesn't exist
 	UnknownExternalWorkflowExecutionError = internal.UnknownExternalWorkflowExecutionError
+
+	// ChildWorkflowExecutionAlreadyStartedError is the cause of the *ChildWorkflowExecutionError returned when a child
+	// workflow fails to start because its workflow ID is already used.
+	ChildWorkflowExecutionAlreadyStartedError = internal.ChildWorkflowExecutionAlreadyStartedError
+
+	// WorkflowExecutionAlreadyStartedError is returned by Client.ExecuteWorkflow when the workflow ID is already used,
+	// with the run ID and start time of the existing run.
+	WorkflowExecutionAlreadyStartedError = internal.WorkflowExecutionAlreadyStartedError
 )
 
 var (
@@ -184,10 +192,18 @@ func IsApplicationError(err error) bool {
 	return errors.As(err, &applicationError)
 }
 
-// IsWorkflowExecutionAlreadyStartedError return if the err is a WorkflowExecutionAlreadyStartedError
+// IsWorkflowExecutionAlreadyStartedError return if the err is a WorkflowExecutionAlreadyStartedError, or the error of a
+// child workflow which failed to start because its workflow ID is already used.
 func IsWorkflowExecutionAlreadyStartedError(err error) bool {
-	_, ok := err.(*serviceerror.WorkflowExecutionAlreadyStarted)
-	return ok
+	if _, ok := err.(*serviceerror.WorkflowExecutionAlreadyStarted); ok {
+		return true
+	}
+	var alreadyStartedErr *WorkflowExecutionAlreadyStartedError
+	if errors.As(err, &alreadyStartedErr) {
+		return true
+	}
+	var childErr *ChildWorkflowExecutionAlreadyStartedError
+	return errors.As(err, &childErr)
 }
 
 // IsCanceledError return if the err is a CanceledError
@@ -0,0 +1,85 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package temporal
+
+import "go.temporal.io/sdk/internal"
+
+type (
+	// SearchAttributeKeyString is the key of a search attribute of type Text, searched with full text search.
+	SearchAttributeKeyString = internal.SearchAttributeKeyString
+
+	// SearchAttributeKeyKeyword is the key of a search attribute of type Keyword, searched by exact value.
+	SearchAttributeKeyKeyword = internal.SearchAttributeKeyKeyword
+
+	// SearchAttributeKeyInt64 is the key of a search attribute of type Int.
+	SearchAttributeKeyInt64 = internal.SearchAttributeKeyInt64
+
+	// SearchAttributeKeyFloat64 is the key of a search attribute of type Double.
+	SearchAttributeKeyFloat64 = internal.SearchAttributeKeyFloat64
+
+	// SearchAttributeKeyBool is the key of a search attribute of type Bool.
+	SearchAttributeKeyBool = internal.SearchAttributeKeyBool
+
+	// SearchAttributeKeyTime is the key of a search attribute of type Datetime.
+	SearchAttributeKeyTime = internal.SearchAttributeKeyTime
+
+	// SearchAttributeUpdate is a value of a search attribute to upsert with workflow.UpsertTypedSearchAttributes,
+	// created with the ValueSet method of the key of the search attribute.
+	SearchAttributeUpdate = internal.SearchAttributeUpdate
+
+	// SearchAttributes are the search attributes of a workflow returned by workflow.GetTypedSearchAttributes, read
+	// with the keys of the search attributes.
+	SearchAttributes = internal.SearchAttributes
+)
+
+// NewSearchAttributeKeyString creates the key of a search attribute of type Text.
+func NewSearchAttributeKeyString(name string) SearchAttributeKeyString {
+	return internal.NewSearchAttributeKeyString(name)
+}
+
+// NewSearchAttributeKeyKeyword creates the key of a search attribute of type Keyword.
+func NewSearchAttributeKeyKeyword(name string) SearchAttributeKeyKeyword {
+	return internal.NewSearchAttributeKeyKeyword(name)
+}
+
+// NewSearchAttributeKeyInt64 creates the key of a search attribute of type Int.
+func NewSearchAttributeKeyInt64(name string) SearchAttributeKeyInt64 {
+	return internal.NewSearchAttributeKeyInt64(name)
+}
+
+// NewSearchAttributeKeyFloat64 creates the key of a search attribute of type Double.
+func NewSearchAttributeKeyFloat64(name string) SearchAttributeKeyFloat64 {
+	return internal.NewSearchAttributeKeyFloat64(name)
+}
+
+// NewSearchAttributeKeyBool creates the key of a search attribute of type Bool.
+func NewSearchAttributeKeyBool(name string) SearchAttributeKeyBool {
+	return internal.NewSearchAttributeKeyBool(name)
+}
+
+// NewSearchAttributeKeyTime creates the key of a search attribute of type Datetime.
+func NewSearchAttributeKeyTime(name string) SearchAttributeKeyTime {
+	return internal.NewSearchAttributeKeyTime(name)
+}
@@ -41,7 +41,55 @@ type (
 
 	// MockCallWrapper is a wrapper to mock.Call. It offers the ability to wait on workflow's clock instead of wall clock.
 	MockCallWrapper = internal.MockCallWrapper
+
+	// TestQueryResult is the result of a query registered with TestWorkflowEnvironment.QueryWorkflowByTime.
+	TestQueryResult = internal.TestQueryResult
+
+	// IntegrationEnv attaches end-to-end tests to a running Temporal server. It registers the test namespace, waits
+	// until the server accepts requests on it, and provides a client and workers which are stopped by Close.
+	IntegrationEnv = internal.IntegrationEnv
+
+	// IntegrationEnvOptions configure NewIntegrationEnv.
+	IntegrationEnvOptions = internal.IntegrationEnvOptions
 )
 
+// NewIntegrationEnv connects to the Temporal server, registers the namespace if it doesn't exist yet, and waits until
+// the server serves requests on it. The server address defaults to the SERVICE_ADDR environment variable, or to
+// localhost:7233, and a temporary namespace is registered unless one is set in the client options.
+// Example:
+//   env, err := testsuite.NewIntegrationEnv(testsuite.IntegrationEnvOptions{})
+//   if err != nil {
+//     t.Skip("no Temporal server:", err)
+//   }
+//   defer env.Close()
+//   w := env.NewWorker("my-task-queue", worker.Options{})
+//   w.RegisterWorkflow(MyWorkflow)
+//   _ = w.Start()
+//   run, err := env.Client().ExecuteWorkflow(ctx, client.StartWorkflowOptions{TaskQueue: "my-task-queue"}, MyWorkflow)
+func NewIntegrationEnv(options IntegrationEnvOptions) (*IntegrationEnv, error) {
+	return internal.NewIntegrationEnv(options)
+}
+
 // ErrMockStartChildWorkflowFailed is special error used to indicate the mocked child workflow should fail to start.
 var ErrMockStartChildWorkflowFailed = internal.ErrMockStartChildWorkflowFailed
+
+// TimeoutHeartbeat is returned as the error of a mocked activity to simulate a heartbeat timeout of the activity
+// attempt, after the activity's HeartbeatTimeout has elapsed on the workflow clock. The details, if any, are recorded
+// as the last heartbeat details.
+// Example:
+//   env.OnActivity(MyActivity, mock.Anything).Return(testsuite.TimeoutHeartbeat("progress")).Once()
+func TimeoutHeartbeat(details ...interface{}) error {
+	return internal.TimeoutHeartbeat(details...)
+}
+
+// TimeoutStartToClose is returned as the error of a mocked activity to simulate a start-to-close timeout of the activity
+// attempt, after the activity's StartToCloseTimeout has elapsed on the workflow clock.
+func TimeoutStartToClose() error {
+	return internal.TimeoutStartToClose()
+}
+
+// TimeoutScheduleToStart is returned as the error of a mocked activity to simulate a schedule-to-start timeout, after
+// the activity's ScheduleToStartTimeout has elapsed on the workflow clock.
+func TimeoutScheduleToStart() error {
+	return internal.TimeoutScheduleToStart()
+}
@@ -0,0 +1,394 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package timeline converts a workflow execution history into a timeline model suitable for rendering Gantt-style
+// views of an execution.
+//
+// Activities, timers and child workflows become spans, from the time they were scheduled to the time they closed.
+// Signals, cancellation requests and markers, such as the ones recorded for local activities and side effects, become
+// instants. For example:
+//  iter := c.GetWorkflowHistory(ctx, workflowID, runID, false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+//  var events []*historypb.HistoryEvent
+//  for iter.HasNext() {
+//    event, err := iter.Next()
+//    if err != nil {
+//      return err
+//    }
+//    events = append(events, event)
+//  }
+//  t, err := timeline.FromEvents(events)
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+)
+
+// SpanKind is the kind of operation represented by a Span.
+type SpanKind int
+
+const (
+	// SpanKindActivity is an activity, from its scheduling to its completion, including its retries.
+	SpanKindActivity SpanKind = iota
+	// SpanKindTimer is a timer, from its start to the time it fired or was canceled.
+	SpanKindTimer
+	// SpanKindChildWorkflow is a child workflow, from its initiation to its completion.
+	SpanKindChildWorkflow
+)
+
+// String returns the name of the kind.
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindActivity:
+		return "Activity"
+	case SpanKindTimer:
+		return "Timer"
+	case SpanKindChildWorkflow:
+		return "ChildWorkflow"
+	}
+	return fmt.Sprintf("SpanKind(%d)", int(k))
+}
+
+// SpanStatus is the state of the operation represented by a Span at the end of the history.
+type SpanStatus int
+
+const (
+	// SpanStatusOpen is the status of operations which haven't closed yet.
+	SpanStatusOpen SpanStatus = iota
+	// SpanStatusCompleted is the status of activities and child workflows which completed, and of timers which fired.
+	SpanStatusCompleted
+	// SpanStatusFailed is the status of activities and child workflows which failed, or child workflows which failed
+	// to start.
+	SpanStatusFailed
+	// SpanStatusTimedOut is the status of activities and child workflows which timed out.
+	SpanStatusTimedOut
+	// SpanStatusCanceled is the status of canceled operations.
+	SpanStatusCanceled
+	// SpanStatusTerminated is the status of terminated child workflows.
+	SpanStatusTerminated
+)
+
+// String returns the name of the status.
+func (s SpanStatus) String() string {
+	switch s {
+	case SpanStatusOpen:
+		return "Open"
+	case SpanStatusCompleted:
+		return "Completed"
+	case SpanStatusFailed:
+		return "Failed"
+	case SpanStatusTimedOut:
+		return "TimedOut"
+	case SpanStatusCanceled:
+		return "Canceled"
+	case SpanStatusTerminated:
+		return "Terminated"
+	}
+	return fmt.Sprintf("SpanStatus(%d)", int(s))
+}
+
+type (
+	// Timeline is the timeline of a workflow execution.
+	Timeline struct {
+		WorkflowType string
+		// StartTime is the time of the first event of the history.
+		StartTime time.Time
+		// CloseTime is the time the workflow execution closed, zero if it is still open.
+		CloseTime time.Time
+		// CloseEventType is the type of the event which closed the workflow execution, unspecified if it is still open.
+		CloseEventType enumspb.EventType
+		// Spans are ordered by ScheduledTime, then by the ID of their first event.
+		Spans []*Span
+		// Instants are ordered by Time.
+		Instants []*Instant
+	}
+
+	// Span is an operation of the workflow execution that lasts.
+	Span struct {
+		Kind SpanKind
+		// ID is the activity ID, the timer ID or the child workflow ID.
+		ID string
+		// Name is the activity type or the child workflow type, empty for timers.
+		Name string
+		// ScheduledTime is when the activity was scheduled, the timer started or the child workflow initiated.
+		ScheduledTime time.Time
+		// StartTime is when the last attempt of the activity or the child workflow started, zero if it didn't start.
+		// It is the same as ScheduledTime for timers.
+		StartTime time.Time
+		// CloseTime is when the operation closed, zero if it is still open.
+		CloseTime time.Time
+		Status    SpanStatus
+		// Attempt is the attempt of the activity which last started, starting from 1. Zero for timers, child workflows
+		// and activities which didn't start.
+		Attempt int32
+		// CancelRequested is set when the workflow requested the cancellation of the activity.
+		CancelRequested bool
+		// FailureMessage is the message of the failure of failed or timed out operations.
+		FailureMessage string
+		// EventIDs are the IDs of the events of the operation, in order.
+		EventIDs []int64
+	}
+
+	// Instant is an event of the workflow execution without duration.
+	Instant struct {
+		// EventType is the type of the event, for example EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED.
+		EventType enumspb.EventType
+		// Name is the signal name or the marker name, empty for other events.
+		Name    string
+		Time    time.Time
+		EventID int64
+	}
+)
+
+// FromHistory returns the timeline of the history.
+func FromHistory(history *historypb.History) (*Timeline, error) {
+	return FromEvents(history.GetEvents())
+}
+
+// FromEvents returns the timeline of the history events, which must be in order. The history doesn't need to be
+// complete, operations which haven't closed are open spans. An error is returned if an event refers to an operation
+// missing from the history.
+func FromEvents(events []*historypb.HistoryEvent) (*Timeline, error) {
+	b := &builder{
+		timeline:       &Timeline{},
+		activities:     make(map[int64]*Span),
+		timers:         make(map[string]*Span),
+		childWorkflows: make(map[int64]*Span),
+	}
+	for _, event := range events {
+		if err := b.add(event); err != nil {
+			return nil, err
+		}
+	}
+	t := b.timeline
+	sort.SliceStable(t.Spans, func(i, j int) bool {
+		if !t.Spans[i].ScheduledTime.Equal(t.Spans[j].ScheduledTime) {
+			return t.Spans[i].ScheduledTime.Before(t.Spans[j].ScheduledTime)
+		}
+		return t.Spans[i].EventIDs[0] < t.Spans[j].EventIDs[0]
+	})
+	sort.SliceStable(t.Instants, func(i, j int) bool {
+		return t.Instants[i].Time.Before(t.Instants[j].Time)
+	})
+	return t, nil
+}
+
+type builder struct {
+	timeline *Timeline
+	// activities are indexed by scheduled event ID.
+	activities map[int64]*Span
+	// timers are indexed by timer ID while they are open, timer IDs can be reused once the timer closed.
+	timers map[string]*Span
+	// childWorkflows are indexed by initiated event ID.
+	childWorkflows map[int64]*Span
+}
+
+func (b *builder) add(event *historypb.HistoryEvent) error {
+	eventTime := timeValue(event.GetEventTime())
+	if b.timeline.StartTime.IsZero() {
+		b.timeline.StartTime = eventTime
+	}
+
+	switch event.GetEventType() {
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED:
+		b.timeline.WorkflowType = event.GetWorkflowExecutionStartedEventAttributes().GetWorkflowType().GetName()
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_COMPLETED,
+		enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_FAILED,
+		enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_TIMED_OUT,
+		enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_CANCELED,
+		enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_TERMINATED,
+		enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_CONTINUED_AS_NEW:
+		b.timeline.CloseTime = eventTime
+		b.timeline.CloseEventType = event.GetEventType()
+
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED:
+		b.addInstant(event, event.GetWorkflowExecutionSignaledEventAttributes().GetSignalName())
+	case enumspb.EVENT_TYPE_MARKER_RECORDED:
+		b.addInstant(event, event.GetMarkerRecordedEventAttributes().GetMarkerName())
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_CANCEL_REQUESTED:
+		b.addInstant(event, "")
+
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED:
+		attributes := event.GetActivityTaskScheduledEventAttributes()
+		b.activities[event.GetEventId()] = b.addSpan(event, SpanKindActivity, attributes.GetActivityId(),
+			attributes.GetActivityType().GetName())
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_STARTED:
+		attributes := event.GetActivityTaskStartedEventAttributes()
+		span, err := b.activity(event, attributes.GetScheduledEventId())
+		if err != nil {
+			return err
+		}
+		span.StartTime = eventTime
+		span.Attempt = attributes.GetAttempt()
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_CANCEL_REQUESTED:
+		span, err := b.activity(event, event.GetActivityTaskCancelRequestedEventAttributes().GetScheduledEventId())
+		if err != nil {
+			return err
+		}
+		span.CancelRequested = true
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_COMPLETED:
+		return b.closeActivity(event, event.GetActivityTaskCompletedEventAttributes().GetScheduledEventId(),
+			SpanStatusCompleted, "")
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_FAILED:
+		attributes := event.GetActivityTaskFailedEventAttributes()
+		return b.closeActivity(event, attributes.GetScheduledEventId(), SpanStatusFailed,
+			attributes.GetFailure().GetMessage())
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_TIMED_OUT:
+		attributes := event.GetActivityTaskTimedOutEventAttributes()
+		return b.closeActivity(event, attributes.GetScheduledEventId(), SpanStatusTimedOut,
+			attributes.GetFailure().GetMessage())
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_CANCELED:
+		return b.closeActivity(event, event.GetActivityTaskCanceledEventAttributes().GetScheduledEventId(),
+			SpanStatusCanceled, "")
+
+	case enumspb.EVENT_TYPE_TIMER_STARTED:
+		timerID := event.GetTimerStartedEventAttributes().GetTimerId()
+		span := b.addSpan(event, SpanKindTimer, timerID, "")
+		span.StartTime = eventTime
+		b.timers[timerID] = span
+	case enumspb.EVENT_TYPE_TIMER_FIRED:
+		return b.closeTimer(event, event.GetTimerFiredEventAttributes().GetTimerId(), SpanStatusCompleted)
+	case enumspb.EVENT_TYPE_TIMER_CANCELED:
+		return b.closeTimer(event, event.GetTimerCanceledEventAttributes().GetTimerId(), SpanStatusCanceled)
+
+	case enumspb.EVENT_TYPE_START_CHILD_WORKFLOW_EXECUTION_INITIATED:
+		attributes := event.GetStartChildWorkflowExecutionInitiatedEventAttributes()
+		b.childWorkflows[event.GetEventId()] = b.addSpan(event, SpanKindChildWorkflow, attributes.GetWorkflowId(),
+			attributes.GetWorkflowType().GetName())
+	case enumspb.EVENT_TYPE_START_CHILD_WORKFLOW_EXECUTION_FAILED:
+		attributes := event.GetStartChildWorkflowExecutionFailedEventAttributes()
+		return b.closeChildWorkflow(event, attributes.GetInitiatedEventId(), SpanStatusFailed,
+			fmt.Sprintf("child workflow failed to start: %v", attributes.GetCause()))
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_STARTED:
+		span, err := b.childWorkflow(event, event.GetChildWorkflowExecutionStartedEventAttributes().GetInitiatedEventId())
+		if err != nil {
+			return err
+		}
+		span.StartTime = eventTime
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_COMPLETED:
+		return b.closeChildWorkflow(event, event.GetChildWorkflowExecutionCompletedEventAttributes().GetInitiatedEventId(),
+			SpanStatusCompleted, "")
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_FAILED:
+		attributes := event.GetChildWorkflowExecutionFailedEventAttributes()
+		return b.closeChildWorkflow(event, attributes.GetInitiatedEventId(), SpanStatusFailed,
+			attributes.GetFailure().GetMessage())
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_TIMED_OUT:
+		return b.closeChildWorkflow(event, event.GetChildWorkflowExecutionTimedOutEventAttributes().GetInitiatedEventId(),
+			SpanStatusTimedOut, "")
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_CANCELED:
+		return b.closeChildWorkflow(event, event.GetChildWorkflowExecutionCanceledEventAttributes().GetInitiatedEventId(),
+			SpanStatusCanceled, "")
+	case enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_TERMINATED:
+		return b.closeChildWorkflow(event, event.GetChildWorkflowExecutionTerminatedEventAttributes().GetInitiatedEventId(),
+			SpanStatusTerminated, "")
+	}
+	return nil
+}
+
+func (b *builder) addSpan(event *historypb.HistoryEvent, kind SpanKind, id, name string) *Span {
+	span := &Span{
+		Kind:          kind,
+		ID:            id,
+		Name:          name,
+		ScheduledTime: timeValue(event.GetEventTime()),
+		EventIDs:      []int64{event.GetEventId()},
+	}
+	b.timeline.Spans = append(b.timeline.Spans, span)
+	return span
+}
+
+func (b *builder) addInstant(event *historypb.HistoryEvent, name string) {
+	b.timeline.Instants = append(b.timeline.Instants, &Instant{
+		EventType: event.GetEventType(),
+		Name:      name,
+		Time:      timeValue(event.GetEventTime()),
+		EventID:   event.GetEventId(),
+	})
+}
+
+func (b *builder) activity(event *historypb.HistoryEvent, scheduledEventID int64) (*Span, error) {
+	span, ok := b.activities[scheduledEventID]
+	if !ok {
+		return nil, fmt.Errorf("event %v of type %v refers to unknown activity scheduled event %v",
+			event.GetEventId(), event.GetEventType(), scheduledEventID)
+	}
+	span.EventIDs = append(span.EventIDs, event.GetEventId())
+	return span, nil
+}
+
+func (b *builder) closeActivity(event *historypb.HistoryEvent, scheduledEventID int64, status SpanStatus, failureMessage string) error {
+	span, err := b.activity(event, scheduledEventID)
+	if err != nil {
+		return err
+	}
+	closeSpan(span, event, status, failureMessage)
+	return nil
+}
+
+func (b *builder) closeTimer(event *historypb.HistoryEvent, timerID string, status SpanStatus) error {
+	span, ok := b.timers[timerID]
+	if !ok {
+		return fmt.Errorf("event %v of type %v refers to unknown timer %v", event.GetEventId(), event.GetEventType(), timerID)
+	}
+	delete(b.timers, timerID)
+	span.EventIDs = append(span.EventIDs, event.GetEventId())
+	closeSpan(span, event, status, "")
+	return nil
+}
+
+func (b *builder) childWorkflow(event *historypb.HistoryEvent, initiatedEventID int64) (*Span, error) {
+	span, ok := b.childWorkflows[initiatedEventID]
+	if !ok {
+		return nil, fmt.Errorf("event %v of type %v refers to unknown child workflow initiated event %v",
+			event.GetEventId(), event.GetEventType(), initiatedEventID)
+	}
+	span.EventIDs = append(span.EventIDs, event.GetEventId())
+	return span, nil
+}
+
+func (b *builder) closeChildWorkflow(event *historypb.HistoryEvent, initiatedEventID int64, status SpanStatus, failureMessage string) error {
+	span, err := b.childWorkflow(event, initiatedEventID)
+	if err != nil {
+		return err
+	}
+	closeSpan(span, event, status, failureMessage)
+	return nil
+}
+
+func closeSpan(span *Span, event *historypb.HistoryEvent, status SpanStatus, failureMessage string) {
+	span.CloseTime = timeValue(event.GetEventTime())
+	span.Status = status
+	span.FailureMessage = failureMessage
+}
+
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
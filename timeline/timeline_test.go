@@ -0,0 +1,160 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	failurepb "go.temporal.io/api/failure/v1"
+	historypb "go.temporal.io/api/history/v1"
+)
+
+var startTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type historyBuilder struct {
+	events []*historypb.HistoryEvent
+}
+
+func (h *historyBuilder) add(offset time.Duration, eventType enumspb.EventType, attributes interface{}) int64 {
+	eventTime := startTime.Add(offset)
+	event := &historypb.HistoryEvent{
+		EventId:   int64(len(h.events) + 1),
+		EventTime: &eventTime,
+		EventType: eventType,
+	}
+	switch a := attributes.(type) {
+	case *historypb.WorkflowExecutionStartedEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_WorkflowExecutionStartedEventAttributes{WorkflowExecutionStartedEventAttributes: a}
+	case *historypb.WorkflowExecutionCompletedEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_WorkflowExecutionCompletedEventAttributes{WorkflowExecutionCompletedEventAttributes: a}
+	case *historypb.WorkflowExecutionSignaledEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_WorkflowExecutionSignaledEventAttributes{WorkflowExecutionSignaledEventAttributes: a}
+	case *historypb.ActivityTaskScheduledEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_ActivityTaskScheduledEventAttributes{ActivityTaskScheduledEventAttributes: a}
+	case *historypb.ActivityTaskStartedEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_ActivityTaskStartedEventAttributes{ActivityTaskStartedEventAttributes: a}
+	case *historypb.ActivityTaskCompletedEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_ActivityTaskCompletedEventAttributes{ActivityTaskCompletedEventAttributes: a}
+	case *historypb.ActivityTaskFailedEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_ActivityTaskFailedEventAttributes{ActivityTaskFailedEventAttributes: a}
+	case *historypb.TimerStartedEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_TimerStartedEventAttributes{TimerStartedEventAttributes: a}
+	case *historypb.TimerFiredEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_TimerFiredEventAttributes{TimerFiredEventAttributes: a}
+	case *historypb.StartChildWorkflowExecutionInitiatedEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_StartChildWorkflowExecutionInitiatedEventAttributes{StartChildWorkflowExecutionInitiatedEventAttributes: a}
+	case *historypb.ChildWorkflowExecutionStartedEventAttributes:
+		event.Attributes = &historypb.HistoryEvent_ChildWorkflowExecutionStartedEventAttributes{ChildWorkflowExecutionStartedEventAttributes: a}
+	}
+	h.events = append(h.events, event)
+	return event.EventId
+}
+
+func TestFromEvents(t *testing.T) {
+	h := &historyBuilder{}
+	h.add(0, enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, &historypb.WorkflowExecutionStartedEventAttributes{
+		WorkflowType: &commonpb.WorkflowType{Name: "OrderWorkflow"},
+	})
+	activityScheduled := h.add(time.Second, enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED, &historypb.ActivityTaskScheduledEventAttributes{
+		ActivityId:   "5",
+		ActivityType: &commonpb.ActivityType{Name: "Charge"},
+	})
+	timerStarted := h.add(time.Second, enumspb.EVENT_TYPE_TIMER_STARTED, &historypb.TimerStartedEventAttributes{TimerId: "6"})
+	childInitiated := h.add(2*time.Second, enumspb.EVENT_TYPE_START_CHILD_WORKFLOW_EXECUTION_INITIATED, &historypb.StartChildWorkflowExecutionInitiatedEventAttributes{
+		WorkflowId:   "child",
+		WorkflowType: &commonpb.WorkflowType{Name: "ShipWorkflow"},
+	})
+	h.add(3*time.Second, enumspb.EVENT_TYPE_ACTIVITY_TASK_STARTED, &historypb.ActivityTaskStartedEventAttributes{
+		ScheduledEventId: activityScheduled,
+		Attempt:          2,
+	})
+	h.add(4*time.Second, enumspb.EVENT_TYPE_CHILD_WORKFLOW_EXECUTION_STARTED, &historypb.ChildWorkflowExecutionStartedEventAttributes{
+		InitiatedEventId: childInitiated,
+	})
+	h.add(5*time.Second, enumspb.EVENT_TYPE_ACTIVITY_TASK_FAILED, &historypb.ActivityTaskFailedEventAttributes{
+		ScheduledEventId: activityScheduled,
+		Failure:          &failurepb.Failure{Message: "card declined"},
+	})
+	h.add(6*time.Second, enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED, &historypb.WorkflowExecutionSignaledEventAttributes{SignalName: "cancel-order"})
+	h.add(11*time.Second, enumspb.EVENT_TYPE_TIMER_FIRED, &historypb.TimerFiredEventAttributes{TimerId: "6"})
+	h.add(12*time.Second, enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_COMPLETED, &historypb.WorkflowExecutionCompletedEventAttributes{})
+
+	timeline, err := FromHistory(&historypb.History{Events: h.events})
+	require.NoError(t, err)
+	require.Equal(t, "OrderWorkflow", timeline.WorkflowType)
+	require.Equal(t, startTime, timeline.StartTime)
+	require.Equal(t, startTime.Add(12*time.Second), timeline.CloseTime)
+	require.Equal(t, enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_COMPLETED, timeline.CloseEventType)
+
+	require.Len(t, timeline.Spans, 3)
+	activity := timeline.Spans[0]
+	require.Equal(t, SpanKindActivity, activity.Kind)
+	require.Equal(t, "5", activity.ID)
+	require.Equal(t, "Charge", activity.Name)
+	require.Equal(t, startTime.Add(time.Second), activity.ScheduledTime)
+	require.Equal(t, startTime.Add(3*time.Second), activity.StartTime)
+	require.Equal(t, startTime.Add(5*time.Second), activity.CloseTime)
+	require.Equal(t, SpanStatusFailed, activity.Status)
+	require.Equal(t, int32(2), activity.Attempt)
+	require.Equal(t, "card declined", activity.FailureMessage)
+	require.Equal(t, []int64{2, 5, 7}, activity.EventIDs)
+
+	timer := timeline.Spans[1]
+	require.Equal(t, SpanKindTimer, timer.Kind)
+	require.Equal(t, "6", timer.ID)
+	require.Equal(t, timerStarted, timer.EventIDs[0])
+	require.Equal(t, timer.ScheduledTime, timer.StartTime)
+	require.Equal(t, startTime.Add(11*time.Second), timer.CloseTime)
+	require.Equal(t, SpanStatusCompleted, timer.Status)
+
+	child := timeline.Spans[2]
+	require.Equal(t, SpanKindChildWorkflow, child.Kind)
+	require.Equal(t, "child", child.ID)
+	require.Equal(t, "ShipWorkflow", child.Name)
+	require.Equal(t, startTime.Add(4*time.Second), child.StartTime)
+	require.True(t, child.CloseTime.IsZero())
+	require.Equal(t, SpanStatusOpen, child.Status)
+
+	require.Len(t, timeline.Instants, 1)
+	require.Equal(t, enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED, timeline.Instants[0].EventType)
+	require.Equal(t, "cancel-order", timeline.Instants[0].Name)
+	require.Equal(t, int64(8), timeline.Instants[0].EventID)
+}
+
+func TestFromEventsUnknownReference(t *testing.T) {
+	h := &historyBuilder{}
+	h.add(0, enumspb.EVENT_TYPE_ACTIVITY_TASK_COMPLETED, &historypb.ActivityTaskCompletedEventAttributes{ScheduledEventId: 42})
+	_, err := FromEvents(h.events)
+	require.Error(t, err)
+
+	h = &historyBuilder{}
+	h.add(0, enumspb.EVENT_TYPE_TIMER_FIRED, &historypb.TimerFiredEventAttributes{TimerId: "1"})
+	_, err = FromEvents(h.events)
+	require.Error(t, err)
+}
@@ -33,6 +33,7 @@ import (
 
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/internal"
 	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/workflow"
@@ -56,6 +57,38 @@ type (
 
 		// Stop the worker.
 		Stop()
+
+		// EvictWorkflowExecution removes the workflow execution from the sticky cache of the worker. The next workflow
+		// task of the execution is dispatched to any worker of the task queue and the workflow state is rebuilt by
+		// replaying its history. Returns true if the workflow execution was cached.
+		EvictWorkflowExecution(workflowID, runID string) bool
+
+		// BinaryChecksum returns the checksum identifying the binary of the worker, which is recorded in the history
+		// of the workflows the worker makes progress on. Pass it to client.NamespaceClient.MarkBadBinary to stop
+		// workers of the binary from processing workflow tasks.
+		BinaryChecksum() string
+
+		// PauseActivityType stops the worker from running the activities of the activity type, to stop calling a
+		// misbehaving downstream service without terminating the workflows. The activity tasks of the type are held
+		// by the worker until the type is resumed, so the pause uses up no attempt. A held task takes an execution
+		// slot of the worker, heartbeats to keep its heartbeat timeout from firing and still times out after its
+		// StartToCloseTimeout.
+		// Activities already running are not interrupted, see ActivityInfo.Paused.
+		PauseActivityType(activityType string)
+
+		// ResumeActivityType runs the activities of the paused activity type again, from their next attempt.
+		ResumeActivityType(activityType string)
+
+		// IsActivityTypePaused returns whether the activity type is paused on the worker.
+		IsActivityTypePaused(activityType string) bool
+
+		// RegisteredWorkflowTypes returns the sorted names of the workflow types registered with the worker or its
+		// Options.Registry.
+		RegisteredWorkflowTypes() []string
+
+		// RegisteredActivityTypes returns the sorted names of the activity types registered with the worker or its
+		// Options.Registry.
+		RegisteredActivityTypes() []string
 	}
 
 	// Registry exposes registration functions to consumers.
@@ -86,6 +119,20 @@ type (
 		// This method panics if workflowFunc doesn't comply with the expected format or tries to register the same workflow
 		// type name twice. Use workflow.RegisterOptions.DisableAlreadyRegisteredCheck to allow multiple registrations.
 		RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions)
+
+		// RegisterDynamicWorkflow registers a workflow function that is invoked for every workflow type that doesn't
+		// have a registered workflow, instead of failing the workflow task. The function receives the workflow type
+		// name and the encoded arguments, which makes it possible to build generic interpreters or to keep serving
+		// renamed workflows. For example:
+		//  worker.RegisterDynamicWorkflow(func(ctx workflow.Context, workflowType string, args converter.EncodedValues) (interface{}, error) {
+		//    var input string
+		//    if err := args.Get(&input); err != nil {
+		//      return nil, err
+		//    }
+		//    return interpret(ctx, workflowType, input)
+		//  })
+		// This method panics if a dynamic workflow is already registered.
+		RegisterDynamicWorkflow(w workflow.DynamicFunc)
 	}
 
 	// ActivityRegistry exposes activity registration functions to consumers.
@@ -137,6 +184,17 @@ type (
 		// which might be useful for integration tests.
 		// worker.RegisterActivityWithOptions(barActivity, RegisterActivityOptions{DisableAlreadyRegisteredCheck: true})
 		RegisterActivityWithOptions(a interface{}, options activity.RegisterOptions)
+
+		// RegisterDynamicActivity registers an activity function that is invoked for every activity type that doesn't
+		// have a registered activity, instead of failing the activity task. The function receives the activity type
+		// name and the raw input payloads and returns the raw result payloads, so a single worker can proxy arbitrary
+		// activity types to another process. For example:
+		//  worker.RegisterDynamicActivity(func(ctx context.Context, activityType string, input *commonpb.Payloads) (*commonpb.Payloads, error) {
+		//    return forwardToExecutor(ctx, activityType, input)
+		//  })
+		// Dynamic activities are not used for local activities.
+		// This method panics if a dynamic activity is already registered.
+		RegisterDynamicActivity(a activity.DynamicFunc)
 	}
 
 	// WorkflowReplayer supports replaying a workflow from its event history.
@@ -153,11 +211,24 @@ type (
 		// RegisterWorkflowWithOptions registers workflow that is going to be replayed with user provided name
 		RegisterWorkflowWithOptions(w interface{}, options workflow.RegisterOptions)
 
+		// RegisterDynamicWorkflow registers the workflow function that replays the workflow types without a registered
+		// workflow. See WorkflowRegistry.RegisterDynamicWorkflow.
+		RegisterDynamicWorkflow(w workflow.DynamicFunc)
+
 		// ReplayWorkflowHistory executes a single workflow task for the given json history file.
 		// Use for testing the backwards compatibility of code changes and troubleshooting workflows in a debugger.
+		// When the replay diverges from the history, the error is a *NondeterministicReplayError describing where.
 		// The logger is an optional parameter. Defaults to the noop logger.
 		ReplayWorkflowHistory(logger log.Logger, history *historypb.History) error
 
+		// DiffWorkflowHistory replays the given history like ReplayWorkflowHistory and returns the differences
+		// between the commands generated by the replay and the events of the history, instead of stopping at the
+		// first non-deterministic one. It returns no difference and no error for deterministic workflows. When the
+		// replay fails, the error is returned along with the differences found so far; if the failure is caused by
+		// them, it is a *NondeterministicReplayError.
+		// The logger is an optional parameter. Defaults to the noop logger.
+		DiffWorkflowHistory(logger log.Logger, history *historypb.History) ([]client.HistoryDifference, error)
+
 		// ReplayWorkflowHistoryFromJSONFile executes a single workflow task for the json history file downloaded from the cli.
 		// To download the history file: temporal workflow showid <workflow_id> -of <output_filename>
 		// See https://github.com/temporalio/temporal/blob/master/tools/cli/README.md for full documentation
@@ -177,16 +248,124 @@ type (
 		// Use for testing the backwards compatibility of code changes and troubleshooting workflows in a debugger.
 		// The logger is the only optional parameter. Defaults to the noop logger.
 		ReplayWorkflowExecution(ctx context.Context, service workflowservice.WorkflowServiceClient, logger log.Logger, namespace string, execution workflow.Execution) error
+
+		// ReplayWorkflowHistoryChain replays the runs of a continue-as-new chain one after the other, starting with
+		// the history of the first run. When a run continued as new, nextRun is called with the run ID of the next
+		// run to get its history, e.g. from a file named after the run ID. The next run must start with the input the
+		// previous run continued as new with.
+		// The logger is an optional parameter. Defaults to the noop logger.
+		ReplayWorkflowHistoryChain(logger log.Logger, history *historypb.History, nextRun func(runID string) (*historypb.History, error)) error
+
+		// ReplayWorkflowExecutionChain loads the histories of the runs of the continue-as-new chain starting with the
+		// workflow execution from the Temporal service and replays them like ReplayWorkflowHistoryChain.
+		// The logger is the only optional parameter. Defaults to the noop logger.
+		ReplayWorkflowExecutionChain(ctx context.Context, service workflowservice.WorkflowServiceClient, logger log.Logger, namespace string, execution workflow.Execution) error
+	}
+
+	// Group runs workers polling several task queues, possibly in different namespaces, in a single process. The
+	// workers share the workflows and activities registered with the group and the concurrency limits set with
+	// GroupOptions, so a process hosting many small task queues doesn't need independent workers each with their own
+	// registrations and budgets. For example:
+	//  group := worker.NewGroup(worker.GroupOptions{MaxConcurrentActivityExecutionSize: 100})
+	//  group.RegisterWorkflow(OrderWorkflow)
+	//  group.RegisterActivity(&Activities{})
+	//  _ = group.AddWorker(ordersClient, "orders", worker.Options{})
+	//  _ = group.AddWorker(billingClient, "billing", worker.Options{})
+	//  err := group.Run(worker.InterruptCh())
+	Group interface {
+		Registry
+
+		// AddWorker adds a worker polling the task queue in the namespace of the client. Workers can't be added once
+		// the group is started. WorkflowInterceptorChainFactories, TaskQueueRoutes and Registry of the options are
+		// ignored, use the GroupOptions and the registration functions of the group instead.
+		AddWorker(client client.Client, taskQueue string, options Options) error
+
+		// Start all the workers of the group in a non-blocking fashion. If a worker fails to start, the workers
+		// already started are stopped.
+		Start() error
+
+		// Run the workers of the group in a blocking fashion. Stop the workers when interruptCh receives signal.
+		// Pass worker.InterruptCh() to stop the workers with SIGINT or SIGTERM.
+		// Pass nil to stop the workers with external Stop() call.
+		// Returns error only if a worker fails to start.
+		Run(interruptCh <-chan interface{}) error
+
+		// Stop all the workers of the group.
+		Stop()
 	}
 
+	// GroupOptions is used to configure a worker group.
+	GroupOptions = internal.WorkerGroupOptions
+
 	// Options is used to configure a worker instance.
 	Options = internal.WorkerOptions
 
+	// ActivityResultCache caches the results of idempotent activities by activity type and arguments.
+	ActivityResultCache = internal.ActivityResultCache
+
+	// ActivityResultCacheOptions configure an ActivityResultCache.
+	ActivityResultCacheOptions = internal.ActivityResultCacheOptions
+
+	// TaskQueueRoutes maps activity and workflow types to the task queues they are scheduled on by default.
+	TaskQueueRoutes = internal.TaskQueueRoutes
+
+	// SharedRegistry holds workflow and activity registrations shared by several workers through Options.Registry.
+	// Each registry detects the name collisions of its own registrations only, so independent workers of a process,
+	// or of a test binary, don't interfere with each other.
+	SharedRegistry = internal.SharedRegistry
+
 	// WorkflowPanicPolicy is used for configuring how worker deals with workflow
 	// code panicking which includes non backwards compatible changes to the workflow code without appropriate
 	// versioning (see workflow.GetVersion).
 	// The default behavior is to block workflow execution until the problem is fixed.
 	WorkflowPanicPolicy = internal.WorkflowPanicPolicy
+
+	// WorkflowPanicInfo describes a workflow panic or a detected non-determinism passed to
+	// WorkerOptions.OnWorkflowPanic.
+	WorkflowPanicInfo = internal.WorkflowPanicInfo
+
+	// WorkflowStuckInfo describes a workflow which workflow tasks repeatedly fail, passed to
+	// WorkerOptions.OnWorkflowStuck.
+	WorkflowStuckInfo = internal.WorkflowStuckInfo
+
+	// NondeterministicReplayError is returned by WorkflowReplayer when the commands generated by the replay don't
+	// match the events of the history. Use errors.As to get it from the error returned by a replay.
+	NondeterministicReplayError = internal.NondeterministicReplayError
+
+	// VersionMarker is a version recorded in a history by a workflow.GetVersion call.
+	VersionMarker = internal.VersionMarker
+
+	// Identity describes where a worker runs, see Options.IdentityDetails.
+	Identity = internal.WorkerIdentity
+
+	// WorkflowTaskLimitExceededError is the failure of a workflow task whose completion exceeds
+	// Options.MaxWorkflowTaskCommands or Options.MaxWorkflowTaskCompletionSize.
+	WorkflowTaskLimitExceededError = internal.WorkflowTaskLimitExceededError
+
+	// NamespaceFailoverOptions configure how a worker follows the failovers of its namespace between clusters, see
+	// Options.NamespaceFailover.
+	NamespaceFailoverOptions = internal.NamespaceFailoverOptions
+
+	// NamespaceFailoverInfo describes a change of the active cluster of the namespace of a worker, passed to
+	// NamespaceFailoverOptions.OnActiveClusterChange.
+	NamespaceFailoverInfo = internal.NamespaceFailoverInfo
+
+	// ActivityPanicPolicy is used for configuring how worker deals with activity code panicking.
+	// The default behavior is to fail the activity attempt with a retryable error.
+	ActivityPanicPolicy = internal.ActivityPanicPolicy
+
+	// WorkflowAuditor is called by a worker on the lifecycle events of the workflow executions it processes, see
+	// Options.WorkflowAudit.
+	WorkflowAuditor = internal.WorkflowAuditor
+
+	// WorkflowAuditOptions configure the auditing of the workflow executions processed by a worker.
+	WorkflowAuditOptions = internal.WorkflowAuditOptions
+
+	// WorkflowAuditEvent describes a lifecycle event of a workflow execution, passed to WorkflowAuditor.
+	WorkflowAuditEvent = internal.WorkflowAuditEvent
+
+	// WorkflowAuditEventType is the type of a WorkflowAuditEvent.
+	WorkflowAuditEventType = internal.WorkflowAuditEventType
 )
 
 const (
@@ -201,6 +380,40 @@ const (
 	FailWorkflow = internal.FailWorkflow
 )
 
+const (
+	// RetryActivityOnPanic is the default ActivityPanicPolicy. The activity attempt fails with a retryable
+	// PanicError, and the activity is retried according to its retry policy.
+	RetryActivityOnPanic = internal.RetryActivityOnPanic
+	// FailActivityOnPanic ActivityPanicPolicy fails the activity with a non-retryable PanicError.
+	FailActivityOnPanic = internal.FailActivityOnPanic
+	// CrashWorkerOnPanic ActivityPanicPolicy raises the activity panic again, which crashes the worker process.
+	// The activity times out and is retried by another worker according to its retry policy.
+	CrashWorkerOnPanic = internal.CrashWorkerOnPanic
+)
+
+const (
+	// WorkflowAuditStarted is reported when the first workflow task of a workflow execution is completed.
+	WorkflowAuditStarted = internal.WorkflowAuditStarted
+	// WorkflowAuditCompleted is reported when a workflow execution completes successfully.
+	WorkflowAuditCompleted = internal.WorkflowAuditCompleted
+	// WorkflowAuditFailed is reported when a workflow execution fails.
+	WorkflowAuditFailed = internal.WorkflowAuditFailed
+	// WorkflowAuditCanceled is reported when a workflow execution is canceled.
+	WorkflowAuditCanceled = internal.WorkflowAuditCanceled
+	// WorkflowAuditContinuedAsNew is reported when a workflow execution continues as new.
+	WorkflowAuditContinuedAsNew = internal.WorkflowAuditContinuedAsNew
+)
+
+// NewSharedRegistry creates an empty SharedRegistry, to register workflows and activities once for all the workers
+// created with it as Options.Registry:
+//  registry := worker.NewSharedRegistry()
+//  registry.RegisterWorkflow(OrderWorkflow)
+//  w1 := worker.New(c, "orders", worker.Options{Registry: registry})
+//  w2 := worker.New(c, "orders-priority", worker.Options{Registry: registry})
+func NewSharedRegistry() *SharedRegistry {
+	return internal.NewSharedRegistry()
+}
+
 // New creates an instance of worker for managing workflow and activity executions.
 //    namespace   - the name of the temporal namespace
 //    taskQueue - is the task queue name you use to identify your client worker, also
@@ -215,6 +428,48 @@ func New(
 	return internal.NewWorker(client, taskQueue, options)
 }
 
+// NewWorkflowWorker creates an instance of worker which only executes workflows and local activities, like New with
+// Options.LocalActivityWorkerOnly set. It polls with more workflow task pollers by default, since it polls no activity
+// task. The activity options of Options are ignored.
+func NewWorkflowWorker(
+	client client.Client,
+	taskQueue string,
+	options Options,
+) Worker {
+	return internal.NewWorkflowWorker(client, taskQueue, options)
+}
+
+// NewActivityWorker creates an instance of worker which only executes activities. It polls with more activity task
+// pollers by default, since it polls no workflow task, and has no sticky workflow cache. The workflow options of
+// Options, as well as LocalActivityWorkerOnly and ReplayOnly, are ignored, and the workflows registered with the
+// worker are never executed.
+func NewActivityWorker(
+	client client.Client,
+	taskQueue string,
+	options Options,
+) Worker {
+	return internal.NewActivityWorker(client, taskQueue, options)
+}
+
+// NewActivityResultCache creates a cache of activity results to set as Options.ActivityResultCache.
+func NewActivityResultCache(options ActivityResultCacheOptions) *ActivityResultCache {
+	return internal.NewActivityResultCache(options)
+}
+
+// NewGroup creates a group of workers. Add workers with Group.AddWorker.
+func NewGroup(options GroupOptions) Group {
+	return &group{WorkerGroup: internal.NewWorkerGroup(options)}
+}
+
+// group adapts internal.WorkerGroup to the client.Client of the Group interface.
+type group struct {
+	*internal.WorkerGroup
+}
+
+func (g *group) AddWorker(client client.Client, taskQueue string, options Options) error {
+	return g.WorkerGroup.AddWorker(client, taskQueue, options)
+}
+
 // NewWorkflowReplayer creates a WorkflowReplayer instance.
 func NewWorkflowReplayer() WorkflowReplayer {
 	return internal.NewWorkflowReplayer()
@@ -231,11 +486,28 @@ func EnableVerboseLogging(enable bool) {
 // between workflow tasks of a specific workflow execution to a specific worker. The benefit of sticky execution is that
 // the workflow does not have to reconstruct state by replaying history from the beginning. The cache is shared between
 // workers running within same process. This must be called before any worker is started. If not called, the default
-// size of 10K (which may change) will be used.
+// size of 10K (which may change) will be used. Workers with Options.StickyWorkflowCacheSize set use their own cache
+// instead of the shared one.
 func SetStickyWorkflowCacheSize(cacheSize int) {
 	internal.SetStickyWorkflowCacheSize(cacheSize)
 }
 
+// SetStickyWorkflowCacheMaxBytes limits the sticky workflow cache shared by workers running within the same process
+// by the total size of the history of the cached workflow executions, in bytes. When the limit is exceeded, the least
+// recently used workflow executions are evicted. This must be called before any worker is started. If not called or
+// set to 0, only the number of cached workflow executions is limited.
+func SetStickyWorkflowCacheMaxBytes(maxBytes int64) {
+	internal.SetStickyWorkflowCacheMaxBytes(maxBytes)
+}
+
+// GetChangeVersions returns the versions recorded by workflow.GetVersion calls in the given history, by change ID.
+// Use it to find out which executions still depend on a change ID before retiring the GetVersion call. A change ID
+// missing from the result was never recorded by the execution. The data converter must match the one of the worker
+// which recorded the history, nil means the default data converter.
+func GetChangeVersions(history *historypb.History, dataConverter converter.DataConverter) (map[string]workflow.Version, error) {
+	return internal.GetChangeVersions(history, dataConverter)
+}
+
 // PurgeStickyWorkflowCache resets the sticky workflow cache. This must be called only when all workers are stopped.
 func PurgeStickyWorkflowCache() {
 	internal.PurgeStickyWorkflowCache()
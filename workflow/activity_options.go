@@ -46,6 +46,13 @@ func WithActivityOptions(ctx Context, options ActivityOptions) Context {
 	return internal.WithActivityOptions(ctx, options)
 }
 
+// ValidateActivityOptions returns an *InvalidOptionsError naming the invalid fields of the options along with
+// suggested fixes, nil if the options are valid. ExecuteActivity fails with this error without scheduling the activity
+// when the options of its context are invalid, use this function to check options in unit tests.
+func ValidateActivityOptions(options ActivityOptions) error {
+	return internal.ValidateActivityOptions(options)
+}
+
 // WithLocalActivityOptions makes a copy of the context and adds the
 // passed in options to the context. If a local activity options exists,
 // it will be overwritten by the passed in value.
@@ -63,6 +63,33 @@ func WithCancel(parent Context) (ctx Context, cancel CancelFunc) {
 	return internal.WithCancel(parent)
 }
 
+// CancellationScope groups the operations started by the code it runs so that they can be canceled together. Nested
+// scopes are canceled with their parent scope, except detached ones.
+type CancellationScope = internal.CancellationScope
+
+// NewCancellationScope creates a scope nested in the scope of ctx, canceled when ctx is canceled.
+//  scope := workflow.NewCancellationScope(ctx)
+//  scope.Go(func(ctx workflow.Context) {
+//    _ = workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, nil)
+//  })
+//  _ = workflow.Sleep(ctx, time.Minute)
+//  scope.Cancel() // cancels ActivityFoo if it is still running
+func NewCancellationScope(ctx Context) CancellationScope {
+	return internal.NewCancellationScope(ctx)
+}
+
+// NewDetachedCancellationScope creates a scope which is not canceled when ctx is canceled, only by its own Cancel.
+// Use it to run cleanup logic after the workflow is canceled.
+//  err := workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, nil)
+//  if temporal.IsCanceledError(err) {
+//    return workflow.NewDetachedCancellationScope(ctx).Run(func(ctx workflow.Context) error {
+//      return workflow.ExecuteActivity(ctx, CleanupFoo).Get(ctx, nil)
+//    })
+//  }
+func NewDetachedCancellationScope(ctx Context) CancellationScope {
+	return internal.NewDetachedCancellationScope(ctx)
+}
+
 // WithValue returns a copy of parent in which the value associated with key is
 // val.
 //
@@ -77,10 +104,16 @@ func WithValue(parent Context, key interface{}, val interface{}) Context {
 //  err := workflow.ExecuteActivity(ctx, ActivityFoo).Get(ctx, &activityFooResult)
 //  if err != nil && temporal.IsCanceledError(ctx.Err()) {
 //    // activity failed, and workflow context is canceled
-//    disconnectedCtx, _ := workflow.newDisconnectedContext(ctx);
+//    disconnectedCtx, _ := workflow.NewDisconnectedContext(ctx)
 //    workflow.ExecuteActivity(disconnectedCtx, handleCancellationActivity).Get(disconnectedCtx, nil)
 //    return err // workflow return CanceledError
 //  }
+//
+// The new context keeps the values of the parent, including the activity, child workflow and local activity options,
+// so the cleanup work is scheduled like the work of the parent. It is canceled only when the returned cancel function
+// is called, in which case the contexts derived from it are canceled as well. The parent keeps being canceled: code
+// using the parent context after the workflow is canceled still gets a CanceledError. Activities, timers and child
+// workflows which were started with the parent context are canceled with it, even when awaited with the new context.
 func NewDisconnectedContext(parent Context) (ctx Context, cancel CancelFunc) {
 	return internal.NewDisconnectedContext(parent)
 }
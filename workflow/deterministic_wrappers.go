@@ -48,6 +48,10 @@ type (
 	// Use workflow.NewSelector(ctx) method to create a Selector instance.
 	Selector = internal.Selector
 
+	// SelectorBranchOptions are options for a Selector branch, see Selector.AddReceiveWithOptions and
+	// Selector.AddFutureWithOptions.
+	SelectorBranchOptions = internal.SelectorBranchOptions
+
 	// Future represents the result of an asynchronous computation.
 	Future = internal.Future
 
@@ -166,6 +170,12 @@ func NewTimer(ctx Context, d time.Duration) Future {
 	return internal.NewTimer(ctx, d)
 }
 
+// NewTimerWithOptions is NewTimer with additional options. TimerOptions.Summary names the timer in the stack trace of
+// the workflow, which helps to tell pending timers apart when debugging.
+func NewTimerWithOptions(ctx Context, d time.Duration, options TimerOptions) Future {
+	return internal.NewTimerWithOptions(ctx, d, options)
+}
+
 // Sleep pauses the current workflow for at least the duration d. A negative or zero duration causes Sleep to return
 // immediately. Workflow code needs to use this Sleep() to sleep instead of the Go lang library one(timer.Sleep()).
 // You can cancel the pending sleep by cancel the Context (using context from workflow.WithCancel(ctx)).
@@ -177,3 +187,43 @@ func NewTimer(ctx Context, d time.Duration) Future {
 func Sleep(ctx Context, d time.Duration) (err error) {
 	return internal.Sleep(ctx, d)
 }
+
+// SleepUntil pauses the current workflow until the workflow time returned by Now reaches t, e.g. until an invoice due
+// date. If t is not after the current workflow time, SleepUntil returns immediately without starting a timer.
+// The duration is computed from the deterministic workflow time, so SleepUntil is safe to use on replay.
+// Like Sleep, it returns *CanceledError if the ctx is canceled.
+func SleepUntil(ctx Context, t time.Time) (err error) {
+	return internal.SleepUntil(ctx, t)
+}
+
+// SleepWithOptions is Sleep with additional options, see NewTimerWithOptions.
+func SleepWithOptions(ctx Context, d time.Duration, options TimerOptions) (err error) {
+	return internal.SleepWithOptions(ctx, d, options)
+}
+
+// DeterministicKeys returns the keys of the map m sorted in ascending order. Ranging over a map is not deterministic,
+// so workflow code that depends on the order of the keys should iterate over the result instead. The result is a
+// slice of the key type of m, e.g. []string for map[string]int, and needs to be type asserted by the caller:
+//  keys := workflow.DeterministicKeys(m).([]string)
+// Keys must be of a boolean, numeric or string kind. DeterministicKeys panics if m is not such a map.
+func DeterministicKeys(m interface{}) interface{} {
+	return internal.DeterministicKeys(m)
+}
+
+// DeterministicStringKeys is DeterministicKeys for maps with keys of a string kind.
+func DeterministicStringKeys(m interface{}) []string {
+	return internal.DeterministicStringKeys(m)
+}
+
+// DeterministicMapRange calls f for every key and value of the map m in ascending key order. Unlike the range
+// statement, the order is the same on every run, which is required for code that starts activities, timers or
+// other commands while iterating over a map. f must be a function taking the key and the value of m, e.g.
+// func(string, int) for map[string]int. If f also returns a bool, the iteration stops when it returns false:
+//  workflow.DeterministicMapRange(m, func(name string, count int) bool {
+//      ...
+//      return true
+//  })
+// Keys must be of a boolean, numeric or string kind. DeterministicMapRange panics if m or f don't match.
+func DeterministicMapRange(m interface{}, f interface{}) {
+	internal.DeterministicMapRange(m, f)
+}
@@ -115,7 +115,8 @@ A simplistic way to think about these requirements is that the workflow code:
   - Should do all logging via the logger provided by the Temporal client
     library (i.e. workflow.GetLogger())
   - Should not iterate over maps using range as order of map iteration is
-    randomized
+    randomized (use workflow.DeterministicMapRange() or iterate over
+    workflow.DeterministicKeys() instead)
 
 Now that we laid out the ground rules we can take a look at how to implement some common patterns inside workflows.
 
@@ -26,12 +26,15 @@ package workflow
 
 import (
 	"errors"
+	"math/rand"
+	"time"
 
 	"github.com/uber-go/tally"
 
 	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/internal"
 	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/temporal"
 )
 
 type (
@@ -39,6 +42,10 @@ type (
 	// ChildWorkflowFuture represents the result of a child workflow execution
 	ChildWorkflowFuture = internal.ChildWorkflowFuture
 
+	// ExternalWorkflowHandle is a handle to a workflow execution which is not a child of the current workflow.
+	// Use workflow.GetExternalWorkflowHandle(ctx, workflowID, runID) to create an instance.
+	ExternalWorkflowHandle = internal.ExternalWorkflowHandle
+
 	// Type identifies a workflow type.
 	Type = internal.WorkflowType
 
@@ -54,12 +61,45 @@ type (
 	// RegisterOptions consists of options for registering a workflow
 	RegisterOptions = internal.RegisterWorkflowOptions
 
+	// DynamicFunc is a workflow function that handles all workflow types that don't have a registered workflow.
+	// It receives the name of the workflow type and the encoded workflow arguments. See
+	// worker.WorkflowRegistry.RegisterDynamicWorkflow.
+	DynamicFunc = internal.DynamicWorkflowFunc
+
 	// Info information about currently executing workflow
 	Info = internal.WorkflowInfo
 
 	// ContinueAsNewError can be returned by a workflow implementation function and indicates that
 	// the workflow should continue as new with the same WorkflowID, but new RunID and new history.
 	ContinueAsNewError = internal.ContinueAsNewError
+
+	// ContinueAsNewOptions overrides the options of the new run created by NewContinueAsNewErrorWithOptions.
+	ContinueAsNewOptions = internal.ContinueAsNewOptions
+
+	// CarriedOverSignal is a signal carried over to the new run with ContinueAsNewOptions.CarryOverSignals.
+	CarriedOverSignal = internal.CarriedOverSignal
+
+	// SignalExternalWorkflowsOptions configures SignalExternalWorkflows.
+	SignalExternalWorkflowsOptions = internal.SignalExternalWorkflowsOptions
+
+	// OperationOptions configure the call of an operation with ExecuteOperation.
+	OperationOptions = internal.OperationOptions
+
+	// CallbackFuture is the Future of a callback created with NewCallback.
+	CallbackFuture = internal.CallbackFuture
+
+	// CallbackRequest is the HTTP request delivered to a callback created with NewCallback.
+	CallbackRequest = internal.CallbackRequest
+
+	// TimerOptions are options for NewTimerWithOptions and SleepWithOptions.
+	TimerOptions = internal.TimerOptions
+
+	// InvalidOptionsError is returned for activities and child workflows executed with invalid options, see
+	// ValidateActivityOptions and ValidateChildWorkflowOptions.
+	InvalidOptionsError = internal.InvalidOptionsError
+
+	// OptionsProblem describes the problem of an invalid field of options.
+	OptionsProblem = internal.OptionsProblem
 )
 
 // ExecuteActivity requests activity execution in the context of a workflow.
@@ -113,6 +153,21 @@ func ExecuteActivity(ctx Context, activity interface{}, args ...interface{}) Fut
 	return internal.ExecuteActivity(ctx, activity, args...)
 }
 
+// ExecuteActivityWithOptions requests activity execution like ExecuteActivity, with the activity options of the context
+// overridden by the options set in options for this call only. This saves deriving a context with
+// WithActivityOptions when a single call needs, for example, a different timeout:
+//
+//  ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+//  ...
+//  err := workflow.ExecuteActivityWithOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Hour},
+//      a.SlowActivity).Get(ctx, nil)
+//
+// Zero fields of options keep the value of the context, WaitForCancellation only overrides the context when set to
+// true.
+func ExecuteActivityWithOptions(ctx Context, options ActivityOptions, activity interface{}, args ...interface{}) Future {
+	return internal.ExecuteActivityWithOptions(ctx, options, activity, args...)
+}
+
 // ExecuteLocalActivity requests to run a local activity. A local activity is like a regular activity with some key
 // differences:
 //
@@ -195,6 +250,30 @@ func GetMetricsScope(ctx Context) tally.Scope {
 	return internal.GetMetricsScope(ctx)
 }
 
+// NewReplayAwareLogger wraps a custom logger so that, like the logger returned by GetLogger, it doesn't log while the
+// workflow is replaying. Use it instead of guarding log calls with IsReplaying.
+func NewReplayAwareLogger(ctx Context, logger log.Logger) log.Logger {
+	return internal.NewReplayAwareLogger(ctx, logger)
+}
+
+// NewReplayAwareMetricsScope wraps a custom metrics scope so that, like the scope returned by GetMetricsScope, it
+// doesn't report metrics while the workflow is replaying. Use it instead of guarding metrics with IsReplaying.
+func NewReplayAwareMetricsScope(ctx Context, scope tally.Scope) tally.Scope {
+	return internal.NewReplayAwareMetricsScope(ctx, scope)
+}
+
+// GetExternalWorkflowHandle returns a handle to the workflow execution with the given workflowID and runID. The runID
+// is optional (default is ""), an empty runID targets the currently running instance of the workflowID. The namespace
+// of the target workflow is fixed when the handle is created: it is the namespace set on the context with
+// WithWorkflowNamespace, or the current workflow's namespace otherwise:
+//  handle := workflow.GetExternalWorkflowHandle(workflow.WithWorkflowNamespace(ctx, "namespace"), workflowID, "")
+//  err := handle.Signal(ctx, "signal-name", arg).Get(ctx, nil)
+// Prefer the handle to SignalExternalWorkflow and RequestCancelExternalWorkflow, where the target namespace depends
+// on the context passed to every call.
+func GetExternalWorkflowHandle(ctx Context, workflowID, runID string) ExternalWorkflowHandle {
+	return internal.GetExternalWorkflowHandle(ctx, workflowID, runID)
+}
+
 // RequestCancelExternalWorkflow can be used to request cancellation of an external workflow.
 // Input workflowID is the workflow ID of target workflow.
 // Input runID indicates the instance of a workflow. Input runID is optional (default is ""). When runID is not specified,
@@ -219,11 +298,84 @@ func SignalExternalWorkflow(ctx Context, workflowID, runID, signalName string, a
 	return internal.SignalExternalWorkflow(ctx, workflowID, runID, signalName, arg)
 }
 
+// SignalExternalWorkflows sends the same signal to every workflow in targets with bounded parallelism.
+// At most options.MaxConcurrent signals are in flight at a time, and at most options.MaxSignalsPerWorkflowTask
+// signals are issued per workflow task, so a large batch is split across workflow tasks instead of
+// producing a single oversized workflow task completion.
+// It blocks until every signal is delivered or failed and returns one error per target, in the order of targets.
+func SignalExternalWorkflows(ctx Context, targets []Execution, signalName string, arg interface{}, options SignalExternalWorkflowsOptions) []error {
+	return internal.SignalExternalWorkflows(ctx, targets, signalName, arg, options)
+}
+
+// ExecuteOperation calls an operation exposed with SetOperationHandler by another workflow, possibly in another
+// namespace, and returns a Future which is ready with the result of the operation handler.
+// It replaces the hand-rolled pattern of signaling a workflow and waiting for a callback signal: the request is
+// signaled to the target workflow, which signals the response back when the handler returns, so both workflows
+// must be able to signal each other. A failure of the handler is returned as an ApplicationError with the message,
+// type and retryability of the original error.
+// Canceling ctx or reaching OperationOptions.Timeout cancels the context of the handler.
+// Example:
+//  options := workflow.OperationOptions{Namespace: "billing", WorkflowID: "billing-service", Timeout: time.Hour}
+//  var invoice Invoice
+//  err := workflow.ExecuteOperation(ctx, options, "CreateInvoice", order).Get(ctx, &invoice)
+func ExecuteOperation(ctx Context, options OperationOptions, operation string, args ...interface{}) Future {
+	return internal.ExecuteOperation(ctx, options, operation, args...)
+}
+
+// SetOperationHandler exposes an operation of the workflow which other workflows can call with ExecuteOperation.
+// The handler must be a function taking a Context and the operation arguments and returning a serializable result
+// and an error. Each call runs the handler in its own coroutine of the workflow, so it can block, e.g. to execute
+// activities. The context of the handler is canceled when the caller cancels the call or it times out.
+// Example:
+//  err := workflow.SetOperationHandler(ctx, "CreateInvoice", func(ctx workflow.Context, order Order) (Invoice, error) {
+//      var invoice Invoice
+//      err := workflow.ExecuteActivity(ctx, CreateInvoiceActivity, order).Get(ctx, &invoice)
+//      return invoice, err
+//  })
+func SetOperationHandler(ctx Context, operation string, handler interface{}) error {
+	return internal.SetOperationHandler(ctx, operation, handler)
+}
+
+// NewCallback creates a one-time callback which an external system completes with an HTTP request to the handler
+// returned by client.NewCallbackHandler. Pass the ID of the returned future to an activity which encodes it with
+// client.EncodeCallbackToken and registers the callback URL, then wait for the CallbackRequest, e.g.
+//  callback := workflow.NewCallback(ctx)
+//  if err := workflow.ExecuteActivity(ctx, RegisterWebhook, callback.ID()).Get(ctx, nil); err != nil {
+//      return err
+//  }
+//  var request workflow.CallbackRequest
+//  if err := callback.Get(ctx, &request); err != nil {
+//      return err
+//  }
+func NewCallback(ctx Context) CallbackFuture {
+	return internal.NewCallback(ctx)
+}
+
 // GetSignalChannel returns channel corresponding to the signal name.
 func GetSignalChannel(ctx Context, signalName string) ReceiveChannel {
 	return internal.GetSignalChannel(ctx, signalName)
 }
 
+// DrainSignalChannel receives up to max signals with the given name which are buffered on the signal channel without
+// blocking, or all of them if max is not positive. The signals are returned in the order they were received by the
+// workflow, as EncodedValue so that they can be decoded by the caller. Use it before the workflow completes or
+// continues as new to process signals which would be dropped otherwise:
+//  for _, signal := range workflow.DrainSignalChannel(ctx, "signal-name", 0) {
+//      var value string
+//      err := signal.Get(&value)
+//      ...
+//  }
+func DrainSignalChannel(ctx Context, signalName string, max int) []converter.EncodedValue {
+	return internal.DrainSignalChannel(ctx, signalName, max)
+}
+
+// GetUnhandledSignalNames returns the sorted names of the signals which were received by the workflow but not
+// consumed from their signal channels yet. Signals which are still unhandled when the workflow completes or continues
+// as new are dropped, which is reported with a warning and the temporal_unhandled_signals metric.
+func GetUnhandledSignalNames(ctx Context) []string {
+	return internal.GetUnhandledSignalNames(ctx)
+}
+
 // SideEffect executes the provided function once, records its result into the workflow history. The recorded result on
 // history will be returned without executing the provided function during replay. This guarantees the deterministic
 // requirement for workflow as the exact same result will be returned in replay.
@@ -264,6 +416,24 @@ func SideEffect(ctx Context, f func(ctx Context) interface{}) converter.EncodedV
 	return internal.SideEffect(ctx, f)
 }
 
+// Rand returns a pseudo-random number generator which produces the same sequence of numbers when the workflow is
+// replayed. The generator is seeded with a random value recorded through SideEffect, so every call to Rand adds a
+// marker to the workflow history. Create the generator once and reuse it instead of calling Rand for every number:
+//  r := workflow.Rand(ctx)
+//  if r.Intn(100) < 50 {
+//         ....
+//  }
+// The returned generator must not be shared between workflow executions.
+func Rand(ctx Context) *rand.Rand {
+	return internal.Rand(ctx)
+}
+
+// NewUUID returns a random UUID in its string form. The value is recorded through SideEffect, so the same UUID is
+// returned when the workflow is replayed.
+func NewUUID(ctx Context) string {
+	return internal.NewUUID(ctx)
+}
+
 // MutableSideEffect executes the provided function once, then it looks up the history for the value with the given id.
 // If there is no existing value, then it records the function result as a value with the given id on history;
 // otherwise, it compares whether the existing value from history has changed from the new function result by calling the
@@ -283,6 +453,61 @@ func MutableSideEffect(ctx Context, id string, f func(ctx Context) interface{},
 	return internal.MutableSideEffect(ctx, id, f, equals)
 }
 
+// SetValue stores a small value under the given key in the workflow execution. The value is recorded in the workflow
+// history with a MutableSideEffect marker when it changes, and restored from it on replay, so helper libraries can
+// keep state across the calls of a workflow without threading it through every function signature.
+// The value must be serializable by the DataConverter of the context. Store values of a single type under a key: an
+// error is returned if the previous value can't be decoded into the type of the new one. Values are not carried over
+// to the run started by continue-as-new.
+func SetValue(ctx Context, key string, value interface{}) error {
+	return internal.SetValue(ctx, key, value)
+}
+
+// GetValue decodes the value stored with SetValue under the given key into valuePtr. It returns false if no value is
+// stored under the key.
+func GetValue(ctx Context, key string, valuePtr interface{}) (bool, error) {
+	return internal.GetValue(ctx, key, valuePtr)
+}
+
+// RegisterObservableVariable exposes a workflow variable in the result of the client.QueryTypeWorkflowState query
+// under the given name, along with the values stored with SetValue, so the state of a workflow can be inspected
+// without adding a query handler to it. The get function is called when the query is handled, so it must not block
+// nor change the workflow state.
+// Example:
+//  progress := 0
+//  _ = workflow.RegisterObservableVariable(ctx, "progress", func() interface{} { return progress })
+func RegisterObservableVariable(ctx Context, name string, get func() interface{}) error {
+	return internal.RegisterObservableVariable(ctx, name, get)
+}
+
+// SetDeadline records an absolute deadline under the given name and returns a Future which is ready when the deadline
+// is reached. The deadline is carried over to the run started by continue-as-new, where GetDeadline re-arms its timer
+// for the remaining time, so long business deadlines don't need to be passed from run to run.
+// Example:
+//  _, err := workflow.SetDeadline(ctx, "expiration", workflow.Now(ctx).Add(90*24*time.Hour))
+//  ...
+//  // In this run or in any run continued as new after it:
+//  _, expired, _ := workflow.GetDeadline(ctx, "expiration")
+//  selector.AddFuture(expired, func(f workflow.Future) { ... })
+// Setting a deadline again under the same name replaces it. The future fails with a CanceledError if ctx is canceled or
+// the deadline is removed before it is reached.
+func SetDeadline(ctx Context, name string, deadline time.Time) (Future, error) {
+	return internal.SetDeadline(ctx, name, deadline)
+}
+
+// GetDeadline returns the deadline recorded under the given name with SetDeadline, in this run or in a previous run
+// continued as new, and a Future which is ready when the deadline is reached, right away if it has already passed.
+// It returns false if no deadline is recorded under the name.
+func GetDeadline(ctx Context, name string) (time.Time, Future, bool) {
+	return internal.GetDeadline(ctx, name)
+}
+
+// RemoveDeadline removes the deadline recorded under the given name, canceling its timer, so that it isn't carried
+// over to the next run anymore.
+func RemoveDeadline(ctx Context, name string) {
+	internal.RemoveDeadline(ctx, name)
+}
+
 // DefaultVersion is a version returned by GetVersion for code that wasn't versioned before
 const DefaultVersion Version = internal.DefaultVersion
 
@@ -343,10 +568,46 @@ const DefaultVersion Version = internal.DefaultVersion
 //  } else {
 //    err = workflow.ExecuteActivity(ctx, qux, data).Get(ctx, nil)
 //  }
+//
+// To find out whether a GetVersion() call can be retired, list the executions which still record an older version
+// using the TemporalChangeVersion search attribute, e.g. TemporalChangeVersion="fooChange-1", or inspect their
+// histories with worker.GetChangeVersions. Then replay the histories of the open executions against the code without
+// the call using worker.WorkflowReplayer to verify that the removal doesn't break determinism.
 func GetVersion(ctx Context, changeID string, minSupported, maxSupported Version) Version {
 	return internal.GetVersion(ctx, changeID, minSupported, maxSupported)
 }
 
+// Patched is a simpler alternative to GetVersion for the common case of a single change with two branches. It
+// returns true if the workflow execution should run the new code and false if it should keep running the code
+// that existed before the change:
+//  if workflow.Patched(ctx, "fooChange") {
+//      err = workflow.ExecuteActivity(ctx, bar).Get(ctx, nil)
+//  } else {
+//      err = workflow.ExecuteActivity(ctx, foo).Get(ctx, nil)
+//  }
+//
+// The marker for the patchID is only recorded when the new branch is taken. An execution which is replayed from a
+// history that reached this point without the marker gets false. Patched(ctx, patchID) is equivalent to
+// GetVersion(ctx, patchID, DefaultVersion, 1) == 1, so the same patchID must not be used with GetVersion.
+//
+// Once there are no executions running the old branch, replace the call with DeprecatePatch and remove the old
+// branch:
+//  workflow.DeprecatePatch(ctx, "fooChange")
+//  err = workflow.ExecuteActivity(ctx, bar).Get(ctx, nil)
+//
+// When all executions which could have called Patched are completed, DeprecatePatch can be removed too.
+func Patched(ctx Context, patchID string) bool {
+	return internal.Patched(ctx, patchID)
+}
+
+// DeprecatePatch marks the patch introduced with Patched as deprecated after its old branch has been removed. It
+// keeps recording the marker, so that workers still running the code with Patched take the new branch when they
+// replay executions started on the newer code. Executions which reached this point without the marker still replay,
+// as DeprecatePatch doesn't check the recorded marker, so only remove the old branch once no execution can take it.
+func DeprecatePatch(ctx Context, patchID string) {
+	internal.DeprecatePatch(ctx, patchID)
+}
+
 // SetQueryHandler sets the query handler to handle workflow query. The queryType specify which query type this handler
 // should handle. The handler must be a function that returns 2 values. The first return value must be a serializable
 // result. The second return value must be an error. The handler function could receive any number of input parameters.
@@ -389,6 +650,23 @@ func SetQueryHandler(ctx Context, queryType string, handler interface{}) error {
 	return internal.SetQueryHandler(ctx, queryType, handler)
 }
 
+// Defer registers a finalizer run when the workflow function returns an error, which includes the workflow being
+// canceled, but not continuing as new. Finalizers run in reverse order of registration after the workflow function
+// returned, with a context which isn't canceled with the workflow, so they can execute activities to compensate or
+// clean up. The context of the finalizers is canceled when they run longer than the finalizer timeout, see
+// SetFinalizerTimeout.
+//  workflow.Defer(ctx, func(ctx workflow.Context) {
+//    _ = workflow.ExecuteActivity(ctx, ReleaseReservation, reservationID).Get(ctx, nil)
+//  })
+func Defer(ctx Context, finalizer func(ctx Context)) {
+	internal.Defer(ctx, finalizer)
+}
+
+// SetFinalizerTimeout sets the time all the finalizers registered with Defer may run for. Defaults to 1 minute.
+func SetFinalizerTimeout(ctx Context, timeout time.Duration) {
+	internal.SetFinalizerTimeout(ctx, timeout)
+}
+
 // IsReplaying returns whether the current workflow code is replaying.
 //
 // Warning! Never make commands, like schedule activity/childWorkflow/timer or send/wait on future/channel, based on
@@ -463,6 +741,22 @@ func UpsertSearchAttributes(ctx Context, attributes map[string]interface{}) erro
 	return internal.UpsertSearchAttributes(ctx, attributes)
 }
 
+// GetTypedSearchAttributes returns the search attributes of the workflow, the ones it was started with merged with
+// the ones upserted since. The values are read with typed keys, for example:
+//   customerKey := temporal.NewSearchAttributeKeyKeyword("CustomerId")
+//   customerID, ok := workflow.GetTypedSearchAttributes(ctx).GetKeyword(customerKey)
+func GetTypedSearchAttributes(ctx Context) temporal.SearchAttributes {
+	return internal.GetTypedSearchAttributes(ctx)
+}
+
+// UpsertTypedSearchAttributes adds or updates search attributes of the workflow like UpsertSearchAttributes, with the
+// values set with typed keys so that a value of the wrong type doesn't compile, for example:
+//   countKey := temporal.NewSearchAttributeKeyInt64("RetryCount")
+//   err := workflow.UpsertTypedSearchAttributes(ctx, countKey.ValueSet(3))
+func UpsertTypedSearchAttributes(ctx Context, updates ...temporal.SearchAttributeUpdate) error {
+	return internal.UpsertTypedSearchAttributes(ctx, updates...)
+}
+
 // NewContinueAsNewError creates ContinueAsNewError instance
 // If the workflow main function returns this error then the current execution is ended and
 // the new execution with same workflow ID is started automatically with options
@@ -479,6 +773,13 @@ func NewContinueAsNewError(ctx Context, wfn interface{}, args ...interface{}) er
 	return internal.NewContinueAsNewError(ctx, wfn, args...)
 }
 
+// NewContinueAsNewErrorWithOptions creates ContinueAsNewError instance like NewContinueAsNewError, with the task
+// queue, timeouts, memo and search attributes of the new run overridden by options. Together with a different wfn it
+// lets a workflow migrate itself to a new workflow type or task queue. It panics when the options are invalid.
+func NewContinueAsNewErrorWithOptions(ctx Context, options ContinueAsNewOptions, wfn interface{}, args ...interface{}) error {
+	return internal.NewContinueAsNewErrorWithOptions(ctx, options, wfn, args...)
+}
+
 // IsContinueAsNewError return if the err is a ContinueAsNewError
 func IsContinueAsNewError(err error) bool {
 	var continueAsNewErr *ContinueAsNewError
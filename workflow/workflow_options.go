@@ -36,6 +36,13 @@ func WithChildOptions(ctx Context, cwo ChildWorkflowOptions) Context {
 	return internal.WithChildWorkflowOptions(ctx, cwo)
 }
 
+// ValidateChildWorkflowOptions returns an *InvalidOptionsError naming the invalid fields of the options along with
+// suggested fixes, nil if the options are valid. ExecuteChildWorkflow fails with this error without starting the child
+// workflow when the options of its context are invalid, use this function to check options in unit tests.
+func ValidateChildWorkflowOptions(options ChildWorkflowOptions) error {
+	return internal.ValidateChildWorkflowOptions(options)
+}
+
 // WithWorkflowNamespace adds a namespace to the context.
 func WithWorkflowNamespace(ctx Context, name string) Context {
 	return internal.WithWorkflowNamespace(ctx, name)
@@ -0,0 +1,8 @@
+module go.temporal.io/sdk/workflowcheck
+
+go 1.16
+
+require (
+	golang.org/x/sys v0.0.0-20210910150752-751e447fb3d0 // indirect
+	golang.org/x/tools v0.1.5
+)
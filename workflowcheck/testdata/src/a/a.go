@@ -0,0 +1,69 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package a
+
+import (
+	"math/rand"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+var counter int
+
+var config struct {
+	Name string
+}
+
+func Workflow(ctx workflow.Context, m map[string]int) error {
+	_ = time.Now()          // want `time.Now is not allowed in workflow code, use workflow.Now instead`
+	time.Sleep(time.Second) // want `time.Sleep is not allowed in workflow code, use workflow.Sleep instead`
+	_ = rand.Intn(10)       // want `rand.Intn is not allowed in workflow code, use workflow.SideEffect instead`
+	for k := range m {      // want `iteration over a map is randomized, sort the keys before iterating`
+		_ = k
+	}
+	go func() {}()       // want `goroutines are not allowed in workflow code, use workflow.Go instead`
+	ch := make(chan int) // want `native channels are not allowed in workflow code, use workflow.Channel instead`
+	ch <- 1              // want `native channels are not allowed in workflow code, use workflow.Channel instead`
+	counter++            // want `package level variable counter must not be modified in workflow code`
+	config.Name = "name" // want `package level variable config must not be modified in workflow code`
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		_ = time.Now() // want `time.Now is not allowed in workflow code, use workflow.Now instead`
+	})
+	_ = time.Now() //workflowcheck:ignore
+	//workflowcheck:ignore
+	_ = time.Now()
+
+	local := 0
+	local++
+	_ = time.Duration(local).String()
+	return nil
+}
+
+func notAWorkflow() {
+	_ = time.Now()
+	go func() {}()
+	counter++
+}
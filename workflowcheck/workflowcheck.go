@@ -0,0 +1,255 @@
+// The MIT License
+//
+// Copyright (c) 2020 Temporal Technologies Inc.  All rights reserved.
+//
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package workflowcheck provides a static analyzer that reports code which is not safe to run inside of a workflow
+// function. Workflow code must be deterministic, so using the wall clock, random numbers, map iteration order, native
+// goroutines and channels, or mutating package level state can break replay.
+//
+// The analyzer treats every function or function literal whose first parameter is a workflow.Context as a workflow
+// function and inspects its body, including the bodies of closures declared inside of it.
+//
+// A finding can be suppressed by putting a "//workflowcheck:ignore" comment on the offending line or the line above.
+//
+// The analyzer can be run through go vet:
+//  go install go.temporal.io/sdk/workflowcheck/cmd/workflowcheck
+//  go vet -vettool=$(which workflowcheck) ./...
+package workflowcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const (
+	ignoreDirective = "//workflowcheck:ignore"
+
+	workflowPackagePath = "go.temporal.io/sdk/workflow"
+	internalPackagePath = "go.temporal.io/sdk/internal"
+)
+
+// Analyzer reports non-deterministic code inside of workflow functions.
+var Analyzer = &analysis.Analyzer{
+	Name:     "workflowcheck",
+	Doc:      "reports non-deterministic code inside of workflow functions",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// disallowedFuncs maps package paths to functions that must not be called from workflow code along with the
+// deterministic alternative. An empty function name matches every function of the package.
+var disallowedFuncs = map[string]map[string]string{
+	"time": {
+		"Now":       "workflow.Now",
+		"Since":     "workflow.Now",
+		"Until":     "workflow.Now",
+		"Sleep":     "workflow.Sleep",
+		"After":     "workflow.NewTimer",
+		"AfterFunc": "workflow.NewTimer",
+		"Tick":      "workflow.NewTimer",
+		"NewTimer":  "workflow.NewTimer",
+		"NewTicker": "workflow.NewTimer",
+	},
+	"math/rand":   {"": "workflow.SideEffect"},
+	"crypto/rand": {"": "workflow.SideEffect"},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ignored := ignoredLines(pass)
+
+	report := func(pos token.Pos, format string, args ...interface{}) {
+		position := pass.Fset.Position(pos)
+		if ignored[position.Filename][position.Line] || ignored[position.Filename][position.Line-1] {
+			return
+		}
+		pass.Reportf(pos, format, args...)
+	}
+
+	nodeFilter := []ast.Node{(*ast.FuncDecl)(nil), (*ast.FuncLit)(nil)}
+	insp.Nodes(nodeFilter, func(n ast.Node, push bool) bool {
+		if !push {
+			return false
+		}
+		var fnType *ast.FuncType
+		var body *ast.BlockStmt
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			fnType, body = fn.Type, fn.Body
+		case *ast.FuncLit:
+			fnType, body = fn.Type, fn.Body
+		}
+		if body == nil || !isWorkflowFunc(pass, fnType) {
+			return true
+		}
+		// Function literals nested in a workflow function are checked as part of the enclosing function, so there is
+		// no need to descend any further.
+		checkWorkflowBody(pass, body, report)
+		return false
+	})
+	return nil, nil
+}
+
+func checkWorkflowBody(pass *analysis.Pass, body *ast.BlockStmt, report func(token.Pos, string, ...interface{})) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.GoStmt:
+			report(node.Pos(), "goroutines are not allowed in workflow code, use workflow.Go instead")
+		case *ast.SelectStmt:
+			report(node.Pos(), "select statements are not allowed in workflow code, use workflow.NewSelector instead")
+		case *ast.SendStmt:
+			report(node.Pos(), "native channels are not allowed in workflow code, use workflow.Channel instead")
+		case *ast.UnaryExpr:
+			if node.Op == token.ARROW {
+				report(node.Pos(), "native channels are not allowed in workflow code, use workflow.Channel instead")
+			}
+		case *ast.ChanType:
+			report(node.Pos(), "native channels are not allowed in workflow code, use workflow.Channel instead")
+		case *ast.RangeStmt:
+			switch pass.TypesInfo.TypeOf(node.X).Underlying().(type) {
+			case *types.Map:
+				report(node.Pos(), "iteration over a map is randomized, sort the keys before iterating")
+			case *types.Chan:
+				report(node.Pos(), "native channels are not allowed in workflow code, use workflow.Channel instead")
+			}
+		case *ast.CallExpr:
+			checkCall(pass, node, report)
+		case *ast.AssignStmt:
+			for _, lhs := range node.Lhs {
+				checkGlobalWrite(pass, lhs, report)
+			}
+		case *ast.IncDecStmt:
+			checkGlobalWrite(pass, node.X, report)
+		}
+		return true
+	})
+}
+
+func checkCall(pass *analysis.Pass, call *ast.CallExpr, report func(token.Pos, string, ...interface{})) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return
+	}
+	fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return
+	}
+	// Methods such as rand.Rand.Intn are as non-deterministic as the package functions, but methods on time.Time are
+	// not, so only package level functions are matched.
+	if fn.Type().(*types.Signature).Recv() != nil {
+		return
+	}
+	funcs, ok := disallowedFuncs[fn.Pkg().Path()]
+	if !ok {
+		return
+	}
+	alternative, ok := funcs[fn.Name()]
+	if !ok {
+		if alternative, ok = funcs[""]; !ok {
+			return
+		}
+	}
+	report(call.Pos(), "%s.%s is not allowed in workflow code, use %s instead", fn.Pkg().Name(), fn.Name(), alternative)
+}
+
+func checkGlobalWrite(pass *analysis.Pass, expr ast.Expr, report func(token.Pos, string, ...interface{})) {
+	ident := rootIdent(pass, expr)
+	if ident == nil || ident.Name == "_" {
+		return
+	}
+	v, ok := pass.TypesInfo.ObjectOf(ident).(*types.Var)
+	if !ok || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return
+	}
+	report(expr.Pos(), "package level variable %s must not be modified in workflow code", ident.Name)
+}
+
+// rootIdent returns the identifier an assignment target is rooted at, for example "a" for "a.b[c].d" and "Var" for
+// "pkg.Var.field".
+func rootIdent(pass *analysis.Pass, expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.SelectorExpr:
+			if x, ok := e.X.(*ast.Ident); ok {
+				if _, isPkg := pass.TypesInfo.Uses[x].(*types.PkgName); isPkg {
+					return e.Sel
+				}
+			}
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// isWorkflowFunc returns true if the first parameter of the function is a workflow.Context.
+func isWorkflowFunc(pass *analysis.Pass, fnType *ast.FuncType) bool {
+	if fnType.Params == nil || len(fnType.Params.List) == 0 {
+		return false
+	}
+	named, ok := pass.TypesInfo.TypeOf(fnType.Params.List[0].Type).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Name() != "Context" {
+		return false
+	}
+	path := named.Obj().Pkg().Path()
+	return path == workflowPackagePath || path == internalPackagePath
+}
+
+// ignoredLines returns the lines that are marked with the ignore directive, keyed by file name.
+func ignoredLines(pass *analysis.Pass) map[string]map[int]bool {
+	ignored := make(map[string]map[int]bool)
+	for _, file := range pass.Files {
+		for _, group := range file.Comments {
+			for _, comment := range group.List {
+				if !strings.HasPrefix(comment.Text, ignoreDirective) {
+					continue
+				}
+				position := pass.Fset.Position(comment.Slash)
+				if ignored[position.Filename] == nil {
+					ignored[position.Filename] = make(map[int]bool)
+				}
+				ignored[position.Filename][position.Line] = true
+			}
+		}
+	}
+	return ignored
+}